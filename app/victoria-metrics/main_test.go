@@ -18,6 +18,8 @@ import (
 	"time"
 
 	testutil "github.com/VictoriaMetrics/VictoriaMetrics/app/victoria-metrics/test"
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmagent/prometheusimport"
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmagent/remotewrite"
 	"github.com/VictoriaMetrics/VictoriaMetrics/app/vminsert"
 	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmselect"
 	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmstorage"
@@ -196,6 +198,59 @@ func TestWriteRead(t *testing.T) {
 	t.Run("read", testRead)
 }
 
+type MetadataResponse struct {
+	Status string                         `json:"status"`
+	Data   map[string][]map[string]string `json:"data"`
+}
+
+// TestVMAgentMetadata verifies that metric metadata passes through vmagent remote write pipeline to /api/v1/metadata.
+func TestVMAgentMetadata(t *testing.T) {
+	tmpDataPath := filepath.Join(os.TempDir(), "vmagent-test-remotewrite-data")
+	defer fs.MustRemoveAll(tmpDataPath)
+	for _, fv := range []struct {
+		flag  string
+		value string
+	}{
+		{flag: "remoteWrite.url", value: testPromWriteHTTPPath},
+		{flag: "remoteWrite.tmpDataPath", value: tmpDataPath},
+	} {
+		if err := flag.Lookup(fv.flag).Value.Set(fv.value); err != nil {
+			t.Fatalf("unable to set %q with value %q, err: %v", fv.flag, fv.value, err)
+		}
+	}
+	remotewrite.Init()
+	defer remotewrite.Stop()
+
+	data := `# HELP vmagent_metadata_requests_total The number of served requests.
+# TYPE vmagent_metadata_requests_total counter
+# UNIT vmagent_metadata_requests_total requests
+vmagent_metadata_requests_total 42
+`
+	if err := prometheusimport.InsertHandlerForReader(strings.NewReader(data)); err != nil {
+		t.Fatalf("cannot insert data to vmagent: %s", err)
+	}
+
+	want := MetadataResponse{
+		Status: "success",
+		Data: map[string][]map[string]string{
+			"vmagent_metadata_requests_total": {{
+				"type": "counter",
+				"help": "The number of served requests.",
+				"unit": "requests",
+			}},
+		},
+	}
+	var got MetadataResponse
+	err := waitFor(10*time.Second, func() bool {
+		got = MetadataResponse{}
+		httpReadStruct(t, testReadHTTPPath, "/api/v1/metadata?metric=vmagent_metadata_requests_total", &got)
+		return reflect.DeepEqual(got, want)
+	})
+	if err != nil {
+		t.Fatalf("unexpected metadata response;\ngot\n%+v\nwant\n%+v", got, want)
+	}
+}
+
 func testWrite(t *testing.T) {
 	t.Run("prometheus", func(t *testing.T) {
 		for _, test := range readIn("prometheus", t, insertionTime) {
@@ -27,6 +27,7 @@ func (ctx *PushCtx) Reset() {
 		ts.Samples = nil
 	}
 	ctx.WriteRequest.Timeseries = ctx.WriteRequest.Timeseries[:0]
+	ctx.WriteRequest.Metadata = prompbmarshal.ResetMetadata(ctx.WriteRequest.Metadata)
 
 	labels := ctx.Labels
 	for i := range labels {
@@ -0,0 +1,231 @@
+package kafka
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmagent/influx"
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmagent/prometheusimport"
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmagent/promremotewrite"
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmagent/vmimport"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/flagutil"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/httpserver"
+	kafkaclient "github.com/VictoriaMetrics/VictoriaMetrics/lib/kafka"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logger"
+	"github.com/VictoriaMetrics/metrics"
+)
+
+var (
+	consumerTopics = flagutil.NewArray("kafka.consumer.topic", "Kafka topic to consume data from. "+
+		"See https://victoriametrics.github.io/vmagent.html#reading-data-from-kafka")
+	consumerTopicBrokers = flagutil.NewArray("kafka.consumer.topic.brokers", "Semicolon-separated list of Kafka brokers for the corresponding -kafka.consumer.topic. "+
+		"For example, -kafka.consumer.topic.brokers='host1:9092;host2:9092'")
+	consumerTopicFormats = flagutil.NewArray("kafka.consumer.topic.format", "Data format for messages in the corresponding -kafka.consumer.topic. "+
+		"Supported formats: promremotewrite, influx, prometheus, jsonline. By default promremotewrite is used")
+	consumerTopicGroupIDs = flagutil.NewArray("kafka.consumer.topic.groupID", "Consumer group for committing offsets of the corresponding -kafka.consumer.topic. "+
+		"By default vmagent group is used")
+	consumerTimeout = flag.Duration("kafka.consumer.timeout", 30*time.Second, "Timeout for requests to Kafka brokers specified via -kafka.consumer.topic.brokers")
+)
+
+var (
+	consumers []*consumer
+	wg        sync.WaitGroup
+	stopCh    chan struct{}
+)
+
+// Init starts reading data from -kafka.consumer.topic.
+//
+// MustStop must be called when reading from Kafka is no longer needed.
+func Init() {
+	stopCh = make(chan struct{})
+	for i, topic := range *consumerTopics {
+		c, err := newConsumer(i, topic)
+		if err != nil {
+			logger.Fatalf("cannot initialize consumer for -kafka.consumer.topic=%q: %s", topic, err)
+		}
+		consumers = append(consumers, c)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.run()
+		}()
+	}
+}
+
+// MustStop stops reading data from Kafka.
+//
+// The offsets for the already read messages are committed before returning.
+func MustStop() {
+	close(stopCh)
+	wg.Wait()
+	for _, c := range consumers {
+		c.c.Close()
+	}
+	consumers = nil
+}
+
+type consumer struct {
+	topic   string
+	c       *kafkaclient.Consumer
+	handler func(r io.Reader) error
+
+	messagesRead  *metrics.Counter
+	parseErrors   *metrics.Counter
+	processErrors *metrics.Counter
+	fetchErrors   *metrics.Counter
+	commitErrors  *metrics.Counter
+	fetchDuration *metrics.Histogram
+}
+
+func newConsumer(argIdx int, topic string) (*consumer, error) {
+	brokersStr := consumerTopicBrokers.GetOptionalArg(argIdx)
+	if brokersStr == "" {
+		return nil, fmt.Errorf("missing -kafka.consumer.topic.brokers")
+	}
+	brokers := strings.Split(brokersStr, ";")
+	format := consumerTopicFormats.GetOptionalArg(argIdx)
+	handler, err := getHandlerForFormat(format)
+	if err != nil {
+		return nil, err
+	}
+	groupID := consumerTopicGroupIDs.GetOptionalArg(argIdx)
+	if groupID == "" {
+		groupID = "vmagent"
+	}
+	c := &consumer{
+		topic:   topic,
+		c:       kafkaclient.NewConsumer(brokers, groupID, topic, *consumerTimeout),
+		handler: handler,
+
+		messagesRead:  metrics.GetOrCreateCounter(fmt.Sprintf(`vmagent_kafka_consumer_messages_read_total{topic=%q}`, topic)),
+		parseErrors:   metrics.GetOrCreateCounter(fmt.Sprintf(`vmagent_kafka_consumer_parse_errors_total{topic=%q}`, topic)),
+		processErrors: metrics.GetOrCreateCounter(fmt.Sprintf(`vmagent_kafka_consumer_process_errors_total{topic=%q}`, topic)),
+		fetchErrors:   metrics.GetOrCreateCounter(fmt.Sprintf(`vmagent_kafka_consumer_fetch_errors_total{topic=%q}`, topic)),
+		commitErrors:  metrics.GetOrCreateCounter(fmt.Sprintf(`vmagent_kafka_consumer_commit_errors_total{topic=%q}`, topic)),
+		fetchDuration: metrics.GetOrCreateHistogram(fmt.Sprintf(`vmagent_kafka_consumer_fetch_duration_seconds{topic=%q}`, topic)),
+	}
+	logger.Infof("reading %s data from -kafka.consumer.topic=%q at brokers %q with group %q", formatName(format), topic, brokers, groupID)
+	return c, nil
+}
+
+func getHandlerForFormat(format string) (func(r io.Reader) error, error) {
+	switch format {
+	case "", "promremotewrite":
+		return promremotewrite.InsertHandlerForReader, nil
+	case "influx":
+		return influx.InsertHandlerForReader, nil
+	case "prometheus":
+		return prometheusimport.InsertHandlerForReader, nil
+	case "jsonline":
+		return vmimport.InsertHandlerForReader, nil
+	default:
+		return nil, fmt.Errorf("unsupported -kafka.consumer.topic.format=%q; supported values: promremotewrite, influx, prometheus, jsonline", format)
+	}
+}
+
+func formatName(format string) string {
+	if format == "" {
+		return "promremotewrite"
+	}
+	return format
+}
+
+func (c *consumer) run() {
+	retryDuration := time.Second
+	for {
+		select {
+		case <-stopCh:
+			return
+		default:
+		}
+		startTime := time.Now()
+		msgs, err := c.c.Fetch(time.Second)
+		c.fetchDuration.UpdateDuration(startTime)
+		if err != nil {
+			c.fetchErrors.Inc()
+			logger.Errorf("cannot read messages from -kafka.consumer.topic=%q: %s; retrying in %.3f seconds", c.topic, err, retryDuration.Seconds())
+			if !sleepRetry(&retryDuration) {
+				return
+			}
+			continue
+		}
+		if len(msgs) == 0 {
+			retryDuration = time.Second
+			continue
+		}
+		if err := c.processMessages(msgs); err != nil {
+			// The messages cannot be processed at the moment. Do not commit their offsets,
+			// so they are read again starting from the last committed offsets.
+			c.processErrors.Inc()
+			logger.Errorf("cannot process messages from -kafka.consumer.topic=%q: %s; retrying from the last committed offsets in %.3f seconds",
+				c.topic, err, retryDuration.Seconds())
+			c.c.Rewind()
+			if !sleepRetry(&retryDuration) {
+				return
+			}
+			continue
+		}
+		retryDuration = time.Second
+		c.messagesRead.Add(len(msgs))
+		// Commit offsets only after all the messages are pushed to remote storage queues.
+		// This guarantees at-least-once delivery.
+		if err := c.c.Commit(); err != nil {
+			c.commitErrors.Inc()
+			logger.Errorf("cannot commit offsets for -kafka.consumer.topic=%q: %s", c.topic, err)
+		}
+	}
+}
+
+// processMessages passes msgs to c.handler.
+//
+// Messages with invalid data are skipped, since they cannot be processed on retries.
+// An error is returned if some of msgs cannot be processed because of other reasons.
+func (c *consumer) processMessages(msgs []kafkaclient.Message) error {
+	for i := range msgs {
+		msg := &msgs[i]
+		err := c.handler(bytes.NewReader(msg.Value))
+		if err == nil {
+			continue
+		}
+		if isTemporaryError(err) {
+			return fmt.Errorf("cannot process message at offset %d for partition %d: %w", msg.Offset, msg.Partition, err)
+		}
+		c.parseErrors.Inc()
+		logger.Errorf("cannot parse message at offset %d for -kafka.consumer.topic=%q partition %d: %s; skipping it",
+			msg.Offset, c.topic, msg.Partition, err)
+	}
+	return nil
+}
+
+// isTemporaryError returns true if err returned from the handler doesn't depend on the message contents.
+//
+// Handlers return such errors when the message cannot be processed at the moment,
+// for example, when -maxConcurrentInserts limit is reached. Other errors are returned for invalid data.
+func isTemporaryError(err error) bool {
+	var esc *httpserver.ErrorWithStatusCode
+	return errors.As(err, &esc) && esc.StatusCode >= 500
+}
+
+// sleepRetry sleeps for *retryDuration and then doubles it up to a minute.
+//
+// false is returned if the consumers must be stopped.
+func sleepRetry(retryDuration *time.Duration) bool {
+	t := time.NewTimer(*retryDuration)
+	select {
+	case <-stopCh:
+		t.Stop()
+		return false
+	case <-t.C:
+	}
+	*retryDuration *= 2
+	if *retryDuration > time.Minute {
+		*retryDuration = time.Minute
+	}
+	return true
+}
@@ -0,0 +1,70 @@
+package kafka
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/httpserver"
+	kafkaclient "github.com/VictoriaMetrics/VictoriaMetrics/lib/kafka"
+	"github.com/VictoriaMetrics/metrics"
+)
+
+func TestConsumerProcessMessages(t *testing.T) {
+	f := func(values []string, resultExpected bool, processedExpected []string, parseErrorsExpected uint64) {
+		t.Helper()
+		var processed []string
+		c := &consumer{
+			topic: "test",
+			handler: func(r io.Reader) error {
+				data, err := ioutil.ReadAll(r)
+				if err != nil {
+					return err
+				}
+				switch string(data) {
+				case "invalid":
+					return fmt.Errorf("cannot parse %q", data)
+				case "busy":
+					return &httpserver.ErrorWithStatusCode{
+						Err:        fmt.Errorf("cannot handle more concurrent inserts"),
+						StatusCode: http.StatusServiceUnavailable,
+					}
+				}
+				processed = append(processed, string(data))
+				return nil
+			},
+			parseErrors: metrics.NewSet().NewCounter(`parse_errors_total`),
+		}
+		var msgs []kafkaclient.Message
+		for i, v := range values {
+			msgs = append(msgs, kafkaclient.Message{
+				Offset: int64(i),
+				Value:  []byte(v),
+			})
+		}
+		err := c.processMessages(msgs)
+		if resultExpected && err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if !resultExpected && err == nil {
+			t.Fatalf("expecting non-nil error")
+		}
+		if fmt.Sprintf("%q", processed) != fmt.Sprintf("%q", processedExpected) {
+			t.Fatalf("unexpected processed messages;\ngot\n%q\nwant\n%q", processed, processedExpected)
+		}
+		if n := c.parseErrors.Get(); n != parseErrorsExpected {
+			t.Fatalf("unexpected number of parse errors; got %d; want %d", n, parseErrorsExpected)
+		}
+	}
+
+	f([]string{"foo", "bar"}, true, []string{"foo", "bar"}, 0)
+
+	// Messages with invalid data are skipped.
+	f([]string{"foo", "invalid", "bar"}, true, []string{"foo", "bar"}, 1)
+
+	// Temporary errors stop processing without counting parse errors.
+	f([]string{"foo", "busy", "bar"}, false, []string{"foo"}, 0)
+	f([]string{"invalid", "busy", "bar"}, false, nil, 1)
+}
@@ -1,6 +1,7 @@
 package prometheusimport
 
 import (
+	"io"
 	"net/http"
 
 	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmagent/common"
@@ -29,13 +30,22 @@ func InsertHandler(req *http.Request) error {
 	}
 	return writeconcurrencylimiter.Do(func() error {
 		isGzipped := req.Header.Get("Content-Encoding") == "gzip"
-		return parser.ParseStream(req.Body, defaultTimestamp, isGzipped, func(rows []parser.Row) error {
-			return insertRows(rows, extraLabels)
+		return parser.ParseStream(req.Body, defaultTimestamp, isGzipped, func(rows []parser.Row, metadata []parser.Metadata) error {
+			return insertRows(rows, metadata, extraLabels)
 		})
 	})
 }
 
-func insertRows(rows []parser.Row, extraLabels []prompbmarshal.Label) error {
+// InsertHandlerForReader processes metrics in Prometheus text exposition format from r.
+func InsertHandlerForReader(r io.Reader) error {
+	return writeconcurrencylimiter.Do(func() error {
+		return parser.ParseStream(r, 0, false, func(rows []parser.Row, metadata []parser.Metadata) error {
+			return insertRows(rows, metadata, nil)
+		})
+	})
+}
+
+func insertRows(rows []parser.Row, metadata []parser.Metadata, extraLabels []prompbmarshal.Label) error {
 	ctx := common.GetPushCtx()
 	defer common.PutPushCtx(ctx)
 
@@ -67,6 +77,17 @@ func insertRows(rows []parser.Row, extraLabels []prompbmarshal.Label) error {
 		})
 	}
 	ctx.WriteRequest.Timeseries = tssDst
+	mdsDst := ctx.WriteRequest.Metadata[:0]
+	for i := range metadata {
+		md := &metadata[i]
+		mdsDst = append(mdsDst, prompbmarshal.MetricMetadata{
+			Type:             prompbmarshal.ParseMetricType(md.Type),
+			MetricFamilyName: md.Metric,
+			Help:             md.Help,
+			Unit:             md.Unit,
+		})
+	}
+	ctx.WriteRequest.Metadata = mdsDst
 	ctx.Labels = labels
 	ctx.Samples = samples
 	remotewrite.Push(&ctx.WriteRequest)
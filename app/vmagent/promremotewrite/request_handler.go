@@ -1,6 +1,7 @@
 package promremotewrite
 
 import (
+	"io"
 	"net/http"
 
 	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmagent/common"
@@ -21,11 +22,18 @@ var (
 // InsertHandler processes remote write for prometheus.
 func InsertHandler(req *http.Request) error {
 	return writeconcurrencylimiter.Do(func() error {
-		return parser.ParseStream(req, insertRows)
+		return parser.ParseStream(req.Body, insertRows)
 	})
 }
 
-func insertRows(timeseries []prompb.TimeSeries) error {
+// InsertHandlerForReader processes snappy-compressed Prometheus remote_write message from r.
+func InsertHandlerForReader(r io.Reader) error {
+	return writeconcurrencylimiter.Do(func() error {
+		return parser.ParseStream(r, insertRows)
+	})
+}
+
+func insertRows(timeseries []prompb.TimeSeries, metadata []prompb.MetricMetadata) error {
 	ctx := common.GetPushCtx()
 	defer common.PutPushCtx(ctx)
 
@@ -58,6 +66,17 @@ func insertRows(timeseries []prompb.TimeSeries) error {
 		rowsTotal += len(ts.Samples)
 	}
 	ctx.WriteRequest.Timeseries = tssDst
+	mdsDst := ctx.WriteRequest.Metadata[:0]
+	for i := range metadata {
+		md := &metadata[i]
+		mdsDst = append(mdsDst, prompbmarshal.MetricMetadata{
+			Type:             prompbmarshal.MetricMetadata_MetricType(md.Type),
+			MetricFamilyName: bytesutil.ToUnsafeString(md.MetricFamilyName),
+			Help:             bytesutil.ToUnsafeString(md.Help),
+			Unit:             bytesutil.ToUnsafeString(md.Unit),
+		})
+	}
+	ctx.WriteRequest.Metadata = mdsDst
 	ctx.Labels = labels
 	ctx.Samples = samples
 	remotewrite.Push(&ctx.WriteRequest)
@@ -14,6 +14,7 @@ import (
 	"time"
 
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/flagutil"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/kafka"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logger"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/persistentqueue"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promauth"
@@ -50,6 +51,9 @@ type client struct {
 	fq             *persistentqueue.FastQueue
 	hc             *http.Client
 
+	// kp is set if remoteWriteURL points to Kafka.
+	kp *kafka.Producer
+
 	requestDuration *metrics.Histogram
 	requestsOKCount *metrics.Counter
 	errorsCount     *metrics.Counter
@@ -111,6 +115,9 @@ func newClient(argIdx int, remoteWriteURL, urlLabelValue string, fq *persistentq
 		},
 		stopCh: make(chan struct{}),
 	}
+	if isKafkaURL(remoteWriteURL) {
+		c.kp = newKafkaProducer(remoteWriteURL)
+	}
 	c.requestDuration = metrics.GetOrCreateHistogram(fmt.Sprintf(`vmagent_remotewrite_duration_seconds{url=%q}`, c.urlLabelValue))
 	c.requestsOKCount = metrics.GetOrCreateCounter(fmt.Sprintf(`vmagent_remotewrite_requests_total{url=%q, status_code="2XX"}`, c.urlLabelValue))
 	c.errorsCount = metrics.GetOrCreateCounter(fmt.Sprintf(`vmagent_remotewrite_errors_total{url=%q}`, c.urlLabelValue))
@@ -129,6 +136,9 @@ func newClient(argIdx int, remoteWriteURL, urlLabelValue string, fq *persistentq
 func (c *client) MustStop() {
 	close(c.stopCh)
 	c.wg.Wait()
+	if c.kp != nil {
+		c.kp.Close()
+	}
 	logger.Infof("stopped client for -remoteWrite.url=%q", c.remoteWriteURL)
 }
 
@@ -184,6 +194,10 @@ func (c *client) runWorker() {
 }
 
 func (c *client) sendBlock(block []byte) {
+	if c.kp != nil {
+		c.sendBlockToKafka(block)
+		return
+	}
 	retryDuration := time.Second
 	retriesCount := 0
 
@@ -0,0 +1,90 @@
+package remotewrite
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/kafka"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logger"
+)
+
+// isKafkaURL returns true if remoteWriteURL points to Kafka topic instead of remote storage.
+func isKafkaURL(remoteWriteURL string) bool {
+	return strings.HasPrefix(remoteWriteURL, "kafka://")
+}
+
+// parseKafkaURL parses Kafka url in the form `kafka://host1:9092;host2:9092/?topic=name`
+// and returns brokers and topic from it.
+func parseKafkaURL(remoteWriteURL string) ([]string, string, error) {
+	s := strings.TrimPrefix(remoteWriteURL, "kafka://")
+	brokersStr := s
+	var query string
+	if n := strings.IndexAny(s, "/?"); n >= 0 {
+		brokersStr = s[:n]
+		tail := s[n:]
+		tail = strings.TrimPrefix(tail, "/")
+		if !strings.HasPrefix(tail, "?") {
+			return nil, "", fmt.Errorf("unexpected path in Kafka url %q; the url must be in the form kafka://host1:9092;host2:9092/?topic=name", remoteWriteURL)
+		}
+		query = tail[1:]
+	}
+	if brokersStr == "" {
+		return nil, "", fmt.Errorf("missing brokers in Kafka url %q", remoteWriteURL)
+	}
+	brokers := strings.Split(brokersStr, ";")
+	for _, broker := range brokers {
+		if broker == "" {
+			return nil, "", fmt.Errorf("empty broker address in Kafka url %q", remoteWriteURL)
+		}
+	}
+	args, err := url.ParseQuery(query)
+	if err != nil {
+		return nil, "", fmt.Errorf("cannot parse query args in Kafka url %q: %w", remoteWriteURL, err)
+	}
+	topic := args.Get("topic")
+	if topic == "" {
+		return nil, "", fmt.Errorf("missing `topic` query arg in Kafka url %q", remoteWriteURL)
+	}
+	return brokers, topic, nil
+}
+
+func newKafkaProducer(remoteWriteURL string) *kafka.Producer {
+	brokers, topic, err := parseKafkaURL(remoteWriteURL)
+	if err != nil {
+		logger.Fatalf("cannot parse -remoteWrite.url: %s", err)
+	}
+	return kafka.NewProducer(brokers, topic, *sendTimeout)
+}
+
+// sendBlockToKafka sends block to Kafka until it is acknowledged by all the in-sync replicas.
+//
+// The block is sent as a single message in the same format as for Prometheus remote_write protocol.
+func (c *client) sendBlockToKafka(block []byte) {
+	retryDuration := time.Second
+	for {
+		startTime := time.Now()
+		err := c.kp.Produce(block)
+		c.requestDuration.UpdateDuration(startTime)
+		if err == nil {
+			c.requestsOKCount.Inc()
+			return
+		}
+		c.errorsCount.Inc()
+		retryDuration *= 2
+		if retryDuration > time.Minute {
+			retryDuration = time.Minute
+		}
+		logger.Errorf("couldn't send a block with size %d bytes to %q: %s; re-sending the block in %.3f seconds",
+			len(block), c.remoteWriteURL, err, retryDuration.Seconds())
+		t := time.NewTimer(retryDuration)
+		select {
+		case <-c.stopCh:
+			t.Stop()
+			return
+		case <-t.C:
+		}
+		c.retriesCount.Inc()
+	}
+}
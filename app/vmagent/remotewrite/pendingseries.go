@@ -57,6 +57,12 @@ func (ps *pendingSeries) Push(tss []prompbmarshal.TimeSeries) {
 	ps.mu.Unlock()
 }
 
+func (ps *pendingSeries) PushMetadata(mds []prompbmarshal.MetricMetadata) {
+	ps.mu.Lock()
+	ps.wr.pushMetadata(mds)
+	ps.mu.Unlock()
+}
+
 func (ps *pendingSeries) periodicFlusher() {
 	flushSeconds := int64(flushInterval.Seconds())
 	if flushSeconds <= 0 {
@@ -87,7 +93,8 @@ type writeRequest struct {
 	wr        prompbmarshal.WriteRequest
 	pushBlock func(block []byte)
 
-	tss []prompbmarshal.TimeSeries
+	tss      []prompbmarshal.TimeSeries
+	metadata []prompbmarshal.MetricMetadata
 
 	labels  []prompbmarshal.Label
 	samples []prompbmarshal.Sample
@@ -96,6 +103,7 @@ type writeRequest struct {
 
 func (wr *writeRequest) reset() {
 	wr.wr.Timeseries = nil
+	wr.wr.Metadata = nil
 
 	for i := range wr.tss {
 		ts := &wr.tss[i]
@@ -104,6 +112,8 @@ func (wr *writeRequest) reset() {
 	}
 	wr.tss = wr.tss[:0]
 
+	wr.metadata = prompbmarshal.ResetMetadata(wr.metadata)
+
 	for i := range wr.labels {
 		label := &wr.labels[i]
 		label.Name = ""
@@ -117,6 +127,7 @@ func (wr *writeRequest) reset() {
 
 func (wr *writeRequest) flush() {
 	wr.wr.Timeseries = wr.tss
+	wr.wr.Metadata = wr.metadata
 	atomic.StoreUint64(&wr.lastFlushTime, fasttime.UnixTimestamp())
 	pushWriteRequest(&wr.wr, wr.pushBlock)
 	wr.reset()
@@ -136,6 +147,30 @@ func (wr *writeRequest) push(src []prompbmarshal.TimeSeries) {
 	wr.tss = tssDst
 }
 
+func (wr *writeRequest) pushMetadata(src []prompbmarshal.MetricMetadata) {
+	mdsDst := wr.metadata
+	buf := wr.buf
+	for i := range src {
+		srcMD := &src[i]
+		mdsDst = append(mdsDst, prompbmarshal.MetricMetadata{
+			Type: srcMD.Type,
+		})
+		dstMD := &mdsDst[len(mdsDst)-1]
+
+		buf = append(buf, srcMD.MetricFamilyName...)
+		dstMD.MetricFamilyName = bytesutil.ToUnsafeString(buf[len(buf)-len(srcMD.MetricFamilyName):])
+		buf = append(buf, srcMD.Help...)
+		dstMD.Help = bytesutil.ToUnsafeString(buf[len(buf)-len(srcMD.Help):])
+		buf = append(buf, srcMD.Unit...)
+		dstMD.Unit = bytesutil.ToUnsafeString(buf[len(buf)-len(srcMD.Unit):])
+	}
+	wr.metadata = mdsDst
+	wr.buf = buf
+	if len(wr.metadata) >= maxRowsPerBlock {
+		wr.flush()
+	}
+}
+
 func (wr *writeRequest) copyTimeSeries(dst, src *prompbmarshal.TimeSeries) {
 	labelsDst := wr.labels
 	labelsLen := len(wr.labels)
@@ -162,7 +197,7 @@ func (wr *writeRequest) copyTimeSeries(dst, src *prompbmarshal.TimeSeries) {
 }
 
 func pushWriteRequest(wr *prompbmarshal.WriteRequest, pushBlock func(block []byte)) {
-	if len(wr.Timeseries) == 0 {
+	if len(wr.Timeseries) == 0 && len(wr.Metadata) == 0 {
 		// Nothing to push
 		return
 	}
@@ -186,12 +221,17 @@ func pushWriteRequest(wr *prompbmarshal.WriteRequest, pushBlock func(block []byt
 
 	// Too big block. Recursively split it into smaller parts.
 	timeseries := wr.Timeseries
+	metadata := wr.Metadata
 	n := len(timeseries) / 2
+	m := len(metadata) / 2
 	wr.Timeseries = timeseries[:n]
+	wr.Metadata = metadata[:m]
 	pushWriteRequest(wr, pushBlock)
 	wr.Timeseries = timeseries[n:]
+	wr.Metadata = metadata[m:]
 	pushWriteRequest(wr, pushBlock)
 	wr.Timeseries = timeseries
+	wr.Metadata = metadata
 }
 
 var (
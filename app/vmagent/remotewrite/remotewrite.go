@@ -15,6 +15,7 @@ import (
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/persistentqueue"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/procutil"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/prompbmarshal"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/streamaggr"
 	"github.com/VictoriaMetrics/metrics"
 	xxhash "github.com/cespare/xxhash/v2"
 )
@@ -22,7 +23,8 @@ import (
 var (
 	remoteWriteURLs = flagutil.NewArray("remoteWrite.url", "Remote storage URL to write data to. It must support Prometheus remote_write API. "+
 		"It is recommended using VictoriaMetrics as remote storage. Example url: http://<victoriametrics-host>:8428/api/v1/write . "+
-		"Pass multiple -remoteWrite.url flags in order to write data concurrently to multiple remote storage systems")
+		"Pass multiple -remoteWrite.url flags in order to write data concurrently to multiple remote storage systems. "+
+		"Data may be written to Kafka topic via kafka://host1:9092;host2:9092/?topic=name url. See https://victoriametrics.github.io/vmagent.html#writing-data-to-kafka")
 	tmpDataPath = flag.String("remoteWrite.tmpDataPath", "vmagent-remotewrite-data", "Path to directory where temporary data for remote write component is stored")
 	queues      = flag.Int("remoteWrite.queues", 1, "The number of concurrent queues to each -remoteWrite.url. Set more queues if a single queue "+
 		"isn't enough for sending high volume of collected data to remote storage")
@@ -71,6 +73,10 @@ func Init() {
 		logger.Fatalf("cannot load relabel configs: %s", err)
 	}
 	allRelabelConfigs.Store(rcs)
+	if len(*streamAggrConfig) > len(*remoteWriteURLs) {
+		logger.Fatalf("too many -remoteWrite.streamAggr.config args: %d; it mustn't exceed the number of -remoteWrite.url args: %d",
+			len(*streamAggrConfig), len(*remoteWriteURLs))
+	}
 
 	maxInmemoryBlocks := memory.Allowed() / len(*remoteWriteURLs) / maxRowsPerBlock / 100
 	if maxInmemoryBlocks > 200 {
@@ -132,6 +138,8 @@ func Stop() {
 
 // Push sends wr to remote storage systems set via `-remoteWrite.url`.
 //
+// Metric metadata from wr is sent as is, since relabeling and stream aggregation are applied only to time series.
+//
 // Note that wr may be modified by Push due to relabeling and rounding.
 func Push(wr *prompbmarshal.WriteRequest) {
 	if *significantFigures > 0 {
@@ -176,6 +184,11 @@ func Push(wr *prompbmarshal.WriteRequest) {
 	if rctx != nil {
 		putRelabelCtx(rctx)
 	}
+	if len(wr.Metadata) > 0 {
+		for _, rwctx := range rwctxs {
+			rwctx.PushMetadata(wr.Metadata)
+		}
+	}
 }
 
 var globalRelabelMetricsDropped = metrics.NewCounter("vmagent_remotewrite_global_relabel_metrics_dropped_total")
@@ -187,6 +200,9 @@ type remoteWriteCtx struct {
 	pss        []*pendingSeries
 	pssNextIdx uint64
 
+	// sas contains optional stream aggregators for the data sent to the remote storage.
+	sas *streamaggr.Aggregators
+
 	relabelMetricsDropped *metrics.Counter
 }
 
@@ -205,7 +221,7 @@ func newRemoteWriteCtx(argIdx int, remoteWriteURL string, maxInmemoryBlocks int,
 	for i := range pss {
 		pss[i] = newPendingSeries(fq.MustWriteBlock)
 	}
-	return &remoteWriteCtx{
+	rwctx := &remoteWriteCtx{
 		idx: argIdx,
 		fq:  fq,
 		c:   c,
@@ -213,9 +229,23 @@ func newRemoteWriteCtx(argIdx int, remoteWriteURL string, maxInmemoryBlocks int,
 
 		relabelMetricsDropped: metrics.GetOrCreateCounter(fmt.Sprintf(`vmagent_remotewrite_relabel_metrics_dropped_total{path=%q, url=%q}`, path, urlLabelValue)),
 	}
+
+	// Initialize stream aggregation for the given remoteWriteURL if needed.
+	sas, err := newStreamAggrConfig(argIdx, rwctx.pushInternal)
+	if err != nil {
+		logger.Fatalf("cannot initialize stream aggregators: %s", err)
+	}
+	rwctx.sas = sas
+
+	return rwctx
 }
 
 func (rwctx *remoteWriteCtx) MustStop() {
+	// Stop stream aggregators before stopping pending series,
+	// since they push the remaining aggregated data on stop.
+	rwctx.sas.MustStop()
+	rwctx.sas = nil
+
 	for _, ps := range rwctx.pss {
 		ps.MustStop()
 	}
@@ -246,9 +276,16 @@ func (rwctx *remoteWriteCtx) Push(tss []prompbmarshal.TimeSeries) {
 		tss = rctx.applyRelabeling(tss, nil, prcs)
 		rwctx.relabelMetricsDropped.Add(tssLen - len(tss))
 	}
-	pss := rwctx.pss
-	idx := atomic.AddUint64(&rwctx.pssNextIdx, 1) % uint64(len(pss))
-	pss[idx].Push(tss)
+	if rwctx.sas != nil {
+		rwctx.sas.Push(tss)
+		if !*streamAggrKeepInput {
+			// Drop the input samples, since only the aggregated data must be sent to the remote storage.
+			tss = tss[:0]
+		}
+	}
+	if len(tss) > 0 {
+		rwctx.pushInternal(tss)
+	}
 	if rctx != nil {
 		*v = prompbmarshal.ResetTimeSeries(tss)
 		tssRelabelPool.Put(v)
@@ -256,6 +293,18 @@ func (rwctx *remoteWriteCtx) Push(tss []prompbmarshal.TimeSeries) {
 	}
 }
 
+func (rwctx *remoteWriteCtx) PushMetadata(mds []prompbmarshal.MetricMetadata) {
+	pss := rwctx.pss
+	idx := atomic.AddUint64(&rwctx.pssNextIdx, 1) % uint64(len(pss))
+	pss[idx].PushMetadata(mds)
+}
+
+func (rwctx *remoteWriteCtx) pushInternal(tss []prompbmarshal.TimeSeries) {
+	pss := rwctx.pss
+	idx := atomic.AddUint64(&rwctx.pssNextIdx, 1) % uint64(len(pss))
+	pss[idx].Push(tss)
+}
+
 var tssRelabelPool = &sync.Pool{
 	New: func() interface{} {
 		a := []prompbmarshal.TimeSeries{}
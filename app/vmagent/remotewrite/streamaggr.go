@@ -0,0 +1,51 @@
+package remotewrite
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/flagutil"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/prompbmarshal"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/streamaggr"
+)
+
+var (
+	streamAggrConfig = flagutil.NewArray("remoteWrite.streamAggr.config", "Optional path to config file with stream aggregation config for the corresponding -remoteWrite.url. "+
+		"Only the aggregated metrics are sent to the corresponding -remoteWrite.url unless -remoteWrite.streamAggr.keepInput is set. "+
+		"See https://victoriametrics.github.io/vmagent.html#stream-aggregation")
+	streamAggrKeepInput = flag.Bool("remoteWrite.streamAggr.keepInput", false, "Whether to keep input samples after the aggregation with -remoteWrite.streamAggr.config. "+
+		"By default the input samples are dropped after the aggregation, so only the aggregate data is sent to the -remoteWrite.url. "+
+		"See https://victoriametrics.github.io/vmagent.html#stream-aggregation")
+)
+
+// CheckStreamAggrConfigs checks -remoteWrite.streamAggr.config.
+func CheckStreamAggrConfigs() error {
+	pushNoop := func(tss []prompbmarshal.TimeSeries) {}
+	for idx := range *streamAggrConfig {
+		sas, err := newStreamAggrConfig(idx, pushNoop)
+		if err != nil {
+			return err
+		}
+		sas.MustStop()
+	}
+	return nil
+}
+
+// newStreamAggrConfig returns stream aggregators for -remoteWrite.url with the given argIdx.
+//
+// nil is returned if -remoteWrite.streamAggr.config isn't set for the given argIdx.
+func newStreamAggrConfig(argIdx int, pushFunc streamaggr.PushFunc) (*streamaggr.Aggregators, error) {
+	if argIdx >= len(*streamAggrConfig) {
+		return nil, nil
+	}
+	path := (*streamAggrConfig)[argIdx]
+	if len(path) == 0 {
+		// Skip empty stream aggregation config.
+		return nil, nil
+	}
+	sas, err := streamaggr.LoadFromFile(path, pushFunc)
+	if err != nil {
+		return nil, fmt.Errorf("cannot load -remoteWrite.streamAggr.config=%q: %w", path, err)
+	}
+	return sas, nil
+}
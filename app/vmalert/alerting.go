@@ -19,14 +19,15 @@ import (
 
 // AlertingRule is basic alert entity
 type AlertingRule struct {
-	RuleID      uint64
-	Name        string
-	Expr        string
-	For         time.Duration
-	Labels      map[string]string
-	Annotations map[string]string
-	GroupID     uint64
-	GroupName   string
+	RuleID        uint64
+	Name          string
+	Expr          string
+	For           time.Duration
+	KeepFiringFor time.Duration
+	Labels        map[string]string
+	Annotations   map[string]string
+	GroupID       uint64
+	GroupName     string
 
 	// guard status fields
 	mu sync.RWMutex
@@ -50,16 +51,17 @@ type alertingRuleMetrics struct {
 
 func newAlertingRule(group *Group, cfg config.Rule) *AlertingRule {
 	ar := &AlertingRule{
-		RuleID:      cfg.ID,
-		Name:        cfg.Alert,
-		Expr:        cfg.Expr,
-		For:         cfg.For,
-		Labels:      cfg.Labels,
-		Annotations: cfg.Annotations,
-		GroupID:     group.ID(),
-		GroupName:   group.Name,
-		alerts:      make(map[uint64]*notifier.Alert),
-		metrics:     &alertingRuleMetrics{},
+		RuleID:        cfg.ID,
+		Name:          cfg.Alert,
+		Expr:          cfg.Expr,
+		For:           cfg.For,
+		KeepFiringFor: cfg.KeepFiringFor,
+		Labels:        cfg.Labels,
+		Annotations:   cfg.Annotations,
+		GroupID:       group.ID(),
+		GroupName:     group.Name,
+		alerts:        make(map[uint64]*notifier.Alert),
+		metrics:       &alertingRuleMetrics{},
 	}
 
 	labels := fmt.Sprintf(`alertname=%q, group=%q, id="%d"`, ar.Name, group.Name, ar.ID())
@@ -119,7 +121,7 @@ func (ar *AlertingRule) ID() uint64 {
 
 // Exec executes AlertingRule expression via the given Querier.
 // Based on the Querier results AlertingRule maintains notifier.Alerts
-func (ar *AlertingRule) Exec(ctx context.Context, q datasource.Querier, series bool) ([]prompbmarshal.TimeSeries, error) {
+func (ar *AlertingRule) Exec(ctx context.Context, q datasource.Querier, series bool, limit int) ([]prompbmarshal.TimeSeries, error) {
 	qMetrics, err := q.Query(ctx, ar.Expr)
 	ar.mu.Lock()
 	defer ar.mu.Unlock()
@@ -137,6 +139,17 @@ func (ar *AlertingRule) Exec(ctx context.Context, q datasource.Querier, series b
 		}
 	}
 
+	var prevAlerts map[uint64]*notifier.Alert
+	if limit > 0 {
+		// save the current alerts state in order to restore it
+		// if the evaluation result exceeds the limit
+		prevAlerts = make(map[uint64]*notifier.Alert, len(ar.alerts))
+		for h, a := range ar.alerts {
+			prevAlert := *a
+			prevAlerts[h] = &prevAlert
+		}
+	}
+
 	updated := make(map[uint64]struct{})
 	// update list of active alerts
 	for _, m := range qMetrics {
@@ -175,20 +188,165 @@ func (ar *AlertingRule) Exec(ctx context.Context, q datasource.Querier, series b
 				delete(ar.alerts, h)
 				continue
 			}
+			if a.State == notifier.StateFiring && ar.KeepFiringFor > 0 {
+				if a.KeepFiringSince.IsZero() {
+					a.KeepFiringSince = ar.lastExecTime
+				}
+				// keep the alert firing in order
+				// to suppress flapping resolves
+				if ar.lastExecTime.Sub(a.KeepFiringSince) < ar.KeepFiringFor {
+					continue
+				}
+			}
 			a.State = notifier.StateInactive
 			continue
 		}
+		a.KeepFiringSince = time.Time{}
 		if a.State == notifier.StatePending && time.Since(a.Start) >= ar.For {
 			a.State = notifier.StateFiring
 			alertsFired.Inc()
 		}
 	}
+
+	if limit > 0 {
+		var numActive int
+		for _, a := range ar.alerts {
+			if a.State != notifier.StateInactive {
+				numActive++
+			}
+		}
+		if numActive > limit {
+			// the evaluation result can't be trusted, so keep the previous
+			// alerts state. Dropping the alerts would lose the keep_firing_for
+			// state and firing alerts would never be resolved in Alertmanager.
+			ar.alerts = prevAlerts
+			ar.lastExecError = fmt.Errorf("exec exceeded limit of %d with %d alerts", limit, numActive)
+			return nil, ar.lastExecError
+		}
+	}
 	if series {
 		return ar.toTimeSeries(ar.lastExecTime), nil
 	}
 	return nil, nil
 }
 
+// ExecRange executes AlertingRule expression on the [start...end] time range via the given Querier
+// and returns ALERTS and ALERTS_FOR_STATE series for every evaluation step, where the alert was active.
+// See alertsRange for details. ExecRange doesn't change the rule state.
+func (ar *AlertingRule) ExecRange(ctx context.Context, q datasource.Querier, start, end time.Time, step time.Duration, limit int) ([]prompbmarshal.TimeSeries, error) {
+	var tss []prompbmarshal.TimeSeries
+	series := make(map[uint64]int)
+	err := ar.alertsRange(ctx, q, start, end, step, limit, func(a *notifier.Alert, timestamp int64) {
+		// merge data points of the same series
+		for _, ts := range ar.alertToTimeSeries(a, time.Unix(timestamp, 0)) {
+			h := hashTimeSeries(ts)
+			if idx, ok := series[h]; ok {
+				tss[idx].Samples = append(tss[idx].Samples, ts.Samples...)
+				continue
+			}
+			series[h] = len(tss)
+			tss = append(tss, ts)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tss, nil
+}
+
+// alertsRange executes AlertingRule expression on the [start...end] time range via the given Querier
+// and calls f for every alert, which was active at the evaluation step with the given timestamp.
+// Alerts are considered active since the first data point of the uninterrupted sequence of data points
+// with the given step. Firing alerts are kept firing for ar.KeepFiringFor after the sequence ends.
+// The range is extended by ar.For and ar.KeepFiringFor into the past, so alerts which became active
+// before start get the proper state.
+func (ar *AlertingRule) alertsRange(ctx context.Context, q datasource.Querier, start, end time.Time, step time.Duration, limit int,
+	f func(a *notifier.Alert, timestamp int64)) error {
+	qMetrics, err := q.QueryRange(ctx, ar.Expr, start.Add(-ar.For-ar.KeepFiringFor), end, step)
+	if err != nil {
+		return fmt.Errorf("failed to execute query %q: %w", ar.Expr, err)
+	}
+
+	// group data points by series, since they may be interleaved
+	var hashes []uint64
+	points := make(map[uint64][]datasource.Metric)
+	for _, m := range qMetrics {
+		h := hash(m)
+		if _, ok := points[h]; !ok {
+			hashes = append(hashes, h)
+		}
+		points[h] = append(points[h], m)
+	}
+
+	perStep := make(map[int64]int)
+	stepSecs := int64(step.Seconds())
+	keepFiringSecs := int64(ar.KeepFiringFor.Seconds())
+	addAlert := func(m datasource.Metric, h uint64, activeAt, timestamp int64) error {
+		if timestamp < start.Unix() || timestamp > end.Unix() {
+			return nil
+		}
+		perStep[timestamp]++
+		if limit > 0 && perStep[timestamp] > limit {
+			return fmt.Errorf("exec exceeded limit of %d alerts at %s", limit, time.Unix(timestamp, 0).Format(time.RFC3339))
+		}
+		a, err := ar.newAlert(m, time.Unix(activeAt, 0))
+		if err != nil {
+			return fmt.Errorf("failed to create alert: %w", err)
+		}
+		a.ID = h
+		a.State = notifier.StatePending
+		if time.Duration(timestamp-activeAt)*time.Second >= ar.For {
+			a.State = notifier.StateFiring
+		}
+		f(a, timestamp)
+		return nil
+	}
+	// keepFiring adds firing alert data points for evaluation steps after prev
+	// until the alert is resolved or next timestamp is reached.
+	// It returns true if the alert is still firing at next.
+	keepFiring := func(prev datasource.Metric, h uint64, activeAt, next int64) (bool, error) {
+		if keepFiringSecs <= 0 || time.Duration(prev.Timestamp-activeAt)*time.Second < ar.For {
+			return false, nil
+		}
+		keepFiringSince := prev.Timestamp + stepSecs
+		for ts := keepFiringSince; ts < next && ts-keepFiringSince < keepFiringSecs; ts += stepSecs {
+			if err := addAlert(prev, h, activeAt, ts); err != nil {
+				return false, err
+			}
+		}
+		return next-keepFiringSince < keepFiringSecs, nil
+	}
+	for _, h := range hashes {
+		ms := points[h]
+		sort.SliceStable(ms, func(i, j int) bool {
+			return ms[i].Timestamp < ms[j].Timestamp
+		})
+		var activeAt int64
+		for i, m := range ms {
+			if i == 0 {
+				activeAt = m.Timestamp
+			} else if prev := ms[i-1]; m.Timestamp-prev.Timestamp > stepSecs {
+				stillFiring, err := keepFiring(prev, h, activeAt, m.Timestamp)
+				if err != nil {
+					return err
+				}
+				if !stillFiring {
+					activeAt = m.Timestamp
+				}
+			}
+			if err := addAlert(m, h, activeAt, m.Timestamp); err != nil {
+				return err
+			}
+		}
+		if len(ms) > 0 {
+			if _, err := keepFiring(ms[len(ms)-1], h, activeAt, end.Unix()+1); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 func (ar *AlertingRule) toTimeSeries(timestamp time.Time) []prompbmarshal.TimeSeries {
 	var tss []prompbmarshal.TimeSeries
 	for _, a := range ar.alerts {
@@ -211,6 +369,7 @@ func (ar *AlertingRule) UpdateWith(r Rule) error {
 	}
 	ar.Expr = nr.Expr
 	ar.For = nr.For
+	ar.KeepFiringFor = nr.KeepFiringFor
 	ar.Labels = nr.Labels
 	ar.Annotations = nr.Annotations
 	return nil
@@ -301,15 +460,16 @@ func (ar *AlertingRule) RuleAPI() APIAlertingRule {
 	}
 	return APIAlertingRule{
 		// encode as strings to avoid rounding
-		ID:          fmt.Sprintf("%d", ar.ID()),
-		GroupID:     fmt.Sprintf("%d", ar.GroupID),
-		Name:        ar.Name,
-		Expression:  ar.Expr,
-		For:         ar.For.String(),
-		LastError:   lastErr,
-		LastExec:    ar.lastExecTime,
-		Labels:      ar.Labels,
-		Annotations: ar.Annotations,
+		ID:            fmt.Sprintf("%d", ar.ID()),
+		GroupID:       fmt.Sprintf("%d", ar.GroupID),
+		Name:          ar.Name,
+		Expression:    ar.Expr,
+		For:           ar.For.String(),
+		KeepFiringFor: ar.KeepFiringFor.String(),
+		LastError:     lastErr,
+		LastExec:      ar.lastExecTime,
+		Labels:        ar.Labels,
+		Annotations:   ar.Annotations,
 	}
 }
 
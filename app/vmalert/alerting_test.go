@@ -2,6 +2,8 @@ package main
 
 import (
 	"context"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -299,6 +301,38 @@ func TestAlertingRule_Exec(t *testing.T) {
 				hash(metricWithLabels(t, "name", "foo")): {State: notifier.StateFiring},
 			},
 		},
+		{
+			newTestAlertingRuleKeepFiring("firing=>keep_firing", 0, time.Hour),
+			[][]datasource.Metric{
+				{metricWithLabels(t, "name", "foo")},
+				// the alert must remain firing during keep_firing_for
+				{},
+				{},
+			},
+			map[uint64]*notifier.Alert{
+				hash(metricWithLabels(t, "name", "foo")): {State: notifier.StateFiring},
+			},
+		},
+		{
+			newTestAlertingRuleKeepFiring("firing=>keep_firing=>inactive", 0, defaultStep),
+			[][]datasource.Metric{
+				{metricWithLabels(t, "name", "foo")},
+				{},
+				{},
+			},
+			map[uint64]*notifier.Alert{
+				hash(metricWithLabels(t, "name", "foo")): {State: notifier.StateInactive},
+			},
+		},
+		{
+			newTestAlertingRuleKeepFiring("pending=>inactive with keep_firing", time.Hour, time.Hour),
+			[][]datasource.Metric{
+				{metricWithLabels(t, "name", "foo")},
+				// keep_firing_for isn't applied to pending alerts
+				{},
+			},
+			map[uint64]*notifier.Alert{},
+		},
 	}
 	fakeGroup := Group{Name: "TestRule_Exec"}
 	for _, tc := range testCases {
@@ -308,7 +342,7 @@ func TestAlertingRule_Exec(t *testing.T) {
 			for _, step := range tc.steps {
 				fq.reset()
 				fq.add(step...)
-				if _, err := tc.rule.Exec(context.TODO(), fq, false); err != nil {
+				if _, err := tc.rule.Exec(context.TODO(), fq, false, 0); err != nil {
 					t.Fatalf("unexpected err: %s", err)
 				}
 				// artificial delay between applying steps
@@ -330,6 +364,172 @@ func TestAlertingRule_Exec(t *testing.T) {
 	}
 }
 
+func TestAlertingRule_ExecLimit(t *testing.T) {
+	ar := newTestAlertingRule("limit", 0)
+	fq := &fakeQuerier{}
+	fq.add(metricWithLabels(t, "name", "foo"), metricWithLabels(t, "name", "bar"))
+	if _, err := ar.Exec(context.TODO(), fq, false, 2); err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+	if len(ar.alerts) != 2 {
+		t.Fatalf("expected 2 alerts; got %d", len(ar.alerts))
+	}
+	fq.add(metricWithLabels(t, "name", "baz"))
+	_, err := ar.Exec(context.TODO(), fq, false, 2)
+	if err == nil {
+		t.Fatalf("expected to get limit exceeded err; got nil")
+	}
+	if !strings.Contains(err.Error(), "exceeded limit of 2 with 3 alerts") {
+		t.Fatalf("unexpected err: %s", err)
+	}
+	if len(ar.alerts) != 2 {
+		t.Fatalf("expected alerts state to be kept on limit exceeding; got %d alerts", len(ar.alerts))
+	}
+}
+
+func TestAlertingRule_ExecLimitFiring(t *testing.T) {
+	ar := newTestAlertingRuleKeepFiring("limit firing", 0, time.Hour)
+	fq := &fakeQuerier{}
+	foo := metricWithLabels(t, "name", "foo")
+	fq.add(foo)
+	if _, err := ar.Exec(context.TODO(), fq, false, 1); err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+	// the alert must be kept firing during keep_firing_for
+	fq.reset()
+	if _, err := ar.Exec(context.TODO(), fq, false, 1); err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+	a, ok := ar.alerts[hash(foo)]
+	if !ok || a.State != notifier.StateFiring || a.KeepFiringSince.IsZero() {
+		t.Fatalf("expected the alert to be kept firing; got %+v", a)
+	}
+	keepFiringSince := a.KeepFiringSince
+
+	// the limit breach mustn't change the alerts state
+	fq.add(metricWithLabels(t, "name", "bar"), metricWithLabels(t, "name", "baz"))
+	if _, err := ar.Exec(context.TODO(), fq, false, 1); err == nil {
+		t.Fatalf("expected to get limit exceeded err; got nil")
+	}
+	if len(ar.alerts) != 1 {
+		t.Fatalf("expected 1 alert after limit exceeding; got %d", len(ar.alerts))
+	}
+	a, ok = ar.alerts[hash(foo)]
+	if !ok || a.State != notifier.StateFiring {
+		t.Fatalf("expected the alert to remain firing after limit exceeding; got %+v", a)
+	}
+	if !a.KeepFiringSince.Equal(keepFiringSince) {
+		t.Fatalf("expected keep_firing_for state to be kept; got %s; want %s", a.KeepFiringSince, keepFiringSince)
+	}
+
+	// the alert must be resolved once the limit isn't exceeded anymore
+	ar.KeepFiringFor = 0
+	fq.reset()
+	if _, err := ar.Exec(context.TODO(), fq, false, 1); err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+	if a := ar.alerts[hash(foo)]; a == nil || a.State != notifier.StateInactive {
+		t.Fatalf("expected the alert to become inactive; got %+v", a)
+	}
+}
+
+func TestAlertingRule_ExecRange(t *testing.T) {
+	ar := newTestAlertingRule("for", 2*time.Minute)
+	ar.GroupName = "test"
+	fq := &fakeQuerier{}
+	// the alert is active since 880 and becomes inactive
+	// after 1060 until the next data point at 1240
+	for _, ts := range []int64{880, 940, 1000, 1060, 1240} {
+		m := metricWithValueAndLabels(t, 1, "__name__", "foo", "job", "foo")
+		m.Timestamp = ts
+		fq.add(m)
+	}
+
+	tss, err := ar.ExecRange(context.TODO(), fq, time.Unix(1000, 0), time.Unix(1240, 0), time.Minute, 0)
+	if err != nil {
+		t.Fatalf("unexpected ExecRange err: %s", err)
+	}
+	alertLabels := func(name, state string) []prompbmarshal.Label {
+		labels := []prompbmarshal.Label{
+			{Name: "__name__", Value: name},
+			{Name: alertGroupNameLabel, Value: "test"},
+			{Name: alertNameLabel, Value: "for"},
+		}
+		if state != "" {
+			labels = append(labels, prompbmarshal.Label{Name: alertStateLabel, Value: state})
+		}
+		return append(labels, prompbmarshal.Label{Name: "job", Value: "foo"})
+	}
+	expTS := []prompbmarshal.TimeSeries{
+		{
+			Labels: alertLabels(alertMetricName, notifier.StateFiring.String()),
+			Samples: []prompbmarshal.Sample{
+				{Value: 1, Timestamp: 1000e3},
+				{Value: 1, Timestamp: 1060e3},
+			},
+		},
+		{
+			Labels: alertLabels(alertForStateMetricName, ""),
+			Samples: []prompbmarshal.Sample{
+				{Value: 880, Timestamp: 1000e3},
+				{Value: 880, Timestamp: 1060e3},
+				{Value: 1240, Timestamp: 1240e3},
+			},
+		},
+		{
+			Labels: alertLabels(alertMetricName, notifier.StatePending.String()),
+			Samples: []prompbmarshal.Sample{
+				{Value: 1, Timestamp: 1240e3},
+			},
+		},
+	}
+	if !reflect.DeepEqual(tss, expTS) {
+		t.Fatalf("unexpected time series;\ngot\n%+v\nwant\n%+v", tss, expTS)
+	}
+	if len(ar.alerts) != 0 {
+		t.Fatalf("ExecRange mustn't change the rule state; got %d alerts", len(ar.alerts))
+	}
+}
+
+func TestAlertingRule_ExecRangeKeepFiring(t *testing.T) {
+	ar := newTestAlertingRuleKeepFiring("keep", 0, 2*time.Minute)
+	fq := &fakeQuerier{}
+	// the gap at 1060 is covered by keep_firing_for,
+	// while the alert is resolved after 1120 since the next
+	// data point at 1360 is out of keep_firing_for
+	for _, ts := range []int64{1000, 1120, 1360} {
+		m := metricWithValueAndLabels(t, 1, "__name__", "foo")
+		m.Timestamp = ts
+		fq.add(m)
+	}
+	tss, err := ar.ExecRange(context.TODO(), fq, time.Unix(1000, 0), time.Unix(1400, 0), time.Minute, 0)
+	if err != nil {
+		t.Fatalf("unexpected ExecRange err: %s", err)
+	}
+	if len(tss) != 1 {
+		t.Fatalf("expected 1 time series; got %d: %+v", len(tss), tss)
+	}
+	var timestamps []int64
+	for _, s := range tss[0].Samples {
+		timestamps = append(timestamps, s.Timestamp/1e3)
+	}
+	// 1180 and 1240 are kept firing after 1120,
+	// 1420 is out of range
+	expTimestamps := []int64{1000, 1060, 1120, 1180, 1240, 1360}
+	if !reflect.DeepEqual(timestamps, expTimestamps) {
+		t.Fatalf("unexpected firing timestamps; got %v; want %v", timestamps, expTimestamps)
+	}
+
+	if _, err := ar.ExecRange(context.TODO(), fq, time.Unix(1000, 0), time.Unix(1400, 0), time.Minute, 1); err != nil {
+		t.Fatalf("unexpected err for limit=1: %s", err)
+	}
+	fq.add(metricWithValueAndLabels(t, 1, "__name__", "foo", "job", "bar"))
+	fq.metrics[len(fq.metrics)-1].Timestamp = 1360
+	if _, err := ar.ExecRange(context.TODO(), fq, time.Unix(1000, 0), time.Unix(1400, 0), time.Minute, 1); err == nil {
+		t.Fatalf("expected to get limit exceeded err; got nil")
+	}
+}
+
 func TestAlertingRule_Restore(t *testing.T) {
 	testCases := []struct {
 		rule      *AlertingRule
@@ -453,3 +653,9 @@ func newTestRuleWithLabels(name string, labels ...string) *AlertingRule {
 func newTestAlertingRule(name string, waitFor time.Duration) *AlertingRule {
 	return &AlertingRule{Name: name, alerts: make(map[uint64]*notifier.Alert), For: waitFor}
 }
+
+func newTestAlertingRuleKeepFiring(name string, waitFor, keepFiringFor time.Duration) *AlertingRule {
+	ar := newTestAlertingRule(name, waitFor)
+	ar.KeepFiringFor = keepFiringFor
+	return ar
+}
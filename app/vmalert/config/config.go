@@ -5,11 +5,13 @@ import (
 	"fmt"
 	"hash/fnv"
 	"io/ioutil"
+	"net/url"
 	"path/filepath"
 	"sort"
 	"strings"
 	"time"
 
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmalert/datasource"
 	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmalert/notifier"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/envtemplate"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logger"
@@ -25,6 +27,20 @@ type Group struct {
 	Interval    time.Duration `yaml:"interval,omitempty"`
 	Rules       []Rule        `yaml:"rules"`
 	Concurrency int           `yaml:"concurrency"`
+	// Type is the datasource type used for executing the group rules,
+	// e.g. `prometheus` or `graphite`. See datasource.Type.
+	Type string `yaml:"type,omitempty"`
+	// DatasourceURL overrides -datasource.url for the group rules.
+	DatasourceURL string `yaml:"datasource_url,omitempty"`
+	// Limit is the max number of alerts or series a single rule of the group may produce
+	// on evaluation. The evaluation fails if the limit is exceeded. Zero means no limit.
+	Limit int `yaml:"limit,omitempty"`
+	// Params contains optional query params, which are passed to the datasource
+	// with every request made by the group rules. E.g. `nocache=1` or `extra_label`.
+	Params url.Values `yaml:"params,omitempty"`
+	// Headers contains optional HTTP headers, which are passed to the datasource
+	// with every request made by the group rules.
+	Headers []Header `yaml:"headers,omitempty"`
 	// Checksum stores the hash of yaml definition for this group.
 	// May be used to detect any changes like rules re-ordering etc.
 	Checksum string
@@ -49,11 +65,56 @@ func (g *Group) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	return nil
 }
 
+// Header is an HTTP header in the form `name: value`
+type Header struct {
+	Key   string
+	Value string
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (h *Header) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var s string
+	if err := unmarshal(&s); err != nil {
+		return err
+	}
+	n := strings.IndexByte(s, ':')
+	if n < 0 {
+		return fmt.Errorf("missing ':' in header %q; it must be in the form `name: value`", s)
+	}
+	h.Key = strings.TrimSpace(s[:n])
+	h.Value = strings.TrimSpace(s[n+1:])
+	if h.Key == "" {
+		return fmt.Errorf("empty header name in %q", s)
+	}
+	return nil
+}
+
+// MarshalYAML implements the yaml.Marshaler interface.
+func (h Header) MarshalYAML() (interface{}, error) {
+	return fmt.Sprintf("%s: %s", h.Key, h.Value), nil
+}
+
 // Validate check for internal Group or Rule configuration errors
 func (g *Group) Validate(validateAnnotations, validateExpressions bool) error {
 	if g.Name == "" {
 		return fmt.Errorf("group name must be set")
 	}
+	if g.Limit < 0 {
+		return fmt.Errorf("group %q: limit can't be negative; got %d", g.Name, g.Limit)
+	}
+	dsType, err := datasource.ParseType(g.Type)
+	if err != nil {
+		return fmt.Errorf("group %q: %w", g.Name, err)
+	}
+	if g.DatasourceURL != "" {
+		u, err := url.Parse(g.DatasourceURL)
+		if err != nil {
+			return fmt.Errorf("group %q: cannot parse datasource_url: %w", g.Name, err)
+		}
+		if u.Scheme != "http" && u.Scheme != "https" {
+			return fmt.Errorf("group %q: unsupported scheme %q in datasource_url; supported schemes: http, https", g.Name, u.Scheme)
+		}
+	}
 	if len(g.Rules) == 0 {
 		return fmt.Errorf("group %q can't contain no rules", g.Name)
 	}
@@ -70,7 +131,8 @@ func (g *Group) Validate(validateAnnotations, validateExpressions bool) error {
 		if err := r.Validate(); err != nil {
 			return fmt.Errorf("invalid rule %q.%q: %w", g.Name, ruleName, err)
 		}
-		if validateExpressions {
+		// Graphite expressions can't be validated via MetricsQL engine
+		if validateExpressions && dsType == datasource.TypePrometheus {
 			if _, err := metricsql.Parse(r.Expr); err != nil {
 				return fmt.Errorf("invalid expression for rule %q.%q: %w", g.Name, ruleName, err)
 			}
@@ -90,13 +152,16 @@ func (g *Group) Validate(validateAnnotations, validateExpressions bool) error {
 // Rule describes entity that represent either
 // recording rule or alerting rule.
 type Rule struct {
-	ID          uint64
-	Record      string            `yaml:"record,omitempty"`
-	Alert       string            `yaml:"alert,omitempty"`
-	Expr        string            `yaml:"expr"`
-	For         time.Duration     `yaml:"for,omitempty"`
-	Labels      map[string]string `yaml:"labels,omitempty"`
-	Annotations map[string]string `yaml:"annotations,omitempty"`
+	ID     uint64
+	Record string        `yaml:"record,omitempty"`
+	Alert  string        `yaml:"alert,omitempty"`
+	Expr   string        `yaml:"expr"`
+	For    time.Duration `yaml:"for,omitempty"`
+	// KeepFiringFor defines how long the alert keeps firing
+	// after the alert condition stopped being met.
+	KeepFiringFor time.Duration     `yaml:"keep_firing_for,omitempty"`
+	Labels        map[string]string `yaml:"labels,omitempty"`
+	Annotations   map[string]string `yaml:"annotations,omitempty"`
 
 	// Catches all undefined fields and must be empty after parsing.
 	XXX map[string]interface{} `yaml:",inline"`
@@ -149,6 +214,12 @@ func (r *Rule) Validate() error {
 	if r.Expr == "" {
 		return fmt.Errorf("expression can't be empty")
 	}
+	if r.Record != "" && (r.For != 0 || r.KeepFiringFor != 0) {
+		return fmt.Errorf("`for` and `keep_firing_for` can be set only for alerting rules")
+	}
+	if r.For < 0 || r.KeepFiringFor < 0 {
+		return fmt.Errorf("`for` and `keep_firing_for` can't be negative")
+	}
 	return checkOverflow(r.XXX, "rule")
 }
 
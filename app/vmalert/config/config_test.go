@@ -52,6 +52,14 @@ func TestParseBad(t *testing.T) {
 			[]string{"testdata/dir/rules4-bad.rules"},
 			"either `record` or `alert` must be set",
 		},
+		{
+			[]string{"testdata/dir/rules6-bad.rules"},
+			"missing ':' in header",
+		},
+		{
+			[]string{"testdata/dir/rules7-bad.rules"},
+			"can be set only for alerting rules",
+		},
 	}
 	for _, tc := range testCases {
 		_, err := Parse(tc.path, true, true)
@@ -75,6 +83,39 @@ func TestRule_Validate(t *testing.T) {
 	if err := (&Rule{Alert: "alert", Expr: "test>0"}).Validate(); err != nil {
 		t.Errorf("expected valid rule; got %s", err)
 	}
+	if err := (&Rule{Alert: "alert", Expr: "test>0", KeepFiringFor: -time.Minute}).Validate(); err == nil {
+		t.Errorf("expected negative keep_firing_for error")
+	}
+	if err := (&Rule{Record: "record", Expr: "test", For: time.Minute}).Validate(); err == nil {
+		t.Errorf("expected `for` in recording rule error")
+	}
+}
+
+func TestParseGroupParams(t *testing.T) {
+	groups, err := Parse([]string{"testdata/rules3-good.rules"}, true, true)
+	if err != nil {
+		t.Fatalf("error parsing file: %s", err)
+	}
+	if len(groups) != 2 {
+		t.Fatalf("expected 2 groups; got %d", len(groups))
+	}
+	g := groups[0]
+	if g.Limit != 1000 {
+		t.Fatalf("expected limit 1000; got %d", g.Limit)
+	}
+	if g.Params.Get("nocache") != "1" || g.Params.Get("extra_label") != "env=dev" {
+		t.Fatalf("unexpected params %v", g.Params)
+	}
+	if len(g.Headers) != 1 || g.Headers[0] != (Header{Key: "X-Scope-OrgID", Value: "tenant1"}) {
+		t.Fatalf("unexpected headers %v", g.Headers)
+	}
+	if g.Rules[0].KeepFiringFor != 10*time.Minute {
+		t.Fatalf("expected keep_firing_for 10m; got %s", g.Rules[0].KeepFiringFor)
+	}
+	g = groups[1]
+	if g.Type != "graphite" || g.DatasourceURL != "http://graphite:8080" {
+		t.Fatalf("unexpected datasource overrides; type=%q, datasource_url=%q", g.Type, g.DatasourceURL)
+	}
 }
 
 func TestGroup_Validate(t *testing.T) {
@@ -93,6 +134,52 @@ func TestGroup_Validate(t *testing.T) {
 			group:  &Group{Name: "test"},
 			expErr: "contain no rules",
 		},
+		{
+			group: &Group{Name: "test", Type: "foo",
+				Rules: []Rule{
+					{
+						Record: "record",
+						Expr:   "up",
+					},
+				},
+			},
+			expErr: "unknown datasource type",
+		},
+		{
+			group: &Group{Name: "test", DatasourceURL: "localhost:8428",
+				Rules: []Rule{
+					{
+						Record: "record",
+						Expr:   "up",
+					},
+				},
+			},
+			expErr: "unsupported scheme",
+		},
+		{
+			// graphite expressions mustn't be validated as MetricsQL
+			group: &Group{Name: "test", Type: "graphite", DatasourceURL: "http://graphite:8080",
+				Rules: []Rule{
+					{
+						Record: "record",
+						Expr:   "sumSeries(foo.*.bar)|aliasByNode(1)",
+					},
+				},
+			},
+			validateExpressions: true,
+			expErr:              "",
+		},
+		{
+			group: &Group{Name: "test", Limit: -1,
+				Rules: []Rule{
+					{
+						Record: "record",
+						Expr:   "up | 0",
+					},
+				},
+			},
+			expErr: "limit can't be negative",
+		},
 		{
 			group: &Group{Name: "test",
 				Rules: []Rule{
@@ -1,12 +1,66 @@
 package datasource
 
-import "context"
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+)
 
-// Querier interface wraps Query method which
-// executes given query and returns list of Metrics
+// Querier interface wraps Query and QueryRange methods which
+// execute given query and return list of Metrics
 // as result
 type Querier interface {
 	Query(ctx context.Context, query string) ([]Metric, error)
+	// QueryRange executes given query on the [start...end] time range
+	// with the given step. Every returned Metric represents a single data point.
+	QueryRange(ctx context.Context, query string, start, end time.Time, step time.Duration) ([]Metric, error)
+}
+
+// QuerierParams contains optional params, which are applied
+// to every request made by Querier
+type QuerierParams struct {
+	// DataSourceType overrides the type of datasource if non-empty
+	DataSourceType Type
+	// DataSourceURL overrides the datasource URL if non-empty
+	DataSourceURL string
+	// QueryParams are added to query args of every request
+	QueryParams url.Values
+	// Headers are set as HTTP headers of every request
+	Headers map[string]string
+}
+
+// Type is the type of datasource, which defines
+// the API used for executing queries
+type Type string
+
+const (
+	// TypePrometheus is the datasource with Prometheus querying API
+	// such as VictoriaMetrics or Prometheus. This is the default type.
+	TypePrometheus Type = "prometheus"
+	// TypeGraphite is the datasource with Graphite Render API
+	TypeGraphite Type = "graphite"
+)
+
+// ParseType returns Type for the given s.
+// Empty s is parsed as TypePrometheus.
+func ParseType(s string) (Type, error) {
+	switch Type(s) {
+	case "", TypePrometheus:
+		return TypePrometheus, nil
+	case TypeGraphite:
+		return TypeGraphite, nil
+	default:
+		return "", fmt.Errorf("unknown datasource type %q; supported types: %q, %q", s, TypePrometheus, TypeGraphite)
+	}
+}
+
+// QuerierBuilder is a Querier, which can be copied
+// with the given QuerierParams applied
+type QuerierBuilder interface {
+	Querier
+	// BuildWithParams returns a copy of Querier with the given params.
+	BuildWithParams(params QuerierParams) Querier
 }
 
 // Metric is the basic entity which should be return by datasource
@@ -0,0 +1,76 @@
+package datasource
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"time"
+)
+
+// graphiteLookback is the time range for instant queries to Graphite datasource.
+// The last data point on this range is used as the query result.
+const graphiteLookback = 5 * time.Minute
+
+const graphitePath = "/render?format=json&target="
+
+type graphiteResponse []graphiteResponseTarget
+
+type graphiteResponseTarget struct {
+	Target     string            `json:"target"`
+	Tags       map[string]string `json:"tags"`
+	DataPoints [][2]*float64     `json:"datapoints"`
+}
+
+// metrics converts r to Metrics. Only the last non-null data point
+// of every target is returned if lastOnly is set.
+func (r graphiteResponse) metrics(lastOnly bool) []Metric {
+	var ms []Metric
+	for _, res := range r {
+		labels := []Label{{Name: "__name__", Value: res.Target}}
+		var tagNames []string
+		for k := range res.Tags {
+			// `name` tag duplicates the target
+			if k == "name" {
+				continue
+			}
+			tagNames = append(tagNames, k)
+		}
+		sort.Strings(tagNames)
+		for _, k := range tagNames {
+			labels = append(labels, Label{Name: k, Value: res.Tags[k]})
+		}
+		var points []Metric
+		for _, dp := range res.DataPoints {
+			// null values mean there is no data for the given timestamp
+			if dp[0] == nil || dp[1] == nil {
+				continue
+			}
+			points = append(points, Metric{
+				Labels:    labels,
+				Timestamp: int64(*dp[1]),
+				Value:     *dp[0],
+			})
+		}
+		if lastOnly && len(points) > 0 {
+			points = points[len(points)-1:]
+		}
+		ms = append(ms, points...)
+	}
+	return ms
+}
+
+func (s *VMStorage) queryGraphite(ctx context.Context, query string, start, end time.Time, lastOnly bool) ([]Metric, error) {
+	reqURL := fmt.Sprintf("%s%s%s&from=%d&until=%d", s.datasourceURL, graphitePath, url.QueryEscape(query), start.Unix(), end.Unix())
+	resp, err := s.doRequest(ctx, reqURL)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	var r graphiteResponse
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		return nil, fmt.Errorf("error parsing graphite metrics for %s: %w", resp.Request.URL, err)
+	}
+	return r.metrics(lastOnly), nil
+}
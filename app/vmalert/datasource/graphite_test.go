@@ -0,0 +1,55 @@
+package datasource
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestGraphiteResponseMetrics(t *testing.T) {
+	f := func(data string, lastOnly bool, expected []Metric) {
+		t.Helper()
+		var r graphiteResponse
+		if err := json.Unmarshal([]byte(data), &r); err != nil {
+			t.Fatalf("cannot parse response: %s", err)
+		}
+		ms := r.metrics(lastOnly)
+		if !reflect.DeepEqual(ms, expected) {
+			t.Fatalf("unexpected metrics;\ngot\n%+v\nwant\n%+v", ms, expected)
+		}
+	}
+	f(`[]`, false, nil)
+	// target without data points
+	f(`[{"target":"foo.bar","datapoints":[[null,1583786000]]}]`, true, nil)
+
+	data := `[{"target":"foo.bar","tags":{"name":"foo.bar","env":"dev"},"datapoints":[[1,1583786000],[null,1583786060],[3,1583786120],[null,1583786180]]}]`
+	labels := []Label{{Name: "__name__", Value: "foo.bar"}, {Name: "env", Value: "dev"}}
+	f(data, false, []Metric{
+		{Labels: labels, Timestamp: 1583786000, Value: 1},
+		{Labels: labels, Timestamp: 1583786120, Value: 3},
+	})
+	// only the last non-null data point must be returned
+	f(data, true, []Metric{
+		{Labels: labels, Timestamp: 1583786120, Value: 3},
+	})
+}
+
+func TestParseType(t *testing.T) {
+	f := func(s string, expected Type) {
+		t.Helper()
+		tp, err := ParseType(s)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if tp != expected {
+			t.Fatalf("unexpected type; got %q; want %q", tp, expected)
+		}
+	}
+	f("", TypePrometheus)
+	f("prometheus", TypePrometheus)
+	f("graphite", TypeGraphite)
+
+	if _, err := ParseType("foo"); err == nil {
+		t.Fatalf("expecting non-nil error for unknown type")
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"net/url"
 	"strconv"
 	"strings"
+	"time"
 )
 
 type response struct {
@@ -18,87 +19,181 @@ type response struct {
 		Result     []struct {
 			Labels map[string]string `json:"metric"`
 			TV     [2]interface{}    `json:"value"`
+			Values [][2]interface{}  `json:"values"`
 		} `json:"result"`
 	} `json:"data"`
 	ErrorType string `json:"errorType"`
 	Error     string `json:"error"`
 }
 
+const (
+	statusSuccess, statusError = "success", "error"
+	rtVector, rtMatrix         = "vector", "matrix"
+)
+
 func (r response) metrics() ([]Metric, error) {
 	var ms []Metric
 	var m Metric
-	var f float64
 	var err error
 	for i, res := range r.Data.Result {
-		f, err = strconv.ParseFloat(res.TV[1].(string), 64)
-		if err != nil {
-			return nil, fmt.Errorf("metric %v, unable to parse float64 from %s: %w", res, res.TV[1], err)
-		}
 		m.Labels = nil
 		for k, v := range r.Data.Result[i].Labels {
 			m.Labels = append(m.Labels, Label{Name: k, Value: v})
 		}
-		m.Timestamp = int64(res.TV[0].(float64))
-		m.Value = f
-		ms = append(ms, m)
+		if r.Data.ResultType == rtVector {
+			m.Timestamp, m.Value, err = parseTV(res.TV)
+			if err != nil {
+				return nil, fmt.Errorf("metric %v: %w", res.Labels, err)
+			}
+			ms = append(ms, m)
+			continue
+		}
+		// matrix result is flattened into a Metric per each data point
+		for _, tv := range res.Values {
+			m.Timestamp, m.Value, err = parseTV(tv)
+			if err != nil {
+				return nil, fmt.Errorf("metric %v: %w", res.Labels, err)
+			}
+			ms = append(ms, m)
+		}
 	}
 	return ms, nil
 }
 
-const queryPath = "/api/v1/query?query="
+func parseTV(tv [2]interface{}) (int64, float64, error) {
+	ts, ok := tv[0].(float64)
+	if !ok {
+		return 0, 0, fmt.Errorf("unable to parse timestamp from %v", tv[0])
+	}
+	s, ok := tv[1].(string)
+	if !ok {
+		return 0, 0, fmt.Errorf("unable to parse value from %v", tv[1])
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("unable to parse float64 from %s: %w", s, err)
+	}
+	return int64(ts), f, nil
+}
+
+const (
+	queryPath      = "/api/v1/query?query="
+	queryRangePath = "/api/v1/query_range?query="
+)
 
 // VMStorage represents vmstorage entity with ability to read and write metrics
 type VMStorage struct {
-	c             *http.Client
-	queryURL      string
-	basicAuthUser string
-	basicAuthPass string
+	c              *http.Client
+	datasourceURL  string
+	dataSourceType Type
+	basicAuthUser  string
+	basicAuthPass  string
+
+	extraParams  url.Values
+	extraHeaders map[string]string
 }
 
 // NewVMStorage is a constructor for VMStorage
 func NewVMStorage(baseURL, basicAuthUser, basicAuthPass string, c *http.Client) *VMStorage {
 	return &VMStorage{
-		c:             c,
-		basicAuthUser: basicAuthUser,
-		basicAuthPass: basicAuthPass,
-		queryURL:      strings.TrimSuffix(baseURL, "/") + queryPath,
+		c:              c,
+		basicAuthUser:  basicAuthUser,
+		basicAuthPass:  basicAuthPass,
+		datasourceURL:  strings.TrimSuffix(baseURL, "/"),
+		dataSourceType: TypePrometheus,
 	}
 }
 
+// BuildWithParams returns a copy of VMStorage, which passes
+// the given params with every request to datasource.
+func (s *VMStorage) BuildWithParams(params QuerierParams) Querier {
+	ns := *s
+	if params.DataSourceURL != "" {
+		ns.datasourceURL = strings.TrimSuffix(params.DataSourceURL, "/")
+	}
+	if params.DataSourceType != "" {
+		ns.dataSourceType = params.DataSourceType
+	}
+	ns.extraParams = params.QueryParams
+	ns.extraHeaders = params.Headers
+	return &ns
+}
+
 // Query reads metrics from datasource by given query
 func (s *VMStorage) Query(ctx context.Context, query string) ([]Metric, error) {
-	const (
-		statusSuccess, statusError, rtVector = "success", "error", "vector"
-	)
-	req, err := http.NewRequest("POST", s.queryURL+url.QueryEscape(query), nil)
-	if err != nil {
-		return nil, err
+	if s.dataSourceType == TypeGraphite {
+		now := time.Now()
+		return s.queryGraphite(ctx, query, now.Add(-graphiteLookback), now, true)
 	}
-	req.Header.Set("Content-Type", "application/json")
-	if s.basicAuthPass != "" {
-		req.SetBasicAuth(s.basicAuthUser, s.basicAuthPass)
+	return s.do(ctx, s.datasourceURL+queryPath+url.QueryEscape(query), rtVector)
+}
+
+// QueryRange reads metrics from datasource by given query on the [start...end] time range with the given step.
+// Every returned Metric represents a single data point, so a single time series may be returned as multiple Metrics.
+func (s *VMStorage) QueryRange(ctx context.Context, query string, start, end time.Time, step time.Duration) ([]Metric, error) {
+	if step <= 0 {
+		return nil, fmt.Errorf("step must be positive; got %s", step)
 	}
-	resp, err := s.c.Do(req.WithContext(ctx))
+	if end.Before(start) {
+		return nil, fmt.Errorf("end=%s can't be before start=%s", end.Format(time.RFC3339), start.Format(time.RFC3339))
+	}
+	if s.dataSourceType == TypeGraphite {
+		// Graphite has no step param - the data resolution
+		// is defined by the datasource.
+		return s.queryGraphite(ctx, query, start, end, false)
+	}
+	reqURL := fmt.Sprintf("%s%s%s&start=%d&end=%d&step=%ds", s.datasourceURL, queryRangePath, url.QueryEscape(query),
+		start.Unix(), end.Unix(), int64(step.Seconds()))
+	return s.do(ctx, reqURL, rtMatrix)
+}
+
+func (s *VMStorage) do(ctx context.Context, reqURL, resultType string) ([]Metric, error) {
+	resp, err := s.doRequest(ctx, reqURL)
 	if err != nil {
-		return nil, fmt.Errorf("error getting response from %s: %w", req.URL, err)
+		return nil, err
 	}
 	defer func() { _ = resp.Body.Close() }()
-	if resp.StatusCode != http.StatusOK {
-		body, _ := ioutil.ReadAll(resp.Body)
-		return nil, fmt.Errorf("datasource returns unexpected response code %d for %s with err %w. Reponse body %s", resp.StatusCode, req.URL, err, body)
-	}
 	r := &response{}
 	if err := json.NewDecoder(resp.Body).Decode(r); err != nil {
-		return nil, fmt.Errorf("error parsing metrics for %s: %w", req.URL, err)
+		return nil, fmt.Errorf("error parsing metrics for %s: %w", resp.Request.URL, err)
 	}
 	if r.Status == statusError {
-		return nil, fmt.Errorf("response error, query: %s, errorType: %s, error: %s", req.URL, r.ErrorType, r.Error)
+		return nil, fmt.Errorf("response error, query: %s, errorType: %s, error: %s", resp.Request.URL, r.ErrorType, r.Error)
 	}
 	if r.Status != statusSuccess {
 		return nil, fmt.Errorf("unknown status: %s, Expected success or error ", r.Status)
 	}
-	if r.Data.ResultType != rtVector {
-		return nil, fmt.Errorf("unknown restul type:%s. Expected vector", r.Data.ResultType)
+	if r.Data.ResultType != resultType {
+		return nil, fmt.Errorf("unknown restul type:%s. Expected %s", r.Data.ResultType, resultType)
 	}
 	return r.metrics()
 }
+
+// doRequest sends request to reqURL with extra params, headers and basic auth applied.
+// The caller must close the response body if error is nil.
+func (s *VMStorage) doRequest(ctx context.Context, reqURL string) (*http.Response, error) {
+	if len(s.extraParams) > 0 {
+		reqURL += "&" + s.extraParams.Encode()
+	}
+	req, err := http.NewRequest("POST", reqURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range s.extraHeaders {
+		req.Header.Set(k, v)
+	}
+	if s.basicAuthPass != "" {
+		req.SetBasicAuth(s.basicAuthUser, s.basicAuthPass)
+	}
+	resp, err := s.c.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("error getting response from %s: %w", req.URL, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		return nil, fmt.Errorf("datasource returns unexpected response code %d for %s with err %w. Reponse body %s", resp.StatusCode, req.URL, err, body)
+	}
+	return resp, nil
+}
@@ -4,7 +4,10 @@ import (
 	"context"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"reflect"
 	"testing"
+	"time"
 )
 
 var (
@@ -91,3 +94,130 @@ func TestVMSelectQuery(t *testing.T) {
 	}
 
 }
+
+func TestVMSelectQueryRange(t *testing.T) {
+	start, end := time.Unix(1583786000, 0), time.Unix(1583786120, 0)
+	mux := http.NewServeMux()
+	c := -1
+	mux.HandleFunc("/api/v1/query_range", func(w http.ResponseWriter, r *http.Request) {
+		c++
+		q := r.URL.Query()
+		if q.Get("query") != query {
+			t.Errorf("expected %s in query param, got %s", query, q.Get("query"))
+		}
+		if q.Get("start") != "1583786000" || q.Get("end") != "1583786120" || q.Get("step") != "60s" {
+			t.Errorf("unexpected range params: start=%q, end=%q, step=%q", q.Get("start"), q.Get("end"), q.Get("step"))
+		}
+		switch c {
+		case 0:
+			w.Write([]byte(`{"status":"success","data":{"resultType":"vector"}}`))
+		case 1:
+			w.Write([]byte(`{"status":"success","data":{"resultType":"matrix","result":[{"metric":{"__name__":"vm_rows"},"values":[[1583786000,"1"],[1583786060,"2"],[1583786120,"3"]]}]}}`))
+		}
+	})
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+	am := NewVMStorage(srv.URL, basicAuthName, basicAuthPass, srv.Client())
+	if _, err := am.QueryRange(ctx, query, start, end, 0); err == nil {
+		t.Fatalf("expected invalid step error got nil")
+	}
+	if _, err := am.QueryRange(ctx, query, end, start, time.Minute); err == nil {
+		t.Fatalf("expected invalid range error got nil")
+	}
+	if _, err := am.QueryRange(ctx, query, start, end, time.Minute); err == nil {
+		t.Fatalf("expected non-matrix resultType error got nil")
+	}
+	m, err := am.QueryRange(ctx, query, start, end, time.Minute)
+	if err != nil {
+		t.Fatalf("unexpected %s", err)
+	}
+	labels := []Label{{Name: "__name__", Value: "vm_rows"}}
+	expected := []Metric{
+		{Labels: labels, Timestamp: 1583786000, Value: 1},
+		{Labels: labels, Timestamp: 1583786060, Value: 2},
+		{Labels: labels, Timestamp: 1583786120, Value: 3},
+	}
+	if !reflect.DeepEqual(m, expected) {
+		t.Fatalf("unexpected metrics %+v want %+v", m, expected)
+	}
+}
+
+func TestVMSelectBuildWithParams(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/query", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("query") != query {
+			t.Errorf("expected %s in query param, got %s", query, q.Get("query"))
+		}
+		if q.Get("nocache") != "1" || q.Get("extra_label") != "env=prod" {
+			t.Errorf("expected extra params in %q", r.URL.RawQuery)
+		}
+		if h := r.Header.Get("X-Tenant"); h != "foo" {
+			t.Errorf("expected X-Tenant header foo; got %q", h)
+		}
+		w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[]}}`))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	s := NewVMStorage(srv.URL, basicAuthName, basicAuthPass, srv.Client())
+	q := s.BuildWithParams(QuerierParams{
+		QueryParams: url.Values{"nocache": {"1"}, "extra_label": {"env=prod"}},
+		Headers:     map[string]string{"X-Tenant": "foo"},
+	})
+	if _, err := q.Query(ctx, query); err != nil {
+		t.Fatalf("unexpected %s", err)
+	}
+	if len(s.extraParams) > 0 || len(s.extraHeaders) > 0 {
+		t.Fatalf("BuildWithParams mustn't change the original VMStorage")
+	}
+}
+
+func TestVMSelectGraphite(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(_ http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request to %s", r.URL)
+	})
+	mux.HandleFunc("/graphite/render", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("format") != "json" {
+			t.Errorf("expected json format; got %q", q.Get("format"))
+		}
+		if q.Get("target") != "foo.bar" {
+			t.Errorf("expected foo.bar in target param, got %s", q.Get("target"))
+		}
+		if q.Get("from") == "" || q.Get("until") == "" {
+			t.Errorf("expected from and until params in %q", r.URL.RawQuery)
+		}
+		w.Write([]byte(`[{"target":"foo.bar","datapoints":[[1,1583786000],[2,1583786060]]}]`))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	s := NewVMStorage("http://unreachable", basicAuthName, basicAuthPass, srv.Client())
+	q := s.BuildWithParams(QuerierParams{
+		DataSourceType: TypeGraphite,
+		DataSourceURL:  srv.URL + "/graphite/",
+	})
+	labels := []Label{{Name: "__name__", Value: "foo.bar"}}
+	m, err := q.Query(ctx, "foo.bar")
+	if err != nil {
+		t.Fatalf("unexpected %s", err)
+	}
+	expected := []Metric{{Labels: labels, Timestamp: 1583786060, Value: 2}}
+	if !reflect.DeepEqual(m, expected) {
+		t.Fatalf("unexpected metrics %+v want %+v", m, expected)
+	}
+	m, err = q.QueryRange(ctx, "foo.bar", time.Unix(1583786000, 0), time.Unix(1583786060, 0), time.Minute)
+	if err != nil {
+		t.Fatalf("unexpected %s", err)
+	}
+	expected = []Metric{
+		{Labels: labels, Timestamp: 1583786000, Value: 1},
+		{Labels: labels, Timestamp: 1583786060, Value: 2},
+	}
+	if !reflect.DeepEqual(m, expected) {
+		t.Fatalf("unexpected metrics %+v want %+v", m, expected)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"hash/fnv"
+	"net/url"
 	"sync"
 	"time"
 
@@ -25,6 +26,13 @@ type Group struct {
 	Interval    time.Duration
 	Concurrency int
 	Checksum    string
+	Limit       int
+	Params      url.Values
+	Headers     map[string]string
+	// Type and DatasourceURL override the type and url
+	// of the datasource if non-empty.
+	Type          datasource.Type
+	DatasourceURL string
 
 	doneCh     chan struct{}
 	finishedCh chan struct{}
@@ -50,14 +58,18 @@ func newGroupMetrics(name, file string) *groupMetrics {
 
 func newGroup(cfg config.Group, defaultInterval time.Duration, labels map[string]string) *Group {
 	g := &Group{
-		Name:        cfg.Name,
-		File:        cfg.File,
-		Interval:    cfg.Interval,
-		Concurrency: cfg.Concurrency,
-		Checksum:    cfg.Checksum,
-		doneCh:      make(chan struct{}),
-		finishedCh:  make(chan struct{}),
-		updateCh:    make(chan *Group),
+		Name:          cfg.Name,
+		File:          cfg.File,
+		Interval:      cfg.Interval,
+		Concurrency:   cfg.Concurrency,
+		Checksum:      cfg.Checksum,
+		Limit:         cfg.Limit,
+		Params:        cfg.Params,
+		Type:          datasource.Type(cfg.Type),
+		DatasourceURL: cfg.DatasourceURL,
+		doneCh:        make(chan struct{}),
+		finishedCh:    make(chan struct{}),
+		updateCh:      make(chan *Group),
 	}
 	g.metrics = newGroupMetrics(g.Name, g.File)
 	if g.Interval == 0 {
@@ -66,6 +78,12 @@ func newGroup(cfg config.Group, defaultInterval time.Duration, labels map[string
 	if g.Concurrency < 1 {
 		g.Concurrency = 1
 	}
+	if len(cfg.Headers) > 0 {
+		g.Headers = make(map[string]string, len(cfg.Headers))
+		for _, h := range cfg.Headers {
+			g.Headers[h.Key] = h.Value
+		}
+	}
 	rules := make([]Rule, len(cfg.Rules))
 	for i, r := range cfg.Rules {
 		// override rule labels with external labels
@@ -92,6 +110,21 @@ func (g *Group) newRule(rule config.Rule) Rule {
 	return newRecordingRule(g, rule)
 }
 
+// withParams returns q with the group datasource overrides,
+// params and headers applied if q supports them.
+func (g *Group) withParams(q datasource.Querier) datasource.Querier {
+	qb, ok := q.(datasource.QuerierBuilder)
+	if !ok || (len(g.Params) == 0 && len(g.Headers) == 0 && g.Type == "" && g.DatasourceURL == "") {
+		return q
+	}
+	return qb.BuildWithParams(datasource.QuerierParams{
+		DataSourceType: g.Type,
+		DataSourceURL:  g.DatasourceURL,
+		QueryParams:    g.Params,
+		Headers:        g.Headers,
+	})
+}
+
 // ID return unique group ID that consists of
 // rules file and group name
 func (g *Group) ID() uint64 {
@@ -159,6 +192,11 @@ func (g *Group) updateWith(newGroup *Group) error {
 	}
 	g.Concurrency = newGroup.Concurrency
 	g.Checksum = newGroup.Checksum
+	g.Limit = newGroup.Limit
+	g.Type = newGroup.Type
+	g.DatasourceURL = newGroup.DatasourceURL
+	g.Params = newGroup.Params
+	g.Headers = newGroup.Headers
 	g.Rules = newRules
 	return nil
 }
@@ -209,7 +247,7 @@ func (g *Group) start(ctx context.Context, querier datasource.Querier, nts []not
 	}
 
 	logger.Infof("group %q started; interval=%v; concurrency=%d", g.Name, g.Interval, g.Concurrency)
-	e := &executor{querier, nts, rw}
+	e := &executor{g.withParams(querier), nts, rw}
 	t := time.NewTicker(g.Interval)
 	defer t.Stop()
 	for {
@@ -233,13 +271,14 @@ func (g *Group) start(ctx context.Context, querier datasource.Querier, nts []not
 				t.Stop()
 				t = time.NewTicker(g.Interval)
 			}
+			e.querier = g.withParams(querier)
 			g.mu.Unlock()
 			logger.Infof("group %q re-started; interval=%v; concurrency=%d", g.Name, g.Interval, g.Concurrency)
 		case <-t.C:
 			g.metrics.iterationTotal.Inc()
 			iterationStart := time.Now()
 
-			errs := e.execConcurrently(ctx, g.Rules, g.Concurrency, g.Interval)
+			errs := e.execConcurrently(ctx, g.Rules, g.Concurrency, g.Interval, g.Limit)
 			for err := range errs {
 				if err != nil {
 					logger.Errorf("group %q: %s", g.Name, err)
@@ -257,7 +296,7 @@ type executor struct {
 	rw        *remotewrite.Client
 }
 
-func (e *executor) execConcurrently(ctx context.Context, rules []Rule, concurrency int, interval time.Duration) chan error {
+func (e *executor) execConcurrently(ctx context.Context, rules []Rule, concurrency int, interval time.Duration, limit int) chan error {
 	res := make(chan error, len(rules))
 	var returnSeries bool
 	if e.rw != nil {
@@ -267,7 +306,7 @@ func (e *executor) execConcurrently(ctx context.Context, rules []Rule, concurren
 	if concurrency == 1 {
 		// fast path
 		for _, rule := range rules {
-			res <- e.exec(ctx, rule, returnSeries, interval)
+			res <- e.exec(ctx, rule, returnSeries, interval, limit)
 		}
 		close(res)
 		return res
@@ -280,7 +319,7 @@ func (e *executor) execConcurrently(ctx context.Context, rules []Rule, concurren
 			sem <- struct{}{}
 			wg.Add(1)
 			go func(r Rule) {
-				res <- e.exec(ctx, r, returnSeries, interval)
+				res <- e.exec(ctx, r, returnSeries, interval, limit)
 				<-sem
 				wg.Done()
 			}(rule)
@@ -299,14 +338,14 @@ var (
 	remoteWriteErrors = metrics.NewCounter(`vmalert_remotewrite_errors_total`)
 )
 
-func (e *executor) exec(ctx context.Context, rule Rule, returnSeries bool, interval time.Duration) error {
+func (e *executor) exec(ctx context.Context, rule Rule, returnSeries bool, interval time.Duration, limit int) error {
 	execTotal.Inc()
 	execStart := time.Now()
 	defer func() {
 		execDuration.UpdateDuration(execStart)
 	}()
 
-	tss, err := rule.Exec(ctx, e.querier, returnSeries)
+	tss, err := rule.Exec(ctx, e.querier, returnSeries, limit)
 	if err != nil {
 		execErrors.Inc()
 		return fmt.Errorf("rule %q: failed to execute: %w", rule, err)
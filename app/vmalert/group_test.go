@@ -2,11 +2,14 @@ package main
 
 import (
 	"context"
+	"net/url"
+	"reflect"
 	"sort"
 	"testing"
 	"time"
 
 	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmalert/config"
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmalert/datasource"
 	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmalert/notifier"
 )
 
@@ -211,3 +214,33 @@ func TestGroupStart(t *testing.T) {
 	g.close()
 	<-finished
 }
+
+func TestGroupWithParams(t *testing.T) {
+	g := newGroup(config.Group{
+		Name:    "params",
+		Params:  url.Values{"nocache": {"1"}},
+		Headers: []config.Header{{Key: "X-Tenant", Value: "foo"}},
+	}, time.Minute, nil)
+	if !reflect.DeepEqual(g.Headers, map[string]string{"X-Tenant": "foo"}) {
+		t.Fatalf("unexpected group headers %v", g.Headers)
+	}
+
+	// queriers without params support must be returned as is
+	fq := &fakeQuerier{}
+	if q := g.withParams(fq); q != fq {
+		t.Fatalf("expected to get the same querier")
+	}
+
+	vms := datasource.NewVMStorage("http://localhost:8428", "", "", nil)
+	if q := g.withParams(vms); q == datasource.Querier(vms) {
+		t.Fatalf("expected to get a new querier with params applied")
+	}
+	g.Params, g.Headers = nil, nil
+	if q := g.withParams(vms); q != datasource.Querier(vms) {
+		t.Fatalf("expected to get the same querier for the group without params")
+	}
+	g.Type = datasource.TypeGraphite
+	if q := g.withParams(vms); q == datasource.Querier(vms) {
+		t.Fatalf("expected to get a new querier for the group with datasource type override")
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"sort"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmalert/datasource"
 	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmalert/notifier"
@@ -49,6 +50,22 @@ func (fq *fakeQuerier) Query(_ context.Context, _ string) ([]datasource.Metric,
 	return cp, nil
 }
 
+func (fq *fakeQuerier) QueryRange(_ context.Context, _ string, start, end time.Time, _ time.Duration) ([]datasource.Metric, error) {
+	fq.Lock()
+	defer fq.Unlock()
+	if fq.err != nil {
+		return nil, fq.err
+	}
+	var cp []datasource.Metric
+	for _, m := range fq.metrics {
+		if m.Timestamp < start.Unix() || m.Timestamp > end.Unix() {
+			continue
+		}
+		cp = append(cp, m)
+	}
+	return cp, nil
+}
+
 type fakeNotifier struct {
 	sync.Mutex
 	alerts []notifier.Alert
@@ -58,7 +58,19 @@ func main() {
 	logger.Init()
 	cgroup.UpdateGOMAXPROCSToCPUQuota()
 
+	if len(*ruleTests) > 0 {
+		os.Exit(runUnitTests(*ruleTests))
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
+	if *replayFrom != "" || *replayTo != "" {
+		if err := runReplay(ctx); err != nil {
+			logger.Fatalf("replay failed: %s", err)
+		}
+		cancel()
+		return
+	}
+
 	manager, err := newManager(ctx)
 	if err != nil {
 		logger.Fatalf("failed to init: %s", err)
@@ -130,7 +142,6 @@ func newManager(ctx context.Context) (*manager, error) {
 		groups:    make(map[uint64]*Group),
 		querier:   q,
 		notifiers: nts,
-		labels:    map[string]string{},
 	}
 	rw, err := remotewrite.Init(ctx)
 	if err != nil {
@@ -144,14 +155,23 @@ func newManager(ctx context.Context) (*manager, error) {
 	}
 	manager.rr = rr
 
-	for _, s := range *externalLabels {
+	manager.labels, err = parseExternalLabels(*externalLabels)
+	if err != nil {
+		return nil, err
+	}
+	return manager, nil
+}
+
+func parseExternalLabels(ss []string) (map[string]string, error) {
+	labels := make(map[string]string)
+	for _, s := range ss {
 		n := strings.IndexByte(s, '=')
 		if n < 0 {
 			return nil, fmt.Errorf("missing '=' in `-label`. It must contain label in the form `name=value`; got %q", s)
 		}
-		manager.labels[s[:n]] = s[n+1:]
+		labels[s[:n]] = s[n+1:]
 	}
-	return manager, nil
+	return labels, nil
 }
 
 func getExternalURL(externalURL, httpListenAddr string, isSecure bool) (*url.URL, error) {
@@ -145,6 +145,8 @@ func (g *Group) toAPI() APIGroup {
 		File:        g.File,
 		Interval:    g.Interval.String(),
 		Concurrency: g.Concurrency,
+		Limit:       g.Limit,
+		Type:        string(g.Type),
 	}
 	for _, r := range g.Rules {
 		switch v := r.(type) {
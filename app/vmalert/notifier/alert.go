@@ -25,6 +25,10 @@ type Alert struct {
 	End   time.Time
 	Value float64
 	ID    uint64
+	// KeepFiringSince is the time when the firing alert
+	// stopped being returned by the rule expression.
+	// It is zero while the alert is returned.
+	KeepFiringSince time.Time
 }
 
 // AlertState type indicates the Alert state
@@ -15,6 +15,10 @@ type AlertManager struct {
 	alertURL      string
 	basicAuthUser string
 	basicAuthPass string
+	// authorization is an optional `Authorization` header value.
+	// It is set for notifiers from `-notifier.config` file.
+	authorization string
+	headers       map[string]string
 	argFunc       AlertURLGenerator
 	client        *http.Client
 }
@@ -30,6 +34,12 @@ func (am *AlertManager) Send(ctx context.Context, alerts []Alert) error {
 	}
 	req.Header.Set("Content-Type", "application/json")
 	req = req.WithContext(ctx)
+	for k, v := range am.headers {
+		req.Header.Set(k, v)
+	}
+	if am.authorization != "" {
+		req.Header.Set("Authorization", am.authorization)
+	}
 	if am.basicAuthPass != "" {
 		req.SetBasicAuth(am.basicAuthUser, am.basicAuthPass)
 	}
@@ -0,0 +1,184 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/envtemplate"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promauth"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/prompbmarshal"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promrelabel"
+	"gopkg.in/yaml.v2"
+)
+
+// Config contains notifiers configuration loaded from `-notifier.config` file.
+type Config struct {
+	Notifiers []NotifierConfig `yaml:"notifiers"`
+}
+
+// NotifierConfig contains configuration for a single notifier.
+type NotifierConfig struct {
+	// Type of the notifier. Supported types are `alertmanager` and `webhook`.
+	// `alertmanager` is used if empty.
+	Type string `yaml:"type,omitempty"`
+	// URL of Alertmanager or the full URL of webhook endpoint.
+	URL string `yaml:"url"`
+
+	BasicAuth       *promauth.BasicAuthConfig `yaml:"basic_auth,omitempty"`
+	BearerToken     string                    `yaml:"bearer_token,omitempty"`
+	BearerTokenFile string                    `yaml:"bearer_token_file,omitempty"`
+	TLSConfig       *promauth.TLSConfig       `yaml:"tls_config,omitempty"`
+
+	// Timeout for sending alerts to the notifier.
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+	// Headers are added to every request to the notifier.
+	// Headers of `webhook` notifier may contain templates.
+	Headers map[string]string `yaml:"headers,omitempty"`
+	// BodyTemplate is a template for `webhook` request body.
+	BodyTemplate string `yaml:"body_template,omitempty"`
+	// AlertRelabelConfigs are applied to alert labels before sending.
+	AlertRelabelConfigs []promrelabel.RelabelConfig `yaml:"alert_relabel_configs,omitempty"`
+}
+
+const (
+	typeAlertManager = "alertmanager"
+	typeWebhook      = "webhook"
+
+	defaultNotifierTimeout = 10 * time.Second
+)
+
+// parseConfig reads notifiers configuration from the file at path.
+func parseConfig(path string) (*Config, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read notifiers config: %w", err)
+	}
+	data = envtemplate.Replace(data)
+	var cfg Config
+	if err := yaml.UnmarshalStrict(data, &cfg); err != nil {
+		return nil, fmt.Errorf("cannot parse notifiers config %q: %w", path, err)
+	}
+	return &cfg, nil
+}
+
+// newNotifiersFromConfig creates notifiers from the `-notifier.config` file at path.
+//
+// Relative paths to auth and TLS files in the config are resolved from the config file directory.
+func newNotifiersFromConfig(path string, gen AlertURLGenerator) ([]Notifier, error) {
+	cfg, err := parseConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	baseDir := filepath.Dir(path)
+	var notifiers []Notifier
+	for i := range cfg.Notifiers {
+		nt, err := cfg.Notifiers[i].newNotifier(baseDir, gen)
+		if err != nil {
+			return nil, fmt.Errorf("notifier #%d in %q: %w", i+1, path, err)
+		}
+		notifiers = append(notifiers, nt)
+	}
+	return notifiers, nil
+}
+
+func (nc *NotifierConfig) newNotifier(baseDir string, gen AlertURLGenerator) (Notifier, error) {
+	if nc.URL == "" {
+		return nil, fmt.Errorf("`url` can't be empty")
+	}
+	if !strings.HasPrefix(nc.URL, "http://") && !strings.HasPrefix(nc.URL, "https://") {
+		return nil, fmt.Errorf("`url` must start with http:// or https://; got %q", nc.URL)
+	}
+	ac, err := promauth.NewConfig(baseDir, nc.BasicAuth, nc.BearerToken, nc.BearerTokenFile, nc.TLSConfig)
+	if err != nil {
+		return nil, fmt.Errorf("cannot initialize auth config: %w", err)
+	}
+	tr := http.DefaultTransport.(*http.Transport).Clone()
+	tr.TLSClientConfig = ac.NewTLSConfig()
+	timeout := nc.Timeout
+	if timeout <= 0 {
+		timeout = defaultNotifierTimeout
+	}
+	c := &http.Client{
+		Transport: tr,
+		Timeout:   timeout,
+	}
+
+	var nt Notifier
+	switch nc.Type {
+	case "", typeAlertManager:
+		if nc.BodyTemplate != "" {
+			return nil, fmt.Errorf("`body_template` is supported only by %q notifier", typeWebhook)
+		}
+		am := NewAlertManager(nc.URL, "", "", gen, c)
+		am.authorization = ac.Authorization
+		am.headers = nc.Headers
+		nt = am
+	case typeWebhook:
+		wh, err := NewWebhook(nc.URL, ac.Authorization, nc.BodyTemplate, nc.Headers, gen, c)
+		if err != nil {
+			return nil, err
+		}
+		nt = wh
+	default:
+		return nil, fmt.Errorf("unknown notifier type %q; supported types: %q, %q", nc.Type, typeAlertManager, typeWebhook)
+	}
+
+	if len(nc.AlertRelabelConfigs) == 0 {
+		return nt, nil
+	}
+	prcs, err := promrelabel.ParseRelabelConfigs(nil, nc.AlertRelabelConfigs)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse `alert_relabel_configs`: %w", err)
+	}
+	return &relabelNotifier{nt: nt, relabelConfigs: prcs}, nil
+}
+
+// relabelNotifier applies relabeling to alert labels
+// before sending alerts to the wrapped Notifier.
+type relabelNotifier struct {
+	nt             Notifier
+	relabelConfigs []promrelabel.ParsedRelabelConfig
+}
+
+// Send relabels alerts and sends them to the wrapped Notifier.
+//
+// Alerts with all the labels removed by relabeling are dropped.
+// `alertname` label is available during relabeling and may be used
+// for changing the alert name.
+func (rn *relabelNotifier) Send(ctx context.Context, alerts []Alert) error {
+	relabeled := make([]Alert, 0, len(alerts))
+	var labels []prompbmarshal.Label
+	for _, a := range alerts {
+		labels = labels[:0]
+		labels = append(labels, prompbmarshal.Label{Name: "alertname", Value: a.Name})
+		for k, v := range a.Labels {
+			if k == "alertname" {
+				continue
+			}
+			labels = append(labels, prompbmarshal.Label{Name: k, Value: v})
+		}
+		labels = promrelabel.ApplyRelabelConfigs(labels, 0, rn.relabelConfigs, false)
+		if len(labels) == 0 {
+			continue
+		}
+		// alert labels may be shared with other notifiers, so do not modify them
+		a.Labels = make(map[string]string, len(labels))
+		for _, l := range labels {
+			if l.Name == "alertname" {
+				a.Name = l.Value
+				continue
+			}
+			a.Labels[l.Name] = l.Value
+		}
+		relabeled = append(relabeled, a)
+	}
+	if len(relabeled) == 0 {
+		return nil
+	}
+	return rn.nt.Send(ctx, relabeled)
+}
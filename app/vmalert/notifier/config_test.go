@@ -0,0 +1,122 @@
+package notifier
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promrelabel"
+)
+
+func TestNewNotifiersFromConfig(t *testing.T) {
+	gen := func(Alert) string { return "" }
+	nts, err := newNotifiersFromConfig("testdata/notifiers-good.yaml", gen)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(nts) != 2 {
+		t.Fatalf("expected 2 notifiers; got %d", len(nts))
+	}
+	am, ok := nts[0].(*AlertManager)
+	if !ok {
+		t.Fatalf("expected *AlertManager; got %T", nts[0])
+	}
+	if am.alertURL != "http://localhost:9093"+alertManagerPath {
+		t.Fatalf("unexpected alertmanager url %q", am.alertURL)
+	}
+	if am.authorization != "Basic Zm9vOmJhcg==" {
+		t.Fatalf("unexpected alertmanager authorization %q", am.authorization)
+	}
+	if am.client.Timeout.String() != "5s" {
+		t.Fatalf("unexpected alertmanager timeout %s", am.client.Timeout)
+	}
+	rn, ok := nts[1].(*relabelNotifier)
+	if !ok {
+		t.Fatalf("expected *relabelNotifier; got %T", nts[1])
+	}
+	wh, ok := rn.nt.(*Webhook)
+	if !ok {
+		t.Fatalf("expected *Webhook; got %T", rn.nt)
+	}
+	if wh.authorization != "Bearer secret" {
+		t.Fatalf("unexpected webhook authorization %q", wh.authorization)
+	}
+	if wh.client.Timeout != defaultNotifierTimeout {
+		t.Fatalf("unexpected webhook timeout %s", wh.client.Timeout)
+	}
+	if wh.body == nil || len(wh.headers) != 1 {
+		t.Fatalf("expected body and header templates to be set")
+	}
+
+	for _, path := range []string{
+		"testdata/notifiers-bad-type.yaml",
+		"testdata/notifiers-bad-body.yaml",
+		"testdata/notifiers-bad-template.yaml",
+		"testdata/notifiers-bad-relabel.yaml",
+		"testdata/notifiers-bad-url.yaml",
+		"testdata/notifiers-bad-field.yaml",
+		"testdata/missing.yaml",
+	} {
+		if _, err := newNotifiersFromConfig(path, gen); err == nil {
+			t.Fatalf("expected non-nil error for %q", path)
+		}
+	}
+}
+
+type fakeNotifier struct {
+	alerts []Alert
+}
+
+func (fn *fakeNotifier) Send(_ context.Context, alerts []Alert) error {
+	fn.alerts = append(fn.alerts, alerts...)
+	return nil
+}
+
+func TestRelabelNotifier_Send(t *testing.T) {
+	var rcs []promrelabel.RelabelConfig
+	s := func(v string) *string { return &v }
+	rcs = append(rcs,
+		promrelabel.RelabelConfig{SourceLabels: []string{"severity"}, Regex: s("debug"), Action: "drop"},
+		promrelabel.RelabelConfig{TargetLabel: "team", Replacement: s("sre")},
+		promrelabel.RelabelConfig{SourceLabels: []string{"alertname"}, TargetLabel: "alertname", Replacement: s("prefix_$1")},
+	)
+	prcs, err := promrelabel.ParseRelabelConfigs(nil, rcs)
+	if err != nil {
+		t.Fatalf("cannot parse relabel configs: %s", err)
+	}
+	fn := &fakeNotifier{}
+	rn := &relabelNotifier{nt: fn, relabelConfigs: prcs}
+
+	labels := map[string]string{"severity": "page"}
+	alerts := []Alert{
+		{Name: "foo", Labels: labels},
+		{Name: "bar", Labels: map[string]string{"severity": "debug"}},
+	}
+	if err := rn.Send(context.Background(), alerts); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(fn.alerts) != 1 {
+		t.Fatalf("expected 1 alert to be sent; got %d", len(fn.alerts))
+	}
+	a := fn.alerts[0]
+	if a.Name != "prefix_foo" {
+		t.Fatalf("unexpected alert name %q", a.Name)
+	}
+	expLabels := map[string]string{"severity": "page", "team": "sre"}
+	if !reflect.DeepEqual(a.Labels, expLabels) {
+		t.Fatalf("unexpected alert labels; got %v; want %v", a.Labels, expLabels)
+	}
+	// original labels must remain unchanged
+	if !reflect.DeepEqual(labels, map[string]string{"severity": "page"}) {
+		t.Fatalf("original alert labels were modified: %v", labels)
+	}
+
+	// nothing must be sent if all the alerts are dropped
+	fn.alerts = nil
+	if err := rn.Send(context.Background(), alerts[1:]); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if fn.alerts != nil {
+		t.Fatalf("expected no alerts to be sent; got %v", fn.alerts)
+	}
+}
@@ -10,7 +10,7 @@ import (
 )
 
 var (
-	addrs             = flagutil.NewArray("notifier.url", "Prometheus alertmanager URL. Required parameter. e.g. http://127.0.0.1:9093")
+	addrs             = flagutil.NewArray("notifier.url", "Prometheus alertmanager URL, e.g. http://127.0.0.1:9093. Required parameter if -notifier.config isn't set")
 	basicAuthUsername = flagutil.NewArray("notifier.basicAuth.username", "Optional basic auth username for -datasource.url")
 	basicAuthPassword = flagutil.NewArray("notifier.basicAuth.password", "Optional basic auth password for -datasource.url")
 
@@ -21,13 +21,19 @@ var (
 		"By default system CA is used")
 	tlsServerName = flagutil.NewArray("notifier.tlsServerName", "Optional TLS server name to use for connections to -notifier.url. "+
 		"By default the server name from -notifier.url is used")
+
+	configPath = flag.String("notifier.config", "", "Optional path to YAML file with notifiers configuration. "+
+		"It allows configuring Alertmanager and webhook notifiers with per-notifier auth, timeouts, headers and alerts relabeling. "+
+		"Notifiers from the file are used in addition to -notifier.url. "+
+		"See https://github.com/VictoriaMetrics/VictoriaMetrics/blob/master/app/vmalert/README.md#notifiers-configuration")
 )
 
-// Init creates a Notifier object based on provided flags.
+// Init creates Notifier objects based on provided flags
+// and `-notifier.config` file.
 func Init(gen AlertURLGenerator) ([]Notifier, error) {
-	if len(*addrs) == 0 {
+	if len(*addrs) == 0 && *configPath == "" {
 		flag.PrintDefaults()
-		return nil, fmt.Errorf("at least one `-notifier.url` must be set")
+		return nil, fmt.Errorf("at least one `-notifier.url` or `-notifier.config` must be set")
 	}
 
 	var notifiers []Notifier
@@ -43,5 +49,15 @@ func Init(gen AlertURLGenerator) ([]Notifier, error) {
 		notifiers = append(notifiers, am)
 	}
 
+	if *configPath != "" {
+		nts, err := newNotifiersFromConfig(*configPath, gen)
+		if err != nil {
+			return nil, fmt.Errorf("failed to init notifiers from `-notifier.config`: %w", err)
+		}
+		if len(nts) == 0 {
+			return nil, fmt.Errorf("no notifiers found in `-notifier.config`=%q", *configPath)
+		}
+		notifiers = append(notifiers, nts...)
+	}
 	return notifiers, nil
 }
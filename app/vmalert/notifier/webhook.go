@@ -0,0 +1,147 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"text/template"
+	"time"
+)
+
+// Webhook represents integration provider with arbitrary HTTP endpoint,
+// which accepts alerts in JSON format.
+//
+// The request body and headers may be customized via templates.
+type Webhook struct {
+	url           string
+	authorization string
+	headers       map[string]*template.Template
+	body          *template.Template
+	argFunc       AlertURLGenerator
+	client        *http.Client
+}
+
+// WebhookAlert is an alert representation passed to Webhook templates
+// and sent by Webhook in the default request body.
+type WebhookAlert struct {
+	Name         string            `json:"name"`
+	Status       string            `json:"status"`
+	Labels       map[string]string `json:"labels"`
+	Annotations  map[string]string `json:"annotations"`
+	Value        float64           `json:"value"`
+	StartsAt     time.Time         `json:"startsAt"`
+	EndsAt       time.Time         `json:"endsAt"`
+	GeneratorURL string            `json:"generatorURL"`
+}
+
+// WebhookData is the data passed to Webhook templates.
+type WebhookData struct {
+	Alerts []WebhookAlert `json:"alerts"`
+}
+
+// NewWebhook is a constructor for Webhook.
+//
+// bodyTemplate and headers values may contain Go templates, which are executed with WebhookData.
+// Alerts are sent as JSON-encoded WebhookData if bodyTemplate is empty.
+func NewWebhook(url, authorization, bodyTemplate string, headers map[string]string, fn AlertURLGenerator, c *http.Client) (*Webhook, error) {
+	wh := &Webhook{
+		url:           url,
+		authorization: authorization,
+		headers:       make(map[string]*template.Template, len(headers)),
+		argFunc:       fn,
+		client:        c,
+	}
+	if bodyTemplate != "" {
+		tpl, err := newWebhookTemplate(bodyTemplate)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse body template: %w", err)
+		}
+		wh.body = tpl
+	}
+	for k, v := range headers {
+		tpl, err := newWebhookTemplate(v)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse template for header %q: %w", k, err)
+		}
+		wh.headers[k] = tpl
+	}
+	return wh, nil
+}
+
+func newWebhookTemplate(text string) (*template.Template, error) {
+	funcs := template.FuncMap{
+		"toJson": func(v interface{}) (string, error) {
+			b, err := json.Marshal(v)
+			return string(b), err
+		},
+	}
+	return template.New("").Funcs(tmplFunc).Funcs(funcs).Option("missingkey=zero").Parse(text)
+}
+
+// Send sends alerts to the webhook
+func (wh *Webhook) Send(ctx context.Context, alerts []Alert) error {
+	data := WebhookData{
+		Alerts: make([]WebhookAlert, len(alerts)),
+	}
+	for i, a := range alerts {
+		status := "firing"
+		if a.State == StateInactive {
+			status = "resolved"
+		}
+		data.Alerts[i] = WebhookAlert{
+			Name:         a.Name,
+			Status:       status,
+			Labels:       a.Labels,
+			Annotations:  a.Annotations,
+			Value:        a.Value,
+			StartsAt:     a.Start,
+			EndsAt:       a.End,
+			GeneratorURL: wh.argFunc(a),
+		}
+	}
+
+	b := &bytes.Buffer{}
+	if wh.body != nil {
+		if err := wh.body.Execute(b, data); err != nil {
+			return fmt.Errorf("cannot execute body template: %w", err)
+		}
+	} else if err := json.NewEncoder(b).Encode(data); err != nil {
+		return fmt.Errorf("cannot marshal alerts: %w", err)
+	}
+
+	req, err := http.NewRequest("POST", wh.url, b)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	var hb bytes.Buffer
+	for k, tpl := range wh.headers {
+		hb.Reset()
+		if err := tpl.Execute(&hb, data); err != nil {
+			return fmt.Errorf("cannot execute template for header %q: %w", k, err)
+		}
+		req.Header.Set(k, hb.String())
+	}
+	if wh.authorization != "" {
+		req.Header.Set("Authorization", wh.authorization)
+	}
+	req = req.WithContext(ctx)
+	resp, err := wh.client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return fmt.Errorf("failed to read response from %q: %w", wh.url, err)
+		}
+		return fmt.Errorf("invalid SC %d from %q; response body: %s", resp.StatusCode, wh.url, string(body))
+	}
+	return nil
+}
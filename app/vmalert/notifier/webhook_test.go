@@ -0,0 +1,117 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestWebhook_Send(t *testing.T) {
+	var body []byte
+	var header http.Header
+	status := http.StatusOK
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("expected POST method got %s", r.Method)
+		}
+		var err error
+		body, err = ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("cannot read request body: %s", err)
+		}
+		header = r.Header
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	gen := func(a Alert) string { return "http://vmalert/" + a.Name }
+	start := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	alerts := []Alert{
+		{
+			Name:        "foo",
+			State:       StateFiring,
+			Labels:      map[string]string{"severity": "page"},
+			Annotations: map[string]string{"summary": "foo is firing"},
+			Value:       42,
+			Start:       start,
+		},
+		{
+			Name:  "bar",
+			State: StateInactive,
+			Start: start,
+			End:   start.Add(time.Minute),
+		},
+	}
+
+	// default body
+	wh, err := NewWebhook(srv.URL, "Bearer secret", "", nil, gen, srv.Client())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := wh.Send(context.Background(), alerts); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if auth := header.Get("Authorization"); auth != "Bearer secret" {
+		t.Fatalf("unexpected Authorization header %q", auth)
+	}
+	var data WebhookData
+	if err := json.Unmarshal(body, &data); err != nil {
+		t.Fatalf("cannot unmarshal request body %q: %s", body, err)
+	}
+	if len(data.Alerts) != 2 {
+		t.Fatalf("expected 2 alerts; got %d", len(data.Alerts))
+	}
+	a := data.Alerts[0]
+	if a.Name != "foo" || a.Status != "firing" || a.Value != 42 || a.Labels["severity"] != "page" ||
+		a.Annotations["summary"] != "foo is firing" || !a.StartsAt.Equal(start) || a.GeneratorURL != "http://vmalert/foo" {
+		t.Fatalf("unexpected alert %+v", a)
+	}
+	if a := data.Alerts[1]; a.Status != "resolved" || !a.EndsAt.Equal(start.Add(time.Minute)) {
+		t.Fatalf("unexpected resolved alert %+v", a)
+	}
+
+	// templated body and headers
+	wh, err = NewWebhook(srv.URL, "", `{"text": "{{ range .Alerts }}{{ .Name }}={{ .Status }};{{ end }}"}`,
+		map[string]string{"X-Alerts-Count": "{{ len .Alerts }}"}, gen, srv.Client())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := wh.Send(context.Background(), alerts); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if exp := `{"text": "foo=firing;bar=resolved;"}`; string(body) != exp {
+		t.Fatalf("unexpected body; got %q; want %q", body, exp)
+	}
+	if h := header.Get("X-Alerts-Count"); h != "2" {
+		t.Fatalf("unexpected X-Alerts-Count header %q", h)
+	}
+
+	// toJson template function
+	wh, err = NewWebhook(srv.URL, "", `{{ toJson (index .Alerts 0).Labels }}`, nil, gen, srv.Client())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := wh.Send(context.Background(), alerts); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if exp := `{"severity":"page"}`; string(body) != exp {
+		t.Fatalf("unexpected body; got %q; want %q", body, exp)
+	}
+
+	// non-2xx response code
+	status = http.StatusBadRequest
+	if err := wh.Send(context.Background(), alerts); err == nil {
+		t.Fatalf("expected error for non-2xx response code")
+	}
+
+	if _, err := NewWebhook(srv.URL, "", "{{ .Alerts ", nil, gen, srv.Client()); err == nil {
+		t.Fatalf("expected error for invalid body template")
+	}
+	if _, err := NewWebhook(srv.URL, "", "", map[string]string{"X-Foo": "{{ "}, gen, srv.Client()); err == nil {
+		t.Fatalf("expected error for invalid header template")
+	}
+}
@@ -82,7 +82,7 @@ func (rr *RecordingRule) Close() {
 var errDuplicate = errors.New("result contains metrics with the same labelset after applying rule labels")
 
 // Exec executes RecordingRule expression via the given Querier.
-func (rr *RecordingRule) Exec(ctx context.Context, q datasource.Querier, series bool) ([]prompbmarshal.TimeSeries, error) {
+func (rr *RecordingRule) Exec(ctx context.Context, q datasource.Querier, series bool, limit int) ([]prompbmarshal.TimeSeries, error) {
 	if !series {
 		return nil, nil
 	}
@@ -98,6 +98,11 @@ func (rr *RecordingRule) Exec(ctx context.Context, q datasource.Querier, series
 		return nil, fmt.Errorf("failed to execute query %q: %w", rr.Expr, err)
 	}
 
+	if limit > 0 && len(qMetrics) > limit {
+		rr.lastExecError = fmt.Errorf("exec exceeded limit of %d with %d series", limit, len(qMetrics))
+		return nil, rr.lastExecError
+	}
+
 	duplicates := make(map[uint64]prompbmarshal.TimeSeries, len(qMetrics))
 	var tss []prompbmarshal.TimeSeries
 	for _, r := range qMetrics {
@@ -113,6 +118,44 @@ func (rr *RecordingRule) Exec(ctx context.Context, q datasource.Querier, series
 	return tss, nil
 }
 
+// ExecRange executes RecordingRule expression on the [start...end] time range via the given Querier.
+// Data points of the same series are merged into a single TimeSeries.
+func (rr *RecordingRule) ExecRange(ctx context.Context, q datasource.Querier, start, end time.Time, step time.Duration, limit int) ([]prompbmarshal.TimeSeries, error) {
+	qMetrics, err := q.QueryRange(ctx, rr.Expr, start, end, step)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query %q: %w", rr.Expr, err)
+	}
+
+	type point struct {
+		h         uint64
+		timestamp int64
+	}
+	duplicates := make(map[point]struct{}, len(qMetrics))
+	series := make(map[uint64]int)
+	perStep := make(map[int64]int)
+	var tss []prompbmarshal.TimeSeries
+	for _, r := range qMetrics {
+		perStep[r.Timestamp]++
+		if limit > 0 && perStep[r.Timestamp] > limit {
+			return nil, fmt.Errorf("exec exceeded limit of %d series at %s", limit, time.Unix(r.Timestamp, 0).Format(time.RFC3339))
+		}
+		ts := rr.toTimeSeries(r, time.Unix(r.Timestamp, 0))
+		h := hashTimeSeries(ts)
+		p := point{h: h, timestamp: r.Timestamp}
+		if _, ok := duplicates[p]; ok {
+			return nil, errDuplicate
+		}
+		duplicates[p] = struct{}{}
+		if idx, ok := series[h]; ok {
+			tss[idx].Samples = append(tss[idx].Samples, ts.Samples...)
+			continue
+		}
+		series[h] = len(tss)
+		tss = append(tss, ts)
+	}
+	return tss, nil
+}
+
 func hashTimeSeries(ts prompbmarshal.TimeSeries) uint64 {
 	hash := fnv.New64a()
 	labels := ts.Labels
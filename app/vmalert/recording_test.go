@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"errors"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
@@ -76,7 +77,7 @@ func TestRecoridngRule_ToTimeSeries(t *testing.T) {
 		t.Run(tc.rule.Name, func(t *testing.T) {
 			fq := &fakeQuerier{}
 			fq.add(tc.metrics...)
-			tss, err := tc.rule.Exec(context.TODO(), fq, true)
+			tss, err := tc.rule.Exec(context.TODO(), fq, true, 0)
 			if err != nil {
 				t.Fatalf("unexpected Exec err: %s", err)
 			}
@@ -96,7 +97,7 @@ func TestRecoridngRule_ToTimeSeriesNegative(t *testing.T) {
 	expErr := "connection reset by peer"
 	fq.setErr(errors.New(expErr))
 
-	_, err := rr.Exec(context.TODO(), fq, true)
+	_, err := rr.Exec(context.TODO(), fq, true, 0)
 	if err == nil {
 		t.Fatalf("expected to get err; got nil")
 	}
@@ -111,7 +112,7 @@ func TestRecoridngRule_ToTimeSeriesNegative(t *testing.T) {
 	fq.add(metricWithValueAndLabels(t, 1, "__name__", "foo", "job", "foo"))
 	fq.add(metricWithValueAndLabels(t, 2, "__name__", "foo", "job", "bar"))
 
-	_, err = rr.Exec(context.TODO(), fq, true)
+	_, err = rr.Exec(context.TODO(), fq, true, 0)
 	if err == nil {
 		t.Fatalf("expected to get err; got nil")
 	}
@@ -119,3 +120,78 @@ func TestRecoridngRule_ToTimeSeriesNegative(t *testing.T) {
 		t.Fatalf("expected to get err %q; got %q insterad", errDuplicate, err)
 	}
 }
+
+func TestRecordingRule_ExecRange(t *testing.T) {
+	start := time.Unix(1000, 0)
+	rr := &RecordingRule{Name: "job:foo", Labels: map[string]string{
+		"source": "test",
+	}}
+	fq := &fakeQuerier{}
+	for i, v := range []float64{1, 2, 3} {
+		m := metricWithValueAndLabels(t, v, "__name__", "foo", "job", "foo")
+		m.Timestamp = start.Unix() + int64(i*60)
+		fq.add(m)
+	}
+	m := metricWithValueAndLabels(t, 10, "__name__", "foo", "job", "bar")
+	m.Timestamp = start.Unix()
+	fq.add(m)
+
+	tss, err := rr.ExecRange(context.TODO(), fq, start, start.Add(2*time.Minute), time.Minute, 0)
+	if err != nil {
+		t.Fatalf("unexpected ExecRange err: %s", err)
+	}
+	expTS := []prompbmarshal.TimeSeries{
+		{
+			Labels: []prompbmarshal.Label{
+				{Name: "__name__", Value: "job:foo"},
+				{Name: "job", Value: "foo"},
+				{Name: "source", Value: "test"},
+			},
+			Samples: []prompbmarshal.Sample{
+				{Value: 1, Timestamp: 1000e3},
+				{Value: 2, Timestamp: 1060e3},
+				{Value: 3, Timestamp: 1120e3},
+			},
+		},
+		{
+			Labels: []prompbmarshal.Label{
+				{Name: "__name__", Value: "job:foo"},
+				{Name: "job", Value: "bar"},
+				{Name: "source", Value: "test"},
+			},
+			Samples: []prompbmarshal.Sample{
+				{Value: 10, Timestamp: 1000e3},
+			},
+		},
+	}
+	if !reflect.DeepEqual(tss, expTS) {
+		t.Fatalf("unexpected time series;\ngot\n%+v\nwant\n%+v", tss, expTS)
+	}
+
+	// data points which differ only by `job` label
+	// must result in duplicates error
+	rr.Labels["job"] = "test"
+	if _, err := rr.ExecRange(context.TODO(), fq, start, start.Add(2*time.Minute), time.Minute, 0); err != errDuplicate {
+		t.Fatalf("expected to get err %q; got %v instead", errDuplicate, err)
+	}
+}
+
+func TestRecordingRule_ExecLimit(t *testing.T) {
+	rr := &RecordingRule{Name: "job:foo"}
+	fq := &fakeQuerier{}
+	fq.add(metricWithValueAndLabels(t, 1, "__name__", "foo", "job", "foo"))
+	fq.add(metricWithValueAndLabels(t, 2, "__name__", "foo", "job", "bar"))
+	if _, err := rr.Exec(context.TODO(), fq, true, 2); err != nil {
+		t.Fatalf("unexpected err: %s", err)
+	}
+	_, err := rr.Exec(context.TODO(), fq, true, 1)
+	if err == nil {
+		t.Fatalf("expected to get limit exceeded err; got nil")
+	}
+	if !strings.Contains(err.Error(), "exceeded limit of 1 with 2 series") {
+		t.Fatalf("unexpected err: %s", err)
+	}
+	if _, err := rr.ExecRange(context.TODO(), fq, time.Unix(0, 0), time.Unix(60, 0), time.Minute, 1); err == nil {
+		t.Fatalf("expected to get limit exceeded err for ExecRange; got nil")
+	}
+}
@@ -3,11 +3,13 @@ package remotewrite
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logger"
@@ -19,6 +21,14 @@ import (
 // Client is an asynchronous HTTP client for writing
 // timeseries via remote write protocol.
 type Client struct {
+	// WARN: ordering of fields is important for alignment!
+	// see https://golang.org/pkg/sync/atomic/#pkg-note-BUG
+
+	// pending is the number of pushed timeseries, which weren't sent or dropped yet.
+	pending int64
+	// dropped is the number of timeseries dropped since the last Flush call.
+	dropped uint64
+
 	addr           string
 	c              *http.Client
 	input          chan prompbmarshal.TimeSeries
@@ -26,9 +36,12 @@ type Client struct {
 	flushInterval  time.Duration
 	maxBatchSize   int
 	maxQueueSize   int
+	// retryInterval is the interval between attempts to send a batch.
+	retryInterval time.Duration
 
-	wg     sync.WaitGroup
-	doneCh chan struct{}
+	wg      sync.WaitGroup
+	doneCh  chan struct{}
+	flushCh chan struct{}
 }
 
 // Config is config for remote write.
@@ -100,7 +113,9 @@ func NewClient(ctx context.Context, cfg Config) (*Client, error) {
 		flushInterval: cfg.FlushInterval,
 		maxBatchSize:  cfg.MaxBatchSize,
 		maxQueueSize:  cfg.MaxQueueSize,
+		retryInterval: time.Second,
 		doneCh:        make(chan struct{}),
+		flushCh:       make(chan struct{}),
 		input:         make(chan prompbmarshal.TimeSeries, cfg.MaxQueueSize),
 	}
 	cc := defaultConcurrency
@@ -113,21 +128,64 @@ func NewClient(ctx context.Context, cfg Config) (*Client, error) {
 	return c, nil
 }
 
+// ErrQueueFull is returned by Client.Push if the queue is full.
+//
+// The caller may retry the Push later, when the queue is drained.
+var ErrQueueFull = errors.New("queue is full")
+
 // Push adds timeseries into queue for writing into remote storage.
 // Push returns and error if client is stopped or if queue is full.
+// The error wraps ErrQueueFull in the latter case.
 func (c *Client) Push(s prompbmarshal.TimeSeries) error {
 	select {
 	case <-c.doneCh:
 		return fmt.Errorf("client is closed")
+	default:
+	}
+	// pending must be incremented before the timeseries becomes visible to readers,
+	// since they decrement it after sending the timeseries.
+	atomic.AddInt64(&c.pending, 1)
+	select {
 	case c.input <- s:
 		return nil
 	default:
-		return fmt.Errorf("failed to push timeseries - queue is full (%d entries). "+
+		atomic.AddInt64(&c.pending, -1)
+		return fmt.Errorf("failed to push timeseries - %w (%d entries). "+
 			"Queue size is controlled by -remoteWrite.maxQueueSize flag",
-			c.maxQueueSize)
+			ErrQueueFull, c.maxQueueSize)
 	}
 }
 
+// Flush sends all the timeseries pushed before the call to remote storage
+// and waits until they are delivered.
+//
+// An error is returned if some of the pushed timeseries were dropped
+// after all the attempts to send them since the previous Flush call.
+// Timeseries pushed concurrently with Flush may be delivered
+// before Flush returns as well.
+func (c *Client) Flush(ctx context.Context) error {
+	t := time.NewTicker(10 * time.Millisecond)
+	defer t.Stop()
+	for atomic.LoadInt64(&c.pending) > 0 {
+		// ask an idle reader to send its batch without waiting for flushInterval
+		select {
+		case c.flushCh <- struct{}{}:
+		default:
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-c.doneCh:
+			return fmt.Errorf("client is closed")
+		case <-t.C:
+		}
+	}
+	if n := atomic.SwapUint64(&c.dropped, 0); n > 0 {
+		return fmt.Errorf("%d timeseries were dropped after all the attempts to send them to %s", n, c.addr)
+	}
+	return nil
+}
+
 // Close stops the client and waits for all goroutines
 // to exit.
 func (c *Client) Close() error {
@@ -165,6 +223,8 @@ func (c *Client) run(ctx context.Context) {
 				return
 			case <-ticker.C:
 				c.flush(ctx, wr)
+			case <-c.flushCh:
+				c.flush(ctx, wr)
 			case ts, ok := <-c.input:
 				if !ok {
 					continue
@@ -189,13 +249,18 @@ var (
 // it to remote write endpoint. Flush performs limited amount of retries
 // if request fails.
 func (c *Client) flush(ctx context.Context, wr *prompbmarshal.WriteRequest) {
-	if len(wr.Timeseries) < 1 {
+	n := len(wr.Timeseries)
+	if n < 1 {
 		return
 	}
-	defer prompbmarshal.ResetWriteRequest(wr)
+	defer func() {
+		prompbmarshal.ResetWriteRequest(wr)
+		atomic.AddInt64(&c.pending, -int64(n))
+	}()
 
 	data, err := wr.Marshal()
 	if err != nil {
+		atomic.AddUint64(&c.dropped, uint64(n))
 		logger.Errorf("failed to marshal WriteRequest: %s", err)
 		return
 	}
@@ -212,10 +277,11 @@ func (c *Client) flush(ctx context.Context, wr *prompbmarshal.WriteRequest) {
 
 		logger.Errorf("attempt %d to send request failed: %s", i+1, err)
 		// sleeping to avoid remote db hammering
-		time.Sleep(time.Second)
+		time.Sleep(c.retryInterval)
 		continue
 	}
 
+	atomic.AddUint64(&c.dropped, uint64(n))
 	droppedRows.Add(len(wr.Timeseries))
 	droppedBytes.Add(len(b))
 	logger.Errorf("all %d attempts to send request failed - dropping %d timeseries",
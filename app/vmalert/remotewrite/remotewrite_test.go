@@ -2,6 +2,7 @@ package remotewrite
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"math/rand"
@@ -53,6 +54,70 @@ func TestClient_Push(t *testing.T) {
 	}
 }
 
+func TestClient_PushQueueFull(t *testing.T) {
+	// the client has no readers, so the queue isn't drained
+	client := &Client{
+		input:        make(chan prompbmarshal.TimeSeries, 1),
+		doneCh:       make(chan struct{}),
+		maxQueueSize: 1,
+	}
+	if err := client.Push(prompbmarshal.TimeSeries{}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	err := client.Push(prompbmarshal.TimeSeries{})
+	if !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("expected to get ErrQueueFull; got %v", err)
+	}
+}
+
+func TestClient_Flush(t *testing.T) {
+	f := func(addr string, resultExpected bool) {
+		t.Helper()
+		client, err := NewClient(context.Background(), Config{
+			Addr: addr,
+			// flushInterval mustn't trigger sending in the test
+			FlushInterval: time.Hour,
+		})
+		if err != nil {
+			t.Fatalf("failed to create client: %s", err)
+		}
+		client.retryInterval = 0
+		defer func() {
+			_ = client.Close()
+		}()
+		const rowsN = 100
+		for i := 0; i < rowsN; i++ {
+			if err := client.Push(prompbmarshal.TimeSeries{}); err != nil {
+				t.Fatalf("unexpected error when pushing timeseries: %s", err)
+			}
+		}
+		err = client.Flush(context.Background())
+		if resultExpected && err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if !resultExpected && err == nil {
+			t.Fatalf("expecting non-nil error")
+		}
+		// the dropped timeseries must be reported only once
+		if err := client.Flush(context.Background()); err != nil {
+			t.Fatalf("unexpected error on the second flush: %s", err)
+		}
+	}
+
+	testSrv := newRWServer()
+	defer testSrv.Close()
+	f(testSrv.URL, true)
+	if got := testSrv.accepted(); got != 100 {
+		t.Fatalf("expected to have 100 series after flush; got %d", got)
+	}
+
+	failingSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer failingSrv.Close()
+	f(failingSrv.URL, false)
+}
+
 func newRWServer() *rwServer {
 	rw := &rwServer{}
 	rw.Server = httptest.NewServer(http.HandlerFunc(rw.handler))
@@ -0,0 +1,312 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmalert/config"
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmalert/datasource"
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmalert/notifier"
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmalert/remotewrite"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/fs"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/httpserver"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logger"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/prompbmarshal"
+)
+
+var (
+	replayFrom = flag.String("replay.timeFrom", "", "The time filter in RFC3339 format to select time series with timestamp equal or higher than provided value. "+
+		"E.g. '2020-01-01T20:07:00Z'. If set, vmalert evaluates rules on the [replay.timeFrom...replay.timeTo] range, writes results to -remoteWrite.url and exits")
+	replayTo = flag.String("replay.timeTo", "", "The time filter in RFC3339 format to select time series with timestamp equal or lower than provided value. "+
+		"E.g. '2020-01-01T20:07:00Z'. The current time is used if empty")
+	replayRulesDelay = flag.Duration("replay.rulesDelay", time.Second, "Delay between rules evaluation within the group during replay. "+
+		"Could be important if there are chained rules inside the group and processing need to wait for previous rule results to be persisted by remote storage before evaluating the next rule")
+	replayMaxDatapoints = flag.Int("replay.maxDatapointsPerQuery", 1e3, "Max number of data points expected in one request during replay. "+
+		"The time range of every request is calculated as group interval multiplied by this value. The higher the value, the less requests will be made during replay")
+	replayRuleRetryAttempts = flag.Int("replay.ruleRetryAttempts", 5, "Defines how many retries to make before giving up on rule if request for it returns an error during replay")
+	replayProgressFile      = flag.String("replay.progressFile", "", "Optional path to file for persisting replay progress. "+
+		"If set, replay restarted with the same -replay.timeFrom, -replay.timeTo and rules continues from the last successfully processed time range")
+)
+
+// runReplay evaluates rules from -rule files on the time range
+// specified via -replay.* flags and writes results to -remoteWrite.url.
+func runReplay(ctx context.Context) error {
+	from, to, err := parseReplayRange(*replayFrom, *replayTo, time.Now())
+	if err != nil {
+		return err
+	}
+	if *replayMaxDatapoints < 1 {
+		return fmt.Errorf("`-replay.maxDatapointsPerQuery` must be positive; got %d", *replayMaxDatapoints)
+	}
+	q, err := datasource.Init()
+	if err != nil {
+		return fmt.Errorf("failed to init datasource: %w", err)
+	}
+	rw, err := remotewrite.Init(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to init remoteWrite: %w", err)
+	}
+	if rw == nil {
+		return fmt.Errorf("`-remoteWrite.url` must be set in replay mode")
+	}
+	eu, err := getExternalURL(*externalURL, *httpListenAddr, httpserver.IsTLS())
+	if err != nil {
+		return fmt.Errorf("failed to init `external.url`: %w", err)
+	}
+	notifier.InitTemplateFunc(eu)
+	labels, err := parseExternalLabels(*externalLabels)
+	if err != nil {
+		return err
+	}
+	logger.Infof("reading rules configuration file from %q", strings.Join(*rulePath, ";"))
+	groupsCfg, err := config.Parse(*rulePath, *validateTemplates, *validateExpressions)
+	if err != nil {
+		return fmt.Errorf("cannot parse configuration file: %w", err)
+	}
+
+	r := &replayer{
+		querier:       q,
+		rw:            rw,
+		from:          from,
+		to:            to,
+		maxDatapoints: *replayMaxDatapoints,
+		rulesDelay:    *replayRulesDelay,
+		retryAttempts: *replayRuleRetryAttempts,
+		progressPath:  *replayProgressFile,
+	}
+	err = r.replay(ctx, groupsCfg, labels)
+	// Close flushes the pending time series to remote storage,
+	// so it must be called even if replay failed.
+	if closeErr := rw.Close(); closeErr != nil && err == nil {
+		err = fmt.Errorf("cannot stop the remotewrite: %w", closeErr)
+	}
+	return err
+}
+
+func parseReplayRange(fromStr, toStr string, now time.Time) (time.Time, time.Time, error) {
+	if fromStr == "" {
+		return time.Time{}, time.Time{}, fmt.Errorf("`-replay.timeFrom` must be set in replay mode")
+	}
+	from, err := time.Parse(time.RFC3339, fromStr)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("failed to parse `-replay.timeFrom`: %w", err)
+	}
+	to := now
+	if toStr != "" {
+		to, err = time.Parse(time.RFC3339, toStr)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("failed to parse `-replay.timeTo`: %w", err)
+		}
+	}
+	if !from.Before(to) {
+		return time.Time{}, time.Time{}, fmt.Errorf("`-replay.timeFrom`=%s must be smaller than `-replay.timeTo`=%s",
+			from.Format(time.RFC3339), to.Format(time.RFC3339))
+	}
+	return from, to, nil
+}
+
+// replayer evaluates rules on historical time range
+// and writes the results to remote storage.
+type replayer struct {
+	querier       datasource.Querier
+	rw            *remotewrite.Client
+	from, to      time.Time
+	maxDatapoints int
+	rulesDelay    time.Duration
+	retryAttempts int
+	// progressPath is an optional path to the file
+	// with the replay progress.
+	progressPath string
+
+	progress *replayProgress
+}
+
+// replayProgress is persisted to replayer.progressPath after every successfully processed time range.
+type replayProgress struct {
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+	// Rules contains the unix timestamp in seconds up to which the rule has been replayed.
+	// The key is in the form "<groupID>/<ruleID>".
+	Rules map[string]int64 `json:"rules"`
+}
+
+func (r *replayer) replay(ctx context.Context, groupsCfg []config.Group, labels map[string]string) error {
+	if err := r.loadProgress(); err != nil {
+		return err
+	}
+	logger.Infof("replay mode: from=%s, to=%s, maxDatapointsPerQuery=%d",
+		r.from.Format(time.RFC3339), r.to.Format(time.RFC3339), r.maxDatapoints)
+	var series int
+	for _, cfg := range groupsCfg {
+		g := newGroup(cfg, *evaluationInterval, labels)
+		n, err := r.replayGroup(ctx, g)
+		// the group wasn't started, so only rules need to be closed
+		for _, rule := range g.Rules {
+			rule.Close()
+		}
+		if err != nil {
+			return fmt.Errorf("group %q: %w", g.Name, err)
+		}
+		series += n
+	}
+	logger.Infof("replay finished: %d groups processed; %d time series written", len(groupsCfg), series)
+	return nil
+}
+
+func (r *replayer) replayGroup(ctx context.Context, g *Group) (int, error) {
+	step := g.Interval
+	chunk := step * time.Duration(r.maxDatapoints)
+	var chunks int
+	for ts := r.from; !ts.After(r.to); ts = ts.Add(chunk) {
+		chunks++
+	}
+	total := chunks * len(g.Rules)
+	logger.Infof("group %q: replaying %d rules with interval=%v; %d requests to make", g.Name, len(g.Rules), step, total)
+
+	q := g.withParams(r.querier)
+	var series, processed int
+	for i, rule := range g.Rules {
+		if i > 0 && r.rulesDelay > 0 {
+			// give remote storage a chance to persist results of
+			// the previous rule, which may be used by the next rule
+			time.Sleep(r.rulesDelay)
+		}
+		key := fmt.Sprintf("%d/%d", g.ID(), rule.ID())
+		for ts := r.from; !ts.After(r.to); ts = ts.Add(chunk) {
+			processed++
+			end := ts.Add(chunk - step)
+			if end.After(r.to) {
+				end = r.to
+			}
+			if done, ok := r.progress.Rules[key]; ok && end.Unix() <= done {
+				continue
+			}
+			n, err := r.replayRule(ctx, q, rule, ts, end, step, g.Limit)
+			if err != nil {
+				return series, fmt.Errorf("rule %q: failed to replay range %s-%s: %w; %s", rule, ts.Format(time.RFC3339),
+					end.Format(time.RFC3339), err, r.resumeHint(ts))
+			}
+			series += n
+			r.progress.Rules[key] = end.Unix()
+			if err := r.saveProgress(); err != nil {
+				return series, err
+			}
+			logger.Infof("group %q, rule %q: range %s-%s replayed; %d time series written; progress %d/%d (%.1f%%)",
+				g.Name, rule, ts.Format(time.RFC3339), end.Format(time.RFC3339), n, processed, total, 100*float64(processed)/float64(total))
+		}
+	}
+	return series, nil
+}
+
+func (r *replayer) replayRule(ctx context.Context, q datasource.Querier, rule Rule, start, end time.Time, step time.Duration, limit int) (int, error) {
+	var tss []prompbmarshal.TimeSeries
+	var err error
+	for i := 0; i < r.retryAttempts+1; i++ {
+		if i > 0 {
+			logger.Errorf("rule %q: attempt %d to replay range %s-%s failed: %s; retrying", rule, i,
+				start.Format(time.RFC3339), end.Format(time.RFC3339), err)
+			time.Sleep(time.Second * time.Duration(i))
+		}
+		tss, err = rule.ExecRange(ctx, q, start, end, step, limit)
+		if err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return 0, err
+	}
+	for _, ts := range tss {
+		if err := r.push(ctx, ts); err != nil {
+			remoteWriteErrors.Inc()
+			return 0, fmt.Errorf("remote write failure: %w", err)
+		}
+	}
+	// Wait until the pushed time series are delivered to remote storage,
+	// so the range isn't marked as replayed in the progress file if they were dropped.
+	if err := r.rw.Flush(ctx); err != nil {
+		remoteWriteErrors.Inc()
+		return 0, fmt.Errorf("remote write failure: %w", err)
+	}
+	return len(tss), nil
+}
+
+// push waits until the remote write queue has enough space for ts.
+func (r *replayer) push(ctx context.Context, ts prompbmarshal.TimeSeries) error {
+	for {
+		err := r.rw.Push(ts)
+		if err == nil {
+			return nil
+		}
+		if !errors.Is(err, remotewrite.ErrQueueFull) {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
+func (r *replayer) resumeHint(ts time.Time) string {
+	if r.progressPath != "" {
+		return "replay will be resumed from this range on the next run with the same flags"
+	}
+	return fmt.Sprintf("pass `-replay.progressFile` or `-replay.timeFrom=%s` in order to resume replay", ts.Format(time.RFC3339))
+}
+
+func (r *replayer) loadProgress() error {
+	r.progress = &replayProgress{
+		From:  r.from,
+		To:    r.to,
+		Rules: make(map[string]int64),
+	}
+	if r.progressPath == "" || !fs.IsPathExist(r.progressPath) {
+		return nil
+	}
+	data, err := ioutil.ReadFile(r.progressPath)
+	if err != nil {
+		return fmt.Errorf("cannot read replay progress: %w", err)
+	}
+	var p replayProgress
+	if err := json.Unmarshal(data, &p); err != nil {
+		return fmt.Errorf("cannot parse replay progress from %q: %w", r.progressPath, err)
+	}
+	if !p.From.Equal(r.from) || !p.To.Equal(r.to) {
+		logger.Warnf("ignoring replay progress from %q, since it was made for another time range %s-%s",
+			r.progressPath, p.From.Format(time.RFC3339), p.To.Format(time.RFC3339))
+		return nil
+	}
+	if p.Rules != nil {
+		r.progress.Rules = p.Rules
+	}
+	logger.Infof("loaded replay progress for %d rules from %q", len(r.progress.Rules), r.progressPath)
+	return nil
+}
+
+func (r *replayer) saveProgress() error {
+	if r.progressPath == "" {
+		return nil
+	}
+	data, err := json.Marshal(r.progress)
+	if err != nil {
+		return fmt.Errorf("BUG: cannot marshal replay progress: %w", err)
+	}
+	// Write the data to a temporary file and then atomically rename it,
+	// so the previous progress remains valid if the process is killed in the middle of the write.
+	tmpPath := r.progressPath + ".tmp"
+	if err := ioutil.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("cannot write replay progress to %q: %w", tmpPath, err)
+	}
+	if err := os.Rename(tmpPath, r.progressPath); err != nil {
+		return fmt.Errorf("cannot move %q to %q: %w", tmpPath, r.progressPath, err)
+	}
+	return nil
+}
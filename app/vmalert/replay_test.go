@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmalert/config"
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmalert/datasource"
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmalert/remotewrite"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/prompb"
+	"github.com/golang/snappy"
+)
+
+func TestParseReplayRange(t *testing.T) {
+	now := time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)
+	f := func(fromStr, toStr string, fromExpected, toExpected time.Time) {
+		t.Helper()
+		from, to, err := parseReplayRange(fromStr, toStr, now)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if !from.Equal(fromExpected) || !to.Equal(toExpected) {
+			t.Fatalf("unexpected range; got %s-%s; want %s-%s", from, to, fromExpected, toExpected)
+		}
+	}
+	f("2020-01-01T00:00:00Z", "", time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), now)
+	f("2020-01-01T00:00:00Z", "2020-01-01T10:00:00Z",
+		time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2020, 1, 1, 10, 0, 0, 0, time.UTC))
+
+	fError := func(fromStr, toStr string) {
+		t.Helper()
+		if _, _, err := parseReplayRange(fromStr, toStr, now); err == nil {
+			t.Fatalf("expecting non-nil error for from=%q, to=%q", fromStr, toStr)
+		}
+	}
+	fError("", "2020-01-01T10:00:00Z")
+	fError("foo", "")
+	fError("2020-01-01T00:00:00Z", "bar")
+	fError("2020-01-01T10:00:00Z", "2020-01-01T00:00:00Z")
+	fError("2020-01-03T00:00:00Z", "")
+}
+
+// fakeRemoteWrite counts samples received via remote write protocol
+type fakeRemoteWrite struct {
+	sync.Mutex
+	samples int
+}
+
+func (rw *fakeRemoteWrite) handler(w http.ResponseWriter, r *http.Request) {
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	b, err := snappy.Decode(nil, data)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var wr prompb.WriteRequest
+	if err := wr.Unmarshal(b); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	rw.Lock()
+	for _, ts := range wr.Timeseries {
+		rw.samples += len(ts.Samples)
+	}
+	rw.Unlock()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (rw *fakeRemoteWrite) getSamples() int {
+	rw.Lock()
+	defer rw.Unlock()
+	return rw.samples
+}
+
+func TestReplay(t *testing.T) {
+	progressPath := "TestReplay.progress.json"
+	defer func() {
+		_ = os.Remove(progressPath)
+	}()
+
+	frw := &fakeRemoteWrite{}
+	srv := httptest.NewServer(http.HandlerFunc(frw.handler))
+	defer srv.Close()
+
+	from := time.Unix(0, 0)
+	to := from.Add(time.Hour)
+	fq := &fakeQuerier{}
+	for ts := from; !ts.After(to); ts = ts.Add(time.Minute) {
+		m := metricWithValueAndLabels(t, 1, "__name__", "foo")
+		m.Timestamp = ts.Unix()
+		fq.add(m)
+	}
+	groupsCfg := []config.Group{
+		{
+			Name:     "replay",
+			Interval: time.Minute,
+			Rules: []config.Rule{
+				{ID: 1, Record: "foo:record", Expr: "foo"},
+				{ID: 2, Record: "bar:record", Expr: "bar"},
+			},
+		},
+	}
+	replay := func(q datasource.Querier) (int, error) {
+		t.Helper()
+		rw, err := remotewrite.NewClient(context.Background(), remotewrite.Config{Addr: srv.URL})
+		if err != nil {
+			t.Fatalf("cannot create remotewrite client: %s", err)
+		}
+		r := &replayer{
+			querier:       q,
+			rw:            rw,
+			from:          from,
+			to:            to,
+			maxDatapoints: 10,
+			progressPath:  progressPath,
+		}
+		samplesBefore := frw.getSamples()
+		err = r.replay(context.Background(), groupsCfg, nil)
+		if err := rw.Close(); err != nil {
+			t.Fatalf("cannot close remotewrite client: %s", err)
+		}
+		return frw.getSamples() - samplesBefore, err
+	}
+
+	// interrupt replay on the second rule
+	n, err := replay(&failingQuerier{fakeQuerier: fq, failExpr: "bar"})
+	if err == nil {
+		t.Fatalf("expecting non-nil error")
+	}
+	// every data point in [from...to] range must be written for the first rule
+	if n != 61 {
+		t.Fatalf("unexpected number of samples written by the first rule; got %d; want 61", n)
+	}
+
+	// resumed replay must process only the second rule
+	n, err = replay(fq)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if n != 61 {
+		t.Fatalf("unexpected number of samples written after resume; got %d; want 61", n)
+	}
+
+	// replay for the already processed range must be no-op
+	n, err = replay(fq)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if n != 0 {
+		t.Fatalf("expecting no samples to be written for already processed range; got %d", n)
+	}
+}
+
+// failingQuerier returns an error for range queries with failExpr
+type failingQuerier struct {
+	*fakeQuerier
+	failExpr string
+}
+
+func (fq *failingQuerier) QueryRange(ctx context.Context, query string, start, end time.Time, step time.Duration) ([]datasource.Metric, error) {
+	if query == fq.failExpr {
+		return nil, errors.New("query failed")
+	}
+	return fq.fakeQuerier.QueryRange(ctx, query, start, end, step)
+}
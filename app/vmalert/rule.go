@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"time"
 
 	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmalert/datasource"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/prompbmarshal"
@@ -16,8 +17,15 @@ type Rule interface {
 	ID() uint64
 	// Exec executes the rule with given context
 	// and Querier. If returnSeries is true, Exec
-	// may return TimeSeries as result of execution
-	Exec(ctx context.Context, q datasource.Querier, returnSeries bool) ([]prompbmarshal.TimeSeries, error)
+	// may return TimeSeries as result of execution.
+	// Exec fails if the rule produces more than limit
+	// alerts or series. Zero limit means no limit.
+	Exec(ctx context.Context, q datasource.Querier, returnSeries bool, limit int) ([]prompbmarshal.TimeSeries, error)
+	// ExecRange executes the rule on the [start...end] time range
+	// with the given evaluation step and returns TimeSeries
+	// as result of execution. It doesn't change the rule state.
+	// The limit is applied to every evaluation step.
+	ExecRange(ctx context.Context, q datasource.Querier, start, end time.Time, step time.Duration, limit int) ([]prompbmarshal.TimeSeries, error)
 	// UpdateWith performs modification of current Rule
 	// with fields of the given Rule.
 	UpdateWith(Rule) error
@@ -0,0 +1,636 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmalert/config"
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmalert/datasource"
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmalert/notifier"
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmalert/remotewrite"
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vminsert"
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmselect"
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmstorage"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/flagutil"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/fs"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/httpserver"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/prompbmarshal"
+	"github.com/VictoriaMetrics/metricsql"
+	"gopkg.in/yaml.v2"
+)
+
+var ruleTests = flagutil.NewArray("rule.test", "Path to the file with unit tests for alerting and recording rules. "+
+	"Flag can be specified multiple times. If set, vmalert runs the tests against in-process storage, prints the results and exits. "+
+	"See https://github.com/VictoriaMetrics/VictoriaMetrics/blob/master/app/vmalert/README.md#unit-testing-for-rules")
+
+// unitTestStartTime is the time of the first sample
+// of input series and the first evaluation of rules.
+// It isn't set to zero, since the storage doesn't support
+// lookback windows with negative timestamps.
+var unitTestStartTime = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// unitTestFile contains unit tests for rules
+type unitTestFile struct {
+	RuleFiles          []string      `yaml:"rule_files"`
+	EvaluationInterval time.Duration `yaml:"evaluation_interval"`
+	Tests              []testGroup   `yaml:"tests"`
+}
+
+// testGroup is a group of tests,
+// which share the same input series
+type testGroup struct {
+	Interval           time.Duration       `yaml:"interval"`
+	InputSeries        []inputSeries       `yaml:"input_series"`
+	AlertRuleTests     []alertTestCase     `yaml:"alert_rule_test"`
+	MetricsqlExprTests []metricsqlTestCase `yaml:"metricsql_expr_test"`
+	ExternalLabels     map[string]string   `yaml:"external_labels"`
+}
+
+// inputSeries is a series with values in expanding notation,
+// e.g. `1+1x10` or `_x3`
+type inputSeries struct {
+	Series string `yaml:"series"`
+	Values string `yaml:"values"`
+}
+
+// alertTestCase contains alerts expected to be firing
+// for the given alerting rule at eval_time
+type alertTestCase struct {
+	EvalTime  time.Duration `yaml:"eval_time"`
+	GroupName string        `yaml:"groupname"`
+	Alertname string        `yaml:"alertname"`
+	ExpAlerts []expAlert    `yaml:"exp_alerts"`
+}
+
+type expAlert struct {
+	ExpLabels      map[string]string `yaml:"exp_labels"`
+	ExpAnnotations map[string]string `yaml:"exp_annotations"`
+}
+
+// metricsqlTestCase contains samples expected
+// to be returned by expr at eval_time
+type metricsqlTestCase struct {
+	Expr       string        `yaml:"expr"`
+	EvalTime   time.Duration `yaml:"eval_time"`
+	ExpSamples []expSample   `yaml:"exp_samples"`
+}
+
+type expSample struct {
+	Labels string  `yaml:"labels"`
+	Value  float64 `yaml:"value"`
+}
+
+// runUnitTests runs tests from the given files
+// and returns the process exit code.
+func runUnitTests(files []string) int {
+	eu, err := getExternalURL(*externalURL, *httpListenAddr, httpserver.IsTLS())
+	if err != nil {
+		fmt.Printf("failed to init `external.url`: %s\n", err)
+		return 1
+	}
+	notifier.InitTemplateFunc(eu)
+
+	ctx := context.Background()
+	s, err := startUnitTestStorage()
+	if err != nil {
+		fmt.Printf("cannot start storage for unit tests: %s\n", err)
+		return 1
+	}
+	defer s.stop()
+
+	failed := false
+	for _, f := range files {
+		fmt.Printf("Unit testing: %s\n", f)
+		errs := s.testFile(ctx, f)
+		if len(errs) == 0 {
+			fmt.Printf("  SUCCESS\n\n")
+			continue
+		}
+		failed = true
+		fmt.Printf("  FAILED:\n")
+		for _, err := range errs {
+			fmt.Printf("%s\n", indent(err.Error(), "    "))
+		}
+		fmt.Println()
+	}
+	if failed {
+		return 1
+	}
+	return 0
+}
+
+// unitTestStorage is in-process VictoriaMetrics
+// used as datasource and remote storage for unit tests
+type unitTestStorage struct {
+	path string
+	addr string
+	q    datasource.QuerierBuilder
+}
+
+// startUnitTestStorage starts in-process storage.
+// It may be called only once per process.
+func startUnitTestStorage() (*unitTestStorage, error) {
+	path, err := ioutil.TempDir("", "vmalert-unittest")
+	if err != nil {
+		return nil, fmt.Errorf("cannot create temporary dir: %w", err)
+	}
+	addr, err := getFreeAddr()
+	if err != nil {
+		fs.MustRemoveAll(path)
+		return nil, err
+	}
+	flags := map[string]string{
+		"storageDataPath": path,
+		// input series start at unitTestStartTime,
+		// so retention must cover it
+		"retentionPeriod":     "100y",
+		"search.disableCache": "true",
+	}
+	if !isFlagSet("loggerLevel") {
+		// storage logs are useless in tests output
+		flags["loggerLevel"] = "ERROR"
+	}
+	for name, value := range flags {
+		if err := flag.Set(name, value); err != nil {
+			fs.MustRemoveAll(path)
+			return nil, fmt.Errorf("cannot set flag %q to %q: %w", name, value, err)
+		}
+	}
+
+	vmstorage.InitWithoutMetrics()
+	vmselect.Init()
+	vminsert.Init()
+	go httpserver.Serve(addr, unitTestRequestHandler)
+
+	s := &unitTestStorage{
+		path: path,
+		addr: addr,
+		q:    datasource.NewVMStorage("http://"+addr, "", "", &http.Client{}),
+	}
+	for i := 0; i < 50; i++ {
+		resp, err := http.Get("http://" + addr + "/health")
+		if err == nil {
+			_ = resp.Body.Close()
+			if resp.StatusCode == http.StatusOK {
+				return s, nil
+			}
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	s.stop()
+	return nil, fmt.Errorf("storage didn't start at %q in 5 seconds", addr)
+}
+
+func unitTestRequestHandler(w http.ResponseWriter, r *http.Request) bool {
+	if vminsert.RequestHandler(w, r) {
+		return true
+	}
+	if vmselect.RequestHandler(w, r) {
+		return true
+	}
+	return vmstorage.RequestHandler(w, r)
+}
+
+func (s *unitTestStorage) stop() {
+	_ = httpserver.Stop(s.addr)
+	vminsert.Stop()
+	vmstorage.Stop()
+	vmselect.Stop()
+	fs.MustRemoveAll(s.path)
+}
+
+// reset drops all the data from the storage
+func (s *unitTestStorage) reset() {
+	vmstorage.Stop()
+	fs.MustRemoveAll(s.path)
+	vmstorage.InitWithoutMetrics()
+}
+
+// write writes tss to the storage and makes them visible for search
+func (s *unitTestStorage) write(ctx context.Context, tss []prompbmarshal.TimeSeries) error {
+	if len(tss) == 0 {
+		return nil
+	}
+	rw, err := remotewrite.NewClient(ctx, remotewrite.Config{
+		Addr:         "http://" + s.addr,
+		Concurrency:  1,
+		MaxQueueSize: len(tss),
+	})
+	if err != nil {
+		return err
+	}
+	for _, ts := range tss {
+		if err := rw.Push(ts); err != nil {
+			_ = rw.Close()
+			return err
+		}
+	}
+	// Close flushes all the pushed series
+	if err := rw.Close(); err != nil {
+		return err
+	}
+	vmstorage.Storage.DebugFlush()
+	return nil
+}
+
+func (s *unitTestStorage) testFile(ctx context.Context, path string) []error {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return []error{fmt.Errorf("cannot read file: %w", err)}
+	}
+	var tf unitTestFile
+	if err := yaml.UnmarshalStrict(b, &tf); err != nil {
+		return []error{fmt.Errorf("cannot parse file: %w", err)}
+	}
+	if tf.EvaluationInterval <= 0 {
+		tf.EvaluationInterval = time.Minute
+	}
+	// rule files paths are relative to the test file
+	ruleFiles := make([]string, len(tf.RuleFiles))
+	for i, rf := range tf.RuleFiles {
+		if !filepath.IsAbs(rf) {
+			rf = filepath.Join(filepath.Dir(path), rf)
+		}
+		ruleFiles[i] = rf
+	}
+	groups, err := config.Parse(ruleFiles, true, true)
+	if err != nil {
+		return []error{fmt.Errorf("cannot parse rule files: %w", err)}
+	}
+
+	var errs []error
+	for i := range tf.Tests {
+		s.reset()
+		for _, err := range s.test(ctx, &tf.Tests[i], groups, tf.EvaluationInterval) {
+			errs = append(errs, fmt.Errorf("test #%d: %w", i+1, err))
+		}
+	}
+	return errs
+}
+
+// groupAlert identifies alerts of the alerting rule
+type groupAlert struct {
+	group string
+	alert string
+}
+
+func (s *unitTestStorage) test(ctx context.Context, tg *testGroup, groupsCfg []config.Group, evalInterval time.Duration) []error {
+	interval := tg.Interval
+	if interval <= 0 {
+		interval = evalInterval
+	}
+	tss, err := tg.inputTimeSeries(interval)
+	if err != nil {
+		return []error{err}
+	}
+	if err := s.write(ctx, tss); err != nil {
+		return []error{fmt.Errorf("cannot write input series: %w", err)}
+	}
+
+	var maxEvalTime time.Duration
+	for _, at := range tg.AlertRuleTests {
+		if at.EvalTime > maxEvalTime {
+			maxEvalTime = at.EvalTime
+		}
+	}
+	for _, mt := range tg.MetricsqlExprTests {
+		if mt.EvalTime > maxEvalTime {
+			maxEvalTime = mt.EvalTime
+		}
+	}
+
+	// evaluate rules in the order they are defined,
+	// so chained rules see results of the previous rules
+	start, end := unitTestStartTime, unitTestStartTime.Add(maxEvalTime)
+	intervals := make(map[string]time.Duration)
+	alerts := make(map[groupAlert]map[int64][]*notifier.Alert)
+	for _, cfg := range groupsCfg {
+		g := newGroup(cfg, evalInterval, tg.ExternalLabels)
+		intervals[g.Name] = g.Interval
+		q := g.withParams(s.q)
+		for _, rule := range g.Rules {
+			var tss []prompbmarshal.TimeSeries
+			var err error
+			switch r := rule.(type) {
+			case *RecordingRule:
+				tss, err = r.ExecRange(ctx, q, start, end, g.Interval, g.Limit)
+			case *AlertingRule:
+				key := groupAlert{group: g.Name, alert: r.Name}
+				if alerts[key] == nil {
+					alerts[key] = make(map[int64][]*notifier.Alert)
+				}
+				err = r.alertsRange(ctx, q, start, end, g.Interval, g.Limit, func(a *notifier.Alert, timestamp int64) {
+					if a.State == notifier.StateFiring {
+						alerts[key][timestamp] = append(alerts[key][timestamp], a)
+					}
+					tss = append(tss, r.alertToTimeSeries(a, time.Unix(timestamp, 0))...)
+				})
+			}
+			rule.Close()
+			if err != nil {
+				return []error{fmt.Errorf("cannot evaluate rule %q in group %q: %w", rule, g.Name, err)}
+			}
+			if err := s.write(ctx, tss); err != nil {
+				return []error{fmt.Errorf("cannot write results of rule %q in group %q: %w", rule, g.Name, err)}
+			}
+		}
+	}
+
+	var errs []error
+	for _, at := range tg.AlertRuleTests {
+		interval, ok := intervals[at.GroupName]
+		if !ok {
+			errs = append(errs, fmt.Errorf("group %q not found", at.GroupName))
+			continue
+		}
+		key := groupAlert{group: at.GroupName, alert: at.Alertname}
+		byTime, ok := alerts[key]
+		if !ok {
+			errs = append(errs, fmt.Errorf("alerting rule %q not found in group %q", at.Alertname, at.GroupName))
+			continue
+		}
+		// alerts are checked at the last evaluation before eval_time
+		ts := start.Add(at.EvalTime.Truncate(interval)).Unix()
+		var got []string
+		for _, a := range byTime[ts] {
+			labels := make(map[string]string, len(a.Labels)+1)
+			for k, v := range a.Labels {
+				labels[k] = v
+			}
+			labels[alertNameLabel] = a.Name
+			got = append(got, alertString(labels, a.Annotations))
+		}
+		var exp []string
+		for _, ea := range at.ExpAlerts {
+			labels := make(map[string]string, len(ea.ExpLabels)+2)
+			for k, v := range ea.ExpLabels {
+				labels[k] = v
+			}
+			if _, ok := labels[alertNameLabel]; !ok {
+				labels[alertNameLabel] = at.Alertname
+			}
+			if _, ok := labels[alertGroupNameLabel]; !ok {
+				labels[alertGroupNameLabel] = at.GroupName
+			}
+			exp = append(exp, alertString(labels, ea.ExpAnnotations))
+		}
+		sort.Strings(got)
+		sort.Strings(exp)
+		if strings.Join(got, "\n") != strings.Join(exp, "\n") {
+			errs = append(errs, fmt.Errorf("alertname: %s, group: %s, time: %s,\n    exp: %s,\n    got: %s",
+				at.Alertname, at.GroupName, at.EvalTime, listString(exp), listString(got)))
+		}
+	}
+
+	for _, mt := range tg.MetricsqlExprTests {
+		if err := s.testExpr(ctx, start, &mt); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+func (s *unitTestStorage) testExpr(ctx context.Context, start time.Time, mt *metricsqlTestCase) error {
+	q := s.q.BuildWithParams(datasource.QuerierParams{
+		QueryParams: url.Values{"time": {strconv.FormatInt(start.Add(mt.EvalTime).Unix(), 10)}},
+	})
+	metrics, err := q.Query(ctx, mt.Expr)
+	if err != nil {
+		return fmt.Errorf("expr: %q, time: %s, err: %w", mt.Expr, mt.EvalTime, err)
+	}
+	type sample struct {
+		labels string
+		value  float64
+	}
+	var got, exp []sample
+	for _, m := range metrics {
+		labels := make(map[string]string, len(m.Labels))
+		for _, l := range m.Labels {
+			labels[l.Name] = l.Value
+		}
+		got = append(got, sample{labels: labelsString(labels), value: m.Value})
+	}
+	for _, es := range mt.ExpSamples {
+		labels, err := parseSeries(es.Labels)
+		if err != nil {
+			return fmt.Errorf("expr: %q, time: %s, cannot parse expected labels %q: %w", mt.Expr, mt.EvalTime, es.Labels, err)
+		}
+		exp = append(exp, sample{labels: labelsString(labels), value: es.Value})
+	}
+	sortSamples := func(ss []sample) {
+		sort.Slice(ss, func(i, j int) bool {
+			return ss[i].labels < ss[j].labels
+		})
+	}
+	sortSamples(got)
+	sortSamples(exp)
+	equal := len(got) == len(exp)
+	for i := 0; equal && i < len(got); i++ {
+		equal = got[i].labels == exp[i].labels && almostEqual(got[i].value, exp[i].value)
+	}
+	if equal {
+		return nil
+	}
+	samplesString := func(ss []sample) string {
+		a := make([]string, len(ss))
+		for i, s := range ss {
+			a[i] = fmt.Sprintf("%s %g", s.labels, s.value)
+		}
+		return listString(a)
+	}
+	return fmt.Errorf("expr: %q, time: %s,\n    exp: %s,\n    got: %s", mt.Expr, mt.EvalTime, samplesString(exp), samplesString(got))
+}
+
+// inputTimeSeries returns input series with samples
+// starting at unitTestStartTime with the given interval
+func (tg *testGroup) inputTimeSeries(interval time.Duration) ([]prompbmarshal.TimeSeries, error) {
+	var tss []prompbmarshal.TimeSeries
+	for _, is := range tg.InputSeries {
+		labels, err := parseSeries(is.Series)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse series %q: %w", is.Series, err)
+		}
+		values, err := parseInputValues(is.Values)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse values %q for series %q: %w", is.Values, is.Series, err)
+		}
+		ts := prompbmarshal.TimeSeries{}
+		for k, v := range labels {
+			ts.Labels = append(ts.Labels, prompbmarshal.Label{Name: k, Value: v})
+		}
+		for i, v := range values {
+			if v.missing {
+				continue
+			}
+			ts.Samples = append(ts.Samples, prompbmarshal.Sample{
+				Value:     v.value,
+				Timestamp: unitTestStartTime.Add(time.Duration(i)*interval).UnixNano() / 1e6,
+			})
+		}
+		tss = append(tss, ts)
+	}
+	return tss, nil
+}
+
+// parseSeries parses series selector like `foo{bar="baz"}` into labels
+func parseSeries(s string) (map[string]string, error) {
+	expr, err := metricsql.Parse(s)
+	if err != nil {
+		return nil, err
+	}
+	me, ok := expr.(*metricsql.MetricExpr)
+	if !ok {
+		return nil, fmt.Errorf("expecting series selector; got %q", expr.AppendString(nil))
+	}
+	labels := make(map[string]string, len(me.LabelFilters))
+	for _, lf := range me.LabelFilters {
+		if lf.IsNegative || lf.IsRegexp {
+			return nil, fmt.Errorf("only `=` label matchers are supported; got %q", lf.AppendString(nil))
+		}
+		labels[lf.Label] = lf.Value
+	}
+	return labels, nil
+}
+
+type inputValue struct {
+	value   float64
+	missing bool
+}
+
+// parseInputValues parses space-separated values in expanding notation:
+//
+//	'a+bxn' becomes 'a a+b a+(2*b) ... a+(n*b)'
+//	'a-bxn' becomes 'a a-b a-(2*b) ... a-(n*b)'
+//	'axn' becomes 'a a ... a' (n+1 times)
+//	'_' represents a missing sample
+//	'_xn' becomes '_ _ ... _' (n times)
+//	'stale' represents a missing sample
+func parseInputValues(s string) ([]inputValue, error) {
+	var values []inputValue
+	for _, item := range strings.Fields(s) {
+		if item == "_" || item == "stale" {
+			values = append(values, inputValue{missing: true})
+			continue
+		}
+		n := strings.LastIndexByte(item, 'x')
+		if n < 0 {
+			v, err := strconv.ParseFloat(item, 64)
+			if err != nil {
+				return nil, fmt.Errorf("cannot parse %q: %w", item, err)
+			}
+			values = append(values, inputValue{value: v})
+			continue
+		}
+		count, err := strconv.Atoi(item[n+1:])
+		if err != nil || count < 0 {
+			return nil, fmt.Errorf("cannot parse repeat count in %q", item)
+		}
+		item = item[:n]
+		if item == "_" {
+			for i := 0; i < count; i++ {
+				values = append(values, inputValue{missing: true})
+			}
+			continue
+		}
+		start, delta, err := parseInputDelta(item)
+		if err != nil {
+			return nil, err
+		}
+		for i := 0; i <= count; i++ {
+			values = append(values, inputValue{value: start + float64(i)*delta})
+		}
+	}
+	return values, nil
+}
+
+// parseInputDelta parses 'a+b', 'a-b' or 'a' into start and delta values
+func parseInputDelta(s string) (float64, float64, error) {
+	if v, err := strconv.ParseFloat(s, 64); err == nil {
+		return v, 0, nil
+	}
+	// skip the leading sign and signs of exponent
+	for i := 1; i < len(s); i++ {
+		if (s[i] != '+' && s[i] != '-') || s[i-1] == 'e' || s[i-1] == 'E' {
+			continue
+		}
+		start, err := strconv.ParseFloat(s[:i], 64)
+		if err != nil {
+			return 0, 0, fmt.Errorf("cannot parse %q: %w", s, err)
+		}
+		delta, err := strconv.ParseFloat(s[i+1:], 64)
+		if err != nil {
+			return 0, 0, fmt.Errorf("cannot parse %q: %w", s, err)
+		}
+		if s[i] == '-' {
+			delta = -delta
+		}
+		return start, delta, nil
+	}
+	return 0, 0, fmt.Errorf("cannot parse %q", s)
+}
+
+func alertString(labels, annotations map[string]string) string {
+	return fmt.Sprintf("{labels: %s, annotations: %s}", labelsString(labels), labelsString(annotations))
+}
+
+func labelsString(m map[string]string) string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	a := make([]string, len(keys))
+	for i, k := range keys {
+		a[i] = fmt.Sprintf("%s=%q", k, m[k])
+	}
+	return "{" + strings.Join(a, ", ") + "}"
+}
+
+func listString(a []string) string {
+	return "[" + strings.Join(a, ", ") + "]"
+}
+
+func almostEqual(a, b float64) bool {
+	if math.IsNaN(a) || math.IsNaN(b) {
+		return math.IsNaN(a) && math.IsNaN(b)
+	}
+	if a == b {
+		return true
+	}
+	return math.Abs(a-b) <= 1e-12*math.Max(math.Abs(a), math.Abs(b))
+}
+
+func indent(s, prefix string) string {
+	return prefix + strings.Replace(s, "\n", "\n"+prefix, -1)
+}
+
+func getFreeAddr() (string, error) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", fmt.Errorf("cannot find free port for storage: %w", err)
+	}
+	addr := ln.Addr().String()
+	_ = ln.Close()
+	return addr, nil
+}
+
+func isFlagSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestParseInputValues(t *testing.T) {
+	f := func(s string, expected []inputValue) {
+		t.Helper()
+		values, err := parseInputValues(s)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if !reflect.DeepEqual(values, expected) {
+			t.Fatalf("unexpected values for %q;\ngot\n%v\nwant\n%v", s, values, expected)
+		}
+	}
+	v := func(value float64) inputValue {
+		return inputValue{value: value}
+	}
+	missing := inputValue{missing: true}
+	f("", nil)
+	f("1 2.5 -3", []inputValue{v(1), v(2.5), v(-3)})
+	f("1+1x3", []inputValue{v(1), v(2), v(3), v(4)})
+	f("-2+4x2", []inputValue{v(-2), v(2), v(6)})
+	f("10-2x2", []inputValue{v(10), v(8), v(6)})
+	f("1e2+1e1x1", []inputValue{v(100), v(110)})
+	f("5x2", []inputValue{v(5), v(5), v(5)})
+	f("1 _ stale _x2 3", []inputValue{v(1), missing, missing, missing, missing, v(3)})
+
+	fError := func(s string) {
+		t.Helper()
+		if _, err := parseInputValues(s); err == nil {
+			t.Fatalf("expecting non-nil error for %q", s)
+		}
+	}
+	fError("foo")
+	fError("1+1xfoo")
+	fError("1+1x-1")
+	fError("1*2x3")
+	fError("1+x3")
+}
+
+func TestParseSeries(t *testing.T) {
+	labels, err := parseSeries(`foo{bar="baz", job="x"}`)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	expected := map[string]string{"__name__": "foo", "bar": "baz", "job": "x"}
+	if !reflect.DeepEqual(labels, expected) {
+		t.Fatalf("unexpected labels; got %v; want %v", labels, expected)
+	}
+	for _, s := range []string{`foo{bar=~"baz"}`, `foo{bar!="baz"}`, `sum(foo)`, `foo{`} {
+		if _, err := parseSeries(s); err == nil {
+			t.Fatalf("expecting non-nil error for %q", s)
+		}
+	}
+}
+
+func TestUnitTestStorage(t *testing.T) {
+	// the storage can be started only once per process
+	s, err := startUnitTestStorage()
+	if err != nil {
+		t.Fatalf("cannot start storage: %s", err)
+	}
+	defer s.stop()
+
+	ctx := context.Background()
+	if errs := s.testFile(ctx, "testdata/unittest/test-good.yaml"); len(errs) > 0 {
+		t.Fatalf("unexpected errors for good tests: %v", errs)
+	}
+	errs := s.testFile(ctx, "testdata/unittest/test-bad.yaml")
+	if len(errs) != 2 {
+		t.Fatalf("expecting 2 errors for bad tests; got %d: %v", len(errs), errs)
+	}
+	if errs := s.testFile(ctx, "testdata/unittest/missing.yaml"); len(errs) != 1 {
+		t.Fatalf("expecting 1 error for missing file; got %d: %v", len(errs), errs)
+	}
+}
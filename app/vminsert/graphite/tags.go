@@ -0,0 +1,127 @@
+package graphite
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vminsert/relabel"
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmstorage"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/bytesutil"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/prompb"
+	parser "github.com/VictoriaMetrics/VictoriaMetrics/lib/protoparser/graphite"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/storage"
+	"github.com/VictoriaMetrics/metrics"
+)
+
+// TagsTagSeriesHandler implements /tags/tagSeries handler.
+//
+// See https://graphite.readthedocs.io/en/stable/tags.html#adding-series-to-the-tagdb
+func TagsTagSeriesHandler(w http.ResponseWriter, r *http.Request) error {
+	startTime := time.Now()
+	if err := r.ParseForm(); err != nil {
+		return fmt.Errorf("cannot parse form values: %w", err)
+	}
+	paths := r.Form["path"]
+	if len(paths) != 1 {
+		return fmt.Errorf("expecting a single `path` arg; got %d args", len(paths))
+	}
+	canonicalPaths, err := registerPaths(startTime, paths)
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", "application/json")
+	WriteTagsTagSeriesResponse(w, canonicalPaths[0])
+	tagsTagSeriesDuration.UpdateDuration(startTime)
+	return nil
+}
+
+var tagsTagSeriesDuration = metrics.NewSummary(`vm_request_duration_seconds{path="/tags/tagSeries"}`)
+
+// TagsTagMultiSeriesHandler implements /tags/tagMultiSeries handler.
+//
+// See https://graphite.readthedocs.io/en/stable/tags.html#adding-series-to-the-tagdb
+func TagsTagMultiSeriesHandler(w http.ResponseWriter, r *http.Request) error {
+	startTime := time.Now()
+	if err := r.ParseForm(); err != nil {
+		return fmt.Errorf("cannot parse form values: %w", err)
+	}
+	paths := r.Form["path"]
+	if len(paths) == 0 {
+		return fmt.Errorf("expecting at least one `path` arg")
+	}
+	canonicalPaths, err := registerPaths(startTime, paths)
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", "application/json")
+	WriteTagsTagMultiSeriesResponse(w, canonicalPaths)
+	tagsTagMultiSeriesDuration.UpdateDuration(startTime)
+	return nil
+}
+
+var tagsTagMultiSeriesDuration = metrics.NewSummary(`vm_request_duration_seconds{path="/tags/tagMultiSeries"}`)
+
+// registerPaths registers the given Graphite paths in the storage without adding samples for them.
+//
+// It returns canonical paths for the given paths.
+func registerPaths(startTime time.Time, paths []string) ([]string, error) {
+	var (
+		row        parser.Row
+		tagsPool   []parser.Tag
+		labels     []prompb.Label
+		relabelCtx relabel.Ctx
+		b          []byte
+	)
+	hasRelabeling := relabel.HasRelabeling()
+	timestamp := startTime.UnixNano() / 1e6
+	canonicalPaths := make([]string, 0, len(paths))
+	mrs := make([]storage.MetricRow, 0, len(paths))
+	for _, path := range paths {
+		var err error
+		tagsPool, err = row.UnmarshalMetricAndTags(path, tagsPool[:0])
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse path=%q: %w", path, err)
+		}
+
+		// Construct canonical path according to https://graphite.readthedocs.io/en/stable/tags.html#carbon
+		sort.Slice(row.Tags, func(i, j int) bool {
+			return row.Tags[i].Key < row.Tags[j].Key
+		})
+		b = append(b[:0], row.Metric...)
+		for _, tag := range row.Tags {
+			b = append(b, ';')
+			b = append(b, tag.Key...)
+			b = append(b, '=')
+			b = append(b, tag.Value...)
+		}
+		canonicalPaths = append(canonicalPaths, string(b))
+
+		labels = append(labels[:0], prompb.Label{
+			Value: bytesutil.ToUnsafeBytes(row.Metric),
+		})
+		for _, tag := range row.Tags {
+			labels = append(labels, prompb.Label{
+				Name:  bytesutil.ToUnsafeBytes(tag.Key),
+				Value: bytesutil.ToUnsafeBytes(tag.Value),
+			})
+		}
+		if hasRelabeling {
+			relabelCtx.Reset()
+			labels = relabelCtx.ApplyRelabeling(labels)
+			if len(labels) == 0 {
+				// The path has been dropped by relabeling.
+				continue
+			}
+		}
+		mrs = append(mrs, storage.MetricRow{
+			MetricNameRaw: storage.MarshalMetricNameRaw(nil, labels),
+			Timestamp:     timestamp,
+		})
+	}
+	if err := vmstorage.RegisterMetricNames(mrs); err != nil {
+		return nil, fmt.Errorf("cannot register paths: %w", err)
+	}
+	return canonicalPaths, nil
+}
@@ -5,6 +5,8 @@ import (
 
 	"github.com/VictoriaMetrics/VictoriaMetrics/app/vminsert/common"
 	"github.com/VictoriaMetrics/VictoriaMetrics/app/vminsert/relabel"
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmstorage"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/metricsmetadata"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/prompbmarshal"
 	parserCommon "github.com/VictoriaMetrics/VictoriaMetrics/lib/protoparser/common"
 	parser "github.com/VictoriaMetrics/VictoriaMetrics/lib/protoparser/prometheus"
@@ -29,7 +31,8 @@ func InsertHandler(req *http.Request) error {
 	}
 	return writeconcurrencylimiter.Do(func() error {
 		isGzipped := req.Header.Get("Content-Encoding") == "gzip"
-		return parser.ParseStream(req.Body, defaultTimestamp, isGzipped, func(rows []parser.Row) error {
+		return parser.ParseStream(req.Body, defaultTimestamp, isGzipped, func(rows []parser.Row, metadata []parser.Metadata) error {
+			insertMetadata(metadata)
 			return insertRows(rows, extraLabels)
 		})
 	})
@@ -68,3 +71,20 @@ func insertRows(rows []parser.Row, extraLabels []prompbmarshal.Label) error {
 	rowsPerInsert.Update(float64(len(rows)))
 	return ctx.FlushBufs()
 }
+
+func insertMetadata(metadata []parser.Metadata) {
+	if len(metadata) == 0 {
+		return
+	}
+	rows := make([]metricsmetadata.Row, len(metadata))
+	for i := range metadata {
+		md := &metadata[i]
+		rows[i] = metricsmetadata.Row{
+			MetricFamilyName: md.Metric,
+			Type:             md.Type,
+			Help:             md.Help,
+			Unit:             md.Unit,
+		}
+	}
+	vmstorage.AddMetricsMetadata(rows)
+}
@@ -2,7 +2,9 @@ package prompush
 
 import (
 	"github.com/VictoriaMetrics/VictoriaMetrics/app/vminsert/common"
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmstorage"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logger"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/metricsmetadata"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/prompbmarshal"
 	"github.com/VictoriaMetrics/metrics"
 )
@@ -16,6 +18,8 @@ const maxRowsPerBlock = 10000
 
 // Push pushes wr to storage.
 func Push(wr *prompbmarshal.WriteRequest) {
+	pushMetadata(wr.Metadata)
+
 	ctx := common.GetInsertCtx()
 	defer common.PutInsertCtx(ctx)
 
@@ -70,3 +74,20 @@ func push(ctx *common.InsertCtx, tss []prompbmarshal.TimeSeries) {
 		logger.Errorf("cannot flush promscrape data to storage: %s", err)
 	}
 }
+
+func pushMetadata(metadata []prompbmarshal.MetricMetadata) {
+	if len(metadata) == 0 {
+		return
+	}
+	rows := make([]metricsmetadata.Row, len(metadata))
+	for i := range metadata {
+		md := &metadata[i]
+		rows[i] = metricsmetadata.Row{
+			MetricFamilyName: md.MetricFamilyName,
+			Type:             md.Type.String(),
+			Help:             md.Help,
+			Unit:             md.Unit,
+		}
+	}
+	vmstorage.AddMetricsMetadata(rows)
+}
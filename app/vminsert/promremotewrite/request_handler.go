@@ -5,6 +5,9 @@ import (
 
 	"github.com/VictoriaMetrics/VictoriaMetrics/app/vminsert/common"
 	"github.com/VictoriaMetrics/VictoriaMetrics/app/vminsert/relabel"
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmstorage"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/bytesutil"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/metricsmetadata"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/prompb"
 	parser "github.com/VictoriaMetrics/VictoriaMetrics/lib/protoparser/promremotewrite"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/writeconcurrencylimiter"
@@ -19,11 +22,13 @@ var (
 // InsertHandler processes remote write for prometheus.
 func InsertHandler(req *http.Request) error {
 	return writeconcurrencylimiter.Do(func() error {
-		return parser.ParseStream(req, insertRows)
+		return parser.ParseStream(req.Body, insertRows)
 	})
 }
 
-func insertRows(timeseries []prompb.TimeSeries) error {
+func insertRows(timeseries []prompb.TimeSeries, metadata []prompb.MetricMetadata) error {
+	insertMetadata(metadata)
+
 	ctx := common.GetInsertCtx()
 	defer common.PutInsertCtx(ctx)
 
@@ -63,3 +68,20 @@ func insertRows(timeseries []prompb.TimeSeries) error {
 	rowsPerInsert.Update(float64(rowsTotal))
 	return ctx.FlushBufs()
 }
+
+func insertMetadata(metadata []prompb.MetricMetadata) {
+	if len(metadata) == 0 {
+		return
+	}
+	rows := make([]metricsmetadata.Row, len(metadata))
+	for i := range metadata {
+		md := &metadata[i]
+		rows[i] = metricsmetadata.Row{
+			MetricFamilyName: bytesutil.ToUnsafeString(md.MetricFamilyName),
+			Type:             md.Type.String(),
+			Help:             bytesutil.ToUnsafeString(md.Help),
+			Unit:             bytesutil.ToUnsafeString(md.Unit),
+		}
+	}
+	vmstorage.AddMetricsMetadata(rows)
+}
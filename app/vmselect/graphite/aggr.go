@@ -0,0 +1,212 @@
+package graphite
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// aggrFunc aggregates values into a single value.
+//
+// NaN values must be ignored. NaN must be returned if values contain only NaNs.
+type aggrFunc func(values []float64) float64
+
+var aggrFuncs = map[string]aggrFunc{
+	"average":  aggrAvg,
+	"avg":      aggrAvg,
+	"sum":      aggrSum,
+	"total":    aggrSum,
+	"min":      aggrMin,
+	"max":      aggrMax,
+	"median":   aggrMedian,
+	"count":    aggrCount,
+	"diff":     aggrDiff,
+	"multiply": aggrMultiply,
+	"range":    aggrRange,
+	"rangeOf":  aggrRange,
+	"first":    aggrFirst,
+	"last":     aggrLast,
+	"current":  aggrLast,
+}
+
+func getAggrFunc(name string) (aggrFunc, error) {
+	af := aggrFuncs[name]
+	if af == nil {
+		return nil, fmt.Errorf("unsupported aggregate function %q", name)
+	}
+	return af, nil
+}
+
+func aggrAvg(values []float64) float64 {
+	sum := float64(0)
+	n := 0
+	for _, v := range values {
+		if math.IsNaN(v) {
+			continue
+		}
+		sum += v
+		n++
+	}
+	if n == 0 {
+		return nan
+	}
+	return sum / float64(n)
+}
+
+func aggrSum(values []float64) float64 {
+	sum := float64(0)
+	n := 0
+	for _, v := range values {
+		if math.IsNaN(v) {
+			continue
+		}
+		sum += v
+		n++
+	}
+	if n == 0 {
+		return nan
+	}
+	return sum
+}
+
+func aggrMin(values []float64) float64 {
+	min := nan
+	for _, v := range values {
+		if math.IsNaN(v) {
+			continue
+		}
+		if math.IsNaN(min) || v < min {
+			min = v
+		}
+	}
+	return min
+}
+
+func aggrMax(values []float64) float64 {
+	max := nan
+	for _, v := range values {
+		if math.IsNaN(v) {
+			continue
+		}
+		if math.IsNaN(max) || v > max {
+			max = v
+		}
+	}
+	return max
+}
+
+func aggrMedian(values []float64) float64 {
+	a := make([]float64, 0, len(values))
+	for _, v := range values {
+		if !math.IsNaN(v) {
+			a = append(a, v)
+		}
+	}
+	if len(a) == 0 {
+		return nan
+	}
+	sort.Float64s(a)
+	n := len(a) / 2
+	if len(a)%2 == 1 {
+		return a[n]
+	}
+	return (a[n-1] + a[n]) / 2
+}
+
+func aggrCount(values []float64) float64 {
+	n := 0
+	for _, v := range values {
+		if !math.IsNaN(v) {
+			n++
+		}
+	}
+	if n == 0 {
+		return nan
+	}
+	return float64(n)
+}
+
+func aggrDiff(values []float64) float64 {
+	diff := nan
+	for _, v := range values {
+		if math.IsNaN(v) {
+			continue
+		}
+		if math.IsNaN(diff) {
+			diff = v
+		} else {
+			diff -= v
+		}
+	}
+	return diff
+}
+
+func aggrMultiply(values []float64) float64 {
+	result := nan
+	for _, v := range values {
+		if math.IsNaN(v) {
+			continue
+		}
+		if math.IsNaN(result) {
+			result = v
+		} else {
+			result *= v
+		}
+	}
+	return result
+}
+
+func aggrRange(values []float64) float64 {
+	return aggrMax(values) - aggrMin(values)
+}
+
+func aggrFirst(values []float64) float64 {
+	for _, v := range values {
+		if !math.IsNaN(v) {
+			return v
+		}
+	}
+	return nan
+}
+
+func aggrLast(values []float64) float64 {
+	for i := len(values) - 1; i >= 0; i-- {
+		if !math.IsNaN(values[i]) {
+			return values[i]
+		}
+	}
+	return nan
+}
+
+// aggregateSeries aggregates ss into a single series with the given name using af.
+//
+// All the ss must have identical timestamps.
+func aggregateSeries(ss []*series, name string, af aggrFunc) (*series, error) {
+	if len(ss) == 0 {
+		return nil, nil
+	}
+	timestamps := ss[0].Timestamps
+	for _, s := range ss[1:] {
+		if len(s.Timestamps) != len(timestamps) || len(s.Timestamps) > 0 && s.Timestamps[0] != timestamps[0] {
+			return nil, fmt.Errorf("cannot aggregate series with distinct timestamps: %q and %q", ss[0].Name, s.Name)
+		}
+	}
+	values := make([]float64, len(timestamps))
+	a := make([]float64, len(ss))
+	for i := range values {
+		for j, s := range ss {
+			a[j] = s.Values[i]
+		}
+		values[i] = af(a)
+	}
+	return &series{
+		Name: name,
+		Tags: map[string]string{
+			"name": name,
+		},
+		Timestamps:      append([]int64{}, timestamps...),
+		Values:          values,
+		pathExpression:  name,
+		consolidateFunc: ss[0].consolidateFunc,
+	}, nil
+}
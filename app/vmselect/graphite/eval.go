@@ -0,0 +1,353 @@
+package graphite
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmselect/graphiteql"
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmselect/netstorage"
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmselect/searchutils"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/storage"
+)
+
+// evalConfig is the configuration for evaluating Graphite render API targets.
+type evalConfig struct {
+	// startTime and endTime are aligned to storageStep.
+	startTime int64
+	endTime   int64
+
+	// storageStep is the interval between points in the series returned from the storage.
+	storageStep int64
+
+	deadline searchutils.Deadline
+}
+
+// pointsLen returns the number of points with the given step in the ec time range.
+func (ec *evalConfig) pointsLen(step int64) int {
+	return int((ec.endTime-ec.startTime)/step) + 1
+}
+
+// newTimestamps returns timestamps with the given step for the ec time range.
+func (ec *evalConfig) newTimestamps(step int64) []int64 {
+	pointsLen := ec.pointsLen(step)
+	timestamps := make([]int64, pointsLen)
+	ts := ec.startTime
+	for i := range timestamps {
+		timestamps[i] = ts
+		ts += step
+	}
+	return timestamps
+}
+
+// series is a single Graphite series.
+type series struct {
+	Name string
+	Tags map[string]string
+
+	Timestamps []int64
+
+	// Values contain NaN for missing points.
+	Values []float64
+
+	// pathExpression is the metric path expression the series was selected with.
+	pathExpression string
+
+	// consolidateFunc is used for consolidating series points when the number of points exceeds maxDataPoints.
+	consolidateFunc aggrFunc
+}
+
+// rename sets s name to the given name and updates the name tag.
+func (s *series) rename(name string) {
+	s.Name = name
+	s.Tags = copyTags(s.Tags)
+	s.Tags["name"] = name
+}
+
+func copyTags(tags map[string]string) map[string]string {
+	m := make(map[string]string, len(tags))
+	for k, v := range tags {
+		m[k] = v
+	}
+	return m
+}
+
+func evalExpr(ec *evalConfig, expr graphiteql.Expr) ([]*series, error) {
+	switch t := expr.(type) {
+	case *graphiteql.MetricExpr:
+		return evalMetricExpr(ec, t)
+	case *graphiteql.FuncExpr:
+		tf := getTransformFunc(t.FuncName)
+		if tf == nil {
+			return nil, fmt.Errorf("unsupported function %q", t.FuncName)
+		}
+		ss, err := tf(ec, t)
+		if err != nil {
+			return nil, fmt.Errorf("cannot evaluate %s: %w", t.AppendString(nil), err)
+		}
+		return ss, nil
+	default:
+		return nil, fmt.Errorf("unexpected expression %s; want series expression", expr.AppendString(nil))
+	}
+}
+
+func evalMetricExpr(ec *evalConfig, me *graphiteql.MetricExpr) ([]*series, error) {
+	query := me.Query
+	tf := storage.TagFilter{
+		Value: []byte(query),
+	}
+	if strings.IndexAny(query, "*{[") >= 0 {
+		re, err := getRegexpForQuery(query, '.')
+		if err != nil {
+			return nil, fmt.Errorf("cannot convert query %q to regexp: %w", query, err)
+		}
+		tf.Value = []byte(re.String())
+		tf.IsRegexp = true
+	}
+	return fetchSeries(ec, []storage.TagFilter{tf}, query)
+}
+
+// fetchSeries returns series matching tfs on the ec time range.
+//
+// pathExpression is set to the returned series.
+func fetchSeries(ec *evalConfig, tfs []storage.TagFilter, pathExpression string) ([]*series, error) {
+	sq := &storage.SearchQuery{
+		// Fetch an additional step before the startTime in order to fill the first point.
+		MinTimestamp: ec.startTime - ec.storageStep,
+		MaxTimestamp: ec.endTime,
+		TagFilterss:  [][]storage.TagFilter{tfs},
+	}
+	rss, err := netstorage.ProcessSearchQuery(nil, sq, true, ec.deadline)
+	if err != nil {
+		return nil, fmt.Errorf("cannot fetch data for %q: %w", pathExpression, err)
+	}
+	var ssLock sync.Mutex
+	var ss []*series
+	err = rss.RunParallel(nil, func(rs *netstorage.Result, workerID uint) {
+		name, tags := getGraphiteNameAndTags(&rs.MetricName)
+		s := &series{
+			Name:           name,
+			Tags:           tags,
+			Timestamps:     ec.newTimestamps(ec.storageStep),
+			pathExpression: pathExpression,
+		}
+		s.Values = consolidateSamples(s.Timestamps, ec.storageStep, rs.Timestamps, rs.Values)
+		ssLock.Lock()
+		ss = append(ss, s)
+		ssLock.Unlock()
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error when fetching data for %q: %w", pathExpression, err)
+	}
+	sortSeriesByName(ss)
+	return ss, nil
+}
+
+// getGraphiteNameAndTags returns Graphite name and tags for the given mn.
+//
+// Graphite name for series with tags is `name;tag1=value1;...;tagN=valueN`.
+func getGraphiteNameAndTags(mn *storage.MetricName) (string, map[string]string) {
+	name := string(mn.MetricGroup)
+	tags := make(map[string]string, len(mn.Tags)+1)
+	tags["name"] = name
+	if len(mn.Tags) == 0 {
+		return name, tags
+	}
+	var b []byte
+	b = append(b, name...)
+	for _, tag := range mn.Tags {
+		b = append(b, ';')
+		b = append(b, tag.Key...)
+		b = append(b, '=')
+		b = append(b, tag.Value...)
+		tags[string(tag.Key)] = string(tag.Value)
+	}
+	return string(b), tags
+}
+
+// consolidateSamples returns values for the given timestamps with the given step from the raw samples.
+//
+// The point at timestamp t contains the average for raw samples on the time range (t-step ... t].
+// NaN is returned for points without raw samples.
+func consolidateSamples(timestamps []int64, step int64, srcTimestamps []int64, srcValues []float64) []float64 {
+	values := make([]float64, len(timestamps))
+	counts := make([]int, len(timestamps))
+	if len(timestamps) == 0 {
+		return values
+	}
+	startTime := timestamps[0]
+	for i, ts := range srcTimestamps {
+		v := srcValues[i]
+		if math.IsNaN(v) {
+			continue
+		}
+		if ts <= startTime-step {
+			continue
+		}
+		idx := int((ts - startTime + step - 1) / step)
+		if ts < startTime {
+			idx = 0
+		}
+		if idx >= len(values) {
+			break
+		}
+		values[idx] += v
+		counts[idx]++
+	}
+	for i, n := range counts {
+		if n == 0 {
+			values[i] = nan
+		} else {
+			values[i] /= float64(n)
+		}
+	}
+	return values
+}
+
+var nan = math.NaN()
+
+func sortSeriesByName(ss []*series) {
+	sort.Slice(ss, func(i, j int) bool {
+		return ss[i].Name < ss[j].Name
+	})
+}
+
+// getArg returns the function arg with the given name or at the given position idx.
+//
+// nil is returned if the arg is missing.
+func getArg(fe *graphiteql.FuncExpr, name string, idx int) *graphiteql.ArgExpr {
+	if idx < len(fe.Args) && fe.Args[idx].Name == "" {
+		return fe.Args[idx]
+	}
+	for _, arg := range fe.Args {
+		if arg.Name == name {
+			return arg
+		}
+	}
+	return nil
+}
+
+func getSeriesArg(ec *evalConfig, fe *graphiteql.FuncExpr, name string, idx int) ([]*series, error) {
+	arg := getArg(fe, name, idx)
+	if arg == nil {
+		return nil, fmt.Errorf("missing %q arg", name)
+	}
+	return evalExpr(ec, arg.Expr)
+}
+
+// getSeriesListArgs returns series for all the positional args starting from the given startIdx.
+func getSeriesListArgs(ec *evalConfig, fe *graphiteql.FuncExpr, startIdx int) ([]*series, error) {
+	var ssAll []*series
+	for i := startIdx; i < len(fe.Args); i++ {
+		arg := fe.Args[i]
+		if arg.Name != "" {
+			break
+		}
+		ss, err := evalExpr(ec, arg.Expr)
+		if err != nil {
+			return nil, err
+		}
+		ssAll = append(ssAll, ss...)
+	}
+	return ssAll, nil
+}
+
+func getNumberArg(fe *graphiteql.FuncExpr, name string, idx int) (float64, error) {
+	arg := getArg(fe, name, idx)
+	if arg == nil {
+		return 0, fmt.Errorf("missing %q arg", name)
+	}
+	ne, ok := arg.Expr.(*graphiteql.NumberExpr)
+	if !ok {
+		return 0, fmt.Errorf("%q arg must be a number; got %s", name, arg.Expr.AppendString(nil))
+	}
+	return ne.N, nil
+}
+
+func getOptionalNumberArg(fe *graphiteql.FuncExpr, name string, idx int, defaultValue float64) (float64, error) {
+	arg := getArg(fe, name, idx)
+	if arg == nil {
+		return defaultValue, nil
+	}
+	if _, ok := arg.Expr.(*graphiteql.NoneExpr); ok {
+		return defaultValue, nil
+	}
+	return getNumberArg(fe, name, idx)
+}
+
+func getIntArg(fe *graphiteql.FuncExpr, name string, idx int) (int, error) {
+	n, err := getNumberArg(fe, name, idx)
+	if err != nil {
+		return 0, err
+	}
+	if n != math.Trunc(n) {
+		return 0, fmt.Errorf("%q arg must be integer; got %v", name, n)
+	}
+	return int(n), nil
+}
+
+func getOptionalIntArg(fe *graphiteql.FuncExpr, name string, idx int, defaultValue int) (int, error) {
+	arg := getArg(fe, name, idx)
+	if arg == nil {
+		return defaultValue, nil
+	}
+	return getIntArg(fe, name, idx)
+}
+
+func getStringArg(fe *graphiteql.FuncExpr, name string, idx int) (string, error) {
+	arg := getArg(fe, name, idx)
+	if arg == nil {
+		return "", fmt.Errorf("missing %q arg", name)
+	}
+	se, ok := arg.Expr.(*graphiteql.StringExpr)
+	if !ok {
+		return "", fmt.Errorf("%q arg must be a string; got %s", name, arg.Expr.AppendString(nil))
+	}
+	return se.S, nil
+}
+
+func getOptionalStringArg(fe *graphiteql.FuncExpr, name string, idx int, defaultValue string) (string, error) {
+	arg := getArg(fe, name, idx)
+	if arg == nil {
+		return defaultValue, nil
+	}
+	return getStringArg(fe, name, idx)
+}
+
+func getOptionalBoolArg(fe *graphiteql.FuncExpr, name string, idx int, defaultValue bool) (bool, error) {
+	arg := getArg(fe, name, idx)
+	if arg == nil {
+		return defaultValue, nil
+	}
+	be, ok := arg.Expr.(*graphiteql.BoolExpr)
+	if !ok {
+		return false, fmt.Errorf("%q arg must be a bool; got %s", name, arg.Expr.AppendString(nil))
+	}
+	return be.B, nil
+}
+
+// getNodeArgs returns node args starting from the given startIdx.
+//
+// Nodes can be either numbers for path nodes or strings for tag names.
+func getNodeArgs(fe *graphiteql.FuncExpr, startIdx int) ([]graphiteql.Expr, error) {
+	var nodes []graphiteql.Expr
+	for i := startIdx; i < len(fe.Args); i++ {
+		arg := fe.Args[i]
+		if arg.Name != "" {
+			break
+		}
+		switch t := arg.Expr.(type) {
+		case *graphiteql.NumberExpr:
+			if t.N != math.Trunc(t.N) {
+				return nil, fmt.Errorf("node number must be integer; got %v", t.N)
+			}
+		case *graphiteql.StringExpr:
+		default:
+			return nil, fmt.Errorf("node must be either a number or a tag name; got %s", arg.Expr.AppendString(nil))
+		}
+		nodes = append(nodes, arg.Expr)
+	}
+	return nodes, nil
+}
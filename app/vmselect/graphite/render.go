@@ -0,0 +1,222 @@
+package graphite
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmselect/graphiteql"
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmselect/promql"
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmselect/searchutils"
+	"github.com/VictoriaMetrics/metrics"
+)
+
+var storageStep = flag.Duration("search.graphiteStorageStep", 10*time.Second, "The interval between datapoints stored in the database. "+
+	"It is used at Graphite Render API handler for normalizing the interval between datapoints in case it isn't normalized. "+
+	"It can be overridden by sending 'storage_step' query arg to /render API")
+
+// RenderHandler implements /render handler.
+//
+// See https://graphite.readthedocs.io/en/stable/render_api.html
+func RenderHandler(startTime time.Time, w http.ResponseWriter, r *http.Request) error {
+	deadline := searchutils.GetDeadlineForQuery(r, startTime)
+	if err := r.ParseForm(); err != nil {
+		return fmt.Errorf("cannot parse form values: %w", err)
+	}
+	format := r.FormValue("format")
+	if format == "" {
+		format = "json"
+	}
+	if format != "json" {
+		return fmt.Errorf(`unsupported "format" query arg: %q; expecting "json"`, format)
+	}
+	targets := r.Form["target"]
+	if len(targets) == 0 {
+		return fmt.Errorf("missing `target` arg")
+	}
+	ct := startTime.UnixNano() / 1e6
+	from, err := getGraphiteTime(r, "from", ct, ct-24*3600*1000)
+	if err != nil {
+		return err
+	}
+	until, err := getGraphiteTime(r, "until", ct, ct)
+	if err != nil {
+		return err
+	}
+	step, err := searchutils.GetDuration(r, "storage_step", storageStep.Milliseconds())
+	if err != nil {
+		return err
+	}
+	if step <= 0 {
+		return fmt.Errorf("`storage_step` must be positive; got %dms", step)
+	}
+	maxDataPoints := 0
+	if s := r.FormValue("maxDataPoints"); s != "" {
+		n, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return fmt.Errorf("cannot parse `maxDataPoints=%q`: %w", s, err)
+		}
+		maxDataPoints = int(n)
+	}
+	jsonp := r.FormValue("jsonp")
+
+	// Align the time range to step, so the points for all the series are located at the same timestamps.
+	from -= from % step
+	until -= until % step
+	if from > until {
+		return fmt.Errorf("`from`=%d cannot exceed `until`=%d", from/1e3, until/1e3)
+	}
+	if err := promql.ValidateMaxPointsPerTimeseries(from, until, step); err != nil {
+		return err
+	}
+	ec := &evalConfig{
+		startTime:   from,
+		endTime:     until,
+		storageStep: step,
+		deadline:    deadline,
+	}
+	var ss []*series
+	for _, target := range targets {
+		expr, err := graphiteql.Parse(target)
+		if err != nil {
+			return fmt.Errorf("cannot parse target=%q: %w", target, err)
+		}
+		ssTarget, err := evalExpr(ec, expr)
+		if err != nil {
+			return fmt.Errorf("cannot evaluate target=%q: %w", target, err)
+		}
+		ss = append(ss, ssTarget...)
+	}
+	if maxDataPoints > 0 {
+		for _, s := range ss {
+			s.Timestamps, s.Values = consolidateMaxDataPoints(s.Timestamps, s.Values, maxDataPoints, s.consolidateFunc)
+		}
+	}
+
+	contentType := "application/json"
+	if jsonp != "" {
+		contentType = "text/javascript"
+	}
+	w.Header().Set("Content-Type", contentType)
+	WriteRenderJSONResponse(w, ss, jsonp)
+	renderDuration.UpdateDuration(startTime)
+	return nil
+}
+
+var renderDuration = metrics.NewSummary(`vm_request_duration_seconds{path="/render"}`)
+
+// consolidateMaxDataPoints consolidates points with af, so their number doesn't exceed maxDataPoints.
+//
+// The average is used if af is nil.
+func consolidateMaxDataPoints(timestamps []int64, values []float64, maxDataPoints int, af aggrFunc) ([]int64, []float64) {
+	if len(values) <= maxDataPoints {
+		return timestamps, values
+	}
+	if af == nil {
+		af = aggrAvg
+	}
+	valuesPerPoint := (len(values) + maxDataPoints - 1) / maxDataPoints
+	dstTimestamps := timestamps[:0]
+	dstValues := values[:0]
+	for i := 0; i < len(values); i += valuesPerPoint {
+		j := i + valuesPerPoint
+		if j > len(values) {
+			j = len(values)
+		}
+		ts := timestamps[i]
+		v := af(values[i:j])
+		dstTimestamps = append(dstTimestamps, ts)
+		dstValues = append(dstValues, v)
+	}
+	return dstTimestamps, dstValues
+}
+
+// getGraphiteTime returns time in milliseconds from the given argKey query arg.
+//
+// See https://graphite.readthedocs.io/en/stable/render_api.html#from-until
+func getGraphiteTime(r *http.Request, argKey string, currentTime, defaultValue int64) (int64, error) {
+	s := r.FormValue(argKey)
+	if s == "" {
+		return defaultValue, nil
+	}
+	t, err := parseGraphiteTime(s, currentTime)
+	if err != nil {
+		return 0, fmt.Errorf("cannot parse %q=%q: %w", argKey, s, err)
+	}
+	return t, nil
+}
+
+// parseGraphiteTime parses Graphite time s relative to currentTime.
+//
+// The following formats are supported:
+//
+//   - `now`
+//   - relative time such as `-1h` or `now-5min`
+//   - unix timestamp in seconds
+//   - `YYYYMMDD` and `HH:MM_YYYYMMDD`
+func parseGraphiteTime(s string, currentTime int64) (int64, error) {
+	if s == "now" {
+		return currentTime, nil
+	}
+	tail := strings.TrimPrefix(s, "now")
+	if strings.HasPrefix(tail, "-") || strings.HasPrefix(tail, "+") {
+		d, err := parseInterval(tail[1:])
+		if err != nil {
+			return 0, err
+		}
+		if tail[0] == '-' {
+			d = -d
+		}
+		return currentTime + d, nil
+	}
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil && len(s) != len("YYYYMMDD") {
+		return n * 1e3, nil
+	}
+	for _, layout := range []string{"20060102", "15:04_20060102"} {
+		if t, err := time.ParseInLocation(layout, s, time.UTC); err == nil {
+			return t.UnixNano() / 1e6, nil
+		}
+	}
+	return 0, fmt.Errorf("unsupported time format; supported formats: `now`, `-1h`, `now-5min`, unix timestamp in seconds, `YYYYMMDD`, `HH:MM_YYYYMMDD`")
+}
+
+// parseInterval parses Graphite interval such as `5min` or `1d` and returns it in milliseconds.
+//
+// See https://graphite.readthedocs.io/en/stable/render_api.html#from-until
+func parseInterval(s string) (int64, error) {
+	n := 0
+	for n < len(s) && (s[n] >= '0' && s[n] <= '9' || s[n] == '.') {
+		n++
+	}
+	if n == 0 {
+		return 0, fmt.Errorf("missing number in interval %q", s)
+	}
+	f, err := strconv.ParseFloat(s[:n], 64)
+	if err != nil {
+		return 0, fmt.Errorf("cannot parse number in interval %q: %w", s, err)
+	}
+	unit := s[n:]
+	var msecs int64
+	switch {
+	case unit == "s" || strings.HasPrefix(unit, "sec"):
+		msecs = 1000
+	case unit == "m" || strings.HasPrefix(unit, "min"):
+		msecs = 60 * 1000
+	case unit == "h" || strings.HasPrefix(unit, "hour"):
+		msecs = 3600 * 1000
+	case unit == "d" || strings.HasPrefix(unit, "day"):
+		msecs = 24 * 3600 * 1000
+	case unit == "w" || strings.HasPrefix(unit, "week"):
+		msecs = 7 * 24 * 3600 * 1000
+	case strings.HasPrefix(unit, "mon"):
+		msecs = 30 * 24 * 3600 * 1000
+	case unit == "y" || strings.HasPrefix(unit, "year"):
+		msecs = 365 * 24 * 3600 * 1000
+	default:
+		return 0, fmt.Errorf("unsupported unit %q in interval %q; supported units: s, min, h, d, w, mon, y", unit, s)
+	}
+	return int64(f * float64(msecs)), nil
+}
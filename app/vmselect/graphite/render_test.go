@@ -0,0 +1,158 @@
+package graphite
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestParseIntervalSuccess(t *testing.T) {
+	f := func(s string, resultExpected int64) {
+		t.Helper()
+		result, err := parseInterval(s)
+		if err != nil {
+			t.Fatalf("unexpected error when parsing %q: %s", s, err)
+		}
+		if result != resultExpected {
+			t.Fatalf("unexpected result for parseInterval(%q); got %d; want %d", s, result, resultExpected)
+		}
+	}
+	f("1s", 1000)
+	f("10sec", 10*1000)
+	f("5min", 5*60*1000)
+	f("5m", 5*60*1000)
+	f("2minutes", 2*60*1000)
+	f("1h", 3600*1000)
+	f("1.5hours", 5400*1000)
+	f("1d", 24*3600*1000)
+	f("2w", 14*24*3600*1000)
+	f("1mon", 30*24*3600*1000)
+	f("1y", 365*24*3600*1000)
+}
+
+func TestParseIntervalFailure(t *testing.T) {
+	f := func(s string) {
+		t.Helper()
+		if _, err := parseInterval(s); err == nil {
+			t.Fatalf("expecting non-nil error when parsing %q", s)
+		}
+	}
+	f("")
+	f("h")
+	f("1")
+	f("1foo")
+	f("1.2.3h")
+}
+
+func TestParseGraphiteTimeSuccess(t *testing.T) {
+	const currentTime = 1600000000000
+	f := func(s string, resultExpected int64) {
+		t.Helper()
+		result, err := parseGraphiteTime(s, currentTime)
+		if err != nil {
+			t.Fatalf("unexpected error when parsing %q: %s", s, err)
+		}
+		if result != resultExpected {
+			t.Fatalf("unexpected result for parseGraphiteTime(%q); got %d; want %d", s, result, resultExpected)
+		}
+	}
+	f("now", currentTime)
+	f("-1h", currentTime-3600*1000)
+	f("now-5min", currentTime-5*60*1000)
+	f("now+1d", currentTime+24*3600*1000)
+	f("1234567890", 1234567890*1000)
+	f("20200102", 1577923200*1000)
+	f("12:30_20200102", 1577968200*1000)
+}
+
+func TestParseGraphiteTimeFailure(t *testing.T) {
+	f := func(s string) {
+		t.Helper()
+		if _, err := parseGraphiteTime(s, 0); err == nil {
+			t.Fatalf("expecting non-nil error when parsing %q", s)
+		}
+	}
+	f("foobar")
+	f("-1foo")
+	f("now-")
+	f("20201350")
+}
+
+func TestConsolidateSamples(t *testing.T) {
+	f := func(timestamps []int64, step int64, srcTimestamps []int64, srcValues []float64, valuesExpected []float64) {
+		t.Helper()
+		values := consolidateSamples(timestamps, step, srcTimestamps, srcValues)
+		if !equalValues(values, valuesExpected) {
+			t.Fatalf("unexpected values; got %v; want %v", values, valuesExpected)
+		}
+	}
+	f(nil, 10, nil, nil, []float64{})
+	f([]int64{100, 110, 120}, 10, nil, nil, []float64{nan, nan, nan})
+	f([]int64{100, 110, 120}, 10, []int64{85, 90, 95, 100, 103, 107, 130}, []float64{1, 2, 3, 4, 5, 6, 7},
+		[]float64{3.5, 5.5, nan})
+	f([]int64{100, 110, 120}, 10, []int64{111, 112, 113}, []float64{1, nan, 3}, []float64{nan, nan, 2})
+}
+
+func TestConsolidateMaxDataPoints(t *testing.T) {
+	f := func(timestamps []int64, values []float64, maxDataPoints int, af aggrFunc, timestampsExpected []int64, valuesExpected []float64) {
+		t.Helper()
+		timestamps, values = consolidateMaxDataPoints(timestamps, values, maxDataPoints, af)
+		if !reflect.DeepEqual(timestamps, timestampsExpected) {
+			t.Fatalf("unexpected timestamps; got %v; want %v", timestamps, timestampsExpected)
+		}
+		if !equalValues(values, valuesExpected) {
+			t.Fatalf("unexpected values; got %v; want %v", values, valuesExpected)
+		}
+	}
+	f([]int64{1, 2, 3}, []float64{1, 2, 3}, 3, nil, []int64{1, 2, 3}, []float64{1, 2, 3})
+	f([]int64{1, 2, 3, 4, 5}, []float64{1, 2, 3, nan, 5}, 2, nil, []int64{1, 4}, []float64{2, 5})
+	f([]int64{1, 2, 3, 4, 5}, []float64{1, 2, 3, nan, 5}, 3, aggrMax, []int64{1, 3, 5}, []float64{2, 3, 5})
+}
+
+func equalValues(a, b []float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i, v := range a {
+		if math.IsNaN(v) != math.IsNaN(b[i]) {
+			return false
+		}
+		if !math.IsNaN(v) && v != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestRenderJSONResponse(t *testing.T) {
+	f := func(ss []*series, jsonp, resultExpected string) {
+		t.Helper()
+		result := RenderJSONResponse(ss, jsonp)
+		if result != resultExpected {
+			t.Fatalf("unexpected response;\ngot\n%s\nwant\n%s", result, resultExpected)
+		}
+	}
+	f(nil, "", `[]`)
+	f(nil, "cb", `cb([])`)
+	ss := []*series{
+		{
+			Name: "foo.bar",
+			Tags: map[string]string{
+				"name": "foo.bar",
+			},
+			Timestamps: []int64{10000, 20000},
+			Values:     []float64{1.5, nan},
+		},
+		{
+			Name: "sumSeries(x)",
+			Tags: map[string]string{
+				"name": "x",
+				"dc":   "a",
+			},
+			Timestamps: []int64{10000},
+			Values:     []float64{2},
+		},
+	}
+	f(ss, "", `[{"target":"foo.bar","tags":{"name":"foo.bar"},"datapoints":[[1.5,10],[null,20]]},`+
+		`{"target":"sumSeries(x)","tags":{"dc":"a","name":"x"},"datapoints":[[2,10]]}]`)
+}
@@ -0,0 +1,404 @@
+package graphite
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmselect/netstorage"
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmselect/searchutils"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/storage"
+	"github.com/VictoriaMetrics/metrics"
+)
+
+// TagsHandler implements /tags handler.
+//
+// See https://graphite.readthedocs.io/en/stable/tags.html#exploring-tags
+func TagsHandler(startTime time.Time, w http.ResponseWriter, r *http.Request) error {
+	deadline := searchutils.GetDeadlineForQuery(r, startTime)
+	if err := r.ParseForm(); err != nil {
+		return fmt.Errorf("cannot parse form values: %w", err)
+	}
+	limit, err := getInt(r, "limit", 0)
+	if err != nil {
+		return err
+	}
+	labels, err := netstorage.GetLabels(deadline)
+	if err != nil {
+		return fmt.Errorf("cannot obtain tags: %w", err)
+	}
+	tags := make([]string, 0, len(labels))
+	for _, label := range labels {
+		tags = append(tags, getGraphiteTagKey(label))
+	}
+	sort.Strings(tags)
+	tags, err = applyRegexpFilter(r.FormValue("filter"), tags)
+	if err != nil {
+		return err
+	}
+	tags = applyLimit(tags, limit)
+
+	w.Header().Set("Content-Type", "application/json")
+	WriteTagsResponse(w, tags)
+	tagsDuration.UpdateDuration(startTime)
+	return nil
+}
+
+var tagsDuration = metrics.NewSummary(`vm_request_duration_seconds{path="/tags"}`)
+
+// TagValuesHandler implements /tags/<tag_name> handler.
+//
+// See https://graphite.readthedocs.io/en/stable/tags.html#exploring-tags
+func TagValuesHandler(startTime time.Time, tagName string, w http.ResponseWriter, r *http.Request) error {
+	deadline := searchutils.GetDeadlineForQuery(r, startTime)
+	if err := r.ParseForm(); err != nil {
+		return fmt.Errorf("cannot parse form values: %w", err)
+	}
+	limit, err := getInt(r, "limit", 0)
+	if err != nil {
+		return err
+	}
+	values, err := netstorage.GetLabelValues(getStorageTagKey(tagName), deadline)
+	if err != nil {
+		return fmt.Errorf("cannot obtain values for tag %q: %w", tagName, err)
+	}
+	values, err = applyRegexpFilter(r.FormValue("filter"), values)
+	if err != nil {
+		return err
+	}
+	values = applyLimit(values, limit)
+
+	w.Header().Set("Content-Type", "application/json")
+	WriteTagValuesResponse(w, tagName, values)
+	tagValuesDuration.UpdateDuration(startTime)
+	return nil
+}
+
+var tagValuesDuration = metrics.NewSummary(`vm_request_duration_seconds{path="/tags/<tag_name>"}`)
+
+// TagsFindSeriesHandler implements /tags/findSeries handler.
+//
+// See https://graphite.readthedocs.io/en/stable/tags.html#exploring-tags
+func TagsFindSeriesHandler(startTime time.Time, w http.ResponseWriter, r *http.Request) error {
+	deadline := searchutils.GetDeadlineForQuery(r, startTime)
+	if err := r.ParseForm(); err != nil {
+		return fmt.Errorf("cannot parse form values: %w", err)
+	}
+	limit, err := getInt(r, "limit", 0)
+	if err != nil {
+		return err
+	}
+	exprs := r.Form["expr"]
+	if len(exprs) == 0 {
+		return fmt.Errorf("expecting at least one `expr` query arg")
+	}
+	mns, err := getMetricNamesForTagExprs(startTime, exprs, deadline)
+	if err != nil {
+		return err
+	}
+	paths := make([]string, 0, len(mns))
+	for i := range mns {
+		path, _ := getGraphiteNameAndTags(&mns[i])
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	paths = applyLimit(paths, limit)
+
+	w.Header().Set("Content-Type", "application/json")
+	WriteTagsFindSeriesResponse(w, paths)
+	tagsFindSeriesDuration.UpdateDuration(startTime)
+	return nil
+}
+
+var tagsFindSeriesDuration = metrics.NewSummary(`vm_request_duration_seconds{path="/tags/findSeries"}`)
+
+// TagsAutoCompleteTagsHandler implements /tags/autoComplete/tags handler.
+//
+// See https://graphite.readthedocs.io/en/stable/tags.html#auto-complete-support
+func TagsAutoCompleteTagsHandler(startTime time.Time, w http.ResponseWriter, r *http.Request) error {
+	deadline := searchutils.GetDeadlineForQuery(r, startTime)
+	if err := r.ParseForm(); err != nil {
+		return fmt.Errorf("cannot parse form values: %w", err)
+	}
+	limit, err := getInt(r, "limit", defaultAutoCompleteLimit)
+	if err != nil {
+		return err
+	}
+	tagPrefix := r.FormValue("tagPrefix")
+	exprs := r.Form["expr"]
+	var tags []string
+	if len(exprs) == 0 {
+		labels, err := netstorage.GetLabels(deadline)
+		if err != nil {
+			return fmt.Errorf("cannot obtain tags: %w", err)
+		}
+		for _, label := range labels {
+			tags = append(tags, getGraphiteTagKey(label))
+		}
+	} else {
+		mns, err := getMetricNamesForTagExprs(startTime, exprs, deadline)
+		if err != nil {
+			return err
+		}
+		// Tags used in exprs must be excluded from the response.
+		// See https://graphite.readthedocs.io/en/stable/tags.html#auto-complete-support
+		usedTags := make(map[string]bool, len(exprs))
+		for _, expr := range exprs {
+			tf, err := parseTagExpr(expr)
+			if err != nil {
+				return err
+			}
+			usedTags[getGraphiteTagKey(string(tf.Key))] = true
+		}
+		m := make(map[string]bool)
+		for i := range mns {
+			mn := &mns[i]
+			m["name"] = true
+			for _, tag := range mn.Tags {
+				m[string(tag.Key)] = true
+			}
+		}
+		for tag := range m {
+			if !usedTags[tag] {
+				tags = append(tags, tag)
+			}
+		}
+	}
+	tags = filterByPrefix(tags, tagPrefix)
+	sort.Strings(tags)
+	tags = applyLimit(tags, limit)
+
+	w.Header().Set("Content-Type", "application/json")
+	WriteTagsAutoCompleteResponse(w, tags)
+	tagsAutoCompleteTagsDuration.UpdateDuration(startTime)
+	return nil
+}
+
+var tagsAutoCompleteTagsDuration = metrics.NewSummary(`vm_request_duration_seconds{path="/tags/autoComplete/tags"}`)
+
+// TagsAutoCompleteValuesHandler implements /tags/autoComplete/values handler.
+//
+// See https://graphite.readthedocs.io/en/stable/tags.html#auto-complete-support
+func TagsAutoCompleteValuesHandler(startTime time.Time, w http.ResponseWriter, r *http.Request) error {
+	deadline := searchutils.GetDeadlineForQuery(r, startTime)
+	if err := r.ParseForm(); err != nil {
+		return fmt.Errorf("cannot parse form values: %w", err)
+	}
+	limit, err := getInt(r, "limit", defaultAutoCompleteLimit)
+	if err != nil {
+		return err
+	}
+	tag := r.FormValue("tag")
+	if len(tag) == 0 {
+		return fmt.Errorf("missing `tag` query arg")
+	}
+	valuePrefix := r.FormValue("valuePrefix")
+	exprs := r.Form["expr"]
+	var values []string
+	if len(exprs) == 0 {
+		values, err = netstorage.GetLabelValues(getStorageTagKey(tag), deadline)
+		if err != nil {
+			return fmt.Errorf("cannot obtain values for tag %q: %w", tag, err)
+		}
+	} else {
+		mns, err := getMetricNamesForTagExprs(startTime, exprs, deadline)
+		if err != nil {
+			return err
+		}
+		m := make(map[string]bool)
+		for i := range mns {
+			mn := &mns[i]
+			if tag == "name" {
+				m[string(mn.MetricGroup)] = true
+				continue
+			}
+			if v := mn.GetTagValue(tag); len(v) > 0 {
+				m[string(v)] = true
+			}
+		}
+		for value := range m {
+			values = append(values, value)
+		}
+	}
+	values = filterByPrefix(values, valuePrefix)
+	sort.Strings(values)
+	values = applyLimit(values, limit)
+
+	w.Header().Set("Content-Type", "application/json")
+	WriteTagsAutoCompleteResponse(w, values)
+	tagsAutoCompleteValuesDuration.UpdateDuration(startTime)
+	return nil
+}
+
+var tagsAutoCompleteValuesDuration = metrics.NewSummary(`vm_request_duration_seconds{path="/tags/autoComplete/values"}`)
+
+// defaultAutoCompleteLimit is the default limit for /tags/autoComplete/* responses.
+//
+// It matches the default TAGDB_AUTOCOMPLETE_LIMIT in graphite-web.
+const defaultAutoCompleteLimit = 100
+
+// getMetricNamesForTagExprs returns metric names matching the given Graphite tag exprs.
+func getMetricNamesForTagExprs(startTime time.Time, exprs []string, deadline searchutils.Deadline) ([]storage.MetricName, error) {
+	tfs, err := getTagFiltersForTagExprs(exprs)
+	if err != nil {
+		return nil, err
+	}
+	sq := &storage.SearchQuery{
+		MinTimestamp: 0,
+		MaxTimestamp: startTime.UnixNano() / 1e6,
+		TagFilterss:  [][]storage.TagFilter{tfs},
+	}
+	rss, err := netstorage.ProcessSearchQuery(nil, sq, false, deadline)
+	if err != nil {
+		return nil, fmt.Errorf("cannot fetch series for %q: %w", exprs, err)
+	}
+	var mnsLock sync.Mutex
+	var mns []storage.MetricName
+	err = rss.RunParallel(nil, func(rs *netstorage.Result, workerID uint) {
+		var mn storage.MetricName
+		mn.CopyFrom(&rs.MetricName)
+		mnsLock.Lock()
+		mns = append(mns, mn)
+		mnsLock.Unlock()
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error when fetching series for %q: %w", exprs, err)
+	}
+	return mns, nil
+}
+
+// getTagFiltersForTagExprs converts Graphite tag exprs into storage tag filters.
+//
+// At least one of exprs must match non-empty values, since otherwise it would select all the series in the storage.
+func getTagFiltersForTagExprs(exprs []string) ([]storage.TagFilter, error) {
+	tfs := make([]storage.TagFilter, 0, len(exprs))
+	hasPositiveFilter := false
+	for _, expr := range exprs {
+		tf, err := parseTagExpr(expr)
+		if err != nil {
+			return nil, err
+		}
+		if !tf.IsNegative && len(tf.Value) > 0 {
+			hasPositiveFilter = true
+		}
+		tfs = append(tfs, *tf)
+	}
+	if !hasPositiveFilter {
+		return nil, fmt.Errorf("at least one tag expression must match non-empty values; got %q", exprs)
+	}
+	return tfs, nil
+}
+
+// parseTagExpr parses Graphite tag expression such as `tag=value`, `tag!=value`, `tag=~regexp` or `tag!=~regexp`.
+//
+// See https://graphite.readthedocs.io/en/stable/tags.html#querying
+func parseTagExpr(expr string) (*storage.TagFilter, error) {
+	n := strings.IndexAny(expr, "!=")
+	if n <= 0 {
+		return nil, fmt.Errorf("cannot find tag name in tag expression %q; expecting `tag=value`, `tag!=value`, `tag=~regexp` or `tag!=~regexp`", expr)
+	}
+	tag := expr[:n]
+	tail := expr[n:]
+	tf := &storage.TagFilter{
+		Key: []byte(tag),
+	}
+	if tag == "name" {
+		tf.Key = nil
+	}
+	switch {
+	case strings.HasPrefix(tail, "!=~"):
+		tf.IsNegative = true
+		tf.IsRegexp = true
+		tail = tail[len("!=~"):]
+	case strings.HasPrefix(tail, "=~"):
+		tf.IsRegexp = true
+		tail = tail[len("=~"):]
+	case strings.HasPrefix(tail, "!="):
+		tf.IsNegative = true
+		tail = tail[len("!="):]
+	case strings.HasPrefix(tail, "="):
+		tail = tail[len("="):]
+	default:
+		return nil, fmt.Errorf("unsupported operator in tag expression %q; supported operators: `=`, `!=`, `=~`, `!=~`", expr)
+	}
+	if tf.IsRegexp && len(tail) > 0 {
+		// Graphite regexps are anchored only at the start, while storage regexps are anchored at both ends.
+		if _, err := regexp.Compile(tail); err != nil {
+			return nil, fmt.Errorf("cannot parse regexp in tag expression %q: %w", expr, err)
+		}
+		tail = "(?:" + tail + ").*"
+	}
+	tf.Value = []byte(tail)
+	return tf, nil
+}
+
+// getGraphiteTagKey returns Graphite tag key for the given storage label name.
+func getGraphiteTagKey(labelName string) string {
+	if labelName == "" || labelName == "__name__" {
+		return "name"
+	}
+	return labelName
+}
+
+// getStorageTagKey returns storage label name for the given Graphite tag key.
+func getStorageTagKey(tagKey string) string {
+	if tagKey == "name" {
+		return "__name__"
+	}
+	return tagKey
+}
+
+func applyRegexpFilter(filter string, ss []string) ([]string, error) {
+	if len(filter) == 0 {
+		return ss, nil
+	}
+	// Graphite regexps are anchored only at the start.
+	re, err := regexp.Compile("^(?:" + filter + ")")
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse regexp filter=%q: %w", filter, err)
+	}
+	dst := ss[:0]
+	for _, s := range ss {
+		if re.MatchString(s) {
+			dst = append(dst, s)
+		}
+	}
+	return dst, nil
+}
+
+func filterByPrefix(ss []string, prefix string) []string {
+	if len(prefix) == 0 {
+		return ss
+	}
+	dst := ss[:0]
+	for _, s := range ss {
+		if strings.HasPrefix(s, prefix) {
+			dst = append(dst, s)
+		}
+	}
+	return dst
+}
+
+func applyLimit(ss []string, limit int) []string {
+	if limit > 0 && limit < len(ss) {
+		ss = ss[:limit]
+	}
+	return ss
+}
+
+func getInt(r *http.Request, argName string, defaultValue int) (int, error) {
+	s := r.FormValue(argName)
+	if len(s) == 0 {
+		return defaultValue, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("cannot parse %q=%q: %w", argName, s, err)
+	}
+	return n, nil
+}
@@ -0,0 +1,124 @@
+package graphite
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/storage"
+)
+
+func TestParseTagExprSuccess(t *testing.T) {
+	f := func(expr string, tfExpected *storage.TagFilter) {
+		t.Helper()
+		tf, err := parseTagExpr(expr)
+		if err != nil {
+			t.Fatalf("unexpected error when parsing %q: %s", expr, err)
+		}
+		if !reflect.DeepEqual(tf, tfExpected) {
+			t.Fatalf("unexpected tag filter for %q;\ngot\n%+v\nwant\n%+v", expr, tf, tfExpected)
+		}
+	}
+	f("name=foo.bar", &storage.TagFilter{
+		Value: []byte("foo.bar"),
+	})
+	f("dc=us-east", &storage.TagFilter{
+		Key:   []byte("dc"),
+		Value: []byte("us-east"),
+	})
+	f("dc!=us-east", &storage.TagFilter{
+		Key:        []byte("dc"),
+		Value:      []byte("us-east"),
+		IsNegative: true,
+	})
+	f("dc=~us|eu", &storage.TagFilter{
+		Key:      []byte("dc"),
+		Value:    []byte("(?:us|eu).*"),
+		IsRegexp: true,
+	})
+	f("name!=~foo", &storage.TagFilter{
+		Value:      []byte("(?:foo).*"),
+		IsNegative: true,
+		IsRegexp:   true,
+	})
+	f("dc=", &storage.TagFilter{
+		Key:   []byte("dc"),
+		Value: []byte{},
+	})
+	f("dc!=", &storage.TagFilter{
+		Key:        []byte("dc"),
+		Value:      []byte{},
+		IsNegative: true,
+	})
+}
+
+func TestParseTagExprFailure(t *testing.T) {
+	f := func(expr string) {
+		t.Helper()
+		if _, err := parseTagExpr(expr); err == nil {
+			t.Fatalf("expecting non-nil error when parsing %q", expr)
+		}
+	}
+	f("")
+	f("foo")
+	f("=foo")
+	f("dc!foo")
+	f("dc=~(foo")
+}
+
+func TestGetTagFiltersForTagExprs(t *testing.T) {
+	f := func(exprs []string, resultExpected bool) {
+		t.Helper()
+		_, err := getTagFiltersForTagExprs(exprs)
+		if result := err == nil; result != resultExpected {
+			t.Fatalf("unexpected result for %q; got %v; want %v; err: %v", exprs, result, resultExpected, err)
+		}
+	}
+	f([]string{"name=foo"}, true)
+	f([]string{"dc!=us", "name=~foo"}, true)
+	f([]string{"dc=", "env=prod"}, true)
+
+	// Missing positive filters
+	f(nil, false)
+	f([]string{"dc!=us"}, false)
+	f([]string{"dc="}, false)
+	f([]string{"dc!=~us", "env!="}, false)
+
+	// Invalid expression
+	f([]string{"name=foo", "bar"}, false)
+}
+
+func TestApplyRegexpFilter(t *testing.T) {
+	f := func(filter string, ss, resultExpected []string) {
+		t.Helper()
+		result, err := applyRegexpFilter(filter, ss)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if !reflect.DeepEqual(result, resultExpected) {
+			t.Fatalf("unexpected result for filter=%q; got %q; want %q", filter, result, resultExpected)
+		}
+	}
+	f("", []string{"foo", "bar"}, []string{"foo", "bar"})
+	f("fo", []string{"foo", "bar", "xfoo"}, []string{"foo"})
+	f("b.r|x", []string{"foo", "bar", "xfoo"}, []string{"bar", "xfoo"})
+	f("baz", []string{"foo", "bar"}, []string{})
+
+	if _, err := applyRegexpFilter("(", nil); err == nil {
+		t.Fatalf("expecting non-nil error for invalid filter")
+	}
+}
+
+func TestTagsResponses(t *testing.T) {
+	f := func(result, resultExpected string) {
+		t.Helper()
+		if result != resultExpected {
+			t.Fatalf("unexpected response;\ngot\n%s\nwant\n%s", result, resultExpected)
+		}
+	}
+	f(TagsResponse(nil), `[]`)
+	f(TagsResponse([]string{"dc", "name"}), `[{"tag":"dc"},{"tag":"name"}]`)
+	f(TagValuesResponse("dc", nil), `{"tag":"dc","values":[]}`)
+	f(TagValuesResponse("dc", []string{"eu", "us"}), `{"tag":"dc","values":[{"count":1,"value":"eu"},{"count":1,"value":"us"}]}`)
+	f(TagsFindSeriesResponse([]string{"foo;dc=eu", "foo;dc=us"}), `["foo;dc=eu","foo;dc=us"]`)
+	f(TagsAutoCompleteResponse([]string{"dc"}), `["dc"]`)
+}
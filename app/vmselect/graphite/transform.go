@@ -0,0 +1,889 @@
+package graphite
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmselect/graphiteql"
+)
+
+// transformFunc evaluates the function call fe.
+type transformFunc func(ec *evalConfig, fe *graphiteql.FuncExpr) ([]*series, error)
+
+// transformFuncs contains the supported Graphite functions.
+//
+// It is initialized in init() in order to avoid initialization loop, since evalExpr refers to transformFuncs.
+//
+// See https://graphite.readthedocs.io/en/stable/functions.html
+var transformFuncs map[string]transformFunc
+
+func init() {
+	transformFuncs = map[string]transformFunc{
+		"absolute":              transformAbsolute,
+		"aggregate":             transformAggregate,
+		"alias":                 transformAlias,
+		"aliasByNode":           transformAliasByNode,
+		"aliasSub":              transformAliasSub,
+		"averageSeries":         newTransformAggrSeries(aggrAvg),
+		"avg":                   newTransformAggrSeries(aggrAvg),
+		"consolidateBy":         transformConsolidateBy,
+		"constantLine":          transformConstantLine,
+		"countSeries":           newTransformAggrSeries(aggrCount),
+		"derivative":            transformDerivative,
+		"diffSeries":            newTransformAggrSeries(aggrDiff),
+		"exclude":               newTransformFilterByName(false),
+		"grep":                  newTransformFilterByName(true),
+		"groupByNode":           transformGroupByNode,
+		"groupByNodes":          transformGroupByNodes,
+		"highestAverage":        newTransformHighestLowest(aggrAvg, true),
+		"highestCurrent":        newTransformHighestLowest(aggrLast, true),
+		"highestMax":            newTransformHighestLowest(aggrMax, true),
+		"integral":              transformIntegral,
+		"keepLastValue":         transformKeepLastValue,
+		"limit":                 transformLimit,
+		"lowestAverage":         newTransformHighestLowest(aggrAvg, false),
+		"lowestCurrent":         newTransformHighestLowest(aggrLast, false),
+		"maxSeries":             newTransformAggrSeries(aggrMax),
+		"medianSeries":          newTransformAggrSeries(aggrMedian),
+		"minSeries":             newTransformAggrSeries(aggrMin),
+		"movingAverage":         newTransformMovingWindow(aggrAvg),
+		"movingMax":             newTransformMovingWindow(aggrMax),
+		"movingMedian":          newTransformMovingWindow(aggrMedian),
+		"movingMin":             newTransformMovingWindow(aggrMin),
+		"movingSum":             newTransformMovingWindow(aggrSum),
+		"multiplySeries":        newTransformAggrSeries(aggrMultiply),
+		"nonNegativeDerivative": transformNonNegativeDerivative,
+		"offset":                transformOffset,
+		"perSecond":             transformPerSecond,
+		"rangeSeries":           newTransformAggrSeries(aggrRange),
+		"scale":                 transformScale,
+		"seriesByTag":           transformSeriesByTag,
+		"sortByName":            transformSortByName,
+		"sum":                   newTransformAggrSeries(aggrSum),
+		"sumSeries":             newTransformAggrSeries(aggrSum),
+		"summarize":             transformSummarize,
+		"timeShift":             transformTimeShift,
+		"transformNull":         transformTransformNull,
+	}
+}
+
+func getTransformFunc(name string) transformFunc {
+	return transformFuncs[name]
+}
+
+func newTransformAggrSeries(af aggrFunc) transformFunc {
+	return func(ec *evalConfig, fe *graphiteql.FuncExpr) ([]*series, error) {
+		ss, err := getSeriesListArgs(ec, fe, 0)
+		if err != nil {
+			return nil, err
+		}
+		return aggregateSeriesList(ss, string(fe.AppendString(nil)), af)
+	}
+}
+
+func aggregateSeriesList(ss []*series, name string, af aggrFunc) ([]*series, error) {
+	if len(ss) == 0 {
+		return nil, nil
+	}
+	s, err := aggregateSeries(ss, name, af)
+	if err != nil {
+		return nil, err
+	}
+	return []*series{s}, nil
+}
+
+// See https://graphite.readthedocs.io/en/stable/functions.html#graphite.render.functions.aggregate
+func transformAggregate(ec *evalConfig, fe *graphiteql.FuncExpr) ([]*series, error) {
+	ss, err := getSeriesArg(ec, fe, "seriesList", 0)
+	if err != nil {
+		return nil, err
+	}
+	funcName, err := getStringArg(fe, "func", 1)
+	if err != nil {
+		return nil, err
+	}
+	funcName = strings.TrimSuffix(funcName, "Series")
+	af, err := getAggrFunc(funcName)
+	if err != nil {
+		return nil, err
+	}
+	name := fmt.Sprintf("%sSeries(%s)", funcName, formatPathExpressions(ss))
+	return aggregateSeriesList(ss, name, af)
+}
+
+func formatPathExpressions(ss []*series) string {
+	var a []string
+	m := make(map[string]bool)
+	for _, s := range ss {
+		if m[s.pathExpression] {
+			continue
+		}
+		m[s.pathExpression] = true
+		a = append(a, s.pathExpression)
+	}
+	return strings.Join(a, ",")
+}
+
+// See https://graphite.readthedocs.io/en/stable/functions.html#graphite.render.functions.alias
+func transformAlias(ec *evalConfig, fe *graphiteql.FuncExpr) ([]*series, error) {
+	ss, err := getSeriesArg(ec, fe, "seriesList", 0)
+	if err != nil {
+		return nil, err
+	}
+	newName, err := getStringArg(fe, "newName", 1)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range ss {
+		s.Name = newName
+	}
+	return ss, nil
+}
+
+// See https://graphite.readthedocs.io/en/stable/functions.html#graphite.render.functions.aliasByNode
+func transformAliasByNode(ec *evalConfig, fe *graphiteql.FuncExpr) ([]*series, error) {
+	ss, err := getSeriesArg(ec, fe, "seriesList", 0)
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := getNodeArgs(fe, 1)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range ss {
+		s.Name = getNodesKey(s, nodes)
+	}
+	return ss, nil
+}
+
+// See https://graphite.readthedocs.io/en/stable/functions.html#graphite.render.functions.aliasSub
+func transformAliasSub(ec *evalConfig, fe *graphiteql.FuncExpr) ([]*series, error) {
+	ss, err := getSeriesArg(ec, fe, "seriesList", 0)
+	if err != nil {
+		return nil, err
+	}
+	search, err := getStringArg(fe, "search", 1)
+	if err != nil {
+		return nil, err
+	}
+	replace, err := getStringArg(fe, "replace", 2)
+	if err != nil {
+		return nil, err
+	}
+	re, err := regexp.Compile(search)
+	if err != nil {
+		return nil, fmt.Errorf("cannot compile `search` regexp %q: %w", search, err)
+	}
+	// Convert Python-style backreferences `\1` to Go-style `${1}`.
+	replace = backreferenceRegexp.ReplaceAllString(replace, "$${$1}")
+	for _, s := range ss {
+		s.Name = re.ReplaceAllString(s.Name, replace)
+	}
+	return ss, nil
+}
+
+var backreferenceRegexp = regexp.MustCompile(`\\(\d+)`)
+
+// getNodesKey returns the key for the given nodes of s.
+//
+// Number nodes refer to the dot-delimited parts of the series path, while string nodes refer to tag values.
+func getNodesKey(s *series, nodes []graphiteql.Expr) string {
+	path := getPathFromName(s.Name)
+	parts := strings.Split(path, ".")
+	a := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		switch t := node.(type) {
+		case *graphiteql.NumberExpr:
+			n := int(t.N)
+			if n < 0 {
+				n += len(parts)
+			}
+			if n >= 0 && n < len(parts) {
+				a = append(a, parts[n])
+			}
+		case *graphiteql.StringExpr:
+			if v, ok := s.Tags[t.S]; ok {
+				a = append(a, v)
+			}
+		}
+	}
+	return strings.Join(a, ".")
+}
+
+// getPathFromName returns the first metric path from the given series name.
+//
+// For example, `foo.bar` is returned for `movingAverage(foo.bar;baz=x,5)`.
+func getPathFromName(name string) string {
+	expr, err := graphiteql.Parse(name)
+	if err == nil {
+		if path := getFirstPath(expr); path != "" {
+			name = path
+		}
+	} else {
+		// The name may contain chars, which cannot be parsed, such as `=` in tags.
+		// Extract the innermost function arg in this case.
+		if n := strings.LastIndexByte(name, '('); n >= 0 {
+			name = name[n+1:]
+		}
+		if n := strings.IndexAny(name, ",)"); n >= 0 {
+			name = name[:n]
+		}
+	}
+	if n := strings.IndexByte(name, ';'); n >= 0 {
+		name = name[:n]
+	}
+	return name
+}
+
+func getFirstPath(expr graphiteql.Expr) string {
+	switch t := expr.(type) {
+	case *graphiteql.MetricExpr:
+		return t.Query
+	case *graphiteql.FuncExpr:
+		for _, arg := range t.Args {
+			if path := getFirstPath(arg.Expr); path != "" {
+				return path
+			}
+		}
+	}
+	return ""
+}
+
+// See https://graphite.readthedocs.io/en/stable/functions.html#graphite.render.functions.consolidateBy
+func transformConsolidateBy(ec *evalConfig, fe *graphiteql.FuncExpr) ([]*series, error) {
+	ss, err := getSeriesArg(ec, fe, "seriesList", 0)
+	if err != nil {
+		return nil, err
+	}
+	funcName, err := getStringArg(fe, "consolidationFunc", 1)
+	if err != nil {
+		return nil, err
+	}
+	af, err := getAggrFunc(funcName)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range ss {
+		s.consolidateFunc = af
+		s.rename(fmt.Sprintf("consolidateBy(%s,%s)", s.Name, strconv.Quote(funcName)))
+	}
+	return ss, nil
+}
+
+// See https://graphite.readthedocs.io/en/stable/functions.html#graphite.render.functions.constantLine
+func transformConstantLine(ec *evalConfig, fe *graphiteql.FuncExpr) ([]*series, error) {
+	value, err := getNumberArg(fe, "value", 0)
+	if err != nil {
+		return nil, err
+	}
+	name := formatNumber(value)
+	timestamps := ec.newTimestamps(ec.storageStep)
+	values := make([]float64, len(timestamps))
+	for i := range values {
+		values[i] = value
+	}
+	s := &series{
+		Name: name,
+		Tags: map[string]string{
+			"name": name,
+		},
+		Timestamps:     timestamps,
+		Values:         values,
+		pathExpression: name,
+	}
+	return []*series{s}, nil
+}
+
+// See https://graphite.readthedocs.io/en/stable/functions.html#graphite.render.functions.exclude
+// and https://graphite.readthedocs.io/en/stable/functions.html#graphite.render.functions.grep
+func newTransformFilterByName(keepMatching bool) transformFunc {
+	return func(ec *evalConfig, fe *graphiteql.FuncExpr) ([]*series, error) {
+		ss, err := getSeriesArg(ec, fe, "seriesList", 0)
+		if err != nil {
+			return nil, err
+		}
+		pattern, err := getStringArg(fe, "pattern", 1)
+		if err != nil {
+			return nil, err
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("cannot compile `pattern` regexp %q: %w", pattern, err)
+		}
+		dst := ss[:0]
+		for _, s := range ss {
+			if re.MatchString(s.Name) == keepMatching {
+				dst = append(dst, s)
+			}
+		}
+		return dst, nil
+	}
+}
+
+// See https://graphite.readthedocs.io/en/stable/functions.html#graphite.render.functions.groupByNode
+func transformGroupByNode(ec *evalConfig, fe *graphiteql.FuncExpr) ([]*series, error) {
+	ss, err := getSeriesArg(ec, fe, "seriesList", 0)
+	if err != nil {
+		return nil, err
+	}
+	nodeArg := getArg(fe, "nodeNum", 1)
+	if nodeArg == nil {
+		return nil, fmt.Errorf("missing %q arg", "nodeNum")
+	}
+	switch nodeArg.Expr.(type) {
+	case *graphiteql.NumberExpr, *graphiteql.StringExpr:
+	default:
+		return nil, fmt.Errorf("`nodeNum` must be either a number or a tag name; got %s", nodeArg.Expr.AppendString(nil))
+	}
+	callback, err := getOptionalStringArg(fe, "callback", 2, "average")
+	if err != nil {
+		return nil, err
+	}
+	return groupByNodes(ss, []graphiteql.Expr{nodeArg.Expr}, callback)
+}
+
+// See https://graphite.readthedocs.io/en/stable/functions.html#graphite.render.functions.groupByNodes
+func transformGroupByNodes(ec *evalConfig, fe *graphiteql.FuncExpr) ([]*series, error) {
+	ss, err := getSeriesArg(ec, fe, "seriesList", 0)
+	if err != nil {
+		return nil, err
+	}
+	callback, err := getStringArg(fe, "callback", 1)
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := getNodeArgs(fe, 2)
+	if err != nil {
+		return nil, err
+	}
+	return groupByNodes(ss, nodes, callback)
+}
+
+func groupByNodes(ss []*series, nodes []graphiteql.Expr, callback string) ([]*series, error) {
+	af, err := getAggrFunc(strings.TrimSuffix(callback, "Series"))
+	if err != nil {
+		return nil, err
+	}
+	m := make(map[string][]*series)
+	for _, s := range ss {
+		key := getNodesKey(s, nodes)
+		m[key] = append(m[key], s)
+	}
+	result := make([]*series, 0, len(m))
+	for key, ss := range m {
+		s, err := aggregateSeries(ss, key, af)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, s)
+	}
+	sortSeriesByName(result)
+	return result, nil
+}
+
+// See https://graphite.readthedocs.io/en/stable/functions.html#graphite.render.functions.highestAverage
+// and similar functions.
+func newTransformHighestLowest(af aggrFunc, isHighest bool) transformFunc {
+	return func(ec *evalConfig, fe *graphiteql.FuncExpr) ([]*series, error) {
+		ss, err := getSeriesArg(ec, fe, "seriesList", 0)
+		if err != nil {
+			return nil, err
+		}
+		n, err := getOptionalIntArg(fe, "n", 1, 1)
+		if err != nil {
+			return nil, err
+		}
+		return highestLowest(ss, af, n, isHighest), nil
+	}
+}
+
+func highestLowest(ss []*series, af aggrFunc, n int, isHighest bool) []*series {
+	keys := make([]float64, len(ss))
+	for i, s := range ss {
+		keys[i] = af(s.Values)
+	}
+	idxs := make([]int, len(ss))
+	for i := range idxs {
+		idxs[i] = i
+	}
+	sort.SliceStable(idxs, func(i, j int) bool {
+		a, b := keys[idxs[i]], keys[idxs[j]]
+		if math.IsNaN(a) {
+			return false
+		}
+		if math.IsNaN(b) {
+			return true
+		}
+		if isHighest {
+			return a > b
+		}
+		return a < b
+	})
+	if n < 0 {
+		n = 0
+	}
+	if n > len(idxs) {
+		n = len(idxs)
+	}
+	result := make([]*series, n)
+	for i := range result {
+		result[i] = ss[idxs[i]]
+	}
+	return result
+}
+
+// See https://graphite.readthedocs.io/en/stable/functions.html#graphite.render.functions.limit
+func transformLimit(ec *evalConfig, fe *graphiteql.FuncExpr) ([]*series, error) {
+	ss, err := getSeriesArg(ec, fe, "seriesList", 0)
+	if err != nil {
+		return nil, err
+	}
+	n, err := getIntArg(fe, "n", 1)
+	if err != nil {
+		return nil, err
+	}
+	if n < 0 {
+		n = 0
+	}
+	if n < len(ss) {
+		ss = ss[:n]
+	}
+	return ss, nil
+}
+
+// See https://graphite.readthedocs.io/en/stable/functions.html#graphite.render.functions.seriesByTag
+func transformSeriesByTag(ec *evalConfig, fe *graphiteql.FuncExpr) ([]*series, error) {
+	if len(fe.Args) == 0 {
+		return nil, fmt.Errorf("expecting at least one tag expression")
+	}
+	exprs := make([]string, 0, len(fe.Args))
+	for i := range fe.Args {
+		expr, err := getStringArg(fe, "tagExpression", i)
+		if err != nil {
+			return nil, err
+		}
+		exprs = append(exprs, expr)
+	}
+	tfs, err := getTagFiltersForTagExprs(exprs)
+	if err != nil {
+		return nil, err
+	}
+	return fetchSeries(ec, tfs, string(fe.AppendString(nil)))
+}
+
+// See https://graphite.readthedocs.io/en/stable/functions.html#graphite.render.functions.sortByName
+func transformSortByName(ec *evalConfig, fe *graphiteql.FuncExpr) ([]*series, error) {
+	ss, err := getSeriesArg(ec, fe, "seriesList", 0)
+	if err != nil {
+		return nil, err
+	}
+	sortSeriesByName(ss)
+	return ss, nil
+}
+
+// See https://graphite.readthedocs.io/en/stable/functions.html#graphite.render.functions.movingAverage
+// and similar functions.
+func newTransformMovingWindow(af aggrFunc) transformFunc {
+	return func(ec *evalConfig, fe *graphiteql.FuncExpr) ([]*series, error) {
+		windowArg := getArg(fe, "windowSize", 1)
+		if windowArg == nil {
+			return nil, fmt.Errorf("missing %q arg", "windowSize")
+		}
+		var windowMsecs int64
+		var windowStr string
+		switch t := windowArg.Expr.(type) {
+		case *graphiteql.NumberExpr:
+			if t.N <= 0 || t.N != math.Trunc(t.N) {
+				return nil, fmt.Errorf("`windowSize` must be positive integer; got %v", t.N)
+			}
+			windowMsecs = int64(t.N) * ec.storageStep
+			windowStr = formatNumber(t.N)
+		case *graphiteql.StringExpr:
+			d, err := parseInterval(t.S)
+			if err != nil {
+				return nil, fmt.Errorf("cannot parse `windowSize`: %w", err)
+			}
+			if d <= 0 {
+				return nil, fmt.Errorf("`windowSize` must be positive; got %q", t.S)
+			}
+			windowMsecs = d
+			windowStr = strconv.Quote(t.S)
+		default:
+			return nil, fmt.Errorf("`windowSize` must be either a number or a string; got %s", windowArg.Expr.AppendString(nil))
+		}
+
+		// Fetch additional data for the window before the start time.
+		ecCopy := *ec
+		ecCopy.startTime -= windowMsecs - windowMsecs%ec.storageStep
+		ss, err := getSeriesArg(&ecCopy, fe, "seriesList", 0)
+		if err != nil {
+			return nil, err
+		}
+		funcName := fe.FuncName
+		for _, s := range ss {
+			s.Values = movingWindow(s.Timestamps, s.Values, windowMsecs, af)
+			s.Timestamps, s.Values = trimPointsBefore(s.Timestamps, s.Values, ec.startTime)
+			s.rename(fmt.Sprintf("%s(%s,%s)", funcName, s.Name, windowStr))
+		}
+		return ss, nil
+	}
+}
+
+// movingWindow returns values where every point contains af result over the points on the time range [t-windowMsecs ... t).
+func movingWindow(timestamps []int64, values []float64, windowMsecs int64, af aggrFunc) []float64 {
+	dstValues := make([]float64, len(values))
+	i := 0
+	for j, ts := range timestamps {
+		for i < j && timestamps[i] < ts-windowMsecs {
+			i++
+		}
+		if i == j {
+			dstValues[j] = nan
+			continue
+		}
+		dstValues[j] = af(values[i:j])
+	}
+	return dstValues
+}
+
+func trimPointsBefore(timestamps []int64, values []float64, startTime int64) ([]int64, []float64) {
+	i := 0
+	for i < len(timestamps) && timestamps[i] < startTime {
+		i++
+	}
+	return timestamps[i:], values[i:]
+}
+
+// See https://graphite.readthedocs.io/en/stable/functions.html#graphite.render.functions.summarize
+func transformSummarize(ec *evalConfig, fe *graphiteql.FuncExpr) ([]*series, error) {
+	ss, err := getSeriesArg(ec, fe, "seriesList", 0)
+	if err != nil {
+		return nil, err
+	}
+	intervalString, err := getStringArg(fe, "intervalString", 1)
+	if err != nil {
+		return nil, err
+	}
+	interval, err := parseInterval(intervalString)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse `intervalString`: %w", err)
+	}
+	if interval <= 0 {
+		return nil, fmt.Errorf("`intervalString` must be positive; got %q", intervalString)
+	}
+	funcName, err := getOptionalStringArg(fe, "func", 2, "sum")
+	if err != nil {
+		return nil, err
+	}
+	af, err := getAggrFunc(funcName)
+	if err != nil {
+		return nil, err
+	}
+	alignToFrom, err := getOptionalBoolArg(fe, "alignToFrom", 3, false)
+	if err != nil {
+		return nil, err
+	}
+	startTime := ec.startTime
+	if !alignToFrom {
+		startTime -= startTime % interval
+	}
+	for _, s := range ss {
+		s.Timestamps, s.Values = summarize(s.Timestamps, s.Values, startTime, ec.endTime, interval, af)
+		name := fmt.Sprintf("summarize(%s, %s, %s)", s.Name, strconv.Quote(intervalString), strconv.Quote(funcName))
+		if alignToFrom {
+			name = fmt.Sprintf("summarize(%s, %s, %s, true)", s.Name, strconv.Quote(intervalString), strconv.Quote(funcName))
+		}
+		s.rename(name)
+	}
+	return ss, nil
+}
+
+// summarize aggregates points into buckets with the given interval starting from startTime using af.
+func summarize(timestamps []int64, values []float64, startTime, endTime, interval int64, af aggrFunc) ([]int64, []float64) {
+	var dstTimestamps []int64
+	var dstValues []float64
+	i := 0
+	for ts := startTime; ts <= endTime; ts += interval {
+		for i < len(timestamps) && timestamps[i] < ts {
+			i++
+		}
+		j := i
+		for j < len(timestamps) && timestamps[j] < ts+interval {
+			j++
+		}
+		dstTimestamps = append(dstTimestamps, ts)
+		dstValues = append(dstValues, af(values[i:j]))
+		i = j
+	}
+	return dstTimestamps, dstValues
+}
+
+// See https://graphite.readthedocs.io/en/stable/functions.html#graphite.render.functions.perSecond
+func transformPerSecond(ec *evalConfig, fe *graphiteql.FuncExpr) ([]*series, error) {
+	ss, err := getSeriesArg(ec, fe, "seriesList", 0)
+	if err != nil {
+		return nil, err
+	}
+	maxValue, err := getOptionalNumberArg(fe, "maxValue", 1, nan)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range ss {
+		s.Values = nonNegativeDerivative(s.Timestamps, s.Values, maxValue, true)
+		s.rename(fmt.Sprintf("perSecond(%s)", s.Name))
+	}
+	return ss, nil
+}
+
+// See https://graphite.readthedocs.io/en/stable/functions.html#graphite.render.functions.nonNegativeDerivative
+func transformNonNegativeDerivative(ec *evalConfig, fe *graphiteql.FuncExpr) ([]*series, error) {
+	ss, err := getSeriesArg(ec, fe, "seriesList", 0)
+	if err != nil {
+		return nil, err
+	}
+	maxValue, err := getOptionalNumberArg(fe, "maxValue", 1, nan)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range ss {
+		s.Values = nonNegativeDerivative(s.Timestamps, s.Values, maxValue, false)
+		s.rename(fmt.Sprintf("nonNegativeDerivative(%s)", s.Name))
+	}
+	return ss, nil
+}
+
+// nonNegativeDerivative returns the difference between adjacent non-NaN points.
+//
+// Counter wraps are handled if maxValue isn't NaN. Otherwise NaN is returned for negative differences.
+// The difference is divided by the time between points in seconds if perSecond is set.
+func nonNegativeDerivative(timestamps []int64, values []float64, maxValue float64, perSecond bool) []float64 {
+	dstValues := make([]float64, len(values))
+	prevValue := nan
+	var prevTimestamp int64
+	for i, v := range values {
+		if math.IsNaN(v) || math.IsNaN(prevValue) {
+			dstValues[i] = nan
+			if !math.IsNaN(v) {
+				prevValue = v
+				prevTimestamp = timestamps[i]
+			}
+			continue
+		}
+		delta := v - prevValue
+		if delta < 0 {
+			if !math.IsNaN(maxValue) && maxValue >= v {
+				delta = maxValue - prevValue + v + 1
+			} else {
+				delta = nan
+			}
+		}
+		if perSecond && !math.IsNaN(delta) {
+			delta /= float64(timestamps[i]-prevTimestamp) / 1e3
+		}
+		dstValues[i] = delta
+		prevValue = v
+		prevTimestamp = timestamps[i]
+	}
+	return dstValues
+}
+
+// See https://graphite.readthedocs.io/en/stable/functions.html#graphite.render.functions.derivative
+func transformDerivative(ec *evalConfig, fe *graphiteql.FuncExpr) ([]*series, error) {
+	ss, err := getSeriesArg(ec, fe, "seriesList", 0)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range ss {
+		prevValue := nan
+		for i, v := range s.Values {
+			s.Values[i] = v - prevValue
+			prevValue = v
+		}
+		s.rename(fmt.Sprintf("derivative(%s)", s.Name))
+	}
+	return ss, nil
+}
+
+// See https://graphite.readthedocs.io/en/stable/functions.html#graphite.render.functions.integral
+func transformIntegral(ec *evalConfig, fe *graphiteql.FuncExpr) ([]*series, error) {
+	ss, err := getSeriesArg(ec, fe, "seriesList", 0)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range ss {
+		sum := float64(0)
+		for i, v := range s.Values {
+			if math.IsNaN(v) {
+				continue
+			}
+			sum += v
+			s.Values[i] = sum
+		}
+		s.rename(fmt.Sprintf("integral(%s)", s.Name))
+	}
+	return ss, nil
+}
+
+// See https://graphite.readthedocs.io/en/stable/functions.html#graphite.render.functions.absolute
+func transformAbsolute(ec *evalConfig, fe *graphiteql.FuncExpr) ([]*series, error) {
+	ss, err := getSeriesArg(ec, fe, "seriesList", 0)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range ss {
+		for i, v := range s.Values {
+			s.Values[i] = math.Abs(v)
+		}
+		s.rename(fmt.Sprintf("absolute(%s)", s.Name))
+	}
+	return ss, nil
+}
+
+// See https://graphite.readthedocs.io/en/stable/functions.html#graphite.render.functions.scale
+func transformScale(ec *evalConfig, fe *graphiteql.FuncExpr) ([]*series, error) {
+	ss, err := getSeriesArg(ec, fe, "seriesList", 0)
+	if err != nil {
+		return nil, err
+	}
+	factor, err := getNumberArg(fe, "factor", 1)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range ss {
+		for i, v := range s.Values {
+			s.Values[i] = v * factor
+		}
+		s.rename(fmt.Sprintf("scale(%s,%s)", s.Name, formatNumber(factor)))
+	}
+	return ss, nil
+}
+
+// See https://graphite.readthedocs.io/en/stable/functions.html#graphite.render.functions.offset
+func transformOffset(ec *evalConfig, fe *graphiteql.FuncExpr) ([]*series, error) {
+	ss, err := getSeriesArg(ec, fe, "seriesList", 0)
+	if err != nil {
+		return nil, err
+	}
+	factor, err := getNumberArg(fe, "factor", 1)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range ss {
+		for i, v := range s.Values {
+			s.Values[i] = v + factor
+		}
+		s.rename(fmt.Sprintf("offset(%s,%s)", s.Name, formatNumber(factor)))
+	}
+	return ss, nil
+}
+
+// See https://graphite.readthedocs.io/en/stable/functions.html#graphite.render.functions.transformNull
+func transformTransformNull(ec *evalConfig, fe *graphiteql.FuncExpr) ([]*series, error) {
+	ss, err := getSeriesArg(ec, fe, "seriesList", 0)
+	if err != nil {
+		return nil, err
+	}
+	defaultValue, err := getOptionalNumberArg(fe, "default", 1, 0)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range ss {
+		for i, v := range s.Values {
+			if math.IsNaN(v) {
+				s.Values[i] = defaultValue
+			}
+		}
+		s.rename(fmt.Sprintf("transformNull(%s,%s)", s.Name, formatNumber(defaultValue)))
+	}
+	return ss, nil
+}
+
+// See https://graphite.readthedocs.io/en/stable/functions.html#graphite.render.functions.keepLastValue
+func transformKeepLastValue(ec *evalConfig, fe *graphiteql.FuncExpr) ([]*series, error) {
+	ss, err := getSeriesArg(ec, fe, "seriesList", 0)
+	if err != nil {
+		return nil, err
+	}
+	limit, err := getOptionalNumberArg(fe, "limit", 1, math.Inf(1))
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range ss {
+		keepLastValue(s.Values, limit)
+		s.rename(fmt.Sprintf("keepLastValue(%s)", s.Name))
+	}
+	return ss, nil
+}
+
+// keepLastValue replaces up to limit consecutive NaN values with the last non-NaN value.
+func keepLastValue(values []float64, limit float64) {
+	prevValue := nan
+	i := 0
+	for i < len(values) {
+		if !math.IsNaN(values[i]) {
+			prevValue = values[i]
+			i++
+			continue
+		}
+		j := i
+		for j < len(values) && math.IsNaN(values[j]) {
+			j++
+		}
+		// Do not fill trailing gaps and gaps exceeding the limit.
+		if j < len(values) && float64(j-i) <= limit {
+			for k := i; k < j; k++ {
+				values[k] = prevValue
+			}
+		}
+		i = j
+	}
+}
+
+// See https://graphite.readthedocs.io/en/stable/functions.html#graphite.render.functions.timeShift
+func transformTimeShift(ec *evalConfig, fe *graphiteql.FuncExpr) ([]*series, error) {
+	timeShift, err := getStringArg(fe, "timeShift", 1)
+	if err != nil {
+		return nil, err
+	}
+	// The time shift is performed backwards unless it starts with `+`.
+	shiftStr := timeShift
+	isForward := false
+	switch {
+	case strings.HasPrefix(shiftStr, "+"):
+		isForward = true
+		shiftStr = shiftStr[1:]
+	case strings.HasPrefix(shiftStr, "-"):
+		shiftStr = shiftStr[1:]
+	}
+	shift, err := parseInterval(shiftStr)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse `timeShift`: %w", err)
+	}
+	// Align the shift to storage step, so the shifted points are located at the same timestamps as the original points.
+	shift -= shift % ec.storageStep
+	if !isForward {
+		shift = -shift
+	}
+	ecCopy := *ec
+	ecCopy.startTime += shift
+	ecCopy.endTime += shift
+	ss, err := getSeriesArg(&ecCopy, fe, "seriesList", 0)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range ss {
+		for i := range s.Timestamps {
+			s.Timestamps[i] -= shift
+		}
+		s.rename(fmt.Sprintf("timeShift(%s,%s)", s.Name, strconv.Quote(timeShift)))
+	}
+	return ss, nil
+}
+
+func formatNumber(n float64) string {
+	return strconv.FormatFloat(n, 'g', -1, 64)
+}
@@ -0,0 +1,195 @@
+package graphite
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmselect/graphiteql"
+)
+
+func TestAggrFuncs(t *testing.T) {
+	f := func(name string, values []float64, resultExpected float64) {
+		t.Helper()
+		af, err := getAggrFunc(name)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		result := af(values)
+		if !equalValues([]float64{result}, []float64{resultExpected}) {
+			t.Fatalf("unexpected result for %s(%v); got %v; want %v", name, values, result, resultExpected)
+		}
+	}
+	values := []float64{nan, 4, 1, nan, 3}
+	f("average", values, 8.0/3)
+	f("sum", values, 8)
+	f("min", values, 1)
+	f("max", values, 4)
+	f("median", values, 3)
+	f("median", []float64{4, 1, 2, 3}, 2.5)
+	f("count", values, 3)
+	f("diff", values, 0)
+	f("multiply", values, 12)
+	f("range", values, 3)
+	f("first", values, 4)
+	f("last", values, 3)
+	for name := range aggrFuncs {
+		f(name, nil, nan)
+		f(name, []float64{nan, nan}, nan)
+	}
+
+	if _, err := getAggrFunc("foobar"); err == nil {
+		t.Fatalf("expecting non-nil error for unknown aggregate function")
+	}
+}
+
+func TestAggregateSeries(t *testing.T) {
+	ss := []*series{
+		{
+			Name:       "foo.a",
+			Timestamps: []int64{10, 20, 30},
+			Values:     []float64{1, nan, 3},
+		},
+		{
+			Name:       "foo.b",
+			Timestamps: []int64{10, 20, 30},
+			Values:     []float64{2, nan, nan},
+		},
+	}
+	s, err := aggregateSeries(ss, "sumSeries(foo.*)", aggrSum)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if s.Name != "sumSeries(foo.*)" {
+		t.Fatalf("unexpected name; got %q; want %q", s.Name, "sumSeries(foo.*)")
+	}
+	if !reflect.DeepEqual(s.Timestamps, []int64{10, 20, 30}) {
+		t.Fatalf("unexpected timestamps: %v", s.Timestamps)
+	}
+	if !equalValues(s.Values, []float64{3, nan, 3}) {
+		t.Fatalf("unexpected values: %v", s.Values)
+	}
+
+	// Series with distinct timestamps cannot be aggregated
+	ss = append(ss, &series{
+		Name:       "foo.c",
+		Timestamps: []int64{10, 30},
+		Values:     []float64{1, 2},
+	})
+	if _, err := aggregateSeries(ss, "x", aggrSum); err == nil {
+		t.Fatalf("expecting non-nil error")
+	}
+}
+
+func TestGetNodesKey(t *testing.T) {
+	f := func(name string, tags map[string]string, nodes []graphiteql.Expr, keyExpected string) {
+		t.Helper()
+		s := &series{
+			Name: name,
+			Tags: tags,
+		}
+		key := getNodesKey(s, nodes)
+		if key != keyExpected {
+			t.Fatalf("unexpected key for %q; got %q; want %q", name, key, keyExpected)
+		}
+	}
+	n := func(n float64) graphiteql.Expr {
+		return &graphiteql.NumberExpr{N: n}
+	}
+	tag := func(s string) graphiteql.Expr {
+		return &graphiteql.StringExpr{S: s}
+	}
+	f("foo.bar.baz", nil, []graphiteql.Expr{n(1)}, "bar")
+	f("foo.bar.baz", nil, []graphiteql.Expr{n(0), n(2)}, "foo.baz")
+	f("foo.bar.baz", nil, []graphiteql.Expr{n(-1)}, "baz")
+	f("foo.bar.baz", nil, []graphiteql.Expr{n(5)}, "")
+	f("movingAverage(foo.bar.baz,5)", nil, []graphiteql.Expr{n(1)}, "bar")
+	f(`summarize(scale(foo.bar.baz,2), "1h", "sum")`, nil, []graphiteql.Expr{n(2)}, "baz")
+	f("foo.bar;dc=x", map[string]string{"dc": "x"}, []graphiteql.Expr{n(1), tag("dc")}, "bar.x")
+	f("perSecond(foo.bar;dc=x)", map[string]string{"dc": "x"}, []graphiteql.Expr{n(0)}, "foo")
+}
+
+func TestMovingWindow(t *testing.T) {
+	f := func(timestamps []int64, values []float64, windowMsecs int64, af aggrFunc, valuesExpected []float64) {
+		t.Helper()
+		result := movingWindow(timestamps, values, windowMsecs, af)
+		if !equalValues(result, valuesExpected) {
+			t.Fatalf("unexpected values; got %v; want %v", result, valuesExpected)
+		}
+	}
+	timestamps := []int64{10, 20, 30, 40, 50}
+	values := []float64{1, 2, nan, 4, 5}
+	f(timestamps, values, 20, aggrAvg, []float64{nan, 1, 1.5, 2, 4})
+	f(timestamps, values, 30, aggrSum, []float64{nan, 1, 3, 3, 6})
+	f(timestamps, values, 10, aggrMax, []float64{nan, 1, 2, nan, 4})
+}
+
+func TestSummarize(t *testing.T) {
+	f := func(startTime, interval int64, af aggrFunc, timestampsExpected []int64, valuesExpected []float64) {
+		t.Helper()
+		timestamps := []int64{10, 20, 30, 40, 50, 60}
+		values := []float64{1, 2, 3, nan, 5, 6}
+		timestamps, values = summarize(timestamps, values, startTime, 60, interval, af)
+		if !reflect.DeepEqual(timestamps, timestampsExpected) {
+			t.Fatalf("unexpected timestamps; got %v; want %v", timestamps, timestampsExpected)
+		}
+		if !equalValues(values, valuesExpected) {
+			t.Fatalf("unexpected values; got %v; want %v", values, valuesExpected)
+		}
+	}
+	f(10, 10, aggrSum, []int64{10, 20, 30, 40, 50, 60}, []float64{1, 2, 3, nan, 5, 6})
+	f(0, 30, aggrSum, []int64{0, 30, 60}, []float64{3, 8, 6})
+	f(10, 30, aggrMax, []int64{10, 40}, []float64{3, 6})
+	f(10, 20, aggrCount, []int64{10, 30, 50}, []float64{2, 1, 2})
+}
+
+func TestNonNegativeDerivative(t *testing.T) {
+	f := func(values []float64, maxValue float64, perSecond bool, valuesExpected []float64) {
+		t.Helper()
+		timestamps := []int64{1000, 3000, 5000, 7000, 9000, 11000}
+		result := nonNegativeDerivative(timestamps, values, maxValue, perSecond)
+		if !equalValues(result, valuesExpected) {
+			t.Fatalf("unexpected values; got %v; want %v", result, valuesExpected)
+		}
+	}
+	values := []float64{1, 5, nan, 9, 3, 7}
+	f(values, nan, false, []float64{nan, 4, nan, 4, nan, 4})
+	f(values, nan, true, []float64{nan, 2, nan, 1, nan, 2})
+	f(values, 10, false, []float64{nan, 4, nan, 4, 5, 4})
+}
+
+func TestKeepLastValue(t *testing.T) {
+	f := func(values []float64, limit float64, valuesExpected []float64) {
+		t.Helper()
+		keepLastValue(values, limit)
+		if !equalValues(values, valuesExpected) {
+			t.Fatalf("unexpected values; got %v; want %v", values, valuesExpected)
+		}
+	}
+	f([]float64{nan, 1, nan, nan, 2, nan}, 1e9, []float64{nan, 1, 1, 1, 2, nan})
+	f([]float64{nan, 1, nan, nan, 2, nan, 3}, 1, []float64{nan, 1, nan, nan, 2, 2, 3})
+}
+
+func TestHighestLowest(t *testing.T) {
+	ss := []*series{
+		{Name: "a", Values: []float64{1, 5, 2}},
+		{Name: "b", Values: []float64{3, 3, 3}},
+		{Name: "c", Values: []float64{nan, nan, nan}},
+		{Name: "d", Values: []float64{0, 1, 4}},
+	}
+	f := func(af aggrFunc, n int, isHighest bool, namesExpected []string) {
+		t.Helper()
+		result := highestLowest(ss, af, n, isHighest)
+		var names []string
+		for _, s := range result {
+			names = append(names, s.Name)
+		}
+		if !reflect.DeepEqual(names, namesExpected) {
+			t.Fatalf("unexpected names; got %q; want %q", names, namesExpected)
+		}
+	}
+	f(aggrMax, 2, true, []string{"a", "d"})
+	f(aggrLast, 1, true, []string{"d"})
+	f(aggrAvg, 2, false, []string{"d", "a"})
+	f(aggrAvg, 10, true, []string{"b", "a", "d", "c"})
+	f(aggrAvg, 0, true, nil)
+}
@@ -0,0 +1,180 @@
+package graphiteql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type lexer struct {
+	// Token contains the currently parsed token.
+	// An empty token means EOF.
+	Token string
+
+	sOrig string
+	sTail string
+
+	err error
+}
+
+func (lex *lexer) Context() string {
+	return fmt.Sprintf("%s%s", lex.Token, lex.sTail)
+}
+
+func (lex *lexer) Init(s string) {
+	lex.Token = ""
+	lex.err = nil
+
+	lex.sOrig = s
+	lex.sTail = s
+}
+
+func (lex *lexer) Next() error {
+	if lex.err != nil {
+		return lex.err
+	}
+	token, err := lex.next()
+	if err != nil {
+		lex.err = err
+		return err
+	}
+	lex.Token = token
+	return nil
+}
+
+func (lex *lexer) next() (string, error) {
+	// Skip whitespace
+	s := lex.sTail
+	i := 0
+	for i < len(s) && isSpaceChar(s[i]) {
+		i++
+	}
+	s = s[i:]
+	lex.sTail = s
+
+	if len(s) == 0 {
+		return "", nil
+	}
+
+	var token string
+	var err error
+	switch s[0] {
+	case '(', ')', ',', '=':
+		token = s[:1]
+		goto tokenFoundLabel
+	}
+	if isStringPrefix(s) {
+		token, err = scanString(s)
+		if err != nil {
+			return "", err
+		}
+		goto tokenFoundLabel
+	}
+	token, err = scanIdent(s)
+	if err != nil {
+		return "", err
+	}
+	if token == "" {
+		return "", fmt.Errorf("cannot recognize %q", s)
+	}
+
+tokenFoundLabel:
+	lex.sTail = s[len(token):]
+	return token, nil
+}
+
+// scanIdent scans metric path or function name from the beginning of s.
+//
+// Metric paths may contain `{a,b}` and `[a-z]` wildcards, so commas inside curly braces are part of the path.
+func scanIdent(s string) (string, error) {
+	braces := 0
+	i := 0
+	for i < len(s) {
+		c := s[i]
+		switch {
+		case c == '{':
+			braces++
+		case c == '}':
+			if braces == 0 {
+				return "", fmt.Errorf("unexpected `}` in %q", s)
+			}
+			braces--
+		case braces > 0:
+			// Any char is allowed inside curly braces.
+		case c == '(' || c == ')' || c == ',' || c == '=' || isSpaceChar(c) || c == '\'' || c == '"':
+			return s[:i], nil
+		}
+		i++
+	}
+	if braces > 0 {
+		return "", fmt.Errorf("missing `}` in %q", s)
+	}
+	return s, nil
+}
+
+func scanString(s string) (string, error) {
+	if len(s) < 2 {
+		return "", fmt.Errorf("cannot find end of string in %q", s)
+	}
+
+	quote := s[0]
+	i := 1
+	for {
+		n := strings.IndexByte(s[i:], quote)
+		if n < 0 {
+			return "", fmt.Errorf("cannot find closing quote %c for the string %q", quote, s)
+		}
+		i += n
+		bs := 0
+		for bs < i && s[i-bs-1] == '\\' {
+			bs++
+		}
+		if bs%2 == 0 {
+			token := s[:i+1]
+			return token, nil
+		}
+		i++
+	}
+}
+
+func unquote(s string) (string, error) {
+	if len(s) < 2 {
+		return "", fmt.Errorf("string literal contains less than 2 chars; got %q", s)
+	}
+	if s[0] == '\'' {
+		// Convert single-quoted string to double-quoted string, which can be parsed by strconv.Unquote.
+		s = strings.ReplaceAll(s[1:len(s)-1], `\'`, "'")
+		s = strings.ReplaceAll(s, `"`, `\"`)
+		s = `"` + s + `"`
+	}
+	return strconv.Unquote(s)
+}
+
+func isStringPrefix(s string) bool {
+	if len(s) == 0 {
+		return false
+	}
+	return s[0] == '"' || s[0] == '\''
+}
+
+func isSpaceChar(c byte) bool {
+	switch c {
+	case ' ', '\t', '\n', '\v', '\f', '\r':
+		return true
+	default:
+		return false
+	}
+}
+
+func isFuncName(s string) bool {
+	if len(s) == 0 {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_' || i > 0 && c >= '0' && c <= '9') {
+			return false
+		}
+	}
+	return true
+}
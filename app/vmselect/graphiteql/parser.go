@@ -0,0 +1,228 @@
+// Package graphiteql implements parser for Graphite render API target expressions.
+package graphiteql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Expr is Graphite expression for render API.
+type Expr interface {
+	// AppendString appends Expr contents to dst and returns the result.
+	AppendString(dst []byte) []byte
+}
+
+// Parse parses Graphite render API target expression.
+//
+// See https://graphite.readthedocs.io/en/stable/render_api.html
+func Parse(s string) (Expr, error) {
+	var lex lexer
+	lex.Init(s)
+	if err := lex.Next(); err != nil {
+		return nil, fmt.Errorf("cannot parse target expression: %w; context: %q", err, lex.Context())
+	}
+	expr, err := parseExpr(&lex)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse target expression: %w; context: %q", err, lex.Context())
+	}
+	if !isEOF(lex.Token) {
+		return nil, fmt.Errorf("unexpected tail left after parsing %q: %q", expr.AppendString(nil), lex.Context())
+	}
+	return expr, nil
+}
+
+func parseExpr(lex *lexer) (Expr, error) {
+	switch {
+	case isEOF(lex.Token):
+		return nil, fmt.Errorf("unexpected end of expression")
+	case isStringPrefix(lex.Token):
+		s, err := unquote(lex.Token)
+		if err != nil {
+			return nil, fmt.Errorf("cannot unquote string %s: %w", lex.Token, err)
+		}
+		if err := lex.Next(); err != nil {
+			return nil, err
+		}
+		return &StringExpr{S: s}, nil
+	case lex.Token == "(" || lex.Token == ")" || lex.Token == "," || lex.Token == "=":
+		return nil, fmt.Errorf("unexpected token %q", lex.Token)
+	}
+	token := lex.Token
+	if err := lex.Next(); err != nil {
+		return nil, err
+	}
+	if lex.Token == "(" {
+		if !isFuncName(token) {
+			return nil, fmt.Errorf("invalid function name %q", token)
+		}
+		return parseFuncExpr(lex, token)
+	}
+	switch token {
+	case "true", "True":
+		return &BoolExpr{B: true}, nil
+	case "false", "False":
+		return &BoolExpr{B: false}, nil
+	case "None":
+		return &NoneExpr{}, nil
+	}
+	if n, err := strconv.ParseFloat(token, 64); err == nil {
+		return &NumberExpr{N: n}, nil
+	}
+	return &MetricExpr{Query: token}, nil
+}
+
+func parseFuncExpr(lex *lexer, funcName string) (*FuncExpr, error) {
+	// Skip '('
+	if err := lex.Next(); err != nil {
+		return nil, err
+	}
+	fe := &FuncExpr{
+		FuncName: funcName,
+	}
+	for {
+		if lex.Token == ")" {
+			if err := lex.Next(); err != nil {
+				return nil, err
+			}
+			return fe, nil
+		}
+		arg, err := parseArgExpr(lex)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse arg #%d for %s(): %w", len(fe.Args)+1, funcName, err)
+		}
+		if arg.Name == "" && len(fe.Args) > 0 && fe.Args[len(fe.Args)-1].Name != "" {
+			return nil, fmt.Errorf("positional arg #%d for %s() cannot follow named args", len(fe.Args)+1, funcName)
+		}
+		fe.Args = append(fe.Args, arg)
+		switch lex.Token {
+		case ",":
+			if err := lex.Next(); err != nil {
+				return nil, err
+			}
+		case ")":
+		default:
+			return nil, fmt.Errorf("unexpected token %q in %s() args; expecting `,` or `)`", lex.Token, funcName)
+		}
+	}
+}
+
+func parseArgExpr(lex *lexer) (*ArgExpr, error) {
+	tail := strings.TrimLeft(lex.sTail, " \t\n\v\f\r")
+	if isFuncName(lex.Token) && strings.HasPrefix(tail, "=") {
+		// Named arg.
+		name := lex.Token
+		if err := lex.Next(); err != nil {
+			return nil, err
+		}
+		// Skip '='
+		if err := lex.Next(); err != nil {
+			return nil, err
+		}
+		expr, err := parseExpr(lex)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse named arg %q: %w", name, err)
+		}
+		return &ArgExpr{
+			Name: name,
+			Expr: expr,
+		}, nil
+	}
+	expr, err := parseExpr(lex)
+	if err != nil {
+		return nil, err
+	}
+	return &ArgExpr{
+		Expr: expr,
+	}, nil
+}
+
+func isEOF(s string) bool {
+	return len(s) == 0
+}
+
+// MetricExpr represents metric path expression such as `foo.*.bar`.
+type MetricExpr struct {
+	Query string
+}
+
+// AppendString appends me to dst and returns the result.
+func (me *MetricExpr) AppendString(dst []byte) []byte {
+	return append(dst, me.Query...)
+}
+
+// StringExpr represents string literal.
+type StringExpr struct {
+	S string
+}
+
+// AppendString appends se to dst and returns the result.
+func (se *StringExpr) AppendString(dst []byte) []byte {
+	return strconv.AppendQuote(dst, se.S)
+}
+
+// NumberExpr represents numeric literal.
+type NumberExpr struct {
+	N float64
+}
+
+// AppendString appends ne to dst and returns the result.
+func (ne *NumberExpr) AppendString(dst []byte) []byte {
+	return strconv.AppendFloat(dst, ne.N, 'g', -1, 64)
+}
+
+// BoolExpr represents boolean literal.
+type BoolExpr struct {
+	B bool
+}
+
+// AppendString appends be to dst and returns the result.
+func (be *BoolExpr) AppendString(dst []byte) []byte {
+	return strconv.AppendBool(dst, be.B)
+}
+
+// NoneExpr represents None literal.
+type NoneExpr struct{}
+
+// AppendString appends ne to dst and returns the result.
+func (ne *NoneExpr) AppendString(dst []byte) []byte {
+	return append(dst, "None"...)
+}
+
+// FuncExpr represents function call.
+type FuncExpr struct {
+	FuncName string
+	Args     []*ArgExpr
+}
+
+// AppendString appends fe to dst and returns the result.
+func (fe *FuncExpr) AppendString(dst []byte) []byte {
+	dst = append(dst, fe.FuncName...)
+	dst = append(dst, '(')
+	for i, arg := range fe.Args {
+		dst = arg.AppendString(dst)
+		if i+1 < len(fe.Args) {
+			dst = append(dst, ',')
+		}
+	}
+	dst = append(dst, ')')
+	return dst
+}
+
+// ArgExpr represents function arg.
+type ArgExpr struct {
+	// Name is an optional name for the named arg.
+	Name string
+
+	Expr Expr
+}
+
+// AppendString appends ae to dst and returns the result.
+func (ae *ArgExpr) AppendString(dst []byte) []byte {
+	if ae.Name != "" {
+		dst = append(dst, ae.Name...)
+		dst = append(dst, '=')
+	}
+	dst = ae.Expr.AppendString(dst)
+	return dst
+}
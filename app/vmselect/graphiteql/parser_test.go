@@ -0,0 +1,84 @@
+package graphiteql
+
+import (
+	"testing"
+)
+
+func TestParseSuccess(t *testing.T) {
+	another := func(s, resultExpected string) {
+		t.Helper()
+		expr, err := Parse(s)
+		if err != nil {
+			t.Fatalf("unexpected error when parsing %s: %s", s, err)
+		}
+		result := string(expr.AppendString(nil))
+		if result != resultExpected {
+			t.Fatalf("unexpected result when parsing %s;\ngot\n%s\nwant\n%s", s, result, resultExpected)
+		}
+	}
+	same := func(s string) {
+		t.Helper()
+		another(s, s)
+	}
+
+	// Metric paths
+	same("a")
+	same("foo.bar.baz")
+	same("foo.*.bar")
+	same("foo.{a,b,c}.bar")
+	same("foo.b[a-z]r.{x,y}*")
+	another("  foo.bar  ", "foo.bar")
+
+	// Literals
+	same(`"foo"`)
+	another(`'foo'`, `"foo"`)
+	another(`'f"o\'o'`, `"f\"o'o"`)
+	same("123")
+	same("-1.5")
+	another("1e3", "1000")
+	same("true")
+	another("False", "false")
+	same("None")
+
+	// Function calls
+	same("foo()")
+	same("sumSeries(foo.bar)")
+	same("sumSeries(foo.bar,x.*.y)")
+	another("sumSeries( foo.{a,b} , bar )", "sumSeries(foo.{a,b},bar)")
+	same(`aliasByNode(movingAverage(foo.*.bar,"5min"),1,2)`)
+	another(`summarize(foo.bar, '1h', 'sum', true)`, `summarize(foo.bar,"1h","sum",true)`)
+	another(`summarize(foo.bar, "1h", func="max", alignToFrom=false)`, `summarize(foo.bar,"1h",func="max",alignToFrom=false)`)
+	same("transformNull(foo,None)")
+}
+
+func TestParseFailure(t *testing.T) {
+	f := func(s string) {
+		t.Helper()
+		expr, err := Parse(s)
+		if err == nil {
+			t.Fatalf("expecting non-nil error when parsing %s", s)
+		}
+		if expr != nil {
+			t.Fatalf("expecting nil expr when parsing %s; got %s", s, expr.AppendString(nil))
+		}
+	}
+	f("")
+	f("   ")
+	f("(")
+	f(")")
+	f(",")
+	f("foo.{a,b")
+	f("foo.a}")
+	f(`"foo`)
+	f(`'foo"`)
+	f("foo(")
+	f("foo(bar")
+	f("foo(bar,")
+	f("foo(bar baz)")
+	f("foo(,)")
+	f("foo.bar(baz)")
+	f("foo(a=1,b)")
+	f("foo(a=)")
+	f("foo bar")
+	f("foo()bar")
+}
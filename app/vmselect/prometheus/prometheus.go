@@ -13,11 +13,14 @@ import (
 
 	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmselect/netstorage"
 	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmselect/promql"
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmselect/querystats"
 	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmselect/searchutils"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/encoding"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/fasttime"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/flagutil"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/httpserver"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logger"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/querytracer"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/storage"
 	"github.com/VictoriaMetrics/metrics"
 	"github.com/VictoriaMetrics/metricsql"
@@ -79,7 +82,7 @@ func FederateHandler(startTime time.Time, w http.ResponseWriter, r *http.Request
 		MaxTimestamp: end,
 		TagFilterss:  tagFilterss,
 	}
-	rss, err := netstorage.ProcessSearchQuery(sq, true, deadline)
+	rss, err := netstorage.ProcessSearchQuery(nil, sq, true, deadline)
 	if err != nil {
 		return fmt.Errorf("cannot fetch data for %q: %w", sq, err)
 	}
@@ -87,7 +90,7 @@ func FederateHandler(startTime time.Time, w http.ResponseWriter, r *http.Request
 	resultsCh := make(chan *quicktemplate.ByteBuffer)
 	doneCh := make(chan error)
 	go func() {
-		err := rss.RunParallel(func(rs *netstorage.Result, workerID uint) {
+		err := rss.RunParallel(nil, func(rs *netstorage.Result, workerID uint) {
 			bb := quicktemplate.AcquireByteBuffer()
 			WriteFederate(bb, rs)
 			resultsCh <- bb
@@ -150,6 +153,102 @@ func ExportHandler(startTime time.Time, w http.ResponseWriter, r *http.Request)
 
 var exportDuration = metrics.NewSummary(`vm_request_duration_seconds{path="/api/v1/export"}`)
 
+// ExportNativeHandler exports data in native format from /api/v1/export/native.
+func ExportNativeHandler(startTime time.Time, w http.ResponseWriter, r *http.Request) error {
+	ct := startTime.UnixNano() / 1e6
+	if err := r.ParseForm(); err != nil {
+		return fmt.Errorf("cannot parse request form values: %w", err)
+	}
+	matches := r.Form["match[]"]
+	if len(matches) == 0 {
+		// Maintain backwards compatibility
+		match := r.FormValue("match")
+		if len(match) == 0 {
+			return fmt.Errorf("missing `match[]` arg")
+		}
+		matches = []string{match}
+	}
+	start, err := searchutils.GetTime(r, "start", 0)
+	if err != nil {
+		return err
+	}
+	end, err := searchutils.GetTime(r, "end", ct)
+	if err != nil {
+		return err
+	}
+	deadline := searchutils.GetDeadlineForExport(r, startTime)
+	if start >= end {
+		end = start + defaultStep
+	}
+	if err := exportNativeHandler(w, matches, start, end, deadline); err != nil {
+		return fmt.Errorf("error when exporting data in native format for queries=%q on the time range (start=%d, end=%d): %w", matches, start, end, err)
+	}
+	exportNativeDuration.UpdateDuration(startTime)
+	return nil
+}
+
+var exportNativeDuration = metrics.NewSummary(`vm_request_duration_seconds{path="/api/v1/export/native"}`)
+
+func exportNativeHandler(w http.ResponseWriter, matches []string, start, end int64, deadline searchutils.Deadline) error {
+	tagFilterss, err := getTagFilterssFromMatches(matches)
+	if err != nil {
+		return err
+	}
+	sq := &storage.SearchQuery{
+		MinTimestamp: start,
+		MaxTimestamp: end,
+		TagFilterss:  tagFilterss,
+	}
+
+	resultsCh := make(chan *quicktemplate.ByteBuffer, runtime.GOMAXPROCS(-1))
+	doneCh := make(chan error)
+	go func() {
+		err := netstorage.ExportBlocks(sq, deadline, func(mn *storage.MetricName, b *storage.Block, tr storage.TimeRange) error {
+			bb := quicktemplate.AcquireByteBuffer()
+			tmpBuf := quicktemplate.AcquireByteBuffer()
+
+			// Marshal mn
+			tmpBuf.B = mn.Marshal(tmpBuf.B[:0])
+			bb.B = encoding.MarshalUint32(bb.B, uint32(len(tmpBuf.B)))
+			bb.B = append(bb.B, tmpBuf.B...)
+
+			// Marshal b
+			tmpBuf.B = b.MarshalPortable(tmpBuf.B[:0])
+			bb.B = encoding.MarshalUint32(bb.B, uint32(len(tmpBuf.B)))
+			bb.B = append(bb.B, tmpBuf.B...)
+
+			quicktemplate.ReleaseByteBuffer(tmpBuf)
+			resultsCh <- bb
+			return nil
+		})
+		close(resultsCh)
+		doneCh <- err
+	}()
+
+	w.Header().Set("Content-Type", "VictoriaMetrics/native")
+
+	// Marshal the time range, so the importer could drop samples outside it.
+	// Blocks may contain samples outside the requested time range.
+	var trBuf []byte
+	trBuf = encoding.MarshalInt64(trBuf, start)
+	trBuf = encoding.MarshalInt64(trBuf, end)
+	_, writeErr := w.Write(trBuf)
+	for bb := range resultsCh {
+		if writeErr == nil {
+			_, writeErr = w.Write(bb.B)
+		}
+		quicktemplate.ReleaseByteBuffer(bb)
+	}
+	err = <-doneCh
+	if err != nil {
+		return fmt.Errorf("error during data fetching: %w", err)
+	}
+	if writeErr != nil {
+		return fmt.Errorf("cannot send data to client: %w", writeErr)
+	}
+	return nil
+}
+
 func exportHandler(w http.ResponseWriter, matches []string, start, end int64, format string, maxRowsPerLine int, deadline searchutils.Deadline) error {
 	writeResponseFunc := WriteExportStdResponse
 	writeLineFunc := func(rs *netstorage.Result, resultsCh chan<- *quicktemplate.ByteBuffer) {
@@ -213,7 +312,7 @@ func exportHandler(w http.ResponseWriter, matches []string, start, end int64, fo
 		MaxTimestamp: end,
 		TagFilterss:  tagFilterss,
 	}
-	rss, err := netstorage.ProcessSearchQuery(sq, true, deadline)
+	rss, err := netstorage.ProcessSearchQuery(nil, sq, true, deadline)
 	if err != nil {
 		return fmt.Errorf("cannot fetch data for %q: %w", sq, err)
 	}
@@ -221,7 +320,7 @@ func exportHandler(w http.ResponseWriter, matches []string, start, end int64, fo
 	resultsCh := make(chan *quicktemplate.ByteBuffer, runtime.GOMAXPROCS(-1))
 	doneCh := make(chan error)
 	go func() {
-		err := rss.RunParallel(func(rs *netstorage.Result, workerID uint) {
+		err := rss.RunParallel(nil, func(rs *netstorage.Result, workerID uint) {
 			writeLineFunc(rs, resultsCh)
 		})
 		close(resultsCh)
@@ -351,14 +450,14 @@ func labelValuesWithMatches(labelName string, matches []string, start, end int64
 		MaxTimestamp: end,
 		TagFilterss:  tagFilterss,
 	}
-	rss, err := netstorage.ProcessSearchQuery(sq, false, deadline)
+	rss, err := netstorage.ProcessSearchQuery(nil, sq, false, deadline)
 	if err != nil {
 		return nil, fmt.Errorf("cannot fetch data for %q: %w", sq, err)
 	}
 
 	m := make(map[string]struct{})
 	var mLock sync.Mutex
-	err = rss.RunParallel(func(rs *netstorage.Result, workerID uint) {
+	err = rss.RunParallel(nil, func(rs *netstorage.Result, workerID uint) {
 		labelValue := rs.MetricName.GetTagValue(labelName)
 		if len(labelValue) == 0 {
 			return
@@ -401,19 +500,18 @@ const secsPerDay = 3600 * 24
 // TSDBStatusHandler processes /api/v1/status/tsdb request.
 //
 // See https://prometheus.io/docs/prometheus/latest/querying/api/#tsdb-stats
+//
+// It can accept `match[]` filters in order to narrow down the search,
+// `focusLabel` for obtaining series counts per each value of the given label
+// and `compareDate` for comparing the results with another date.
 func TSDBStatusHandler(startTime time.Time, w http.ResponseWriter, r *http.Request) error {
 	deadline := searchutils.GetDeadlineForQuery(r, startTime)
 	if err := r.ParseForm(); err != nil {
 		return fmt.Errorf("cannot parse form values: %w", err)
 	}
-	date := fasttime.UnixDate()
-	dateStr := r.FormValue("date")
-	if len(dateStr) > 0 {
-		t, err := time.Parse("2006-01-02", dateStr)
-		if err != nil {
-			return fmt.Errorf("cannot parse `date` arg %q: %w", dateStr, err)
-		}
-		date = uint64(t.Unix()) / secsPerDay
+	tr, err := getTSDBStatusTimeRange(r, startTime)
+	if err != nil {
+		return err
 	}
 	topN := 10
 	topNStr := r.FormValue("topN")
@@ -430,18 +528,114 @@ func TSDBStatusHandler(startTime time.Time, w http.ResponseWriter, r *http.Reque
 		}
 		topN = n
 	}
-	status, err := netstorage.GetTSDBStatusForDate(deadline, date, topN)
+	focusLabel := r.FormValue("focusLabel")
+	var tagFilterss [][]storage.TagFilter
+	if matches := r.Form["match[]"]; len(matches) > 0 {
+		tagFilterss, err = getTagFilterssFromMatches(matches)
+		if err != nil {
+			return err
+		}
+	}
+	status, err := netstorage.GetTSDBStatusWithFilters(deadline, tagFilterss, tr, focusLabel, topN)
 	if err != nil {
-		return fmt.Errorf(`cannot obtain tsdb status for date=%d, topN=%d: %w`, date, topN, err)
+		return fmt.Errorf(`cannot obtain tsdb status for time range %s, topN=%d: %w`, &tr, topN, err)
+	}
+	var statusPrev *storage.TSDBStatus
+	if len(r.FormValue("compareDate")) > 0 {
+		if len(r.FormValue("start")) > 0 || len(r.FormValue("end")) > 0 {
+			return fmt.Errorf("`compareDate` arg cannot be used together with `start` and `end` args")
+		}
+		compareDate, err := getTSDBStatusDate(r, "compareDate", 0)
+		if err != nil {
+			return err
+		}
+		statusPrev, err = netstorage.GetTSDBStatusForEntries(deadline, tagFilterss, getDateTimeRange(compareDate), focusLabel, status)
+		if err != nil {
+			return fmt.Errorf(`cannot obtain tsdb status for compareDate=%d: %w`, compareDate, err)
+		}
 	}
 	w.Header().Set("Content-Type", "application/json")
-	WriteTSDBStatusResponse(w, status)
+	WriteTSDBStatusResponse(w, status, statusPrev)
 	tsdbStatusDuration.UpdateDuration(startTime)
 	return nil
 }
 
+// getTSDBStatusTimeRange returns the time range for /api/v1/status/tsdb from either `date` or `start` and `end` args in r.
+//
+// The current date is returned if all these args are missing.
+func getTSDBStatusTimeRange(r *http.Request, startTime time.Time) (storage.TimeRange, error) {
+	if len(r.FormValue("start")) == 0 && len(r.FormValue("end")) == 0 {
+		date, err := getTSDBStatusDate(r, "date", fasttime.UnixDate())
+		if err != nil {
+			return storage.TimeRange{}, err
+		}
+		return getDateTimeRange(date), nil
+	}
+	if len(r.FormValue("date")) > 0 {
+		return storage.TimeRange{}, fmt.Errorf("`date` arg cannot be used together with `start` and `end` args")
+	}
+	ct := startTime.UnixNano() / 1e6
+	end, err := searchutils.GetTime(r, "end", ct)
+	if err != nil {
+		return storage.TimeRange{}, err
+	}
+	start, err := searchutils.GetTime(r, "start", end)
+	if err != nil {
+		return storage.TimeRange{}, err
+	}
+	if start > end {
+		return storage.TimeRange{}, fmt.Errorf("`start` arg cannot exceed `end` arg; got start=%d, end=%d", start, end)
+	}
+	return storage.TimeRange{
+		MinTimestamp: start,
+		MaxTimestamp: end,
+	}, nil
+}
+
+// getDateTimeRange returns the time range covering the given date in days since Unix epoch.
+func getDateTimeRange(date uint64) storage.TimeRange {
+	return storage.TimeRange{
+		MinTimestamp: int64(date) * secsPerDay * 1000,
+		MaxTimestamp: int64(date+1)*secsPerDay*1000 - 1,
+	}
+}
+
+// getTSDBStatusDate returns the date in days since Unix epoch from the given argKey in YYYY-MM-DD format.
+//
+// defaultValue is returned if argKey is missing in r.
+func getTSDBStatusDate(r *http.Request, argKey string, defaultValue uint64) (uint64, error) {
+	dateStr := r.FormValue(argKey)
+	if len(dateStr) == 0 {
+		return defaultValue, nil
+	}
+	t, err := time.Parse("2006-01-02", dateStr)
+	if err != nil {
+		return 0, fmt.Errorf("cannot parse `%s` arg %q: %w", argKey, dateStr, err)
+	}
+	return uint64(t.Unix()) / secsPerDay, nil
+}
+
 var tsdbStatusDuration = metrics.NewSummary(`vm_request_duration_seconds{path="/api/v1/status/tsdb"}`)
 
+// MetadataHandler processes /api/v1/metadata request.
+//
+// See https://prometheus.io/docs/prometheus/latest/querying/api/#querying-metric-metadata
+func MetadataHandler(startTime time.Time, w http.ResponseWriter, r *http.Request) error {
+	limit, err := searchutils.GetInt(r, "limit", 0)
+	if err != nil {
+		return err
+	}
+	metric := r.FormValue("metric")
+	rows := netstorage.GetMetricsMetadata(metric, limit)
+
+	w.Header().Set("Content-Type", "application/json")
+	WriteMetadataResponse(w, rows)
+	metadataDuration.UpdateDuration(startTime)
+	return nil
+}
+
+var metadataDuration = metrics.NewSummary(`vm_request_duration_seconds{path="/api/v1/metadata"}`)
+
 // LabelsHandler processes /api/v1/labels request.
 //
 // See https://prometheus.io/docs/prometheus/latest/querying/api/#getting-label-names
@@ -501,14 +695,14 @@ func labelsWithMatches(matches []string, start, end int64, deadline searchutils.
 		MaxTimestamp: end,
 		TagFilterss:  tagFilterss,
 	}
-	rss, err := netstorage.ProcessSearchQuery(sq, false, deadline)
+	rss, err := netstorage.ProcessSearchQuery(nil, sq, false, deadline)
 	if err != nil {
 		return nil, fmt.Errorf("cannot fetch data for %q: %w", sq, err)
 	}
 
 	m := make(map[string]struct{})
 	var mLock sync.Mutex
-	err = rss.RunParallel(func(rs *netstorage.Result, workerID uint) {
+	err = rss.RunParallel(nil, func(rs *netstorage.Result, workerID uint) {
 		mLock.Lock()
 		tags := rs.MetricName.Tags
 		for i := range tags {
@@ -586,7 +780,7 @@ func SeriesHandler(startTime time.Time, w http.ResponseWriter, r *http.Request)
 		MaxTimestamp: end,
 		TagFilterss:  tagFilterss,
 	}
-	rss, err := netstorage.ProcessSearchQuery(sq, false, deadline)
+	rss, err := netstorage.ProcessSearchQuery(nil, sq, false, deadline)
 	if err != nil {
 		return fmt.Errorf("cannot fetch data for %q: %w", sq, err)
 	}
@@ -594,7 +788,7 @@ func SeriesHandler(startTime time.Time, w http.ResponseWriter, r *http.Request)
 	resultsCh := make(chan *quicktemplate.ByteBuffer)
 	doneCh := make(chan error)
 	go func() {
-		err := rss.RunParallel(func(rs *netstorage.Result, workerID uint) {
+		err := rss.RunParallel(nil, func(rs *netstorage.Result, workerID uint) {
 			bb := quicktemplate.AcquireByteBuffer()
 			writemetricNameObject(bb, &rs.MetricName)
 			resultsCh <- bb
@@ -621,6 +815,31 @@ func SeriesHandler(startTime time.Time, w http.ResponseWriter, r *http.Request)
 
 var seriesDuration = metrics.NewSummary(`vm_request_duration_seconds{path="/api/v1/series"}`)
 
+// QueryStatsHandler returns query stats at `/api/v1/status/top_queries`
+func QueryStatsHandler(startTime time.Time, w http.ResponseWriter, r *http.Request) error {
+	if err := r.ParseForm(); err != nil {
+		return fmt.Errorf("cannot parse form values: %w", err)
+	}
+	topN, err := searchutils.GetInt(r, "topN", 20)
+	if err != nil {
+		return err
+	}
+	if topN < 0 {
+		return fmt.Errorf("`topN` arg cannot be negative; got %d", topN)
+	}
+	maxLifetimeMsecs, err := searchutils.GetDuration(r, "maxLifetime", 10*60*1000)
+	if err != nil {
+		return fmt.Errorf("cannot parse `maxLifetime` arg: %w", err)
+	}
+	maxLifetime := time.Duration(maxLifetimeMsecs) * time.Millisecond
+	w.Header().Set("Content-Type", "application/json")
+	querystats.WriteJSONQueryStats(w, topN, maxLifetime)
+	queryStatsDuration.UpdateDuration(startTime)
+	return nil
+}
+
+var queryStatsDuration = metrics.NewSummary(`vm_request_duration_seconds{path="/api/v1/status/top_queries"}`)
+
 // QueryHandler processes /api/v1/query request.
 //
 // See https://prometheus.io/docs/prometheus/latest/querying/api/#instant-queries
@@ -630,6 +849,7 @@ func QueryHandler(startTime time.Time, w http.ResponseWriter, r *http.Request) e
 	if len(query) == 0 {
 		return fmt.Errorf("missing `query` arg")
 	}
+	qt := querytracer.New(searchutils.GetBool(r, "trace"), "/api/v1/query: query=%s", query)
 	start, err := searchutils.GetTime(r, "time", ct)
 	if err != nil {
 		return err
@@ -693,7 +913,7 @@ func QueryHandler(startTime time.Time, w http.ResponseWriter, r *http.Request) e
 		start -= offset
 		end := start
 		start = end - window
-		if err := queryRangeHandler(startTime, w, childQuery, start, end, step, r, ct); err != nil {
+		if err := queryRangeHandler(qt, startTime, w, childQuery, start, end, step, r, ct); err != nil {
 			return fmt.Errorf("error when executing query=%q on the time range (start=%d, end=%d, step=%d): %w", childQuery, start, end, step, err)
 		}
 		queryDuration.UpdateDuration(startTime)
@@ -708,13 +928,14 @@ func QueryHandler(startTime time.Time, w http.ResponseWriter, r *http.Request) e
 		Deadline:         deadline,
 		LookbackDelta:    lookbackDelta,
 	}
-	result, err := promql.Exec(&ec, query, true)
+	result, err := promql.Exec(qt, &ec, query, true)
 	if err != nil {
 		return fmt.Errorf("error when executing query=%q for (time=%d, step=%d): %w", query, start, step, err)
 	}
+	qt.Donef("time=%d, step=%d, series=%d", start, step, len(result))
 
 	w.Header().Set("Content-Type", "application/json")
-	WriteQueryResponse(w, result)
+	WriteQueryResponse(w, result, qt)
 	queryDuration.UpdateDuration(startTime)
 	return nil
 }
@@ -756,14 +977,15 @@ func QueryRangeHandler(startTime time.Time, w http.ResponseWriter, r *http.Reque
 	if err != nil {
 		return err
 	}
-	if err := queryRangeHandler(startTime, w, query, start, end, step, r, ct); err != nil {
+	qt := querytracer.New(searchutils.GetBool(r, "trace"), "/api/v1/query_range: query=%s", query)
+	if err := queryRangeHandler(qt, startTime, w, query, start, end, step, r, ct); err != nil {
 		return fmt.Errorf("error when executing query=%q on the time range (start=%d, end=%d, step=%d): %w", query, start, end, step, err)
 	}
 	queryRangeDuration.UpdateDuration(startTime)
 	return nil
 }
 
-func queryRangeHandler(startTime time.Time, w http.ResponseWriter, query string, start, end, step int64, r *http.Request, ct int64) error {
+func queryRangeHandler(qt *querytracer.Tracer, startTime time.Time, w http.ResponseWriter, query string, start, end, step int64, r *http.Request, ct int64) error {
 	deadline := searchutils.GetDeadlineForQuery(r, startTime)
 	mayCache := !searchutils.GetBool(r, "nocache")
 	lookbackDelta, err := getMaxLookback(r)
@@ -794,7 +1016,7 @@ func queryRangeHandler(startTime time.Time, w http.ResponseWriter, query string,
 		MayCache:         mayCache,
 		LookbackDelta:    lookbackDelta,
 	}
-	result, err := promql.Exec(&ec, query, false)
+	result, err := promql.Exec(qt, &ec, query, false)
 	if err != nil {
 		return fmt.Errorf("cannot execute query: %w", err)
 	}
@@ -806,9 +1028,10 @@ func queryRangeHandler(startTime time.Time, w http.ResponseWriter, query string,
 	// Remove NaN values as Prometheus does.
 	// See https://github.com/VictoriaMetrics/VictoriaMetrics/issues/153
 	result = removeEmptyValuesAndTimeseries(result)
+	qt.Donef("start=%d, end=%d, step=%d, series=%d", start, end, step, len(result))
 
 	w.Header().Set("Content-Type", "application/json")
-	WriteQueryRangeResponse(w, result)
+	WriteQueryRangeResponse(w, result, qt)
 	return nil
 }
 
@@ -0,0 +1,353 @@
+package prometheus
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmselect/netstorage"
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmselect/searchutils"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/bytesutil"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/flagutil"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/prompb"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/prompbmarshal"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/storage"
+	"github.com/VictoriaMetrics/metrics"
+	"github.com/golang/snappy"
+)
+
+var maxRemoteReadRequestSize = flagutil.NewBytes("search.maxRemoteReadRequestSize", 1024*1024, "The maximum size in bytes of a single Prometheus remote_read API request")
+
+// maxChunkedReadResponseFrameSize is the maximum size of a single frame in STREAMED_XOR_CHUNKS response.
+//
+// Prometheus uses the same limit for its own responses.
+const maxChunkedReadResponseFrameSize = 1024 * 1024
+
+// RemoteReadHandler processes /api/v1/read request.
+//
+// See https://prometheus.io/docs/prometheus/latest/storage/#remote-storage-integrations
+func RemoteReadHandler(startTime time.Time, w http.ResponseWriter, r *http.Request) error {
+	deadline := searchutils.GetDeadlineForExport(r, startTime)
+	rr, err := readRemoteReadRequest(r.Body)
+	if err != nil {
+		return err
+	}
+	responseType, err := getRemoteReadResponseType(rr.AcceptedResponseTypes)
+	if err != nil {
+		return err
+	}
+	sqs := make([]*storage.SearchQuery, len(rr.Queries))
+	for i := range rr.Queries {
+		sq, err := getSearchQueryForRemoteReadQuery(&rr.Queries[i])
+		if err != nil {
+			return fmt.Errorf("cannot parse query #%d: %w", i, err)
+		}
+		sqs[i] = sq
+	}
+	switch responseType {
+	case prompb.ReadRequest_STREAMED_XOR_CHUNKS:
+		err = remoteReadStreamedXORChunks(w, sqs, deadline)
+	default:
+		err = remoteReadSamples(w, sqs, deadline)
+	}
+	if err != nil {
+		return err
+	}
+	remoteReadDuration.UpdateDuration(startTime)
+	return nil
+}
+
+var remoteReadDuration = metrics.NewSummary(`vm_request_duration_seconds{path="/api/v1/read"}`)
+
+func readRemoteReadRequest(r io.Reader) (*prompb.ReadRequest, error) {
+	lr := io.LimitReader(r, int64(maxRemoteReadRequestSize.N)+1)
+	reqBuf, err := ioutil.ReadAll(lr)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read remote_read request: %w", err)
+	}
+	if len(reqBuf) > maxRemoteReadRequestSize.N {
+		return nil, fmt.Errorf("too big packed remote_read request; mustn't exceed `-search.maxRemoteReadRequestSize=%d` bytes", maxRemoteReadRequestSize.N)
+	}
+	n, err := snappy.DecodedLen(reqBuf)
+	if err != nil {
+		return nil, fmt.Errorf("cannot determine the size of unpacked remote_read request: %w", err)
+	}
+	if n > maxRemoteReadRequestSize.N {
+		return nil, fmt.Errorf("too big unpacked remote_read request; mustn't exceed `-search.maxRemoteReadRequestSize=%d` bytes; got %d bytes", maxRemoteReadRequestSize.N, n)
+	}
+	buf, err := snappy.Decode(nil, reqBuf)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decompress remote_read request with size %d: %w", len(reqBuf), err)
+	}
+	var rr prompb.ReadRequest
+	if err := rr.Unmarshal(buf); err != nil {
+		return nil, fmt.Errorf("cannot unmarshal remote_read request with size %d: %w", len(buf), err)
+	}
+	return &rr, nil
+}
+
+// getRemoteReadResponseType returns the first supported response type from acceptedTypes.
+//
+// SAMPLES response type is returned if acceptedTypes is empty.
+func getRemoteReadResponseType(acceptedTypes []prompb.ReadRequest_ResponseType) (prompb.ReadRequest_ResponseType, error) {
+	if len(acceptedTypes) == 0 {
+		return prompb.ReadRequest_SAMPLES, nil
+	}
+	for _, rt := range acceptedTypes {
+		switch rt {
+		case prompb.ReadRequest_SAMPLES, prompb.ReadRequest_STREAMED_XOR_CHUNKS:
+			return rt, nil
+		}
+	}
+	return 0, fmt.Errorf("unsupported response types %v; supported types: %d (SAMPLES), %d (STREAMED_XOR_CHUNKS)",
+		acceptedTypes, prompb.ReadRequest_SAMPLES, prompb.ReadRequest_STREAMED_XOR_CHUNKS)
+}
+
+func getSearchQueryForRemoteReadQuery(q *prompb.Query) (*storage.SearchQuery, error) {
+	if len(q.Matchers) == 0 {
+		return nil, fmt.Errorf("missing label matchers")
+	}
+	tfs := make([]storage.TagFilter, 0, len(q.Matchers))
+	for _, lm := range q.Matchers {
+		tf := storage.TagFilter{
+			Key:   append([]byte{}, lm.Name...),
+			Value: append([]byte{}, lm.Value...),
+		}
+		if string(tf.Key) == "__name__" {
+			tf.Key = nil
+		}
+		switch lm.Type {
+		case prompb.LabelMatcher_EQ:
+		case prompb.LabelMatcher_NEQ:
+			tf.IsNegative = true
+		case prompb.LabelMatcher_RE:
+			tf.IsRegexp = true
+		case prompb.LabelMatcher_NRE:
+			tf.IsNegative = true
+			tf.IsRegexp = true
+		default:
+			return nil, fmt.Errorf("unsupported label matcher type %d for label %q", lm.Type, lm.Name)
+		}
+		tfs = append(tfs, tf)
+	}
+	sq := &storage.SearchQuery{
+		MinTimestamp: q.StartTimestampMs,
+		MaxTimestamp: q.EndTimestampMs,
+		TagFilterss:  [][]storage.TagFilter{tfs},
+	}
+	return sq, nil
+}
+
+// remoteReadSamples writes SAMPLES response for sqs to w.
+func remoteReadSamples(w http.ResponseWriter, sqs []*storage.SearchQuery, deadline searchutils.Deadline) error {
+	var resp prompbmarshal.ReadResponse
+	resp.Results = make([]prompbmarshal.QueryResult, len(sqs))
+	for i, sq := range sqs {
+		rss, err := netstorage.ProcessSearchQuery(nil, sq, true, deadline)
+		if err != nil {
+			return fmt.Errorf("cannot fetch data for %q: %w", sq, err)
+		}
+		var tssLock sync.Mutex
+		var tss []prompbmarshal.TimeSeries
+		err = rss.RunParallel(nil, func(rs *netstorage.Result, workerID uint) {
+			if len(rs.Timestamps) == 0 {
+				return
+			}
+			samples := make([]prompbmarshal.Sample, len(rs.Timestamps))
+			for j, ts := range rs.Timestamps {
+				samples[j] = prompbmarshal.Sample{
+					Value:     rs.Values[j],
+					Timestamp: ts,
+				}
+			}
+			ts := prompbmarshal.TimeSeries{
+				Labels:  getRemoteReadLabels(&rs.MetricName),
+				Samples: samples,
+			}
+			tssLock.Lock()
+			tss = append(tss, ts)
+			tssLock.Unlock()
+		})
+		if err != nil {
+			return fmt.Errorf("error during data fetching for %q: %w", sq, err)
+		}
+		sort.Slice(tss, func(i, j int) bool {
+			return lessLabels(tss[i].Labels, tss[j].Labels)
+		})
+		resp.Results[i].Timeseries = tss
+	}
+
+	bb := bbPool.Get()
+	defer bbPool.Put(bb)
+	bb.B = prompbmarshal.MarshalReadResponse(bb.B[:0], &resp)
+	zb := bbPool.Get()
+	defer bbPool.Put(zb)
+	zb.B = snappy.Encode(zb.B[:cap(zb.B)], bb.B)
+
+	w.Header().Set("Content-Type", "application/x-protobuf")
+	w.Header().Set("Content-Encoding", "snappy")
+	if _, err := w.Write(zb.B); err != nil {
+		return fmt.Errorf("cannot send remote_read response to client: %w", err)
+	}
+	return nil
+}
+
+// remoteReadStreamedXORChunks writes STREAMED_XOR_CHUNKS response for sqs to w.
+//
+// The response consists of frames with marshaled prompbmarshal.ChunkedReadResponse messages.
+// Every frame starts with uvarint-encoded message size followed by big-endian CRC32 Castagnoli checksum of the message.
+func remoteReadStreamedXORChunks(w http.ResponseWriter, sqs []*storage.SearchQuery, deadline searchutils.Deadline) error {
+	w.Header().Set("Content-Type", "application/x-streamed-protobuf; proto=prometheus.ChunkedReadResponse")
+	flusher, _ := w.(http.Flusher)
+	for i, sq := range sqs {
+		rss, err := netstorage.ProcessSearchQuery(nil, sq, true, deadline)
+		if err != nil {
+			return fmt.Errorf("cannot fetch data for %q: %w", sq, err)
+		}
+		// Series are encoded in parallel, but they must be streamed to the client in sorted order.
+		// So collect the encoded series at first. XOR chunks are much more compact than raw samples,
+		// so this should use reasonable amounts of memory.
+		var cssLock sync.Mutex
+		var css []prompbmarshal.ChunkedSeries
+		err = rss.RunParallel(nil, func(rs *netstorage.Result, workerID uint) {
+			if len(rs.Timestamps) == 0 {
+				return
+			}
+			cs := prompbmarshal.ChunkedSeries{
+				Labels: getRemoteReadLabels(&rs.MetricName),
+				Chunks: encodeXORChunks(rs.Timestamps, rs.Values),
+			}
+			cssLock.Lock()
+			css = append(css, cs)
+			cssLock.Unlock()
+		})
+		if err != nil {
+			return fmt.Errorf("error during data fetching for %q: %w", sq, err)
+		}
+		sort.Slice(css, func(i, j int) bool {
+			return lessLabels(css[i].Labels, css[j].Labels)
+		})
+		if err := writeChunkedSeries(w, css, int64(i)); err != nil {
+			return fmt.Errorf("cannot send remote_read response to client: %w", err)
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+	return nil
+}
+
+// writeChunkedSeries writes css for the query with the given queryIndex to w.
+//
+// Chunks for a single series are split into multiple frames if they exceed maxChunkedReadResponseFrameSize.
+func writeChunkedSeries(w io.Writer, css []prompbmarshal.ChunkedSeries, queryIndex int64) error {
+	bb := bbPool.Get()
+	defer bbPool.Put(bb)
+	for i := range css {
+		cs := &css[i]
+		chunks := cs.Chunks
+		for len(chunks) > 0 {
+			frameSize := 0
+			n := 0
+			for n < len(chunks) && (n == 0 || frameSize+len(chunks[n].Data) <= maxChunkedReadResponseFrameSize) {
+				frameSize += len(chunks[n].Data)
+				n++
+			}
+			crr := prompbmarshal.ChunkedReadResponse{
+				ChunkedSeries: []prompbmarshal.ChunkedSeries{{
+					Labels: cs.Labels,
+					Chunks: chunks[:n],
+				}},
+				QueryIndex: queryIndex,
+			}
+			chunks = chunks[n:]
+			bb.B = marshalChunkedReadResponseFrame(bb.B[:0], &crr)
+			if _, err := w.Write(bb.B); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// marshalChunkedReadResponseFrame appends crr frame to dst and returns the result.
+func marshalChunkedReadResponseFrame(dst []byte, crr *prompbmarshal.ChunkedReadResponse) []byte {
+	size := crr.Size()
+	var sizeBuf [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(sizeBuf[:], uint64(size))
+	dst = append(dst, sizeBuf[:n]...)
+	crcStart := len(dst)
+	dst = append(dst, 0, 0, 0, 0)
+	dataStart := len(dst)
+	dst = prompbmarshal.MarshalChunkedReadResponse(dst, crr)
+	crc := crc32.Checksum(dst[dataStart:], castagnoliTable)
+	binary.BigEndian.PutUint32(dst[crcStart:], crc)
+	return dst
+}
+
+var castagnoliTable = crc32.MakeTable(crc32.Castagnoli)
+
+// encodeXORChunks encodes the given samples into Prometheus XOR chunks.
+func encodeXORChunks(timestamps []int64, values []float64) []prompbmarshal.Chunk {
+	var chunks []prompbmarshal.Chunk
+	var e xorChunkEncoder
+	for len(timestamps) > 0 {
+		n := maxSamplesPerXORChunk
+		if n > len(timestamps) {
+			n = len(timestamps)
+		}
+		e.reset()
+		for j, ts := range timestamps[:n] {
+			e.append(ts, values[j])
+		}
+		chunks = append(chunks, prompbmarshal.Chunk{
+			MinTimeMs: timestamps[0],
+			MaxTimeMs: timestamps[n-1],
+			Type:      prompbmarshal.Chunk_XOR,
+			Data:      append([]byte{}, e.bytes()...),
+		})
+		timestamps = timestamps[n:]
+		values = values[n:]
+	}
+	return chunks
+}
+
+// getRemoteReadLabels returns labels sorted by name for the given mn.
+func getRemoteReadLabels(mn *storage.MetricName) []prompbmarshal.Label {
+	labels := make([]prompbmarshal.Label, 0, len(mn.Tags)+1)
+	labels = append(labels, prompbmarshal.Label{
+		Name:  "__name__",
+		Value: string(mn.MetricGroup),
+	})
+	for _, tag := range mn.Tags {
+		labels = append(labels, prompbmarshal.Label{
+			Name:  string(tag.Key),
+			Value: string(tag.Value),
+		})
+	}
+	sort.Slice(labels, func(i, j int) bool {
+		return labels[i].Name < labels[j].Name
+	})
+	return labels
+}
+
+// lessLabels returns true if a is less than b in the same way as Prometheus compares label sets.
+func lessLabels(a, b []prompbmarshal.Label) bool {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i].Name != b[i].Name {
+			return a[i].Name < b[i].Name
+		}
+		if a[i].Value != b[i].Value {
+			return a[i].Value < b[i].Value
+		}
+	}
+	return len(a) < len(b)
+}
+
+var bbPool bytesutil.ByteBufferPool
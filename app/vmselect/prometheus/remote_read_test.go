@@ -0,0 +1,412 @@
+package prometheus
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/prompb"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/prompbmarshal"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/storage"
+	"github.com/golang/snappy"
+)
+
+func TestXORChunkEncoder(t *testing.T) {
+	f := func(timestamps []int64, values []float64) {
+		t.Helper()
+		chunks := encodeXORChunks(timestamps, values)
+		var timestampsGot []int64
+		var valuesGot []float64
+		for i, chunk := range chunks {
+			if chunk.Type != prompbmarshal.Chunk_XOR {
+				t.Fatalf("unexpected chunk type for chunk #%d: %d", i, chunk.Type)
+			}
+			tsChunk, vsChunk, err := decodeXORChunk(chunk.Data)
+			if err != nil {
+				t.Fatalf("cannot decode chunk #%d: %s", i, err)
+			}
+			if len(tsChunk) > maxSamplesPerXORChunk {
+				t.Fatalf("too many samples in chunk #%d; got %d; mustn't exceed %d", i, len(tsChunk), maxSamplesPerXORChunk)
+			}
+			if chunk.MinTimeMs != tsChunk[0] || chunk.MaxTimeMs != tsChunk[len(tsChunk)-1] {
+				t.Fatalf("unexpected time range for chunk #%d; got [%d..%d]; want [%d..%d]",
+					i, chunk.MinTimeMs, chunk.MaxTimeMs, tsChunk[0], tsChunk[len(tsChunk)-1])
+			}
+			timestampsGot = append(timestampsGot, tsChunk...)
+			valuesGot = append(valuesGot, vsChunk...)
+		}
+		if !reflect.DeepEqual(timestampsGot, timestamps) {
+			t.Fatalf("unexpected timestamps;\ngot\n%v\nwant\n%v", timestampsGot, timestamps)
+		}
+		if len(valuesGot) != len(values) {
+			t.Fatalf("unexpected number of values; got %d; want %d", len(valuesGot), len(values))
+		}
+		for i, v := range values {
+			if math.Float64bits(v) != math.Float64bits(valuesGot[i]) {
+				t.Fatalf("unexpected value at position %d; got %v; want %v", i, valuesGot[i], v)
+			}
+		}
+	}
+	f(nil, nil)
+	f([]int64{1000}, []float64{1.5})
+	f([]int64{1000, 2000}, []float64{1.5, -2})
+	f([]int64{-1000, 0, 1000, 1000, 5000}, []float64{0, 0, 1e300, math.Inf(-1), math.NaN()})
+
+	// Regular samples with the same interval
+	var timestamps []int64
+	var values []float64
+	for i := 0; i < 1000; i++ {
+		timestamps = append(timestamps, 1600000000000+int64(i)*15000)
+		values = append(values, float64(i))
+	}
+	f(timestamps, values)
+
+	// Irregular samples with delta-of-delta values requiring all the encoding sizes
+	timestamps = timestamps[:0]
+	values = values[:0]
+	ts := int64(1600000000000)
+	deltas := []int64{1, 5000, 10, 70000, 1, 500000, 3, 1e10, 10, 10, 20}
+	for i := 0; i < 300; i++ {
+		ts += deltas[i%len(deltas)]
+		timestamps = append(timestamps, ts)
+		values = append(values, math.Sin(float64(i))*float64(i%7)*1e6)
+	}
+	f(timestamps, values)
+}
+
+func TestMarshalChunkedReadResponseFrame(t *testing.T) {
+	crr := &prompbmarshal.ChunkedReadResponse{
+		ChunkedSeries: []prompbmarshal.ChunkedSeries{{
+			Labels: []prompbmarshal.Label{{
+				Name:  "__name__",
+				Value: "foo",
+			}},
+			Chunks: encodeXORChunks([]int64{1, 2, 3}, []float64{4, 5, 6}),
+		}},
+		QueryIndex: 3,
+	}
+	data := marshalChunkedReadResponseFrame(nil, crr)
+	size, n := binary.Uvarint(data)
+	if n <= 0 {
+		t.Fatalf("cannot read frame size")
+	}
+	data = data[n:]
+	if len(data) != 4+int(size) {
+		t.Fatalf("unexpected frame length; got %d; want %d", len(data), 4+size)
+	}
+	crc := binary.BigEndian.Uint32(data)
+	msg := data[4:]
+	if crcExpected := crc32.Checksum(msg, castagnoliTable); crc != crcExpected {
+		t.Fatalf("unexpected crc; got %d; want %d", crc, crcExpected)
+	}
+	msgExpected, err := crr.Marshal()
+	if err != nil {
+		t.Fatalf("cannot marshal ChunkedReadResponse: %s", err)
+	}
+	if !bytes.Equal(msg, msgExpected) {
+		t.Fatalf("unexpected message;\ngot\n%X\nwant\n%X", msg, msgExpected)
+	}
+}
+
+func TestReadRemoteReadRequestSuccess(t *testing.T) {
+	// Construct ReadRequest with two queries and packed accepted_response_types.
+	var matcher1, matcher2, matcher3 []byte
+	matcher1 = appendProtoString(matcher1, 2, "__name__")
+	matcher1 = appendProtoString(matcher1, 3, "foo")
+	matcher2 = appendProtoVarint(matcher2, 1, uint64(prompb.LabelMatcher_NRE))
+	matcher2 = appendProtoString(matcher2, 2, "job")
+	matcher2 = appendProtoString(matcher2, 3, "bar.+")
+	matcher3 = appendProtoVarint(matcher3, 1, uint64(prompb.LabelMatcher_NEQ))
+	matcher3 = appendProtoString(matcher3, 2, "instance")
+	var query1, query2 []byte
+	query1 = appendProtoVarint(query1, 1, 1000)
+	query1 = appendProtoVarint(query1, 2, 2000)
+	query1 = appendProtoBytes(query1, 3, matcher1)
+	query1 = appendProtoBytes(query1, 3, matcher2)
+	// Unknown hints field must be skipped.
+	query1 = appendProtoBytes(query1, 4, appendProtoVarint(nil, 1, 15000))
+	query2 = appendProtoVarint(query2, 2, 3000)
+	query2 = appendProtoBytes(query2, 3, matcher3)
+	var req []byte
+	req = appendProtoBytes(req, 1, query1)
+	req = appendProtoBytes(req, 1, query2)
+	req = appendProtoBytes(req, 2, []byte{byte(prompb.ReadRequest_STREAMED_XOR_CHUNKS), byte(prompb.ReadRequest_SAMPLES)})
+
+	rr, err := readRemoteReadRequest(bytes.NewReader(snappy.Encode(nil, req)))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	rtsExpected := []prompb.ReadRequest_ResponseType{prompb.ReadRequest_STREAMED_XOR_CHUNKS, prompb.ReadRequest_SAMPLES}
+	if !reflect.DeepEqual(rr.AcceptedResponseTypes, rtsExpected) {
+		t.Fatalf("unexpected AcceptedResponseTypes; got %v; want %v", rr.AcceptedResponseTypes, rtsExpected)
+	}
+	if len(rr.Queries) != 2 {
+		t.Fatalf("unexpected number of queries; got %d; want 2", len(rr.Queries))
+	}
+
+	sq, err := getSearchQueryForRemoteReadQuery(&rr.Queries[0])
+	if err != nil {
+		t.Fatalf("cannot create search query: %s", err)
+	}
+	sqExpected := &storage.SearchQuery{
+		MinTimestamp: 1000,
+		MaxTimestamp: 2000,
+		TagFilterss: [][]storage.TagFilter{{
+			{
+				Value: []byte("foo"),
+			},
+			{
+				Key:        []byte("job"),
+				Value:      []byte("bar.+"),
+				IsNegative: true,
+				IsRegexp:   true,
+			},
+		}},
+	}
+	if !reflect.DeepEqual(sq, sqExpected) {
+		t.Fatalf("unexpected search query;\ngot\n%s\nwant\n%s", sq, sqExpected)
+	}
+
+	sq, err = getSearchQueryForRemoteReadQuery(&rr.Queries[1])
+	if err != nil {
+		t.Fatalf("cannot create search query: %s", err)
+	}
+	sqExpected = &storage.SearchQuery{
+		MinTimestamp: 0,
+		MaxTimestamp: 3000,
+		TagFilterss: [][]storage.TagFilter{{
+			{
+				Key:        []byte("instance"),
+				Value:      []byte{},
+				IsNegative: true,
+			},
+		}},
+	}
+	if !reflect.DeepEqual(sq, sqExpected) {
+		t.Fatalf("unexpected search query;\ngot\n%s\nwant\n%s", sq, sqExpected)
+	}
+}
+
+func TestReadRemoteReadRequestFailure(t *testing.T) {
+	f := func(data []byte) {
+		t.Helper()
+		if _, err := readRemoteReadRequest(bytes.NewReader(data)); err == nil {
+			t.Fatalf("expecting non-nil error")
+		}
+	}
+	// Not snappy-compressed data
+	f([]byte("foobar"))
+
+	// Invalid protobuf message
+	f(snappy.Encode(nil, []byte{0xa, 0x10, 1}))
+}
+
+func TestGetRemoteReadResponseType(t *testing.T) {
+	f := func(acceptedTypes []prompb.ReadRequest_ResponseType, rtExpected prompb.ReadRequest_ResponseType) {
+		t.Helper()
+		rt, err := getRemoteReadResponseType(acceptedTypes)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if rt != rtExpected {
+			t.Fatalf("unexpected response type; got %d; want %d", rt, rtExpected)
+		}
+	}
+	f(nil, prompb.ReadRequest_SAMPLES)
+	f([]prompb.ReadRequest_ResponseType{prompb.ReadRequest_SAMPLES}, prompb.ReadRequest_SAMPLES)
+	f([]prompb.ReadRequest_ResponseType{prompb.ReadRequest_STREAMED_XOR_CHUNKS, prompb.ReadRequest_SAMPLES}, prompb.ReadRequest_STREAMED_XOR_CHUNKS)
+	f([]prompb.ReadRequest_ResponseType{123, prompb.ReadRequest_SAMPLES}, prompb.ReadRequest_SAMPLES)
+
+	if _, err := getRemoteReadResponseType([]prompb.ReadRequest_ResponseType{123}); err == nil {
+		t.Fatalf("expecting non-nil error for unsupported response type")
+	}
+}
+
+func TestGetRemoteReadLabels(t *testing.T) {
+	mn := &storage.MetricName{
+		MetricGroup: []byte("foo"),
+		Tags: []storage.Tag{
+			{Key: []byte("job"), Value: []byte("x")},
+			{Key: []byte("Instance"), Value: []byte("y")},
+		},
+	}
+	labels := getRemoteReadLabels(mn)
+	labelsExpected := []prompbmarshal.Label{
+		{Name: "Instance", Value: "y"},
+		{Name: "__name__", Value: "foo"},
+		{Name: "job", Value: "x"},
+	}
+	if !reflect.DeepEqual(labels, labelsExpected) {
+		t.Fatalf("unexpected labels;\ngot\n%v\nwant\n%v", labels, labelsExpected)
+	}
+}
+
+func appendProtoVarint(dst []byte, fieldNum int, v uint64) []byte {
+	dst = appendUvarint(dst, uint64(fieldNum<<3))
+	return appendUvarint(dst, v)
+}
+
+func appendProtoBytes(dst []byte, fieldNum int, b []byte) []byte {
+	dst = appendUvarint(dst, uint64(fieldNum<<3|2))
+	dst = appendUvarint(dst, uint64(len(b)))
+	return append(dst, b...)
+}
+
+func appendUvarint(dst []byte, v uint64) []byte {
+	var buf [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(buf[:], v)
+	return append(dst, buf[:n]...)
+}
+
+func appendProtoString(dst []byte, fieldNum int, s string) []byte {
+	return appendProtoBytes(dst, fieldNum, []byte(s))
+}
+
+// decodeXORChunk decodes Prometheus XOR chunk.
+//
+// See https://github.com/prometheus/prometheus/blob/master/tsdb/chunkenc/xor.go
+func decodeXORChunk(data []byte) ([]int64, []float64, error) {
+	if len(data) < 2 {
+		return nil, nil, fmt.Errorf("too short chunk; got %d bytes", len(data))
+	}
+	samplesCount := int(binary.BigEndian.Uint16(data))
+	br := &bitReader{
+		b: data[2:],
+	}
+	var timestamps []int64
+	var values []float64
+	var t int64
+	var tDelta uint64
+	var v uint64
+	var leading, trailing uint8
+	for i := 0; i < samplesCount; i++ {
+		switch i {
+		case 0:
+			ts, err := binary.ReadVarint(br)
+			if err != nil {
+				return nil, nil, err
+			}
+			t = ts
+			if v, err = br.readBits(64); err != nil {
+				return nil, nil, err
+			}
+		default:
+			if i == 1 {
+				d, err := binary.ReadUvarint(br)
+				if err != nil {
+					return nil, nil, err
+				}
+				tDelta = d
+			} else {
+				var prefix, nbits int
+				for prefix < 4 {
+					bit, err := br.readBit()
+					if err != nil {
+						return nil, nil, err
+					}
+					if !bit {
+						break
+					}
+					prefix++
+				}
+				switch prefix {
+				case 1:
+					nbits = 14
+				case 2:
+					nbits = 17
+				case 3:
+					nbits = 20
+				case 4:
+					nbits = 64
+				}
+				var dod int64
+				if nbits > 0 {
+					bits, err := br.readBits(nbits)
+					if err != nil {
+						return nil, nil, err
+					}
+					dod = int64(bits)
+					if nbits < 64 && bits > (1<<(nbits-1)) {
+						// Sign-extend the delta-of-delta.
+						dod -= 1 << nbits
+					}
+				}
+				tDelta = uint64(int64(tDelta) + dod)
+			}
+			t += int64(tDelta)
+
+			bit, err := br.readBit()
+			if err != nil {
+				return nil, nil, err
+			}
+			if bit {
+				bit, err = br.readBit()
+				if err != nil {
+					return nil, nil, err
+				}
+				if bit {
+					l, err := br.readBits(5)
+					if err != nil {
+						return nil, nil, err
+					}
+					sigbits, err := br.readBits(6)
+					if err != nil {
+						return nil, nil, err
+					}
+					if sigbits == 0 {
+						sigbits = 64
+					}
+					leading = uint8(l)
+					trailing = 64 - leading - uint8(sigbits)
+				}
+				bits, err := br.readBits(64 - int(leading) - int(trailing))
+				if err != nil {
+					return nil, nil, err
+				}
+				v ^= bits << trailing
+			}
+		}
+		timestamps = append(timestamps, t)
+		values = append(values, math.Float64frombits(v))
+	}
+	return timestamps, values, nil
+}
+
+type bitReader struct {
+	b     []byte
+	nbits uint
+}
+
+func (br *bitReader) readBit() (bool, error) {
+	if br.nbits >= uint(len(br.b))*8 {
+		return false, fmt.Errorf("unexpected end of chunk")
+	}
+	byt := br.b[br.nbits/8]
+	bit := byt&(0x80>>(br.nbits%8)) != 0
+	br.nbits++
+	return bit, nil
+}
+
+func (br *bitReader) readBits(nbits int) (uint64, error) {
+	var u uint64
+	for i := 0; i < nbits; i++ {
+		bit, err := br.readBit()
+		if err != nil {
+			return 0, err
+		}
+		u <<= 1
+		if bit {
+			u |= 1
+		}
+	}
+	return u, nil
+}
+
+func (br *bitReader) ReadByte() (byte, error) {
+	u, err := br.readBits(8)
+	return byte(u), err
+}
@@ -23,101 +23,147 @@ var (
 )
 
 //line app/vmselect/prometheus/tsdb_status_response.qtpl:5
-func StreamTSDBStatusResponse(qw422016 *qt422016.Writer, status *storage.TSDBStatus) {
-//line app/vmselect/prometheus/tsdb_status_response.qtpl:5
-	qw422016.N().S(`{"status":"success","data":{"seriesCountByMetricName":`)
-//line app/vmselect/prometheus/tsdb_status_response.qtpl:9
-	streamtsdbStatusEntries(qw422016, status.SeriesCountByMetricName)
-//line app/vmselect/prometheus/tsdb_status_response.qtpl:9
+func StreamTSDBStatusResponse(qw422016 *qt422016.Writer, status, statusPrev *storage.TSDBStatus) {
+//line app/vmselect/prometheus/tsdb_status_response.qtpl:7
+	var prev storage.TSDBStatus
+	if statusPrev != nil {
+		prev = *statusPrev
+	}
+
+//line app/vmselect/prometheus/tsdb_status_response.qtpl:11
+	qw422016.N().S(`{"status":"success","data":{"totalSeries":`)
+//line app/vmselect/prometheus/tsdb_status_response.qtpl:15
+	qw422016.N().DUL(status.TotalSeries)
+//line app/vmselect/prometheus/tsdb_status_response.qtpl:15
+	qw422016.N().S(`,"totalLabelValuePairs":`)
+//line app/vmselect/prometheus/tsdb_status_response.qtpl:16
+	qw422016.N().DUL(status.TotalLabelValuePairs)
+//line app/vmselect/prometheus/tsdb_status_response.qtpl:16
+	qw422016.N().S(`,`)
+//line app/vmselect/prometheus/tsdb_status_response.qtpl:17
+	if statusPrev != nil {
+//line app/vmselect/prometheus/tsdb_status_response.qtpl:17
+		qw422016.N().S(`"totalSeriesPrev":`)
+//line app/vmselect/prometheus/tsdb_status_response.qtpl:18
+		qw422016.N().DUL(statusPrev.TotalSeries)
+//line app/vmselect/prometheus/tsdb_status_response.qtpl:18
+		qw422016.N().S(`,"totalLabelValuePairsPrev":`)
+//line app/vmselect/prometheus/tsdb_status_response.qtpl:19
+		qw422016.N().DUL(statusPrev.TotalLabelValuePairs)
+//line app/vmselect/prometheus/tsdb_status_response.qtpl:19
+		qw422016.N().S(`,`)
+//line app/vmselect/prometheus/tsdb_status_response.qtpl:20
+	}
+//line app/vmselect/prometheus/tsdb_status_response.qtpl:20
+	qw422016.N().S(`"seriesCountByMetricName":`)
+//line app/vmselect/prometheus/tsdb_status_response.qtpl:21
+	streamtsdbStatusEntries(qw422016, status.SeriesCountByMetricName, prev.SeriesCountByMetricName)
+//line app/vmselect/prometheus/tsdb_status_response.qtpl:21
+	qw422016.N().S(`,"seriesCountByLabelName":`)
+//line app/vmselect/prometheus/tsdb_status_response.qtpl:22
+	streamtsdbStatusEntries(qw422016, status.SeriesCountByLabelName, prev.SeriesCountByLabelName)
+//line app/vmselect/prometheus/tsdb_status_response.qtpl:22
+	qw422016.N().S(`,"seriesCountByFocusLabelValue":`)
+//line app/vmselect/prometheus/tsdb_status_response.qtpl:23
+	streamtsdbStatusEntries(qw422016, status.SeriesCountByFocusLabelValue, prev.SeriesCountByFocusLabelValue)
+//line app/vmselect/prometheus/tsdb_status_response.qtpl:23
 	qw422016.N().S(`,"labelValueCountByLabelName":`)
-//line app/vmselect/prometheus/tsdb_status_response.qtpl:10
-	streamtsdbStatusEntries(qw422016, status.LabelValueCountByLabelName)
-//line app/vmselect/prometheus/tsdb_status_response.qtpl:10
+//line app/vmselect/prometheus/tsdb_status_response.qtpl:24
+	streamtsdbStatusEntries(qw422016, status.LabelValueCountByLabelName, prev.LabelValueCountByLabelName)
+//line app/vmselect/prometheus/tsdb_status_response.qtpl:24
 	qw422016.N().S(`,"seriesCountByLabelValuePair":`)
-//line app/vmselect/prometheus/tsdb_status_response.qtpl:11
-	streamtsdbStatusEntries(qw422016, status.SeriesCountByLabelValuePair)
-//line app/vmselect/prometheus/tsdb_status_response.qtpl:11
+//line app/vmselect/prometheus/tsdb_status_response.qtpl:25
+	streamtsdbStatusEntries(qw422016, status.SeriesCountByLabelValuePair, prev.SeriesCountByLabelValuePair)
+//line app/vmselect/prometheus/tsdb_status_response.qtpl:25
 	qw422016.N().S(`}}`)
-//line app/vmselect/prometheus/tsdb_status_response.qtpl:14
+//line app/vmselect/prometheus/tsdb_status_response.qtpl:28
 }
 
-//line app/vmselect/prometheus/tsdb_status_response.qtpl:14
-func WriteTSDBStatusResponse(qq422016 qtio422016.Writer, status *storage.TSDBStatus) {
-//line app/vmselect/prometheus/tsdb_status_response.qtpl:14
+//line app/vmselect/prometheus/tsdb_status_response.qtpl:28
+func WriteTSDBStatusResponse(qq422016 qtio422016.Writer, status, statusPrev *storage.TSDBStatus) {
+//line app/vmselect/prometheus/tsdb_status_response.qtpl:28
 	qw422016 := qt422016.AcquireWriter(qq422016)
-//line app/vmselect/prometheus/tsdb_status_response.qtpl:14
-	StreamTSDBStatusResponse(qw422016, status)
-//line app/vmselect/prometheus/tsdb_status_response.qtpl:14
+//line app/vmselect/prometheus/tsdb_status_response.qtpl:28
+	StreamTSDBStatusResponse(qw422016, status, statusPrev)
+//line app/vmselect/prometheus/tsdb_status_response.qtpl:28
 	qt422016.ReleaseWriter(qw422016)
-//line app/vmselect/prometheus/tsdb_status_response.qtpl:14
+//line app/vmselect/prometheus/tsdb_status_response.qtpl:28
 }
 
-//line app/vmselect/prometheus/tsdb_status_response.qtpl:14
-func TSDBStatusResponse(status *storage.TSDBStatus) string {
-//line app/vmselect/prometheus/tsdb_status_response.qtpl:14
+//line app/vmselect/prometheus/tsdb_status_response.qtpl:28
+func TSDBStatusResponse(status, statusPrev *storage.TSDBStatus) string {
+//line app/vmselect/prometheus/tsdb_status_response.qtpl:28
 	qb422016 := qt422016.AcquireByteBuffer()
-//line app/vmselect/prometheus/tsdb_status_response.qtpl:14
-	WriteTSDBStatusResponse(qb422016, status)
-//line app/vmselect/prometheus/tsdb_status_response.qtpl:14
+//line app/vmselect/prometheus/tsdb_status_response.qtpl:28
+	WriteTSDBStatusResponse(qb422016, status, statusPrev)
+//line app/vmselect/prometheus/tsdb_status_response.qtpl:28
 	qs422016 := string(qb422016.B)
-//line app/vmselect/prometheus/tsdb_status_response.qtpl:14
+//line app/vmselect/prometheus/tsdb_status_response.qtpl:28
 	qt422016.ReleaseByteBuffer(qb422016)
-//line app/vmselect/prometheus/tsdb_status_response.qtpl:14
+//line app/vmselect/prometheus/tsdb_status_response.qtpl:28
 	return qs422016
-//line app/vmselect/prometheus/tsdb_status_response.qtpl:14
+//line app/vmselect/prometheus/tsdb_status_response.qtpl:28
 }
 
-//line app/vmselect/prometheus/tsdb_status_response.qtpl:16
-func streamtsdbStatusEntries(qw422016 *qt422016.Writer, a []storage.TopHeapEntry) {
-//line app/vmselect/prometheus/tsdb_status_response.qtpl:16
+//line app/vmselect/prometheus/tsdb_status_response.qtpl:30
+func streamtsdbStatusEntries(qw422016 *qt422016.Writer, a, prev []storage.TopHeapEntry) {
+//line app/vmselect/prometheus/tsdb_status_response.qtpl:30
 	qw422016.N().S(`[`)
-//line app/vmselect/prometheus/tsdb_status_response.qtpl:18
+//line app/vmselect/prometheus/tsdb_status_response.qtpl:32
 	for i, e := range a {
-//line app/vmselect/prometheus/tsdb_status_response.qtpl:18
+//line app/vmselect/prometheus/tsdb_status_response.qtpl:32
 		qw422016.N().S(`{"name":`)
-//line app/vmselect/prometheus/tsdb_status_response.qtpl:20
+//line app/vmselect/prometheus/tsdb_status_response.qtpl:34
 		qw422016.N().Q(e.Name)
-//line app/vmselect/prometheus/tsdb_status_response.qtpl:20
+//line app/vmselect/prometheus/tsdb_status_response.qtpl:34
 		qw422016.N().S(`,"value":`)
-//line app/vmselect/prometheus/tsdb_status_response.qtpl:21
+//line app/vmselect/prometheus/tsdb_status_response.qtpl:35
 		qw422016.N().D(int(e.Count))
-//line app/vmselect/prometheus/tsdb_status_response.qtpl:21
+//line app/vmselect/prometheus/tsdb_status_response.qtpl:36
+		if i < len(prev) {
+//line app/vmselect/prometheus/tsdb_status_response.qtpl:36
+			qw422016.N().S(`,"valuePrev":`)
+//line app/vmselect/prometheus/tsdb_status_response.qtpl:37
+			qw422016.N().D(int(prev[i].Count))
+//line app/vmselect/prometheus/tsdb_status_response.qtpl:38
+		}
+//line app/vmselect/prometheus/tsdb_status_response.qtpl:38
 		qw422016.N().S(`}`)
-//line app/vmselect/prometheus/tsdb_status_response.qtpl:23
+//line app/vmselect/prometheus/tsdb_status_response.qtpl:40
 		if i+1 < len(a) {
-//line app/vmselect/prometheus/tsdb_status_response.qtpl:23
+//line app/vmselect/prometheus/tsdb_status_response.qtpl:40
 			qw422016.N().S(`,`)
-//line app/vmselect/prometheus/tsdb_status_response.qtpl:23
+//line app/vmselect/prometheus/tsdb_status_response.qtpl:40
 		}
-//line app/vmselect/prometheus/tsdb_status_response.qtpl:24
+//line app/vmselect/prometheus/tsdb_status_response.qtpl:41
 	}
-//line app/vmselect/prometheus/tsdb_status_response.qtpl:24
+//line app/vmselect/prometheus/tsdb_status_response.qtpl:41
 	qw422016.N().S(`]`)
-//line app/vmselect/prometheus/tsdb_status_response.qtpl:26
+//line app/vmselect/prometheus/tsdb_status_response.qtpl:43
 }
 
-//line app/vmselect/prometheus/tsdb_status_response.qtpl:26
-func writetsdbStatusEntries(qq422016 qtio422016.Writer, a []storage.TopHeapEntry) {
-//line app/vmselect/prometheus/tsdb_status_response.qtpl:26
+//line app/vmselect/prometheus/tsdb_status_response.qtpl:43
+func writetsdbStatusEntries(qq422016 qtio422016.Writer, a, prev []storage.TopHeapEntry) {
+//line app/vmselect/prometheus/tsdb_status_response.qtpl:43
 	qw422016 := qt422016.AcquireWriter(qq422016)
-//line app/vmselect/prometheus/tsdb_status_response.qtpl:26
-	streamtsdbStatusEntries(qw422016, a)
-//line app/vmselect/prometheus/tsdb_status_response.qtpl:26
+//line app/vmselect/prometheus/tsdb_status_response.qtpl:43
+	streamtsdbStatusEntries(qw422016, a, prev)
+//line app/vmselect/prometheus/tsdb_status_response.qtpl:43
 	qt422016.ReleaseWriter(qw422016)
-//line app/vmselect/prometheus/tsdb_status_response.qtpl:26
+//line app/vmselect/prometheus/tsdb_status_response.qtpl:43
 }
 
-//line app/vmselect/prometheus/tsdb_status_response.qtpl:26
-func tsdbStatusEntries(a []storage.TopHeapEntry) string {
-//line app/vmselect/prometheus/tsdb_status_response.qtpl:26
+//line app/vmselect/prometheus/tsdb_status_response.qtpl:43
+func tsdbStatusEntries(a, prev []storage.TopHeapEntry) string {
+//line app/vmselect/prometheus/tsdb_status_response.qtpl:43
 	qb422016 := qt422016.AcquireByteBuffer()
-//line app/vmselect/prometheus/tsdb_status_response.qtpl:26
-	writetsdbStatusEntries(qb422016, a)
-//line app/vmselect/prometheus/tsdb_status_response.qtpl:26
+//line app/vmselect/prometheus/tsdb_status_response.qtpl:43
+	writetsdbStatusEntries(qb422016, a, prev)
+//line app/vmselect/prometheus/tsdb_status_response.qtpl:43
 	qs422016 := string(qb422016.B)
-//line app/vmselect/prometheus/tsdb_status_response.qtpl:26
+//line app/vmselect/prometheus/tsdb_status_response.qtpl:43
 	qt422016.ReleaseByteBuffer(qb422016)
-//line app/vmselect/prometheus/tsdb_status_response.qtpl:26
+//line app/vmselect/prometheus/tsdb_status_response.qtpl:43
 	return qs422016
-//line app/vmselect/prometheus/tsdb_status_response.qtpl:26
+//line app/vmselect/prometheus/tsdb_status_response.qtpl:43
 }
@@ -0,0 +1,199 @@
+package prometheus
+
+import (
+	"encoding/binary"
+	"math"
+	"math/bits"
+)
+
+// maxSamplesPerXORChunk is the maximum number of samples per XOR chunk.
+//
+// Prometheus uses the same limit for its own chunks.
+const maxSamplesPerXORChunk = 120
+
+// xorChunkEncoder encodes samples into Prometheus XOR chunk.
+//
+// See https://github.com/prometheus/prometheus/blob/master/tsdb/chunkenc/xor.go
+type xorChunkEncoder struct {
+	bs bitStream
+
+	samplesCount int
+
+	t      int64
+	tDelta uint64
+	v      float64
+
+	leading  uint8
+	trailing uint8
+}
+
+// reset prepares e for encoding a new chunk.
+func (e *xorChunkEncoder) reset() {
+	e.bs.reset()
+	// The first two bytes contain the number of samples in the chunk.
+	e.bs.b = append(e.bs.b, 0, 0)
+	e.samplesCount = 0
+	e.t = 0
+	e.tDelta = 0
+	e.v = 0
+	e.leading = 0xff
+	e.trailing = 0
+}
+
+// bytes returns the encoded chunk.
+//
+// The returned result is valid until the next call to e.
+func (e *xorChunkEncoder) bytes() []byte {
+	return e.bs.b
+}
+
+// append appends (t, v) sample to the chunk.
+func (e *xorChunkEncoder) append(t int64, v float64) {
+	var tDelta uint64
+	switch e.samplesCount {
+	case 0:
+		e.bs.writeVarint(t)
+		e.bs.writeBits(math.Float64bits(v), 64)
+	case 1:
+		tDelta = uint64(t - e.t)
+		e.bs.writeUvarint(tDelta)
+		e.writeVDelta(v)
+	default:
+		tDelta = uint64(t - e.t)
+		dod := int64(tDelta - e.tDelta)
+		switch {
+		case dod == 0:
+			e.bs.writeBit(false)
+		case bitRange(dod, 14):
+			e.bs.writeBits(0x02, 2)
+			e.bs.writeBits(uint64(dod), 14)
+		case bitRange(dod, 17):
+			e.bs.writeBits(0x06, 3)
+			e.bs.writeBits(uint64(dod), 17)
+		case bitRange(dod, 20):
+			e.bs.writeBits(0x0e, 4)
+			e.bs.writeBits(uint64(dod), 20)
+		default:
+			e.bs.writeBits(0x0f, 4)
+			e.bs.writeBits(uint64(dod), 64)
+		}
+		e.writeVDelta(v)
+	}
+	e.t = t
+	e.v = v
+	e.tDelta = tDelta
+	e.samplesCount++
+	binary.BigEndian.PutUint16(e.bs.b, uint16(e.samplesCount))
+}
+
+func (e *xorChunkEncoder) writeVDelta(v float64) {
+	vDelta := math.Float64bits(v) ^ math.Float64bits(e.v)
+	if vDelta == 0 {
+		e.bs.writeBit(false)
+		return
+	}
+	e.bs.writeBit(true)
+
+	leading := uint8(bits.LeadingZeros64(vDelta))
+	trailing := uint8(bits.TrailingZeros64(vDelta))
+
+	// Clamp number of leading zeros to avoid overflow when encoding.
+	if leading >= 32 {
+		leading = 31
+	}
+
+	if e.leading != 0xff && leading >= e.leading && trailing >= e.trailing {
+		// Re-use the leading and trailing zeros from the previous value.
+		e.bs.writeBit(false)
+		e.bs.writeBits(vDelta>>e.trailing, 64-int(e.leading)-int(e.trailing))
+		return
+	}
+	e.leading, e.trailing = leading, trailing
+
+	e.bs.writeBit(true)
+	e.bs.writeBits(uint64(leading), 5)
+
+	// Note that if leading == trailing == 0, then sigbits == 64.
+	// But that value doesn't actually fit into the 6 bits we have.
+	// Luckily, we never need to encode 0 significant bits, since that would put us in the other case (vDelta == 0).
+	// So instead we write out a 0 and adjust it back to 64 on unpacking.
+	sigbits := 64 - leading - trailing
+	e.bs.writeBits(uint64(sigbits), 6)
+	e.bs.writeBits(vDelta>>trailing, int(sigbits))
+}
+
+// bitRange returns true if x fits into nbits signed integer.
+func bitRange(x int64, nbits uint8) bool {
+	return -((1<<(nbits-1))-1) <= x && x <= 1<<(nbits-1)
+}
+
+// bitStream is a stream of bits.
+type bitStream struct {
+	b []byte
+
+	// count is the number of free bits in the last byte of b.
+	count uint8
+}
+
+func (bs *bitStream) reset() {
+	bs.b = bs.b[:0]
+	bs.count = 0
+}
+
+func (bs *bitStream) writeBit(bit bool) {
+	if bs.count == 0 {
+		bs.b = append(bs.b, 0)
+		bs.count = 8
+	}
+	i := len(bs.b) - 1
+	if bit {
+		bs.b[i] |= 1 << (bs.count - 1)
+	}
+	bs.count--
+}
+
+func (bs *bitStream) writeByte(byt byte) {
+	if bs.count == 0 {
+		bs.b = append(bs.b, 0)
+		bs.count = 8
+	}
+	i := len(bs.b) - 1
+
+	// Fill up the remaining bits of the last byte with the highest bits of byt.
+	bs.b[i] |= byt >> (8 - bs.count)
+
+	// Put the remaining bits of byt into the next byte.
+	bs.b = append(bs.b, byt<<bs.count)
+}
+
+// writeBits writes the lowest nbits of u to bs.
+func (bs *bitStream) writeBits(u uint64, nbits int) {
+	u <<= 64 - uint(nbits)
+	for nbits >= 8 {
+		byt := byte(u >> 56)
+		bs.writeByte(byt)
+		u <<= 8
+		nbits -= 8
+	}
+	for nbits > 0 {
+		bs.writeBit((u >> 63) == 1)
+		u <<= 1
+		nbits--
+	}
+}
+
+func (bs *bitStream) writeVarint(x int64) {
+	var buf [binary.MaxVarintLen64]byte
+	n := binary.PutVarint(buf[:], x)
+	for _, byt := range buf[:n] {
+		bs.writeByte(byt)
+	}
+}
+
+func (bs *bitStream) writeUvarint(x uint64) {
+	var buf [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(buf[:], x)
+	for _, byt := range buf[:n] {
+		bs.writeByte(byt)
+	}
+}
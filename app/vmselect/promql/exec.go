@@ -10,7 +10,9 @@ import (
 	"time"
 
 	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmselect/netstorage"
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmselect/querystats"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logger"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/querytracer"
 	"github.com/VictoriaMetrics/metrics"
 	"github.com/VictoriaMetrics/metricsql"
 )
@@ -20,7 +22,13 @@ var logSlowQueryDuration = flag.Duration("search.logSlowQueryDuration", 5*time.S
 var slowQueries = metrics.NewCounter(`vm_slow_queries_total`)
 
 // Exec executes q for the given ec.
-func Exec(ec *EvalConfig, q string, isFirstPointOnly bool) ([]netstorage.Result, error) {
+//
+// qt may be nil if query tracing is disabled.
+func Exec(qt *querytracer.Tracer, ec *EvalConfig, q string, isFirstPointOnly bool) ([]netstorage.Result, error) {
+	if querystats.Enabled() {
+		startTime := time.Now()
+		defer querystats.RegisterQuery(q, ec.End-ec.Start, startTime)
+	}
 	if *logSlowQueryDuration > 0 {
 		startTime := time.Now()
 		defer func() {
@@ -35,13 +43,15 @@ func Exec(ec *EvalConfig, q string, isFirstPointOnly bool) ([]netstorage.Result,
 
 	ec.validate()
 
+	qtChild := qt.NewChild("parse query")
 	e, err := parsePromQLWithCache(q)
 	if err != nil {
 		return nil, err
 	}
+	qtChild.Donef("query=%s", e.AppendString(nil))
 
 	qid := activeQueriesV.Add(ec, q)
-	rv, err := evalExpr(ec, e)
+	rv, err := evalExpr(qt, ec, e)
 	activeQueriesV.Remove(qid)
 	if err != nil {
 		return nil, err
@@ -60,6 +70,13 @@ func Exec(ec *EvalConfig, q string, isFirstPointOnly bool) ([]netstorage.Result,
 	if err != nil {
 		return nil, err
 	}
+	if qt.Enabled() {
+		pointsCount := 0
+		for i := range result {
+			pointsCount += len(result[i].Values)
+		}
+		qt.Printf("sort=%v, series=%d, points=%d", maySort, len(result), pointsCount)
+	}
 	return result, err
 }
 
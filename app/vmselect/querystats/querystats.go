@@ -0,0 +1,194 @@
+package querystats
+
+import (
+	"flag"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	lastQueriesCount = flag.Int("search.queryStats.lastQueriesCount", 20000, "Query stats for /api/v1/status/top_queries is tracked on this number of last queries. "+
+		"Zero value disables query stats tracking")
+	minQueryDuration = flag.Duration("search.queryStats.minQueryDuration", time.Millisecond, "The minimum duration for queries to track in query stats at /api/v1/status/top_queries. "+
+		"Queries with lower duration are ignored in query stats")
+)
+
+var (
+	qsTracker *queryStatsTracker
+	initOnce  sync.Once
+)
+
+// Enabled returns true if query stats tracking is enabled.
+func Enabled() bool {
+	return *lastQueriesCount > 0
+}
+
+// RegisterQuery registers the query on the given timeRangeMsecs, which has been started at startTime.
+//
+// RegisterQuery must be called when the query is finished.
+func RegisterQuery(query string, timeRangeMsecs int64, startTime time.Time) {
+	initOnce.Do(initQueryStats)
+	qsTracker.registerQuery(query, timeRangeMsecs, startTime)
+}
+
+// WriteJSONQueryStats writes query stats to given writer in json format.
+//
+// Only queries executed during the last maxLifetime are taken into account.
+// Up to topN entries are returned per each list.
+func WriteJSONQueryStats(w io.Writer, topN int, maxLifetime time.Duration) {
+	initOnce.Do(initQueryStats)
+	qs := qsTracker.getQueryStats(topN, maxLifetime)
+	writequeryStatsResponse(w, qs)
+}
+
+// queryStatsTracker holds statistics for the last queries.
+//
+// The statistics is kept in a ring buffer, so only the last len(a) queries are tracked.
+type queryStatsTracker struct {
+	mu      sync.Mutex
+	a       []queryStatRecord
+	nextIdx uint
+}
+
+type queryStatRecord struct {
+	query         string
+	timeRangeSecs int64
+	registerTime  time.Time
+	duration      time.Duration
+}
+
+type queryStatKey struct {
+	query         string
+	timeRangeSecs int64
+}
+
+// queryStats contains the aggregated query stats returned from /api/v1/status/top_queries.
+type queryStats struct {
+	topN             int
+	maxLifetime      time.Duration
+	lastQueriesCount int
+	minQueryDuration time.Duration
+
+	topByCount       []queryStatEntry
+	topByAvgDuration []queryStatEntry
+	topBySumDuration []queryStatEntry
+}
+
+// queryStatEntry contains the aggregated stats for the query on the given time range.
+type queryStatEntry struct {
+	query         string
+	timeRangeSecs int64
+	count         int
+	sumDuration   time.Duration
+}
+
+func (e *queryStatEntry) avgDuration() time.Duration {
+	return e.sumDuration / time.Duration(e.count)
+}
+
+func initQueryStats() {
+	recordsCount := *lastQueriesCount
+	if recordsCount <= 0 {
+		recordsCount = 1
+	}
+	qsTracker = &queryStatsTracker{
+		a: make([]queryStatRecord, recordsCount),
+	}
+}
+
+func (qst *queryStatsTracker) registerQuery(query string, timeRangeMsecs int64, startTime time.Time) {
+	registerTime := time.Now()
+	duration := registerTime.Sub(startTime)
+	if duration < *minQueryDuration {
+		return
+	}
+
+	qst.mu.Lock()
+	defer qst.mu.Unlock()
+
+	a := qst.a
+	idx := qst.nextIdx
+	if idx >= uint(len(a)) {
+		idx = 0
+	}
+	qst.nextIdx = idx + 1
+	r := &a[idx]
+	r.query = query
+	r.timeRangeSecs = timeRangeMsecs / 1000
+	r.registerTime = registerTime
+	r.duration = duration
+}
+
+func (qst *queryStatsTracker) getQueryStats(topN int, maxLifetime time.Duration) *queryStats {
+	entries := qst.getAggregatedEntries(maxLifetime)
+	return &queryStats{
+		topN:             topN,
+		maxLifetime:      maxLifetime,
+		lastQueriesCount: *lastQueriesCount,
+		minQueryDuration: *minQueryDuration,
+
+		topByCount: getTopEntries(entries, topN, func(a, b *queryStatEntry) bool {
+			return a.count > b.count
+		}),
+		topByAvgDuration: getTopEntries(entries, topN, func(a, b *queryStatEntry) bool {
+			return a.avgDuration() > b.avgDuration()
+		}),
+		topBySumDuration: getTopEntries(entries, topN, func(a, b *queryStatEntry) bool {
+			return a.sumDuration > b.sumDuration
+		}),
+	}
+}
+
+func (qst *queryStatsTracker) getAggregatedEntries(maxLifetime time.Duration) []queryStatEntry {
+	currentTime := time.Now()
+	m := make(map[queryStatKey]*queryStatEntry)
+	qst.mu.Lock()
+	for i := range qst.a {
+		r := &qst.a[i]
+		if r.query == "" || currentTime.Sub(r.registerTime) > maxLifetime {
+			continue
+		}
+		k := queryStatKey{
+			query:         r.query,
+			timeRangeSecs: r.timeRangeSecs,
+		}
+		e := m[k]
+		if e == nil {
+			e = &queryStatEntry{
+				query:         r.query,
+				timeRangeSecs: r.timeRangeSecs,
+			}
+			m[k] = e
+		}
+		e.count++
+		e.sumDuration += r.duration
+	}
+	qst.mu.Unlock()
+
+	entries := make([]queryStatEntry, 0, len(m))
+	for _, e := range m {
+		entries = append(entries, *e)
+	}
+	// Sort entries by query and time range, so the results are stable for entries with equal stats.
+	sort.Slice(entries, func(i, j int) bool {
+		a, b := &entries[i], &entries[j]
+		if a.query != b.query {
+			return a.query < b.query
+		}
+		return a.timeRangeSecs < b.timeRangeSecs
+	})
+	return entries
+}
+
+func getTopEntries(entries []queryStatEntry, topN int, less func(a, b *queryStatEntry) bool) []queryStatEntry {
+	a := append([]queryStatEntry{}, entries...)
+	sort.SliceStable(a, func(i, j int) bool {
+		return less(&a[i], &a[j])
+	})
+	if topN >= 0 && len(a) > topN {
+		a = a[:topN]
+	}
+	return a
+}
@@ -0,0 +1,122 @@
+package querystats
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestQueryStatsTrackerRingBuffer(t *testing.T) {
+	qst := &queryStatsTracker{
+		a: make([]queryStatRecord, 3),
+	}
+	startTime := time.Now().Add(-time.Second)
+	qst.registerQuery("q1", 3600e3, startTime)
+	qst.registerQuery("q2", 3600e3, startTime)
+	qst.registerQuery("q3", 3600e3, startTime)
+	qst.registerQuery("q4", 3600e3, startTime)
+
+	entries := qst.getAggregatedEntries(time.Hour)
+	var queries []string
+	for _, e := range entries {
+		queries = append(queries, e.query)
+	}
+	// q1 must be evicted by q4
+	if len(queries) != 3 || queries[0] != "q2" || queries[1] != "q3" || queries[2] != "q4" {
+		t.Fatalf("unexpected queries: %q", queries)
+	}
+}
+
+func TestQueryStatsTrackerMinQueryDuration(t *testing.T) {
+	qst := &queryStatsTracker{
+		a: make([]queryStatRecord, 10),
+	}
+	qst.registerQuery("fast", 1000, time.Now().Add(time.Hour))
+	if entries := qst.getAggregatedEntries(time.Hour); len(entries) != 0 {
+		t.Fatalf("fast query mustn't be registered; got %d entries", len(entries))
+	}
+}
+
+func TestQueryStatsTrackerGetQueryStats(t *testing.T) {
+	qst := &queryStatsTracker{
+		a: make([]queryStatRecord, 100),
+	}
+	now := time.Now()
+	// "frequent" query is executed 3 times on 1h time range.
+	for i := 0; i < 3; i++ {
+		qst.registerQuery("frequent", 3600e3, now.Add(-time.Second))
+	}
+	// The same query on another time range must be tracked separately.
+	qst.registerQuery("frequent", 86400e3, now.Add(-2*time.Second))
+	// "slow" query is executed once.
+	qst.registerQuery("slow", 3600e3, now.Add(-10*time.Second))
+
+	qs := qst.getQueryStats(2, time.Hour)
+	if len(qs.topByCount) != 2 {
+		t.Fatalf("unexpected number of topByCount entries; got %d; want 2", len(qs.topByCount))
+	}
+	e := &qs.topByCount[0]
+	if e.query != "frequent" || e.timeRangeSecs != 3600 || e.count != 3 {
+		t.Fatalf("unexpected top entry by count: %+v", e)
+	}
+	e = &qs.topByAvgDuration[0]
+	if e.query != "slow" || e.count != 1 {
+		t.Fatalf("unexpected top entry by avg duration: %+v", e)
+	}
+	e = &qs.topBySumDuration[0]
+	if e.query != "slow" {
+		t.Fatalf("unexpected top entry by sum duration: %+v", e)
+	}
+	if d := qs.topBySumDuration[1].sumDuration; d < 3*time.Second {
+		t.Fatalf("unexpected sum duration for the second entry; got %s; want at least 3s", d)
+	}
+
+	// Entries older than maxLifetime must be ignored.
+	qs = qst.getQueryStats(10, time.Nanosecond)
+	if len(qs.topByCount) != 0 {
+		t.Fatalf("expecting zero entries for too small maxLifetime; got %d entries", len(qs.topByCount))
+	}
+}
+
+func TestQueryStatsResponse(t *testing.T) {
+	qst := &queryStatsTracker{
+		a: make([]queryStatRecord, 10),
+	}
+	qst.registerQuery(`sum(rate(foo{bar="baz"}[5m]))`, 3600e3, time.Now().Add(-time.Second))
+	qs := qst.getQueryStats(5, time.Hour)
+
+	var bb bytes.Buffer
+	writequeryStatsResponse(&bb, qs)
+	var resp struct {
+		TopN             int    `json:"topN"`
+		MaxLifetime      string `json:"maxLifetime"`
+		LastQueriesCount int    `json:"lastQueriesCount"`
+		MinQueryDuration string `json:"minQueryDuration"`
+		TopByCount       []struct {
+			Query              string  `json:"query"`
+			TimeRangeSeconds   int64   `json:"timeRangeSeconds"`
+			Count              int     `json:"count"`
+			AvgDurationSeconds float64 `json:"avgDurationSeconds"`
+			SumDurationSeconds float64 `json:"sumDurationSeconds"`
+		} `json:"topByCount"`
+		TopByAvgDuration []interface{} `json:"topByAvgDuration"`
+		TopBySumDuration []interface{} `json:"topBySumDuration"`
+	}
+	if err := json.Unmarshal(bb.Bytes(), &resp); err != nil {
+		t.Fatalf("cannot parse response %q: %s", bb.String(), err)
+	}
+	if resp.TopN != 5 || resp.MaxLifetime != "1h0m0s" {
+		t.Fatalf("unexpected response header: %s", bb.String())
+	}
+	if len(resp.TopByCount) != 1 || len(resp.TopByAvgDuration) != 1 || len(resp.TopBySumDuration) != 1 {
+		t.Fatalf("unexpected number of entries in response: %s", bb.String())
+	}
+	r := resp.TopByCount[0]
+	if r.Query != `sum(rate(foo{bar="baz"}[5m]))` || r.TimeRangeSeconds != 3600 || r.Count != 1 {
+		t.Fatalf("unexpected entry in response: %s", bb.String())
+	}
+	if r.AvgDurationSeconds < 1 || r.AvgDurationSeconds != r.SumDurationSeconds {
+		t.Fatalf("unexpected durations in response: %s", bb.String())
+	}
+}
@@ -0,0 +1,40 @@
+package vmstorage
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/flagutil"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/storage"
+	"github.com/VictoriaMetrics/metricsql"
+)
+
+var downsamplingPeriods = flagutil.NewArray("downsampling.period", "Comma-separated downsampling periods in the format 'offset:interval'. For example, '30d:5m,180d:1h' "+
+	"leaves a single sample per 5 minutes for samples older than 30 days and a single sample per hour for samples older than 180 days. "+
+	"Downsampling is performed during background merges. "+
+	"See https://victoriametrics.github.io/Single-server-VictoriaMetrics.html#downsampling")
+
+// parseDownsamplingPeriods parses downsampling periods in the form `offset:interval`.
+func parseDownsamplingPeriods(a []string) ([]storage.DownsamplingPeriod, error) {
+	dps := make([]storage.DownsamplingPeriod, 0, len(a))
+	for _, s := range a {
+		n := strings.IndexByte(s, ':')
+		if n < 0 {
+			return nil, fmt.Errorf("missing `:` in downsampling period %q; it must be in the form `offset:interval`", s)
+		}
+		offset, err := metricsql.PositiveDurationValue(s[:n], 0)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse offset in downsampling period %q: %w", s, err)
+		}
+		interval, err := metricsql.PositiveDurationValue(s[n+1:], 0)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse interval in downsampling period %q: %w", s, err)
+		}
+		dps = append(dps, storage.DownsamplingPeriod{
+			Offset:   time.Duration(offset) * time.Millisecond,
+			Interval: time.Duration(interval) * time.Millisecond,
+		})
+	}
+	return dps, nil
+}
@@ -4,22 +4,25 @@ import (
 	"flag"
 	"fmt"
 	"net/http"
+	"path/filepath"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/encoding"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/fasttime"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/flagutil"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/fs"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/httpserver"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logger"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/metricsmetadata"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/storage"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/syncwg"
 	"github.com/VictoriaMetrics/metrics"
 )
 
 var (
-	retentionPeriod = flag.Int("retentionPeriod", 1, "Retention period in months")
+	retentionPeriod = flagutil.NewDuration("retentionPeriod", 1, "Data with timestamps outside the retentionPeriod is automatically deleted")
 	snapshotAuthKey = flag.String("snapshotAuthKey", "", "authKey, which must be passed in query string to /snapshot* pages")
 
 	precisionBits = flag.Int("precisionBits", 64, "The number of precision bits to store per each value. Lower precision bits improves data compression at the cost of precision loss")
@@ -30,9 +33,17 @@ var (
 	bigMergeConcurrency   = flag.Int("bigMergeConcurrency", 0, "The maximum number of CPU cores to use for big merges. Default value is used if set to 0")
 	smallMergeConcurrency = flag.Int("smallMergeConcurrency", 0, "The maximum number of CPU cores to use for small merges. Default value is used if set to 0")
 
+	maxHourlySeries = flag.Int("storage.maxHourlySeries", 0, "The maximum number of unique series can be added to the storage during the last hour. "+
+		"Excess series are logged and dropped. This can be useful for limiting series churn rate. See also -storage.maxDailySeries")
+	maxDailySeries = flag.Int("storage.maxDailySeries", 0, "The maximum number of unique series can be added to the storage during the last 24 hours. "+
+		"Excess series are logged and dropped. This can be useful for limiting series cardinality. See also -storage.maxHourlySeries")
+
 	denyQueriesOutsideRetention = flag.Bool("denyQueriesOutsideRetention", false, "Whether to deny queries outside of the configured -retentionPeriod. "+
 		"When set, then /api/v1/query_range would return '503 Service Unavailable' error for queries with 'from' value outside -retentionPeriod. "+
 		"This may be useful when multiple data sources with distinct retentions are hidden behind query-tee")
+
+	maxMetricsMetadataEntries = flag.Int("storage.maxMetricsMetadataEntries", 100000, "The maximum number of metric families to store HELP, TYPE and UNIT metadata for. "+
+		"Metadata for new metric families is dropped when the limit is reached. The metadata is served at /api/v1/metadata")
 )
 
 // CheckTimeRange returns true if the given tr is denied for querying.
@@ -40,12 +51,12 @@ func CheckTimeRange(tr storage.TimeRange) error {
 	if !*denyQueriesOutsideRetention {
 		return nil
 	}
-	minAllowedTimestamp := (int64(fasttime.UnixTimestamp()) - int64(*retentionPeriod)*3600*24*30) * 1000
+	minAllowedTimestamp := int64(fasttime.UnixTimestamp())*1000 - retentionPeriod.Msecs
 	if tr.MinTimestamp > minAllowedTimestamp {
 		return nil
 	}
 	return &httpserver.ErrorWithStatusCode{
-		Err:        fmt.Errorf("the given time range %s is outside the allowed retention of %d months according to -denyQueriesOutsideRetention", &tr, *retentionPeriod),
+		Err:        fmt.Errorf("the given time range %s is outside the allowed -retentionPeriod=%s according to -denyQueriesOutsideRetention", &tr, retentionPeriod),
 		StatusCode: http.StatusServiceUnavailable,
 	}
 }
@@ -66,15 +77,32 @@ func InitWithoutMetrics() {
 
 	storage.SetBigMergeWorkersCount(*bigMergeConcurrency)
 	storage.SetSmallMergeWorkersCount(*smallMergeConcurrency)
+	for i := range retentionFilters.rfs {
+		rf := &retentionFilters.rfs[i]
+		if retentionPeriod.Msecs > 0 && rf.Retention.Milliseconds() > retentionPeriod.Msecs {
+			logger.Fatalf("retention in `-retentionFilter=%q` cannot exceed -retentionPeriod=%s", retentionFilters.a[i], retentionPeriod)
+		}
+	}
+	if err := storage.SetRetentionFilters(retentionFilters.rfs); err != nil {
+		logger.Fatalf("invalid `-retentionFilter`: %s", err)
+	}
+	dps, err := parseDownsamplingPeriods(*downsamplingPeriods)
+	if err != nil {
+		logger.Fatalf("invalid `-downsampling.period`: %s", err)
+	}
+	if err := storage.SetDownsamplingPeriods(dps); err != nil {
+		logger.Fatalf("invalid `-downsampling.period`: %s", err)
+	}
 
-	logger.Infof("opening storage at %q with retention period %d months", *DataPath, *retentionPeriod)
+	logger.Infof("opening storage at %q with -retentionPeriod=%s", *DataPath, retentionPeriod)
 	startTime := time.Now()
 	WG = syncwg.WaitGroup{}
-	strg, err := storage.OpenStorage(*DataPath, *retentionPeriod)
+	strg, err := storage.OpenStorage(*DataPath, retentionPeriod.Msecs, *maxHourlySeries, *maxDailySeries)
 	if err != nil {
-		logger.Fatalf("cannot open a storage at %s with retention period %d months: %s", *DataPath, *retentionPeriod, err)
+		logger.Fatalf("cannot open a storage at %s with -retentionPeriod=%s: %s", *DataPath, retentionPeriod, err)
 	}
 	Storage = strg
+	metricsMetadata = metricsmetadata.MustOpen(filepath.Join(*DataPath, "metrics_metadata.json"), *maxMetricsMetadataEntries)
 
 	var m storage.Metrics
 	Storage.UpdateMetrics(&m)
@@ -106,6 +134,35 @@ func AddRows(mrs []storage.MetricRow) error {
 	return err
 }
 
+// metricsMetadata holds HELP, TYPE and UNIT metadata per each metric family name.
+var metricsMetadata *metricsmetadata.Storage
+
+// AddMetricsMetadata adds rows to metrics metadata storage.
+func AddMetricsMetadata(rows []metricsmetadata.Row) {
+	WG.Add(1)
+	metricsMetadata.Add(rows)
+	WG.Done()
+}
+
+// GetMetricsMetadata returns metrics metadata for the given metricFamilyName.
+//
+// Metadata for all the metric families is returned if metricFamilyName is empty.
+// Up to limit rows are returned if limit is positive.
+func GetMetricsMetadata(metricFamilyName string, limit int) []metricsmetadata.Row {
+	WG.Add(1)
+	rows := metricsMetadata.Get(metricFamilyName, limit)
+	WG.Done()
+	return rows
+}
+
+// RegisterMetricNames registers all the metrics from mrs in the storage.
+func RegisterMetricNames(mrs []storage.MetricRow) error {
+	WG.Add(1)
+	err := Storage.RegisterMetricNames(mrs)
+	WG.Done()
+	return err
+}
+
 // DeleteMetrics deletes metrics matching tfss.
 //
 // Returns the number of deleted metrics.
@@ -150,10 +207,18 @@ func SearchTagEntries(maxTagKeys, maxTagValues int, deadline uint64) ([]storage.
 	return tagEntries, err
 }
 
-// GetTSDBStatusForDate returns TSDB status for the given date.
-func GetTSDBStatusForDate(date uint64, topN int, deadline uint64) (*storage.TSDBStatus, error) {
+// GetTSDBStatusWithFilters returns TSDB status for the given tfss, tr and focusLabel.
+func GetTSDBStatusWithFilters(tfss []*storage.TagFilters, tr storage.TimeRange, focusLabel string, topN, maxMetrics int, deadline uint64) (*storage.TSDBStatus, error) {
 	WG.Add(1)
-	status, err := Storage.GetTSDBStatusForDate(date, topN, deadline)
+	status, err := Storage.GetTSDBStatusWithFilters(tfss, tr, focusLabel, topN, maxMetrics, deadline)
+	WG.Done()
+	return status, err
+}
+
+// GetTSDBStatusForEntries returns TSDB status with the same entries as in the given status for the given tfss, tr and focusLabel.
+func GetTSDBStatusForEntries(tfss []*storage.TagFilters, tr storage.TimeRange, focusLabel string, status *storage.TSDBStatus, maxMetrics int, deadline uint64) (*storage.TSDBStatus, error) {
+	WG.Add(1)
+	status, err := Storage.GetTSDBStatusForEntries(tfss, tr, focusLabel, status, maxMetrics, deadline)
 	WG.Done()
 	return status, err
 }
@@ -172,6 +237,7 @@ func Stop() {
 	startTime := time.Now()
 	WG.WaitAndBlock()
 	Storage.MustClose()
+	metricsMetadata.MustClose()
 	logger.Infof("successfully closed the storage in %.3f seconds", time.Since(startTime).Seconds())
 
 	logger.Infof("the storage has been stopped")
@@ -415,6 +481,9 @@ func registerStorageMetrics() {
 	metrics.NewGauge(`vm_deduplicated_samples_total{type="merge"}`, func() float64 {
 		return float64(m().DedupsDuringMerge)
 	})
+	metrics.NewGauge(`vm_downsampled_samples_total{type="merge"}`, func() float64 {
+		return float64(m().DownsampledRowsDuringMerge)
+	})
 
 	metrics.NewGauge(`vm_rows_ignored_total{reason="big_timestamp"}`, func() float64 {
 		return float64(m().TooBigTimestampRows)
@@ -423,6 +492,30 @@ func registerStorageMetrics() {
 		return float64(m().TooSmallTimestampRows)
 	})
 
+	if *maxHourlySeries > 0 {
+		metrics.NewGauge(`vm_hourly_series_limit_current_series`, func() float64 {
+			return float64(m().HourlySeriesLimitCurrentSeries)
+		})
+		metrics.NewGauge(`vm_hourly_series_limit_max_series`, func() float64 {
+			return float64(m().HourlySeriesLimitMaxSeries)
+		})
+		metrics.NewGauge(`vm_hourly_series_limit_rows_dropped_total`, func() float64 {
+			return float64(m().HourlySeriesLimitRowsDropped)
+		})
+	}
+
+	if *maxDailySeries > 0 {
+		metrics.NewGauge(`vm_daily_series_limit_current_series`, func() float64 {
+			return float64(m().DailySeriesLimitCurrentSeries)
+		})
+		metrics.NewGauge(`vm_daily_series_limit_max_series`, func() float64 {
+			return float64(m().DailySeriesLimitMaxSeries)
+		})
+		metrics.NewGauge(`vm_daily_series_limit_rows_dropped_total`, func() float64 {
+			return float64(m().DailySeriesLimitRowsDropped)
+		})
+	}
+
 	metrics.NewGauge(`vm_concurrent_addrows_limit_reached_total`, func() float64 {
 		return float64(m().AddRowsConcurrencyLimitReached)
 	})
@@ -501,6 +594,10 @@ func registerStorageMetrics() {
 		return float64(m().DateMetricIDCacheResetsCount)
 	})
 
+	metrics.NewGauge(`vm_metrics_metadata_entries`, func() float64 {
+		return float64(metricsMetadata.Len())
+	})
+
 	metrics.NewGauge(`vm_cache_entries{type="storage/tsid"}`, func() float64 {
 		return float64(m().TSIDCacheSize)
 	})
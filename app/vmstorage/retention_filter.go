@@ -0,0 +1,82 @@
+package vmstorage
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/storage"
+	"github.com/VictoriaMetrics/metricsql"
+)
+
+var retentionFilters = newRetentionFiltersFlag("retentionFilter", "Retention filter in the format 'series_selector:retention'. For example, '{env=\"dev\"}:7d'. "+
+	"Samples for time series matching series_selector are deleted after the given retention. The retention cannot exceed -retentionPeriod. "+
+	"Supported retention suffixes: s, m, h, d, w, y. The flag can be specified multiple times. The first matching filter is applied to each time series. "+
+	"See https://victoriametrics.github.io/Single-server-VictoriaMetrics.html#retention-filters")
+
+type retentionFiltersFlag struct {
+	a   []string
+	rfs []storage.RetentionFilter
+}
+
+func newRetentionFiltersFlag(name, description string) *retentionFiltersFlag {
+	var rff retentionFiltersFlag
+	flag.Var(&rff, name, description)
+	return &rff
+}
+
+// String implements flag.Value interface.
+func (rff *retentionFiltersFlag) String() string {
+	return strings.Join(rff.a, " ")
+}
+
+// Set implements flag.Value interface.
+func (rff *retentionFiltersFlag) Set(value string) error {
+	rf, err := parseRetentionFilter(value)
+	if err != nil {
+		return err
+	}
+	rff.a = append(rff.a, value)
+	rff.rfs = append(rff.rfs, rf)
+	return nil
+}
+
+// parseRetentionFilter parses retention filter in the form `series_selector:retention`.
+func parseRetentionFilter(s string) (storage.RetentionFilter, error) {
+	var rf storage.RetentionFilter
+	n := strings.LastIndexByte(s, ':')
+	if n < 0 {
+		return rf, fmt.Errorf("missing `:` in retention filter %q; it must be in the form `series_selector:retention`", s)
+	}
+	selector, retention := s[:n], s[n+1:]
+	retentionMsecs, err := metricsql.PositiveDurationValue(retention, 0)
+	if err != nil {
+		return rf, fmt.Errorf("cannot parse retention %q in retention filter %q: %w", retention, s, err)
+	}
+	if retentionMsecs <= 0 {
+		return rf, fmt.Errorf("retention must be positive in retention filter %q", s)
+	}
+	expr, err := metricsql.Parse(selector)
+	if err != nil {
+		return rf, fmt.Errorf("cannot parse series selector %q in retention filter %q: %w", selector, s, err)
+	}
+	me, ok := expr.(*metricsql.MetricExpr)
+	if !ok {
+		return rf, fmt.Errorf("expecting series selector in retention filter %q; got %q", s, expr.AppendString(nil))
+	}
+	tfs := make([]storage.TagFilter, len(me.LabelFilters))
+	for i := range me.LabelFilters {
+		lf := &me.LabelFilters[i]
+		tf := &tfs[i]
+		if lf.Label != "__name__" {
+			tf.Key = []byte(lf.Label)
+		}
+		tf.Value = []byte(lf.Value)
+		tf.IsNegative = lf.IsNegative
+		tf.IsRegexp = lf.IsRegexp
+	}
+	rf.Filters = tfs
+	rf.Retention = time.Duration(retentionMsecs) * time.Millisecond
+	return rf, nil
+}
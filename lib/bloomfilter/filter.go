@@ -0,0 +1,78 @@
+package bloomfilter
+
+import (
+	"encoding/binary"
+	"sync/atomic"
+
+	xxhash "github.com/cespare/xxhash/v2"
+)
+
+const hashesCount = 4
+const bitsPerItem = 16
+
+// filter is a bloom filter for uint64 items.
+//
+// It is safe to use the filter from concurrent goroutines.
+type filter struct {
+	maxItems int
+	bits     []uint64
+}
+
+func newFilter(maxItems int) *filter {
+	bitsCount := maxItems * bitsPerItem
+	bits := make([]uint64, (bitsCount+63)/64)
+	return &filter{
+		maxItems: maxItems,
+		bits:     bits,
+	}
+}
+
+// Has checks whether h presents in f.
+//
+// Has can be called from concurrent goroutines.
+func (f *filter) Has(h uint64) bool {
+	bits := f.bits
+	maxBits := uint64(len(bits)) * 64
+	var b [8]byte
+	for i := 0; i < hashesCount; i++ {
+		binary.LittleEndian.PutUint64(b[:], h+uint64(i))
+		hi := xxhash.Sum64(b[:])
+		idx := hi % maxBits
+		w := atomic.LoadUint64(&bits[idx/64])
+		mask := uint64(1) << (idx % 64)
+		if (w & mask) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Add adds h to f.
+//
+// True is returned if h was missing in f.
+//
+// Add can be called from concurrent goroutines.
+// If the same h is added to f from concurrent goroutines, then both goroutines may return true.
+func (f *filter) Add(h uint64) bool {
+	bits := f.bits
+	maxBits := uint64(len(bits)) * 64
+	var b [8]byte
+	isNew := false
+	for i := 0; i < hashesCount; i++ {
+		binary.LittleEndian.PutUint64(b[:], h+uint64(i))
+		hi := xxhash.Sum64(b[:])
+		idx := hi % maxBits
+		ptr := &bits[idx/64]
+		mask := uint64(1) << (idx % 64)
+		w := atomic.LoadUint64(ptr)
+		for (w & mask) == 0 {
+			wNew := w | mask
+			if atomic.CompareAndSwapUint64(ptr, w, wNew) {
+				isNew = true
+				break
+			}
+			w = atomic.LoadUint64(ptr)
+		}
+	}
+	return isNew
+}
@@ -0,0 +1,102 @@
+package bloomfilter
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestFilter(t *testing.T) {
+	for _, maxItems := range []int{1e0, 1e1, 1e2, 1e3, 1e4, 1e5} {
+		testFilter(t, maxItems)
+	}
+}
+
+func testFilter(t *testing.T, maxItems int) {
+	r := newTestRand()
+	f := newFilter(maxItems)
+	items := make(map[uint64]struct{}, maxItems)
+
+	// Populate f with maxItems
+	collisions := 0
+	for i := 0; i < maxItems; i++ {
+		h := r.Uint64()
+		items[h] = struct{}{}
+		if !f.Add(h) {
+			collisions++
+		}
+		if f.Add(h) {
+			t.Fatalf("unexpected double addition of item %d on iteration %d for maxItems %d", h, i, maxItems)
+		}
+	}
+	p := float64(collisions) / float64(maxItems)
+	if collisions > 1 && p > 0.005 {
+		t.Fatalf("too big collision share; got %.5f; want 0.005 max", p)
+	}
+
+	// Verify that the added items exist in f.
+	for h := range items {
+		if !f.Has(h) {
+			t.Fatalf("cannot find item %d in the filter for maxItems %d", h, maxItems)
+		}
+	}
+
+	// Verify false positives rate for missing items.
+	falsePositives := 0
+	for i := 0; i < maxItems; i++ {
+		h := r.Uint64()
+		if _, ok := items[h]; ok {
+			continue
+		}
+		if f.Has(h) {
+			falsePositives++
+		}
+	}
+	p = float64(falsePositives) / float64(maxItems)
+	if falsePositives > 1 && p > 0.005 {
+		t.Fatalf("too big false hits share for maxItems=%d: %.5f, falsePositives: %d", maxItems, p, falsePositives)
+	}
+}
+
+func TestFilterConcurrent(t *testing.T) {
+	const concurrency = 3
+	const maxItems = 10000
+	f := newFilter(maxItems)
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func(offset uint64) {
+			defer wg.Done()
+			for h := offset; h < offset+maxItems/concurrency; h++ {
+				f.Add(h)
+			}
+			for h := offset; h < offset+maxItems/concurrency; h++ {
+				if !f.Has(h) {
+					panic(fmt.Errorf("missing item %d", h))
+				}
+			}
+		}(uint64(i) * maxItems)
+	}
+	wg.Wait()
+}
+
+// testRand is a simple deterministic pseudo-random generator, so the test results are reproducible.
+type testRand struct {
+	x uint64
+}
+
+func newTestRand() *testRand {
+	return &testRand{
+		x: 0x2545F4914F6CDD1D,
+	}
+}
+
+func (r *testRand) Uint64() uint64 {
+	// See https://en.wikipedia.org/wiki/Xorshift
+	x := r.x
+	x ^= x << 13
+	x ^= x >> 7
+	x ^= x << 17
+	r.x = x
+	return x
+}
@@ -0,0 +1,70 @@
+package bloomfilter
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLimiter(t *testing.T) {
+	for _, maxItems := range []int{1, 10, 100, 1000, 10000} {
+		testLimiter(t, maxItems)
+	}
+}
+
+func testLimiter(t *testing.T, maxItems int) {
+	r := newTestRand()
+	l := NewLimiter(maxItems, time.Hour)
+	defer l.MustStop()
+	if n := l.MaxItems(); n != maxItems {
+		t.Fatalf("unexpected MaxItems; got %d; want %d", n, maxItems)
+	}
+	items := make([]uint64, 0, maxItems)
+
+	// Populate the limiter
+	for i := 0; i < maxItems; i++ {
+		h := r.Uint64()
+		if !l.Add(h) {
+			t.Fatalf("cannot add item %d on iteration %d out of %d", h, i, maxItems)
+		}
+		items = append(items, h)
+	}
+
+	// The limiter must accept already registered items.
+	for _, h := range items {
+		if !l.Add(h) {
+			t.Fatalf("cannot add already existing item %d", h)
+		}
+	}
+
+	// The limiter must reject new items.
+	accepted := 0
+	for i := 0; i < maxItems; i++ {
+		if l.Add(r.Uint64()) {
+			accepted++
+		}
+	}
+	if p := float64(accepted) / float64(maxItems); accepted > 1 && p > 0.005 {
+		t.Fatalf("too big share of accepted new items for maxItems=%d: %.5f", maxItems, p)
+	}
+	if n := l.CurrentItems(); n > maxItems {
+		t.Fatalf("too big CurrentItems; got %d; mustn't exceed %d", n, maxItems)
+	}
+}
+
+func TestLimiterRefresh(t *testing.T) {
+	l := NewLimiter(1, 10*time.Millisecond)
+	defer l.MustStop()
+	if !l.Add(1) {
+		t.Fatalf("cannot add the first item")
+	}
+	if l.Add(2) {
+		t.Fatalf("the second item must be rejected")
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for !l.Add(2) {
+		if time.Now().After(deadline) {
+			t.Fatalf("the limiter must be refreshed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,68 @@
+package flagutil
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// NewDuration returns new `duration` flag with the given name, defaultValue and description.
+//
+// DefaultValue is in months.
+func NewDuration(name string, defaultValue float64, description string) *Duration {
+	description += "\nThe following optional suffixes are supported: h (hour), d (day), w (week), y (year). If suffix isn't set, then the duration is counted in months"
+	d := Duration{
+		Msecs:       int64(defaultValue * msecsPerMonth),
+		valueString: fmt.Sprintf("%g", defaultValue),
+	}
+	flag.Var(&d, name, description)
+	return &d
+}
+
+// Duration is a flag for holding duration.
+//
+// It supports the following optional suffixes for values: h (hour), d (day), w (week), y (year).
+// The duration is counted in months if the suffix is missing.
+type Duration struct {
+	// Msecs contains parsed duration in milliseconds.
+	Msecs int64
+
+	valueString string
+}
+
+// String implements flag.Value interface
+func (d *Duration) String() string {
+	return d.valueString
+}
+
+// Set implements flag.Value interface
+func (d *Duration) Set(value string) error {
+	n, msecsPerUnit := value, float64(msecsPerMonth)
+	switch {
+	case strings.HasSuffix(value, "h"):
+		n, msecsPerUnit = value[:len(value)-1], msecsPerHour
+	case strings.HasSuffix(value, "d"):
+		n, msecsPerUnit = value[:len(value)-1], msecsPerDay
+	case strings.HasSuffix(value, "w"):
+		n, msecsPerUnit = value[:len(value)-1], 7*msecsPerDay
+	case strings.HasSuffix(value, "y"):
+		n, msecsPerUnit = value[:len(value)-1], 365*msecsPerDay
+	}
+	f, err := strconv.ParseFloat(n, 64)
+	if err != nil {
+		return fmt.Errorf("cannot parse duration %q: %w", value, err)
+	}
+	if f < 0 {
+		return fmt.Errorf("duration cannot be negative; got %q", value)
+	}
+	d.Msecs = int64(f * msecsPerUnit)
+	d.valueString = value
+	return nil
+}
+
+const (
+	msecsPerHour  = 3600 * 1000
+	msecsPerDay   = 24 * msecsPerHour
+	msecsPerMonth = 31 * msecsPerDay
+)
@@ -0,0 +1,52 @@
+package flagutil
+
+import (
+	"testing"
+)
+
+func TestDurationSetFailure(t *testing.T) {
+	f := func(value string) {
+		t.Helper()
+		var d Duration
+		if err := d.Set(value); err == nil {
+			t.Fatalf("expecting non-nil error in d.Set(%q)", value)
+		}
+	}
+	f("")
+	f("foobar")
+	f("5foobar")
+	f("ah")
+	f("134xd")
+	f("2.43sdfw")
+	f("y")
+
+	// Negative durations aren't allowed.
+	f("-1")
+	f("-2d")
+}
+
+func TestDurationSetSuccess(t *testing.T) {
+	f := func(value string, expectedMsecs int64) {
+		t.Helper()
+		var d Duration
+		if err := d.Set(value); err != nil {
+			t.Fatalf("unexpected error in d.Set(%q): %s", value, err)
+		}
+		if d.Msecs != expectedMsecs {
+			t.Fatalf("unexpected result; got %d; want %d", d.Msecs, expectedMsecs)
+		}
+		valueString := d.String()
+		if valueString != value {
+			t.Fatalf("unexpected valueString; got %q; want %q", valueString, value)
+		}
+	}
+	f("0", 0)
+	f("1", msecsPerMonth)
+	f("123.456", int64(123.456*msecsPerMonth))
+	f("1h", msecsPerHour)
+	f("14d", 14*msecsPerDay)
+	f("45d", 45*msecsPerDay)
+	f("0.5d", 12*msecsPerHour)
+	f("3w", 21*msecsPerDay)
+	f("2y", 2*365*msecsPerDay)
+}
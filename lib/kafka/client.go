@@ -0,0 +1,263 @@
+package kafka
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Client is a minimal client for Kafka brokers.
+//
+// It supports Kafka 0.11 and newer versions.
+// It is safe calling Client methods from concurrently running goroutines.
+type Client struct {
+	brokers  []string
+	clientID string
+	timeout  time.Duration
+
+	mu sync.Mutex
+
+	// conns contains connections to brokers keyed by broker address.
+	conns map[string]*conn
+
+	// metadata contains the cached metadata keyed by topic name.
+	metadata map[string]*topicMetadata
+}
+
+// NewClient returns new client for the given bootstrap brokers.
+//
+// clientID is sent to brokers with each request. timeout is used for each request to brokers.
+func NewClient(brokers []string, clientID string, timeout time.Duration) *Client {
+	return &Client{
+		brokers:  append([]string{}, brokers...),
+		clientID: clientID,
+		timeout:  timeout,
+		conns:    make(map[string]*conn),
+		metadata: make(map[string]*topicMetadata),
+	}
+}
+
+// Close closes all the connections to brokers.
+func (c *Client) Close() {
+	c.mu.Lock()
+	for addr, cn := range c.conns {
+		cn.close()
+		delete(c.conns, addr)
+	}
+	c.mu.Unlock()
+}
+
+// Partitions returns sorted partition ids for the given topic.
+func (c *Client) Partitions(topic string) ([]int32, error) {
+	tm, err := c.getTopicMetadata(topic)
+	if err != nil {
+		return nil, err
+	}
+	partitions := make([]int32, 0, len(tm.partitions))
+	for _, pm := range tm.partitions {
+		partitions = append(partitions, pm.partition)
+	}
+	return partitions, nil
+}
+
+// roundTrip sends the request to the broker with the given addr and returns the response.
+func (c *Client) roundTrip(addr string, apiKey, apiVersion int16, body []byte, timeout time.Duration) ([]byte, error) {
+	cn, err := c.getConn(addr)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := cn.roundTrip(apiKey, apiVersion, body, timeout)
+	if err != nil {
+		// The connection may be in inconsistent state after the error, so close it.
+		c.closeConn(cn)
+		return nil, err
+	}
+	return resp, nil
+}
+
+// roundTripAny sends the request to the first available bootstrap broker and returns the response.
+func (c *Client) roundTripAny(apiKey, apiVersion int16, body []byte) ([]byte, error) {
+	var lastErr error
+	for _, addr := range c.brokers {
+		resp, err := c.roundTrip(addr, apiKey, apiVersion, body, c.timeout)
+		if err == nil {
+			return resp, nil
+		}
+		lastErr = err
+	}
+	if lastErr == nil {
+		lastErr = fmt.Errorf("missing Kafka brokers")
+	}
+	return nil, lastErr
+}
+
+func (c *Client) getConn(addr string) (*conn, error) {
+	c.mu.Lock()
+	cn := c.conns[addr]
+	c.mu.Unlock()
+	if cn != nil {
+		return cn, nil
+	}
+	cn, err := dialConn(addr, c.clientID, c.timeout)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	if cnExisting := c.conns[addr]; cnExisting != nil {
+		// Concurrent goroutine already established the connection.
+		c.mu.Unlock()
+		cn.close()
+		return cnExisting, nil
+	}
+	c.conns[addr] = cn
+	c.mu.Unlock()
+	return cn, nil
+}
+
+func (c *Client) closeConn(cn *conn) {
+	c.mu.Lock()
+	if c.conns[cn.addr] == cn {
+		delete(c.conns, cn.addr)
+	}
+	c.mu.Unlock()
+	cn.close()
+}
+
+func (c *Client) getTopicMetadata(topic string) (*topicMetadata, error) {
+	c.mu.Lock()
+	tm := c.metadata[topic]
+	c.mu.Unlock()
+	if tm != nil {
+		return tm, nil
+	}
+	return c.refreshTopicMetadata(topic)
+}
+
+func (c *Client) refreshTopicMetadata(topic string) (*topicMetadata, error) {
+	req := appendMetadataRequest(nil, topic)
+	resp, err := c.roundTripAny(apiKeyMetadata, apiVersionMetadata, req)
+	if err != nil {
+		return nil, fmt.Errorf("cannot obtain metadata for topic %q: %w", topic, err)
+	}
+	tm, err := parseMetadataResponse(resp, topic)
+	if err != nil {
+		return nil, err
+	}
+	if len(tm.partitions) == 0 {
+		return nil, fmt.Errorf("topic %q has no partitions", topic)
+	}
+	c.mu.Lock()
+	c.metadata[topic] = tm
+	c.mu.Unlock()
+	return tm, nil
+}
+
+func (c *Client) resetTopicMetadata(topic string) {
+	c.mu.Lock()
+	delete(c.metadata, topic)
+	c.mu.Unlock()
+}
+
+// getLeaderAddr returns the address of the leader broker for the given topic partition.
+func (c *Client) getLeaderAddr(topic string, partition int32) (string, error) {
+	tm, err := c.getTopicMetadata(topic)
+	if err != nil {
+		return "", err
+	}
+	for _, pm := range tm.partitions {
+		if pm.partition != partition {
+			continue
+		}
+		if pm.err != nil && pm.leader < 0 {
+			c.resetTopicMetadata(topic)
+			return "", fmt.Errorf("cannot obtain leader for topic %q partition %d: %w", topic, partition, pm.err)
+		}
+		for _, bm := range tm.brokers {
+			if bm.nodeID == pm.leader {
+				return bm.addr, nil
+			}
+		}
+		c.resetTopicMetadata(topic)
+		return "", fmt.Errorf("cannot find leader broker %d for topic %q partition %d", pm.leader, topic, partition)
+	}
+	c.resetTopicMetadata(topic)
+	return "", fmt.Errorf("missing partition %d for topic %q", partition, topic)
+}
+
+// produce sends records to the given topic partition and waits until all the in-sync replicas acknowledge them.
+func (c *Client) produce(topic string, partition int32, records []byte) error {
+	addr, err := c.getLeaderAddr(topic, partition)
+	if err != nil {
+		return err
+	}
+	req := appendProduceRequest(nil, topic, partition, records, int32(c.timeout/time.Millisecond))
+	resp, err := c.roundTrip(addr, apiKeyProduce, apiVersionProduce, req, 2*c.timeout)
+	if err != nil {
+		return err
+	}
+	if err := parseProduceResponse(resp, topic, partition); err != nil {
+		if isMetadataError(err) {
+			c.resetTopicMetadata(topic)
+		}
+		return fmt.Errorf("cannot produce messages to topic %q partition %d at %q: %w", topic, partition, addr, err)
+	}
+	return nil
+}
+
+// fetch fetches records from the given partitions starting from the given offsets.
+//
+// All the partitions must have the same leader at addr.
+func (c *Client) fetch(addr, topic string, offsets map[int32]int64, maxWait time.Duration, maxBytes int32) ([]fetchPartitionResult, error) {
+	req := appendFetchRequest(nil, topic, offsets, int32(maxWait/time.Millisecond), maxBytes, maxBytes)
+	resp, err := c.roundTrip(addr, apiKeyFetch, apiVersionFetch, req, maxWait+c.timeout)
+	if err != nil {
+		return nil, err
+	}
+	return parseFetchResponse(resp, topic)
+}
+
+// listOffsets returns offsets for the given topic partitions at the given timestamp such as offsetEarliest.
+func (c *Client) listOffsets(topic string, partitions []int32, timestamp int64) (map[int32]int64, error) {
+	// Group partitions by leaders.
+	m := make(map[string][]int32)
+	for _, p := range partitions {
+		addr, err := c.getLeaderAddr(topic, p)
+		if err != nil {
+			return nil, err
+		}
+		m[addr] = append(m[addr], p)
+	}
+	offsets := make(map[int32]int64, len(partitions))
+	for addr, ps := range m {
+		req := appendListOffsetsRequest(nil, topic, ps, timestamp)
+		resp, err := c.roundTrip(addr, apiKeyListOffsets, apiVersionListOffsets, req, c.timeout)
+		if err != nil {
+			return nil, err
+		}
+		result, err := parseListOffsetsResponse(resp, topic)
+		if err != nil {
+			if isMetadataError(err) {
+				c.resetTopicMetadata(topic)
+			}
+			return nil, err
+		}
+		for p, offset := range result {
+			offsets[p] = offset
+		}
+	}
+	return offsets, nil
+}
+
+// findCoordinator returns the address of the coordinator broker for the given groupID.
+func (c *Client) findCoordinator(groupID string) (string, error) {
+	req := appendFindCoordinatorRequest(nil, groupID)
+	resp, err := c.roundTripAny(apiKeyFindCoordinator, apiVersionFindCoordinator, req)
+	if err != nil {
+		return "", fmt.Errorf("cannot find coordinator for group %q: %w", groupID, err)
+	}
+	addr, err := parseFindCoordinatorResponse(resp)
+	if err != nil {
+		return "", fmt.Errorf("cannot find coordinator for group %q: %w", groupID, err)
+	}
+	return addr, nil
+}
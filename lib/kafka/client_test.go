@@ -0,0 +1,261 @@
+package kafka
+
+import (
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestProducerConsumer(t *testing.T) {
+	tb := newTestBroker(t, map[string]int{
+		"metrics": 3,
+	})
+	defer tb.stop()
+
+	brokers := []string{"127.0.0.1:1", tb.addr()}
+	p := NewProducer(brokers, "metrics", time.Second)
+	defer p.Close()
+	for i := 0; i < 10; i++ {
+		if err := p.Produce([]byte(fmt.Sprintf("msg_%d", i))); err != nil {
+			t.Fatalf("cannot produce message #%d: %s", i, err)
+		}
+	}
+
+	// Messages must be spread among all the partitions.
+	msgs := tb.messages("metrics")
+	if len(msgs) != 10 {
+		t.Fatalf("unexpected number of messages at the broker; got %d; want 10", len(msgs))
+	}
+	partitions := make(map[int32]int)
+	for _, msg := range msgs {
+		partitions[msg.Partition]++
+	}
+	if len(partitions) != 3 {
+		t.Fatalf("messages must be spread among 3 partitions; got %v", partitions)
+	}
+
+	c := NewConsumer([]string{tb.addr()}, "group1", "metrics", time.Second)
+	values := fetchValues(t, c)
+	if err := c.Commit(); err != nil {
+		t.Fatalf("cannot commit offsets: %s", err)
+	}
+	checkValues(t, values, 0, 10)
+	if values := fetchValues(t, c); len(values) != 0 {
+		t.Fatalf("unexpected messages after reading all the messages: %q", values)
+	}
+	c.Close()
+
+	// Produce more messages and verify that the new consumer for the same group continues from the committed offsets.
+	for i := 10; i < 15; i++ {
+		if err := p.Produce([]byte(fmt.Sprintf("msg_%d", i))); err != nil {
+			t.Fatalf("cannot produce message #%d: %s", i, err)
+		}
+	}
+	c = NewConsumer([]string{tb.addr()}, "group1", "metrics", time.Second)
+	values = fetchValues(t, c)
+	checkValues(t, values, 10, 15)
+	c.Close()
+
+	// The consumer for another group must read all the messages.
+	c = NewConsumer([]string{tb.addr()}, "group2", "metrics", time.Second)
+	values = fetchValues(t, c)
+	checkValues(t, values, 0, 15)
+	c.Close()
+}
+
+func TestConsumerRewind(t *testing.T) {
+	tb := newTestBroker(t, map[string]int{
+		"metrics": 2,
+	})
+	defer tb.stop()
+
+	p := NewProducer([]string{tb.addr()}, "metrics", time.Second)
+	defer p.Close()
+	produce := func(start, end int) {
+		t.Helper()
+		for i := start; i < end; i++ {
+			if err := p.Produce([]byte(fmt.Sprintf("msg_%d", i))); err != nil {
+				t.Fatalf("cannot produce message #%d: %s", i, err)
+			}
+		}
+	}
+
+	c := NewConsumer([]string{tb.addr()}, "group", "metrics", time.Second)
+	defer c.Close()
+
+	// Rewind without committed offsets must start from the earliest offsets.
+	produce(0, 5)
+	checkValues(t, fetchValues(t, c), 0, 5)
+	c.Rewind()
+	checkValues(t, fetchValues(t, c), 0, 5)
+	if err := c.Commit(); err != nil {
+		t.Fatalf("cannot commit offsets: %s", err)
+	}
+
+	// Rewind must return only the messages fetched after the last commit.
+	produce(5, 10)
+	checkValues(t, fetchValues(t, c), 5, 10)
+	c.Rewind()
+	checkValues(t, fetchValues(t, c), 5, 10)
+	if err := c.Commit(); err != nil {
+		t.Fatalf("cannot commit offsets: %s", err)
+	}
+	c.Rewind()
+	if values := fetchValues(t, c); len(values) != 0 {
+		t.Fatalf("unexpected messages after rewinding to the committed offsets: %q", values)
+	}
+}
+
+func TestConsumerOffsetOutOfRange(t *testing.T) {
+	tb := newTestBroker(t, map[string]int{
+		"metrics": 1,
+	})
+	defer tb.stop()
+
+	p := NewProducer([]string{tb.addr()}, "metrics", time.Second)
+	defer p.Close()
+	for i := 0; i < 5; i++ {
+		if err := p.Produce([]byte(fmt.Sprintf("msg_%d", i))); err != nil {
+			t.Fatalf("cannot produce message #%d: %s", i, err)
+		}
+	}
+	c := NewConsumer([]string{tb.addr()}, "group", "metrics", time.Second)
+	defer c.Close()
+	values := fetchValues(t, c)
+	checkValues(t, values, 0, 5)
+	if err := c.Commit(); err != nil {
+		t.Fatalf("cannot commit offsets: %s", err)
+	}
+
+	// Delete messages, which weren't consumed yet, because of retention.
+	for i := 5; i < 10; i++ {
+		if err := p.Produce([]byte(fmt.Sprintf("msg_%d", i))); err != nil {
+			t.Fatalf("cannot produce message #%d: %s", i, err)
+		}
+	}
+	tb.deleteMessages("metrics", 0, 7)
+
+	// The consumer must continue from the earliest available offset.
+	// The first Fetch call resets the offset, so it returns nothing.
+	msgs, err := c.Fetch(0)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(msgs) != 0 {
+		t.Fatalf("unexpected messages returned after resetting the offset: %+v", msgs)
+	}
+	values = fetchValues(t, c)
+	checkValues(t, values, 7, 10)
+}
+
+func TestConsumerControlBatch(t *testing.T) {
+	tb := newTestBroker(t, map[string]int{
+		"metrics": 1,
+	})
+	defer tb.stop()
+
+	// The partition contains only a transaction marker.
+	tb.appendControlRecord("metrics", 0)
+	c := NewConsumer([]string{tb.addr()}, "group", "metrics", time.Second)
+	defer c.Close()
+	if values := fetchValues(t, c); len(values) != 0 {
+		t.Fatalf("unexpected messages for control batch: %q", values)
+	}
+	if offset := c.offsets[0]; offset != 1 {
+		t.Fatalf("the offset must be advanced past the control batch; got %d; want 1", offset)
+	}
+
+	// Messages after transaction markers must be returned.
+	p := NewProducer([]string{tb.addr()}, "metrics", time.Second)
+	defer p.Close()
+	for i := 0; i < 3; i++ {
+		if err := p.Produce([]byte(fmt.Sprintf("msg_%d", i))); err != nil {
+			t.Fatalf("cannot produce message #%d: %s", i, err)
+		}
+		tb.appendControlRecord("metrics", 0)
+	}
+	values := fetchValues(t, c)
+	checkValues(t, values, 0, 3)
+	if offset := c.offsets[0]; offset != 7 {
+		t.Fatalf("unexpected offset after reading all the messages; got %d; want 7", offset)
+	}
+}
+
+func TestProducerRetry(t *testing.T) {
+	tb := newTestBroker(t, map[string]int{
+		"metrics": 2,
+	})
+	defer tb.stop()
+
+	p := NewProducer([]string{tb.addr()}, "metrics", time.Second)
+	defer p.Close()
+	tb.mu.Lock()
+	tb.produceErrors = 1
+	tb.mu.Unlock()
+	if err := p.Produce([]byte("foo")); err == nil {
+		t.Fatalf("expecting non-nil error")
+	}
+	if err := p.Produce([]byte("foo")); err != nil {
+		t.Fatalf("unexpected error on retry: %s", err)
+	}
+	if msgs := tb.messages("metrics"); len(msgs) != 1 {
+		t.Fatalf("unexpected number of messages; got %d; want 1", len(msgs))
+	}
+}
+
+func TestClientFailure(t *testing.T) {
+	tb := newTestBroker(t, map[string]int{
+		"metrics": 1,
+	})
+	defer tb.stop()
+
+	// Missing topic
+	p := NewProducer([]string{tb.addr()}, "missing-topic", time.Second)
+	if err := p.Produce([]byte("foo")); err == nil {
+		t.Fatalf("expecting non-nil error for missing topic")
+	}
+	p.Close()
+	c := NewConsumer([]string{tb.addr()}, "group", "missing-topic", time.Second)
+	if _, err := c.Fetch(0); err == nil {
+		t.Fatalf("expecting non-nil error for missing topic")
+	}
+	c.Close()
+
+	// Unavailable brokers
+	p = NewProducer([]string{"127.0.0.1:1"}, "metrics", time.Second)
+	if err := p.Produce([]byte("foo")); err == nil {
+		t.Fatalf("expecting non-nil error for unavailable broker")
+	}
+	p.Close()
+}
+
+func fetchValues(t *testing.T, c *Consumer) []string {
+	t.Helper()
+	var values []string
+	for {
+		msgs, err := c.Fetch(0)
+		if err != nil {
+			t.Fatalf("cannot fetch messages: %s", err)
+		}
+		if len(msgs) == 0 {
+			return values
+		}
+		for _, msg := range msgs {
+			values = append(values, string(msg.Value))
+		}
+	}
+}
+
+func checkValues(t *testing.T, values []string, start, end int) {
+	t.Helper()
+	var valuesExpected []string
+	for i := start; i < end; i++ {
+		valuesExpected = append(valuesExpected, fmt.Sprintf("msg_%d", i))
+	}
+	sort.Strings(values)
+	sort.Strings(valuesExpected)
+	if fmt.Sprintf("%q", values) != fmt.Sprintf("%q", valuesExpected) {
+		t.Fatalf("unexpected values;\ngot\n%q\nwant\n%q", values, valuesExpected)
+	}
+}
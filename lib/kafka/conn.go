@@ -0,0 +1,90 @@
+package kafka
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+// maxResponseSize is the maximum response size, which can be read from Kafka broker.
+const maxResponseSize = 256 * 1024 * 1024
+
+// conn is a connection to a single Kafka broker.
+//
+// Requests over conn are serialized.
+type conn struct {
+	addr     string
+	clientID string
+
+	mu            sync.Mutex
+	c             net.Conn
+	br            *bufio.Reader
+	correlationID int32
+	buf           []byte
+}
+
+func dialConn(addr, clientID string, timeout time.Duration) (*conn, error) {
+	c, err := net.DialTimeout("tcp", addr, timeout)
+	if err != nil {
+		return nil, fmt.Errorf("cannot connect to Kafka broker %q: %w", addr, err)
+	}
+	return &conn{
+		addr:     addr,
+		clientID: clientID,
+		c:        c,
+		br:       bufio.NewReaderSize(c, 64*1024),
+	}, nil
+}
+
+func (c *conn) close() {
+	_ = c.c.Close()
+}
+
+// roundTrip sends the request with the given apiKey, apiVersion and body to the broker and returns response body.
+//
+// The connection must be closed on error, since it may be left in inconsistent state.
+func (c *conn) roundTrip(apiKey, apiVersion int16, body []byte, timeout time.Duration) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.correlationID++
+	correlationID := c.correlationID
+
+	// Request header v1. See https://kafka.apache.org/protocol#protocol_messages
+	b := c.buf[:0]
+	b = appendInt32(b, 0)
+	b = appendInt16(b, apiKey)
+	b = appendInt16(b, apiVersion)
+	b = appendInt32(b, correlationID)
+	b = appendString(b, c.clientID)
+	b = append(b, body...)
+	binary.BigEndian.PutUint32(b, uint32(len(b)-4))
+	c.buf = b
+
+	if err := c.c.SetDeadline(time.Now().Add(timeout)); err != nil {
+		return nil, fmt.Errorf("cannot set deadline for connection to Kafka broker %q: %w", c.addr, err)
+	}
+	if _, err := c.c.Write(b); err != nil {
+		return nil, fmt.Errorf("cannot send request to Kafka broker %q: %w", c.addr, err)
+	}
+	var sizeBuf [4]byte
+	if _, err := io.ReadFull(c.br, sizeBuf[:]); err != nil {
+		return nil, fmt.Errorf("cannot read response size from Kafka broker %q: %w", c.addr, err)
+	}
+	size := binary.BigEndian.Uint32(sizeBuf[:])
+	if size < 4 || size > maxResponseSize {
+		return nil, fmt.Errorf("unexpected response size from Kafka broker %q: %d bytes", c.addr, size)
+	}
+	resp := make([]byte, size)
+	if _, err := io.ReadFull(c.br, resp); err != nil {
+		return nil, fmt.Errorf("cannot read response from Kafka broker %q: %w", c.addr, err)
+	}
+	if id := int32(binary.BigEndian.Uint32(resp)); id != correlationID {
+		return nil, fmt.Errorf("unexpected correlation id in response from Kafka broker %q; got %d; want %d", c.addr, id, correlationID)
+	}
+	return resp[4:], nil
+}
@@ -0,0 +1,238 @@
+package kafka
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// maxFetchBytes is the maximum number of bytes to fetch per partition in a single request.
+const maxFetchBytes = 16 * 1024 * 1024
+
+// Consumer consumes messages from all the partitions of the given Kafka topic.
+//
+// Consumer doesn't participate in consumer group rebalancing - it reads all the topic partitions.
+// The consumed offsets are committed for the given group, so the consumption continues
+// from the last committed offsets after the restart. If there are no committed offsets,
+// then the consumption starts from the earliest available offsets.
+//
+// Consumer methods cannot be called from concurrently running goroutines.
+type Consumer struct {
+	c       *Client
+	groupID string
+	topic   string
+
+	// offsets contains the next offset to fetch per each partition.
+	offsets map[int32]int64
+
+	// committedOffsets contains the last committed offset per each partition.
+	committedOffsets map[int32]int64
+
+	coordinatorAddr string
+}
+
+// NewConsumer returns new consumer for the given topic at the given bootstrap brokers.
+//
+// Offsets are committed for the given groupID.
+func NewConsumer(brokers []string, groupID, topic string, timeout time.Duration) *Consumer {
+	return &Consumer{
+		c:       NewClient(brokers, "vmagent-consumer", timeout),
+		groupID: groupID,
+		topic:   topic,
+	}
+}
+
+// Close closes c.
+func (c *Consumer) Close() {
+	c.c.Close()
+}
+
+// Fetch returns the next messages from the topic.
+//
+// It waits for up to maxWait for new messages. It returns empty result if there are no new messages.
+// Key and Value fields of the returned messages remain valid until the next Fetch call.
+// Call Commit after the returned messages are processed.
+func (c *Consumer) Fetch(maxWait time.Duration) ([]Message, error) {
+	if c.offsets == nil {
+		if err := c.initOffsets(); err != nil {
+			return nil, err
+		}
+	}
+
+	// Group partitions by leaders.
+	m := make(map[string]map[int32]int64)
+	for p, offset := range c.offsets {
+		addr, err := c.c.getLeaderAddr(c.topic, p)
+		if err != nil {
+			return nil, err
+		}
+		offsets := m[addr]
+		if offsets == nil {
+			offsets = make(map[int32]int64)
+			m[addr] = offsets
+		}
+		offsets[p] = offset
+	}
+	addrs := make([]string, 0, len(m))
+	for addr := range m {
+		addrs = append(addrs, addr)
+	}
+	sort.Strings(addrs)
+
+	// Fetch messages from leaders. Wait for new messages only at the first leader,
+	// since the rest of leaders are queried after that.
+	// The offsets are updated only on success, so the messages aren't lost on errors.
+	var msgs []Message
+	nextOffsets := make(map[int32]int64)
+	for _, addr := range addrs {
+		results, err := c.c.fetch(addr, c.topic, m[addr], maxWait, maxFetchBytes)
+		if err != nil {
+			return nil, fmt.Errorf("cannot fetch messages for topic %q from %q: %w", c.topic, addr, err)
+		}
+		maxWait = 0
+		for _, r := range results {
+			if r.err == errOffsetOutOfRange {
+				if err := c.resetOffset(r.partition); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			if r.err != nil {
+				if isMetadataError(r.err) {
+					c.c.resetTopicMetadata(c.topic)
+				}
+				return nil, fmt.Errorf("cannot fetch messages for topic %q partition %d from %q: %w", c.topic, r.partition, addr, r.err)
+			}
+			offset, ok := c.offsets[r.partition]
+			if !ok {
+				continue
+			}
+			var nextOffset int64
+			msgs, nextOffset, err = unmarshalRecordBatches(msgs, r.records, r.partition, offset)
+			if err != nil {
+				return nil, fmt.Errorf("cannot read messages for topic %q partition %d: %w", c.topic, r.partition, err)
+			}
+			// The offset must be advanced even if no messages are returned,
+			// since the fetched batches may contain only control records or already read messages.
+			if nextOffset > offset {
+				nextOffsets[r.partition] = nextOffset
+			}
+		}
+	}
+	for p, offset := range nextOffsets {
+		c.offsets[p] = offset
+	}
+	return msgs, nil
+}
+
+// Commit commits the offsets for messages returned from Fetch calls.
+func (c *Consumer) Commit() error {
+	offsets := make(map[int32]int64)
+	for p, offset := range c.offsets {
+		if c.committedOffsets[p] != offset {
+			offsets[p] = offset
+		}
+	}
+	if len(offsets) == 0 {
+		return nil
+	}
+	if c.coordinatorAddr == "" {
+		addr, err := c.c.findCoordinator(c.groupID)
+		if err != nil {
+			return err
+		}
+		c.coordinatorAddr = addr
+	}
+	req := appendOffsetCommitRequest(nil, c.groupID, c.topic, offsets)
+	resp, err := c.c.roundTrip(c.coordinatorAddr, apiKeyOffsetCommit, apiVersionOffsetCommit, req, c.c.timeout)
+	if err == nil {
+		err = parseOffsetCommitResponse(resp)
+	}
+	if err != nil {
+		// Look up the coordinator again on the next call, since it may be moved to another broker.
+		c.coordinatorAddr = ""
+		return fmt.Errorf("cannot commit offsets for topic %q, group %q: %w", c.topic, c.groupID, err)
+	}
+	for p, offset := range offsets {
+		c.committedOffsets[p] = offset
+	}
+	return nil
+}
+
+// Rewind makes the next Fetch call to return messages starting from the last committed offsets.
+//
+// This allows processing the messages fetched after the last Commit call again.
+func (c *Consumer) Rewind() {
+	// The offsets are re-initialized from the committed offsets on the next Fetch call.
+	c.offsets = nil
+}
+
+func (c *Consumer) initOffsets() error {
+	partitions, err := c.c.Partitions(c.topic)
+	if err != nil {
+		return err
+	}
+	committedOffsets, err := c.fetchCommittedOffsets(partitions)
+	if err != nil {
+		return err
+	}
+	var missingPartitions []int32
+	for _, p := range partitions {
+		if _, ok := committedOffsets[p]; !ok {
+			missingPartitions = append(missingPartitions, p)
+		}
+	}
+	offsets := make(map[int32]int64, len(partitions))
+	if len(missingPartitions) > 0 {
+		earliestOffsets, err := c.c.listOffsets(c.topic, missingPartitions, offsetEarliest)
+		if err != nil {
+			return fmt.Errorf("cannot obtain the earliest offsets for topic %q: %w", c.topic, err)
+		}
+		for p, offset := range earliestOffsets {
+			offsets[p] = offset
+		}
+	}
+	for p, offset := range committedOffsets {
+		offsets[p] = offset
+	}
+	c.offsets = offsets
+	c.committedOffsets = committedOffsets
+	return nil
+}
+
+func (c *Consumer) fetchCommittedOffsets(partitions []int32) (map[int32]int64, error) {
+	addr, err := c.c.findCoordinator(c.groupID)
+	if err != nil {
+		return nil, err
+	}
+	req := appendOffsetFetchRequest(nil, c.groupID, c.topic, partitions)
+	resp, err := c.c.roundTrip(addr, apiKeyOffsetFetch, apiVersionOffsetFetch, req, c.c.timeout)
+	if err != nil {
+		return nil, fmt.Errorf("cannot obtain committed offsets for topic %q, group %q: %w", c.topic, c.groupID, err)
+	}
+	offsets, err := parseOffsetFetchResponse(resp, c.topic)
+	if err != nil {
+		if isCoordinatorError(err) {
+			return nil, fmt.Errorf("coordinator %q isn't ready for group %q: %w", addr, c.groupID, err)
+		}
+		return nil, fmt.Errorf("cannot obtain committed offsets for group %q: %w", c.groupID, err)
+	}
+	c.coordinatorAddr = addr
+	return offsets, nil
+}
+
+// resetOffset resets the offset for the given partition to the earliest available offset.
+//
+// This is needed when the next offset to fetch is already deleted because of topic retention.
+func (c *Consumer) resetOffset(partition int32) error {
+	offsets, err := c.c.listOffsets(c.topic, []int32{partition}, offsetEarliest)
+	if err != nil {
+		return fmt.Errorf("cannot reset offset for topic %q partition %d: %w", c.topic, partition, err)
+	}
+	offset, ok := offsets[partition]
+	if !ok {
+		return fmt.Errorf("missing the earliest offset for topic %q partition %d", c.topic, partition)
+	}
+	c.offsets[partition] = offset
+	return nil
+}
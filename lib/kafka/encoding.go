@@ -0,0 +1,176 @@
+package kafka
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// The functions below marshal primitive types according to https://kafka.apache.org/protocol#protocol_types
+
+func appendInt8(dst []byte, v int8) []byte {
+	return append(dst, byte(v))
+}
+
+func appendInt16(dst []byte, v int16) []byte {
+	u := uint16(v)
+	return append(dst, byte(u>>8), byte(u))
+}
+
+func appendInt32(dst []byte, v int32) []byte {
+	u := uint32(v)
+	return append(dst, byte(u>>24), byte(u>>16), byte(u>>8), byte(u))
+}
+
+func appendInt64(dst []byte, v int64) []byte {
+	u := uint64(v)
+	return append(dst, byte(u>>56), byte(u>>48), byte(u>>40), byte(u>>32), byte(u>>24), byte(u>>16), byte(u>>8), byte(u))
+}
+
+func appendString(dst []byte, s string) []byte {
+	dst = appendInt16(dst, int16(len(s)))
+	return append(dst, s...)
+}
+
+func appendNullString(dst []byte) []byte {
+	return appendInt16(dst, -1)
+}
+
+// appendBytes appends b to dst. Nil b is marshaled as null bytes.
+func appendBytes(dst, b []byte) []byte {
+	if b == nil {
+		return appendInt32(dst, -1)
+	}
+	dst = appendInt32(dst, int32(len(b)))
+	return append(dst, b...)
+}
+
+func appendArrayLen(dst []byte, n int) []byte {
+	return appendInt32(dst, int32(n))
+}
+
+func appendVarint(dst []byte, v int64) []byte {
+	var buf [binary.MaxVarintLen64]byte
+	n := binary.PutVarint(buf[:], v)
+	return append(dst, buf[:n]...)
+}
+
+// appendVarintBytes appends b with varint length prefix to dst. Nil b is marshaled with -1 length.
+func appendVarintBytes(dst, b []byte) []byte {
+	if b == nil {
+		return appendVarint(dst, -1)
+	}
+	dst = appendVarint(dst, int64(len(b)))
+	return append(dst, b...)
+}
+
+// decoder unmarshals primitive types from b.
+//
+// The first error is stored in err, so the caller may check it only once after reading all the needed values.
+type decoder struct {
+	b   []byte
+	err error
+}
+
+func (d *decoder) next(n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+	if n < 0 || len(d.b) < n {
+		d.err = fmt.Errorf("unexpected end of data; want %d bytes; got %d bytes", n, len(d.b))
+		d.b = nil
+		return nil
+	}
+	b := d.b[:n]
+	d.b = d.b[n:]
+	return b
+}
+
+func (d *decoder) int8() int8 {
+	b := d.next(1)
+	if b == nil {
+		return 0
+	}
+	return int8(b[0])
+}
+
+func (d *decoder) int16() int16 {
+	b := d.next(2)
+	if b == nil {
+		return 0
+	}
+	return int16(binary.BigEndian.Uint16(b))
+}
+
+func (d *decoder) int32() int32 {
+	b := d.next(4)
+	if b == nil {
+		return 0
+	}
+	return int32(binary.BigEndian.Uint32(b))
+}
+
+func (d *decoder) int64() int64 {
+	b := d.next(8)
+	if b == nil {
+		return 0
+	}
+	return int64(binary.BigEndian.Uint64(b))
+}
+
+func (d *decoder) string() string {
+	n := d.int16()
+	if n < 0 {
+		// null string
+		return ""
+	}
+	return string(d.next(int(n)))
+}
+
+// bytes returns the next bytes. The returned bytes refer to d.b.
+func (d *decoder) bytes() []byte {
+	n := d.int32()
+	if n < 0 {
+		return nil
+	}
+	return d.next(int(n))
+}
+
+// arrayLen returns the next array length. Null array is returned as zero length.
+func (d *decoder) arrayLen() int {
+	n := d.int32()
+	if d.err != nil {
+		return 0
+	}
+	if n < 0 {
+		return 0
+	}
+	if int(n) > len(d.b) {
+		// Each array item occupies at least a single byte.
+		d.err = fmt.Errorf("too big array length: %d; remaining data is %d bytes", n, len(d.b))
+		return 0
+	}
+	return int(n)
+}
+
+func (d *decoder) varint() int64 {
+	if d.err != nil {
+		return 0
+	}
+	v, n := binary.Varint(d.b)
+	if n <= 0 {
+		d.err = fmt.Errorf("cannot read varint")
+		d.b = nil
+		return 0
+	}
+	d.b = d.b[n:]
+	return v
+}
+
+// varintBytes returns the next bytes with varint length prefix. The returned bytes refer to d.b.
+func (d *decoder) varintBytes() []byte {
+	n := d.varint()
+	if n < 0 {
+		return nil
+	}
+	return d.next(int(n))
+}
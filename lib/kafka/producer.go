@@ -0,0 +1,54 @@
+package kafka
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// Producer sends messages to the given Kafka topic.
+//
+// Messages are spread among topic partitions in round-robin manner.
+// It is safe calling Producer methods from concurrently running goroutines.
+type Producer struct {
+	c     *Client
+	topic string
+
+	// n is used for selecting the next partition to send message to.
+	n uint32
+}
+
+// NewProducer returns new producer for the given topic at the given bootstrap brokers.
+func NewProducer(brokers []string, topic string, timeout time.Duration) *Producer {
+	return &Producer{
+		c:     NewClient(brokers, "vmagent-producer", timeout),
+		topic: topic,
+	}
+}
+
+// Produce sends the message with the given value to Kafka.
+//
+// It returns nil only after the message is acknowledged by all the in-sync replicas,
+// so the caller may safely drop the value after that.
+func (p *Producer) Produce(value []byte) error {
+	partitions, err := p.c.Partitions(p.topic)
+	if err != nil {
+		return err
+	}
+	n := atomic.AddUint32(&p.n, 1)
+	partition := partitions[n%uint32(len(partitions))]
+	msgs := []Message{{
+		Timestamp: time.Now().UnixNano() / 1e6,
+		Value:     value,
+	}}
+	records := appendRecordBatch(nil, 0, msgs)
+	if err := p.c.produce(p.topic, partition, records); err != nil {
+		return fmt.Errorf("cannot send message with size %d bytes: %w", len(value), err)
+	}
+	return nil
+}
+
+// Close closes p.
+func (p *Producer) Close() {
+	p.c.Close()
+}
@@ -0,0 +1,460 @@
+package kafka
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// API keys and versions for the supported Kafka requests.
+//
+// See https://kafka.apache.org/protocol#protocol_api_keys
+const (
+	apiKeyProduce         = 0
+	apiKeyFetch           = 1
+	apiKeyListOffsets     = 2
+	apiKeyMetadata        = 3
+	apiKeyOffsetCommit    = 8
+	apiKeyOffsetFetch     = 9
+	apiKeyFindCoordinator = 10
+
+	apiVersionProduce         = 3
+	apiVersionFetch           = 4
+	apiVersionListOffsets     = 1
+	apiVersionMetadata        = 1
+	apiVersionOffsetCommit    = 2
+	apiVersionOffsetFetch     = 1
+	apiVersionFindCoordinator = 0
+)
+
+// offsetEarliest is a special timestamp for ListOffsets request, which returns the earliest available offset.
+const offsetEarliest = -2
+
+// Error is an error code returned by Kafka broker.
+//
+// See https://kafka.apache.org/protocol#protocol_error_codes
+type Error int16
+
+// Error codes, which are handled by the client.
+const (
+	errOffsetOutOfRange         Error = 1
+	errUnknownTopicOrPartition  Error = 3
+	errLeaderNotAvailable       Error = 5
+	errNotLeaderForPartition    Error = 6
+	errCoordinatorNotAvailable  Error = 15
+	errNotCoordinator           Error = 16
+	errCoordinatorLoadInProcess Error = 14
+)
+
+var errorNames = map[Error]string{
+	errOffsetOutOfRange:         "OFFSET_OUT_OF_RANGE",
+	errUnknownTopicOrPartition:  "UNKNOWN_TOPIC_OR_PARTITION",
+	errLeaderNotAvailable:       "LEADER_NOT_AVAILABLE",
+	errNotLeaderForPartition:    "NOT_LEADER_OR_FOLLOWER",
+	7:                           "REQUEST_TIMED_OUT",
+	errCoordinatorLoadInProcess: "COORDINATOR_LOAD_IN_PROGRESS",
+	errCoordinatorNotAvailable:  "COORDINATOR_NOT_AVAILABLE",
+	errNotCoordinator:           "NOT_COORDINATOR",
+	19:                          "NOT_ENOUGH_REPLICAS",
+	20:                          "NOT_ENOUGH_REPLICAS_AFTER_APPEND",
+	25:                          "UNKNOWN_MEMBER_ID",
+	29:                          "TOPIC_AUTHORIZATION_FAILED",
+	30:                          "GROUP_AUTHORIZATION_FAILED",
+}
+
+// Error implements error interface.
+func (e Error) Error() string {
+	if name, ok := errorNames[e]; ok {
+		return fmt.Sprintf("kafka error %d (%s)", int16(e), name)
+	}
+	return fmt.Sprintf("kafka error %d", int16(e))
+}
+
+func errorFromCode(code int16) error {
+	if code == 0 {
+		return nil
+	}
+	return Error(code)
+}
+
+// isMetadataError returns true if err means that the cached topic metadata must be refreshed.
+func isMetadataError(err error) bool {
+	var e Error
+	if !errors.As(err, &e) {
+		return false
+	}
+	switch e {
+	case errUnknownTopicOrPartition, errLeaderNotAvailable, errNotLeaderForPartition:
+		return true
+	default:
+		return false
+	}
+}
+
+// isCoordinatorError returns true if err means that the group coordinator must be looked up again.
+func isCoordinatorError(err error) bool {
+	var e Error
+	if !errors.As(err, &e) {
+		return false
+	}
+	switch e {
+	case errCoordinatorNotAvailable, errNotCoordinator, errCoordinatorLoadInProcess:
+		return true
+	default:
+		return false
+	}
+}
+
+type brokerMetadata struct {
+	nodeID int32
+	addr   string
+}
+
+type partitionMetadata struct {
+	partition int32
+	leader    int32
+	err       error
+}
+
+type topicMetadata struct {
+	brokers    []brokerMetadata
+	partitions []partitionMetadata
+}
+
+func appendMetadataRequest(dst []byte, topic string) []byte {
+	dst = appendArrayLen(dst, 1)
+	return appendString(dst, topic)
+}
+
+func parseMetadataResponse(data []byte, topic string) (*topicMetadata, error) {
+	d := &decoder{
+		b: data,
+	}
+	var tm topicMetadata
+	n := d.arrayLen()
+	for i := 0; i < n; i++ {
+		nodeID := d.int32()
+		host := d.string()
+		port := d.int32()
+		_ = d.string() // rack
+		tm.brokers = append(tm.brokers, brokerMetadata{
+			nodeID: nodeID,
+			addr:   fmt.Sprintf("%s:%d", host, port),
+		})
+	}
+	_ = d.int32() // controller_id
+	var topicErr error
+	topicFound := false
+	n = d.arrayLen()
+	for i := 0; i < n; i++ {
+		errCode := d.int16()
+		name := d.string()
+		_ = d.int8() // is_internal
+		m := d.arrayLen()
+		for j := 0; j < m; j++ {
+			pErrCode := d.int16()
+			partition := d.int32()
+			leader := d.int32()
+			for k, replicas := 0, d.arrayLen(); k < replicas; k++ {
+				_ = d.int32()
+			}
+			for k, isr := 0, d.arrayLen(); k < isr; k++ {
+				_ = d.int32()
+			}
+			if name != topic {
+				continue
+			}
+			tm.partitions = append(tm.partitions, partitionMetadata{
+				partition: partition,
+				leader:    leader,
+				err:       errorFromCode(pErrCode),
+			})
+		}
+		if name == topic {
+			topicFound = true
+			topicErr = errorFromCode(errCode)
+		}
+	}
+	if d.err != nil {
+		return nil, fmt.Errorf("cannot parse metadata response: %w", d.err)
+	}
+	if !topicFound {
+		return nil, fmt.Errorf("missing topic %q in metadata response", topic)
+	}
+	if topicErr != nil {
+		return nil, fmt.Errorf("cannot obtain metadata for topic %q: %w", topic, topicErr)
+	}
+	sort.Slice(tm.partitions, func(i, j int) bool {
+		return tm.partitions[i].partition < tm.partitions[j].partition
+	})
+	return &tm, nil
+}
+
+func appendProduceRequest(dst []byte, topic string, partition int32, records []byte, timeoutMsecs int32) []byte {
+	// transactional_id
+	dst = appendNullString(dst)
+	// acks=-1 means waiting for all the in-sync replicas
+	dst = appendInt16(dst, -1)
+	dst = appendInt32(dst, timeoutMsecs)
+	dst = appendArrayLen(dst, 1)
+	dst = appendString(dst, topic)
+	dst = appendArrayLen(dst, 1)
+	dst = appendInt32(dst, partition)
+	return appendBytes(dst, records)
+}
+
+func parseProduceResponse(data []byte, topic string, partition int32) error {
+	d := &decoder{
+		b: data,
+	}
+	var partitionErr error
+	found := false
+	n := d.arrayLen()
+	for i := 0; i < n; i++ {
+		name := d.string()
+		m := d.arrayLen()
+		for j := 0; j < m; j++ {
+			p := d.int32()
+			errCode := d.int16()
+			_ = d.int64() // base_offset
+			_ = d.int64() // log_append_time_ms
+			if name == topic && p == partition {
+				found = true
+				partitionErr = errorFromCode(errCode)
+			}
+		}
+	}
+	_ = d.int32() // throttle_time_ms
+	if d.err != nil {
+		return fmt.Errorf("cannot parse produce response: %w", d.err)
+	}
+	if !found {
+		return fmt.Errorf("missing topic %q partition %d in produce response", topic, partition)
+	}
+	return partitionErr
+}
+
+type fetchPartitionResult struct {
+	partition int32
+	err       error
+	records   []byte
+}
+
+func appendFetchRequest(dst []byte, topic string, offsets map[int32]int64, maxWaitMsecs, maxBytes, partitionMaxBytes int32) []byte {
+	// replica_id
+	dst = appendInt32(dst, -1)
+	dst = appendInt32(dst, maxWaitMsecs)
+	// min_bytes
+	dst = appendInt32(dst, 1)
+	dst = appendInt32(dst, maxBytes)
+	// isolation_level=READ_COMMITTED
+	dst = appendInt8(dst, 1)
+	dst = appendArrayLen(dst, 1)
+	dst = appendString(dst, topic)
+	partitions := sortedPartitions(offsets)
+	dst = appendArrayLen(dst, len(partitions))
+	for _, p := range partitions {
+		dst = appendInt32(dst, p)
+		dst = appendInt64(dst, offsets[p])
+		dst = appendInt32(dst, partitionMaxBytes)
+	}
+	return dst
+}
+
+func parseFetchResponse(data []byte, topic string) ([]fetchPartitionResult, error) {
+	d := &decoder{
+		b: data,
+	}
+	_ = d.int32() // throttle_time_ms
+	var results []fetchPartitionResult
+	n := d.arrayLen()
+	for i := 0; i < n; i++ {
+		name := d.string()
+		m := d.arrayLen()
+		for j := 0; j < m; j++ {
+			partition := d.int32()
+			errCode := d.int16()
+			_ = d.int64() // high_watermark
+			_ = d.int64() // last_stable_offset
+			for k, aborted := 0, d.arrayLen(); k < aborted; k++ {
+				_ = d.int64() // producer_id
+				_ = d.int64() // first_offset
+			}
+			records := d.bytes()
+			if name != topic {
+				continue
+			}
+			results = append(results, fetchPartitionResult{
+				partition: partition,
+				err:       errorFromCode(errCode),
+				records:   records,
+			})
+		}
+	}
+	if d.err != nil {
+		return nil, fmt.Errorf("cannot parse fetch response: %w", d.err)
+	}
+	return results, nil
+}
+
+func appendListOffsetsRequest(dst []byte, topic string, partitions []int32, timestamp int64) []byte {
+	// replica_id
+	dst = appendInt32(dst, -1)
+	dst = appendArrayLen(dst, 1)
+	dst = appendString(dst, topic)
+	dst = appendArrayLen(dst, len(partitions))
+	for _, p := range partitions {
+		dst = appendInt32(dst, p)
+		dst = appendInt64(dst, timestamp)
+	}
+	return dst
+}
+
+func parseListOffsetsResponse(data []byte, topic string) (map[int32]int64, error) {
+	d := &decoder{
+		b: data,
+	}
+	offsets := make(map[int32]int64)
+	n := d.arrayLen()
+	for i := 0; i < n; i++ {
+		name := d.string()
+		m := d.arrayLen()
+		for j := 0; j < m; j++ {
+			partition := d.int32()
+			errCode := d.int16()
+			_ = d.int64() // timestamp
+			offset := d.int64()
+			if name != topic {
+				continue
+			}
+			if err := errorFromCode(errCode); err != nil {
+				return nil, fmt.Errorf("cannot obtain offset for topic %q partition %d: %w", topic, partition, err)
+			}
+			offsets[partition] = offset
+		}
+	}
+	if d.err != nil {
+		return nil, fmt.Errorf("cannot parse list offsets response: %w", d.err)
+	}
+	return offsets, nil
+}
+
+func appendFindCoordinatorRequest(dst []byte, groupID string) []byte {
+	return appendString(dst, groupID)
+}
+
+func parseFindCoordinatorResponse(data []byte) (string, error) {
+	d := &decoder{
+		b: data,
+	}
+	errCode := d.int16()
+	_ = d.int32() // node_id
+	host := d.string()
+	port := d.int32()
+	if d.err != nil {
+		return "", fmt.Errorf("cannot parse find coordinator response: %w", d.err)
+	}
+	if err := errorFromCode(errCode); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s:%d", host, port), nil
+}
+
+func appendOffsetCommitRequest(dst []byte, groupID, topic string, offsets map[int32]int64) []byte {
+	dst = appendString(dst, groupID)
+	// generation_id=-1 and empty member_id are used for committing offsets without group membership.
+	dst = appendInt32(dst, -1)
+	dst = appendString(dst, "")
+	// retention_time_ms=-1 means the default retention configured at the broker.
+	dst = appendInt64(dst, -1)
+	dst = appendArrayLen(dst, 1)
+	dst = appendString(dst, topic)
+	partitions := sortedPartitions(offsets)
+	dst = appendArrayLen(dst, len(partitions))
+	for _, p := range partitions {
+		dst = appendInt32(dst, p)
+		dst = appendInt64(dst, offsets[p])
+		// metadata
+		dst = appendNullString(dst)
+	}
+	return dst
+}
+
+func parseOffsetCommitResponse(data []byte) error {
+	d := &decoder{
+		b: data,
+	}
+	var firstErr error
+	n := d.arrayLen()
+	for i := 0; i < n; i++ {
+		_ = d.string() // name
+		m := d.arrayLen()
+		for j := 0; j < m; j++ {
+			_ = d.int32() // partition
+			errCode := d.int16()
+			if err := errorFromCode(errCode); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	if d.err != nil {
+		return fmt.Errorf("cannot parse offset commit response: %w", d.err)
+	}
+	return firstErr
+}
+
+func appendOffsetFetchRequest(dst []byte, groupID, topic string, partitions []int32) []byte {
+	dst = appendString(dst, groupID)
+	dst = appendArrayLen(dst, 1)
+	dst = appendString(dst, topic)
+	dst = appendArrayLen(dst, len(partitions))
+	for _, p := range partitions {
+		dst = appendInt32(dst, p)
+	}
+	return dst
+}
+
+// parseOffsetFetchResponse returns committed offsets for topic partitions.
+//
+// Partitions without committed offsets are missing in the result.
+func parseOffsetFetchResponse(data []byte, topic string) (map[int32]int64, error) {
+	d := &decoder{
+		b: data,
+	}
+	offsets := make(map[int32]int64)
+	n := d.arrayLen()
+	for i := 0; i < n; i++ {
+		name := d.string()
+		m := d.arrayLen()
+		for j := 0; j < m; j++ {
+			partition := d.int32()
+			offset := d.int64()
+			_ = d.string() // metadata
+			errCode := d.int16()
+			if name != topic {
+				continue
+			}
+			if err := errorFromCode(errCode); err != nil {
+				return nil, fmt.Errorf("cannot obtain committed offset for topic %q partition %d: %w", topic, partition, err)
+			}
+			if offset >= 0 {
+				offsets[partition] = offset
+			}
+		}
+	}
+	if d.err != nil {
+		return nil, fmt.Errorf("cannot parse offset fetch response: %w", d.err)
+	}
+	return offsets, nil
+}
+
+func sortedPartitions(offsets map[int32]int64) []int32 {
+	partitions := make([]int32, 0, len(offsets))
+	for p := range offsets {
+		partitions = append(partitions, p)
+	}
+	sort.Slice(partitions, func(i, j int) bool {
+		return partitions[i] < partitions[j]
+	})
+	return partitions
+}
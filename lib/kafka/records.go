@@ -0,0 +1,223 @@
+package kafka
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io/ioutil"
+
+	"github.com/klauspost/compress/gzip"
+)
+
+// Message is a single Kafka message.
+type Message struct {
+	// Partition is the topic partition the message belongs to.
+	Partition int32
+
+	// Offset is the message offset in the Partition.
+	Offset int64
+
+	// Timestamp is the message timestamp in milliseconds.
+	Timestamp int64
+
+	Key   []byte
+	Value []byte
+}
+
+const (
+	// recordBatchMagic is the magic byte for record batch format.
+	//
+	// See https://kafka.apache.org/documentation/#recordbatch
+	recordBatchMagic = 2
+
+	// recordBatchHeaderSize is the size of record batch header up to records count inclusive.
+	recordBatchHeaderSize = 61
+
+	// recordBatchCRCOffset is the offset of crc field in the record batch.
+	recordBatchCRCOffset = 17
+
+	// recordBatchAttributesOffset is the offset of the attributes field in the record batch.
+	recordBatchAttributesOffset = 21
+
+	compressionCodecMask = 0x07
+	compressionNone      = 0
+	compressionGzip      = 1
+
+	controlBatchFlag = 0x20
+)
+
+var castagnoliTable = crc32.MakeTable(crc32.Castagnoli)
+
+// appendRecordBatch appends uncompressed record batch with msgs to dst.
+//
+// Partition and Offset fields of msgs are ignored. Offsets in the batch start from baseOffset.
+func appendRecordBatch(dst []byte, baseOffset int64, msgs []Message) []byte {
+	if len(msgs) == 0 {
+		return dst
+	}
+	baseTimestamp := msgs[0].Timestamp
+	maxTimestamp := baseTimestamp
+	for i := range msgs {
+		if ts := msgs[i].Timestamp; ts > maxTimestamp {
+			maxTimestamp = ts
+		}
+	}
+	batchStart := len(dst)
+	dst = appendInt64(dst, baseOffset)
+	// batchLength is updated below
+	dst = appendInt32(dst, 0)
+	// partitionLeaderEpoch
+	dst = appendInt32(dst, -1)
+	dst = appendInt8(dst, recordBatchMagic)
+	// crc is updated below
+	dst = appendInt32(dst, 0)
+	// attributes
+	dst = appendInt16(dst, 0)
+	// lastOffsetDelta
+	dst = appendInt32(dst, int32(len(msgs)-1))
+	dst = appendInt64(dst, baseTimestamp)
+	dst = appendInt64(dst, maxTimestamp)
+	// producerId, producerEpoch and baseSequence aren't used, since idempotent producer isn't supported.
+	dst = appendInt64(dst, -1)
+	dst = appendInt16(dst, -1)
+	dst = appendInt32(dst, -1)
+	dst = appendArrayLen(dst, len(msgs))
+	var record []byte
+	for i := range msgs {
+		msg := &msgs[i]
+		record = record[:0]
+		// attributes
+		record = appendInt8(record, 0)
+		record = appendVarint(record, msg.Timestamp-baseTimestamp)
+		record = appendVarint(record, int64(i))
+		record = appendVarintBytes(record, msg.Key)
+		record = appendVarintBytes(record, msg.Value)
+		// headers count
+		record = appendVarint(record, 0)
+
+		dst = appendVarint(dst, int64(len(record)))
+		dst = append(dst, record...)
+	}
+	batch := dst[batchStart:]
+	binary.BigEndian.PutUint32(batch[8:], uint32(len(batch)-12))
+	crc := crc32.Checksum(batch[recordBatchAttributesOffset:], castagnoliTable)
+	binary.BigEndian.PutUint32(batch[recordBatchCRCOffset:], crc)
+	return dst
+}
+
+// unmarshalRecordBatches appends messages from record batches in data to dst and returns the result
+// together with the offset following the last complete batch in data.
+//
+// Messages with offsets smaller than minOffset are skipped, since the broker may return the whole batch
+// containing the requested offset. The last incomplete batch is ignored, since the broker may truncate the response.
+// The returned offset accounts for control batches and batches with all the messages skipped,
+// so the caller may continue reading after them. It equals to minOffset if data contains no complete batches.
+//
+// Key and Value fields of the returned messages may refer to data.
+func unmarshalRecordBatches(dst []Message, data []byte, partition int32, minOffset int64) ([]Message, int64, error) {
+	nextOffset := minOffset
+	for len(data) >= 12 {
+		batchLength := int(int32(binary.BigEndian.Uint32(data[8:])))
+		if batchLength < recordBatchHeaderSize-12 {
+			return dst, nextOffset, fmt.Errorf("too small record batch length: %d bytes", batchLength)
+		}
+		if len(data) < 12+batchLength {
+			// Incomplete batch at the end of the response.
+			break
+		}
+		batch := data[:12+batchLength]
+		data = data[12+batchLength:]
+		var batchEnd int64
+		var err error
+		dst, batchEnd, err = unmarshalRecordBatch(dst, batch, partition, minOffset)
+		if err != nil {
+			return dst, nextOffset, err
+		}
+		if batchEnd > nextOffset {
+			nextOffset = batchEnd
+		}
+	}
+	return dst, nextOffset, nil
+}
+
+// unmarshalRecordBatch appends messages from the given record batch to dst
+// and returns the result together with the offset following the batch.
+func unmarshalRecordBatch(dst []Message, batch []byte, partition int32, minOffset int64) ([]Message, int64, error) {
+	d := &decoder{
+		b: batch,
+	}
+	baseOffset := d.int64()
+	_ = d.int32() // batchLength
+	_ = d.int32() // partitionLeaderEpoch
+	magic := d.int8()
+	if magic != recordBatchMagic {
+		return dst, 0, fmt.Errorf("unsupported message format version: %d; only version %d is supported (Kafka 0.11+)", magic, recordBatchMagic)
+	}
+	crc := uint32(d.int32())
+	if crcExpected := crc32.Checksum(batch[recordBatchAttributesOffset:], castagnoliTable); crc != crcExpected {
+		return dst, 0, fmt.Errorf("crc mismatch for record batch at offset %d; got %d; want %d", baseOffset, crc, crcExpected)
+	}
+	attributes := d.int16()
+	lastOffsetDelta := d.int32()
+	baseTimestamp := d.int64()
+	_ = d.int64() // maxTimestamp
+	_ = d.int64() // producerId
+	_ = d.int16() // producerEpoch
+	_ = d.int32() // baseSequence
+	recordsCount := d.int32()
+	if d.err != nil {
+		return dst, 0, fmt.Errorf("cannot read record batch header: %w", d.err)
+	}
+	batchEnd := baseOffset + int64(lastOffsetDelta) + 1
+	if attributes&controlBatchFlag != 0 {
+		// Skip control batches used by transactions.
+		return dst, batchEnd, nil
+	}
+	switch attributes & compressionCodecMask {
+	case compressionNone:
+	case compressionGzip:
+		zr, err := gzip.NewReader(bytes.NewReader(d.b))
+		if err != nil {
+			return dst, 0, fmt.Errorf("cannot read gzipped record batch at offset %d: %w", baseOffset, err)
+		}
+		records, err := ioutil.ReadAll(zr)
+		if err != nil {
+			return dst, 0, fmt.Errorf("cannot decompress gzipped record batch at offset %d: %w", baseOffset, err)
+		}
+		d.b = records
+	default:
+		return dst, 0, fmt.Errorf("unsupported compression codec for record batch at offset %d: %d; only gzip compression is supported",
+			baseOffset, attributes&compressionCodecMask)
+	}
+	for i := int32(0); i < recordsCount; i++ {
+		recordLen := d.varint()
+		rd := &decoder{
+			b: d.next(int(recordLen)),
+		}
+		if d.err != nil {
+			return dst, 0, fmt.Errorf("cannot read record #%d in batch at offset %d: %w", i, baseOffset, d.err)
+		}
+		_ = rd.int8() // attributes
+		timestampDelta := rd.varint()
+		offsetDelta := rd.varint()
+		key := rd.varintBytes()
+		value := rd.varintBytes()
+		// Headers are ignored.
+		if rd.err != nil {
+			return dst, 0, fmt.Errorf("cannot unmarshal record #%d in batch at offset %d: %w", i, baseOffset, rd.err)
+		}
+		offset := baseOffset + offsetDelta
+		if offset < minOffset {
+			continue
+		}
+		dst = append(dst, Message{
+			Partition: partition,
+			Offset:    offset,
+			Timestamp: baseTimestamp + timestampDelta,
+			Key:       key,
+			Value:     value,
+		})
+	}
+	return dst, batchEnd, nil
+}
@@ -0,0 +1,215 @@
+package kafka
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"reflect"
+	"testing"
+
+	"github.com/klauspost/compress/gzip"
+)
+
+func TestRecordBatchMarshalUnmarshal(t *testing.T) {
+	f := func(baseOffset int64, msgs []Message) {
+		t.Helper()
+		data := appendRecordBatch(nil, baseOffset, msgs)
+		result, nextOffset, err := unmarshalRecordBatches(nil, data, 3, 0)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		nextOffsetExpected := int64(0)
+		if len(msgs) > 0 {
+			nextOffsetExpected = baseOffset + int64(len(msgs))
+		}
+		if nextOffset != nextOffsetExpected {
+			t.Fatalf("unexpected next offset; got %d; want %d", nextOffset, nextOffsetExpected)
+		}
+		var msgsExpected []Message
+		for i, msg := range msgs {
+			msg.Partition = 3
+			msg.Offset = baseOffset + int64(i)
+			msgsExpected = append(msgsExpected, msg)
+		}
+		if !reflect.DeepEqual(result, msgsExpected) {
+			t.Fatalf("unexpected messages;\ngot\n%+v\nwant\n%+v", result, msgsExpected)
+		}
+	}
+	f(0, nil)
+	f(0, []Message{{
+		Timestamp: 1600000000000,
+		Value:     []byte("foo"),
+	}})
+	f(123, []Message{
+		{
+			Timestamp: 1600000000000,
+			Key:       []byte("key"),
+			Value:     []byte("foo"),
+		},
+		{
+			Timestamp: 1599999999000,
+			Value:     []byte{},
+		},
+		{
+			Timestamp: 1600000005000,
+			Key:       []byte{},
+			Value:     bytes.Repeat([]byte("x"), 1000),
+		},
+	})
+}
+
+func TestUnmarshalRecordBatchesMultipleBatches(t *testing.T) {
+	msgs := []Message{
+		{Value: []byte("a")},
+		{Value: []byte("b")},
+		{Value: []byte("c")},
+	}
+	var data []byte
+	data = appendRecordBatch(data, 10, msgs[:2])
+	data = appendRecordBatch(data, 12, msgs[2:])
+
+	f := func(data []byte, minOffset int64, valuesExpected []string, nextOffsetExpected int64) {
+		t.Helper()
+		result, nextOffset, err := unmarshalRecordBatches(nil, data, 0, minOffset)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		var values []string
+		for _, msg := range result {
+			values = append(values, string(msg.Value))
+		}
+		if !reflect.DeepEqual(values, valuesExpected) {
+			t.Fatalf("unexpected values; got %q; want %q", values, valuesExpected)
+		}
+		if nextOffset != nextOffsetExpected {
+			t.Fatalf("unexpected next offset; got %d; want %d", nextOffset, nextOffsetExpected)
+		}
+	}
+	f(data, 0, []string{"a", "b", "c"}, 13)
+
+	// Messages with smaller offsets must be skipped
+	f(data, 11, []string{"b", "c"}, 13)
+	f(data, 12, []string{"c"}, 13)
+	f(data, 13, nil, 13)
+
+	// Batches with all the messages skipped mustn't move the next offset back
+	f(data[:len(data)-1], 12, nil, 12)
+
+	// Incomplete batch at the end must be ignored
+	f(data[:len(data)-1], 0, []string{"a", "b"}, 12)
+	f(data[:8], 0, nil, 0)
+}
+
+func TestUnmarshalRecordBatchesControlBatch(t *testing.T) {
+	f := func(data []byte, valuesExpected []string, nextOffsetExpected int64) {
+		t.Helper()
+		result, nextOffset, err := unmarshalRecordBatches(nil, data, 0, 5)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		var values []string
+		for _, msg := range result {
+			values = append(values, string(msg.Value))
+		}
+		if !reflect.DeepEqual(values, valuesExpected) {
+			t.Fatalf("unexpected values; got %q; want %q", values, valuesExpected)
+		}
+		if nextOffset != nextOffsetExpected {
+			t.Fatalf("unexpected next offset; got %d; want %d", nextOffset, nextOffsetExpected)
+		}
+	}
+
+	// Control batch only
+	data := appendControlBatch(nil, 5)
+	f(data, nil, 6)
+
+	// Control batch between data batches
+	data = appendRecordBatch(nil, 5, []Message{{Value: []byte("a")}})
+	data = appendControlBatch(data, 6)
+	data = appendRecordBatch(data, 7, []Message{{Value: []byte("b")}})
+	f(data, []string{"a", "b"}, 8)
+
+	// Control batch at the end
+	data = appendRecordBatch(nil, 5, []Message{{Value: []byte("a")}})
+	data = appendControlBatch(data, 6)
+	f(data, []string{"a"}, 7)
+}
+
+// appendControlBatch appends a record batch with a single transaction marker at baseOffset to dst.
+func appendControlBatch(dst []byte, baseOffset int64) []byte {
+	batchStart := len(dst)
+	// The control record key contains version and type; the value contains version and coordinator epoch.
+	dst = appendRecordBatch(dst, baseOffset, []Message{{
+		Key:   []byte{0, 0, 0, 1},
+		Value: []byte{0, 0, 0, 0, 0, 0},
+	}})
+	batch := dst[batchStart:]
+	binary.BigEndian.PutUint16(batch[recordBatchAttributesOffset:], controlBatchFlag)
+	binary.BigEndian.PutUint32(batch[recordBatchCRCOffset:], crc32.Checksum(batch[recordBatchAttributesOffset:], castagnoliTable))
+	return dst
+}
+
+func TestUnmarshalRecordBatchesGzip(t *testing.T) {
+	msgs := []Message{
+		{Timestamp: 1000, Value: []byte("foo")},
+		{Timestamp: 2000, Value: []byte("bar")},
+	}
+	batch := appendRecordBatch(nil, 5, msgs)
+
+	// Compress records and update batch header accordingly.
+	var bb bytes.Buffer
+	zw := gzip.NewWriter(&bb)
+	if _, err := zw.Write(batch[recordBatchHeaderSize:]); err != nil {
+		t.Fatalf("cannot compress records: %s", err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("cannot close gzip writer: %s", err)
+	}
+	data := append([]byte{}, batch[:recordBatchHeaderSize]...)
+	data = append(data, bb.Bytes()...)
+	binary.BigEndian.PutUint16(data[recordBatchAttributesOffset:], compressionGzip)
+	binary.BigEndian.PutUint32(data[8:], uint32(len(data)-12))
+	binary.BigEndian.PutUint32(data[recordBatchCRCOffset:], crc32.Checksum(data[recordBatchAttributesOffset:], castagnoliTable))
+
+	result, _, err := unmarshalRecordBatches(nil, data, 0, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(result) != 2 || string(result[0].Value) != "foo" || string(result[1].Value) != "bar" {
+		t.Fatalf("unexpected messages: %+v", result)
+	}
+	if result[1].Offset != 6 || result[1].Timestamp != 2000 {
+		t.Fatalf("unexpected offset or timestamp for the second message: %+v", result[1])
+	}
+}
+
+func TestUnmarshalRecordBatchesFailure(t *testing.T) {
+	f := func(data []byte) {
+		t.Helper()
+		if _, _, err := unmarshalRecordBatches(nil, data, 0, 0); err == nil {
+			t.Fatalf("expecting non-nil error")
+		}
+	}
+	batch := appendRecordBatch(nil, 0, []Message{{Value: []byte("foo")}})
+
+	// crc mismatch
+	data := append([]byte{}, batch...)
+	data[len(data)-2]++
+	f(data)
+
+	// unsupported magic
+	data = append([]byte{}, batch...)
+	data[16] = 1
+	f(data)
+
+	// unsupported compression codec
+	data = append([]byte{}, batch...)
+	binary.BigEndian.PutUint16(data[recordBatchAttributesOffset:], 2)
+	binary.BigEndian.PutUint32(data[recordBatchCRCOffset:], crc32.Checksum(data[recordBatchAttributesOffset:], castagnoliTable))
+	f(data)
+
+	// too small batch length
+	data = append([]byte{}, batch...)
+	binary.BigEndian.PutUint32(data[8:], 10)
+	f(data)
+}
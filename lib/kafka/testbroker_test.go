@@ -0,0 +1,475 @@
+package kafka
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+// testBroker is a local stand-in for a single-node Kafka cluster.
+//
+// It supports the subset of Kafka protocol used by Client.
+type testBroker struct {
+	t  *testing.T
+	ln net.Listener
+	wg sync.WaitGroup
+
+	mu sync.Mutex
+
+	// topics contains messages per each topic partition.
+	topics map[string][][]Message
+
+	// logStartOffsets contains the offset of the first message per each topic partition.
+	logStartOffsets map[string][]int64
+
+	// committedOffsets contains committed offsets keyed by "group/topic/partition".
+	committedOffsets map[string]int64
+
+	// controlOffsets contains offsets of transaction markers keyed by "topic/partition/offset".
+	controlOffsets map[string]bool
+
+	// produceErrors contains the number of errors to return to the following produce requests.
+	produceErrors int
+}
+
+func newTestBroker(t *testing.T, topics map[string]int) *testBroker {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("cannot start test broker: %s", err)
+	}
+	tb := &testBroker{
+		t:                t,
+		ln:               ln,
+		topics:           make(map[string][][]Message),
+		logStartOffsets:  make(map[string][]int64),
+		committedOffsets: make(map[string]int64),
+		controlOffsets:   make(map[string]bool),
+	}
+	for topic, partitions := range topics {
+		tb.topics[topic] = make([][]Message, partitions)
+		tb.logStartOffsets[topic] = make([]int64, partitions)
+	}
+	tb.wg.Add(1)
+	go func() {
+		defer tb.wg.Done()
+		tb.serve()
+	}()
+	return tb
+}
+
+func (tb *testBroker) addr() string {
+	return tb.ln.Addr().String()
+}
+
+func (tb *testBroker) stop() {
+	_ = tb.ln.Close()
+	tb.wg.Wait()
+}
+
+// deleteMessages deletes messages with offsets smaller than offset from the given topic partition.
+func (tb *testBroker) deleteMessages(topic string, partition int32, offset int64) {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+	msgs := tb.topics[topic][partition]
+	for len(msgs) > 0 && msgs[0].Offset < offset {
+		msgs = msgs[1:]
+	}
+	tb.topics[topic][partition] = msgs
+	tb.logStartOffsets[topic][partition] = offset
+}
+
+// appendControlRecord appends a transaction marker to the given topic partition.
+//
+// The marker occupies a single offset and is returned in a separate control batch.
+func (tb *testBroker) appendControlRecord(topic string, partition int32) {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+	pmsgs := tb.topics[topic][partition]
+	offset := tb.logStartOffsets[topic][partition] + int64(len(pmsgs))
+	tb.topics[topic][partition] = append(pmsgs, Message{
+		Partition: partition,
+		Offset:    offset,
+	})
+	tb.controlOffsets[controlOffsetKey(topic, partition, offset)] = true
+}
+
+func controlOffsetKey(topic string, partition int32, offset int64) string {
+	return fmt.Sprintf("%s/%d/%d", topic, partition, offset)
+}
+
+func (tb *testBroker) messages(topic string) []Message {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+	var msgs []Message
+	for p, pmsgs := range tb.topics[topic] {
+		for _, msg := range pmsgs {
+			if !tb.controlOffsets[controlOffsetKey(topic, int32(p), msg.Offset)] {
+				msgs = append(msgs, msg)
+			}
+		}
+	}
+	return msgs
+}
+
+func (tb *testBroker) serve() {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	for {
+		c, err := tb.ln.Accept()
+		if err != nil {
+			return
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tb.serveConn(c)
+		}()
+	}
+}
+
+func (tb *testBroker) serveConn(c net.Conn) {
+	defer func() {
+		_ = c.Close()
+	}()
+	var sizeBuf [4]byte
+	for {
+		if _, err := io.ReadFull(c, sizeBuf[:]); err != nil {
+			return
+		}
+		req := make([]byte, binary.BigEndian.Uint32(sizeBuf[:]))
+		if _, err := io.ReadFull(c, req); err != nil {
+			return
+		}
+		d := &decoder{
+			b: req,
+		}
+		apiKey := d.int16()
+		apiVersion := d.int16()
+		correlationID := d.int32()
+		_ = d.string() // client_id
+		if d.err != nil {
+			tb.t.Errorf("cannot read request header: %s", d.err)
+			return
+		}
+		body, err := tb.handleRequest(apiKey, apiVersion, d)
+		if err != nil {
+			tb.t.Errorf("cannot handle request with api_key=%d, api_version=%d: %s", apiKey, apiVersion, err)
+			return
+		}
+		resp := appendInt32(nil, 0)
+		resp = appendInt32(resp, correlationID)
+		resp = append(resp, body...)
+		binary.BigEndian.PutUint32(resp, uint32(len(resp)-4))
+		if _, err := c.Write(resp); err != nil {
+			return
+		}
+	}
+}
+
+func (tb *testBroker) handleRequest(apiKey, apiVersion int16, d *decoder) ([]byte, error) {
+	expectedVersions := map[int16]int16{
+		apiKeyProduce:         apiVersionProduce,
+		apiKeyFetch:           apiVersionFetch,
+		apiKeyListOffsets:     apiVersionListOffsets,
+		apiKeyMetadata:        apiVersionMetadata,
+		apiKeyOffsetCommit:    apiVersionOffsetCommit,
+		apiKeyOffsetFetch:     apiVersionOffsetFetch,
+		apiKeyFindCoordinator: apiVersionFindCoordinator,
+	}
+	if v, ok := expectedVersions[apiKey]; !ok || v != apiVersion {
+		return nil, fmt.Errorf("unsupported request")
+	}
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+	var resp []byte
+	switch apiKey {
+	case apiKeyMetadata:
+		resp = tb.handleMetadata(d)
+	case apiKeyProduce:
+		resp = tb.handleProduce(d)
+	case apiKeyFetch:
+		resp = tb.handleFetch(d)
+	case apiKeyListOffsets:
+		resp = tb.handleListOffsets(d)
+	case apiKeyFindCoordinator:
+		resp = tb.handleFindCoordinator(d)
+	case apiKeyOffsetCommit:
+		resp = tb.handleOffsetCommit(d)
+	case apiKeyOffsetFetch:
+		resp = tb.handleOffsetFetch(d)
+	}
+	if d.err != nil {
+		return nil, d.err
+	}
+	if len(d.b) > 0 {
+		return nil, fmt.Errorf("unexpected tail left after reading the request: %d bytes", len(d.b))
+	}
+	return resp, nil
+}
+
+func (tb *testBroker) hostPort() (string, int32) {
+	host, portStr, err := net.SplitHostPort(tb.addr())
+	if err != nil {
+		panic(err)
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		panic(err)
+	}
+	return host, int32(port)
+}
+
+func (tb *testBroker) handleMetadata(d *decoder) []byte {
+	var topics []string
+	for i, n := 0, d.arrayLen(); i < n; i++ {
+		topics = append(topics, d.string())
+	}
+	host, port := tb.hostPort()
+	var dst []byte
+	dst = appendArrayLen(dst, 1)
+	dst = appendInt32(dst, 1)
+	dst = appendString(dst, host)
+	dst = appendInt32(dst, port)
+	dst = appendNullString(dst)
+	// controller_id
+	dst = appendInt32(dst, 1)
+	dst = appendArrayLen(dst, len(topics))
+	for _, topic := range topics {
+		partitions, ok := tb.topics[topic]
+		if !ok {
+			dst = appendInt16(dst, int16(errUnknownTopicOrPartition))
+		} else {
+			dst = appendInt16(dst, 0)
+		}
+		dst = appendString(dst, topic)
+		dst = appendInt8(dst, 0)
+		dst = appendArrayLen(dst, len(partitions))
+		for p := range partitions {
+			dst = appendInt16(dst, 0)
+			dst = appendInt32(dst, int32(p))
+			// leader
+			dst = appendInt32(dst, 1)
+			// replicas
+			dst = appendArrayLen(dst, 1)
+			dst = appendInt32(dst, 1)
+			// isr
+			dst = appendArrayLen(dst, 1)
+			dst = appendInt32(dst, 1)
+		}
+	}
+	return dst
+}
+
+func (tb *testBroker) handleProduce(d *decoder) []byte {
+	_ = d.string() // transactional_id
+	if acks := d.int16(); acks != -1 {
+		d.err = fmt.Errorf("unexpected acks=%d; want -1", acks)
+	}
+	_ = d.int32() // timeout_ms
+	var dst []byte
+	n := d.arrayLen()
+	dst = appendArrayLen(dst, n)
+	for i := 0; i < n; i++ {
+		topic := d.string()
+		dst = appendString(dst, topic)
+		m := d.arrayLen()
+		dst = appendArrayLen(dst, m)
+		for j := 0; j < m; j++ {
+			partition := d.int32()
+			records := d.bytes()
+			errCode := tb.appendRecords(topic, partition, records)
+			dst = appendInt32(dst, partition)
+			dst = appendInt16(dst, int16(errCode))
+			// base_offset
+			dst = appendInt64(dst, 0)
+			// log_append_time_ms
+			dst = appendInt64(dst, -1)
+		}
+	}
+	// throttle_time_ms
+	return appendInt32(dst, 0)
+}
+
+func (tb *testBroker) appendRecords(topic string, partition int32, records []byte) Error {
+	if tb.produceErrors > 0 {
+		tb.produceErrors--
+		return errNotLeaderForPartition
+	}
+	partitions, ok := tb.topics[topic]
+	if !ok || int(partition) >= len(partitions) {
+		return errUnknownTopicOrPartition
+	}
+	msgs, _, err := unmarshalRecordBatches(nil, records, partition, 0)
+	if err != nil {
+		tb.t.Errorf("cannot unmarshal records: %s", err)
+		return 2 // CORRUPT_MESSAGE
+	}
+	pmsgs := partitions[partition]
+	nextOffset := tb.logStartOffsets[topic][partition] + int64(len(pmsgs))
+	for _, msg := range msgs {
+		msg.Offset = nextOffset
+		msg.Value = append([]byte{}, msg.Value...)
+		pmsgs = append(pmsgs, msg)
+		nextOffset++
+	}
+	partitions[partition] = pmsgs
+	return 0
+}
+
+func (tb *testBroker) handleFetch(d *decoder) []byte {
+	_ = d.int32() // replica_id
+	_ = d.int32() // max_wait_ms
+	_ = d.int32() // min_bytes
+	_ = d.int32() // max_bytes
+	_ = d.int8()  // isolation_level
+	var dst []byte
+	// throttle_time_ms
+	dst = appendInt32(dst, 0)
+	n := d.arrayLen()
+	dst = appendArrayLen(dst, n)
+	for i := 0; i < n; i++ {
+		topic := d.string()
+		dst = appendString(dst, topic)
+		m := d.arrayLen()
+		dst = appendArrayLen(dst, m)
+		for j := 0; j < m; j++ {
+			partition := d.int32()
+			offset := d.int64()
+			_ = d.int32() // partition_max_bytes
+			dst = appendInt32(dst, partition)
+			partitions := tb.topics[topic]
+			if int(partition) >= len(partitions) {
+				dst = appendInt16(dst, int16(errUnknownTopicOrPartition))
+				dst = appendInt64(dst, -1)
+				dst = appendInt64(dst, -1)
+				dst = appendArrayLen(dst, 0)
+				dst = appendBytes(dst, nil)
+				continue
+			}
+			pmsgs := partitions[partition]
+			startOffset := tb.logStartOffsets[topic][partition]
+			highWatermark := startOffset + int64(len(pmsgs))
+			if offset < startOffset || offset > highWatermark {
+				dst = appendInt16(dst, int16(errOffsetOutOfRange))
+				dst = appendInt64(dst, highWatermark)
+				dst = appendInt64(dst, highWatermark)
+				dst = appendArrayLen(dst, 0)
+				dst = appendBytes(dst, nil)
+				continue
+			}
+			// Return messages in batches of up to 3 messages. The first batch starts before the requested offset
+			// in the same way as real Kafka brokers do. Transaction markers are returned in separate control batches.
+			var records []byte
+			idx := int(offset - startOffset)
+			idx -= idx % 3
+			for idx < len(pmsgs) {
+				if tb.controlOffsets[controlOffsetKey(topic, partition, pmsgs[idx].Offset)] {
+					records = appendControlBatch(records, pmsgs[idx].Offset)
+					idx++
+					continue
+				}
+				end := idx + 1
+				for end < len(pmsgs) && end < idx+3 && !tb.controlOffsets[controlOffsetKey(topic, partition, pmsgs[end].Offset)] {
+					end++
+				}
+				records = appendRecordBatch(records, pmsgs[idx].Offset, pmsgs[idx:end])
+				idx = end
+			}
+			dst = appendInt16(dst, 0)
+			dst = appendInt64(dst, highWatermark)
+			dst = appendInt64(dst, highWatermark)
+			dst = appendArrayLen(dst, 0)
+			dst = appendBytes(dst, records)
+		}
+	}
+	return dst
+}
+
+func (tb *testBroker) handleListOffsets(d *decoder) []byte {
+	_ = d.int32() // replica_id
+	var dst []byte
+	n := d.arrayLen()
+	dst = appendArrayLen(dst, n)
+	for i := 0; i < n; i++ {
+		topic := d.string()
+		dst = appendString(dst, topic)
+		m := d.arrayLen()
+		dst = appendArrayLen(dst, m)
+		for j := 0; j < m; j++ {
+			partition := d.int32()
+			timestamp := d.int64()
+			if timestamp != offsetEarliest {
+				d.err = fmt.Errorf("unexpected timestamp=%d", timestamp)
+			}
+			dst = appendInt32(dst, partition)
+			dst = appendInt16(dst, 0)
+			dst = appendInt64(dst, -1)
+			dst = appendInt64(dst, tb.logStartOffsets[topic][partition])
+		}
+	}
+	return dst
+}
+
+func (tb *testBroker) handleFindCoordinator(d *decoder) []byte {
+	_ = d.string() // group_id
+	host, port := tb.hostPort()
+	var dst []byte
+	dst = appendInt16(dst, 0)
+	dst = appendInt32(dst, 1)
+	dst = appendString(dst, host)
+	return appendInt32(dst, port)
+}
+
+func (tb *testBroker) handleOffsetCommit(d *decoder) []byte {
+	groupID := d.string()
+	_ = d.int32()  // generation_id
+	_ = d.string() // member_id
+	_ = d.int64()  // retention_time_ms
+	var dst []byte
+	n := d.arrayLen()
+	dst = appendArrayLen(dst, n)
+	for i := 0; i < n; i++ {
+		topic := d.string()
+		dst = appendString(dst, topic)
+		m := d.arrayLen()
+		dst = appendArrayLen(dst, m)
+		for j := 0; j < m; j++ {
+			partition := d.int32()
+			offset := d.int64()
+			_ = d.string() // metadata
+			tb.committedOffsets[fmt.Sprintf("%s/%s/%d", groupID, topic, partition)] = offset
+			dst = appendInt32(dst, partition)
+			dst = appendInt16(dst, 0)
+		}
+	}
+	return dst
+}
+
+func (tb *testBroker) handleOffsetFetch(d *decoder) []byte {
+	groupID := d.string()
+	var dst []byte
+	n := d.arrayLen()
+	dst = appendArrayLen(dst, n)
+	for i := 0; i < n; i++ {
+		topic := d.string()
+		dst = appendString(dst, topic)
+		m := d.arrayLen()
+		dst = appendArrayLen(dst, m)
+		for j := 0; j < m; j++ {
+			partition := d.int32()
+			offset, ok := tb.committedOffsets[fmt.Sprintf("%s/%s/%d", groupID, topic, partition)]
+			if !ok {
+				offset = -1
+			}
+			dst = appendInt32(dst, partition)
+			dst = appendInt64(dst, offset)
+			dst = appendNullString(dst)
+			dst = appendInt16(dst, 0)
+		}
+	}
+	return dst
+}
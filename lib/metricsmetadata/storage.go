@@ -0,0 +1,213 @@
+package metricsmetadata
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/fs"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logger"
+	"github.com/VictoriaMetrics/metrics"
+)
+
+// Row contains metadata for a single metric family.
+//
+// See https://prometheus.io/docs/prometheus/latest/querying/api/#querying-metric-metadata
+type Row struct {
+	MetricFamilyName string `json:"metric"`
+	Type             string `json:"type"`
+	Help             string `json:"help"`
+	Unit             string `json:"unit"`
+}
+
+// Storage holds the most recently added metadata per each metric family name.
+//
+// The metadata is persisted to a file on MustClose and periodically while the Storage is open.
+type Storage struct {
+	path       string
+	maxEntries int
+
+	mu      sync.Mutex
+	m       map[string]*Row
+	isDirty bool
+
+	stopCh chan struct{}
+	wg     sync.WaitGroup
+}
+
+// flushInterval is the interval for persisting the changed metadata to disk.
+const flushInterval = 10 * time.Second
+
+// MustOpen opens metadata storage at the given file path.
+//
+// The storage holds up to maxEntries metric families. Metadata for new metric families is dropped when the limit is reached.
+// The returned storage must be closed with MustClose when no longer needed.
+func MustOpen(path string, maxEntries int) *Storage {
+	s := &Storage{
+		path:       path,
+		maxEntries: maxEntries,
+		m:          make(map[string]*Row),
+		stopCh:     make(chan struct{}),
+	}
+	if err := s.load(); err != nil {
+		logger.Panicf("FATAL: cannot load metrics metadata from %q: %s", path, err)
+	}
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.flusher()
+	}()
+	return s
+}
+
+// MustClose stops the storage and persists its contents to disk.
+func (s *Storage) MustClose() {
+	close(s.stopCh)
+	s.wg.Wait()
+	s.mustFlush()
+}
+
+// Add adds rows to s.
+//
+// Rows with empty MetricFamilyName are ignored. rows may refer to temporary buffers, since they are copied when needed.
+func (s *Storage) Add(rows []Row) {
+	s.mu.Lock()
+	for i := range rows {
+		r := &rows[i]
+		if r.MetricFamilyName == "" {
+			continue
+		}
+		e := s.m[r.MetricFamilyName]
+		if e != nil && *e == *r {
+			// Fast path - the metadata didn't change.
+			continue
+		}
+		if e == nil {
+			if s.maxEntries > 0 && len(s.m) >= s.maxEntries {
+				droppedRows.Inc()
+				continue
+			}
+			e = &Row{
+				MetricFamilyName: copyString(r.MetricFamilyName),
+			}
+			s.m[e.MetricFamilyName] = e
+		}
+		e.Type = copyString(r.Type)
+		e.Help = copyString(r.Help)
+		e.Unit = copyString(r.Unit)
+		s.isDirty = true
+	}
+	s.mu.Unlock()
+}
+
+// Get returns metadata sorted by metric family name.
+//
+// Only metadata for the given metricFamilyName is returned if it is non-empty.
+// Up to limit rows are returned if limit is positive.
+func (s *Storage) Get(metricFamilyName string, limit int) []Row {
+	var rows []Row
+	s.mu.Lock()
+	if metricFamilyName != "" {
+		if e := s.m[metricFamilyName]; e != nil {
+			rows = append(rows, *e)
+		}
+	} else {
+		rows = make([]Row, 0, len(s.m))
+		for _, e := range s.m {
+			rows = append(rows, *e)
+		}
+	}
+	s.mu.Unlock()
+
+	sort.Slice(rows, func(i, j int) bool {
+		return rows[i].MetricFamilyName < rows[j].MetricFamilyName
+	})
+	if limit > 0 && len(rows) > limit {
+		rows = rows[:limit]
+	}
+	return rows
+}
+
+// Len returns the number of metric families in s.
+func (s *Storage) Len() int {
+	s.mu.Lock()
+	n := len(s.m)
+	s.mu.Unlock()
+	return n
+}
+
+func (s *Storage) flusher() {
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stopCh:
+			return
+		case <-ticker.C:
+			s.mustFlush()
+		}
+	}
+}
+
+func (s *Storage) mustFlush() {
+	s.mu.Lock()
+	if !s.isDirty {
+		s.mu.Unlock()
+		return
+	}
+	rows := make([]Row, 0, len(s.m))
+	for _, e := range s.m {
+		rows = append(rows, *e)
+	}
+	s.isDirty = false
+	s.mu.Unlock()
+
+	sort.Slice(rows, func(i, j int) bool {
+		return rows[i].MetricFamilyName < rows[j].MetricFamilyName
+	})
+	data, err := json.Marshal(rows)
+	if err != nil {
+		logger.Panicf("BUG: cannot marshal metrics metadata: %s", err)
+	}
+	// Write the data to a temporary file and then atomically rename it,
+	// so the previous contents remain valid if the process is killed in the middle of the write.
+	tmpPath := s.path + ".tmp"
+	if err := ioutil.WriteFile(tmpPath, data, 0644); err != nil {
+		logger.Panicf("FATAL: cannot write metrics metadata to %q: %s", tmpPath, err)
+	}
+	if err := os.Rename(tmpPath, s.path); err != nil {
+		logger.Panicf("FATAL: cannot move %q to %q: %s", tmpPath, s.path, err)
+	}
+}
+
+func (s *Storage) load() error {
+	if !fs.IsPathExist(s.path) {
+		return nil
+	}
+	data, err := ioutil.ReadFile(s.path)
+	if err != nil {
+		return err
+	}
+	var rows []Row
+	if err := json.Unmarshal(data, &rows); err != nil {
+		return fmt.Errorf("cannot parse JSON: %w", err)
+	}
+	for i := range rows {
+		r := &rows[i]
+		if r.MetricFamilyName == "" {
+			continue
+		}
+		s.m[r.MetricFamilyName] = r
+	}
+	return nil
+}
+
+func copyString(s string) string {
+	return string(append([]byte{}, s...))
+}
+
+var droppedRows = metrics.NewCounter(`vm_metrics_metadata_dropped_rows_total`)
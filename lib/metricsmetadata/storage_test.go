@@ -0,0 +1,86 @@
+package metricsmetadata
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestStorageAddGet(t *testing.T) {
+	path := "TestStorageAddGet.json"
+	defer func() {
+		_ = os.Remove(path)
+	}()
+	s := MustOpen(path, 3)
+	s.Add([]Row{
+		{
+			MetricFamilyName: "foo",
+			Type:             "counter",
+			Help:             "foo help",
+		},
+		{
+			MetricFamilyName: "bar",
+			Type:             "gauge",
+			Unit:             "seconds",
+		},
+		{
+			// Rows without metric family name must be ignored.
+			Type: "gauge",
+		},
+	})
+	// Update existing entry
+	s.Add([]Row{{
+		MetricFamilyName: "foo",
+		Type:             "counter",
+		Help:             "new foo help",
+	}})
+	s.Add([]Row{
+		{
+			MetricFamilyName: "baz",
+			Type:             "summary",
+		},
+		{
+			// This entry must be dropped because of maxEntries limit.
+			MetricFamilyName: "qwe",
+			Type:             "summary",
+		},
+	})
+	if n := s.Len(); n != 3 {
+		t.Fatalf("unexpected number of entries; got %d; want 3", n)
+	}
+
+	f := func(metricFamilyName string, limit int, rowsExpected []Row) {
+		t.Helper()
+		rows := s.Get(metricFamilyName, limit)
+		if !reflect.DeepEqual(rows, rowsExpected) {
+			t.Fatalf("unexpected rows for metric=%q, limit=%d;\ngot\n%+v\nwant\n%+v", metricFamilyName, limit, rows, rowsExpected)
+		}
+	}
+	allRows := []Row{
+		{
+			MetricFamilyName: "bar",
+			Type:             "gauge",
+			Unit:             "seconds",
+		},
+		{
+			MetricFamilyName: "baz",
+			Type:             "summary",
+		},
+		{
+			MetricFamilyName: "foo",
+			Type:             "counter",
+			Help:             "new foo help",
+		},
+	}
+	f("", 0, allRows)
+	f("", 2, allRows[:2])
+	f("", 10, allRows)
+	f("foo", 0, allRows[2:])
+	f("qwe", 0, nil)
+
+	// Verify the metadata survives storage re-opening.
+	s.MustClose()
+	s = MustOpen(path, 3)
+	f("", 0, allRows)
+	s.MustClose()
+}
@@ -10,6 +10,7 @@ import (
 // WriteRequest represents Prometheus remote write API request
 type WriteRequest struct {
 	Timeseries []TimeSeries
+	Metadata   []MetricMetadata
 
 	labelsPool  []Label
 	samplesPool []Sample
@@ -83,6 +84,229 @@ func (m *WriteRequest) Unmarshal(dAtA []byte) error {
 				return err
 			}
 			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Metadata", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return errIntOverflowRemote
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return errInvalidLengthRemote
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if cap(m.Metadata) > len(m.Metadata) {
+				m.Metadata = m.Metadata[:len(m.Metadata)+1]
+			} else {
+				m.Metadata = append(m.Metadata, MetricMetadata{})
+			}
+			md := &m.Metadata[len(m.Metadata)-1]
+			if err := md.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipRemote(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return errInvalidLengthRemote
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+
+// ReadRequest_ResponseType is the response type accepted by Prometheus remote read client.
+type ReadRequest_ResponseType int32
+
+const (
+	// ReadRequest_SAMPLES is the simple server-side implementation of the remote read protocol.
+	//
+	// The server returns snappy-compressed ReadResponse with raw samples.
+	ReadRequest_SAMPLES ReadRequest_ResponseType = 0
+
+	// ReadRequest_STREAMED_XOR_CHUNKS is the streamed remote read protocol.
+	//
+	// The server returns a stream of ChunkedReadResponse messages with XOR-encoded chunks.
+	ReadRequest_STREAMED_XOR_CHUNKS ReadRequest_ResponseType = 1
+)
+
+// ReadRequest represents Prometheus remote read API request
+type ReadRequest struct {
+	Queries []Query
+
+	// AcceptedResponseTypes contains response types accepted by the client in the order of preference.
+	AcceptedResponseTypes []ReadRequest_ResponseType
+
+	matchersPool []LabelMatcher
+}
+
+// Query represents a single query in Prometheus remote read API request
+type Query struct {
+	StartTimestampMs int64
+	EndTimestampMs   int64
+	Matchers         []LabelMatcher
+}
+
+// Unmarshal unmarshals m from dAtA.
+func (m *ReadRequest) Unmarshal(dAtA []byte) error {
+	m.Reset()
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return errIntOverflowRemote
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ReadRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ReadRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Queries", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return errIntOverflowRemote
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return errInvalidLengthRemote
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if cap(m.Queries) > len(m.Queries) {
+				m.Queries = m.Queries[:len(m.Queries)+1]
+			} else {
+				m.Queries = append(m.Queries, Query{})
+			}
+			q := &m.Queries[len(m.Queries)-1]
+			var err error
+			m.matchersPool, err = q.Unmarshal(dAtA[iNdEx:postIndex], m.matchersPool)
+			if err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 2:
+			switch wireType {
+			case 0:
+				var v ReadRequest_ResponseType
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return errIntOverflowRemote
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					v |= ReadRequest_ResponseType(b&0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				m.AcceptedResponseTypes = append(m.AcceptedResponseTypes, v)
+			case 2:
+				// Packed repeated field.
+				var packedLen int
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return errIntOverflowRemote
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					packedLen |= (int(b) & 0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				if packedLen < 0 {
+					return errInvalidLengthRemote
+				}
+				postIndex := iNdEx + packedLen
+				if postIndex > l {
+					return io.ErrUnexpectedEOF
+				}
+				for iNdEx < postIndex {
+					var v ReadRequest_ResponseType
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return errIntOverflowRemote
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						v |= ReadRequest_ResponseType(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					m.AcceptedResponseTypes = append(m.AcceptedResponseTypes, v)
+				}
+			default:
+				return fmt.Errorf("proto: wrong wireType = %d for field AcceptedResponseTypes", wireType)
+			}
 		default:
 			iNdEx = preIndex
 			skippy, err := skipRemote(dAtA[iNdEx:])
@@ -104,6 +328,138 @@ func (m *WriteRequest) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
+
+// Unmarshal unmarshals m from dAtA.
+//
+// Matchers are appended to dstMatchers, which is returned from the function.
+func (m *Query) Unmarshal(dAtA []byte, dstMatchers []LabelMatcher) ([]LabelMatcher, error) {
+	matchersStart := len(dstMatchers)
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return dstMatchers, errIntOverflowRemote
+			}
+			if iNdEx >= l {
+				return dstMatchers, io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return dstMatchers, fmt.Errorf("proto: Query: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return dstMatchers, fmt.Errorf("proto: Query: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return dstMatchers, fmt.Errorf("proto: wrong wireType = %d for field StartTimestampMs", wireType)
+			}
+			m.StartTimestampMs = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return dstMatchers, errIntOverflowRemote
+				}
+				if iNdEx >= l {
+					return dstMatchers, io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.StartTimestampMs |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 0 {
+				return dstMatchers, fmt.Errorf("proto: wrong wireType = %d for field EndTimestampMs", wireType)
+			}
+			m.EndTimestampMs = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return dstMatchers, errIntOverflowRemote
+				}
+				if iNdEx >= l {
+					return dstMatchers, io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.EndTimestampMs |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 2 {
+				return dstMatchers, fmt.Errorf("proto: wrong wireType = %d for field Matchers", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return dstMatchers, errIntOverflowRemote
+				}
+				if iNdEx >= l {
+					return dstMatchers, io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return dstMatchers, errInvalidLengthRemote
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return dstMatchers, io.ErrUnexpectedEOF
+			}
+			if cap(dstMatchers) > len(dstMatchers) {
+				dstMatchers = dstMatchers[:len(dstMatchers)+1]
+			} else {
+				dstMatchers = append(dstMatchers, LabelMatcher{})
+			}
+			lm := &dstMatchers[len(dstMatchers)-1]
+			if err := lm.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return dstMatchers, err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipRemote(dAtA[iNdEx:])
+			if err != nil {
+				return dstMatchers, err
+			}
+			if skippy < 0 {
+				return dstMatchers, errInvalidLengthRemote
+			}
+			if (iNdEx + skippy) > l {
+				return dstMatchers, io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return dstMatchers, io.ErrUnexpectedEOF
+	}
+	m.Matchers = dstMatchers[matchersStart:]
+	m.Matchers = m.Matchers[:len(m.Matchers):len(m.Matchers)]
+	return dstMatchers, nil
+}
+
 func skipRemote(dAtA []byte) (n int, err error) {
 	l := len(dAtA)
 	iNdEx := 0
@@ -349,6 +349,383 @@ func (m *Label) Unmarshal(dAtA []byte) error {
 	return nil
 }
 
+// LabelMatcher_Type is the type of label matcher.
+type LabelMatcher_Type int32
+
+const (
+	// LabelMatcher_EQ matches label value equal to the given value.
+	LabelMatcher_EQ LabelMatcher_Type = 0
+
+	// LabelMatcher_NEQ matches label value not equal to the given value.
+	LabelMatcher_NEQ LabelMatcher_Type = 1
+
+	// LabelMatcher_RE matches label value against the given regexp.
+	LabelMatcher_RE LabelMatcher_Type = 2
+
+	// LabelMatcher_NRE matches label value not matching the given regexp.
+	LabelMatcher_NRE LabelMatcher_Type = 3
+)
+
+// LabelMatcher specifies a rule, which can match or set of labels or not.
+type LabelMatcher struct {
+	Type  LabelMatcher_Type
+	Name  []byte
+	Value []byte
+}
+
+// Unmarshal unmarshals LabelMatcher from dAtA.
+func (m *LabelMatcher) Unmarshal(dAtA []byte) error {
+	m.Type = 0
+	m.Name = nil
+	m.Value = nil
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return errIntOverflowTypes
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: LabelMatcher: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: LabelMatcher: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Type", wireType)
+			}
+			m.Type = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return errIntOverflowTypes
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Type |= LabelMatcher_Type(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Name", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return errIntOverflowTypes
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return errInvalidLengthTypes
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Name = dAtA[iNdEx:postIndex]
+			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Value", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return errIntOverflowTypes
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return errInvalidLengthTypes
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Value = dAtA[iNdEx:postIndex]
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipTypes(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return errInvalidLengthTypes
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+
+// MetricMetadata_MetricType is the type of metric in MetricMetadata.
+type MetricMetadata_MetricType int32
+
+const (
+	// MetricMetadata_UNKNOWN is unknown metric type.
+	MetricMetadata_UNKNOWN MetricMetadata_MetricType = 0
+
+	// MetricMetadata_COUNTER is counter metric type.
+	MetricMetadata_COUNTER MetricMetadata_MetricType = 1
+
+	// MetricMetadata_GAUGE is gauge metric type.
+	MetricMetadata_GAUGE MetricMetadata_MetricType = 2
+
+	// MetricMetadata_HISTOGRAM is histogram metric type.
+	MetricMetadata_HISTOGRAM MetricMetadata_MetricType = 3
+
+	// MetricMetadata_GAUGEHISTOGRAM is gauge histogram metric type.
+	MetricMetadata_GAUGEHISTOGRAM MetricMetadata_MetricType = 4
+
+	// MetricMetadata_SUMMARY is summary metric type.
+	MetricMetadata_SUMMARY MetricMetadata_MetricType = 5
+
+	// MetricMetadata_INFO is info metric type.
+	MetricMetadata_INFO MetricMetadata_MetricType = 6
+
+	// MetricMetadata_STATESET is stateset metric type.
+	MetricMetadata_STATESET MetricMetadata_MetricType = 7
+)
+
+// String returns the metric type name as used in Prometheus exposition format.
+func (t MetricMetadata_MetricType) String() string {
+	switch t {
+	case MetricMetadata_COUNTER:
+		return "counter"
+	case MetricMetadata_GAUGE:
+		return "gauge"
+	case MetricMetadata_HISTOGRAM:
+		return "histogram"
+	case MetricMetadata_GAUGEHISTOGRAM:
+		return "gaugehistogram"
+	case MetricMetadata_SUMMARY:
+		return "summary"
+	case MetricMetadata_INFO:
+		return "info"
+	case MetricMetadata_STATESET:
+		return "stateset"
+	default:
+		return "unknown"
+	}
+}
+
+// MetricMetadata is metadata for the metric family.
+type MetricMetadata struct {
+	Type             MetricMetadata_MetricType
+	MetricFamilyName []byte
+	Help             []byte
+	Unit             []byte
+}
+
+// Unmarshal unmarshals MetricMetadata from dAtA.
+func (m *MetricMetadata) Unmarshal(dAtA []byte) error {
+	m.Type = 0
+	m.MetricFamilyName = nil
+	m.Help = nil
+	m.Unit = nil
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return errIntOverflowTypes
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: MetricMetadata: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: MetricMetadata: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Type", wireType)
+			}
+			m.Type = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return errIntOverflowTypes
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Type |= MetricMetadata_MetricType(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field MetricFamilyName", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return errIntOverflowTypes
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return errInvalidLengthTypes
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.MetricFamilyName = dAtA[iNdEx:postIndex]
+			iNdEx = postIndex
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Help", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return errIntOverflowTypes
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return errInvalidLengthTypes
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Help = dAtA[iNdEx:postIndex]
+			iNdEx = postIndex
+		case 5:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Unit", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return errIntOverflowTypes
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return errInvalidLengthTypes
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Unit = dAtA[iNdEx:postIndex]
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipTypes(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return errInvalidLengthTypes
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+
 func skipTypes(dAtA []byte) (n int, err error) {
 	l := len(dAtA)
 	iNdEx := 0
@@ -9,6 +9,14 @@ func (wr *WriteRequest) Reset() {
 	}
 	wr.Timeseries = wr.Timeseries[:0]
 
+	for i := range wr.Metadata {
+		md := &wr.Metadata[i]
+		md.MetricFamilyName = nil
+		md.Help = nil
+		md.Unit = nil
+	}
+	wr.Metadata = wr.Metadata[:0]
+
 	for i := range wr.labelsPool {
 		lb := &wr.labelsPool[i]
 		lb.Name = nil
@@ -23,3 +31,23 @@ func (wr *WriteRequest) Reset() {
 	}
 	wr.samplesPool = wr.samplesPool[:0]
 }
+
+// Reset resets rr.
+func (rr *ReadRequest) Reset() {
+	for i := range rr.Queries {
+		q := &rr.Queries[i]
+		q.StartTimestampMs = 0
+		q.EndTimestampMs = 0
+		q.Matchers = nil
+	}
+	rr.Queries = rr.Queries[:0]
+	rr.AcceptedResponseTypes = rr.AcceptedResponseTypes[:0]
+
+	for i := range rr.matchersPool {
+		lm := &rr.matchersPool[i]
+		lm.Type = 0
+		lm.Name = nil
+		lm.Value = nil
+	}
+	rr.matchersPool = rr.matchersPool[:0]
+}
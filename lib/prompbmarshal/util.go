@@ -19,9 +19,48 @@ func MarshalWriteRequest(dst []byte, wr *WriteRequest) []byte {
 	return dst[:dstLen+n]
 }
 
+// MarshalReadResponse marshals rr to dst and returns the result.
+func MarshalReadResponse(dst []byte, rr *ReadResponse) []byte {
+	size := rr.Size()
+	dstLen := len(dst)
+	if n := size - (cap(dst) - dstLen); n > 0 {
+		dst = append(dst[:cap(dst)], make([]byte, n)...)
+	}
+	dst = dst[:dstLen+size]
+	n, err := rr.MarshalToSizedBuffer(dst[dstLen:])
+	if err != nil {
+		panic(fmt.Errorf("BUG: unexpected error when marshaling ReadResponse: %w", err))
+	}
+	return dst[:dstLen+n]
+}
+
+// MarshalChunkedReadResponse marshals crr to dst and returns the result.
+func MarshalChunkedReadResponse(dst []byte, crr *ChunkedReadResponse) []byte {
+	size := crr.Size()
+	dstLen := len(dst)
+	if n := size - (cap(dst) - dstLen); n > 0 {
+		dst = append(dst[:cap(dst)], make([]byte, n)...)
+	}
+	dst = dst[:dstLen+size]
+	n, err := crr.MarshalToSizedBuffer(dst[dstLen:])
+	if err != nil {
+		panic(fmt.Errorf("BUG: unexpected error when marshaling ChunkedReadResponse: %w", err))
+	}
+	return dst[:dstLen+n]
+}
+
 // ResetWriteRequest resets wr.
 func ResetWriteRequest(wr *WriteRequest) {
 	wr.Timeseries = ResetTimeSeries(wr.Timeseries)
+	wr.Metadata = ResetMetadata(wr.Metadata)
+}
+
+// ResetMetadata clears all the GC references from mds and returns an empty mds ready for further use.
+func ResetMetadata(mds []MetricMetadata) []MetricMetadata {
+	for i := range mds {
+		mds[i] = MetricMetadata{}
+	}
+	return mds[:0]
 }
 
 // ResetTimeSeries clears all the GC references from tss and returns an empty tss ready for further use.
@@ -33,3 +72,49 @@ func ResetTimeSeries(tss []TimeSeries) []TimeSeries {
 	}
 	return tss[:0]
 }
+
+// String returns the metric type name as used in Prometheus exposition format.
+func (t MetricMetadata_MetricType) String() string {
+	switch t {
+	case MetricMetadata_COUNTER:
+		return "counter"
+	case MetricMetadata_GAUGE:
+		return "gauge"
+	case MetricMetadata_HISTOGRAM:
+		return "histogram"
+	case MetricMetadata_GAUGEHISTOGRAM:
+		return "gaugehistogram"
+	case MetricMetadata_SUMMARY:
+		return "summary"
+	case MetricMetadata_INFO:
+		return "info"
+	case MetricMetadata_STATESET:
+		return "stateset"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseMetricType returns the metric type for the given name from `# TYPE` line of Prometheus exposition format.
+//
+// MetricMetadata_UNKNOWN is returned for unknown names.
+func ParseMetricType(s string) MetricMetadata_MetricType {
+	switch s {
+	case "counter":
+		return MetricMetadata_COUNTER
+	case "gauge":
+		return MetricMetadata_GAUGE
+	case "histogram":
+		return MetricMetadata_HISTOGRAM
+	case "gaugehistogram":
+		return MetricMetadata_GAUGEHISTOGRAM
+	case "summary":
+		return MetricMetadata_SUMMARY
+	case "info":
+		return MetricMetadata_INFO
+	case "stateset":
+		return MetricMetadata_STATESET
+	default:
+		return MetricMetadata_UNKNOWN
+	}
+}
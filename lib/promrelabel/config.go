@@ -14,13 +14,20 @@ import (
 //
 // See https://prometheus.io/docs/prometheus/latest/configuration/configuration/#relabel_config
 type RelabelConfig struct {
-	SourceLabels []string `yaml:"source_labels"`
-	Separator    *string  `yaml:"separator"`
-	TargetLabel  string   `yaml:"target_label"`
-	Regex        *string  `yaml:"regex"`
-	Modulus      uint64   `yaml:"modulus"`
-	Replacement  *string  `yaml:"replacement"`
-	Action       string   `yaml:"action"`
+	SourceLabels []string      `yaml:"source_labels,flow,omitempty"`
+	Separator    *string       `yaml:"separator,omitempty"`
+	TargetLabel  string        `yaml:"target_label,omitempty"`
+	Regex        *string       `yaml:"regex,omitempty"`
+	Modulus      uint64        `yaml:"modulus,omitempty"`
+	Replacement  *string       `yaml:"replacement,omitempty"`
+	Action       string        `yaml:"action,omitempty"`
+	If           *IfExpression `yaml:"if,omitempty"`
+
+	// Match is used together with Labels for `action: graphite`.
+	Match string `yaml:"match,omitempty"`
+
+	// Labels is used together with Match for `action: graphite`.
+	Labels map[string]string `yaml:"labels,omitempty"`
 }
 
 // LoadRelabelConfigs loads relabel configs from the given path.
@@ -82,6 +89,16 @@ func parseRelabelConfig(dst []ParsedRelabelConfig, rc *RelabelConfig) ([]ParsedR
 	if action == "" {
 		action = "replace"
 	}
+	if action != "graphite" {
+		if rc.Match != "" {
+			return dst, fmt.Errorf("`match` config cannot be applied to `action=%s`; it is applied only to `action=graphite`", action)
+		}
+		if len(rc.Labels) > 0 {
+			return dst, fmt.Errorf("`labels` config cannot be applied to `action=%s`; it is applied only to `action=graphite`", action)
+		}
+	}
+	var graphiteMatchTemplate *graphiteMatchTemplate
+	var graphiteLabelRules []graphiteLabelRule
 	switch action {
 	case "replace":
 		if targetLabel == "" {
@@ -102,12 +119,32 @@ func parseRelabelConfig(dst []ParsedRelabelConfig, rc *RelabelConfig) ([]ParsedR
 		if len(sourceLabels) < 2 {
 			return dst, fmt.Errorf("`source_labels` must contain at least two entries for `action=drop_if_equal`; got %q", sourceLabels)
 		}
-	case "keep":
+	case "keepequal":
 		if len(sourceLabels) == 0 {
+			return dst, fmt.Errorf("missing `source_labels` for `action=keepequal`")
+		}
+		if targetLabel == "" {
+			return dst, fmt.Errorf("missing `target_label` for `action=keepequal`")
+		}
+		if rc.Regex != nil {
+			return dst, fmt.Errorf("`regex` cannot be used for `action=keepequal`")
+		}
+	case "dropequal":
+		if len(sourceLabels) == 0 {
+			return dst, fmt.Errorf("missing `source_labels` for `action=dropequal`")
+		}
+		if targetLabel == "" {
+			return dst, fmt.Errorf("missing `target_label` for `action=dropequal`")
+		}
+		if rc.Regex != nil {
+			return dst, fmt.Errorf("`regex` cannot be used for `action=dropequal`")
+		}
+	case "keep":
+		if len(sourceLabels) == 0 && rc.If == nil {
 			return dst, fmt.Errorf("missing `source_labels` for `action=keep`")
 		}
 	case "drop":
-		if len(sourceLabels) == 0 {
+		if len(sourceLabels) == 0 && rc.If == nil {
 			return dst, fmt.Errorf("missing `source_labels` for `action=drop`")
 		}
 	case "hashmod":
@@ -120,6 +157,42 @@ func parseRelabelConfig(dst []ParsedRelabelConfig, rc *RelabelConfig) ([]ParsedR
 		if modulus < 1 {
 			return dst, fmt.Errorf("unexpected `modulus` for `action=hashmod`: %d; must be greater than 0", modulus)
 		}
+	case "lowercase":
+		if len(sourceLabels) == 0 {
+			return dst, fmt.Errorf("missing `source_labels` for `action=lowercase`")
+		}
+		if targetLabel == "" {
+			return dst, fmt.Errorf("missing `target_label` for `action=lowercase`")
+		}
+	case "uppercase":
+		if len(sourceLabels) == 0 {
+			return dst, fmt.Errorf("missing `source_labels` for `action=uppercase`")
+		}
+		if targetLabel == "" {
+			return dst, fmt.Errorf("missing `target_label` for `action=uppercase`")
+		}
+	case "graphite":
+		if rc.Match == "" {
+			return dst, fmt.Errorf("missing `match` for `action=graphite`")
+		}
+		if len(rc.Labels) == 0 {
+			return dst, fmt.Errorf("missing `labels` for `action=graphite`")
+		}
+		if len(sourceLabels) > 0 {
+			return dst, fmt.Errorf("`source_labels` cannot be used with `action=graphite`")
+		}
+		if targetLabel != "" {
+			return dst, fmt.Errorf("`target_label` cannot be used with `action=graphite`")
+		}
+		if rc.Regex != nil || rc.Replacement != nil {
+			return dst, fmt.Errorf("`regex` and `replacement` cannot be used with `action=graphite`")
+		}
+		gmt, err := newGraphiteMatchTemplate(rc.Match)
+		if err != nil {
+			return dst, err
+		}
+		graphiteMatchTemplate = gmt
+		graphiteLabelRules = newGraphiteLabelRules(rc.Labels)
 	case "labelmap":
 	case "labelmap_all":
 	case "labeldrop":
@@ -135,6 +208,10 @@ func parseRelabelConfig(dst []ParsedRelabelConfig, rc *RelabelConfig) ([]ParsedR
 		Modulus:      modulus,
 		Replacement:  replacement,
 		Action:       action,
+		If:           rc.If,
+
+		graphiteMatchTemplate: graphiteMatchTemplate,
+		graphiteLabelRules:    graphiteLabelRules,
 
 		hasCaptureGroupInTargetLabel: strings.Contains(targetLabel, "$"),
 		hasCaptureGroupInReplacement: strings.Contains(replacement, "$"),
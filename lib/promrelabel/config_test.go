@@ -11,8 +11,8 @@ func TestLoadRelabelConfigsSuccess(t *testing.T) {
 	if err != nil {
 		t.Fatalf("cannot load relabel configs from %q: %s", path, err)
 	}
-	if len(prcs) != 9 {
-		t.Fatalf("unexpected number of relabel configs loaded from %q; got %d; want %d", path, len(prcs), 9)
+	if len(prcs) != 14 {
+		t.Fatalf("unexpected number of relabel configs loaded from %q; got %d; want %d", path, len(prcs), 14)
 	}
 }
 
@@ -181,6 +181,118 @@ func TestParseRelabelConfigsFailure(t *testing.T) {
 			},
 		})
 	})
+	t.Run("keepequal-missing-source-labels", func(t *testing.T) {
+		f([]RelabelConfig{
+			{
+				Action:      "keepequal",
+				TargetLabel: "foo",
+			},
+		})
+	})
+	t.Run("keepequal-missing-target-label", func(t *testing.T) {
+		f([]RelabelConfig{
+			{
+				Action:       "keepequal",
+				SourceLabels: []string{"foo"},
+			},
+		})
+	})
+	t.Run("keepequal-regex", func(t *testing.T) {
+		f([]RelabelConfig{
+			{
+				Action:       "keepequal",
+				SourceLabels: []string{"foo"},
+				TargetLabel:  "bar",
+				Regex:        strPtr("bar"),
+			},
+		})
+	})
+	t.Run("dropequal-missing-target-label", func(t *testing.T) {
+		f([]RelabelConfig{
+			{
+				Action:       "dropequal",
+				SourceLabels: []string{"foo"},
+			},
+		})
+	})
+	t.Run("lowercase-missing-source-labels", func(t *testing.T) {
+		f([]RelabelConfig{
+			{
+				Action:      "lowercase",
+				TargetLabel: "foo",
+			},
+		})
+	})
+	t.Run("uppercase-missing-target-label", func(t *testing.T) {
+		f([]RelabelConfig{
+			{
+				Action:       "uppercase",
+				SourceLabels: []string{"foo"},
+			},
+		})
+	})
+	t.Run("graphite-missing-match", func(t *testing.T) {
+		f([]RelabelConfig{
+			{
+				Action: "graphite",
+				Labels: map[string]string{
+					"foo": "$1",
+				},
+			},
+		})
+	})
+	t.Run("graphite-missing-labels", func(t *testing.T) {
+		f([]RelabelConfig{
+			{
+				Action: "graphite",
+				Match:  "foo.*",
+			},
+		})
+	})
+	t.Run("graphite-invalid-match", func(t *testing.T) {
+		f([]RelabelConfig{
+			{
+				Action: "graphite",
+				Match:  "foo.**",
+				Labels: map[string]string{
+					"foo": "$1",
+				},
+			},
+		})
+	})
+	t.Run("graphite-source-labels", func(t *testing.T) {
+		f([]RelabelConfig{
+			{
+				Action: "graphite",
+				Match:  "foo.*",
+				Labels: map[string]string{
+					"foo": "$1",
+				},
+				SourceLabels: []string{"foo"},
+			},
+		})
+	})
+	t.Run("match-for-non-graphite-action", func(t *testing.T) {
+		f([]RelabelConfig{
+			{
+				Action:       "replace",
+				SourceLabels: []string{"foo"},
+				TargetLabel:  "bar",
+				Match:        "foo.*",
+			},
+		})
+	})
+	t.Run("labels-for-non-graphite-action", func(t *testing.T) {
+		f([]RelabelConfig{
+			{
+				Action:       "keep",
+				SourceLabels: []string{"foo"},
+				Labels: map[string]string{
+					"foo": "$1",
+				},
+			},
+		})
+	})
 	t.Run("invalid-action", func(t *testing.T) {
 		f([]RelabelConfig{
 			{
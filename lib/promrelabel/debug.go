@@ -0,0 +1,159 @@
+package promrelabel
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/prompbmarshal"
+	"github.com/VictoriaMetrics/metricsql"
+	"gopkg.in/yaml.v2"
+)
+
+// DebugStep contains the result of a single relabeling step.
+type DebugStep struct {
+	// Rule contains the relabeling rule in YAML format.
+	Rule string
+
+	// In contains the labels before applying the Rule.
+	In []prompbmarshal.Label
+
+	// Out contains the labels after applying the Rule.
+	//
+	// Out is empty if the Rule drops the labels.
+	Out []prompbmarshal.Label
+}
+
+// DebugRelabelConfigs applies rcs to labels one by one and returns the result of every relabeling step.
+//
+// The steps stop at the first rule, which drops the labels.
+func DebugRelabelConfigs(labels []prompbmarshal.Label, rcs []RelabelConfig) ([]DebugStep, error) {
+	prcs, err := ParseRelabelConfigs(nil, rcs)
+	if err != nil {
+		return nil, err
+	}
+	var dss []DebugStep
+	in := labels
+	for i := range prcs {
+		data, err := yaml.Marshal(&rcs[i])
+		if err != nil {
+			return nil, fmt.Errorf("BUG: cannot marshal `relabel_config` #%d: %w", i+1, err)
+		}
+		out := ApplyRelabelConfigs(append([]prompbmarshal.Label{}, in...), 0, prcs[i:i+1], false)
+		dss = append(dss, DebugStep{
+			Rule: string(data),
+			In:   in,
+			Out:  out,
+		})
+		if len(out) == 0 {
+			break
+		}
+		in = out
+	}
+	return dss, nil
+}
+
+// RelabelDebugHandler processes /relabel-debug request.
+//
+// It applies `relabel_configs` query arg to the labels from `metric` query arg
+// and shows the labels after every relabeling step.
+// The response is returned in JSON if `format=json` query arg is set. Otherwise HTML page is returned.
+func RelabelDebugHandler(w http.ResponseWriter, r *http.Request) {
+	relabelConfigs := r.FormValue("relabel_configs")
+	metric := r.FormValue("metric")
+	dss, labels, err := getRelabelDebugSteps(relabelConfigs, metric)
+	if r.FormValue("format") == "json" {
+		w.Header().Set("Content-Type", "application/json")
+		WriteRelabelDebugStepsJSON(w, dss, labels, err)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	WriteRelabelDebugSteps(w, relabelConfigs, metric, dss, labels, err)
+}
+
+// getRelabelDebugSteps returns relabeling steps for the given relabelConfigs in YAML format and the given metric,
+// plus the resulting labels.
+func getRelabelDebugSteps(relabelConfigs, metric string) ([]DebugStep, []prompbmarshal.Label, error) {
+	if len(strings.TrimSpace(metric)) == 0 {
+		if len(strings.TrimSpace(relabelConfigs)) == 0 {
+			// Nothing to debug.
+			return nil, nil, nil
+		}
+		return nil, nil, fmt.Errorf("missing `metric` to apply `relabel_configs` to")
+	}
+	labels, err := parseDebugMetric(metric)
+	if err != nil {
+		return nil, nil, err
+	}
+	var rcs []RelabelConfig
+	if err := yaml.UnmarshalStrict([]byte(relabelConfigs), &rcs); err != nil {
+		return nil, nil, fmt.Errorf("cannot unmarshal `relabel_configs`: %w", err)
+	}
+	dss, err := DebugRelabelConfigs(labels, rcs)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(dss) > 0 {
+		labels = dss[len(dss)-1].Out
+	} else {
+		SortLabels(labels)
+	}
+	return dss, labels, nil
+}
+
+// parseDebugMetric parses labels from metric in the form `metric_name{label="value",...}` or `{label="value",...}`.
+func parseDebugMetric(metric string) ([]prompbmarshal.Label, error) {
+	expr, err := metricsql.Parse(metric)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse `metric` %q: %w", metric, err)
+	}
+	me, ok := expr.(*metricsql.MetricExpr)
+	if !ok {
+		return nil, fmt.Errorf("`metric` must contain a series in the form `metric_name{label=\"value\",...}`; got %q", metric)
+	}
+	labels := make([]prompbmarshal.Label, 0, len(me.LabelFilters))
+	for _, lf := range me.LabelFilters {
+		if lf.IsNegative || lf.IsRegexp {
+			return nil, fmt.Errorf("`metric` may contain only `label=\"value\"` pairs; got %q", metric)
+		}
+		labels = append(labels, prompbmarshal.Label{
+			Name:  lf.Label,
+			Value: lf.Value,
+		})
+	}
+	return labels, nil
+}
+
+func labelsString(labels []prompbmarshal.Label) string {
+	var b []byte
+	b = append(b, '{')
+	for i, label := range labels {
+		b = appendLabel(b, label)
+		if i+1 < len(labels) {
+			b = append(b, ", "...)
+		}
+	}
+	b = append(b, '}')
+	return string(b)
+}
+
+func labelString(label prompbmarshal.Label) string {
+	return string(appendLabel(nil, label))
+}
+
+func appendLabel(dst []byte, label prompbmarshal.Label) []byte {
+	dst = append(dst, label.Name...)
+	dst = append(dst, '=')
+	return strconv.AppendQuote(dst, label.Value)
+}
+
+// hasLabel returns true if labels contain the label with the same name and value.
+func hasLabel(labels []prompbmarshal.Label, label prompbmarshal.Label) bool {
+	for _, x := range labels {
+		if x.Name == label.Name && x.Value == label.Value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,154 @@
+package promrelabel
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestGetRelabelDebugStepsSuccess(t *testing.T) {
+	f := func(relabelConfigs, metric string, stepsExpected []string, resultExpected string) {
+		t.Helper()
+		dss, labels, err := getRelabelDebugSteps(relabelConfigs, metric)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		var steps []string
+		for _, ds := range dss {
+			steps = append(steps, labelsString(ds.In)+" -> "+labelsString(ds.Out))
+		}
+		if strings.Join(steps, "\n") != strings.Join(stepsExpected, "\n") {
+			t.Fatalf("unexpected steps;\ngot\n%s\nwant\n%s", strings.Join(steps, "\n"), strings.Join(stepsExpected, "\n"))
+		}
+		if result := labelsString(labels); result != resultExpected {
+			t.Fatalf("unexpected resulting labels; got %s; want %s", result, resultExpected)
+		}
+	}
+
+	// Empty input
+	f("", "", nil, "{}")
+
+	// Missing relabel configs
+	f("", `foo{job="bar",instance="baz"}`, nil, `{__name__="foo", instance="baz", job="bar"}`)
+
+	// Multiple steps
+	f(`
+- target_label: env
+  replacement: prod
+- action: labeldrop
+  regex: instance
+`, `foo{job="bar",instance="baz"}`, []string{
+		`{__name__="foo", job="bar", instance="baz"} -> {__name__="foo", env="prod", instance="baz", job="bar"}`,
+		`{__name__="foo", env="prod", instance="baz", job="bar"} -> {__name__="foo", env="prod", job="bar"}`,
+	}, `{__name__="foo", env="prod", job="bar"}`)
+
+	// The steps must stop after the labels are dropped
+	f(`
+- action: drop
+  source_labels: [job]
+  regex: bar
+- target_label: env
+  replacement: prod
+`, `{job="bar"}`, []string{
+		`{job="bar"} -> {}`,
+	}, `{}`)
+}
+
+func TestGetRelabelDebugStepsFailure(t *testing.T) {
+	f := func(relabelConfigs, metric string) {
+		t.Helper()
+		_, _, err := getRelabelDebugSteps(relabelConfigs, metric)
+		if err == nil {
+			t.Fatalf("expecting non-nil error")
+		}
+	}
+
+	// Missing metric
+	f(`- action: keep`, "")
+
+	// Invalid metric
+	f("", "foo{")
+	f("", "sum(foo)")
+	f("", `foo{bar=~"baz"}`)
+	f("", `foo{bar!="baz"}`)
+
+	// Invalid relabel configs
+	f("foobar", `foo`)
+	f(`- action: unknown`, `foo`)
+	f(`- action: keep`, `foo`)
+}
+
+func TestRelabelDebugStepsJSON(t *testing.T) {
+	dss, labels, err := getRelabelDebugSteps(`
+- action: labelmap
+  regex: "__meta_(.+)"
+`, `{__meta_job="foo"}`)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	var bb bytes.Buffer
+	WriteRelabelDebugStepsJSON(&bb, dss, labels, nil)
+	var resp struct {
+		Status string `json:"status"`
+		Steps  []struct {
+			Rule      string `json:"rule"`
+			InLabels  string `json:"inLabels"`
+			OutLabels string `json:"outLabels"`
+		} `json:"steps"`
+		ResultingLabels string `json:"resultingLabels"`
+	}
+	if err := json.Unmarshal(bb.Bytes(), &resp); err != nil {
+		t.Fatalf("cannot parse response %q: %s", bb.String(), err)
+	}
+	if resp.Status != "success" || len(resp.Steps) != 1 {
+		t.Fatalf("unexpected response: %s", bb.String())
+	}
+	step := resp.Steps[0]
+	if step.Rule != "regex: __meta_(.+)\naction: labelmap\n" {
+		t.Fatalf("unexpected rule: %q", step.Rule)
+	}
+	if step.InLabels != `{__meta_job="foo"}` || step.OutLabels != `{job="foo"}` {
+		t.Fatalf("unexpected step labels: %s", bb.String())
+	}
+	if resp.ResultingLabels != `{job="foo"}` {
+		t.Fatalf("unexpected resulting labels: %s", resp.ResultingLabels)
+	}
+
+	// Error response
+	_, _, err = getRelabelDebugSteps("", "foo{")
+	bb.Reset()
+	WriteRelabelDebugStepsJSON(&bb, nil, nil, err)
+	var respErr struct {
+		Status string `json:"status"`
+		Error  string `json:"error"`
+	}
+	if err := json.Unmarshal(bb.Bytes(), &respErr); err != nil {
+		t.Fatalf("cannot parse response %q: %s", bb.String(), err)
+	}
+	if respErr.Status != "error" || respErr.Error == "" {
+		t.Fatalf("unexpected error response: %s", bb.String())
+	}
+}
+
+func TestRelabelDebugStepsHTML(t *testing.T) {
+	relabelConfigs := `
+- action: labeldrop
+  regex: "instance"
+`
+	metric := `foo{instance="<bar>"}`
+	dss, labels, err := getRelabelDebugSteps(relabelConfigs, metric)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	s := RelabelDebugSteps(relabelConfigs, metric, dss, labels, err)
+	if strings.Contains(s, "<bar>") {
+		t.Fatalf("the page must contain escaped label values: %s", s)
+	}
+	if !strings.Contains(s, `<span style="font-weight:bold;color:red">instance=&quot;&lt;bar&gt;&quot;</span>`) {
+		t.Fatalf("the page must contain highlighted dropped label: %s", s)
+	}
+	if !strings.Contains(s, `<h2>Resulting labels</h2><p>{__name__=&quot;foo&quot;}</p>`) {
+		t.Fatalf("the page must contain resulting labels: %s", s)
+	}
+}
@@ -0,0 +1,198 @@
+package promrelabel
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// graphiteMatchTemplate is a template for matching Graphite metric names such as `foo.*.bar`.
+//
+// Every `*` in the template matches a single dot-delimited part of the metric name.
+type graphiteMatchTemplate struct {
+	sOrig string
+
+	// parts contains literal parts of the template and `*` parts.
+	parts []string
+}
+
+func newGraphiteMatchTemplate(s string) (*graphiteMatchTemplate, error) {
+	if strings.Contains(s, "**") {
+		return nil, fmt.Errorf("`match` cannot contain adjacent `*` chars; got %q", s)
+	}
+	sOrig := s
+	var parts []string
+	for {
+		n := strings.IndexByte(s, '*')
+		if n < 0 {
+			if len(s) > 0 {
+				parts = append(parts, s)
+			}
+			break
+		}
+		if n > 0 {
+			parts = append(parts, s[:n])
+		}
+		parts = append(parts, "*")
+		s = s[n+1:]
+	}
+	return &graphiteMatchTemplate{
+		sOrig: sOrig,
+		parts: parts,
+	}, nil
+}
+
+// Match matches s against gmt.
+//
+// On success it adds matched captures to dst and returns it with true.
+// The whole s is put at dst[0], while the values for `*` parts are put at dst[1:].
+func (gmt *graphiteMatchTemplate) Match(dst []string, s string) ([]string, bool) {
+	dst = append(dst, s)
+	parts := gmt.parts
+	for i := 0; i < len(parts); i++ {
+		p := parts[i]
+		if p != "*" {
+			if !strings.HasPrefix(s, p) {
+				// Cannot match the current part
+				return dst, false
+			}
+			s = s[len(p):]
+			continue
+		}
+		if i+1 >= len(parts) {
+			// The last `*` part must match the rest of s.
+			if strings.IndexByte(s, '.') >= 0 {
+				return dst, false
+			}
+			dst = append(dst, s)
+			return dst, true
+		}
+		// Search for the start of the next part.
+		i++
+		p = parts[i]
+		n := strings.Index(s, p)
+		if n < 0 {
+			return dst, false
+		}
+		tmp := s[:n]
+		if strings.IndexByte(tmp, '.') >= 0 {
+			// The `*` part cannot contain dots.
+			return dst, false
+		}
+		dst = append(dst, tmp)
+		s = s[n+len(p):]
+	}
+	return dst, len(s) == 0
+}
+
+// graphiteLabelRule contains target label name and its value template for `action: graphite`.
+type graphiteLabelRule struct {
+	targetLabel string
+	grt         *graphiteReplaceTemplate
+}
+
+func newGraphiteLabelRules(m map[string]string) []graphiteLabelRule {
+	grs := make([]graphiteLabelRule, 0, len(m))
+	for labelName, labelValue := range m {
+		grs = append(grs, graphiteLabelRule{
+			targetLabel: labelName,
+			grt:         newGraphiteReplaceTemplate(labelValue),
+		})
+	}
+	// Sort rules by target label in order to get deterministic results.
+	sort.Slice(grs, func(i, j int) bool {
+		return grs[i].targetLabel < grs[j].targetLabel
+	})
+	return grs
+}
+
+// graphiteReplaceTemplate is a template for label value with `$N` or `${N}` references to `*` captures from graphiteMatchTemplate.
+type graphiteReplaceTemplate struct {
+	sOrig string
+	parts []graphiteReplaceTemplatePart
+}
+
+type graphiteReplaceTemplatePart struct {
+	// n is the index of the capture to substitute. It is set to -1 for literal parts.
+	n int
+	s string
+}
+
+func newGraphiteReplaceTemplate(s string) *graphiteReplaceTemplate {
+	sOrig := s
+	var parts []graphiteReplaceTemplatePart
+	for {
+		n := strings.IndexByte(s, '$')
+		if n < 0 {
+			parts = appendGraphiteReplaceTemplateLiteral(parts, s)
+			break
+		}
+		if n > 0 {
+			parts = appendGraphiteReplaceTemplateLiteral(parts, s[:n])
+		}
+		s = s[n+1:]
+		if len(s) > 0 && s[0] == '{' {
+			// The reference in the form ${N}
+			n := strings.IndexByte(s, '}')
+			if n < 0 {
+				parts = appendGraphiteReplaceTemplateLiteral(parts, "$"+s)
+				break
+			}
+			idx, err := strconv.Atoi(s[1:n])
+			if err != nil {
+				parts = appendGraphiteReplaceTemplateLiteral(parts, "$"+s[:n+1])
+			} else {
+				parts = append(parts, graphiteReplaceTemplatePart{
+					n: idx,
+				})
+			}
+			s = s[n+1:]
+			continue
+		}
+		// The reference in the form $N
+		n = 0
+		for n < len(s) && s[n] >= '0' && s[n] <= '9' {
+			n++
+		}
+		if n == 0 {
+			parts = appendGraphiteReplaceTemplateLiteral(parts, "$")
+			continue
+		}
+		idx, _ := strconv.Atoi(s[:n])
+		parts = append(parts, graphiteReplaceTemplatePart{
+			n: idx,
+		})
+		s = s[n:]
+	}
+	return &graphiteReplaceTemplate{
+		sOrig: sOrig,
+		parts: parts,
+	}
+}
+
+func appendGraphiteReplaceTemplateLiteral(parts []graphiteReplaceTemplatePart, s string) []graphiteReplaceTemplatePart {
+	if len(s) == 0 {
+		return parts
+	}
+	return append(parts, graphiteReplaceTemplatePart{
+		n: -1,
+		s: s,
+	})
+}
+
+// Expand expands grt with the given matches returned from graphiteMatchTemplate.Match and appends the result to dst.
+//
+// References to missing matches are substituted with empty strings.
+func (grt *graphiteReplaceTemplate) Expand(dst []byte, matches []string) []byte {
+	for _, part := range grt.parts {
+		if part.n < 0 {
+			dst = append(dst, part.s...)
+			continue
+		}
+		if part.n < len(matches) {
+			dst = append(dst, matches[part.n]...)
+		}
+	}
+	return dst
+}
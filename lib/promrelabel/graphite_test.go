@@ -0,0 +1,74 @@
+package promrelabel
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGraphiteMatchTemplateMatch(t *testing.T) {
+	f := func(tpl, s string, matchesExpected []string, okExpected bool) {
+		t.Helper()
+		gmt, err := newGraphiteMatchTemplate(tpl)
+		if err != nil {
+			t.Fatalf("unexpected error when parsing %q: %s", tpl, err)
+		}
+		matches, ok := gmt.Match(nil, s)
+		if ok != okExpected {
+			t.Fatalf("unexpected ok result for tpl=%q, s=%q; got %v; want %v", tpl, s, ok, okExpected)
+		}
+		if okExpected && !reflect.DeepEqual(matches, matchesExpected) {
+			t.Fatalf("unexpected matches for tpl=%q, s=%q; got\n%q\nwant\n%q", tpl, s, matches, matchesExpected)
+		}
+	}
+	f("", "", []string{""}, true)
+	f("", "foob", nil, false)
+	f("foo", "foo", []string{"foo"}, true)
+	f("foo", "foobar", nil, false)
+	f("foo.bar.baz", "foo.bar.baz", []string{"foo.bar.baz"}, true)
+	f("*", "foobar", []string{"foobar", "foobar"}, true)
+	f("*", "foo.bar", nil, false)
+	f("foo.*", "foo.bar", []string{"foo.bar", "bar"}, true)
+	f("foo.*", "foo.bar.baz", nil, false)
+	f("*.bar", "foo.bar", []string{"foo.bar", "foo"}, true)
+	f("*.bar", "foo.baz", nil, false)
+	f("foo.*.baz", "foo.bar.baz", []string{"foo.bar.baz", "bar"}, true)
+	f("foo.*.baz", "foo.x.y.baz", nil, false)
+	f("*.*.*_total", "foo.bar.baz_total", []string{"foo.bar.baz_total", "foo", "bar", "baz"}, true)
+	f("*.*.*_total", "foo.bar.baz_sum", nil, false)
+	f("*_*", "foo_bar", []string{"foo_bar", "foo", "bar"}, true)
+}
+
+func TestGraphiteMatchTemplateFailure(t *testing.T) {
+	f := func(tpl string) {
+		t.Helper()
+		if _, err := newGraphiteMatchTemplate(tpl); err == nil {
+			t.Fatalf("expecting non-nil error for %q", tpl)
+		}
+	}
+	f("**")
+	f("foo.**.bar")
+}
+
+func TestGraphiteReplaceTemplateExpand(t *testing.T) {
+	f := func(tpl string, matches []string, resultExpected string) {
+		t.Helper()
+		grt := newGraphiteReplaceTemplate(tpl)
+		result := string(grt.Expand(nil, matches))
+		if result != resultExpected {
+			t.Fatalf("unexpected result for tpl=%q; got %q; want %q", tpl, result, resultExpected)
+		}
+	}
+	matches := []string{"foo.bar.baz", "foo", "bar", "baz"}
+	f("", matches, "")
+	f("foo", matches, "foo")
+	f("$", matches, "$")
+	f("$0", matches, "foo.bar.baz")
+	f("$1", matches, "foo")
+	f("${2}", matches, "bar")
+	f("$1-$3", matches, "foo-baz")
+	f("${1}x${3}", matches, "fooxbaz")
+	f("$4", matches, "")
+	f("$x", matches, "$x")
+	f("${x}", matches, "${x}")
+	f("${1", matches, "${1")
+}
@@ -0,0 +1,112 @@
+package promrelabel
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/prompbmarshal"
+	"github.com/VictoriaMetrics/metricsql"
+)
+
+// IfExpression represents `if` expression at RelabelConfig.
+//
+// The `if` expression can contain arbitrary series selector. For example, `if: '{__name__=~"foo.*",job="bar"}'`.
+// The relabeling rule is applied only to series matching the `if` expression.
+type IfExpression struct {
+	s   string
+	lfs []*labelFilter
+}
+
+// Parse parses `if` expression from s and stores it to ie.
+func (ie *IfExpression) Parse(s string) error {
+	expr, err := metricsql.Parse(s)
+	if err != nil {
+		return err
+	}
+	me, ok := expr.(*metricsql.MetricExpr)
+	if !ok {
+		return fmt.Errorf("expecting series selector; got %q", expr.AppendString(nil))
+	}
+	lfs, err := newLabelFilters(me)
+	if err != nil {
+		return fmt.Errorf("cannot parse series selector: %w", err)
+	}
+	ie.s = s
+	ie.lfs = lfs
+	return nil
+}
+
+// UnmarshalYAML unmarshals ie from YAML passed to f.
+func (ie *IfExpression) UnmarshalYAML(f func(interface{}) error) error {
+	var s string
+	if err := f(&s); err != nil {
+		return fmt.Errorf("cannot unmarshal `if` option: %w", err)
+	}
+	if err := ie.Parse(s); err != nil {
+		return fmt.Errorf("cannot parse `if` series selector %q: %w", s, err)
+	}
+	return nil
+}
+
+// MarshalYAML marshals ie to YAML.
+func (ie *IfExpression) MarshalYAML() (interface{}, error) {
+	return ie.s, nil
+}
+
+// String returns string representation of ie.
+func (ie *IfExpression) String() string {
+	return ie.s
+}
+
+// Match returns true if ie matches the given labels.
+func (ie *IfExpression) Match(labels []prompbmarshal.Label) bool {
+	for _, lf := range ie.lfs {
+		if !lf.match(labels) {
+			return false
+		}
+	}
+	return true
+}
+
+func newLabelFilters(me *metricsql.MetricExpr) ([]*labelFilter, error) {
+	lfs := make([]*labelFilter, 0, len(me.LabelFilters))
+	for i := range me.LabelFilters {
+		lfe := &me.LabelFilters[i]
+		lf := &labelFilter{
+			label:      lfe.Label,
+			value:      lfe.Value,
+			isNegative: lfe.IsNegative,
+		}
+		if lfe.IsRegexp {
+			re, err := regexp.Compile("^(?:" + lfe.Value + ")$")
+			if err != nil {
+				return nil, fmt.Errorf("cannot parse regexp for %s: %w", lfe.AppendString(nil), err)
+			}
+			lf.re = re
+		}
+		lfs = append(lfs, lf)
+	}
+	return lfs, nil
+}
+
+// labelFilter is a single label filter from series selector.
+type labelFilter struct {
+	label      string
+	value      string
+	isNegative bool
+
+	// re is set for regexp filters
+	re *regexp.Regexp
+}
+
+func (lf *labelFilter) match(labels []prompbmarshal.Label) bool {
+	// Missing label is equivalent to the label with empty value.
+	value := GetLabelValueByName(labels, lf.label)
+	var ok bool
+	if lf.re != nil {
+		ok = lf.re.MatchString(value)
+	} else {
+		ok = value == lf.value
+	}
+	return ok != lf.isNegative
+}
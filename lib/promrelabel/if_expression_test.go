@@ -0,0 +1,101 @@
+package promrelabel
+
+import (
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+func TestIfExpressionParseFailure(t *testing.T) {
+	f := func(s string) {
+		t.Helper()
+		var ie IfExpression
+		if err := ie.Parse(s); err == nil {
+			t.Fatalf("expecting non-nil error when parsing %q", s)
+		}
+	}
+	f(`{`)
+	f(`{foo`)
+	f(`foo{`)
+	f(`foo + bar`)
+	f(`sum(foo)`)
+	f(`foo[5m]`)
+	f(`{foo=~"bar["}`)
+}
+
+func TestIfExpressionParseSuccess(t *testing.T) {
+	f := func(s string) {
+		t.Helper()
+		var ie IfExpression
+		if err := ie.Parse(s); err != nil {
+			t.Fatalf("unexpected error when parsing %q: %s", s, err)
+		}
+	}
+	f(`foo`)
+	f(`{foo="bar"}`)
+	f(`foo{bar=~"baz", x!="y"}`)
+}
+
+func TestIfExpressionUnmarshalMarshalYAML(t *testing.T) {
+	f := func(data, resultExpected string) {
+		t.Helper()
+		var ie IfExpression
+		if err := yaml.UnmarshalStrict([]byte(data), &ie); err != nil {
+			t.Fatalf("unexpected error when unmarshaling %q: %s", data, err)
+		}
+		resultData, err := yaml.Marshal(&ie)
+		if err != nil {
+			t.Fatalf("unexpected error when marshaling: %s", err)
+		}
+		if result := string(resultData); result != resultExpected {
+			t.Fatalf("unexpected result; got\n%s\nwant\n%s", result, resultExpected)
+		}
+	}
+	f(`foo`, "foo\n")
+	f(`'{foo="bar"}'`, "'{foo=\"bar\"}'\n")
+}
+
+func TestIfExpressionUnmarshalFailure(t *testing.T) {
+	f := func(data string) {
+		t.Helper()
+		var ie IfExpression
+		if err := yaml.UnmarshalStrict([]byte(data), &ie); err == nil {
+			t.Fatalf("expecting non-nil error when unmarshaling %q", data)
+		}
+	}
+	f(`{`)
+	f(`[foo]`)
+	f(`"sum(foo)"`)
+}
+
+func TestIfExpressionMatch(t *testing.T) {
+	f := func(ifExpr, metric string, resultExpected bool) {
+		t.Helper()
+		var ie IfExpression
+		if err := ie.Parse(ifExpr); err != nil {
+			t.Fatalf("unexpected error when parsing %q: %s", ifExpr, err)
+		}
+		labels, err := parseDebugMetric(metric)
+		if err != nil {
+			t.Fatalf("cannot parse %q: %s", metric, err)
+		}
+		if result := ie.Match(labels); result != resultExpected {
+			t.Fatalf("unexpected result for %s matching %s; got %v; want %v", ifExpr, metric, result, resultExpected)
+		}
+	}
+	f(`foo`, `foo`, true)
+	f(`foo`, `foo{bar="baz"}`, true)
+	f(`foo`, `bar`, false)
+	f(`{foo="bar"}`, `foo{foo="bar"}`, true)
+	f(`{foo="bar"}`, `foo{foo="baz"}`, false)
+	f(`{foo!="bar"}`, `foo{foo="baz"}`, true)
+	f(`{foo!="bar"}`, `foo`, true)
+	f(`{foo=""}`, `foo`, true)
+	f(`{foo=~"ba.*"}`, `foo{foo="baz"}`, true)
+	f(`{foo=~"ba"}`, `foo{foo="baz"}`, false)
+	f(`{foo!~"ba.*"}`, `foo{foo="baz"}`, false)
+	f(`{foo!~"x|y"}`, `foo{foo="baz"}`, true)
+	f(`foo{bar="baz",x=~"y.*"}`, `foo{bar="baz",x="yy"}`, true)
+	f(`foo{bar="baz",x=~"y.*"}`, `foo{bar="baz",x="zy"}`, false)
+	f(`foo{bar="baz",x=~"y.*"}`, `bar{bar="baz",x="yy"}`, false)
+}
@@ -5,6 +5,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/bytesutil"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logger"
@@ -23,6 +24,10 @@ type ParsedRelabelConfig struct {
 	Modulus      uint64
 	Replacement  string
 	Action       string
+	If           *IfExpression
+
+	graphiteMatchTemplate *graphiteMatchTemplate
+	graphiteLabelRules    []graphiteLabelRule
 
 	hasCaptureGroupInTargetLabel bool
 	hasCaptureGroupInReplacement bool
@@ -30,8 +35,15 @@ type ParsedRelabelConfig struct {
 
 // String returns human-readable representation for prc.
 func (prc *ParsedRelabelConfig) String() string {
-	return fmt.Sprintf("SourceLabels=%s, Separator=%s, TargetLabel=%s, Regex=%s, Modulus=%d, Replacement=%s, Action=%s",
+	s := fmt.Sprintf("SourceLabels=%s, Separator=%s, TargetLabel=%s, Regex=%s, Modulus=%d, Replacement=%s, Action=%s",
 		prc.SourceLabels, prc.Separator, prc.TargetLabel, prc.Regex.String(), prc.Modulus, prc.Replacement, prc.Action)
+	if prc.If != nil {
+		s += fmt.Sprintf(", If=%s", prc.If)
+	}
+	if prc.graphiteMatchTemplate != nil {
+		s += fmt.Sprintf(", Match=%s", prc.graphiteMatchTemplate.sOrig)
+	}
+	return s
 }
 
 // ApplyRelabelConfigs applies prcs to labels starting from the labelsOffset.
@@ -111,6 +123,14 @@ func FinalizeLabels(dst, src []prompbmarshal.Label) []prompbmarshal.Label {
 // See https://prometheus.io/docs/prometheus/latest/configuration/configuration/#relabel_config
 func applyRelabelConfig(labels []prompbmarshal.Label, labelsOffset int, prc *ParsedRelabelConfig) []prompbmarshal.Label {
 	src := labels[labelsOffset:]
+	if prc.If != nil && !prc.If.Match(src) {
+		if prc.Action == "keep" {
+			// Drop the target on `if` mismatch for `action: keep`
+			return labels[:labelsOffset]
+		}
+		// Do not apply prc actions on `if` mismatch.
+		return labels
+	}
 	switch prc.Action {
 	case "replace":
 		bb := relabelBufPool.Get()
@@ -172,6 +192,60 @@ func applyRelabelConfig(labels []prompbmarshal.Label, labelsOffset int, prc *Par
 			return labels[:labelsOffset]
 		}
 		return labels
+	case "keepequal":
+		// Keep the entry if `source_labels` joined with `separator` matches `target_label`
+		bb := relabelBufPool.Get()
+		bb.B = concatLabelValues(bb.B[:0], src, prc.SourceLabels, prc.Separator)
+		targetValue := GetLabelValueByName(src, prc.TargetLabel)
+		keep := string(bb.B) == targetValue
+		relabelBufPool.Put(bb)
+		if keep {
+			return labels
+		}
+		return labels[:labelsOffset]
+	case "dropequal":
+		// Drop the entry if `source_labels` joined with `separator` matches `target_label`
+		bb := relabelBufPool.Get()
+		bb.B = concatLabelValues(bb.B[:0], src, prc.SourceLabels, prc.Separator)
+		targetValue := GetLabelValueByName(src, prc.TargetLabel)
+		drop := string(bb.B) == targetValue
+		relabelBufPool.Put(bb)
+		if drop {
+			return labels[:labelsOffset]
+		}
+		return labels
+	case "lowercase":
+		bb := relabelBufPool.Get()
+		bb.B = concatLabelValues(bb.B[:0], src, prc.SourceLabels, prc.Separator)
+		valueStr := strings.ToLower(string(bb.B))
+		relabelBufPool.Put(bb)
+		return setLabelValue(labels, labelsOffset, prc.TargetLabel, valueStr)
+	case "uppercase":
+		bb := relabelBufPool.Get()
+		bb.B = concatLabelValues(bb.B[:0], src, prc.SourceLabels, prc.Separator)
+		valueStr := strings.ToUpper(string(bb.B))
+		relabelBufPool.Put(bb)
+		return setLabelValue(labels, labelsOffset, prc.TargetLabel, valueStr)
+	case "graphite":
+		metricName := GetLabelValueByName(src, "__name__")
+		gm := graphiteMatchesPool.Get().(*graphiteMatches)
+		var ok bool
+		gm.a, ok = prc.graphiteMatchTemplate.Match(gm.a[:0], metricName)
+		if !ok {
+			// Fast path - name mismatch
+			graphiteMatchesPool.Put(gm)
+			return labels
+		}
+		// Slow path - extract labels from graphite metric name
+		bb := relabelBufPool.Get()
+		for _, gl := range prc.graphiteLabelRules {
+			bb.B = gl.grt.Expand(bb.B[:0], gm.a)
+			valueStr := string(bb.B)
+			labels = setLabelValue(labels, labelsOffset, gl.targetLabel, valueStr)
+		}
+		relabelBufPool.Put(bb)
+		graphiteMatchesPool.Put(gm)
+		return labels
 	case "keep":
 		bb := relabelBufPool.Get()
 		bb.B = concatLabelValues(bb.B[:0], src, prc.SourceLabels, prc.Separator)
@@ -273,6 +347,16 @@ func (prc *ParsedRelabelConfig) expandCaptureGroups(template, source string, mat
 
 var relabelBufPool bytesutil.ByteBufferPool
 
+type graphiteMatches struct {
+	a []string
+}
+
+var graphiteMatchesPool = &sync.Pool{
+	New: func() interface{} {
+		return &graphiteMatches{}
+	},
+}
+
 func areEqualLabelValues(labels []prompbmarshal.Label, labelNames []string) bool {
 	if len(labelNames) < 2 {
 		logger.Panicf("BUG: expecting at least 2 labelNames; got %d", len(labelNames))
@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/prompbmarshal"
+	"gopkg.in/yaml.v2"
 )
 
 func TestApplyRelabelConfigs(t *testing.T) {
@@ -698,6 +699,130 @@ func TestApplyRelabelConfigs(t *testing.T) {
 	})
 }
 
+func TestApplyRelabelConfigsYAML(t *testing.T) {
+	f := func(config, metric string, isFinalize bool, resultExpected string) {
+		t.Helper()
+		var rcs []RelabelConfig
+		if err := yaml.UnmarshalStrict([]byte(config), &rcs); err != nil {
+			t.Fatalf("cannot unmarshal relabel configs: %s", err)
+		}
+		prcs, err := ParseRelabelConfigs(nil, rcs)
+		if err != nil {
+			t.Fatalf("cannot parse relabel configs: %s", err)
+		}
+		labels, err := parseDebugMetric(metric)
+		if err != nil {
+			t.Fatalf("cannot parse %q: %s", metric, err)
+		}
+		labels = ApplyRelabelConfigs(labels, 0, prcs, isFinalize)
+		SortLabels(labels)
+		if result := labelsString(labels); result != resultExpected {
+			t.Fatalf("unexpected result; got\n%s\nwant\n%s", result, resultExpected)
+		}
+	}
+	t.Run("keepequal", func(t *testing.T) {
+		config := `
+- action: keepequal
+  source_labels: [foo]
+  target_label: bar
+`
+		f(config, `{foo="x",bar="x"}`, false, `{bar="x", foo="x"}`)
+		f(config, `{foo="x",bar="y"}`, false, `{}`)
+		f(config, `{foo="x"}`, false, `{}`)
+		f(config, `{baz="x"}`, false, `{baz="x"}`)
+	})
+	t.Run("dropequal", func(t *testing.T) {
+		config := `
+- action: dropequal
+  source_labels: [foo, baz]
+  separator: "-"
+  target_label: bar
+`
+		f(config, `{foo="x",baz="y",bar="x-y"}`, false, `{}`)
+		f(config, `{foo="x",baz="y",bar="x"}`, false, `{bar="x", baz="y", foo="x"}`)
+	})
+	t.Run("lowercase", func(t *testing.T) {
+		config := `
+- action: lowercase
+  source_labels: [foo, bar]
+  target_label: baz
+`
+		f(config, `{foo="Foo",bar="BAR"}`, false, `{bar="BAR", baz="foo;bar", foo="Foo"}`)
+		f(config, `{foo="abc"}`, false, `{baz="abc;", foo="abc"}`)
+	})
+	t.Run("uppercase", func(t *testing.T) {
+		config := `
+- action: uppercase
+  source_labels: [foo]
+  target_label: foo
+`
+		f(config, `{foo="Foo",bar="bar"}`, false, `{bar="bar", foo="FOO"}`)
+	})
+	t.Run("graphite", func(t *testing.T) {
+		config := `
+- action: graphite
+  match: "*.server.*.total"
+  labels:
+    __name__: "${2}_total"
+    job: "$1"
+    instance: "$0"
+`
+		f(config, `{__name__="app.server.requests.total"}`, false,
+			`{__name__="requests_total", instance="app.server.requests.total", job="app"}`)
+		f(config, `{__name__="app.server.requests.sum"}`, false, `{__name__="app.server.requests.sum"}`)
+		f(config, `{__name__="app.server.x.y.total"}`, false, `{__name__="app.server.x.y.total"}`)
+	})
+	t.Run("if-replace", func(t *testing.T) {
+		config := `
+- if: '{foo=~"ba.*"}'
+  target_label: matched
+  replacement: "yes"
+`
+		f(config, `metric{foo="bar"}`, false, `{__name__="metric", foo="bar", matched="yes"}`)
+		f(config, `metric{foo="xbar"}`, false, `{__name__="metric", foo="xbar"}`)
+	})
+	t.Run("if-keep", func(t *testing.T) {
+		config := `
+- action: keep
+  if: 'foo{bar!="baz"}'
+`
+		f(config, `foo{bar="x"}`, false, `{__name__="foo", bar="x"}`)
+		f(config, `foo{bar="baz"}`, false, `{}`)
+		f(config, `xxx`, false, `{}`)
+	})
+	t.Run("if-drop", func(t *testing.T) {
+		config := `
+- action: drop
+  if: 'foo'
+`
+		f(config, `foo{bar="x"}`, false, `{}`)
+		f(config, `bar{bar="x"}`, false, `{__name__="bar", bar="x"}`)
+	})
+	t.Run("if-with-source-labels", func(t *testing.T) {
+		config := `
+- action: drop
+  if: '{job="a"}'
+  source_labels: [instance]
+  regex: "host1.*"
+`
+		f(config, `{job="a",instance="host1:80"}`, false, `{}`)
+		f(config, `{job="a",instance="host2:80"}`, false, `{instance="host2:80", job="a"}`)
+		f(config, `{job="b",instance="host1:80"}`, false, `{instance="host1:80", job="b"}`)
+	})
+	t.Run("if-graphite", func(t *testing.T) {
+		config := `
+- action: graphite
+  if: '{env="prod"}'
+  match: "*.*"
+  labels:
+    __name__: "$2"
+    job: "$1"
+`
+		f(config, `{__name__="app.requests",env="prod"}`, false, `{__name__="requests", env="prod", job="app"}`)
+		f(config, `{__name__="app.requests",env="dev"}`, false, `{__name__="app.requests", env="dev"}`)
+	})
+}
+
 func TestFinalizeLabels(t *testing.T) {
 	f := func(labels, resultExpected []prompbmarshal.Label) {
 		t.Helper()
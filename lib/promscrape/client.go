@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/flagutil"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/protoparser/prometheus"
 	"github.com/VictoriaMetrics/fasthttp"
 	"github.com/VictoriaMetrics/metrics"
 )
@@ -22,6 +23,8 @@ var (
 		"This may be useful when targets has no support for HTTP keep-alive connection. "+
 		"It is possible to set `disable_keepalive: true` individually per each 'scrape_config` section in '-promscrape.config' for fine grained control. "+
 		"Note that disabling HTTP keep-alive may increase load on both vmagent and scrape targets")
+	disableOpenMetrics = flag.Bool("promscrape.disableOpenMetrics", false, "Whether to disable requesting OpenMetrics format from scrape targets via 'Accept' request header. "+
+		"In this case only Prometheus text exposition format is requested. This may be useful for targets, which expose broken OpenMetrics responses")
 )
 
 type client struct {
@@ -76,17 +79,23 @@ func newClient(sw *ScrapeWork) *client {
 	}
 }
 
-func (c *client) ReadData(dst []byte) ([]byte, error) {
+// ReadData appends the scraped response body to dst and returns the result.
+//
+// It also returns true if the response is in OpenMetrics format according to its Content-Type header.
+func (c *client) ReadData(dst []byte) ([]byte, bool, error) {
 	deadline := time.Now().Add(c.hc.ReadTimeout)
 	req := fasthttp.AcquireRequest()
 	req.SetRequestURI(c.requestURI)
 	req.SetHost(c.host)
-	// The following `Accept` header has been copied from Prometheus sources.
+	// The following `Accept` headers have been copied from Prometheus sources.
 	// See https://github.com/prometheus/prometheus/blob/f9d21f10ecd2a343a381044f131ea4e46381ce09/scrape/scrape.go#L532 .
 	// This is needed as a workaround for scraping stupid Java-based servers such as Spring Boot.
 	// See https://github.com/VictoriaMetrics/VictoriaMetrics/issues/608 for details.
-	// Do not bloat the `Accept` header with OpenMetrics shit, since it looks like dead standard now.
-	req.Header.Set("Accept", "text/plain;version=0.0.4;q=1,*/*;q=0.1")
+	if *disableOpenMetrics {
+		req.Header.Set("Accept", acceptHeaderPrometheus)
+	} else {
+		req.Header.Set("Accept", acceptHeaderOpenMetrics)
+	}
 	if !*disableCompression || c.disableCompression {
 		req.Header.Set("Accept-Encoding", "gzip")
 	}
@@ -114,13 +123,13 @@ func (c *client) ReadData(dst []byte) ([]byte, error) {
 		fasthttp.ReleaseResponse(resp)
 		if err == fasthttp.ErrTimeout {
 			scrapesTimedout.Inc()
-			return dst, fmt.Errorf("error when scraping %q with timeout %s: %w", c.scrapeURL, c.hc.ReadTimeout, err)
+			return dst, false, fmt.Errorf("error when scraping %q with timeout %s: %w", c.scrapeURL, c.hc.ReadTimeout, err)
 		}
 		if err == fasthttp.ErrBodyTooLarge {
-			return dst, fmt.Errorf("the response from %q exceeds -promscrape.maxScrapeSize=%d; "+
+			return dst, false, fmt.Errorf("the response from %q exceeds -promscrape.maxScrapeSize=%d; "+
 				"either reduce the response size for the target or increase -promscrape.maxScrapeSize", c.scrapeURL, maxScrapeSize.N)
 		}
-		return dst, fmt.Errorf("error when scraping %q: %w", c.scrapeURL, err)
+		return dst, false, fmt.Errorf("error when scraping %q: %w", c.scrapeURL, err)
 	}
 	dstLen := len(dst)
 	if ce := resp.Header.Peek("Content-Encoding"); string(ce) == "gzip" {
@@ -129,7 +138,7 @@ func (c *client) ReadData(dst []byte) ([]byte, error) {
 		if err != nil {
 			fasthttp.ReleaseResponse(resp)
 			scrapesGunzipFailed.Inc()
-			return dst, fmt.Errorf("cannot ungzip response from %q: %w", c.scrapeURL, err)
+			return dst, false, fmt.Errorf("cannot ungzip response from %q: %w", c.scrapeURL, err)
 		}
 		scrapesGunzipped.Inc()
 	} else {
@@ -137,14 +146,20 @@ func (c *client) ReadData(dst []byte) ([]byte, error) {
 	}
 	if statusCode != fasthttp.StatusOK {
 		metrics.GetOrCreateCounter(fmt.Sprintf(`vm_promscrape_scrapes_total{status_code="%d"}`, statusCode)).Inc()
-		return dst, fmt.Errorf("unexpected status code returned when scraping %q: %d; expecting %d; response body: %q",
+		return dst, false, fmt.Errorf("unexpected status code returned when scraping %q: %d; expecting %d; response body: %q",
 			c.scrapeURL, statusCode, fasthttp.StatusOK, dst[dstLen:])
 	}
 	scrapesOK.Inc()
+	isOpenMetrics := prometheus.IsOpenMetricsContentType(string(resp.Header.ContentType()))
 	fasthttp.ReleaseResponse(resp)
-	return dst, nil
+	return dst, isOpenMetrics, nil
 }
 
+const (
+	acceptHeaderPrometheus  = "text/plain;version=0.0.4;q=1,*/*;q=0.1"
+	acceptHeaderOpenMetrics = "application/openmetrics-text;version=1.0.0,application/openmetrics-text;version=0.0.1;q=0.75,text/plain;version=0.0.4;q=0.5,*/*;q=0.1"
+)
+
 var (
 	scrapesTimedout     = metrics.NewCounter(`vm_promscrape_scrapes_timed_out_total`)
 	scrapesOK           = metrics.NewCounter(`vm_promscrape_scrapes_total{status_code="200"}`)
@@ -119,7 +119,9 @@ type scrapeWork struct {
 	Config ScrapeWork
 
 	// ReadData is called for reading the data.
-	ReadData func(dst []byte) ([]byte, error)
+	//
+	// It must return true if the data is in OpenMetrics format.
+	ReadData func(dst []byte) ([]byte, bool, error)
 
 	// PushData is called for pushing collected data.
 	PushData func(wr *prompbmarshal.WriteRequest)
@@ -211,8 +213,9 @@ var (
 
 func (sw *scrapeWork) scrapeInternal(scrapeTimestamp, realTimestamp int64) error {
 	body := leveledbytebufferpool.Get(sw.prevBodyLen)
+	var isOpenMetrics bool
 	var err error
-	body.B, err = sw.ReadData(body.B[:0])
+	body.B, isOpenMetrics, err = sw.ReadData(body.B[:0])
 	endTimestamp := time.Now().UnixNano() / 1e6
 	duration := float64(endTimestamp-realTimestamp) / 1e3
 	scrapeDuration.Update(duration)
@@ -224,7 +227,11 @@ func (sw *scrapeWork) scrapeInternal(scrapeTimestamp, realTimestamp int64) error
 		scrapesFailed.Inc()
 	} else {
 		bodyString := bytesutil.ToUnsafeString(body.B)
-		wc.rows.UnmarshalWithErrLogger(bodyString, sw.logError)
+		if isOpenMetrics {
+			wc.rows.UnmarshalOpenMetricsWithErrLogger(bodyString, sw.logError)
+		} else {
+			wc.rows.UnmarshalWithErrLogger(bodyString, sw.logError)
+		}
 	}
 	srcRows := wc.rows.Rows
 	samplesScraped := len(srcRows)
@@ -257,6 +264,7 @@ func (sw *scrapeWork) scrapeInternal(scrapeTimestamp, realTimestamp int64) error
 	sw.addAutoTimeseries(wc, "scrape_samples_scraped", float64(samplesScraped), scrapeTimestamp)
 	sw.addAutoTimeseries(wc, "scrape_samples_post_metric_relabeling", float64(samplesPostRelabeling), scrapeTimestamp)
 	sw.addAutoTimeseries(wc, "scrape_series_added", float64(seriesAdded), scrapeTimestamp)
+	wc.writeRequest.Metadata = appendMetadata(wc.writeRequest.Metadata[:0], wc.rows.Metadata)
 	startTime := time.Now()
 	sw.PushData(&wc.writeRequest)
 	pushDataDuration.UpdateDuration(startTime)
@@ -334,6 +342,20 @@ func (wc *writeRequestCtx) resetNoRows() {
 
 var writeRequestCtxPool leveledWriteRequestCtxPool
 
+// appendMetadata appends metadata parsed from `# HELP`, `# TYPE` and `# UNIT` lines of the scraped response to dst.
+func appendMetadata(dst []prompbmarshal.MetricMetadata, metadata []parser.Metadata) []prompbmarshal.MetricMetadata {
+	for i := range metadata {
+		md := &metadata[i]
+		dst = append(dst, prompbmarshal.MetricMetadata{
+			Type:             prompbmarshal.ParseMetricType(md.Type),
+			MetricFamilyName: md.Metric,
+			Help:             md.Help,
+			Unit:             md.Unit,
+		})
+	}
+	return dst
+}
+
 func (sw *scrapeWork) updateSeriesAdded(wc *writeRequestCtx) {
 	if sw.seriesMap == nil {
 		sw.seriesMap = make(map[uint64]struct{}, len(wc.writeRequest.Timeseries))
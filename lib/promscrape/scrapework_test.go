@@ -24,9 +24,9 @@ func TestScrapeWorkScrapeInternalFailure(t *testing.T) {
 	var sw scrapeWork
 
 	readDataCalls := 0
-	sw.ReadData = func(dst []byte) ([]byte, error) {
+	sw.ReadData = func(dst []byte) ([]byte, bool, error) {
 		readDataCalls++
-		return dst, fmt.Errorf("error when reading data")
+		return dst, false, fmt.Errorf("error when reading data")
 	}
 
 	pushDataCalls := 0
@@ -63,10 +63,12 @@ func TestScrapeWorkScrapeInternalSuccess(t *testing.T) {
 		sw.Config = *cfg
 
 		readDataCalls := 0
-		sw.ReadData = func(dst []byte) ([]byte, error) {
+		sw.ReadData = func(dst []byte) ([]byte, bool, error) {
 			readDataCalls++
 			dst = append(dst, data...)
-			return dst, nil
+			// Treat responses ending with `# EOF` as OpenMetrics responses.
+			isOpenMetrics := strings.HasSuffix(strings.TrimSpace(data), "# EOF")
+			return dst, isOpenMetrics, nil
 		}
 
 		pushDataCalls := 0
@@ -119,6 +121,22 @@ func TestScrapeWorkScrapeInternalSuccess(t *testing.T) {
 		scrape_samples_post_metric_relabeling 2 123
 		scrape_series_added 2 123
 	`)
+	f(`
+		# TYPE foo counter
+		foo_total{bar="baz"} 34.45 3.5 # {trace_id="abc"} 1 3.4
+		foo_created{bar="baz"} 1.5
+		# EOF
+	`, &ScrapeWork{
+		HonorTimestamps: true,
+	}, `
+		foo_total{bar="baz"} 34.45 3500
+		foo_created{bar="baz"} 1.5 123
+		up 1 123
+		scrape_samples_scraped 2 123
+		scrape_duration_seconds 0 123
+		scrape_samples_post_metric_relabeling 2 123
+		scrape_series_added 2 123
+	`)
 	f(`
 		foo{bar="baz"} 34.45 3
 		abc -2
@@ -30,8 +30,8 @@ vm_tcplistener_read_timeouts_total{name="https", addr=":443"} 12353
 vm_tcplistener_write_calls_total{name="http", addr=":80"} 3996
 vm_tcplistener_write_calls_total{name="https", addr=":443"} 132356
 `
-	readDataFunc := func(dst []byte) ([]byte, error) {
-		return append(dst, data...), nil
+	readDataFunc := func(dst []byte) ([]byte, bool, error) {
+		return append(dst, data...), false, nil
 	}
 	b.ReportAllocs()
 	b.SetBytes(int64(len(data)))
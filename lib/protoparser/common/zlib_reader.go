@@ -0,0 +1,31 @@
+package common
+
+import (
+	"io"
+	"sync"
+
+	"github.com/klauspost/compress/zlib"
+)
+
+// GetZlibReader returns new zlib reader from the pool.
+//
+// Return back the zlib reader when it no longer needed with PutZlibReader.
+func GetZlibReader(r io.Reader) (io.ReadCloser, error) {
+	v := zlibReaderPool.Get()
+	if v == nil {
+		return zlib.NewReader(r)
+	}
+	zr := v.(io.ReadCloser)
+	if err := zr.(zlib.Resetter).Reset(r, nil); err != nil {
+		return nil, err
+	}
+	return zr, nil
+}
+
+// PutZlibReader returns back zlib reader obtained via GetZlibReader.
+func PutZlibReader(zr io.ReadCloser) {
+	_ = zr.Close()
+	zlibReaderPool.Put(zr)
+}
+
+var zlibReaderPool sync.Pool
@@ -0,0 +1,157 @@
+package datadog
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Request represents DataDog POST request to /api/v1/series
+//
+// See https://docs.datadoghq.com/api/latest/metrics/#submit-metrics
+type Request struct {
+	Series []Series `json:"series"`
+}
+
+func (req *Request) reset() {
+	series := req.Series
+	for i := range series {
+		series[i].reset()
+	}
+	req.Series = series[:0]
+}
+
+// Unmarshal unmarshals DataDog /api/v1/series request body from b to req.
+//
+// b shouldn't be modified when req is in use.
+func (req *Request) Unmarshal(b []byte) error {
+	req.reset()
+	if err := json.Unmarshal(b, req); err != nil {
+		return err
+	}
+	for i := range req.Series {
+		pts := req.Series[i].Points
+		for j := range pts {
+			if n := len(pts[j]); n != 2 {
+				return fmt.Errorf("unexpected number of values in point #%d of series %q; got %d; want 2", j, req.Series[i].Metric, n)
+			}
+		}
+	}
+	return nil
+}
+
+// Series represents a series item from DataDog POST request to /api/v1/series
+type Series struct {
+	Host string `json:"host"`
+
+	// Do not decode Interval, since it isn't used by VictoriaMetrics
+	// Interval int64 `json:"interval"`
+
+	Metric string   `json:"metric"`
+	Points []Point  `json:"points"`
+	Tags   []string `json:"tags"`
+
+	// Device is an optional device name for the metric.
+	Device string `json:"device"`
+
+	// Do not decode Type, since it isn't used by VictoriaMetrics
+	// Type string `json:"type"`
+}
+
+func (s *Series) reset() {
+	s.Host = ""
+	s.Metric = ""
+
+	points := s.Points
+	for i := range points {
+		points[i] = Point{}
+	}
+	s.Points = points[:0]
+
+	tags := s.Tags
+	for i := range tags {
+		tags[i] = ""
+	}
+	s.Tags = tags[:0]
+
+	s.Device = ""
+}
+
+// Point represents a point from DataDog POST request to /api/v1/series
+//
+// It is encoded as `[timestamp_in_seconds, value]` JSON array.
+type Point []float64
+
+// Timestamp returns timestamp in milliseconds from the given pt.
+func (pt *Point) Timestamp() int64 {
+	return int64((*pt)[0] * 1000)
+}
+
+// Value returns value from the given pt.
+func (pt *Point) Value() float64 {
+	return (*pt)[1]
+}
+
+// SplitTag splits DataDog tag into label name and value.
+//
+// Tags without values such as `foo` are converted to `foo` label with `no_label_value` value.
+//
+// See https://docs.datadoghq.com/getting_started/tagging/#define-tags
+func SplitTag(tag string) (string, string) {
+	n := strings.IndexByte(tag, ':')
+	if n < 0 {
+		return tag, "no_label_value"
+	}
+	return tag[:n], tag[n+1:]
+}
+
+// SanitizeMetricName converts DataDog metric name to Prometheus-compatible metric name.
+//
+// For example, `system.load.1` is converted to `system_load_1`.
+//
+// See https://prometheus.io/docs/concepts/data_model/#metric-names-and-labels
+func SanitizeMetricName(name string) string {
+	return sanitizeName(name, true)
+}
+
+// SanitizeLabelName converts DataDog tag name to Prometheus-compatible label name.
+//
+// See https://prometheus.io/docs/concepts/data_model/#metric-names-and-labels
+func SanitizeLabelName(name string) string {
+	return sanitizeName(name, false)
+}
+
+func sanitizeName(name string, allowColon bool) string {
+	if isValidName(name, allowColon) {
+		return name
+	}
+	b := make([]byte, 0, len(name)+1)
+	if len(name) > 0 && name[0] >= '0' && name[0] <= '9' {
+		b = append(b, '_')
+	}
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		if isValidNameChar(c, allowColon) {
+			b = append(b, c)
+		} else {
+			b = append(b, '_')
+		}
+	}
+	return string(b)
+}
+
+func isValidName(name string, allowColon bool) bool {
+	if len(name) > 0 && name[0] >= '0' && name[0] <= '9' {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		if !isValidNameChar(name[i], allowColon) {
+			return false
+		}
+	}
+	return true
+}
+
+func isValidNameChar(c byte, allowColon bool) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || allowColon && c == ':'
+}
@@ -0,0 +1,127 @@
+package datadog
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitTag(t *testing.T) {
+	f := func(s, nameExpected, valueExpected string) {
+		t.Helper()
+		name, value := SplitTag(s)
+		if name != nameExpected {
+			t.Fatalf("unexpected name obtained from %q; got %q; want %q", s, name, nameExpected)
+		}
+		if value != valueExpected {
+			t.Fatalf("unexpected value obtained from %q; got %q; want %q", s, value, valueExpected)
+		}
+	}
+	f("", "", "no_label_value")
+	f("foo", "foo", "no_label_value")
+	f("foo:bar", "foo", "bar")
+	f("foo:bar:baz", "foo", "bar:baz")
+	f("foo:", "foo", "")
+}
+
+func TestSanitizeName(t *testing.T) {
+	f := func(s, metricNameExpected, labelNameExpected string) {
+		t.Helper()
+		if metricName := SanitizeMetricName(s); metricName != metricNameExpected {
+			t.Fatalf("unexpected metric name for %q; got %q; want %q", s, metricName, metricNameExpected)
+		}
+		if labelName := SanitizeLabelName(s); labelName != labelNameExpected {
+			t.Fatalf("unexpected label name for %q; got %q; want %q", s, labelName, labelNameExpected)
+		}
+	}
+	f("", "", "")
+	f("foo", "foo", "foo")
+	f("foo_Bar:1", "foo_Bar:1", "foo_Bar_1")
+	f("system.load.1", "system_load_1", "system_load_1")
+	f("1foo", "_1foo", "_1foo")
+	f("foo-bar baz/x", "foo_bar_baz_x", "foo_bar_baz_x")
+}
+
+func TestRequestUnmarshalFailure(t *testing.T) {
+	f := func(s string) {
+		t.Helper()
+		var req Request
+		if err := req.Unmarshal([]byte(s)); err == nil {
+			t.Fatalf("expecting non-nil error for Unmarshal(%q)", s)
+		}
+	}
+	f("")
+	f("foobar")
+	f(`{"series":123`)
+	f(`1234`)
+	f(`[]`)
+	f(`{"series":[{"metric":"foo","points":[[1]]}]}`)
+	f(`{"series":[{"metric":"foo","points":[[1,2,3]]}]}`)
+	f(`{"series":[{"metric":"foo","points":[["bar",2]]}]}`)
+}
+
+func TestRequestUnmarshalSuccess(t *testing.T) {
+	f := func(s string, reqExpected *Request) {
+		t.Helper()
+		var req Request
+		if err := req.Unmarshal([]byte(s)); err != nil {
+			t.Fatalf("unexpected error in Unmarshal(%q): %s", s, err)
+		}
+		if !reflect.DeepEqual(&req, reqExpected) {
+			t.Fatalf("unexpected row;\ngot\n%+v\nwant\n%+v", &req, reqExpected)
+		}
+	}
+	f("{}", &Request{})
+	f(`
+{
+  "series": [
+    {
+      "host": "test.example.com",
+      "interval": 20,
+      "metric": "system.load.1",
+      "points": [[
+        1575317847,
+        0.5
+      ]],
+      "tags": [
+        "environment:test"
+      ],
+      "device": "sda1",
+      "type": "rate"
+    }
+  ]
+}
+`, &Request{
+		Series: []Series{{
+			Host:   "test.example.com",
+			Metric: "system.load.1",
+			Points: []Point{{
+				1575317847,
+				0.5,
+			}},
+			Tags: []string{
+				"environment:test",
+			},
+			Device: "sda1",
+		}},
+	})
+}
+
+func TestRequestUnmarshalReuse(t *testing.T) {
+	var req Request
+	if err := req.Unmarshal([]byte(`{"series":[{"host":"h1","metric":"foo","points":[[1,2]],"tags":["a:b"],"device":"d"}]}`)); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := req.Unmarshal([]byte(`{"series":[{"metric":"bar","points":[[3,4]]}]}`)); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	reqExpected := &Request{
+		Series: []Series{{
+			Metric: "bar",
+			Points: []Point{{3, 4}},
+			Tags:   []string{},
+		}},
+	}
+	if !reflect.DeepEqual(&req, reqExpected) {
+		t.Fatalf("unexpected request after reuse;\ngot\n%+v\nwant\n%+v", &req, reqExpected)
+	}
+}
@@ -0,0 +1,119 @@
+package datadog
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/bytesutil"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/flagutil"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/protoparser/common"
+	"github.com/VictoriaMetrics/metrics"
+)
+
+var (
+	maxInsertRequestSize = flagutil.NewBytes("datadog.maxInsertRequestSize", 64*1024*1024, "The maximum size in bytes of a single DataDog POST request to /api/v1/series")
+	sanitizeMetricName   = flag.Bool("datadog.sanitizeMetricName", true, "Sanitize metric names for the ingested DataDog data to comply with Prometheus naming convention. "+
+		"For example, `system.load.1` is converted to `system_load_1`")
+)
+
+// ParseStream parses DataDog POST request for /api/v1/series from r and calls callback on the parsed request.
+//
+// contentEncoding must contain the value of Content-Encoding request header. gzip and deflate encodings are supported.
+//
+// callback shouldn't hold series after returning.
+func ParseStream(r io.Reader, contentEncoding string, callback func(series []Series) error) error {
+	switch contentEncoding {
+	case "gzip":
+		zr, err := common.GetGzipReader(r)
+		if err != nil {
+			return fmt.Errorf("cannot read gzipped DataDog data: %w", err)
+		}
+		defer common.PutGzipReader(zr)
+		r = zr
+	case "deflate":
+		zlr, err := common.GetZlibReader(r)
+		if err != nil {
+			return fmt.Errorf("cannot read deflated DataDog data: %w", err)
+		}
+		defer common.PutZlibReader(zlr)
+		r = zlr
+	}
+	ctx := getPushCtx()
+	defer putPushCtx(ctx)
+	if err := ctx.Read(r); err != nil {
+		return err
+	}
+	req := &ctx.req
+	if err := req.Unmarshal(ctx.reqBuf.B); err != nil {
+		unmarshalErrors.Inc()
+		return fmt.Errorf("cannot unmarshal DataDog POST request with size %d bytes: %w", len(ctx.reqBuf.B), err)
+	}
+	rows := 0
+	currentTimestamp := float64(time.Now().Unix())
+	series := req.Series
+	for i := range series {
+		s := &series[i]
+		rows += len(s.Points)
+		for j := range s.Points {
+			if pt := s.Points[j]; pt[0] <= 0 {
+				// Use the current time for points without timestamps.
+				pt[0] = currentTimestamp
+			}
+		}
+		if *sanitizeMetricName {
+			s.Metric = SanitizeMetricName(s.Metric)
+		}
+	}
+	rowsRead.Add(rows)
+	return callback(series)
+}
+
+type pushCtx struct {
+	req    Request
+	reqBuf bytesutil.ByteBuffer
+}
+
+func (ctx *pushCtx) reset() {
+	ctx.req.reset()
+	ctx.reqBuf.Reset()
+}
+
+func (ctx *pushCtx) Read(r io.Reader) error {
+	readCalls.Inc()
+	lr := io.LimitReader(r, int64(maxInsertRequestSize.N)+1)
+	reqLen, err := ctx.reqBuf.ReadFrom(lr)
+	if err != nil {
+		readErrors.Inc()
+		return fmt.Errorf("cannot read DataDog POST request: %w", err)
+	}
+	if reqLen > int64(maxInsertRequestSize.N) {
+		readErrors.Inc()
+		return fmt.Errorf("too big DataDog POST request; mustn't exceed `-datadog.maxInsertRequestSize=%d` bytes", maxInsertRequestSize.N)
+	}
+	return nil
+}
+
+var (
+	readCalls       = metrics.NewCounter(`vm_protoparser_read_calls_total{type="datadog"}`)
+	readErrors      = metrics.NewCounter(`vm_protoparser_read_errors_total{type="datadog"}`)
+	rowsRead        = metrics.NewCounter(`vm_protoparser_rows_read_total{type="datadog"}`)
+	unmarshalErrors = metrics.NewCounter(`vm_protoparser_unmarshal_errors_total{type="datadog"}`)
+)
+
+func getPushCtx() *pushCtx {
+	v := pushCtxPool.Get()
+	if v == nil {
+		return &pushCtx{}
+	}
+	return v.(*pushCtx)
+}
+
+func putPushCtx(ctx *pushCtx) {
+	ctx.reset()
+	pushCtxPool.Put(ctx)
+}
+
+var pushCtxPool sync.Pool
@@ -0,0 +1,70 @@
+package datadog
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zlib"
+)
+
+func TestParseStream(t *testing.T) {
+	const data = `{"series":[{"host":"h1","metric":"system.load.1","points":[[1575317847,0.5],[1575317857.5,1]],"tags":["env:test"]}]}`
+	seriesExpected := []Series{{
+		Host:   "h1",
+		Metric: "system_load_1",
+		Points: []Point{{1575317847, 0.5}, {1575317857.5, 1}},
+		Tags:   []string{"env:test"},
+	}}
+	f := func(body []byte, contentEncoding string) {
+		t.Helper()
+		var result []Series
+		err := ParseStream(bytes.NewReader(body), contentEncoding, func(series []Series) error {
+			for _, s := range series {
+				s.Points = append([]Point{}, s.Points...)
+				s.Tags = append([]string{}, s.Tags...)
+				result = append(result, s)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if !reflect.DeepEqual(result, seriesExpected) {
+			t.Fatalf("unexpected series;\ngot\n%+v\nwant\n%+v", result, seriesExpected)
+		}
+		if ts := result[0].Points[1].Timestamp(); ts != 1575317857500 {
+			t.Fatalf("unexpected timestamp; got %d; want %d", ts, 1575317857500)
+		}
+	}
+	f([]byte(data), "")
+
+	var bb bytes.Buffer
+	zw := gzip.NewWriter(&bb)
+	if _, err := zw.Write([]byte(data)); err != nil {
+		t.Fatalf("cannot compress data: %s", err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("cannot close gzip writer: %s", err)
+	}
+	f(bb.Bytes(), "gzip")
+
+	bb.Reset()
+	zlw := zlib.NewWriter(&bb)
+	if _, err := zlw.Write([]byte(data)); err != nil {
+		t.Fatalf("cannot compress data: %s", err)
+	}
+	if err := zlw.Close(); err != nil {
+		t.Fatalf("cannot close zlib writer: %s", err)
+	}
+	f(bb.Bytes(), "deflate")
+
+	// Invalid compressed data
+	if err := ParseStream(bytes.NewReader([]byte(data)), "deflate", func(series []Series) error {
+		t.Fatalf("unexpected callback call")
+		return nil
+	}); err == nil {
+		t.Fatalf("expecting non-nil error")
+	}
+}
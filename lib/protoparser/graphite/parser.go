@@ -64,16 +64,37 @@ func (r *Row) unmarshal(s string, tagsPool []Tag) ([]Tag, error) {
 	metricAndTags := s[:n]
 	tail := s[n+1:]
 
-	n = strings.IndexByte(metricAndTags, ';')
+	tagsPool, err := r.UnmarshalMetricAndTags(metricAndTags, tagsPool)
+	if err != nil {
+		return tagsPool, err
+	}
+
+	n = strings.IndexByte(tail, ' ')
+	if n < 0 {
+		// There is no timestamp. Use default timestamp instead.
+		r.Value = fastfloat.ParseBestEffort(tail)
+		return tagsPool, nil
+	}
+	r.Value = fastfloat.ParseBestEffort(tail[:n])
+	r.Timestamp = fastfloat.ParseInt64BestEffort(tail[n+1:])
+	return tagsPool, nil
+}
+
+// UnmarshalMetricAndTags unmarshals metric and optional tags from s.
+//
+// s must be in the form `metric;tag1=value1;...;tagN=valueN`.
+// See https://graphite.readthedocs.io/en/latest/tags.html
+func (r *Row) UnmarshalMetricAndTags(s string, tagsPool []Tag) ([]Tag, error) {
+	n := strings.IndexByte(s, ';')
 	if n < 0 {
 		// No tags
-		r.Metric = metricAndTags
+		r.Metric = s
 	} else {
 		// Tags found
-		r.Metric = metricAndTags[:n]
+		r.Metric = s[:n]
 		tagsStart := len(tagsPool)
 		var err error
-		tagsPool, err = unmarshalTags(tagsPool, metricAndTags[n+1:])
+		tagsPool, err = unmarshalTags(tagsPool, s[n+1:])
 		if err != nil {
 			return tagsPool, fmt.Errorf("cannot umarshal tags: %w", err)
 		}
@@ -83,15 +104,6 @@ func (r *Row) unmarshal(s string, tagsPool []Tag) ([]Tag, error) {
 	if len(r.Metric) == 0 {
 		return tagsPool, fmt.Errorf("metric cannot be empty")
 	}
-
-	n = strings.IndexByte(tail, ' ')
-	if n < 0 {
-		// There is no timestamp. Use default timestamp instead.
-		r.Value = fastfloat.ParseBestEffort(tail)
-		return tagsPool, nil
-	}
-	r.Value = fastfloat.ParseBestEffort(tail[:n])
-	r.Timestamp = fastfloat.ParseInt64BestEffort(tail[n+1:])
 	return tagsPool, nil
 }
 
@@ -0,0 +1,208 @@
+package native
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"runtime"
+	"sync"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/bytesutil"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/decimal"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/encoding"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/protoparser/common"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/storage"
+	"github.com/VictoriaMetrics/metrics"
+)
+
+// The maximum size of marshaled MetricName in the stream.
+const maxMetricNameSize = 1024 * 1024
+
+// The maximum size of marshaled block in the stream.
+const maxBlockSize = 100 * 1024 * 1024
+
+// Block is a single block from `/api/v1/import/native` request.
+type Block struct {
+	MetricName storage.MetricName
+	Values     []float64
+	Timestamps []int64
+}
+
+func (b *Block) reset() {
+	b.MetricName.Reset()
+	b.Values = b.Values[:0]
+	b.Timestamps = b.Timestamps[:0]
+}
+
+// ParseStream parses /api/v1/import/native data from req and calls callback for each parsed block.
+//
+// The stream must be obtained from /api/v1/export/native.
+//
+// callback shouldn't hold block after returning.
+func ParseStream(req *http.Request, callback func(block *Block) error) error {
+	r := req.Body
+	if req.Header.Get("Content-Encoding") == "gzip" {
+		zr, err := common.GetGzipReader(r)
+		if err != nil {
+			return fmt.Errorf("cannot read gzipped native data: %w", err)
+		}
+		defer common.PutGzipReader(zr)
+		r = zr
+	}
+
+	ctx := getStreamContext(r)
+	defer putStreamContext(ctx)
+
+	// Read the time range from the stream header.
+	if err := ctx.readFull(16); err != nil {
+		if err == io.EOF {
+			// Empty stream.
+			return nil
+		}
+		return fmt.Errorf("cannot read time range: %w", err)
+	}
+	ctx.tr.MinTimestamp = encoding.UnmarshalInt64(ctx.buf)
+	ctx.tr.MaxTimestamp = encoding.UnmarshalInt64(ctx.buf[8:])
+
+	for {
+		ok, err := ctx.readBlock()
+		if err != nil {
+			readErrors.Inc()
+			return err
+		}
+		if !ok {
+			return nil
+		}
+		if len(ctx.block.Timestamps) == 0 {
+			continue
+		}
+		rowsRead.Add(len(ctx.block.Timestamps))
+		if err := callback(&ctx.block); err != nil {
+			return err
+		}
+	}
+}
+
+// readBlock reads the next block from ctx.br into ctx.block.
+//
+// It returns false if the stream has no more blocks.
+func (ctx *streamContext) readBlock() (bool, error) {
+	readCalls.Inc()
+	ctx.block.reset()
+
+	// Read MetricName
+	if err := ctx.readFull(4); err != nil {
+		if err == io.EOF {
+			return false, nil
+		}
+		return false, fmt.Errorf("cannot read metricName size: %w", err)
+	}
+	size := encoding.UnmarshalUint32(ctx.buf)
+	if size > maxMetricNameSize {
+		return false, fmt.Errorf("too big metricName size; got %d; shouldn't exceed %d", size, maxMetricNameSize)
+	}
+	if err := ctx.readFull(int(size)); err != nil {
+		return false, fmt.Errorf("cannot read metricName with size %d bytes: %w", size, err)
+	}
+	if err := ctx.block.MetricName.Unmarshal(ctx.buf); err != nil {
+		return false, fmt.Errorf("cannot unmarshal metricName from %d bytes: %w", size, err)
+	}
+
+	// Read Block
+	if err := ctx.readFull(4); err != nil {
+		return false, fmt.Errorf("cannot read native block size: %w", err)
+	}
+	size = encoding.UnmarshalUint32(ctx.buf)
+	if size > maxBlockSize {
+		return false, fmt.Errorf("too big native block size; got %d; shouldn't exceed %d", size, maxBlockSize)
+	}
+	if err := ctx.readFull(int(size)); err != nil {
+		return false, fmt.Errorf("cannot read native block with size %d bytes: %w", size, err)
+	}
+	tail, err := ctx.b.UnmarshalPortable(ctx.buf)
+	if err != nil {
+		return false, fmt.Errorf("cannot unmarshal native block from %d bytes: %w", size, err)
+	}
+	if len(tail) > 0 {
+		return false, fmt.Errorf("unexpected non-empty tail left after unmarshaling native block from %d bytes; len(tail)=%d bytes", size, len(tail))
+	}
+	if err := ctx.b.UnmarshalData(); err != nil {
+		return false, fmt.Errorf("cannot unmarshal native block data: %w", err)
+	}
+
+	// Drop samples outside the exported time range.
+	timestamps := ctx.b.Timestamps()
+	i := 0
+	for i < len(timestamps) && timestamps[i] < ctx.tr.MinTimestamp {
+		i++
+	}
+	j := len(timestamps)
+	for j > i && timestamps[j-1] > ctx.tr.MaxTimestamp {
+		j--
+	}
+	values := ctx.b.Values()
+	ctx.block.Timestamps = append(ctx.block.Timestamps[:0], timestamps[i:j]...)
+	ctx.block.Values = decimal.AppendDecimalToFloat(ctx.block.Values[:0], values[i:j], ctx.b.Scale())
+	return true, nil
+}
+
+func (ctx *streamContext) readFull(size int) error {
+	ctx.buf = bytesutil.Resize(ctx.buf, size)
+	n, err := io.ReadFull(ctx.br, ctx.buf)
+	if err == io.ErrUnexpectedEOF || (err == io.EOF && n > 0) {
+		return fmt.Errorf("unexpected end of stream after reading %d bytes out of %d bytes", n, size)
+	}
+	return err
+}
+
+var (
+	readCalls  = metrics.NewCounter(`vm_protoparser_read_calls_total{type="native"}`)
+	readErrors = metrics.NewCounter(`vm_protoparser_read_errors_total{type="native"}`)
+	rowsRead   = metrics.NewCounter(`vm_protoparser_rows_read_total{type="native"}`)
+)
+
+type streamContext struct {
+	br    *bufio.Reader
+	buf   []byte
+	tr    storage.TimeRange
+	b     storage.Block
+	block Block
+}
+
+func (ctx *streamContext) reset() {
+	ctx.br.Reset(nil)
+	ctx.buf = ctx.buf[:0]
+	ctx.tr = storage.TimeRange{}
+	ctx.b.Reset()
+	ctx.block.reset()
+}
+
+func getStreamContext(r io.Reader) *streamContext {
+	select {
+	case ctx := <-streamContextPoolCh:
+		ctx.br.Reset(r)
+		return ctx
+	default:
+		if v := streamContextPool.Get(); v != nil {
+			ctx := v.(*streamContext)
+			ctx.br.Reset(r)
+			return ctx
+		}
+		return &streamContext{
+			br: bufio.NewReaderSize(r, 64*1024),
+		}
+	}
+}
+
+func putStreamContext(ctx *streamContext) {
+	ctx.reset()
+	select {
+	case streamContextPoolCh <- ctx:
+	default:
+		streamContextPool.Put(ctx)
+	}
+}
+
+var streamContextPool sync.Pool
+var streamContextPoolCh = make(chan *streamContext, runtime.GOMAXPROCS(-1))
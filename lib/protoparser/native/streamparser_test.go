@@ -0,0 +1,113 @@
+package native
+
+import (
+	"bytes"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/encoding"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/storage"
+)
+
+func TestParseStream(t *testing.T) {
+	f := func(minTimestamp, maxTimestamp int64, timestamps, values []int64, scale int16, timestampsExpected []int64, valuesExpected []float64) {
+		t.Helper()
+
+		var mn storage.MetricName
+		mn.MetricGroup = []byte("foo")
+		mn.AddTag("job", "bar")
+		var tsid storage.TSID
+		var b storage.Block
+		b.Init(&tsid, timestamps, values, scale, 64)
+
+		var data []byte
+		data = encoding.MarshalInt64(data, minTimestamp)
+		data = encoding.MarshalInt64(data, maxTimestamp)
+		tmp := mn.Marshal(nil)
+		data = encoding.MarshalUint32(data, uint32(len(tmp)))
+		data = append(data, tmp...)
+		tmp = b.MarshalPortable(nil)
+		data = encoding.MarshalUint32(data, uint32(len(tmp)))
+		data = append(data, tmp...)
+
+		req, err := http.NewRequest("POST", "http://localhost/api/v1/import/native", bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("cannot create request: %s", err)
+		}
+		var blocks []Block
+		err = ParseStream(req, func(block *Block) error {
+			var bCopy Block
+			bCopy.MetricName.CopyFrom(&block.MetricName)
+			bCopy.Timestamps = append(bCopy.Timestamps, block.Timestamps...)
+			bCopy.Values = append(bCopy.Values, block.Values...)
+			blocks = append(blocks, bCopy)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if len(timestampsExpected) == 0 {
+			if len(blocks) != 0 {
+				t.Fatalf("expecting zero blocks; got %d blocks", len(blocks))
+			}
+			return
+		}
+		if len(blocks) != 1 {
+			t.Fatalf("expecting a single block; got %d blocks", len(blocks))
+		}
+		block := &blocks[0]
+		if block.MetricName.String() != mn.String() {
+			t.Fatalf("unexpected metricName; got %s; want %s", block.MetricName.String(), mn.String())
+		}
+		if !reflect.DeepEqual(block.Timestamps, timestampsExpected) {
+			t.Fatalf("unexpected timestamps; got %d; want %d", block.Timestamps, timestampsExpected)
+		}
+		if !reflect.DeepEqual(block.Values, valuesExpected) {
+			t.Fatalf("unexpected values; got %v; want %v", block.Values, valuesExpected)
+		}
+	}
+
+	// All the samples are inside the time range.
+	f(0, 100, []int64{10, 20, 30}, []int64{1, 2, 3}, 0, []int64{10, 20, 30}, []float64{1, 2, 3})
+
+	// Samples outside the time range are dropped.
+	f(15, 25, []int64{10, 20, 30}, []int64{1, 2, 3}, 0, []int64{20}, []float64{2})
+
+	// Non-zero scale.
+	f(0, 100, []int64{10, 20}, []int64{15, 25}, -1, []int64{10, 20}, []float64{1.5, 2.5})
+
+	// All the samples are outside the time range.
+	f(40, 50, []int64{10, 20, 30}, []int64{1, 2, 3}, 0, nil, nil)
+}
+
+func TestParseStreamFailure(t *testing.T) {
+	f := func(data []byte) {
+		t.Helper()
+		req, err := http.NewRequest("POST", "http://localhost/api/v1/import/native", bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("cannot create request: %s", err)
+		}
+		err = ParseStream(req, func(block *Block) error {
+			return nil
+		})
+		if err == nil {
+			t.Fatalf("expecting non-nil error")
+		}
+	}
+
+	// Incomplete time range
+	f([]byte("foobar"))
+
+	// Incomplete metricName size
+	var data []byte
+	data = encoding.MarshalInt64(data, 0)
+	data = encoding.MarshalInt64(data, 100)
+	f(append(data, 1, 2))
+
+	// Too big metricName size
+	f(encoding.MarshalUint32(data, maxMetricNameSize+1))
+
+	// Missing metricName
+	f(encoding.MarshalUint32(data, 10))
+}
@@ -0,0 +1,191 @@
+package newrelic
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Rows contains rows parsed from NewRelic infrastructure agent request to /infra/v2/metrics/events/bulk
+type Rows struct {
+	Rows []Row
+}
+
+// Reset resets r, so it can be re-used
+func (r *Rows) Reset() {
+	rows := r.Rows
+	for i := range rows {
+		rows[i].reset()
+	}
+	r.Rows = rows[:0]
+}
+
+// Unmarshal unmarshals NewRelic infrastructure agent request from data to r.
+//
+// Every event is converted to a row. Numeric event fields are converted to samples with `<eventType>_<fieldName>` names in snake case,
+// while string event fields are converted to tags with snake case keys. Other fields are ignored.
+//
+// defaultTimestamp is used for events without `timestamp` field.
+//
+// See https://docs.newrelic.com/docs/infrastructure/manage-your-data/data-instrumentation/default-infrastructure-monitoring-data/
+func (r *Rows) Unmarshal(data []byte, defaultTimestamp int64) error {
+	r.Reset()
+	var batches []batch
+	if err := json.Unmarshal(data, &batches); err != nil {
+		return err
+	}
+	for i := range batches {
+		for j, e := range batches[i].Events {
+			if err := r.addEvent(e, defaultTimestamp); err != nil {
+				return fmt.Errorf("cannot parse event #%d in batch #%d: %w", j, i, err)
+			}
+		}
+	}
+	return nil
+}
+
+type batch struct {
+	Events []map[string]interface{} `json:"Events"`
+}
+
+func (r *Rows) addEvent(e map[string]interface{}, defaultTimestamp int64) error {
+	v, ok := e["eventType"]
+	if !ok {
+		return fmt.Errorf("missing `eventType` field")
+	}
+	eventType, ok := v.(string)
+	if !ok || eventType == "" {
+		return fmt.Errorf("`eventType` field must contain non-empty string; got %v", v)
+	}
+	timestamp := defaultTimestamp
+	if v, ok := e["timestamp"]; ok {
+		ts, ok := v.(float64)
+		if !ok {
+			return fmt.Errorf("`timestamp` field must contain number; got %v", v)
+		}
+		// The timestamp is in seconds.
+		timestamp = int64(ts * 1000)
+	}
+
+	if cap(r.Rows) > len(r.Rows) {
+		r.Rows = r.Rows[:len(r.Rows)+1]
+	} else {
+		r.Rows = append(r.Rows, Row{})
+	}
+	row := &r.Rows[len(r.Rows)-1]
+	row.Timestamp = timestamp
+
+	// Sort keys in order to get stable order of tags and samples.
+	keys := make([]string, 0, len(e))
+	for k := range e {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	prefix := camelToSnakeCase(eventType) + "_"
+	for _, k := range keys {
+		if k == "eventType" || k == "timestamp" {
+			continue
+		}
+		switch t := e[k].(type) {
+		case string:
+			if t == "" {
+				continue
+			}
+			row.Tags = append(row.Tags, Tag{
+				Key:   camelToSnakeCase(k),
+				Value: t,
+			})
+		case float64:
+			if math.IsNaN(t) {
+				continue
+			}
+			row.Samples = append(row.Samples, Sample{
+				Name:  prefix + camelToSnakeCase(k),
+				Value: t,
+			})
+		}
+	}
+	return nil
+}
+
+// Row represents a single NewRelic event.
+type Row struct {
+	Tags      []Tag
+	Samples   []Sample
+	Timestamp int64
+}
+
+func (r *Row) reset() {
+	tags := r.Tags
+	for i := range tags {
+		tags[i] = Tag{}
+	}
+	r.Tags = tags[:0]
+
+	samples := r.Samples
+	for i := range samples {
+		samples[i] = Sample{}
+	}
+	r.Samples = samples[:0]
+
+	r.Timestamp = 0
+}
+
+// Tag represents a string attribute of NewRelic event.
+type Tag struct {
+	Key   string
+	Value string
+}
+
+// Sample represents a numeric field of NewRelic event.
+type Sample struct {
+	Name  string
+	Value float64
+}
+
+// camelToSnakeCase converts camel case names such as `cpuPercent` or `SystemSample` to snake case names such as `cpu_percent` or `system_sample`.
+//
+// Chars, which cannot be used in Prometheus metric names, are replaced with `_`.
+func camelToSnakeCase(s string) string {
+	var b strings.Builder
+	b.Grow(len(s) + 4)
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case isUpper(c):
+			if i > 0 && needsUnderscore(s, i) {
+				b.WriteByte('_')
+			}
+			b.WriteByte(c + 'a' - 'A')
+		case isLower(c) || isDigit(c) || c == '_':
+			b.WriteByte(c)
+		default:
+			b.WriteByte('_')
+		}
+	}
+	return b.String()
+}
+
+// needsUnderscore returns true if `_` must be put before the upper-case char at s[i].
+func needsUnderscore(s string, i int) bool {
+	prev := s[i-1]
+	if isLower(prev) || isDigit(prev) {
+		return true
+	}
+	// Split acronyms such as `CPUPercent` into `cpu_percent`.
+	return isUpper(prev) && i+1 < len(s) && isLower(s[i+1])
+}
+
+func isUpper(c byte) bool {
+	return c >= 'A' && c <= 'Z'
+}
+
+func isLower(c byte) bool {
+	return c >= 'a' && c <= 'z'
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
@@ -0,0 +1,135 @@
+package newrelic
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCamelToSnakeCase(t *testing.T) {
+	f := func(s, resultExpected string) {
+		t.Helper()
+		result := camelToSnakeCase(s)
+		if result != resultExpected {
+			t.Fatalf("unexpected result for %q; got %q; want %q", s, result, resultExpected)
+		}
+	}
+	f("", "")
+	f("foo", "foo")
+	f("cpuPercent", "cpu_percent")
+	f("SystemSample", "system_sample")
+	f("loadAverageOneMinute", "load_average_one_minute")
+	f("CPUPercent", "cpu_percent")
+	f("diskUsedBytes", "disk_used_bytes")
+	f("ipV4Address", "ip_v4_address")
+	f("agent.version", "agent_version")
+	f("already_snake", "already_snake")
+}
+
+func TestRowsUnmarshalFailure(t *testing.T) {
+	f := func(data string) {
+		t.Helper()
+		var r Rows
+		if err := r.Unmarshal([]byte(data), 0); err == nil {
+			t.Fatalf("expecting non-nil error for %q", data)
+		}
+	}
+	f("")
+	f("{}")
+	f(`[{"Events":123}]`)
+
+	// Missing eventType
+	f(`[{"Events":[{"cpuPercent":12}]}]`)
+
+	// Non-string eventType
+	f(`[{"Events":[{"eventType":123,"cpuPercent":12}]}]`)
+
+	// Non-numeric timestamp
+	f(`[{"Events":[{"eventType":"SystemSample","timestamp":"foo","cpuPercent":12}]}]`)
+}
+
+func TestRowsUnmarshalSuccess(t *testing.T) {
+	f := func(data string, rowsExpected []Row) {
+		t.Helper()
+		var r Rows
+		if err := r.Unmarshal([]byte(data), 1690286000000); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if !reflect.DeepEqual(r.Rows, rowsExpected) {
+			t.Fatalf("unexpected rows;\ngot\n%+v\nwant\n%+v", r.Rows, rowsExpected)
+		}
+
+		// Try unmarshaling again into the same rows
+		if err := r.Unmarshal([]byte(data), 1690286000000); err != nil {
+			t.Fatalf("unexpected error on the second unmarshal: %s", err)
+		}
+		if !reflect.DeepEqual(r.Rows, rowsExpected) {
+			t.Fatalf("unexpected rows on the second unmarshal;\ngot\n%+v\nwant\n%+v", r.Rows, rowsExpected)
+		}
+	}
+	f(`[]`, nil)
+	f(`[{"EntityID":28257883748326179,"IsAgent":true,"Events":[],"ReportingAgentID":28257883748326179}]`, nil)
+	f(`[
+  {
+    "EntityID": 28257883748326179,
+    "IsAgent": true,
+    "Events": [
+      {
+        "eventType": "SystemSample",
+        "timestamp": 1690286061,
+        "entityKey": "macbook-pro.local",
+        "cpuPercent": 25.056660790748904,
+        "cpuUserPercent": 8.687987912389374,
+        "coreCount": 8,
+        "hasSwap": true,
+        "emptyValue": "",
+        "nested": {"foo": 1}
+      },
+      {
+        "eventType": "NetworkSample",
+        "interfaceName": "eth0",
+        "receiveBytesPerSecond": 123.5
+      }
+    ],
+    "ReportingAgentID": 28257883748326179
+  }
+]`, []Row{
+		{
+			Tags: []Tag{
+				{
+					Key:   "entity_key",
+					Value: "macbook-pro.local",
+				},
+			},
+			Samples: []Sample{
+				{
+					Name:  "system_sample_core_count",
+					Value: 8,
+				},
+				{
+					Name:  "system_sample_cpu_percent",
+					Value: 25.056660790748904,
+				},
+				{
+					Name:  "system_sample_cpu_user_percent",
+					Value: 8.687987912389374,
+				},
+			},
+			Timestamp: 1690286061000,
+		},
+		{
+			Tags: []Tag{
+				{
+					Key:   "interface_name",
+					Value: "eth0",
+				},
+			},
+			Samples: []Sample{
+				{
+					Name:  "network_sample_receive_bytes_per_second",
+					Value: 123.5,
+				},
+			},
+			Timestamp: 1690286000000,
+		},
+	})
+}
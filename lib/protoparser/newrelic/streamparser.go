@@ -0,0 +1,93 @@
+package newrelic
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/bytesutil"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/flagutil"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/protoparser/common"
+	"github.com/VictoriaMetrics/metrics"
+)
+
+var maxInsertRequestSize = flagutil.NewBytes("newrelic.maxInsertRequestSize", 64*1024*1024, "The maximum size in bytes of a single NewRelic request "+
+	"to /newrelic/infra/v2/metrics/events/bulk")
+
+// ParseStream parses NewRelic infrastructure agent request from r and calls callback for the parsed rows.
+//
+// callback shouldn't hold rows after returning.
+func ParseStream(r io.Reader, isGzipped bool, callback func(rows []Row) error) error {
+	if isGzipped {
+		zr, err := common.GetGzipReader(r)
+		if err != nil {
+			return fmt.Errorf("cannot read gzipped NewRelic agent data: %w", err)
+		}
+		defer common.PutGzipReader(zr)
+		r = zr
+	}
+	ctx := getPushCtx()
+	defer putPushCtx(ctx)
+	if err := ctx.Read(r); err != nil {
+		return err
+	}
+	rows := &ctx.rows
+	if err := rows.Unmarshal(ctx.reqBuf.B, time.Now().UnixNano()/1e6); err != nil {
+		unmarshalErrors.Inc()
+		return fmt.Errorf("cannot unmarshal NewRelic request with size %d bytes: %w", len(ctx.reqBuf.B), err)
+	}
+	samples := 0
+	for i := range rows.Rows {
+		samples += len(rows.Rows[i].Samples)
+	}
+	rowsRead.Add(samples)
+	return callback(rows.Rows)
+}
+
+type pushCtx struct {
+	rows   Rows
+	reqBuf bytesutil.ByteBuffer
+}
+
+func (ctx *pushCtx) reset() {
+	ctx.rows.Reset()
+	ctx.reqBuf.Reset()
+}
+
+func (ctx *pushCtx) Read(r io.Reader) error {
+	readCalls.Inc()
+	lr := io.LimitReader(r, int64(maxInsertRequestSize.N)+1)
+	reqLen, err := ctx.reqBuf.ReadFrom(lr)
+	if err != nil {
+		readErrors.Inc()
+		return fmt.Errorf("cannot read NewRelic request: %w", err)
+	}
+	if reqLen > int64(maxInsertRequestSize.N) {
+		readErrors.Inc()
+		return fmt.Errorf("too big NewRelic request; mustn't exceed `-newrelic.maxInsertRequestSize=%d` bytes", maxInsertRequestSize.N)
+	}
+	return nil
+}
+
+var (
+	readCalls       = metrics.NewCounter(`vm_protoparser_read_calls_total{type="newrelic"}`)
+	readErrors      = metrics.NewCounter(`vm_protoparser_read_errors_total{type="newrelic"}`)
+	rowsRead        = metrics.NewCounter(`vm_protoparser_rows_read_total{type="newrelic"}`)
+	unmarshalErrors = metrics.NewCounter(`vm_protoparser_unmarshal_errors_total{type="newrelic"}`)
+)
+
+func getPushCtx() *pushCtx {
+	v := pushCtxPool.Get()
+	if v == nil {
+		return &pushCtx{}
+	}
+	return v.(*pushCtx)
+}
+
+func putPushCtx(ctx *pushCtx) {
+	ctx.reset()
+	pushCtxPool.Put(ctx)
+}
+
+var pushCtxPool sync.Pool
@@ -0,0 +1,47 @@
+package newrelic
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/klauspost/compress/gzip"
+)
+
+func TestParseStream(t *testing.T) {
+	const data = `[{"Events":[{"eventType":"SystemSample","timestamp":1690286061,"entityKey":"host1","cpuPercent":25.5}]}]`
+	f := func(body []byte, isGzipped bool) {
+		t.Helper()
+		var samples []Sample
+		err := ParseStream(bytes.NewReader(body), isGzipped, func(rows []Row) error {
+			for _, r := range rows {
+				samples = append(samples, r.Samples...)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if len(samples) != 1 || samples[0].Name != "system_sample_cpu_percent" || samples[0].Value != 25.5 {
+			t.Fatalf("unexpected samples: %+v", samples)
+		}
+	}
+	f([]byte(data), false)
+
+	var bb bytes.Buffer
+	zw := gzip.NewWriter(&bb)
+	if _, err := zw.Write([]byte(data)); err != nil {
+		t.Fatalf("cannot compress data: %s", err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("cannot close gzip writer: %s", err)
+	}
+	f(bb.Bytes(), true)
+
+	// Invalid gzipped data
+	if err := ParseStream(bytes.NewReader([]byte(data)), true, func(rows []Row) error {
+		t.Fatalf("unexpected callback call")
+		return nil
+	}); err == nil {
+		t.Fatalf("expecting non-nil error")
+	}
+}
@@ -0,0 +1,85 @@
+package opentelemetry
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// OTLP/JSON encodes 64-bit integers as decimal strings, while some clients encode them as numbers.
+// Floating-point values may be encoded as "NaN", "Infinity" and "-Infinity" strings.
+// The types below accept all these forms.
+//
+// See https://github.com/open-telemetry/opentelemetry-proto/blob/main/docs/specification.md#json-protobuf-encoding
+
+type jsonUint64 uint64
+
+func (v *jsonUint64) UnmarshalJSON(data []byte) error {
+	s, err := unquoteJSONNumber(data)
+	if err != nil || s == "" {
+		return err
+	}
+	n, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return fmt.Errorf("cannot parse uint64 from %s: %w", data, err)
+	}
+	*v = jsonUint64(n)
+	return nil
+}
+
+type jsonInt64 int64
+
+func (v *jsonInt64) UnmarshalJSON(data []byte) error {
+	s, err := unquoteJSONNumber(data)
+	if err != nil || s == "" {
+		return err
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return fmt.Errorf("cannot parse int64 from %s: %w", data, err)
+	}
+	*v = jsonInt64(n)
+	return nil
+}
+
+type jsonFloat64 float64
+
+func (v *jsonFloat64) UnmarshalJSON(data []byte) error {
+	s, err := unquoteJSONNumber(data)
+	if err != nil || s == "" {
+		return err
+	}
+	switch s {
+	case "NaN":
+		*v = jsonFloat64(math.NaN())
+		return nil
+	case "Infinity":
+		*v = jsonFloat64(math.Inf(1))
+		return nil
+	case "-Infinity":
+		*v = jsonFloat64(math.Inf(-1))
+		return nil
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return fmt.Errorf("cannot parse float64 from %s: %w", data, err)
+	}
+	*v = jsonFloat64(f)
+	return nil
+}
+
+// unquoteJSONNumber returns an empty string for null.
+func unquoteJSONNumber(data []byte) (string, error) {
+	if string(data) == "null" {
+		return "", nil
+	}
+	if len(data) > 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return "", err
+		}
+		return s, nil
+	}
+	return string(data), nil
+}
@@ -0,0 +1,694 @@
+package opentelemetry
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// The structs below contain the subset of OpenTelemetry metrics data model needed for converting it to time series.
+//
+// See https://github.com/open-telemetry/opentelemetry-proto/blob/main/opentelemetry/proto/metrics/v1/metrics.proto
+// and https://github.com/open-telemetry/opentelemetry-proto/blob/main/opentelemetry/proto/collector/metrics/v1/metrics_service.proto
+//
+// Struct fields have json tags for parsing OTLP/JSON according to https://github.com/open-telemetry/opentelemetry-proto/blob/main/docs/specification.md#json-protobuf-encoding
+
+type exportMetricsServiceRequest struct {
+	ResourceMetrics []*resourceMetrics `json:"resourceMetrics"`
+}
+
+type resourceMetrics struct {
+	Resource     *resource       `json:"resource"`
+	ScopeMetrics []*scopeMetrics `json:"scopeMetrics"`
+
+	// InstrumentationLibraryMetrics is the deprecated name for ScopeMetrics, which is still sent by older clients.
+	InstrumentationLibraryMetrics []*scopeMetrics `json:"instrumentationLibraryMetrics"`
+}
+
+type resource struct {
+	Attributes []*keyValue `json:"attributes"`
+}
+
+type scopeMetrics struct {
+	Metrics []*metric `json:"metrics"`
+}
+
+type metric struct {
+	Name      string     `json:"name"`
+	Gauge     *gauge     `json:"gauge"`
+	Sum       *sum       `json:"sum"`
+	Histogram *histogram `json:"histogram"`
+	Summary   *summary   `json:"summary"`
+}
+
+type gauge struct {
+	DataPoints []*numberDataPoint `json:"dataPoints"`
+}
+
+type sum struct {
+	DataPoints []*numberDataPoint `json:"dataPoints"`
+}
+
+type histogram struct {
+	DataPoints []*histogramDataPoint `json:"dataPoints"`
+}
+
+type summary struct {
+	DataPoints []*summaryDataPoint `json:"dataPoints"`
+}
+
+type numberDataPoint struct {
+	Attributes   []*keyValue  `json:"attributes"`
+	TimeUnixNano jsonUint64   `json:"timeUnixNano"`
+	AsDouble     *jsonFloat64 `json:"asDouble"`
+	AsInt        *jsonInt64   `json:"asInt"`
+	Flags        uint32       `json:"flags"`
+}
+
+type histogramDataPoint struct {
+	Attributes     []*keyValue   `json:"attributes"`
+	TimeUnixNano   jsonUint64    `json:"timeUnixNano"`
+	Count          jsonUint64    `json:"count"`
+	Sum            *jsonFloat64  `json:"sum"`
+	BucketCounts   []jsonUint64  `json:"bucketCounts"`
+	ExplicitBounds []jsonFloat64 `json:"explicitBounds"`
+	Flags          uint32        `json:"flags"`
+}
+
+type summaryDataPoint struct {
+	Attributes     []*keyValue        `json:"attributes"`
+	TimeUnixNano   jsonUint64         `json:"timeUnixNano"`
+	Count          jsonUint64         `json:"count"`
+	Sum            jsonFloat64        `json:"sum"`
+	QuantileValues []*valueAtQuantile `json:"quantileValues"`
+	Flags          uint32             `json:"flags"`
+}
+
+type valueAtQuantile struct {
+	Quantile jsonFloat64 `json:"quantile"`
+	Value    jsonFloat64 `json:"value"`
+}
+
+type keyValue struct {
+	Key   string    `json:"key"`
+	Value *anyValue `json:"value"`
+}
+
+type anyValue struct {
+	StringValue *string       `json:"stringValue"`
+	BoolValue   *bool         `json:"boolValue"`
+	IntValue    *jsonInt64    `json:"intValue"`
+	DoubleValue *jsonFloat64  `json:"doubleValue"`
+	ArrayValue  *arrayValue   `json:"arrayValue"`
+	KvlistValue *keyValueList `json:"kvlistValue"`
+	BytesValue  []byte        `json:"bytesValue"`
+}
+
+type arrayValue struct {
+	Values []*anyValue `json:"values"`
+}
+
+type keyValueList struct {
+	Values []*keyValue `json:"values"`
+}
+
+// flagNoRecordedValue is set in data point flags if the data point has no value, e.g. when the source stopped producing it.
+const flagNoRecordedValue = 1
+
+func (req *exportMetricsServiceRequest) unmarshalProtobuf(src []byte) error {
+	// message ExportMetricsServiceRequest {
+	//   repeated ResourceMetrics resource_metrics = 1;
+	// }
+	return iterateFields(src, func(fieldNum uint64, f *field) error {
+		if fieldNum != 1 {
+			return nil
+		}
+		data, err := f.bytes("resource_metrics")
+		if err != nil {
+			return err
+		}
+		rm := &resourceMetrics{}
+		if err := rm.unmarshalProtobuf(data); err != nil {
+			return fmt.Errorf("cannot unmarshal resource_metrics: %w", err)
+		}
+		req.ResourceMetrics = append(req.ResourceMetrics, rm)
+		return nil
+	})
+}
+
+func (rm *resourceMetrics) unmarshalProtobuf(src []byte) error {
+	// message ResourceMetrics {
+	//   Resource resource = 1;
+	//   repeated ScopeMetrics scope_metrics = 2;
+	//   repeated InstrumentationLibraryMetrics instrumentation_library_metrics = 1000;
+	// }
+	return iterateFields(src, func(fieldNum uint64, f *field) error {
+		switch fieldNum {
+		case 1:
+			data, err := f.bytes("resource")
+			if err != nil {
+				return err
+			}
+			rm.Resource = &resource{}
+			if err := unmarshalAttributes(&rm.Resource.Attributes, data, 1); err != nil {
+				return fmt.Errorf("cannot unmarshal resource: %w", err)
+			}
+		case 2, 1000:
+			data, err := f.bytes("scope_metrics")
+			if err != nil {
+				return err
+			}
+			sm := &scopeMetrics{}
+			if err := sm.unmarshalProtobuf(data); err != nil {
+				return fmt.Errorf("cannot unmarshal scope_metrics: %w", err)
+			}
+			rm.ScopeMetrics = append(rm.ScopeMetrics, sm)
+		}
+		return nil
+	})
+}
+
+func (sm *scopeMetrics) unmarshalProtobuf(src []byte) error {
+	// message ScopeMetrics {
+	//   InstrumentationScope scope = 1;
+	//   repeated Metric metrics = 2;
+	// }
+	return iterateFields(src, func(fieldNum uint64, f *field) error {
+		if fieldNum != 2 {
+			return nil
+		}
+		data, err := f.bytes("metrics")
+		if err != nil {
+			return err
+		}
+		m := &metric{}
+		if err := m.unmarshalProtobuf(data); err != nil {
+			return fmt.Errorf("cannot unmarshal metric: %w", err)
+		}
+		sm.Metrics = append(sm.Metrics, m)
+		return nil
+	})
+}
+
+func (m *metric) unmarshalProtobuf(src []byte) error {
+	// message Metric {
+	//   string name = 1;
+	//   oneof data {
+	//     Gauge gauge = 5;
+	//     Sum sum = 7;
+	//     Histogram histogram = 9;
+	//     ExponentialHistogram exponential_histogram = 10;
+	//     Summary summary = 11;
+	//   }
+	// }
+	return iterateFields(src, func(fieldNum uint64, f *field) error {
+		switch fieldNum {
+		case 1:
+			data, err := f.bytes("name")
+			if err != nil {
+				return err
+			}
+			m.Name = string(data)
+		case 5:
+			m.Gauge = &gauge{}
+			return unmarshalMessageField(f, "gauge", func(data []byte) error {
+				return unmarshalNumberDataPoints(&m.Gauge.DataPoints, data)
+			})
+		case 7:
+			m.Sum = &sum{}
+			return unmarshalMessageField(f, "sum", func(data []byte) error {
+				return unmarshalNumberDataPoints(&m.Sum.DataPoints, data)
+			})
+		case 9:
+			m.Histogram = &histogram{}
+			return unmarshalMessageField(f, "histogram", func(data []byte) error {
+				return iterateFields(data, func(fieldNum uint64, f *field) error {
+					if fieldNum != 1 {
+						return nil
+					}
+					dp := &histogramDataPoint{}
+					if err := unmarshalMessageField(f, "data_points", dp.unmarshalProtobuf); err != nil {
+						return err
+					}
+					m.Histogram.DataPoints = append(m.Histogram.DataPoints, dp)
+					return nil
+				})
+			})
+		case 11:
+			m.Summary = &summary{}
+			return unmarshalMessageField(f, "summary", func(data []byte) error {
+				return iterateFields(data, func(fieldNum uint64, f *field) error {
+					if fieldNum != 1 {
+						return nil
+					}
+					dp := &summaryDataPoint{}
+					if err := unmarshalMessageField(f, "data_points", dp.unmarshalProtobuf); err != nil {
+						return err
+					}
+					m.Summary.DataPoints = append(m.Summary.DataPoints, dp)
+					return nil
+				})
+			})
+		}
+		return nil
+	})
+}
+
+func unmarshalNumberDataPoints(dst *[]*numberDataPoint, src []byte) error {
+	// message Gauge {
+	//   repeated NumberDataPoint data_points = 1;
+	// }
+	// message Sum {
+	//   repeated NumberDataPoint data_points = 1;
+	//   AggregationTemporality aggregation_temporality = 2;
+	//   bool is_monotonic = 3;
+	// }
+	return iterateFields(src, func(fieldNum uint64, f *field) error {
+		if fieldNum != 1 {
+			return nil
+		}
+		dp := &numberDataPoint{}
+		if err := unmarshalMessageField(f, "data_points", dp.unmarshalProtobuf); err != nil {
+			return err
+		}
+		*dst = append(*dst, dp)
+		return nil
+	})
+}
+
+func (dp *numberDataPoint) unmarshalProtobuf(src []byte) error {
+	// message NumberDataPoint {
+	//   repeated KeyValue attributes = 7;
+	//   fixed64 start_time_unix_nano = 2;
+	//   fixed64 time_unix_nano = 3;
+	//   oneof value {
+	//     double as_double = 4;
+	//     sfixed64 as_int = 6;
+	//   }
+	//   uint32 flags = 8;
+	// }
+	return iterateFields(src, func(fieldNum uint64, f *field) error {
+		switch fieldNum {
+		case 7:
+			return unmarshalMessageField(f, "attributes", func(data []byte) error {
+				return appendKeyValue(&dp.Attributes, data)
+			})
+		case 3:
+			v, err := f.fixed64("time_unix_nano")
+			if err != nil {
+				return err
+			}
+			dp.TimeUnixNano = jsonUint64(v)
+		case 4:
+			v, err := f.fixed64("as_double")
+			if err != nil {
+				return err
+			}
+			d := jsonFloat64(math.Float64frombits(v))
+			dp.AsDouble = &d
+		case 6:
+			v, err := f.fixed64("as_int")
+			if err != nil {
+				return err
+			}
+			n := jsonInt64(int64(v))
+			dp.AsInt = &n
+		case 8:
+			v, err := f.varint("flags")
+			if err != nil {
+				return err
+			}
+			dp.Flags = uint32(v)
+		}
+		return nil
+	})
+}
+
+func (dp *histogramDataPoint) unmarshalProtobuf(src []byte) error {
+	// message HistogramDataPoint {
+	//   repeated KeyValue attributes = 9;
+	//   fixed64 start_time_unix_nano = 2;
+	//   fixed64 time_unix_nano = 3;
+	//   fixed64 count = 4;
+	//   optional double sum = 5;
+	//   repeated fixed64 bucket_counts = 6;
+	//   repeated double explicit_bounds = 7;
+	//   uint32 flags = 10;
+	// }
+	return iterateFields(src, func(fieldNum uint64, f *field) error {
+		switch fieldNum {
+		case 9:
+			return unmarshalMessageField(f, "attributes", func(data []byte) error {
+				return appendKeyValue(&dp.Attributes, data)
+			})
+		case 3:
+			v, err := f.fixed64("time_unix_nano")
+			if err != nil {
+				return err
+			}
+			dp.TimeUnixNano = jsonUint64(v)
+		case 4:
+			v, err := f.fixed64("count")
+			if err != nil {
+				return err
+			}
+			dp.Count = jsonUint64(v)
+		case 5:
+			v, err := f.fixed64("sum")
+			if err != nil {
+				return err
+			}
+			d := jsonFloat64(math.Float64frombits(v))
+			dp.Sum = &d
+		case 6:
+			return f.repeatedFixed64("bucket_counts", func(v uint64) {
+				dp.BucketCounts = append(dp.BucketCounts, jsonUint64(v))
+			})
+		case 7:
+			return f.repeatedFixed64("explicit_bounds", func(v uint64) {
+				dp.ExplicitBounds = append(dp.ExplicitBounds, jsonFloat64(math.Float64frombits(v)))
+			})
+		case 10:
+			v, err := f.varint("flags")
+			if err != nil {
+				return err
+			}
+			dp.Flags = uint32(v)
+		}
+		return nil
+	})
+}
+
+func (dp *summaryDataPoint) unmarshalProtobuf(src []byte) error {
+	// message SummaryDataPoint {
+	//   repeated KeyValue attributes = 7;
+	//   fixed64 start_time_unix_nano = 2;
+	//   fixed64 time_unix_nano = 3;
+	//   fixed64 count = 4;
+	//   double sum = 5;
+	//   repeated ValueAtQuantile quantile_values = 6;
+	//   uint32 flags = 8;
+	// }
+	return iterateFields(src, func(fieldNum uint64, f *field) error {
+		switch fieldNum {
+		case 7:
+			return unmarshalMessageField(f, "attributes", func(data []byte) error {
+				return appendKeyValue(&dp.Attributes, data)
+			})
+		case 3:
+			v, err := f.fixed64("time_unix_nano")
+			if err != nil {
+				return err
+			}
+			dp.TimeUnixNano = jsonUint64(v)
+		case 4:
+			v, err := f.fixed64("count")
+			if err != nil {
+				return err
+			}
+			dp.Count = jsonUint64(v)
+		case 5:
+			v, err := f.fixed64("sum")
+			if err != nil {
+				return err
+			}
+			dp.Sum = jsonFloat64(math.Float64frombits(v))
+		case 6:
+			return unmarshalMessageField(f, "quantile_values", func(data []byte) error {
+				// message ValueAtQuantile {
+				//   double quantile = 1;
+				//   double value = 2;
+				// }
+				q := &valueAtQuantile{}
+				err := iterateFields(data, func(fieldNum uint64, f *field) error {
+					switch fieldNum {
+					case 1:
+						v, err := f.fixed64("quantile")
+						if err != nil {
+							return err
+						}
+						q.Quantile = jsonFloat64(math.Float64frombits(v))
+					case 2:
+						v, err := f.fixed64("value")
+						if err != nil {
+							return err
+						}
+						q.Value = jsonFloat64(math.Float64frombits(v))
+					}
+					return nil
+				})
+				if err != nil {
+					return err
+				}
+				dp.QuantileValues = append(dp.QuantileValues, q)
+				return nil
+			})
+		case 8:
+			v, err := f.varint("flags")
+			if err != nil {
+				return err
+			}
+			dp.Flags = uint32(v)
+		}
+		return nil
+	})
+}
+
+// unmarshalAttributes unmarshals repeated KeyValue field with the given fieldNum from src into dst.
+func unmarshalAttributes(dst *[]*keyValue, src []byte, fieldNum uint64) error {
+	return iterateFields(src, func(n uint64, f *field) error {
+		if n != fieldNum {
+			return nil
+		}
+		return unmarshalMessageField(f, "attributes", func(data []byte) error {
+			return appendKeyValue(dst, data)
+		})
+	})
+}
+
+func appendKeyValue(dst *[]*keyValue, src []byte) error {
+	kv := &keyValue{}
+	if err := kv.unmarshalProtobuf(src); err != nil {
+		return err
+	}
+	*dst = append(*dst, kv)
+	return nil
+}
+
+func (kv *keyValue) unmarshalProtobuf(src []byte) error {
+	// message KeyValue {
+	//   string key = 1;
+	//   AnyValue value = 2;
+	// }
+	return iterateFields(src, func(fieldNum uint64, f *field) error {
+		switch fieldNum {
+		case 1:
+			data, err := f.bytes("key")
+			if err != nil {
+				return err
+			}
+			kv.Key = string(data)
+		case 2:
+			kv.Value = &anyValue{}
+			return unmarshalMessageField(f, "value", kv.Value.unmarshalProtobuf)
+		}
+		return nil
+	})
+}
+
+func (av *anyValue) unmarshalProtobuf(src []byte) error {
+	// message AnyValue {
+	//   oneof value {
+	//     string string_value = 1;
+	//     bool bool_value = 2;
+	//     int64 int_value = 3;
+	//     double double_value = 4;
+	//     ArrayValue array_value = 5;
+	//     KeyValueList kvlist_value = 6;
+	//     bytes bytes_value = 7;
+	//   }
+	// }
+	return iterateFields(src, func(fieldNum uint64, f *field) error {
+		switch fieldNum {
+		case 1:
+			data, err := f.bytes("string_value")
+			if err != nil {
+				return err
+			}
+			s := string(data)
+			av.StringValue = &s
+		case 2:
+			v, err := f.varint("bool_value")
+			if err != nil {
+				return err
+			}
+			b := v != 0
+			av.BoolValue = &b
+		case 3:
+			v, err := f.varint("int_value")
+			if err != nil {
+				return err
+			}
+			n := jsonInt64(int64(v))
+			av.IntValue = &n
+		case 4:
+			v, err := f.fixed64("double_value")
+			if err != nil {
+				return err
+			}
+			d := jsonFloat64(math.Float64frombits(v))
+			av.DoubleValue = &d
+		case 5:
+			av.ArrayValue = &arrayValue{}
+			return unmarshalMessageField(f, "array_value", func(data []byte) error {
+				return iterateFields(data, func(fieldNum uint64, f *field) error {
+					if fieldNum != 1 {
+						return nil
+					}
+					v := &anyValue{}
+					if err := unmarshalMessageField(f, "values", v.unmarshalProtobuf); err != nil {
+						return err
+					}
+					av.ArrayValue.Values = append(av.ArrayValue.Values, v)
+					return nil
+				})
+			})
+		case 6:
+			av.KvlistValue = &keyValueList{}
+			return unmarshalMessageField(f, "kvlist_value", func(data []byte) error {
+				return unmarshalAttributes(&av.KvlistValue.Values, data, 1)
+			})
+		case 7:
+			data, err := f.bytes("bytes_value")
+			if err != nil {
+				return err
+			}
+			av.BytesValue = append([]byte{}, data...)
+		}
+		return nil
+	})
+}
+
+// Protobuf wire types.
+//
+// See https://developers.google.com/protocol-buffers/docs/encoding#structure
+const (
+	wireTypeVarint  = 0
+	wireTypeFixed64 = 1
+	wireTypeBytes   = 2
+	wireTypeFixed32 = 5
+)
+
+// field is a single protobuf field.
+type field struct {
+	wireType uint64
+	intValue uint64
+	data     []byte
+}
+
+func (f *field) varint(name string) (uint64, error) {
+	if f.wireType != wireTypeVarint {
+		return 0, fmt.Errorf("unexpected wire type for %q field; got %d; want %d", name, f.wireType, wireTypeVarint)
+	}
+	return f.intValue, nil
+}
+
+func (f *field) fixed64(name string) (uint64, error) {
+	if f.wireType != wireTypeFixed64 {
+		return 0, fmt.Errorf("unexpected wire type for %q field; got %d; want %d", name, f.wireType, wireTypeFixed64)
+	}
+	return f.intValue, nil
+}
+
+func (f *field) bytes(name string) ([]byte, error) {
+	if f.wireType != wireTypeBytes {
+		return nil, fmt.Errorf("unexpected wire type for %q field; got %d; want %d", name, f.wireType, wireTypeBytes)
+	}
+	return f.data, nil
+}
+
+// repeatedFixed64 calls callback for each item in packed or unpacked repeated fixed64 field.
+func (f *field) repeatedFixed64(name string, callback func(v uint64)) error {
+	switch f.wireType {
+	case wireTypeFixed64:
+		callback(f.intValue)
+		return nil
+	case wireTypeBytes:
+		data := f.data
+		if len(data)%8 != 0 {
+			return fmt.Errorf("unexpected length of packed %q field; got %d bytes; it must be multiple of 8", name, len(data))
+		}
+		for len(data) > 0 {
+			callback(binary.LittleEndian.Uint64(data))
+			data = data[8:]
+		}
+		return nil
+	default:
+		return fmt.Errorf("unexpected wire type for %q field; got %d", name, f.wireType)
+	}
+}
+
+func unmarshalMessageField(f *field, name string, unmarshal func(data []byte) error) error {
+	data, err := f.bytes(name)
+	if err != nil {
+		return err
+	}
+	if err := unmarshal(data); err != nil {
+		return fmt.Errorf("cannot unmarshal %q: %w", name, err)
+	}
+	return nil
+}
+
+// iterateFields calls callback for each protobuf field in src.
+func iterateFields(src []byte, callback func(fieldNum uint64, f *field) error) error {
+	var f field
+	for len(src) > 0 {
+		key, n := binary.Uvarint(src)
+		if n <= 0 {
+			return fmt.Errorf("cannot read field key")
+		}
+		src = src[n:]
+		fieldNum := key >> 3
+		f.wireType = key & 0x07
+		f.intValue = 0
+		f.data = nil
+		switch f.wireType {
+		case wireTypeVarint:
+			v, n := binary.Uvarint(src)
+			if n <= 0 {
+				return fmt.Errorf("cannot read varint for field #%d", fieldNum)
+			}
+			f.intValue = v
+			src = src[n:]
+		case wireTypeFixed64:
+			if len(src) < 8 {
+				return fmt.Errorf("cannot read fixed64 for field #%d; too short data: %d bytes", fieldNum, len(src))
+			}
+			f.intValue = binary.LittleEndian.Uint64(src)
+			src = src[8:]
+		case wireTypeBytes:
+			size, n := binary.Uvarint(src)
+			if n <= 0 {
+				return fmt.Errorf("cannot read length for field #%d", fieldNum)
+			}
+			src = src[n:]
+			if uint64(len(src)) < size {
+				return fmt.Errorf("too short data for field #%d; got %d bytes; want %d bytes", fieldNum, len(src), size)
+			}
+			f.data = src[:size]
+			src = src[size:]
+		case wireTypeFixed32:
+			if len(src) < 4 {
+				return fmt.Errorf("cannot read fixed32 for field #%d; too short data: %d bytes", fieldNum, len(src))
+			}
+			f.intValue = uint64(binary.LittleEndian.Uint32(src))
+			src = src[4:]
+		default:
+			return fmt.Errorf("unsupported wire type %d for field #%d", f.wireType, fieldNum)
+		}
+		if err := callback(fieldNum, &f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
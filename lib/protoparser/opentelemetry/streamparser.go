@@ -0,0 +1,364 @@
+package opentelemetry
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/bytesutil"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/flagutil"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/prompbmarshal"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/protoparser/common"
+	"github.com/VictoriaMetrics/metrics"
+)
+
+var maxRequestSize = flagutil.NewBytes("opentelemetry.maxRequestSize", 64*1024*1024, "The maximum size in bytes of a single OpenTelemetry request "+
+	"sent to /opentelemetry/api/v1/push")
+
+// ParseStream parses OpenTelemetry ExportMetricsServiceRequest from r and calls callback for the parsed time series.
+//
+// The request must be encoded in protobuf format unless isJSON is set. In this case OTLP/JSON format is expected.
+// Gauges and sums are converted to time series with the metric name.
+// Histograms are converted to `<name>_bucket{le="..."}`, `<name>_count` and `<name>_sum` time series.
+// Summaries are converted to `<name>{quantile="..."}`, `<name>_count` and `<name>_sum` time series.
+// Resource attributes and data point attributes are converted to labels.
+//
+// callback shouldn't hold tss after returning.
+func ParseStream(r io.Reader, isJSON, isGzipped bool, callback func(tss []prompbmarshal.TimeSeries) error) error {
+	if isGzipped {
+		zr, err := common.GetGzipReader(r)
+		if err != nil {
+			return fmt.Errorf("cannot read gzipped OpenTelemetry data: %w", err)
+		}
+		defer common.PutGzipReader(zr)
+		r = zr
+	}
+
+	wctx := getWriteContext()
+	defer putWriteContext(wctx)
+
+	readCalls.Inc()
+	if err := wctx.readRequest(r); err != nil {
+		readErrors.Inc()
+		return err
+	}
+	var req exportMetricsServiceRequest
+	if isJSON {
+		if err := json.Unmarshal(wctx.reqBuf.B, &req); err != nil {
+			unmarshalErrors.Inc()
+			return fmt.Errorf("cannot unmarshal OpenTelemetry JSON request with size %d bytes: %w", len(wctx.reqBuf.B), err)
+		}
+	} else {
+		if err := req.unmarshalProtobuf(wctx.reqBuf.B); err != nil {
+			unmarshalErrors.Inc()
+			return fmt.Errorf("cannot unmarshal OpenTelemetry protobuf request with size %d bytes: %w", len(wctx.reqBuf.B), err)
+		}
+	}
+	wctx.appendRequest(&req)
+	rowsRead.Add(len(wctx.samples))
+	return callback(wctx.tss)
+}
+
+var (
+	readCalls       = metrics.NewCounter(`vm_protoparser_read_calls_total{type="opentelemetry"}`)
+	readErrors      = metrics.NewCounter(`vm_protoparser_read_errors_total{type="opentelemetry"}`)
+	rowsRead        = metrics.NewCounter(`vm_protoparser_rows_read_total{type="opentelemetry"}`)
+	unmarshalErrors = metrics.NewCounter(`vm_protoparser_unmarshal_errors_total{type="opentelemetry"}`)
+)
+
+type writeContext struct {
+	reqBuf bytesutil.ByteBuffer
+
+	tss     []prompbmarshal.TimeSeries
+	labels  []prompbmarshal.Label
+	samples []prompbmarshal.Sample
+
+	// baseLabels contains resource attributes for the currently processed ResourceMetrics.
+	baseLabels []prompbmarshal.Label
+
+	// buf is used for converting attribute values to strings.
+	buf []byte
+}
+
+func (wctx *writeContext) reset() {
+	wctx.reqBuf.Reset()
+
+	tss := wctx.tss
+	for i := range tss {
+		tss[i] = prompbmarshal.TimeSeries{}
+	}
+	wctx.tss = tss[:0]
+
+	labels := wctx.labels
+	for i := range labels {
+		labels[i] = prompbmarshal.Label{}
+	}
+	wctx.labels = labels[:0]
+	wctx.samples = wctx.samples[:0]
+
+	baseLabels := wctx.baseLabels
+	for i := range baseLabels {
+		baseLabels[i] = prompbmarshal.Label{}
+	}
+	wctx.baseLabels = baseLabels[:0]
+	wctx.buf = wctx.buf[:0]
+}
+
+func (wctx *writeContext) readRequest(r io.Reader) error {
+	lr := io.LimitReader(r, int64(maxRequestSize.N)+1)
+	reqLen, err := wctx.reqBuf.ReadFrom(lr)
+	if err != nil {
+		return fmt.Errorf("cannot read OpenTelemetry request: %w", err)
+	}
+	if reqLen > int64(maxRequestSize.N) {
+		return fmt.Errorf("too big OpenTelemetry request; mustn't exceed `-opentelemetry.maxRequestSize=%d` bytes", maxRequestSize.N)
+	}
+	return nil
+}
+
+func (wctx *writeContext) appendRequest(req *exportMetricsServiceRequest) {
+	for _, rm := range req.ResourceMetrics {
+		if rm == nil {
+			continue
+		}
+		wctx.baseLabels = wctx.baseLabels[:0]
+		if rm.Resource != nil {
+			wctx.baseLabels = wctx.appendAttributes(wctx.baseLabels, rm.Resource.Attributes)
+		}
+		wctx.appendScopeMetrics(rm.ScopeMetrics)
+		wctx.appendScopeMetrics(rm.InstrumentationLibraryMetrics)
+	}
+}
+
+func (wctx *writeContext) appendScopeMetrics(sms []*scopeMetrics) {
+	for _, sm := range sms {
+		if sm == nil {
+			continue
+		}
+		for _, m := range sm.Metrics {
+			if m != nil {
+				wctx.appendMetric(m)
+			}
+		}
+	}
+}
+
+func (wctx *writeContext) appendMetric(m *metric) {
+	switch {
+	case m.Gauge != nil:
+		wctx.appendNumberDataPoints(m.Name, m.Gauge.DataPoints)
+	case m.Sum != nil:
+		wctx.appendNumberDataPoints(m.Name, m.Sum.DataPoints)
+	case m.Histogram != nil:
+		for _, dp := range m.Histogram.DataPoints {
+			if dp == nil || dp.Flags&flagNoRecordedValue != 0 {
+				continue
+			}
+			wctx.appendHistogramDataPoint(m.Name, dp)
+		}
+	case m.Summary != nil:
+		for _, dp := range m.Summary.DataPoints {
+			if dp == nil || dp.Flags&flagNoRecordedValue != 0 {
+				continue
+			}
+			wctx.appendSummaryDataPoint(m.Name, dp)
+		}
+	}
+}
+
+func (wctx *writeContext) appendNumberDataPoints(name string, dps []*numberDataPoint) {
+	for _, dp := range dps {
+		if dp == nil || dp.Flags&flagNoRecordedValue != 0 {
+			continue
+		}
+		var v float64
+		switch {
+		case dp.AsDouble != nil:
+			v = float64(*dp.AsDouble)
+		case dp.AsInt != nil:
+			v = float64(*dp.AsInt)
+		default:
+			// Skip data point without value.
+			continue
+		}
+		wctx.appendSample(name, "", "", dp.Attributes, getTimestamp(dp.TimeUnixNano), v)
+	}
+}
+
+func (wctx *writeContext) appendHistogramDataPoint(name string, dp *histogramDataPoint) {
+	timestamp := getTimestamp(dp.TimeUnixNano)
+	wctx.appendSample(name+"_count", "", "", dp.Attributes, timestamp, float64(dp.Count))
+	if dp.Sum != nil {
+		wctx.appendSample(name+"_sum", "", "", dp.Attributes, timestamp, float64(*dp.Sum))
+	}
+	if len(dp.BucketCounts) == 0 {
+		// The histogram has no buckets.
+		return
+	}
+	// OpenTelemetry bucket counts aren't cumulative, while Prometheus buckets are cumulative.
+	bucketName := name + "_bucket"
+	var cumulative uint64
+	for i, bound := range dp.ExplicitBounds {
+		if i < len(dp.BucketCounts) {
+			cumulative += uint64(dp.BucketCounts[i])
+		}
+		le := strconv.FormatFloat(float64(bound), 'g', -1, 64)
+		wctx.appendSample(bucketName, "le", le, dp.Attributes, timestamp, float64(cumulative))
+	}
+	wctx.appendSample(bucketName, "le", "+Inf", dp.Attributes, timestamp, float64(dp.Count))
+}
+
+func (wctx *writeContext) appendSummaryDataPoint(name string, dp *summaryDataPoint) {
+	timestamp := getTimestamp(dp.TimeUnixNano)
+	wctx.appendSample(name+"_count", "", "", dp.Attributes, timestamp, float64(dp.Count))
+	wctx.appendSample(name+"_sum", "", "", dp.Attributes, timestamp, float64(dp.Sum))
+	for _, q := range dp.QuantileValues {
+		if q == nil {
+			continue
+		}
+		quantile := strconv.FormatFloat(float64(q.Quantile), 'g', -1, 64)
+		wctx.appendSample(name, "quantile", quantile, dp.Attributes, timestamp, float64(q.Value))
+	}
+}
+
+// appendSample appends a time series with a single sample to wctx.tss.
+//
+// The time series gets the given name, resource attributes, data point attributes and an optional extra label.
+func (wctx *writeContext) appendSample(name, extraLabelName, extraLabelValue string, attributes []*keyValue, timestamp int64, value float64) {
+	labelsLen := len(wctx.labels)
+	wctx.labels = append(wctx.labels, prompbmarshal.Label{
+		Name:  "__name__",
+		Value: name,
+	})
+	wctx.labels = append(wctx.labels, wctx.baseLabels...)
+	wctx.labels = wctx.appendAttributes(wctx.labels, attributes)
+	if extraLabelName != "" {
+		wctx.labels = append(wctx.labels, prompbmarshal.Label{
+			Name:  extraLabelName,
+			Value: extraLabelValue,
+		})
+	}
+	samplesLen := len(wctx.samples)
+	wctx.samples = append(wctx.samples, prompbmarshal.Sample{
+		Value:     value,
+		Timestamp: timestamp,
+	})
+	wctx.tss = append(wctx.tss, prompbmarshal.TimeSeries{
+		Labels:  wctx.labels[labelsLen:],
+		Samples: wctx.samples[samplesLen:],
+	})
+}
+
+func (wctx *writeContext) appendAttributes(dst []prompbmarshal.Label, attributes []*keyValue) []prompbmarshal.Label {
+	for _, kv := range attributes {
+		if kv == nil || kv.Value == nil {
+			continue
+		}
+		wctx.buf = appendAnyValue(wctx.buf[:0], kv.Value)
+		if len(wctx.buf) == 0 {
+			// Skip label with empty value, since it is equivalent to missing label.
+			continue
+		}
+		dst = append(dst, prompbmarshal.Label{
+			Name:  kv.Key,
+			Value: string(wctx.buf),
+		})
+	}
+	return dst
+}
+
+// appendAnyValue appends string representation of av to dst.
+//
+// Arrays and key-value lists are marshaled to JSON.
+func appendAnyValue(dst []byte, av *anyValue) []byte {
+	switch {
+	case av.StringValue != nil:
+		return append(dst, *av.StringValue...)
+	case av.BoolValue != nil:
+		return strconv.AppendBool(dst, *av.BoolValue)
+	case av.IntValue != nil:
+		return strconv.AppendInt(dst, int64(*av.IntValue), 10)
+	case av.DoubleValue != nil:
+		return strconv.AppendFloat(dst, float64(*av.DoubleValue), 'g', -1, 64)
+	case av.BytesValue != nil:
+		return append(dst, base64.StdEncoding.EncodeToString(av.BytesValue)...)
+	case av.ArrayValue != nil, av.KvlistValue != nil:
+		data, err := json.Marshal(anyValueToInterface(av))
+		if err != nil {
+			// This shouldn't happen, since anyValueToInterface returns only JSON-compatible values.
+			return dst
+		}
+		return append(dst, data...)
+	default:
+		return dst
+	}
+}
+
+func anyValueToInterface(av *anyValue) interface{} {
+	if av == nil {
+		return nil
+	}
+	switch {
+	case av.StringValue != nil:
+		return *av.StringValue
+	case av.BoolValue != nil:
+		return *av.BoolValue
+	case av.IntValue != nil:
+		return int64(*av.IntValue)
+	case av.DoubleValue != nil:
+		f := float64(*av.DoubleValue)
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			// JSON doesn't support NaN and Inf.
+			return strconv.FormatFloat(f, 'g', -1, 64)
+		}
+		return f
+	case av.BytesValue != nil:
+		return av.BytesValue
+	case av.ArrayValue != nil:
+		a := make([]interface{}, 0, len(av.ArrayValue.Values))
+		for _, v := range av.ArrayValue.Values {
+			a = append(a, anyValueToInterface(v))
+		}
+		return a
+	case av.KvlistValue != nil:
+		m := make(map[string]interface{}, len(av.KvlistValue.Values))
+		for _, kv := range av.KvlistValue.Values {
+			if kv != nil {
+				m[kv.Key] = anyValueToInterface(kv.Value)
+			}
+		}
+		return m
+	default:
+		return nil
+	}
+}
+
+// getTimestamp converts timeUnixNano to milliseconds.
+//
+// The current time is returned if timeUnixNano isn't set.
+func getTimestamp(timeUnixNano jsonUint64) int64 {
+	if timeUnixNano == 0 {
+		return time.Now().UnixNano() / 1e6
+	}
+	return int64(timeUnixNano / 1e6)
+}
+
+func getWriteContext() *writeContext {
+	v := writeContextPool.Get()
+	if v == nil {
+		return &writeContext{}
+	}
+	return v.(*writeContext)
+}
+
+func putWriteContext(wctx *writeContext) {
+	wctx.reset()
+	writeContextPool.Put(wctx)
+}
+
+var writeContextPool sync.Pool
@@ -0,0 +1,294 @@
+package opentelemetry
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/prompbmarshal"
+	"github.com/klauspost/compress/gzip"
+)
+
+func TestParseStreamProtobufSuccess(t *testing.T) {
+	req := newTestRequest()
+	resultExpected := `
+http.requests{service.name="app",host="h1",path="/foo",code="200"} 12 1600000000000
+http.requests{service.name="app",host="h1",path="/bar"} 3.5 1600000000000
+temperature{service.name="app",host="h1",sensor="s1"} -3 1600000001000
+latency_count{service.name="app",host="h1"} 10 1600000002000
+latency_sum{service.name="app",host="h1"} 25.5 1600000002000
+latency_bucket{service.name="app",host="h1",le="0.5"} 2 1600000002000
+latency_bucket{service.name="app",host="h1",le="1"} 2 1600000002000
+latency_bucket{service.name="app",host="h1",le="5"} 9 1600000002000
+latency_bucket{service.name="app",host="h1",le="+Inf"} 10 1600000002000
+rpc_count{service.name="app",host="h1",method="get"} 4 1600000003000
+rpc_sum{service.name="app",host="h1",method="get"} 1.25 1600000003000
+rpc{service.name="app",host="h1",method="get",quantile="0.5"} 0.25 1600000003000
+rpc{service.name="app",host="h1",method="get",quantile="0.99"} 0.5 1600000003000
+old_lib_metric{job="old"} 1 1600000004000
+`
+	checkParseStream(t, req, false, false, resultExpected)
+	checkParseStream(t, req, false, true, resultExpected)
+}
+
+func TestParseStreamJSONSuccess(t *testing.T) {
+	data := `{
+  "resourceMetrics": [{
+    "resource": {"attributes": [
+      {"key": "service.name", "value": {"stringValue": "app"}},
+      {"key": "instance", "value": {"intValue": "42"}},
+      {"key": "enabled", "value": {"boolValue": true}},
+      {"key": "ratio", "value": {"doubleValue": 0.5}},
+      {"key": "tags", "value": {"arrayValue": {"values": [{"stringValue": "a"}, {"intValue": 1}]}}},
+      {"key": "empty", "value": {"stringValue": ""}}
+    ]},
+    "scopeMetrics": [{
+      "metrics": [
+        {"name": "requests", "sum": {"dataPoints": [
+          {"attributes": [{"key": "path", "value": {"stringValue": "/foo"}}], "timeUnixNano": "1600000000000000000", "asInt": "12"},
+          {"timeUnixNano": 1600000000000000000, "asDouble": "NaN", "flags": 1},
+          {"timeUnixNano": "1600000000000000000", "asDouble": 1.5}
+        ], "aggregationTemporality": 2, "isMonotonic": true}},
+        {"name": "latency", "histogram": {"dataPoints": [
+          {"timeUnixNano": "1600000001000000000", "count": "3", "sum": 2.5, "bucketCounts": ["1", "2"], "explicitBounds": [1]}
+        ]}},
+        {"name": "rpc", "summary": {"dataPoints": [
+          {"timeUnixNano": "1600000002000000000", "count": "4", "sum": 1.25, "quantileValues": [{"quantile": 0.5, "value": 0.25}]}
+        ]}}
+      ]
+    }]
+  }]
+}`
+	resultExpected := `
+requests{service.name="app",instance="42",enabled="true",ratio="0.5",tags="[\"a\",1]",path="/foo"} 12 1600000000000
+requests{service.name="app",instance="42",enabled="true",ratio="0.5",tags="[\"a\",1]"} 1.5 1600000000000
+latency_count{service.name="app",instance="42",enabled="true",ratio="0.5",tags="[\"a\",1]"} 3 1600000001000
+latency_sum{service.name="app",instance="42",enabled="true",ratio="0.5",tags="[\"a\",1]"} 2.5 1600000001000
+latency_bucket{service.name="app",instance="42",enabled="true",ratio="0.5",tags="[\"a\",1]",le="1"} 1 1600000001000
+latency_bucket{service.name="app",instance="42",enabled="true",ratio="0.5",tags="[\"a\",1]",le="+Inf"} 3 1600000001000
+rpc_count{service.name="app",instance="42",enabled="true",ratio="0.5",tags="[\"a\",1]"} 4 1600000002000
+rpc_sum{service.name="app",instance="42",enabled="true",ratio="0.5",tags="[\"a\",1]"} 1.25 1600000002000
+rpc{service.name="app",instance="42",enabled="true",ratio="0.5",tags="[\"a\",1]",quantile="0.5"} 0.25 1600000002000
+`
+	checkParseStream(t, []byte(data), true, false, resultExpected)
+	checkParseStream(t, []byte(data), true, true, resultExpected)
+}
+
+func TestParseStreamFailure(t *testing.T) {
+	f := func(data []byte, isJSON bool) {
+		t.Helper()
+		err := ParseStream(bytes.NewReader(data), isJSON, false, func(tss []prompbmarshal.TimeSeries) error {
+			t.Fatalf("unexpected callback call")
+			return nil
+		})
+		if err == nil {
+			t.Fatalf("expecting non-nil error")
+		}
+	}
+	req := newTestRequest()
+
+	// Truncated protobuf
+	f(req[:len(req)-1], false)
+	f(req[:5], false)
+
+	// Invalid wire type for resource_metrics
+	f([]byte{0x09, 0, 0, 0, 0, 0, 0, 0, 0}, false)
+
+	// Unsupported wire type
+	f([]byte{0x0b}, false)
+
+	// Invalid JSON
+	f([]byte(`{"resourceMetrics":[`), true)
+	f([]byte(`{"resourceMetrics":[{"scopeMetrics":[{"metrics":[{"name":"foo","gauge":{"dataPoints":[{"asInt":"bar"}]}}]}]}]}`), true)
+}
+
+func TestParseStreamEmpty(t *testing.T) {
+	checkParseStream(t, nil, false, false, "")
+	checkParseStream(t, []byte("{}"), true, false, "")
+}
+
+func checkParseStream(t *testing.T, data []byte, isJSON, isGzipped bool, resultExpected string) {
+	t.Helper()
+	if isGzipped {
+		var bb bytes.Buffer
+		zw := gzip.NewWriter(&bb)
+		if _, err := zw.Write(data); err != nil {
+			t.Fatalf("cannot compress data: %s", err)
+		}
+		if err := zw.Close(); err != nil {
+			t.Fatalf("cannot close gzip writer: %s", err)
+		}
+		data = bb.Bytes()
+	}
+	var lines []string
+	err := ParseStream(bytes.NewReader(data), isJSON, isGzipped, func(tss []prompbmarshal.TimeSeries) error {
+		for _, ts := range tss {
+			lines = append(lines, timeSeriesToString(ts))
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	result := strings.Join(lines, "\n")
+	resultExpected = strings.TrimSpace(resultExpected)
+	if result != resultExpected {
+		t.Fatalf("unexpected result;\ngot\n%s\nwant\n%s", result, resultExpected)
+	}
+}
+
+func timeSeriesToString(ts prompbmarshal.TimeSeries) string {
+	var name string
+	var labels []string
+	for _, label := range ts.Labels {
+		if label.Name == "__name__" {
+			name = label.Value
+			continue
+		}
+		labels = append(labels, fmt.Sprintf("%s=%q", label.Name, label.Value))
+	}
+	var samples []string
+	for _, s := range ts.Samples {
+		samples = append(samples, fmt.Sprintf("%g %d", s.Value, s.Timestamp))
+	}
+	return fmt.Sprintf("%s{%s} %s", name, strings.Join(labels, ","), strings.Join(samples, " "))
+}
+
+// newTestRequest returns protobuf-encoded ExportMetricsServiceRequest for tests.
+func newTestRequest() []byte {
+	resource := appendKeyValueString(nil, 1, "service.name", "app")
+	resource = appendKeyValueString(resource, 1, "host", "h1")
+
+	// Sum with int and double data points. The data point without value must be ignored.
+	var sumMsg []byte
+	dp := appendKeyValueString(nil, 7, "path", "/foo")
+	dp = appendKeyValueString(dp, 7, "code", "200")
+	dp = appendFixed64(dp, 3, 1600000000000000000)
+	dp = appendFixed64(dp, 6, 12)
+	sumMsg = appendMessage(sumMsg, 1, dp)
+	dp = appendKeyValueString(nil, 7, "path", "/bar")
+	dp = appendFixed64(dp, 3, 1600000000000000000)
+	dp = appendFixed64(dp, 4, math.Float64bits(3.5))
+	sumMsg = appendMessage(sumMsg, 1, dp)
+	sumMsg = appendMessage(sumMsg, 1, appendFixed64(nil, 3, 1600000000000000000))
+	sumMsg = appendVarint(sumMsg, 2, 2)
+	sumMsg = appendVarint(sumMsg, 3, 1)
+	m1 := appendBytes(nil, 1, []byte("http.requests"))
+	m1 = appendBytes(m1, 2, []byte("description is ignored"))
+	m1 = appendMessage(m1, 7, sumMsg)
+
+	// Gauge with negative int value.
+	dp = appendKeyValueString(nil, 7, "sensor", "s1")
+	dp = appendFixed64(dp, 3, 1600000001000000000)
+	dp = appendFixed64(dp, 6, uint64(math.MaxUint64-2))
+	m2 := appendBytes(nil, 1, []byte("temperature"))
+	m2 = appendMessage(m2, 5, appendMessage(nil, 1, dp))
+
+	// Histogram with packed bucket_counts and unpacked explicit_bounds.
+	dp = appendFixed64(nil, 3, 1600000002000000000)
+	dp = appendFixed64(dp, 4, 10)
+	dp = appendFixed64(dp, 5, math.Float64bits(25.5))
+	dp = appendPackedFixed64(dp, 6, []uint64{2, 0, 7, 1})
+	dp = appendFixed64(dp, 7, math.Float64bits(0.5))
+	dp = appendFixed64(dp, 7, math.Float64bits(1))
+	dp = appendFixed64(dp, 7, math.Float64bits(5))
+	m3 := appendBytes(nil, 1, []byte("latency"))
+	m3 = appendMessage(m3, 9, appendMessage(nil, 1, dp))
+
+	// Summary
+	dp = appendKeyValueString(nil, 7, "method", "get")
+	dp = appendFixed64(dp, 3, 1600000003000000000)
+	dp = appendFixed64(dp, 4, 4)
+	dp = appendFixed64(dp, 5, math.Float64bits(1.25))
+	q := appendFixed64(nil, 1, math.Float64bits(0.5))
+	q = appendFixed64(q, 2, math.Float64bits(0.25))
+	dp = appendMessage(dp, 6, q)
+	q = appendFixed64(nil, 1, math.Float64bits(0.99))
+	q = appendFixed64(q, 2, math.Float64bits(0.5))
+	dp = appendMessage(dp, 6, q)
+	m4 := appendBytes(nil, 1, []byte("rpc"))
+	m4 = appendMessage(m4, 11, appendMessage(nil, 1, dp))
+
+	scope := appendMessage(nil, 1, appendBytes(nil, 1, []byte("scope is ignored")))
+	scope = appendMessage(scope, 2, m1)
+	scope = appendMessage(scope, 2, m2)
+	scope = appendMessage(scope, 2, m3)
+	scope = appendMessage(scope, 2, m4)
+	rm := appendMessage(nil, 1, resource)
+	rm = appendMessage(rm, 2, scope)
+
+	// ResourceMetrics with deprecated instrumentation_library_metrics.
+	dp = appendFixed64(nil, 3, 1600000004000000000)
+	dp = appendFixed64(dp, 4, math.Float64bits(1))
+	m5 := appendBytes(nil, 1, []byte("old_lib_metric"))
+	m5 = appendMessage(m5, 5, appendMessage(nil, 1, dp))
+	rmOld := appendMessage(nil, 1, appendKeyValueString(nil, 1, "job", "old"))
+	rmOld = appendMessage(rmOld, 1000, appendMessage(nil, 2, m5))
+
+	req := appendMessage(nil, 1, rm)
+	req = appendMessage(req, 1, rmOld)
+	return req
+}
+
+func appendKeyValueString(dst []byte, fieldNum uint64, key, value string) []byte {
+	kv := appendBytes(nil, 1, []byte(key))
+	kv = appendMessage(kv, 2, appendBytes(nil, 1, []byte(value)))
+	return appendMessage(dst, fieldNum, kv)
+}
+
+func appendMessage(dst []byte, fieldNum uint64, msg []byte) []byte {
+	return appendBytes(dst, fieldNum, msg)
+}
+
+func appendBytes(dst []byte, fieldNum uint64, data []byte) []byte {
+	dst = appendUvarint(dst, fieldNum<<3|wireTypeBytes)
+	dst = appendUvarint(dst, uint64(len(data)))
+	return append(dst, data...)
+}
+
+func appendVarint(dst []byte, fieldNum, v uint64) []byte {
+	dst = appendUvarint(dst, fieldNum<<3|wireTypeVarint)
+	return appendUvarint(dst, v)
+}
+
+func appendFixed64(dst []byte, fieldNum, v uint64) []byte {
+	dst = appendUvarint(dst, fieldNum<<3|wireTypeFixed64)
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], v)
+	return append(dst, b[:]...)
+}
+
+func appendPackedFixed64(dst []byte, fieldNum uint64, a []uint64) []byte {
+	var data []byte
+	for _, v := range a {
+		var b [8]byte
+		binary.LittleEndian.PutUint64(b[:], v)
+		data = append(data, b[:]...)
+	}
+	return appendBytes(dst, fieldNum, data)
+}
+
+func appendUvarint(dst []byte, v uint64) []byte {
+	var b [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(b[:], v)
+	return append(dst, b[:n]...)
+}
+
+func TestAnyValueKvlist(t *testing.T) {
+	av := &anyValue{
+		KvlistValue: &keyValueList{
+			Values: []*keyValue{
+				{Key: "b", Value: &anyValue{DoubleValue: func() *jsonFloat64 { f := jsonFloat64(math.Inf(1)); return &f }()}},
+				{Key: "a", Value: &anyValue{BytesValue: []byte("foo")}},
+			},
+		},
+	}
+	result := string(appendAnyValue(nil, av))
+	resultExpected := `{"a":"Zm9v","b":"+Inf"}`
+	if result != resultExpected {
+		t.Fatalf("unexpected result; got %s; want %s", result, resultExpected)
+	}
+}
@@ -2,6 +2,7 @@ package prometheus
 
 import (
 	"fmt"
+	"math"
 	"strconv"
 	"strings"
 
@@ -14,6 +15,9 @@ import (
 type Rows struct {
 	Rows []Row
 
+	// Metadata contains metric metadata parsed from `# HELP`, `# TYPE` and `# UNIT` lines.
+	Metadata []Metadata
+
 	tagsPool []Tag
 }
 
@@ -26,6 +30,11 @@ func (rs *Rows) Reset() {
 	}
 	rs.Rows = rs.Rows[:0]
 
+	for i := range rs.Metadata {
+		rs.Metadata[i].reset()
+	}
+	rs.Metadata = rs.Metadata[:0]
+
 	for i := range rs.tagsPool {
 		rs.tagsPool[i].reset()
 	}
@@ -51,8 +60,31 @@ func stdErrLogger(s string) {
 //
 // s shouldn't be modified while rs is in use.
 func (rs *Rows) UnmarshalWithErrLogger(s string, errLogger func(s string)) {
+	rs.unmarshal(s, false, errLogger)
+}
+
+// UnmarshalOpenMetricsWithErrLogger unmarshals OpenMetrics text rows from s.
+//
+// OpenMetrics format differs from Prometheus exposition format in timestamps, which are expressed in seconds.
+// Lines after `# EOF` are ignored.
+//
+// See https://github.com/OpenObservability/OpenMetrics/blob/main/specification/OpenMetrics.md
+//
+// It calls errLogger for logging parsing errors.
+//
+// s shouldn't be modified while rs is in use.
+func (rs *Rows) UnmarshalOpenMetricsWithErrLogger(s string, errLogger func(s string)) {
+	rs.unmarshal(s, true, errLogger)
+}
+
+func (rs *Rows) unmarshal(s string, isOpenMetrics bool, errLogger func(s string)) {
 	noEscapes := strings.IndexByte(s, '\\') < 0
-	rs.Rows, rs.tagsPool = unmarshalRows(rs.Rows[:0], s, rs.tagsPool[:0], noEscapes, errLogger)
+	rs.Rows, rs.Metadata, rs.tagsPool = unmarshalRows(rs.Rows[:0], rs.Metadata[:0], s, rs.tagsPool[:0], noEscapes, isOpenMetrics, errLogger)
+}
+
+// IsOpenMetricsContentType returns true if contentType is the Content-Type for OpenMetrics text format.
+func IsOpenMetricsContentType(contentType string) bool {
+	return strings.HasPrefix(contentType, "application/openmetrics-text")
 }
 
 // Row is a single Prometheus row.
@@ -70,6 +102,91 @@ func (r *Row) reset() {
 	r.Timestamp = 0
 }
 
+// Metadata is metadata for the metric parsed from `# HELP`, `# TYPE` and `# UNIT` lines.
+type Metadata struct {
+	Metric string
+	Type   string
+	Help   string
+	Unit   string
+}
+
+func (m *Metadata) reset() {
+	m.Metric = ""
+	m.Type = ""
+	m.Help = ""
+	m.Unit = ""
+}
+
+// appendMetadata appends metadata from the comment line s to dst.
+//
+// Comment lines other than `# HELP`, `# TYPE` and `# UNIT` are ignored.
+// Consecutive metadata lines for the same metric are merged into a single entry.
+func appendMetadata(dst []Metadata, s string) []Metadata {
+	s = skipLeadingWhitespace(s[1:])
+	n := nextWhitespace(s)
+	if n < 0 {
+		return dst
+	}
+	kind := s[:n]
+	if kind != "HELP" && kind != "TYPE" && kind != "UNIT" {
+		return dst
+	}
+	s = skipLeadingWhitespace(s[n+1:])
+	metric := s
+	value := ""
+	if n := nextWhitespace(s); n >= 0 {
+		metric = s[:n]
+		value = skipLeadingWhitespace(s[n+1:])
+	}
+	if len(metric) == 0 {
+		return dst
+	}
+	if len(dst) == 0 || dst[len(dst)-1].Metric != metric {
+		if cap(dst) > len(dst) {
+			dst = dst[:len(dst)+1]
+		} else {
+			dst = append(dst, Metadata{})
+		}
+		m := &dst[len(dst)-1]
+		m.reset()
+		m.Metric = metric
+	}
+	m := &dst[len(dst)-1]
+	switch kind {
+	case "HELP":
+		m.Help = unescapeHelp(value)
+	case "TYPE":
+		m.Type = skipTrailingWhitespace(value)
+	case "UNIT":
+		m.Unit = skipTrailingWhitespace(value)
+	}
+	return dst
+}
+
+func unescapeHelp(s string) string {
+	n := strings.IndexByte(s, '\\')
+	if n < 0 {
+		// Fast path - nothing to unescape
+		return s
+	}
+	b := make([]byte, 0, len(s))
+	for n >= 0 && n+1 < len(s) {
+		b = append(b, s[:n]...)
+		switch s[n+1] {
+		case 'n':
+			b = append(b, '\n')
+		case '\\', '"':
+			b = append(b, s[n+1])
+		default:
+			b = append(b, s[n:n+2]...)
+		}
+		s = s[n+2:]
+		n = strings.IndexByte(s, '\\')
+	}
+	b = append(b, s...)
+	return string(b)
+}
+
 func skipLeadingWhitespace(s string) string {
 	// Prometheus treats ' ' and '\t' as whitespace
 	// according to https://github.com/prometheus/docs/blob/master/content/docs/instrumenting/exposition_formats.md#text-format-details
@@ -100,10 +217,14 @@ func nextWhitespace(s string) int {
 	return n1
 }
 
-func (r *Row) unmarshal(s string, tagsPool []Tag, noEscapes bool) ([]Tag, error) {
+func (r *Row) unmarshal(s string, tagsPool []Tag, noEscapes, isOpenMetrics bool) ([]Tag, error) {
 	r.reset()
 	s = skipLeadingWhitespace(s)
 	n := strings.IndexByte(s, '{')
+	if n >= 0 && strings.IndexByte(s[:n], '#') >= 0 {
+		// The '{' belongs to exemplar labels.
+		n = -1
+	}
 	if n >= 0 {
 		// Tags found. Parse them.
 		r.Metric = skipTrailingWhitespace(s[:n])
@@ -132,6 +253,11 @@ func (r *Row) unmarshal(s string, tagsPool []Tag, noEscapes bool) ([]Tag, error)
 	if len(r.Metric) == 0 {
 		return tagsPool, fmt.Errorf("metric cannot be empty")
 	}
+	if n := strings.IndexByte(s, '#'); n >= 0 {
+		// Skip exemplar, since it isn't stored.
+		// See https://github.com/OpenObservability/OpenMetrics/blob/main/specification/OpenMetrics.md#exemplars
+		s = skipTrailingWhitespace(s[:n])
+	}
 	s = skipLeadingWhitespace(s)
 	if len(s) == 0 {
 		return tagsPool, fmt.Errorf("value cannot be empty")
@@ -145,40 +271,56 @@ func (r *Row) unmarshal(s string, tagsPool []Tag, noEscapes bool) ([]Tag, error)
 	// There is timestamp.
 	r.Value = fastfloat.ParseBestEffort(s[:n])
 	s = skipLeadingWhitespace(s[n+1:])
-	r.Timestamp = fastfloat.ParseInt64BestEffort(s)
+	if isOpenMetrics {
+		// OpenMetrics timestamps are in seconds and may contain fractional part.
+		r.Timestamp = int64(math.Round(fastfloat.ParseBestEffort(s) * 1000))
+	} else {
+		r.Timestamp = fastfloat.ParseInt64BestEffort(s)
+	}
 	return tagsPool, nil
 }
 
 var rowsReadScrape = metrics.NewCounter(`vm_protoparser_rows_read_total{type="promscrape"}`)
 
-func unmarshalRows(dst []Row, s string, tagsPool []Tag, noEscapes bool, errLogger func(s string)) ([]Row, []Tag) {
+func unmarshalRows(dst []Row, md []Metadata, s string, tagsPool []Tag, noEscapes, isOpenMetrics bool, errLogger func(s string)) ([]Row, []Metadata, []Tag) {
 	dstLen := len(dst)
 	for len(s) > 0 {
 		n := strings.IndexByte(s, '\n')
-		if n < 0 {
+		line := s
+		if n >= 0 {
+			line = s[:n]
+			s = s[n+1:]
+		} else {
 			// The last line.
-			dst, tagsPool = unmarshalRow(dst, s, tagsPool, noEscapes, errLogger)
+			s = ""
+		}
+		var isEOF bool
+		dst, md, tagsPool, isEOF = unmarshalRow(dst, md, line, tagsPool, noEscapes, isOpenMetrics, errLogger)
+		if isEOF {
 			break
 		}
-		dst, tagsPool = unmarshalRow(dst, s[:n], tagsPool, noEscapes, errLogger)
-		s = s[n+1:]
 	}
 	rowsReadScrape.Add(len(dst) - dstLen)
-	return dst, tagsPool
+	return dst, md, tagsPool
 }
 
-func unmarshalRow(dst []Row, s string, tagsPool []Tag, noEscapes bool, errLogger func(s string)) ([]Row, []Tag) {
+func unmarshalRow(dst []Row, md []Metadata, s string, tagsPool []Tag, noEscapes, isOpenMetrics bool, errLogger func(s string)) ([]Row, []Metadata, []Tag, bool) {
 	if len(s) > 0 && s[len(s)-1] == '\r' {
 		s = s[:len(s)-1]
 	}
 	s = skipLeadingWhitespace(s)
 	if len(s) == 0 {
 		// Skip empty line
-		return dst, tagsPool
+		return dst, md, tagsPool, false
 	}
 	if s[0] == '#' {
-		// Skip comment
-		return dst, tagsPool
+		if isOpenMetrics && skipTrailingWhitespace(s) == "# EOF" {
+			// The end of OpenMetrics exposition.
+			return dst, md, tagsPool, true
+		}
+		// Parse metadata from comment. Other comments are skipped.
+		md = appendMetadata(md, s)
+		return dst, md, tagsPool, false
 	}
 	if cap(dst) > len(dst) {
 		dst = dst[:len(dst)+1]
@@ -187,14 +329,14 @@ func unmarshalRow(dst []Row, s string, tagsPool []Tag, noEscapes bool, errLogger
 	}
 	r := &dst[len(dst)-1]
 	var err error
-	tagsPool, err = r.unmarshal(s, tagsPool, noEscapes)
+	tagsPool, err = r.unmarshal(s, tagsPool, noEscapes, isOpenMetrics)
 	if err != nil {
 		dst = dst[:len(dst)-1]
 		msg := fmt.Sprintf("cannot unmarshal Prometheus line %q: %s", s, err)
 		errLogger(msg)
 		invalidLines.Inc()
 	}
-	return dst, tagsPool
+	return dst, md, tagsPool, false
 }
 
 var invalidLines = metrics.NewCounter(`vm_rows_invalid_total{type="prometheus"}`)
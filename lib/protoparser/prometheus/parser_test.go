@@ -324,3 +324,177 @@ cassandra_token_ownership_ratio 78.9`, &Rows{
 		},
 	})
 }
+
+func TestRowsUnmarshalOpenMetricsSuccess(t *testing.T) {
+	f := func(s string, rowsExpected *Rows) {
+		t.Helper()
+		var rows Rows
+		rows.UnmarshalOpenMetricsWithErrLogger(s, stdErrLogger)
+		if !reflect.DeepEqual(rows.Rows, rowsExpected.Rows) {
+			t.Fatalf("unexpected rows;\ngot\n%+v;\nwant\n%+v", rows.Rows, rowsExpected.Rows)
+		}
+		if !reflect.DeepEqual(rows.Metadata, rowsExpected.Metadata) {
+			t.Fatalf("unexpected metadata;\ngot\n%+v;\nwant\n%+v", rows.Metadata, rowsExpected.Metadata)
+		}
+
+		// Try unmarshaling again
+		rows.UnmarshalOpenMetricsWithErrLogger(s, stdErrLogger)
+		if !reflect.DeepEqual(rows.Rows, rowsExpected.Rows) {
+			t.Fatalf("unexpected rows;\ngot\n%+v;\nwant\n%+v", rows.Rows, rowsExpected.Rows)
+		}
+
+		rows.Reset()
+		if len(rows.Rows) != 0 || len(rows.Metadata) != 0 {
+			t.Fatalf("non-empty rows after reset: %+v", rows)
+		}
+	}
+
+	// Empty exposition
+	f("# EOF\n", &Rows{})
+
+	// Timestamps in seconds
+	f("foo 1 1.5\nbar{x=\"y\"} 2 123\n# EOF\n", &Rows{
+		Rows: []Row{
+			{
+				Metric:    "foo",
+				Value:     1,
+				Timestamp: 1500,
+			},
+			{
+				Metric: "bar",
+				Tags: []Tag{{
+					Key:   "x",
+					Value: "y",
+				}},
+				Value:     2,
+				Timestamp: 123000,
+			},
+		},
+	})
+
+	// Lines after EOF are ignored
+	f("foo 1\n# EOF\nbar 2\n", &Rows{
+		Rows: []Row{{
+			Metric: "foo",
+			Value:  1,
+		}},
+	})
+
+	// Exemplars
+	f(`foo_bucket{le="0.5"} 3 # {trace_id="abc"} 0.3 1.5
+foo_total 4 2 # {trace_id="def"} 1
+foo_count 5 #{} 1
+# EOF
+`, &Rows{
+		Rows: []Row{
+			{
+				Metric: "foo_bucket",
+				Tags: []Tag{{
+					Key:   "le",
+					Value: "0.5",
+				}},
+				Value: 3,
+			},
+			{
+				Metric:    "foo_total",
+				Value:     4,
+				Timestamp: 2000,
+			},
+			{
+				Metric: "foo_count",
+				Value:  5,
+			},
+		},
+	})
+
+	// Metadata and _created series
+	f(`# TYPE foo_seconds counter
+# UNIT foo_seconds seconds
+# HELP foo_seconds Foo \"total\" in seconds.\nSecond line
+foo_seconds_total 12.5
+foo_seconds_created 1.6e9
+# HELP bar Bar help
+# TYPE bar gauge
+bar 3
+# EOF
+`, &Rows{
+		Rows: []Row{
+			{
+				Metric: "foo_seconds_total",
+				Value:  12.5,
+			},
+			{
+				Metric: "foo_seconds_created",
+				Value:  1.6e9,
+			},
+			{
+				Metric: "bar",
+				Value:  3,
+			},
+		},
+		Metadata: []Metadata{
+			{
+				Metric: "foo_seconds",
+				Type:   "counter",
+				Help:   "Foo \"total\" in seconds.\nSecond line",
+				Unit:   "seconds",
+			},
+			{
+				Metric: "bar",
+				Type:   "gauge",
+				Help:   "Bar help",
+			},
+		},
+	})
+}
+
+func TestRowsUnmarshalExemplarsAndMetadata(t *testing.T) {
+	var rows Rows
+	rows.Unmarshal(`# HELP http_requests_total The total number of requests.
+# TYPE http_requests_total counter
+# some comment
+http_requests_total{code="200"} 1027 1395066363000 # {trace_id="KOO5S4vxi0o"} 0.67
+http_requests_total 3 # {} 1
+# EOF
+`)
+	rowsExpected := []Row{
+		{
+			Metric: "http_requests_total",
+			Tags: []Tag{{
+				Key:   "code",
+				Value: "200",
+			}},
+			Value:     1027,
+			Timestamp: 1395066363000,
+		},
+		{
+			Metric: "http_requests_total",
+			Value:  3,
+		},
+	}
+	if !reflect.DeepEqual(rows.Rows, rowsExpected) {
+		t.Fatalf("unexpected rows;\ngot\n%+v;\nwant\n%+v", rows.Rows, rowsExpected)
+	}
+	metadataExpected := []Metadata{{
+		Metric: "http_requests_total",
+		Type:   "counter",
+		Help:   "The total number of requests.",
+	}}
+	if !reflect.DeepEqual(rows.Metadata, metadataExpected) {
+		t.Fatalf("unexpected metadata;\ngot\n%+v;\nwant\n%+v", rows.Metadata, metadataExpected)
+	}
+}
+
+func TestIsOpenMetricsContentType(t *testing.T) {
+	f := func(contentType string, resultExpected bool) {
+		t.Helper()
+		result := IsOpenMetricsContentType(contentType)
+		if result != resultExpected {
+			t.Fatalf("unexpected result for %q; got %v; want %v", contentType, result, resultExpected)
+		}
+	}
+	f("", false)
+	f("text/plain; version=0.0.4", false)
+	f("application/openmetrics-text", true)
+	f("application/openmetrics-text; version=1.0.0; charset=utf-8", true)
+}
@@ -12,12 +12,12 @@ import (
 	"github.com/VictoriaMetrics/metrics"
 )
 
-// ParseStream parses lines with Prometheus exposition format from r and calls callback for the parsed rows.
+// ParseStream parses lines with Prometheus exposition format from r and calls callback for the parsed rows and metadata.
 //
 // The callback can be called multiple times for streamed data from r.
 //
-// callback shouldn't hold rows after returning.
-func ParseStream(r io.Reader, defaultTimestamp int64, isGzipped bool, callback func(rows []Row) error) error {
+// callback shouldn't hold rows and metadata after returning.
+func ParseStream(r io.Reader, defaultTimestamp int64, isGzipped bool, callback func(rows []Row, metadata []Metadata) error) error {
 	if isGzipped {
 		zr, err := common.GetGzipReader(r)
 		if err != nil {
@@ -29,7 +29,7 @@ func ParseStream(r io.Reader, defaultTimestamp int64, isGzipped bool, callback f
 	ctx := getStreamContext()
 	defer putStreamContext(ctx)
 	for ctx.Read(r, defaultTimestamp) {
-		if err := callback(ctx.Rows.Rows); err != nil {
+		if err := callback(ctx.Rows.Rows, ctx.Rows.Metadata); err != nil {
 			return err
 		}
 	}
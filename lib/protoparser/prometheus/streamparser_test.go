@@ -13,7 +13,7 @@ func TestParseStream(t *testing.T) {
 		t.Helper()
 		bb := bytes.NewBufferString(s)
 		var result []Row
-		err := ParseStream(bb, defaultTimestamp, false, func(rows []Row) error {
+		err := ParseStream(bb, defaultTimestamp, false, func(rows []Row, _ []Metadata) error {
 			result = appendRowCopies(result, rows)
 			return nil
 		})
@@ -34,7 +34,7 @@ func TestParseStream(t *testing.T) {
 			t.Fatalf("unexpected error when closing gzip writer: %s", err)
 		}
 		result = nil
-		err = ParseStream(bb, defaultTimestamp, true, func(rows []Row) error {
+		err = ParseStream(bb, defaultTimestamp, true, func(rows []Row, _ []Metadata) error {
 			result = appendRowCopies(result, rows)
 			return nil
 		})
@@ -3,7 +3,6 @@ package promremotewrite
 import (
 	"fmt"
 	"io"
-	"net/http"
 	"runtime"
 	"sync"
 
@@ -16,16 +15,16 @@ import (
 
 var maxInsertRequestSize = flagutil.NewBytes("maxInsertRequestSize", 32*1024*1024, "The maximum size in bytes of a single Prometheus remote_write API request")
 
-// ParseStream parses Prometheus remote_write message req and calls callback for the parsed timeseries.
+// ParseStream parses Prometheus remote_write message from r and calls callback for the parsed timeseries and metadata.
 //
-// callback shouldn't hold timeseries after returning.
-func ParseStream(req *http.Request, callback func(timeseries []prompb.TimeSeries) error) error {
+// callback shouldn't hold timeseries and metadata after returning.
+func ParseStream(r io.Reader, callback func(timeseries []prompb.TimeSeries, metadata []prompb.MetricMetadata) error) error {
 	ctx := getPushCtx()
 	defer putPushCtx(ctx)
-	if err := ctx.Read(req); err != nil {
+	if err := ctx.Read(r); err != nil {
 		return err
 	}
-	return callback(ctx.wr.Timeseries)
+	return callback(ctx.wr.Timeseries, ctx.wr.Metadata)
 }
 
 type pushCtx struct {
@@ -38,10 +37,10 @@ func (ctx *pushCtx) reset() {
 	ctx.reqBuf = ctx.reqBuf[:0]
 }
 
-func (ctx *pushCtx) Read(r *http.Request) error {
+func (ctx *pushCtx) Read(r io.Reader) error {
 	readCalls.Inc()
 	var err error
-	ctx.reqBuf, err = readSnappy(ctx.reqBuf[:0], r.Body)
+	ctx.reqBuf, err = readSnappy(ctx.reqBuf[:0], r)
 	if err != nil {
 		readErrors.Inc()
 		return fmt.Errorf("cannot read prompb.WriteRequest: %w", err)
@@ -3,7 +3,6 @@ package vmimport
 import (
 	"fmt"
 	"io"
-	"net/http"
 	"runtime"
 	"sync"
 
@@ -15,14 +14,13 @@ import (
 
 var maxLineLen = flagutil.NewBytes("import.maxLineLen", 100*1024*1024, "The maximum length in bytes of a single line accepted by /api/v1/import")
 
-// ParseStream parses /api/v1/import lines from req and calls callback for the parsed rows.
+// ParseStream parses /api/v1/import lines from r and calls callback for the parsed rows.
 //
-// The callback can be called multiple times for streamed data from req.
+// The callback can be called multiple times for streamed data from r.
 //
 // callback shouldn't hold rows after returning.
-func ParseStream(req *http.Request, callback func(rows []Row) error) error {
-	r := req.Body
-	if req.Header.Get("Content-Encoding") == "gzip" {
+func ParseStream(r io.Reader, isGzipped bool, callback func(rows []Row) error) error {
+	if isGzipped {
 		zr, err := common.GetGzipReader(r)
 		if err != nil {
 			return fmt.Errorf("cannot read gzipped vmimport data: %w", err)
@@ -0,0 +1,180 @@
+package querytracer
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var denyQueryTracing = flag.Bool("denyQueryTracing", false, "Whether to disable the ability to trace queries via `trace=1` query arg. "+
+	"See https://victoriametrics.github.io/#query-tracing")
+
+// Tracer represents query tracer.
+//
+// It must be created via New call.
+// Each created tracer must be finalized via Done or Donef call.
+//
+// Tracer may contain sub-tracers (branches) in order to build tree-like execution order.
+// Call Tracer.NewChild func for adding sub-tracer.
+//
+// All the Tracer methods may be called on nil Tracer. Such calls are no-op.
+// This allows passing nil Tracer to functions when query tracing is disabled.
+type Tracer struct {
+	// startTime is the time when Tracer was created
+	startTime time.Time
+
+	// doneTime is the time when Done or Donef was called
+	doneTime time.Time
+
+	// message is the message generated by NewChild, Printf or Donef call.
+	message string
+
+	// mu protects children, since they may be added from concurrently running goroutines.
+	mu       sync.Mutex
+	children []*Tracer
+}
+
+// New creates a new instance of the tracer with the given fmt.Sprintf(format, args...) message.
+//
+// If enabled isn't set, then all function calls to the returned object will be no-op.
+//
+// Done or Donef must be called when the tracer should be finished.
+func New(enabled bool, format string, args ...interface{}) *Tracer {
+	if *denyQueryTracing || !enabled {
+		return nil
+	}
+	return &Tracer{
+		message:   fmt.Sprintf(format, args...),
+		startTime: time.Now(),
+	}
+}
+
+// Enabled returns true if the t is enabled.
+func (t *Tracer) Enabled() bool {
+	return t != nil
+}
+
+// NewChild adds a new child Tracer to t with the given fmt.Sprintf(format, args...) message.
+//
+// NewChild may be called from concurrently running goroutines.
+// The returned child must be finished via Done or Donef call.
+//
+// NewChild cannot be called from the finished t.
+func (t *Tracer) NewChild(format string, args ...interface{}) *Tracer {
+	if t == nil {
+		return nil
+	}
+	if !t.doneTime.IsZero() {
+		panic(fmt.Errorf("BUG: NewChild() cannot be called after Donef(%q) call", t.message))
+	}
+	child := &Tracer{
+		message:   fmt.Sprintf(format, args...),
+		startTime: time.Now(),
+	}
+	t.mu.Lock()
+	t.children = append(t.children, child)
+	t.mu.Unlock()
+	return child
+}
+
+// Done finishes t.
+//
+// Done cannot be called multiple times.
+// Other Tracer functions cannot be called after Done call.
+func (t *Tracer) Done() {
+	if t == nil {
+		return
+	}
+	if !t.doneTime.IsZero() {
+		panic(fmt.Errorf("BUG: Done() cannot be called after Donef(%q) call", t.message))
+	}
+	t.doneTime = time.Now()
+}
+
+// Donef appends the given fmt.Sprintf(format, args..) message to t and finished it.
+//
+// Donef cannot be called multiple times.
+// Other Tracer functions cannot be called after Donef call.
+func (t *Tracer) Donef(format string, args ...interface{}) {
+	if t == nil {
+		return
+	}
+	if !t.doneTime.IsZero() {
+		panic(fmt.Errorf("BUG: Donef() cannot be called after Donef(%q) call", t.message))
+	}
+	t.message += ": " + fmt.Sprintf(format, args...)
+	t.doneTime = time.Now()
+}
+
+// Printf adds new fmt.Sprintf(format, args...) message to t.
+//
+// Printf may be called from concurrently running goroutines.
+// Printf cannot be called from the finished t.
+func (t *Tracer) Printf(format string, args ...interface{}) {
+	if t == nil {
+		return
+	}
+	if !t.doneTime.IsZero() {
+		panic(fmt.Errorf("BUG: Printf() cannot be called after Done(%q) call", t.message))
+	}
+	now := time.Now()
+	child := &Tracer{
+		startTime: now,
+		doneTime:  now,
+		message:   fmt.Sprintf(format, args...),
+	}
+	t.mu.Lock()
+	t.children = append(t.children, child)
+	t.mu.Unlock()
+}
+
+// ToJSON returns JSON representation of t.
+//
+// Each JSON object contains the following fields:
+//
+//   - duration_msec - the duration of the traced step in milliseconds
+//   - message - the message for the traced step
+//   - children - an optional list of children traced steps
+//
+// ToJSON must be called after t is finished.
+func (t *Tracer) ToJSON() string {
+	if t == nil {
+		return ""
+	}
+	var bb bytes.Buffer
+	t.writeJSON(&bb)
+	return bb.String()
+}
+
+func (t *Tracer) writeJSON(bb *bytes.Buffer) {
+	doneTime := t.doneTime
+	if doneTime.IsZero() {
+		// The tracer hasn't been finished, e.g. because of error in the traced step.
+		// Use the current time for calculating its duration.
+		doneTime = time.Now()
+	}
+	d := doneTime.Sub(t.startTime)
+	bb.WriteString(`{"duration_msec":`)
+	bb.WriteString(strconv.FormatFloat(float64(d)/float64(time.Millisecond), 'f', -1, 64))
+	bb.WriteString(`,"message":`)
+	msg, _ := json.Marshal(t.message)
+	bb.Write(msg)
+	t.mu.Lock()
+	children := t.children
+	t.mu.Unlock()
+	if len(children) > 0 {
+		bb.WriteString(`,"children":[`)
+		for i, child := range children {
+			child.writeJSON(bb)
+			if i+1 < len(children) {
+				bb.WriteString(`,`)
+			}
+		}
+		bb.WriteString(`]`)
+	}
+	bb.WriteString(`}`)
+}
@@ -0,0 +1,89 @@
+package querytracer
+
+import (
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func TestTracerDisabled(t *testing.T) {
+	qt := New(false, "test")
+	if qt.Enabled() {
+		t.Fatalf("query tracer must be disabled")
+	}
+	qtChild := qt.NewChild("child done %d", 456)
+	if qtChild.Enabled() {
+		t.Fatalf("query tracer must be disabled")
+	}
+	qtChild.Printf("foo %d", 123)
+	qtChild.Donef("child done %d", 456)
+	qt.Printf("parent %d", 789)
+	qt.Done()
+	if s := qt.ToJSON(); s != "" {
+		t.Fatalf("unexpected JSON for disabled tracer; got %q; want empty string", s)
+	}
+}
+
+func TestTracerEnabled(t *testing.T) {
+	qt := New(true, "test")
+	if !qt.Enabled() {
+		t.Fatalf("query tracer must be enabled")
+	}
+	qtChild := qt.NewChild("child %q", "foo")
+	qtChild.Printf("foo %d", 123)
+	qtChild.Donef("done %d", 456)
+	qt.Printf("parent %d", 789)
+	qt.Donef("series=%d", 10)
+
+	s := qt.ToJSON()
+	var v traceJSON
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		t.Fatalf("cannot unmarshal trace %q: %s", s, err)
+	}
+	vExpected := traceJSON{
+		Message: "test: series=10",
+		Children: []traceJSON{
+			{
+				Message: `child "foo": done 456`,
+				Children: []traceJSON{
+					{
+						Message: "foo 123",
+					},
+				},
+			},
+			{
+				Message: "parent 789",
+			},
+		},
+	}
+	if !reflect.DeepEqual(v, vExpected) {
+		t.Fatalf("unexpected trace\ngot\n%+v\nwant\n%+v", v, vExpected)
+	}
+	if !regexp.MustCompile(`^{"duration_msec":[0-9.e-]+,"message":"test: series=10","children":\[`).MatchString(s) {
+		t.Fatalf("unexpected JSON format: %s", s)
+	}
+}
+
+func TestTracerNotFinished(t *testing.T) {
+	qt := New(true, "test")
+	qtChild := qt.NewChild("child")
+	qtChild.Printf("foo")
+	qt.Done()
+
+	// The trace must be valid JSON even if some children aren't finished.
+	s := qt.ToJSON()
+	var v traceJSON
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		t.Fatalf("cannot unmarshal trace %q: %s", s, err)
+	}
+	if len(v.Children) != 1 || v.Children[0].Message != "child" {
+		t.Fatalf("unexpected children: %+v", v.Children)
+	}
+}
+
+type traceJSON struct {
+	DurationMsec float64     `json:"-"`
+	Message      string      `json:"message"`
+	Children     []traceJSON `json:"children"`
+}
@@ -1,6 +1,8 @@
 package storage
 
 import (
+	"fmt"
+	"math"
 	"sync"
 	"sync/atomic"
 
@@ -169,11 +171,17 @@ func (b *Block) deduplicateSamplesDuringMerge() {
 	timestamps, values := deduplicateSamplesDuringMerge(srcTimestamps, srcValues)
 	dedups := len(srcTimestamps) - len(timestamps)
 	atomic.AddUint64(&dedupsDuringMerge, uint64(dedups))
+	n := len(timestamps)
+	timestamps, values = downsampleSamplesDuringMerge(timestamps, values)
+	atomic.AddUint64(&downsampledRowsDuringMerge, uint64(n-len(timestamps)))
 	b.timestamps = b.timestamps[:b.nextIdx+len(timestamps)]
 	b.values = b.values[:b.nextIdx+len(values)]
 }
 
-var dedupsDuringMerge uint64
+var (
+	dedupsDuringMerge          uint64
+	downsampledRowsDuringMerge uint64
+)
 
 func (b *Block) rowsCount() int {
 	if len(b.values) == 0 {
@@ -288,3 +296,100 @@ func (b *Block) UnmarshalData() error {
 
 	return nil
 }
+
+// MarshalPortable marshals b to dst, so it could be portably migrated to other VictoriaMetrics instance.
+//
+// The marshaled value must be unmarshaled with UnmarshalPortable function.
+func (b *Block) MarshalPortable(dst []byte) []byte {
+	b.MarshalData(0, 0)
+
+	dst = encoding.MarshalVarInt64(dst, b.bh.MinTimestamp)
+	dst = encoding.MarshalVarInt64(dst, b.bh.MaxTimestamp)
+	dst = encoding.MarshalVarInt64(dst, b.bh.FirstValue)
+	dst = encoding.MarshalVarUint64(dst, uint64(b.bh.RowsCount))
+	dst = encoding.MarshalVarInt64(dst, int64(b.bh.Scale))
+	dst = append(dst, byte(b.bh.TimestampsMarshalType), byte(b.bh.ValuesMarshalType), b.bh.PrecisionBits)
+	dst = encoding.MarshalBytes(dst, b.timestampsData)
+	dst = encoding.MarshalBytes(dst, b.valuesData)
+
+	return dst
+}
+
+// UnmarshalPortable unmarshals block from src to b and returns the remaining tail.
+//
+// It is assumed that the block has been marshaled with MarshalPortable.
+// Call b.UnmarshalData after UnmarshalPortable in order to access timestamps and values.
+func (b *Block) UnmarshalPortable(src []byte) ([]byte, error) {
+	b.Reset()
+
+	// Read header
+	src, firstTimestamp, err := encoding.UnmarshalVarInt64(src)
+	if err != nil {
+		return src, fmt.Errorf("cannot unmarshal firstTimestamp: %w", err)
+	}
+	b.bh.MinTimestamp = firstTimestamp
+	src, lastTimestamp, err := encoding.UnmarshalVarInt64(src)
+	if err != nil {
+		return src, fmt.Errorf("cannot unmarshal lastTimestamp: %w", err)
+	}
+	b.bh.MaxTimestamp = lastTimestamp
+	src, firstValue, err := encoding.UnmarshalVarInt64(src)
+	if err != nil {
+		return src, fmt.Errorf("cannot unmarshal firstValue: %w", err)
+	}
+	b.bh.FirstValue = firstValue
+	src, rowsCount, err := encoding.UnmarshalVarUint64(src)
+	if err != nil {
+		return src, fmt.Errorf("cannot unmarshal rowsCount: %w", err)
+	}
+	if rowsCount == 0 {
+		return src, fmt.Errorf("rowsCount cannot be zero")
+	}
+	if rowsCount > math.MaxUint32 {
+		return src, fmt.Errorf("too big rowsCount=%d; it exceeds %d", rowsCount, uint32(math.MaxUint32))
+	}
+	b.bh.RowsCount = uint32(rowsCount)
+	src, scale, err := encoding.UnmarshalVarInt64(src)
+	if err != nil {
+		return src, fmt.Errorf("cannot unmarshal scale: %w", err)
+	}
+	if scale < math.MinInt16 {
+		return src, fmt.Errorf("too small scale=%d; it mustn't be smaller than %d", scale, math.MinInt16)
+	}
+	if scale > math.MaxInt16 {
+		return src, fmt.Errorf("too big scale=%d; it mustn't exceed %d", scale, math.MaxInt16)
+	}
+	b.bh.Scale = int16(scale)
+	if len(src) < 3 {
+		return src, fmt.Errorf("cannot unmarshal marshal types and precisionBits from %d bytes; need at least 3 bytes", len(src))
+	}
+	b.bh.TimestampsMarshalType = encoding.MarshalType(src[0])
+	if err := encoding.CheckMarshalType(b.bh.TimestampsMarshalType); err != nil {
+		return src, fmt.Errorf("unsupported TimestampsMarshalType: %w", err)
+	}
+	b.bh.ValuesMarshalType = encoding.MarshalType(src[1])
+	if err := encoding.CheckMarshalType(b.bh.ValuesMarshalType); err != nil {
+		return src, fmt.Errorf("unsupported ValuesMarshalType: %w", err)
+	}
+	b.bh.PrecisionBits = src[2]
+	if err := encoding.CheckPrecisionBits(b.bh.PrecisionBits); err != nil {
+		return src, err
+	}
+	src = src[3:]
+
+	// Read data
+	src, timestampsData, err := encoding.UnmarshalBytes(src)
+	if err != nil {
+		return src, fmt.Errorf("cannot read timestampsData: %w", err)
+	}
+	b.timestampsData = append(b.timestampsData[:0], timestampsData...)
+	b.bh.TimestampsBlockSize = uint32(len(timestampsData))
+	src, valuesData, err := encoding.UnmarshalBytes(src)
+	if err != nil {
+		return src, fmt.Errorf("cannot read valuesData: %w", err)
+	}
+	b.valuesData = append(b.valuesData[:0], valuesData...)
+	b.bh.ValuesBlockSize = uint32(len(valuesData))
+
+	return src, nil
+}
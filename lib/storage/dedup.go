@@ -51,13 +51,18 @@ func deduplicateSamplesDuringMerge(srcTimestamps, srcValues []int64) ([]int64, [
 	if minScrapeInterval <= 0 {
 		return srcTimestamps, srcValues
 	}
-	if !needsDedup(srcTimestamps, minScrapeInterval) {
+	return deduplicateSamplesDuringMergeInternal(srcTimestamps, srcValues, minScrapeInterval)
+}
+
+// deduplicateSamplesDuringMergeInternal leaves a single sample per each interval in src*.
+func deduplicateSamplesDuringMergeInternal(srcTimestamps, srcValues []int64, interval int64) ([]int64, []int64) {
+	if !needsDedup(srcTimestamps, interval) {
 		// Fast path - nothing to deduplicate
 		return srcTimestamps, srcValues
 	}
 
 	// Slow path - dedup data points.
-	tsNext := (srcTimestamps[0] - srcTimestamps[0]%minScrapeInterval) + minScrapeInterval
+	tsNext := (srcTimestamps[0] - srcTimestamps[0]%interval) + interval
 	dstTimestamps := srcTimestamps[:1]
 	dstValues := srcValues[:1]
 	for i := 1; i < len(srcTimestamps); i++ {
@@ -69,10 +74,10 @@ func deduplicateSamplesDuringMerge(srcTimestamps, srcValues []int64) ([]int64, [
 		dstValues = append(dstValues, srcValues[i])
 
 		// Update tsNext
-		tsNext += minScrapeInterval
+		tsNext += interval
 		if ts >= tsNext {
 			// Slow path for updating ts.
-			tsNext = (ts - ts%minScrapeInterval) + minScrapeInterval
+			tsNext = (ts - ts%interval) + interval
 		}
 	}
 	return dstTimestamps, dstValues
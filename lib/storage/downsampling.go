@@ -0,0 +1,197 @@
+package storage
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/bytesutil"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/encoding"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/fasttime"
+)
+
+// DownsamplingPeriod instructs leaving a single sample per Interval for samples older than Offset.
+type DownsamplingPeriod struct {
+	// Offset is the minimum age of samples for downsampling.
+	Offset time.Duration
+
+	// Interval is the interval between samples after downsampling.
+	Interval time.Duration
+}
+
+// String returns string representation of dp.
+func (dp *DownsamplingPeriod) String() string {
+	return fmt.Sprintf("%s:%s", dp.Offset, dp.Interval)
+}
+
+type downsamplingPeriod struct {
+	offsetMsecs   int64
+	intervalMsecs int64
+}
+
+// downsamplingPeriods contains downsampling periods sorted by offsetMsecs in descending order.
+var downsamplingPeriods []downsamplingPeriod
+
+// SetDownsamplingPeriods sets downsampling periods for the stored data.
+//
+// Samples older than the given Offset are downsampled to a single sample per the given Interval during background merges.
+// Downsampling is disabled if dps is empty.
+//
+// This function must be called before initializing the storage.
+func SetDownsamplingPeriods(dps []DownsamplingPeriod) error {
+	dst := make([]downsamplingPeriod, 0, len(dps))
+	for i := range dps {
+		dp := &dps[i]
+		if dp.Offset <= 0 {
+			return fmt.Errorf("offset must be positive in downsampling period %s", dp)
+		}
+		if dp.Interval <= 0 {
+			return fmt.Errorf("interval must be positive in downsampling period %s", dp)
+		}
+		dst = append(dst, downsamplingPeriod{
+			offsetMsecs:   dp.Offset.Milliseconds(),
+			intervalMsecs: dp.Interval.Milliseconds(),
+		})
+	}
+	sort.Slice(dst, func(i, j int) bool {
+		return dst[i].offsetMsecs > dst[j].offsetMsecs
+	})
+	for i := 1; i < len(dst); i++ {
+		if dst[i].offsetMsecs == dst[i-1].offsetMsecs {
+			return fmt.Errorf("duplicate downsampling offset %s", time.Duration(dst[i].offsetMsecs)*time.Millisecond)
+		}
+		if dst[i].intervalMsecs > dst[i-1].intervalMsecs {
+			return fmt.Errorf("downsampling interval for offset %s cannot exceed downsampling interval for bigger offset %s",
+				time.Duration(dst[i].offsetMsecs)*time.Millisecond, time.Duration(dst[i-1].offsetMsecs)*time.Millisecond)
+		}
+	}
+	downsamplingPeriods = dst
+	return nil
+}
+
+// needsDownsampling returns true if the block with the given bh may contain samples, which must be downsampled.
+//
+// Blocks with samples older than the downsampling offset are already downsampled if they contain no more than
+// a single sample per each downsampling interval. Such blocks are skipped, so they could be copied
+// without unmarshaling during merges.
+func needsDownsampling(bh *blockHeader) bool {
+	if len(downsamplingPeriods) == 0 || bh.RowsCount <= 1 {
+		return false
+	}
+	now := int64(fasttime.UnixTimestamp()) * 1000
+	minOffsetMsecs := downsamplingPeriods[len(downsamplingPeriods)-1].offsetMsecs
+	if bh.MinTimestamp >= now-minOffsetMsecs {
+		// All the samples are too young for downsampling.
+		return false
+	}
+	if bh.MaxTimestamp >= now-minOffsetMsecs {
+		// The block contains samples, which aren't downsampled yet, so it is impossible to determine
+		// whether the rest of samples are downsampled.
+		return true
+	}
+
+	// Calculate the maximum number of samples in the downsampled block.
+	// The samples older than now-offsetMsecs for each period are downsampled with the period interval.
+	maxRowsCount := int64(0)
+	regionStart := int64(math.MinInt64)
+	for _, dp := range downsamplingPeriods {
+		regionEnd := now - dp.offsetMsecs
+		minTimestamp := bh.MinTimestamp
+		if minTimestamp < regionStart {
+			minTimestamp = regionStart
+		}
+		maxTimestamp := bh.MaxTimestamp
+		if maxTimestamp >= regionEnd {
+			maxTimestamp = regionEnd - 1
+		}
+		if minTimestamp <= maxTimestamp {
+			// Downsampling leaves a single sample per each interval aligned to the interval.
+			maxRowsCount += maxTimestamp/dp.intervalMsecs - minTimestamp/dp.intervalMsecs + 1
+		}
+		regionStart = regionEnd
+	}
+	return int64(bh.RowsCount) > maxRowsCount
+}
+
+// getPassedDownsamplingPeriods returns the number of downsampling periods, which offsets are passed
+// by all the samples on the [minTimestamp ... maxTimestamp] time range at the given time now.
+//
+// False is returned if the offset of some downsampling period is inside the time range,
+// i.e. only a part of samples on the time range passed it.
+func getPassedDownsamplingPeriods(minTimestamp, maxTimestamp, now int64) (int, bool) {
+	n := 0
+	for _, dp := range downsamplingPeriods {
+		offsetTimestamp := now - dp.offsetMsecs
+		if maxTimestamp < offsetTimestamp {
+			n++
+			continue
+		}
+		if minTimestamp < offsetTimestamp {
+			return 0, false
+		}
+	}
+	return n, true
+}
+
+// partNeedsDownsampling returns true if p contains blocks, which must be downsampled.
+func partNeedsDownsampling(p *part) (bool, error) {
+	var compressedIndexBuf, indexBuf []byte
+	var bhs []blockHeader
+	for i := range p.metaindex {
+		mr := &p.metaindex[i]
+		compressedIndexBuf = bytesutil.Resize(compressedIndexBuf[:0], int(mr.IndexBlockSize))
+		p.indexFile.MustReadAt(compressedIndexBuf, int64(mr.IndexBlockOffset))
+		var err error
+		indexBuf, err = encoding.DecompressZSTD(indexBuf[:0], compressedIndexBuf)
+		if err != nil {
+			return false, fmt.Errorf("cannot decompress index block from part %q: %w", p.path, err)
+		}
+		bhs, err = unmarshalBlockHeaders(bhs[:0], indexBuf, int(mr.BlockHeadersCount))
+		if err != nil {
+			return false, fmt.Errorf("cannot unmarshal index block from part %q: %w", p.path, err)
+		}
+		for j := range bhs {
+			if needsDownsampling(&bhs[j]) {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// downsampleSamplesDuringMerge leaves a single sample per downsampling interval for samples older than the downsampling offset.
+//
+// srcTimestamps must be sorted in ascending order.
+func downsampleSamplesDuringMerge(srcTimestamps, srcValues []int64) ([]int64, []int64) {
+	if len(downsamplingPeriods) == 0 || len(srcTimestamps) == 0 {
+		return srcTimestamps, srcValues
+	}
+	now := int64(fasttime.UnixTimestamp()) * 1000
+	if srcTimestamps[0] >= now-downsamplingPeriods[len(downsamplingPeriods)-1].offsetMsecs {
+		// Fast path - all the samples are too young for downsampling.
+		return srcTimestamps, srcValues
+	}
+
+	// Slow path - downsample samples for each period.
+	// Samples are processed in place, since the number of samples can only decrease.
+	dstTimestamps := srcTimestamps[:0]
+	dstValues := srcValues[:0]
+	i := 0
+	for _, dp := range downsamplingPeriods {
+		maxTimestamp := now - dp.offsetMsecs
+		n := i
+		for n < len(srcTimestamps) && srcTimestamps[n] < maxTimestamp {
+			n++
+		}
+		if n > i {
+			timestamps, values := deduplicateSamplesDuringMergeInternal(srcTimestamps[i:n], srcValues[i:n], dp.intervalMsecs)
+			dstTimestamps = append(dstTimestamps, timestamps...)
+			dstValues = append(dstValues, values...)
+		}
+		i = n
+	}
+	dstTimestamps = append(dstTimestamps, srcTimestamps[i:]...)
+	dstValues = append(dstValues, srcValues[i:]...)
+	return dstTimestamps, dstValues
+}
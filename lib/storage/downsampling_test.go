@@ -0,0 +1,301 @@
+package storage
+
+import (
+	"os"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSetDownsamplingPeriodsFailure(t *testing.T) {
+	// Disable downsampling before exit, since the rest of tests expect disabled downsampling.
+	defer func() {
+		if err := SetDownsamplingPeriods(nil); err != nil {
+			t.Fatalf("cannot disable downsampling: %s", err)
+		}
+	}()
+
+	f := func(dps []DownsamplingPeriod) {
+		t.Helper()
+		if err := SetDownsamplingPeriods(dps); err == nil {
+			t.Fatalf("expecting non-nil error for %s", dps)
+		}
+	}
+	// Zero offset
+	f([]DownsamplingPeriod{{Interval: time.Minute}})
+
+	// Zero interval
+	f([]DownsamplingPeriod{{Offset: time.Hour}})
+
+	// Duplicate offsets
+	f([]DownsamplingPeriod{
+		{Offset: time.Hour, Interval: time.Minute},
+		{Offset: time.Hour, Interval: time.Second},
+	})
+
+	// Bigger interval for smaller offset
+	f([]DownsamplingPeriod{
+		{Offset: time.Hour, Interval: time.Hour},
+		{Offset: 2 * time.Hour, Interval: time.Minute},
+	})
+}
+
+func TestDownsampleSamplesDuringMerge(t *testing.T) {
+	// Disable downsampling before exit, since the rest of tests expect disabled downsampling.
+	defer func() {
+		if err := SetDownsamplingPeriods(nil); err != nil {
+			t.Fatalf("cannot disable downsampling: %s", err)
+		}
+	}()
+
+	// Align now to hour, so the expected results do not depend on the current time.
+	// The current time may exceed now by up to an hour, so the test timestamps are chosen
+	// at least an hour away from downsampling offsets.
+	now := timestampFromTime(time.Now())
+	now -= now % msecPerHour
+
+	f := func(dps []DownsamplingPeriod, timestamps, timestampsExpected []int64) {
+		t.Helper()
+		if err := SetDownsamplingPeriods(dps); err != nil {
+			t.Fatalf("cannot set downsampling periods: %s", err)
+		}
+		timestampsCopy := make([]int64, len(timestamps))
+		values := make([]int64, len(timestamps))
+		for i, ts := range timestamps {
+			timestampsCopy[i] = now - ts
+			values[i] = int64(i)
+		}
+		timestampsCopy, values = downsampleSamplesDuringMerge(timestampsCopy, values)
+		for i := range timestampsCopy {
+			timestampsCopy[i] = now - timestampsCopy[i]
+		}
+		if !reflect.DeepEqual(timestampsCopy, timestampsExpected) {
+			t.Fatalf("invalid downsampleSamplesDuringMerge(%v) result;\ngot\n%v\nwant\n%v", timestamps, timestampsCopy, timestampsExpected)
+		}
+		// Verify values
+		j := 0
+		for i, ts := range timestamps {
+			if j >= len(timestampsCopy) || ts != timestampsCopy[j] {
+				continue
+			}
+			if values[j] != int64(i) {
+				t.Fatalf("unexpected value at index %d; got %v; want %v; values: %v", j, values[j], i, values)
+			}
+			j++
+		}
+	}
+
+	// Timestamps are passed as offsets from now in descending order, i.e. in ascending time order.
+	const m = 60 * 1000
+	const h = msecPerHour
+
+	// Disabled downsampling
+	f(nil, []int64{10 * m, 9 * m, 8 * m}, []int64{10 * m, 9 * m, 8 * m})
+
+	// Empty samples
+	f([]DownsamplingPeriod{{Offset: time.Hour, Interval: 5 * time.Minute}}, []int64{}, []int64{})
+
+	// All the samples are too young for downsampling
+	f([]DownsamplingPeriod{{Offset: 2 * time.Hour, Interval: 5 * time.Minute}},
+		[]int64{10 * m, 9 * m, 8 * m}, []int64{10 * m, 9 * m, 8 * m})
+
+	// Single downsampling period
+	f([]DownsamplingPeriod{{Offset: 2 * time.Hour, Interval: 5 * time.Minute}},
+		[]int64{2*h + 10*m, 2*h + 9*m, 2*h + 8*m, 2*h + 5*m, 2*h + 4*m, 2*h + 1*m, 59 * m, 58 * m},
+		[]int64{2*h + 10*m, 2*h + 5*m, 59 * m, 58 * m})
+
+	// Multiple downsampling periods
+	f([]DownsamplingPeriod{
+		{Offset: 2 * time.Hour, Interval: 5 * time.Minute},
+		{Offset: 5 * time.Hour, Interval: time.Hour},
+	},
+		[]int64{6*h + 30*m, 6*h + 10*m, 5*h + 50*m, 5*h + 20*m, 3*h + 30*m, 3*h + 29*m, 3*h + 20*m, 30 * m, 29 * m},
+		[]int64{6*h + 30*m, 5*h + 50*m, 3*h + 30*m, 3*h + 20*m, 30 * m, 29 * m})
+}
+
+func TestNeedsDownsampling(t *testing.T) {
+	// Disable downsampling before exit, since the rest of tests expect disabled downsampling.
+	defer func() {
+		if err := SetDownsamplingPeriods(nil); err != nil {
+			t.Fatalf("cannot disable downsampling: %s", err)
+		}
+	}()
+
+	// Align now to hour in the same way as TestDownsampleSamplesDuringMerge does.
+	now := timestampFromTime(time.Now())
+	now -= now % msecPerHour
+
+	f := func(dps []DownsamplingPeriod, minTimestamp, maxTimestamp int64, rowsCount uint32, resultExpected bool) {
+		t.Helper()
+		if err := SetDownsamplingPeriods(dps); err != nil {
+			t.Fatalf("cannot set downsampling periods: %s", err)
+		}
+		bh := &blockHeader{
+			MinTimestamp: now - minTimestamp,
+			MaxTimestamp: now - maxTimestamp,
+			RowsCount:    rowsCount,
+		}
+		if result := needsDownsampling(bh); result != resultExpected {
+			t.Fatalf("unexpected needsDownsampling(minTimestamp=now-%d, maxTimestamp=now-%d, rowsCount=%d); got %v; want %v",
+				minTimestamp, maxTimestamp, rowsCount, result, resultExpected)
+		}
+	}
+
+	// Block boundaries are passed as offsets from now.
+	const m = 60 * 1000
+	const h = msecPerHour
+	dps := []DownsamplingPeriod{
+		{Offset: 2 * time.Hour, Interval: 5 * time.Minute},
+		{Offset: 5 * time.Hour, Interval: time.Hour},
+	}
+
+	// Disabled downsampling
+	f(nil, 10*h, 9*h, 1000, false)
+
+	// Single row
+	f(dps, 10*h, 9*h, 1, false)
+
+	// Too young block
+	f(dps, 50*m, 10*m, 100, false)
+
+	// The block contains both old and young samples
+	f(dps, 3*h, 30*m, 2, true)
+
+	// Raw samples in a single period
+	f(dps, 3*h, 2*h+30*m, 60, true)
+	f(dps, 3*h, 2*h+30*m, 8, true)
+
+	// Already downsampled samples in a single period
+	f(dps, 3*h, 2*h+30*m, 7, false)
+
+	// The boundary between periods depends on the current time, which may exceed now by up to an hour.
+	// So the block contains 3-4 hourly samples and 13-25 samples with 5 minutes interval after downsampling.
+
+	// Raw samples in multiple periods
+	f(dps, 8*h, 3*h, 300, true)
+
+	// Samples downsampled only for the smaller offset must be downsampled for the bigger offset
+	f(dps, 8*h, 3*h, 5*12+1, true)
+
+	// Already downsampled samples in multiple periods
+	f(dps, 8*h, 3*h, 3+13, false)
+}
+
+func TestGetPassedDownsamplingPeriods(t *testing.T) {
+	// Disable downsampling before exit, since the rest of tests expect disabled downsampling.
+	defer func() {
+		if err := SetDownsamplingPeriods(nil); err != nil {
+			t.Fatalf("cannot disable downsampling: %s", err)
+		}
+	}()
+
+	const now = 100 * msecPerHour
+	const h = msecPerHour
+	f := func(dps []DownsamplingPeriod, minTimestamp, maxTimestamp int64, nExpected int, okExpected bool) {
+		t.Helper()
+		if err := SetDownsamplingPeriods(dps); err != nil {
+			t.Fatalf("cannot set downsampling periods: %s", err)
+		}
+		n, ok := getPassedDownsamplingPeriods(now-minTimestamp, now-maxTimestamp, now)
+		if n != nExpected || ok != okExpected {
+			t.Fatalf("unexpected getPassedDownsamplingPeriods(minTimestamp=now-%d, maxTimestamp=now-%d); got (%d, %v); want (%d, %v)",
+				minTimestamp, maxTimestamp, n, ok, nExpected, okExpected)
+		}
+	}
+	dps := []DownsamplingPeriod{
+		{Offset: 2 * time.Hour, Interval: 5 * time.Minute},
+		{Offset: 5 * time.Hour, Interval: time.Hour},
+	}
+
+	// Disabled downsampling
+	f(nil, 10*h, 9*h, 0, true)
+
+	// Too young samples
+	f(dps, 2*h, h, 0, true)
+
+	// Samples passed the smaller offset
+	f(dps, 4*h, 3*h, 1, true)
+
+	// Samples passed both offsets
+	f(dps, 7*h, 6*h, 2, true)
+
+	// Samples on both sides of the offset
+	f(dps, 3*h, h, 0, false)
+	f(dps, 6*h, 3*h, 0, false)
+}
+
+func TestStorageDownsamplingWithoutIngestion(t *testing.T) {
+	path := "TestStorageDownsamplingWithoutIngestion"
+	defer func() {
+		if err := SetDownsamplingPeriods(nil); err != nil {
+			t.Fatalf("cannot disable downsampling: %s", err)
+		}
+		if err := os.RemoveAll(path); err != nil {
+			t.Fatalf("cannot remove storage %q: %s", path, err)
+		}
+	}()
+
+	// Store samples with 10 seconds interval for an hour while downsampling is disabled.
+	const rowsCount = 360
+	mn := MetricName{
+		MetricGroup: []byte("metric"),
+	}
+	metricNameRaw := mn.marshalRaw(nil)
+	startTimestamp := timestampFromTime(time.Now()) - 3*msecPerHour
+	var mrs []MetricRow
+	for i := 0; i < rowsCount; i++ {
+		mrs = append(mrs, MetricRow{
+			MetricNameRaw: metricNameRaw,
+			Timestamp:     startTimestamp + int64(i)*10*1000,
+			Value:         float64(i),
+		})
+	}
+	s, err := OpenStorage(path, 0, 0, 0)
+	if err != nil {
+		t.Fatalf("cannot open storage: %s", err)
+	}
+	if err := s.AddRows(mrs, defaultPrecisionBits); err != nil {
+		t.Fatalf("cannot add rows: %s", err)
+	}
+	s.MustClose()
+
+	// Enable downsampling for the stored samples and verify they are downsampled without new ingestion.
+	if err := SetDownsamplingPeriods([]DownsamplingPeriod{{Offset: time.Hour, Interval: time.Minute}}); err != nil {
+		t.Fatalf("cannot set downsampling periods: %s", err)
+	}
+	s, err = OpenStorage(path, 0, 0, 0)
+	if err != nil {
+		t.Fatalf("cannot re-open storage: %s", err)
+	}
+	defer s.MustClose()
+
+	getRowsCount := func() uint64 {
+		var m Metrics
+		s.UpdateMetrics(&m)
+		return m.TableMetrics.SmallRowsCount + m.TableMetrics.BigRowsCount
+	}
+	if n := getRowsCount(); n != rowsCount {
+		t.Fatalf("unexpected number of rows before downsampling; got %d; want %d", n, rowsCount)
+	}
+	downsampledRowsBefore := atomic.LoadUint64(&downsampledRowsDuringMerge)
+	s.tb.downsampleParts()
+	// The samples cover 60 or 61 minutes depending on the alignment.
+	if n := getRowsCount(); n < 60 || n > 61 {
+		t.Fatalf("unexpected number of rows after downsampling; got %d; want 60 or 61", n)
+	}
+	if n := atomic.LoadUint64(&downsampledRowsDuringMerge) - downsampledRowsBefore; n < rowsCount-61 {
+		t.Fatalf("unexpected number of downsampled rows; got %d; want at least %d", n, rowsCount-61)
+	}
+
+	// Already downsampled parts mustn't be merged again.
+	var m Metrics
+	s.UpdateMetrics(&m)
+	mergesCount := m.TableMetrics.SmallMergesCount + m.TableMetrics.BigMergesCount
+	s.tb.downsampleParts()
+	m.Reset()
+	s.UpdateMetrics(&m)
+	if n := m.TableMetrics.SmallMergesCount + m.TableMetrics.BigMergesCount; n != mergesCount {
+		t.Fatalf("unexpected merges for already downsampled parts; got %d merges; want %d", n, mergesCount)
+	}
+}
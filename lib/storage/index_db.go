@@ -1112,10 +1112,26 @@ func (is *indexSearch) getSeriesCount() (uint64, error) {
 	return metricIDsLen, nil
 }
 
-// GetTSDBStatusForDate returns topN entries for tsdb status for the given date.
-func (db *indexDB) GetTSDBStatusForDate(date uint64, topN int, deadline uint64) (*TSDBStatus, error) {
+// GetTSDBStatusWithFilters returns topN entries for tsdb status for the given tfss, tr and focusLabel.
+//
+// All the time series on the given tr are taken into account if tfss is empty.
+func (db *indexDB) GetTSDBStatusWithFilters(tfss []*TagFilters, tr TimeRange, focusLabel string, topN, maxMetrics int, deadline uint64) (*TSDBStatus, error) {
+	c := newTopTSDBStatusCollector(topN)
+	return db.getTSDBStatus(c, tfss, tr, focusLabel, maxMetrics, deadline)
+}
+
+// GetTSDBStatusForEntries returns tsdb status with the same entries as in the given status for the given tfss, tr and focusLabel.
+//
+// This function may be used for comparing tsdb status for distinct time ranges.
+// Entries missing on the given tr are returned with zero counts.
+func (db *indexDB) GetTSDBStatusForEntries(tfss []*TagFilters, tr TimeRange, focusLabel string, status *TSDBStatus, maxMetrics int, deadline uint64) (*TSDBStatus, error) {
+	c := newEntriesTSDBStatusCollector(status)
+	return db.getTSDBStatus(c, tfss, tr, focusLabel, maxMetrics, deadline)
+}
+
+func (db *indexDB) getTSDBStatus(c *tsdbStatusCollector, tfss []*TagFilters, tr TimeRange, focusLabel string, maxMetrics int, deadline uint64) (*TSDBStatus, error) {
 	is := db.getIndexSearch(deadline)
-	status, err := is.getTSDBStatusForDate(date, topN)
+	status, err := is.getTSDBStatusWithFilters(c, tfss, tr, focusLabel, maxMetrics)
 	db.putIndexSearch(is)
 	if err != nil {
 		return nil, err
@@ -1128,7 +1144,8 @@ func (db *indexDB) GetTSDBStatusForDate(date uint64, topN int, deadline uint64)
 	// The entries weren't found in the db. Try searching them in extDB.
 	ok := db.doExtDB(func(extDB *indexDB) {
 		is := extDB.getIndexSearch(deadline)
-		status, err = is.getTSDBStatusForDate(date, topN)
+		c.reset()
+		status, err = is.getTSDBStatusWithFilters(c, tfss, tr, focusLabel, maxMetrics)
 		extDB.putIndexSearch(is)
 	})
 	if ok && err != nil {
@@ -1137,20 +1154,82 @@ func (db *indexDB) GetTSDBStatusForDate(date uint64, topN int, deadline uint64)
 	return status, nil
 }
 
-func (is *indexSearch) getTSDBStatusForDate(date uint64, topN int) (*TSDBStatus, error) {
+// getTSDBStatusWithFilters collects tsdb status for time series matching tfss on the given tr into c.
+//
+// The per-day index is scanned if tr covers a single day. Otherwise the global index is scanned
+// and only time series with entries in the per-day index for tr are counted,
+// so time series for multiple days are counted only once.
+func (is *indexSearch) getTSDBStatusWithFilters(c *tsdbStatusCollector, tfss []*TagFilters, tr TimeRange, focusLabel string, maxMetrics int) (*TSDBStatus, error) {
+	minDate := uint64(tr.MinTimestamp) / msecPerDay
+	maxDate := uint64(tr.MaxTimestamp) / msecPerDay
+	isSingleDate := minDate == maxDate
+	var filter *uint64set.Set
+	if !isSingleDate {
+		metricIDs, err := is.getMetricIDsForDates(minDate, maxDate, maxMetrics)
+		if err != nil {
+			return nil, fmt.Errorf("cannot find metric ids for the time range %s: %w", &tr, err)
+		}
+		filter = metricIDs
+	}
+	if len(tfss) > 0 {
+		metricIDs, err := is.searchMetricIDs(tfss, tr, maxMetrics)
+		if err != nil {
+			return nil, fmt.Errorf("cannot find metric ids matching the given filters: %w", err)
+		}
+		m := &uint64set.Set{}
+		m.AddMulti(metricIDs)
+		if filter != nil {
+			// searchMetricIDs may return metricIDs without samples on tr.
+			m.Intersect(filter)
+		}
+		filter = m
+	}
+	if filter != nil && filter.Len() == 0 {
+		// Nothing found.
+		return c.getTSDBStatus(), nil
+	}
+
 	ts := &is.ts
 	kb := &is.kb
 	mp := &is.mp
-	thLabelValueCountByLabelName := newTopHeap(topN)
-	thSeriesCountByLabelValuePair := newTopHeap(topN)
-	thSeriesCountByMetricName := newTopHeap(topN)
 	var tmp, labelName, labelNameValue []byte
-	var labelValueCountByLabelName, seriesCountByLabelValuePair uint64
+	var labelValueCountByLabelName, seriesCountByLabelName, seriesCountByLabelValuePair uint64
 	nameEqualBytes := []byte("__name__=")
+	focusLabelEqualBytes := []byte(focusLabel + "=")
+
+	flushLabelValuePair := func() {
+		n := seriesCountByLabelValuePair
+		if n == 0 {
+			return
+		}
+		seriesCountByLabelValuePair = 0
+		c.seriesCountByLabelValuePair.pushIfNonEmpty(labelNameValue, n)
+		if bytes.HasPrefix(labelNameValue, nameEqualBytes) {
+			c.seriesCountByMetricName.pushIfNonEmpty(labelNameValue[len(nameEqualBytes):], n)
+			c.totalSeries += n
+		}
+		if len(focusLabel) > 0 && bytes.HasPrefix(labelNameValue, focusLabelEqualBytes) {
+			c.seriesCountByFocusLabelValue.pushIfNonEmpty(labelNameValue[len(focusLabelEqualBytes):], n)
+		}
+		c.totalLabelValuePairs += n
+		labelValueCountByLabelName++
+		seriesCountByLabelName += n
+	}
+	flushLabelName := func() {
+		flushLabelValuePair()
+		c.labelValueCountByLabelName.pushIfNonEmpty(labelName, labelValueCountByLabelName)
+		c.seriesCountByLabelName.pushIfNonEmpty(labelName, seriesCountByLabelName)
+		labelValueCountByLabelName = 0
+		seriesCountByLabelName = 0
+	}
 
 	loopsPaceLimiter := 0
-	kb.B = is.marshalCommonPrefix(kb.B[:0], nsPrefixDateTagToMetricIDs)
-	kb.B = encoding.MarshalUint64(kb.B, date)
+	if isSingleDate {
+		kb.B = is.marshalCommonPrefix(kb.B[:0], nsPrefixDateTagToMetricIDs)
+		kb.B = encoding.MarshalUint64(kb.B, minDate)
+	} else {
+		kb.B = is.marshalCommonPrefix(kb.B[:0], nsPrefixTagToMetricIDs)
+	}
 	prefix := kb.B
 	ts.Seek(prefix)
 	for ts.NextItem() {
@@ -1174,8 +1253,7 @@ func (is *indexSearch) getTSDBStatusForDate(date uint64, topN int) (*TSDBStatus,
 			tmp = append(tmp, "__name__"...)
 		}
 		if !bytes.Equal(tmp, labelName) {
-			thLabelValueCountByLabelName.pushIfNonEmpty(labelName, labelValueCountByLabelName)
-			labelValueCountByLabelName = 0
+			flushLabelName()
 			labelName = append(labelName[:0], tmp...)
 		}
 		tmp = append(tmp, '=')
@@ -1184,49 +1262,157 @@ func (is *indexSearch) getTSDBStatusForDate(date uint64, topN int) (*TSDBStatus,
 			return nil, fmt.Errorf("cannot unmarshal tag value from line %q: %w", item, err)
 		}
 		if !bytes.Equal(tmp, labelNameValue) {
-			thSeriesCountByLabelValuePair.pushIfNonEmpty(labelNameValue, seriesCountByLabelValuePair)
-			if bytes.HasPrefix(labelNameValue, nameEqualBytes) {
-				thSeriesCountByMetricName.pushIfNonEmpty(labelNameValue[len(nameEqualBytes):], seriesCountByLabelValuePair)
-			}
-			seriesCountByLabelValuePair = 0
-			labelValueCountByLabelName++
+			flushLabelValuePair()
 			labelNameValue = append(labelNameValue[:0], tmp...)
 		}
 		if err := mp.InitOnlyTail(item, tail); err != nil {
 			return nil, err
 		}
-		// Take into account deleted timeseries too.
-		// It is OK if series can be counted multiple times in rare cases -
-		// the returned number is an estimation.
-		seriesCountByLabelValuePair += uint64(mp.MetricIDsLen())
+		if filter == nil {
+			// Take into account deleted timeseries too.
+			// It is OK if series can be counted multiple times in rare cases -
+			// the returned number is an estimation.
+			seriesCountByLabelValuePair += uint64(mp.MetricIDsLen())
+			continue
+		}
+		mp.ParseMetricIDs()
+		for _, metricID := range mp.MetricIDs {
+			if filter.Has(metricID) {
+				seriesCountByLabelValuePair++
+			}
+		}
 	}
 	if err := ts.Error(); err != nil {
 		return nil, fmt.Errorf("error when counting time series by metric names: %w", err)
 	}
-	thLabelValueCountByLabelName.pushIfNonEmpty(labelName, labelValueCountByLabelName)
-	thSeriesCountByLabelValuePair.pushIfNonEmpty(labelNameValue, seriesCountByLabelValuePair)
-	if bytes.HasPrefix(labelNameValue, nameEqualBytes) {
-		thSeriesCountByMetricName.pushIfNonEmpty(labelNameValue[len(nameEqualBytes):], seriesCountByLabelValuePair)
-	}
-	status := &TSDBStatus{
-		SeriesCountByMetricName:     thSeriesCountByMetricName.getSortedResult(),
-		LabelValueCountByLabelName:  thLabelValueCountByLabelName.getSortedResult(),
-		SeriesCountByLabelValuePair: thSeriesCountByLabelValuePair.getSortedResult(),
-	}
-	return status, nil
+	flushLabelName()
+	return c.getTSDBStatus(), nil
 }
 
 // TSDBStatus contains TSDB status data for /api/v1/status/tsdb.
 //
 // See https://prometheus.io/docs/prometheus/latest/querying/api/#tsdb-stats
 type TSDBStatus struct {
-	SeriesCountByMetricName     []TopHeapEntry
-	LabelValueCountByLabelName  []TopHeapEntry
-	SeriesCountByLabelValuePair []TopHeapEntry
+	TotalSeries                  uint64
+	TotalLabelValuePairs         uint64
+	SeriesCountByMetricName      []TopHeapEntry
+	SeriesCountByLabelName       []TopHeapEntry
+	SeriesCountByFocusLabelValue []TopHeapEntry
+	LabelValueCountByLabelName   []TopHeapEntry
+	SeriesCountByLabelValuePair  []TopHeapEntry
 }
 
 func (status *TSDBStatus) hasEntries() bool {
-	return len(status.SeriesCountByLabelValuePair) > 0
+	return status.TotalLabelValuePairs > 0
+}
+
+// tsdbStatusCollector collects TSDBStatus entries.
+type tsdbStatusCollector struct {
+	totalSeries                  uint64
+	totalLabelValuePairs         uint64
+	seriesCountByMetricName      tsdbStatusEntriesCollector
+	seriesCountByLabelName       tsdbStatusEntriesCollector
+	seriesCountByFocusLabelValue tsdbStatusEntriesCollector
+	labelValueCountByLabelName   tsdbStatusEntriesCollector
+	seriesCountByLabelValuePair  tsdbStatusEntriesCollector
+}
+
+// tsdbStatusEntriesCollector collects a list of TSDBStatus entries.
+type tsdbStatusEntriesCollector interface {
+	// pushIfNonEmpty registers count for the given name if count isn't zero.
+	pushIfNonEmpty(name []byte, count uint64)
+
+	// getSortedResult returns the collected entries.
+	getSortedResult() []TopHeapEntry
+
+	// reset resets the collected entries.
+	reset()
+}
+
+// newTopTSDBStatusCollector returns tsdbStatusCollector, which collects topN entries with the biggest counts.
+func newTopTSDBStatusCollector(topN int) *tsdbStatusCollector {
+	return &tsdbStatusCollector{
+		seriesCountByMetricName:      newTopHeap(topN),
+		seriesCountByLabelName:       newTopHeap(topN),
+		seriesCountByFocusLabelValue: newTopHeap(topN),
+		labelValueCountByLabelName:   newTopHeap(topN),
+		seriesCountByLabelValuePair:  newTopHeap(topN),
+	}
+}
+
+// newEntriesTSDBStatusCollector returns tsdbStatusCollector, which collects counts only for the entries from the given status.
+func newEntriesTSDBStatusCollector(status *TSDBStatus) *tsdbStatusCollector {
+	return &tsdbStatusCollector{
+		seriesCountByMetricName:      newNamedCounters(status.SeriesCountByMetricName),
+		seriesCountByLabelName:       newNamedCounters(status.SeriesCountByLabelName),
+		seriesCountByFocusLabelValue: newNamedCounters(status.SeriesCountByFocusLabelValue),
+		labelValueCountByLabelName:   newNamedCounters(status.LabelValueCountByLabelName),
+		seriesCountByLabelValuePair:  newNamedCounters(status.SeriesCountByLabelValuePair),
+	}
+}
+
+func (c *tsdbStatusCollector) reset() {
+	c.totalSeries = 0
+	c.totalLabelValuePairs = 0
+	c.seriesCountByMetricName.reset()
+	c.seriesCountByLabelName.reset()
+	c.seriesCountByFocusLabelValue.reset()
+	c.labelValueCountByLabelName.reset()
+	c.seriesCountByLabelValuePair.reset()
+}
+
+func (c *tsdbStatusCollector) getTSDBStatus() *TSDBStatus {
+	return &TSDBStatus{
+		TotalSeries:                  c.totalSeries,
+		TotalLabelValuePairs:         c.totalLabelValuePairs,
+		SeriesCountByMetricName:      c.seriesCountByMetricName.getSortedResult(),
+		SeriesCountByLabelName:       c.seriesCountByLabelName.getSortedResult(),
+		SeriesCountByFocusLabelValue: c.seriesCountByFocusLabelValue.getSortedResult(),
+		LabelValueCountByLabelName:   c.labelValueCountByLabelName.getSortedResult(),
+		SeriesCountByLabelValuePair:  c.seriesCountByLabelValuePair.getSortedResult(),
+	}
+}
+
+// namedCounters collects counts only for the pre-defined names.
+type namedCounters struct {
+	names []string
+	m     map[string]*uint64
+}
+
+func newNamedCounters(entries []TopHeapEntry) *namedCounters {
+	nc := &namedCounters{
+		names: make([]string, len(entries)),
+		m:     make(map[string]*uint64, len(entries)),
+	}
+	for i, e := range entries {
+		nc.names[i] = e.Name
+		nc.m[e.Name] = new(uint64)
+	}
+	return nc
+}
+
+func (nc *namedCounters) pushIfNonEmpty(name []byte, count uint64) {
+	if p := nc.m[string(name)]; p != nil {
+		*p += count
+	}
+}
+
+// getSortedResult returns entries in the order of names passed to newNamedCounters.
+func (nc *namedCounters) getSortedResult() []TopHeapEntry {
+	result := make([]TopHeapEntry, len(nc.names))
+	for i, name := range nc.names {
+		result[i] = TopHeapEntry{
+			Name:  name,
+			Count: *nc.m[name],
+		}
+	}
+	return result
+}
+
+func (nc *namedCounters) reset() {
+	for _, p := range nc.m {
+		*p = 0
+	}
 }
 
 // topHeap maintains a heap of topHeapEntries with the maximum TopHeapEntry.n values.
@@ -1270,6 +1456,10 @@ func (th *topHeap) pushIfNonEmpty(name []byte, count uint64) {
 	heap.Fix(th, 0)
 }
 
+func (th *topHeap) reset() {
+	th.a = th.a[:0]
+}
+
 func (th *topHeap) getSortedResult() []TopHeapEntry {
 	result := append([]TopHeapEntry{}, th.a...)
 	sort.Slice(result, func(i, j int) bool {
@@ -2930,6 +3120,30 @@ func (is *indexSearch) getMetricIDsForDate(date uint64, maxMetrics int) (*uint64
 	return &metricIDs, nil
 }
 
+// getMetricIDsForDates returns metricIDs for all the dates in the range [minDate ... maxDate].
+//
+// Dates without metricIDs are skipped.
+func (is *indexSearch) getMetricIDsForDates(minDate, maxDate uint64, maxMetrics int) (*uint64set.Set, error) {
+	if maxDate-minDate > maxDaysForDateMetricIDs {
+		return nil, fmt.Errorf("the time range cannot cover more than %d days", maxDaysForDateMetricIDs)
+	}
+	metricIDs := &uint64set.Set{}
+	for date := minDate; date <= maxDate; date++ {
+		m, err := is.getMetricIDsForDate(date, maxMetrics)
+		if err == errMissingMetricIDsForDate {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		metricIDs.UnionMayOwn(m)
+		if metricIDs.Len() > maxMetrics {
+			return nil, fmt.Errorf("the number of unique timeseries exceeds %d; either narrow down the time range or increase -search.maxUniqueTimeseries", maxMetrics)
+		}
+	}
+	return metricIDs, nil
+}
+
 func (is *indexSearch) updateMetricIDsAll(metricIDs *uint64set.Set, maxMetrics int) error {
 	kb := kbPool.Get()
 	defer kbPool.Put(kb)
@@ -1609,10 +1609,10 @@ func TestSearchTSIDWithTimeRange(t *testing.T) {
 		t.Fatal("Expected time series for all days, got", len(matchedTSIDs))
 	}
 
-	// Check GetTSDBStatusForDate
-	status, err := db.GetTSDBStatusForDate(baseDate, 5, noDeadline)
+	// Check GetTSDBStatusWithFilters for a single date
+	status, err := db.GetTSDBStatusWithFilters(nil, getDatesTimeRange(baseDate, baseDate), "day", 5, 1e6, noDeadline)
 	if err != nil {
-		t.Fatalf("error in GetTSDBStatusForDate: %s", err)
+		t.Fatalf("error in GetTSDBStatusWithFilters: %s", err)
 	}
 	if !status.hasEntries() {
 		t.Fatalf("expecting non-empty TSDB status")
@@ -1672,6 +1672,196 @@ func TestSearchTSIDWithTimeRange(t *testing.T) {
 	if !reflect.DeepEqual(status.SeriesCountByLabelValuePair, expectedSeriesCountByLabelValuePair) {
 		t.Fatalf("unexpected SeriesCountByLabelValuePair;\ngot\n%v\nwant\n%v", status.SeriesCountByLabelValuePair, expectedSeriesCountByLabelValuePair)
 	}
+	expectedSeriesCountByLabelName := []TopHeapEntry{
+		{
+			Name:  "__name__",
+			Count: 1000,
+		},
+		{
+			Name:  "constant",
+			Count: 1000,
+		},
+		{
+			Name:  "day",
+			Count: 1000,
+		},
+		{
+			Name:  "uniqueid",
+			Count: 1000,
+		},
+	}
+	if !reflect.DeepEqual(status.SeriesCountByLabelName, expectedSeriesCountByLabelName) {
+		t.Fatalf("unexpected SeriesCountByLabelName;\ngot\n%v\nwant\n%v", status.SeriesCountByLabelName, expectedSeriesCountByLabelName)
+	}
+	expectedSeriesCountByFocusLabelValue := []TopHeapEntry{
+		{
+			Name:  "0",
+			Count: 1000,
+		},
+	}
+	if !reflect.DeepEqual(status.SeriesCountByFocusLabelValue, expectedSeriesCountByFocusLabelValue) {
+		t.Fatalf("unexpected SeriesCountByFocusLabelValue;\ngot\n%v\nwant\n%v", status.SeriesCountByFocusLabelValue, expectedSeriesCountByFocusLabelValue)
+	}
+	if status.TotalSeries != 1000 {
+		t.Fatalf("unexpected TotalSeries; got %d; want %d", status.TotalSeries, 1000)
+	}
+	if status.TotalLabelValuePairs != 4000 {
+		t.Fatalf("unexpected TotalLabelValuePairs; got %d; want %d", status.TotalLabelValuePairs, 4000)
+	}
+
+	// Check GetTSDBStatusWithFilters with filters
+	tfs = NewTagFilters()
+	if err := tfs.Add([]byte("uniqueid"), []byte("0|1|2"), false, true); err != nil {
+		t.Fatalf("cannot add filter: %s", err)
+	}
+	status, err = db.GetTSDBStatusWithFilters([]*TagFilters{tfs}, getDatesTimeRange(baseDate, baseDate), "uniqueid", 5, 1e6, noDeadline)
+	if err != nil {
+		t.Fatalf("error in GetTSDBStatusWithFilters: %s", err)
+	}
+	expectedSeriesCountByMetricName = []TopHeapEntry{
+		{
+			Name:  "testMetric",
+			Count: 3,
+		},
+	}
+	if !reflect.DeepEqual(status.SeriesCountByMetricName, expectedSeriesCountByMetricName) {
+		t.Fatalf("unexpected SeriesCountByMetricName;\ngot\n%v\nwant\n%v", status.SeriesCountByMetricName, expectedSeriesCountByMetricName)
+	}
+	expectedSeriesCountByFocusLabelValue = []TopHeapEntry{
+		{
+			Name:  "0",
+			Count: 1,
+		},
+		{
+			Name:  "1",
+			Count: 1,
+		},
+		{
+			Name:  "2",
+			Count: 1,
+		},
+	}
+	if !reflect.DeepEqual(status.SeriesCountByFocusLabelValue, expectedSeriesCountByFocusLabelValue) {
+		t.Fatalf("unexpected SeriesCountByFocusLabelValue;\ngot\n%v\nwant\n%v", status.SeriesCountByFocusLabelValue, expectedSeriesCountByFocusLabelValue)
+	}
+	expectedLabelValueCountByLabelName = []TopHeapEntry{
+		{
+			Name:  "uniqueid",
+			Count: 3,
+		},
+		{
+			Name:  "__name__",
+			Count: 1,
+		},
+		{
+			Name:  "constant",
+			Count: 1,
+		},
+		{
+			Name:  "day",
+			Count: 1,
+		},
+	}
+	if !reflect.DeepEqual(status.LabelValueCountByLabelName, expectedLabelValueCountByLabelName) {
+		t.Fatalf("unexpected LabelValueCountByLabelName;\ngot\n%v\nwant\n%v", status.LabelValueCountByLabelName, expectedLabelValueCountByLabelName)
+	}
+	if status.TotalSeries != 3 {
+		t.Fatalf("unexpected TotalSeries; got %d; want %d", status.TotalSeries, 3)
+	}
+
+	// Check GetTSDBStatusForEntries for the date without series
+	statusPrev, err := db.GetTSDBStatusForEntries([]*TagFilters{tfs}, getDatesTimeRange(baseDate-1, baseDate-1), "uniqueid", status, 1e6, noDeadline)
+	if err != nil {
+		t.Fatalf("error in GetTSDBStatusForEntries: %s", err)
+	}
+	if statusPrev.TotalSeries != 0 {
+		t.Fatalf("unexpected TotalSeries for the previous date; got %d; want 0", statusPrev.TotalSeries)
+	}
+	expectedSeriesCountByMetricName = []TopHeapEntry{
+		{
+			Name:  "testMetric",
+			Count: 0,
+		},
+	}
+	if !reflect.DeepEqual(statusPrev.SeriesCountByMetricName, expectedSeriesCountByMetricName) {
+		t.Fatalf("unexpected SeriesCountByMetricName for the previous date;\ngot\n%v\nwant\n%v", statusPrev.SeriesCountByMetricName, expectedSeriesCountByMetricName)
+	}
+
+	// Check GetTSDBStatusForEntries for the same date
+	statusPrev, err = db.GetTSDBStatusForEntries([]*TagFilters{tfs}, getDatesTimeRange(baseDate, baseDate), "uniqueid", status, 1e6, noDeadline)
+	if err != nil {
+		t.Fatalf("error in GetTSDBStatusForEntries: %s", err)
+	}
+	if !reflect.DeepEqual(statusPrev, status) {
+		t.Fatalf("unexpected status for the same date;\ngot\n%+v\nwant\n%+v", statusPrev, status)
+	}
+
+	// Register the series for the current date on the previous date too.
+	// They must be counted only once in the stats for the time range covering both dates.
+	isLocal := db.getIndexSearch(noDeadline)
+	metricIDs, err := isLocal.getMetricIDsForDate(baseDate, 1e6)
+	db.putIndexSearch(isLocal)
+	if err != nil {
+		t.Fatalf("error in getMetricIDsForDate(%d): %s", baseDate, err)
+	}
+	for _, metricID := range metricIDs.AppendTo(nil) {
+		if err := is.storeDateMetricID(baseDate-1, metricID); err != nil {
+			t.Fatalf("error in storeDateMetricID(%d, %d): %s", baseDate-1, metricID, err)
+		}
+	}
+	db.tb.DebugFlush()
+
+	// Check GetTSDBStatusWithFilters for multiple dates
+	status, err = db.GetTSDBStatusWithFilters(nil, getDatesTimeRange(baseDate-1, baseDate), "day", 5, 1e6, noDeadline)
+	if err != nil {
+		t.Fatalf("error in GetTSDBStatusWithFilters: %s", err)
+	}
+	expectedSeriesCountByMetricName = []TopHeapEntry{
+		{
+			Name:  "testMetric",
+			Count: 1000,
+		},
+	}
+	if !reflect.DeepEqual(status.SeriesCountByMetricName, expectedSeriesCountByMetricName) {
+		t.Fatalf("unexpected SeriesCountByMetricName for multiple dates;\ngot\n%v\nwant\n%v", status.SeriesCountByMetricName, expectedSeriesCountByMetricName)
+	}
+	expectedSeriesCountByFocusLabelValue = []TopHeapEntry{
+		{
+			Name:  "0",
+			Count: 1000,
+		},
+	}
+	if !reflect.DeepEqual(status.SeriesCountByFocusLabelValue, expectedSeriesCountByFocusLabelValue) {
+		t.Fatalf("unexpected SeriesCountByFocusLabelValue for multiple dates;\ngot\n%v\nwant\n%v", status.SeriesCountByFocusLabelValue, expectedSeriesCountByFocusLabelValue)
+	}
+	if status.TotalSeries != 1000 {
+		t.Fatalf("unexpected TotalSeries for multiple dates; got %d; want %d", status.TotalSeries, 1000)
+	}
+	if status.TotalLabelValuePairs != 4000 {
+		t.Fatalf("unexpected TotalLabelValuePairs for multiple dates; got %d; want %d", status.TotalLabelValuePairs, 4000)
+	}
+
+	// Check GetTSDBStatusWithFilters with filters for multiple dates
+	status, err = db.GetTSDBStatusWithFilters([]*TagFilters{tfs}, getDatesTimeRange(baseDate-1, baseDate), "uniqueid", 5, 1e6, noDeadline)
+	if err != nil {
+		t.Fatalf("error in GetTSDBStatusWithFilters: %s", err)
+	}
+	if status.TotalSeries != 3 {
+		t.Fatalf("unexpected TotalSeries for multiple dates with filters; got %d; want %d", status.TotalSeries, 3)
+	}
+
+	// Check GetTSDBStatusWithFilters for too big time range
+	_, err = db.GetTSDBStatusWithFilters(nil, getDatesTimeRange(baseDate-maxDaysForDateMetricIDs-1, baseDate), "day", 5, 1e6, noDeadline)
+	if err == nil {
+		t.Fatalf("expecting non-nil error for the time range exceeding %d days", maxDaysForDateMetricIDs)
+	}
+}
+
+func getDatesTimeRange(minDate, maxDate uint64) TimeRange {
+	return TimeRange{
+		MinTimestamp: int64(minDate) * msecPerDay,
+		MaxTimestamp: int64(maxDate+1)*msecPerDay - 1,
+	}
 }
 
 func toTFPointers(tfs []tagFilter) []*tagFilter {
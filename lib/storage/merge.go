@@ -14,13 +14,15 @@ import (
 // mergeBlockStreams returns immediately if stopCh is closed.
 //
 // rowsMerged is atomically updated with the number of merged rows during the merge.
+//
+// Samples with timestamps smaller than non-zero getMinTimestampForMetricID(metricID) are dropped if getMinTimestampForMetricID isn't nil.
 func mergeBlockStreams(ph *partHeader, bsw *blockStreamWriter, bsrs []*blockStreamReader, stopCh <-chan struct{},
-	dmis *uint64set.Set, rowsMerged, rowsDeleted *uint64) error {
+	dmis *uint64set.Set, getMinTimestampForMetricID func(metricID uint64) int64, rowsMerged, rowsDeleted *uint64) error {
 	ph.Reset()
 
 	bsm := bsmPool.Get().(*blockStreamMerger)
 	bsm.Init(bsrs)
-	err := mergeBlockStreamsInternal(ph, bsw, bsm, stopCh, dmis, rowsMerged, rowsDeleted)
+	err := mergeBlockStreamsInternal(ph, bsw, bsm, stopCh, dmis, getMinTimestampForMetricID, rowsMerged, rowsDeleted)
 	bsm.reset()
 	bsmPool.Put(bsm)
 	bsw.MustClose()
@@ -42,7 +44,7 @@ var bsmPool = &sync.Pool{
 var errForciblyStopped = fmt.Errorf("forcibly stopped")
 
 func mergeBlockStreamsInternal(ph *partHeader, bsw *blockStreamWriter, bsm *blockStreamMerger, stopCh <-chan struct{},
-	dmis *uint64set.Set, rowsMerged, rowsDeleted *uint64) error {
+	dmis *uint64set.Set, getMinTimestampForMetricID func(metricID uint64) int64, rowsMerged, rowsDeleted *uint64) error {
 	// Search for the first block to merge
 	var pendingBlock *Block
 	for bsm.NextBlock() {
@@ -56,6 +58,23 @@ func mergeBlockStreamsInternal(ph *partHeader, bsw *blockStreamWriter, bsm *bloc
 			*rowsDeleted += uint64(bsm.Block.bh.RowsCount)
 			continue
 		}
+		if getMinTimestampForMetricID != nil {
+			minTimestamp := getMinTimestampForMetricID(bsm.Block.bh.TSID.MetricID)
+			ok, err := removeSamplesBelowMinTimestamp(bsm.Block, minTimestamp, rowsDeleted)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				// Skip blocks outside the retention.
+				continue
+			}
+		}
+		if needsDownsampling(&bsm.Block.bh) {
+			// Unmarshal the block, so it could be downsampled in bsw.WriteExternalBlock.
+			if err := bsm.Block.UnmarshalData(); err != nil {
+				return fmt.Errorf("cannot unmarshal block for downsampling: %w", err)
+			}
+		}
 		pendingBlock = getBlock()
 		pendingBlock.CopyFrom(bsm.Block)
 		break
@@ -78,6 +97,23 @@ func mergeBlockStreamsInternal(ph *partHeader, bsw *blockStreamWriter, bsm *bloc
 			*rowsDeleted += uint64(bsm.Block.bh.RowsCount)
 			continue
 		}
+		if getMinTimestampForMetricID != nil {
+			minTimestamp := getMinTimestampForMetricID(bsm.Block.bh.TSID.MetricID)
+			ok, err := removeSamplesBelowMinTimestamp(bsm.Block, minTimestamp, rowsDeleted)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				// Skip blocks outside the retention.
+				continue
+			}
+		}
+		if needsDownsampling(&bsm.Block.bh) {
+			// Unmarshal the block, so it could be downsampled in bsw.WriteExternalBlock.
+			if err := bsm.Block.UnmarshalData(); err != nil {
+				return fmt.Errorf("cannot unmarshal block for downsampling: %w", err)
+			}
+		}
 
 		// Verify whether pendingBlock may be merged with bsm.Block (the current block).
 		if pendingBlock.bh.TSID.MetricID != bsm.Block.bh.TSID.MetricID {
@@ -184,6 +220,39 @@ func appendRows(ob, ib *Block) {
 	ob.values = append(ob.values, ib.values[ib.nextIdx:]...)
 }
 
+// removeSamplesBelowMinTimestamp removes samples with timestamps smaller than minTimestamp from b.
+//
+// Zero minTimestamp means there is no limit.
+//
+// It returns false if all the samples are removed from b.
+func removeSamplesBelowMinTimestamp(b *Block, minTimestamp int64, rowsDeleted *uint64) (bool, error) {
+	if minTimestamp <= 0 || b.bh.MinTimestamp >= minTimestamp {
+		// Fast path - all the samples are inside the retention.
+		return true, nil
+	}
+	if b.bh.MaxTimestamp < minTimestamp {
+		// Fast path - all the samples are outside the retention.
+		*rowsDeleted += uint64(b.rowsCount())
+		return false, nil
+	}
+
+	// Slow path - remove samples outside the retention.
+	if err := b.UnmarshalData(); err != nil {
+		return false, fmt.Errorf("cannot unmarshal block for removing samples outside the retention: %w", err)
+	}
+	nextIdx := b.nextIdx
+	for nextIdx < len(b.timestamps) && b.timestamps[nextIdx] < minTimestamp {
+		nextIdx++
+	}
+	*rowsDeleted += uint64(nextIdx - b.nextIdx)
+	b.nextIdx = nextIdx
+	if b.nextIdx == len(b.timestamps) {
+		return false, nil
+	}
+	b.fixupTimestamps()
+	return true, nil
+}
+
 func unmarshalAndCalibrateScale(b1, b2 *Block) error {
 	if err := b1.UnmarshalData(); err != nil {
 		return err
@@ -328,6 +328,76 @@ func TestMergeBlockStreamsManyStreamsManyBlocksManyRows(t *testing.T) {
 	testMergeBlockStreams(t, bsrs, blocksCount, rowsCount, minTimestamp, maxTimestamp)
 }
 
+func TestMergeBlockStreamsMinTimestamp(t *testing.T) {
+	var rows []rawRow
+	var r rawRow
+	r.PrecisionBits = defaultPrecisionBits
+	const metricsCount = 10
+	const rowsPerMetric = 100
+	for i := 0; i < metricsCount; i++ {
+		initTestTSID(&r.TSID)
+		r.TSID.MetricID = uint64(i)
+		for j := 0; j < rowsPerMetric; j++ {
+			r.Timestamp = int64(j * 1000)
+			r.Value = float64(j)
+			rows = append(rows, r)
+		}
+	}
+	bsr := newTestBlockStreamReader(t, rows)
+	bsrs := []*blockStreamReader{bsr}
+
+	// Drop all the samples for odd metricIDs and the first half of samples for metricID=4.
+	getMinTimestampForMetricID := func(metricID uint64) int64 {
+		if metricID%2 == 1 {
+			return rowsPerMetric * 1000
+		}
+		if metricID == 4 {
+			return rowsPerMetric / 2 * 1000
+		}
+		return 0
+	}
+
+	var mp inmemoryPart
+	var bsw blockStreamWriter
+	bsw.InitFromInmemoryPart(&mp)
+	var rowsMerged, rowsDeleted uint64
+	if err := mergeBlockStreams(&mp.ph, &bsw, bsrs, nil, nil, getMinTimestampForMetricID, &rowsMerged, &rowsDeleted); err != nil {
+		t.Fatalf("unexpected error in mergeBlockStreams: %s", err)
+	}
+	expectedRowsDeleted := uint64(metricsCount/2*rowsPerMetric + rowsPerMetric/2)
+	if rowsDeleted != expectedRowsDeleted {
+		t.Fatalf("unexpected rowsDeleted; got %d; want %d", rowsDeleted, expectedRowsDeleted)
+	}
+	expectedRowsCount := uint64(metricsCount*rowsPerMetric) - expectedRowsDeleted
+	if mp.ph.RowsCount != expectedRowsCount {
+		t.Fatalf("unexpected rows count in partHeader; got %d; want %d", mp.ph.RowsCount, expectedRowsCount)
+	}
+	if rowsMerged != expectedRowsCount {
+		t.Fatalf("unexpected rowsMerged; got %d; want %d", rowsMerged, expectedRowsCount)
+	}
+
+	// Verify that the remaining samples for metricID=4 are inside the retention.
+	var bsr1 blockStreamReader
+	bsr1.InitFromInmemoryPart(&mp)
+	blocksCount := 0
+	for bsr1.NextBlock() {
+		bh := &bsr1.Block.bh
+		if bh.TSID.MetricID%2 == 1 {
+			t.Fatalf("unexpected block for metricID=%d", bh.TSID.MetricID)
+		}
+		if bh.TSID.MetricID == 4 && bh.MinTimestamp != rowsPerMetric/2*1000 {
+			t.Fatalf("unexpected MinTimestamp for metricID=4; got %d; want %d", bh.MinTimestamp, rowsPerMetric/2*1000)
+		}
+		blocksCount++
+	}
+	if err := bsr1.Error(); err != nil {
+		t.Fatalf("unexpected error when reading merged blocks: %s", err)
+	}
+	if blocksCount != metricsCount/2 {
+		t.Fatalf("unexpected blocks count; got %d; want %d", blocksCount, metricsCount/2)
+	}
+}
+
 func TestMergeForciblyStop(t *testing.T) {
 	minTimestamp := int64(1<<63 - 1)
 	maxTimestamp := int64(-1 << 63)
@@ -364,7 +434,7 @@ func TestMergeForciblyStop(t *testing.T) {
 	ch := make(chan struct{})
 	var rowsMerged, rowsDeleted uint64
 	close(ch)
-	if err := mergeBlockStreams(&mp.ph, &bsw, bsrs, ch, nil, &rowsMerged, &rowsDeleted); err != errForciblyStopped {
+	if err := mergeBlockStreams(&mp.ph, &bsw, bsrs, ch, nil, nil, &rowsMerged, &rowsDeleted); err != errForciblyStopped {
 		t.Fatalf("unexpected error in mergeBlockStreams: got %v; want %v", err, errForciblyStopped)
 	}
 	if rowsMerged != 0 {
@@ -384,7 +454,7 @@ func testMergeBlockStreams(t *testing.T, bsrs []*blockStreamReader, expectedBloc
 	bsw.InitFromInmemoryPart(&mp)
 
 	var rowsMerged, rowsDeleted uint64
-	if err := mergeBlockStreams(&mp.ph, &bsw, bsrs, nil, nil, &rowsMerged, &rowsDeleted); err != nil {
+	if err := mergeBlockStreams(&mp.ph, &bsw, bsrs, nil, nil, nil, &rowsMerged, &rowsDeleted); err != nil {
 		t.Fatalf("unexpected error in mergeBlockStreams: %s", err)
 	}
 
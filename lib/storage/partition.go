@@ -130,6 +130,10 @@ type partition struct {
 	// The callack that returns deleted metric ids which must be skipped during merge.
 	getDeletedMetricIDs func() *uint64set.Set
 
+	// The callback that returns the minimum timestamp for samples of the given metricID.
+	// Older samples must be dropped during merge.
+	getMinTimestampForMetricID func(metricID uint64) int64
+
 	// Name is the name of the partition in the form YYYY_MM.
 	name string
 
@@ -177,6 +181,11 @@ type partWrapper struct {
 
 	// Whether the part is in merge now.
 	isInMerge bool
+
+	// The number of downsampling periods the part is known to be downsampled for.
+	//
+	// It is used for avoiding repeated checks in partition.downsampleParts.
+	downsampledPeriods int
 }
 
 func (pw *partWrapper) incRef() {
@@ -202,7 +211,7 @@ func (pw *partWrapper) decRef() {
 
 // createPartition creates new partition for the given timestamp and the given paths
 // to small and big partitions.
-func createPartition(timestamp int64, smallPartitionsPath, bigPartitionsPath string, getDeletedMetricIDs func() *uint64set.Set) (*partition, error) {
+func createPartition(timestamp int64, smallPartitionsPath, bigPartitionsPath string, getDeletedMetricIDs func() *uint64set.Set, getMinTimestampForMetricID func(metricID uint64) int64) (*partition, error) {
 	name := timestampToPartitionName(timestamp)
 	smallPartsPath := filepath.Clean(smallPartitionsPath) + "/" + name
 	bigPartsPath := filepath.Clean(bigPartitionsPath) + "/" + name
@@ -215,7 +224,7 @@ func createPartition(timestamp int64, smallPartitionsPath, bigPartitionsPath str
 		return nil, fmt.Errorf("cannot create directories for big parts %q: %w", bigPartsPath, err)
 	}
 
-	pt := newPartition(name, smallPartsPath, bigPartsPath, getDeletedMetricIDs)
+	pt := newPartition(name, smallPartsPath, bigPartsPath, getDeletedMetricIDs, getMinTimestampForMetricID)
 	pt.tr.fromPartitionTimestamp(timestamp)
 	pt.startMergeWorkers()
 	pt.startRawRowsFlusher()
@@ -237,7 +246,7 @@ func (pt *partition) Drop() {
 }
 
 // openPartition opens the existing partition from the given paths.
-func openPartition(smallPartsPath, bigPartsPath string, getDeletedMetricIDs func() *uint64set.Set) (*partition, error) {
+func openPartition(smallPartsPath, bigPartsPath string, getDeletedMetricIDs func() *uint64set.Set, getMinTimestampForMetricID func(metricID uint64) int64) (*partition, error) {
 	smallPartsPath = filepath.Clean(smallPartsPath)
 	bigPartsPath = filepath.Clean(bigPartsPath)
 
@@ -261,7 +270,7 @@ func openPartition(smallPartsPath, bigPartsPath string, getDeletedMetricIDs func
 		return nil, fmt.Errorf("cannot open big parts from %q: %w", bigPartsPath, err)
 	}
 
-	pt := newPartition(name, smallPartsPath, bigPartsPath, getDeletedMetricIDs)
+	pt := newPartition(name, smallPartsPath, bigPartsPath, getDeletedMetricIDs, getMinTimestampForMetricID)
 	pt.smallParts = smallParts
 	pt.bigParts = bigParts
 	if err := pt.tr.fromPartitionName(name); err != nil {
@@ -274,13 +283,14 @@ func openPartition(smallPartsPath, bigPartsPath string, getDeletedMetricIDs func
 	return pt, nil
 }
 
-func newPartition(name, smallPartsPath, bigPartsPath string, getDeletedMetricIDs func() *uint64set.Set) *partition {
+func newPartition(name, smallPartsPath, bigPartsPath string, getDeletedMetricIDs func() *uint64set.Set, getMinTimestampForMetricID func(metricID uint64) int64) *partition {
 	p := &partition{
 		name:           name,
 		smallPartsPath: smallPartsPath,
 		bigPartsPath:   bigPartsPath,
 
-		getDeletedMetricIDs: getDeletedMetricIDs,
+		getDeletedMetricIDs:        getDeletedMetricIDs,
+		getMinTimestampForMetricID: getMinTimestampForMetricID,
 
 		mergeIdx: uint64(time.Now().UnixNano()),
 		stopCh:   make(chan struct{}),
@@ -967,6 +977,103 @@ func (pt *partition) mergeSmallParts(isFinal bool) error {
 	return pt.mergeParts(pws, pt.stopCh)
 }
 
+// dropPartsOutsideRetention drops parts with all the rows older than minTimestamp.
+//
+// Background merges are triggered only by new parts, so such parts may remain
+// in partitions without new data forever if the retention is smaller than a month.
+// The parts are dropped via merge, which removes all the rows outside the retention.
+func (pt *partition) dropPartsOutsideRetention(minTimestamp int64, stopCh <-chan struct{}) error {
+	var pws []*partWrapper
+	pt.partsLock.Lock()
+	pws = appendPartsOutsideRetention(pws, pt.smallParts, minTimestamp)
+	pws = appendPartsOutsideRetention(pws, pt.bigParts, minTimestamp)
+	pt.partsLock.Unlock()
+
+	if len(pws) == 0 {
+		return nil
+	}
+	err := pt.mergeParts(pws, stopCh)
+	if err == errForciblyStopped {
+		return nil
+	}
+	return err
+}
+
+// downsampleParts merges parts with samples, which passed downsampling offsets after the parts were created.
+//
+// Background merges are triggered only by new parts, so such samples may remain
+// non-downsampled in partitions without new data forever.
+// Parts are merged only after all their samples pass the offset, so they aren't re-merged
+// while samples pass the offset one by one.
+func (pt *partition) downsampleParts(stopCh <-chan struct{}) error {
+	if len(downsamplingPeriods) == 0 {
+		return nil
+	}
+	now := int64(fasttime.UnixTimestamp()) * 1000
+
+	type candidate struct {
+		pw            *partWrapper
+		passedPeriods int
+	}
+	var cs []candidate
+	pt.partsLock.Lock()
+	for _, src := range [][]*partWrapper{pt.smallParts, pt.bigParts} {
+		for _, pw := range src {
+			if pw.isInMerge || pw.mp != nil {
+				continue
+			}
+			n, ok := getPassedDownsamplingPeriods(pw.p.ph.MinTimestamp, pw.p.ph.MaxTimestamp, now)
+			if !ok || n <= pw.downsampledPeriods {
+				continue
+			}
+			pw.incRef()
+			cs = append(cs, candidate{
+				pw:            pw,
+				passedPeriods: n,
+			})
+		}
+	}
+	pt.partsLock.Unlock()
+
+	// Check whether the candidate parts contain samples for downsampling without holding the lock,
+	// since this requires reading all the block headers from the part.
+	var pws []*partWrapper
+	for _, c := range cs {
+		ok, err := partNeedsDownsampling(c.pw.p)
+		if err != nil {
+			logger.Errorf("cannot check whether the part %q needs downsampling: %s", c.pw.p.path, err)
+		}
+		pt.partsLock.Lock()
+		if err == nil && !ok {
+			c.pw.downsampledPeriods = c.passedPeriods
+		} else if err == nil && !c.pw.isInMerge {
+			c.pw.isInMerge = true
+			pws = append(pws, c.pw)
+		}
+		pt.partsLock.Unlock()
+		c.pw.decRef()
+	}
+
+	if len(pws) == 0 {
+		return nil
+	}
+	err := pt.mergeParts(pws, stopCh)
+	if err == errForciblyStopped {
+		return nil
+	}
+	return err
+}
+
+func appendPartsOutsideRetention(dst, src []*partWrapper, minTimestamp int64) []*partWrapper {
+	for _, pw := range src {
+		if !pw.isInMerge && pw.p.ph.MaxTimestamp < minTimestamp {
+			pw.isInMerge = true
+			dst = append(dst, pw)
+		}
+	}
+	return dst
+}
+
 var errNothingToMerge = fmt.Errorf("nothing to merge")
 
 func (pt *partition) mergeParts(pws []*partWrapper, stopCh <-chan struct{}) error {
@@ -1046,7 +1153,7 @@ func (pt *partition) mergeParts(pws []*partWrapper, stopCh <-chan struct{}) erro
 		atomic.AddUint64(&pt.activeSmallMerges, 1)
 		// Prioritize small merges over big merges.
 	}
-	err := mergeBlockStreams(&ph, bsw, bsrs, stopCh, dmis, rowsMerged, rowsDeleted)
+	err := mergeBlockStreams(&ph, bsw, bsrs, stopCh, dmis, pt.getMinTimestampForMetricID, rowsMerged, rowsDeleted)
 	if isBigPart {
 		atomic.AddUint64(&pt.activeBigMerges, ^uint64(0))
 	} else {
@@ -167,7 +167,7 @@ func testPartitionSearchEx(t *testing.T, ptt int64, tr TimeRange, partsCount, ma
 	})
 
 	// Create partition from rowss and test search on it.
-	pt, err := createPartition(ptt, "./small-table", "./big-table", nilGetDeletedMetricIDs)
+	pt, err := createPartition(ptt, "./small-table", "./big-table", nilGetDeletedMetricIDs, nilGetMinTimestampForMetricID)
 	if err != nil {
 		t.Fatalf("cannot create partition: %s", err)
 	}
@@ -191,7 +191,7 @@ func testPartitionSearchEx(t *testing.T, ptt int64, tr TimeRange, partsCount, ma
 	pt.MustClose()
 
 	// Open the created partition and test search on it.
-	pt, err = openPartition(smallPartsPath, bigPartsPath, nilGetDeletedMetricIDs)
+	pt, err = openPartition(smallPartsPath, bigPartsPath, nilGetDeletedMetricIDs, nilGetMinTimestampForMetricID)
 	if err != nil {
 		t.Fatalf("cannot open partition: %s", err)
 	}
@@ -280,3 +280,7 @@ func testPartitionSearchSerial(pt *partition, tsids []TSID, tr TimeRange, rbsExp
 func nilGetDeletedMetricIDs() *uint64set.Set {
 	return nil
 }
+
+func nilGetMinTimestampForMetricID(metricID uint64) int64 {
+	return 0
+}
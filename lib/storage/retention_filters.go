@@ -0,0 +1,171 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/fasttime"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logger"
+)
+
+// RetentionFilter sets retention for time series matching the given Filters.
+type RetentionFilter struct {
+	// Filters contains tag filters for matching time series.
+	//
+	// MetricGroup must be encoded with nil Key.
+	Filters []TagFilter
+
+	// Retention is the retention for the matching time series.
+	Retention time.Duration
+}
+
+// String returns string representation of rf.
+func (rf *RetentionFilter) String() string {
+	a := make([]string, len(rf.Filters))
+	for i := range rf.Filters {
+		a[i] = rf.Filters[i].String()
+	}
+	return fmt.Sprintf("{Filters: [%s], Retention: %s}", strings.Join(a, ","), rf.Retention)
+}
+
+type retentionFilter struct {
+	tfs            *TagFilters
+	retentionMsecs int64
+}
+
+var retentionFilters []retentionFilter
+
+// SetRetentionFilters sets retention filters for time series.
+//
+// The retention for every time series is determined by the first matching filter from rfs.
+// Time series without matching filters are stored according to the retention passed to OpenStorage.
+// Samples outside the retention are dropped during background merges and are skipped during search.
+//
+// This function must be called before initializing the storage.
+func SetRetentionFilters(rfs []RetentionFilter) error {
+	dst := make([]retentionFilter, 0, len(rfs))
+	for i := range rfs {
+		rf := &rfs[i]
+		if len(rf.Filters) == 0 {
+			return fmt.Errorf("missing filters in retention filter %s", rf)
+		}
+		if rf.Retention <= 0 {
+			return fmt.Errorf("retention must be positive in retention filter %s", rf)
+		}
+		tfs := NewTagFilters()
+		for j := range rf.Filters {
+			tf := &rf.Filters[j]
+			if err := tfs.Add(tf.Key, tf.Value, tf.IsNegative, tf.IsRegexp); err != nil {
+				return fmt.Errorf("cannot parse tag filter %s in retention filter %s: %w", tf, rf, err)
+			}
+		}
+		dst = append(dst, retentionFilter{
+			tfs:            tfs,
+			retentionMsecs: rf.Retention.Milliseconds(),
+		})
+	}
+	retentionFilters = dst
+	return nil
+}
+
+// getMinTimestampForMetricID returns the minimum timestamp for samples of the given metricID
+// according to retention filters and the retention passed to OpenStorage.
+//
+// Zero is returned if there is no limit on the minimum timestamp.
+func (s *Storage) getMinTimestampForMetricID(metricID uint64) int64 {
+	retentionMsecs := s.retentionMsecs
+	if len(retentionFilters) > 0 {
+		rfRetentionMsecs, ok := s.retentionFiltersCache.Get(metricID)
+		if !ok {
+			var err error
+			rfRetentionMsecs, err = s.getRetentionMsecsForMetricIDSlow(metricID)
+			if err != nil {
+				// Do not cache the result, so it could be re-calculated later.
+				rfRetentionMsecs = 0
+			} else {
+				s.retentionFiltersCache.Set(metricID, rfRetentionMsecs)
+			}
+		}
+		if rfRetentionMsecs > 0 && rfRetentionMsecs < retentionMsecs {
+			retentionMsecs = rfRetentionMsecs
+		}
+	}
+	minTimestamp := int64(fasttime.UnixTimestamp())*1000 - retentionMsecs
+	if minTimestamp < 0 {
+		minTimestamp = 0
+	}
+	return minTimestamp
+}
+
+func (s *Storage) getRetentionMsecsForMetricIDSlow(metricID uint64) (int64, error) {
+	metricName := kbPool.Get()
+	defer kbPool.Put(metricName)
+	var err error
+	metricName.B, err = s.searchMetricName(metricName.B[:0], metricID)
+	if err != nil {
+		return 0, err
+	}
+	mn := GetMetricName()
+	defer PutMetricName(mn)
+	if err := mn.Unmarshal(metricName.B); err != nil {
+		logger.Errorf("cannot unmarshal metricName for metricID=%d: %s", metricID, err)
+		return 0, err
+	}
+	kb := kbPool.Get()
+	defer kbPool.Put(kb)
+	var tfs []*tagFilter
+	for i := range retentionFilters {
+		rf := &retentionFilters[i]
+		// Copy tag filters, since matchTagFilters may re-order them.
+		tfs = tfs[:0]
+		for j := range rf.tfs.tfs {
+			tfs = append(tfs, &rf.tfs.tfs[j])
+		}
+		ok, err := matchTagFilters(mn, tfs, kb)
+		if err != nil {
+			logger.Errorf("cannot match metricName %s against retention filter %s: %s", mn, rf.tfs, err)
+			return 0, err
+		}
+		if ok {
+			return rf.retentionMsecs, nil
+		}
+	}
+	return 0, nil
+}
+
+// retentionFiltersCache caches retentions in milliseconds per metricID.
+//
+// Zero retention means that the metricID doesn't match any retention filter.
+type retentionFiltersCache struct {
+	mu sync.RWMutex
+	m  map[uint64]int64
+}
+
+// maxRetentionFiltersCacheSize limits the number of entries in retentionFiltersCache.
+const maxRetentionFiltersCacheSize = 4 * 1024 * 1024
+
+func (rfc *retentionFiltersCache) Get(metricID uint64) (int64, bool) {
+	rfc.mu.RLock()
+	retentionMsecs, ok := rfc.m[metricID]
+	rfc.mu.RUnlock()
+	return retentionMsecs, ok
+}
+
+func (rfc *retentionFiltersCache) Set(metricID uint64, retentionMsecs int64) {
+	rfc.mu.Lock()
+	if rfc.m == nil || len(rfc.m) >= maxRetentionFiltersCacheSize {
+		// Reset the cache in order to limit its memory usage.
+		rfc.m = make(map[uint64]int64)
+	}
+	rfc.m[metricID] = retentionMsecs
+	rfc.mu.Unlock()
+}
+
+func (rfc *retentionFiltersCache) Len() int {
+	rfc.mu.RLock()
+	n := len(rfc.m)
+	rfc.mu.RUnlock()
+	return n
+}
@@ -0,0 +1,325 @@
+package storage
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestSetRetentionFiltersFailure(t *testing.T) {
+	f := func(rfs []RetentionFilter) {
+		t.Helper()
+		if err := SetRetentionFilters(rfs); err == nil {
+			t.Fatalf("expecting non-nil error")
+		}
+	}
+	// Missing filters
+	f([]RetentionFilter{{Retention: time.Hour}})
+
+	// Non-positive retention
+	f([]RetentionFilter{{
+		Filters: []TagFilter{{Key: []byte("job"), Value: []byte("foo")}},
+	}})
+}
+
+func TestStorageRetentionFilters(t *testing.T) {
+	rfs := []RetentionFilter{{
+		Filters:   []TagFilter{{Key: []byte("env"), Value: []byte("dev")}},
+		Retention: time.Hour,
+	}}
+	if err := SetRetentionFilters(rfs); err != nil {
+		t.Fatalf("cannot set retention filters: %s", err)
+	}
+	defer func() {
+		if err := SetRetentionFilters(nil); err != nil {
+			t.Fatalf("cannot reset retention filters: %s", err)
+		}
+	}()
+
+	path := "TestStorageRetentionFilters"
+	s, err := OpenStorage(path, 0, 0, 0)
+	if err != nil {
+		t.Fatalf("cannot open storage: %s", err)
+	}
+	defer func() {
+		s.MustClose()
+		if err := os.RemoveAll(path); err != nil {
+			t.Fatalf("cannot remove storage %q: %s", path, err)
+		}
+	}()
+
+	// Add samples with one-minute interval for the last two hours.
+	const rowsPerSeries = 120
+	now := timestampFromTime(time.Now())
+	minTimestamp := now - rowsPerSeries*60*1000
+	var mrs []MetricRow
+	for _, env := range []string{"dev", "prod"} {
+		mn := MetricName{
+			MetricGroup: []byte("metric"),
+			Tags: []Tag{
+				{[]byte("env"), []byte(env)},
+			},
+		}
+		metricNameRaw := mn.marshalRaw(nil)
+		for i := 0; i < rowsPerSeries; i++ {
+			mrs = append(mrs, MetricRow{
+				MetricNameRaw: metricNameRaw,
+				Timestamp:     minTimestamp + int64(i)*60*1000,
+				Value:         float64(i),
+			})
+		}
+	}
+	if err := s.AddRows(mrs, defaultPrecisionBits); err != nil {
+		t.Fatalf("cannot add rows: %s", err)
+	}
+	s.DebugFlush()
+
+	tfs := NewTagFilters()
+	if err := tfs.Add(nil, []byte("metric"), false, false); err != nil {
+		t.Fatalf("cannot add tag filter: %s", err)
+	}
+	tr := TimeRange{
+		MinTimestamp: minTimestamp,
+		MaxTimestamp: now,
+	}
+	rowsCounts := make(map[string]int)
+	var sr Search
+	sr.Init(nil, s, []*TagFilters{tfs}, tr, 1e5, noDeadline)
+	var mn MetricName
+	var b Block
+	for sr.NextMetricBlock() {
+		if err := mn.Unmarshal(sr.MetricBlockRef.MetricName); err != nil {
+			t.Fatalf("cannot unmarshal metric name: %s", err)
+		}
+		sr.MetricBlockRef.BlockRef.MustReadBlock(&b, true)
+		if err := b.UnmarshalData(); err != nil {
+			t.Fatalf("cannot unmarshal block data: %s", err)
+		}
+		env := string(mn.GetTagValue("env"))
+		for _, timestamp := range b.Timestamps() {
+			if env == "dev" && timestamp < now-time.Hour.Milliseconds()-60*1000 {
+				t.Fatalf("unexpected sample outside the retention for %s: timestamp=%d, now=%d", &mn, timestamp, now)
+			}
+			rowsCounts[env]++
+		}
+	}
+	if err := sr.Error(); err != nil {
+		t.Fatalf("search error: %s", err)
+	}
+	sr.MustClose()
+
+	if rowsCounts["prod"] != rowsPerSeries {
+		t.Fatalf("unexpected number of samples for env=prod; got %d; want %d", rowsCounts["prod"], rowsPerSeries)
+	}
+	if n := rowsCounts["dev"]; n < rowsPerSeries/2-1 || n > rowsPerSeries/2+1 {
+		t.Fatalf("unexpected number of samples for env=dev; got %d; want %d", n, rowsPerSeries/2)
+	}
+	if n := s.retentionFiltersCache.Len(); n != 2 {
+		t.Fatalf("unexpected number of entries in retentionFiltersCache; got %d; want 2", n)
+	}
+}
+
+func TestStorageGetMinTimestampForMetricID(t *testing.T) {
+	path := "TestStorageGetMinTimestampForMetricID"
+	retentionMsecs := int64(14 * msecPerDay)
+	s, err := OpenStorage(path, retentionMsecs, 0, 0)
+	if err != nil {
+		t.Fatalf("cannot open storage: %s", err)
+	}
+	defer func() {
+		s.MustClose()
+		if err := os.RemoveAll(path); err != nil {
+			t.Fatalf("cannot remove storage %q: %s", path, err)
+		}
+	}()
+
+	now := timestampFromTime(time.Now())
+	minTimestamp := s.getMinTimestampForMetricID(123)
+	minTimestampExpected := now - retentionMsecs
+	if minTimestamp < minTimestampExpected-5000 || minTimestamp > minTimestampExpected+5000 {
+		t.Fatalf("unexpected minTimestamp; got %d; want %d", minTimestamp, minTimestampExpected)
+	}
+}
+
+func TestStorageSubMonthRetentionMerge(t *testing.T) {
+	path := "TestStorageSubMonthRetentionMerge"
+	defer func() {
+		if err := os.RemoveAll(path); err != nil {
+			t.Fatalf("cannot remove storage %q: %s", path, err)
+		}
+	}()
+
+	// Add samples on both sides of 14d retention boundary with a bigger retention,
+	// so they aren't rejected during ingestion.
+	const rowsPerSide = 30
+	const retentionMsecs = 14 * msecPerDay
+	now := timestampFromTime(time.Now())
+	boundary := now - retentionMsecs
+	s, err := OpenStorage(path, 30*msecPerDay, 0, 0)
+	if err != nil {
+		t.Fatalf("cannot open storage: %s", err)
+	}
+	mn := MetricName{
+		MetricGroup: []byte("metric"),
+	}
+	metricNameRaw := mn.marshalRaw(nil)
+	var mrs []MetricRow
+	for i := 0; i < rowsPerSide; i++ {
+		// Leave 10 minutes gap around the boundary, so the test doesn't depend on its duration.
+		mrs = append(mrs, MetricRow{
+			MetricNameRaw: metricNameRaw,
+			Timestamp:     boundary - 10*60*1000 - int64(i)*60*1000,
+			Value:         float64(i),
+		}, MetricRow{
+			MetricNameRaw: metricNameRaw,
+			Timestamp:     boundary + 10*60*1000 + int64(i)*60*1000,
+			Value:         float64(i),
+		})
+	}
+	if err := s.AddRows(mrs, defaultPrecisionBits); err != nil {
+		t.Fatalf("cannot add rows: %s", err)
+	}
+	s.MustClose()
+
+	// The older samples belong to the partition, which isn't dropped after decreasing the retention,
+	// unless they belong to the previous month.
+	partitionStart := time.Unix(boundary/1000, 0).UTC()
+	partitionStart = time.Date(partitionStart.Year(), partitionStart.Month(), 1, 0, 0, 0, 0, time.UTC)
+	oldRowsInPartition := 0
+	for _, mr := range mrs {
+		if mr.Timestamp < boundary && mr.Timestamp >= timestampFromTime(partitionStart) {
+			oldRowsInPartition++
+		}
+	}
+
+	s, err = OpenStorage(path, retentionMsecs, 0, 0)
+	if err != nil {
+		t.Fatalf("cannot re-open storage: %s", err)
+	}
+	defer s.MustClose()
+
+	// Force merge for all the parts, so the samples outside the retention are dropped.
+	ptws := s.tb.GetPartitions(nil)
+	for _, ptw := range ptws {
+		pt := ptw.pt
+		var pws []*partWrapper
+		pt.partsLock.Lock()
+		for _, pw := range append(pt.smallParts, pt.bigParts...) {
+			if !pw.isInMerge {
+				pw.isInMerge = true
+				pws = append(pws, pw)
+			}
+		}
+		pt.partsLock.Unlock()
+		if err := pt.mergeParts(pws, nil); err != nil && err != errNothingToMerge {
+			t.Fatalf("cannot merge parts: %s", err)
+		}
+	}
+	s.tb.PutPartitions(ptws)
+
+	var m Metrics
+	s.UpdateMetrics(&m)
+	rowsDeleted := m.TableMetrics.SmallRowsDeleted + m.TableMetrics.BigRowsDeleted
+	if rowsDeleted != uint64(oldRowsInPartition) {
+		t.Fatalf("unexpected number of deleted rows; got %d; want %d", rowsDeleted, oldRowsInPartition)
+	}
+
+	tfs := NewTagFilters()
+	if err := tfs.Add(nil, []byte("metric"), false, false); err != nil {
+		t.Fatalf("cannot add tag filter: %s", err)
+	}
+	tr := TimeRange{
+		MinTimestamp: boundary - msecPerDay,
+		MaxTimestamp: now,
+	}
+	rowsCount := 0
+	var sr Search
+	sr.Init(nil, s, []*TagFilters{tfs}, tr, 1e5, noDeadline)
+	var b Block
+	for sr.NextMetricBlock() {
+		sr.MetricBlockRef.BlockRef.MustReadBlock(&b, true)
+		if err := b.UnmarshalData(); err != nil {
+			t.Fatalf("cannot unmarshal block data: %s", err)
+		}
+		for _, timestamp := range b.Timestamps() {
+			if timestamp < boundary {
+				t.Fatalf("unexpected sample outside the retention: timestamp=%d, boundary=%d", timestamp, boundary)
+			}
+			rowsCount++
+		}
+	}
+	if err := sr.Error(); err != nil {
+		t.Fatalf("search error: %s", err)
+	}
+	sr.MustClose()
+	if rowsCount != rowsPerSide {
+		t.Fatalf("unexpected number of samples; got %d; want %d", rowsCount, rowsPerSide)
+	}
+}
+
+func TestStorageSubMonthRetentionWithoutIngestion(t *testing.T) {
+	path := "TestStorageSubMonthRetentionWithoutIngestion"
+	defer func() {
+		if err := os.RemoveAll(path); err != nil {
+			t.Fatalf("cannot remove storage %q: %s", path, err)
+		}
+	}()
+
+	const rowsPerPart = 30
+	const retentionMsecs = 14 * msecPerDay
+	now := timestampFromTime(time.Now())
+	boundary := now - retentionMsecs
+	mn := MetricName{
+		MetricGroup: []byte("metric"),
+	}
+	metricNameRaw := mn.marshalRaw(nil)
+
+	// Add samples outside and inside 14d retention into distinct parts with a bigger retention,
+	// so they aren't rejected during ingestion.
+	addRows := func(startTimestamp int64) {
+		t.Helper()
+		s, err := OpenStorage(path, 30*msecPerDay, 0, 0)
+		if err != nil {
+			t.Fatalf("cannot open storage: %s", err)
+		}
+		var mrs []MetricRow
+		for i := 0; i < rowsPerPart; i++ {
+			mrs = append(mrs, MetricRow{
+				MetricNameRaw: metricNameRaw,
+				Timestamp:     startTimestamp + int64(i)*60*1000,
+				Value:         float64(i),
+			})
+		}
+		if err := s.AddRows(mrs, defaultPrecisionBits); err != nil {
+			t.Fatalf("cannot add rows: %s", err)
+		}
+		s.MustClose()
+	}
+	// Leave 10 minutes gap around the boundary, so the test doesn't depend on its duration.
+	addRows(boundary - 10*60*1000 - rowsPerPart*60*1000)
+	addRows(boundary + 10*60*1000)
+
+	s, err := OpenStorage(path, retentionMsecs, 0, 0)
+	if err != nil {
+		t.Fatalf("cannot re-open storage: %s", err)
+	}
+	defer s.MustClose()
+
+	// The parts outside the retention must be dropped without new ingestion.
+	s.tb.dropPartsOutsideRetention(timestampFromTime(time.Now()) - retentionMsecs)
+
+	var m Metrics
+	s.UpdateMetrics(&m)
+	rowsDeleted := m.TableMetrics.SmallRowsDeleted + m.TableMetrics.BigRowsDeleted
+	if rowsDeleted != rowsPerPart {
+		t.Fatalf("unexpected number of deleted rows; got %d; want %d", rowsDeleted, rowsPerPart)
+	}
+	partsCount := m.TableMetrics.SmallPartsCount + m.TableMetrics.BigPartsCount
+	if partsCount != 1 {
+		t.Fatalf("unexpected number of parts; got %d; want 1", partsCount)
+	}
+	rowsCount := m.TableMetrics.SmallRowsCount + m.TableMetrics.BigRowsCount
+	if rowsCount != rowsPerPart {
+		t.Fatalf("unexpected number of rows; got %d; want %d", rowsCount, rowsPerPart)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/encoding"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/fasttime"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logger"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/querytracer"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/storagepacelimiter"
 )
 
@@ -18,16 +19,23 @@ import (
 type BlockRef struct {
 	p  *part
 	bh blockHeader
+
+	// minTimestamp is the minimum timestamp for samples in the block according to retention filters.
+	// Samples with smaller timestamps are removed from the block in MustReadBlock.
+	// Zero value means there is no limit.
+	minTimestamp int64
 }
 
 func (br *BlockRef) reset() {
 	br.p = nil
 	br.bh = blockHeader{}
+	br.minTimestamp = 0
 }
 
 func (br *BlockRef) init(p *part, bh *blockHeader) {
 	br.p = p
 	br.bh = *bh
+	br.minTimestamp = 0
 }
 
 // MustReadBlock reads block from br to dst.
@@ -45,6 +53,23 @@ func (br *BlockRef) MustReadBlock(dst *Block, fetchData bool) {
 
 	dst.valuesData = bytesutil.Resize(dst.valuesData[:0], int(br.bh.ValuesBlockSize))
 	br.p.valuesFile.MustReadAt(dst.valuesData, int64(br.bh.ValuesBlockOffset))
+
+	if br.minTimestamp > 0 && br.minTimestamp > br.bh.MinTimestamp {
+		// Remove samples outside the retention set via retention filters.
+		if err := dst.UnmarshalData(); err != nil {
+			logger.Panicf("FATAL: cannot unmarshal block from part %q: %s", br.p.path, err)
+		}
+		n := 0
+		for n < len(dst.timestamps) && dst.timestamps[n] < br.minTimestamp {
+			n++
+		}
+		dst.timestamps = append(dst.timestamps[:0], dst.timestamps[n:]...)
+		dst.values = append(dst.values[:0], dst.values[n:]...)
+		dst.bh.RowsCount = uint32(len(dst.timestamps))
+		if len(dst.timestamps) > 0 {
+			dst.fixupTimestamps()
+		}
+	}
 }
 
 // MetricBlockRef contains reference to time series block for a single metric.
@@ -100,7 +125,11 @@ func (s *Search) reset() {
 // MustClose must be called when the search is done.
 //
 // Init returns the upper bound on the number of found time series.
-func (s *Search) Init(storage *Storage, tfss []*TagFilters, tr TimeRange, maxMetrics int, deadline uint64) int {
+//
+// qt may be nil if query tracing is disabled.
+func (s *Search) Init(qt *querytracer.Tracer, storage *Storage, tfss []*TagFilters, tr TimeRange, maxMetrics int, deadline uint64) int {
+	qt = qt.NewChild("init series search: filters=%s, timeRange=%s", tfss, &tr)
+	defer qt.Done()
 	if s.needClosing {
 		logger.Panicf("BUG: missing MustClose call before the next call to Init")
 	}
@@ -111,9 +140,9 @@ func (s *Search) Init(storage *Storage, tfss []*TagFilters, tr TimeRange, maxMet
 	s.deadline = deadline
 	s.needClosing = true
 
-	tsids, err := storage.searchTSIDs(tfss, tr, maxMetrics, deadline)
+	tsids, err := storage.searchTSIDs(qt, tfss, tr, maxMetrics, deadline)
 	if err == nil {
-		err = storage.prefetchMetricNames(tsids, deadline)
+		err = storage.prefetchMetricNames(qt, tsids, deadline)
 	}
 	// It is ok to call Init on error from storage.searchTSIDs.
 	// Init must be called before returning because it will fail
@@ -160,6 +189,13 @@ func (s *Search) NextMetricBlock() bool {
 		}
 		s.loops++
 		tsid := &s.ts.BlockRef.bh.TSID
+		if minTimestamp := s.storage.getMinTimestampForMetricID(tsid.MetricID); minTimestamp > 0 && minTimestamp > s.ts.BlockRef.bh.MinTimestamp {
+			if s.ts.BlockRef.bh.MaxTimestamp < minTimestamp {
+				// Skip the block outside the retention set via retention filters.
+				continue
+			}
+			s.ts.BlockRef.minTimestamp = minTimestamp
+		}
 		var err error
 		s.MetricBlockRef.MetricName, err = s.storage.searchMetricName(s.MetricBlockRef.MetricName[:0], tsid.MetricID)
 		if err != nil {
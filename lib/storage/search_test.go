@@ -81,7 +81,7 @@ func TestSearch(t *testing.T) {
 
 func testSearchGeneric(t *testing.T, forcePerDayInvertedIndex bool) {
 	path := fmt.Sprintf("TestSearch_%v", forcePerDayInvertedIndex)
-	st, err := OpenStorage(path, 0)
+	st, err := OpenStorage(path, 0, 0, 0)
 	if err != nil {
 		t.Fatalf("cannot open storage %q: %s", path, err)
 	}
@@ -130,7 +130,7 @@ func testSearchGeneric(t *testing.T, forcePerDayInvertedIndex bool) {
 
 	// Re-open the storage in order to flush all the pending cached data.
 	st.MustClose()
-	st, err = OpenStorage(path, 0)
+	st, err = OpenStorage(path, 0, 0, 0)
 	if err != nil {
 		t.Fatalf("cannot re-open storage %q: %s", path, err)
 	}
@@ -218,7 +218,7 @@ func testSearchInternal(st *Storage, tr TimeRange, mrs []MetricRow, accountsCoun
 		}
 
 		// Search
-		s.Init(st, []*TagFilters{tfs}, tr, 1e5, noDeadline)
+		s.Init(nil, st, []*TagFilters{tfs}, tr, 1e5, noDeadline)
 		var mbs []metricBlock
 		for s.NextMetricBlock() {
 			var b Block
@@ -15,19 +15,22 @@ import (
 	"time"
 	"unsafe"
 
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/bloomfilter"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/encoding"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/fasttime"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/fs"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logger"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/memory"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/querytracer"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/storagepacelimiter"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/timerpool"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/uint64set"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/workingsetcache"
 	"github.com/VictoriaMetrics/fastcache"
+	"github.com/cespare/xxhash/v2"
 )
 
-const maxRetentionMonths = 12 * 100
+const maxRetentionMsecs = 100 * 12 * msecPerMonth
 
 // Storage represents TSDB storage.
 type Storage struct {
@@ -47,9 +50,12 @@ type Storage struct {
 	slowPerDayIndexInserts uint64
 	slowMetricNameLoads    uint64
 
-	path            string
-	cachePath       string
-	retentionMonths int
+	hourlySeriesLimitRowsDropped uint64
+	dailySeriesLimitRowsDropped  uint64
+
+	path           string
+	cachePath      string
+	retentionMsecs int64
 
 	// lock file for exclusive access to the storage on the given path.
 	flockF *os.File
@@ -93,6 +99,15 @@ type Storage struct {
 	// metricIDs for pre-fetched metricNames in the prefetchMetricNames function.
 	prefetchedMetricIDs atomic.Value
 
+	// retentionFiltersCache caches retentions from retention filters per metricID.
+	retentionFiltersCache retentionFiltersCache
+
+	// hourlySeriesLimiter limits the number of unique series, which can be added to the storage during the last hour.
+	hourlySeriesLimiter *bloomfilter.Limiter
+
+	// dailySeriesLimiter limits the number of unique series, which can be added to the storage during the last 24 hours.
+	dailySeriesLimiter *bloomfilter.Limiter
+
 	stop chan struct{}
 
 	currHourMetricIDsUpdaterWG sync.WaitGroup
@@ -106,13 +121,18 @@ type Storage struct {
 	snapshotLock sync.Mutex
 }
 
-// OpenStorage opens storage on the given path with the given number of retention months.
-func OpenStorage(path string, retentionMonths int) (*Storage, error) {
-	if retentionMonths > maxRetentionMonths {
-		return nil, fmt.Errorf("too big retentionMonths=%d; cannot exceed %d", retentionMonths, maxRetentionMonths)
+// OpenStorage opens storage on the given path with the given retentionMsecs.
+//
+// The maximum possible retention is used if retentionMsecs <= 0.
+//
+// maxHourlySeries and maxDailySeries limit the number of unique series, which can be added
+// to the storage during the last hour and the last 24 hours. Zero value disables the corresponding limit.
+func OpenStorage(path string, retentionMsecs int64, maxHourlySeries, maxDailySeries int) (*Storage, error) {
+	if retentionMsecs > maxRetentionMsecs {
+		return nil, fmt.Errorf("too big retentionMsecs=%d; cannot exceed %d", retentionMsecs, maxRetentionMsecs)
 	}
-	if retentionMonths <= 0 {
-		retentionMonths = maxRetentionMonths
+	if retentionMsecs <= 0 {
+		retentionMsecs = maxRetentionMsecs
 	}
 	path, err := filepath.Abs(path)
 	if err != nil {
@@ -120,12 +140,18 @@ func OpenStorage(path string, retentionMonths int) (*Storage, error) {
 	}
 
 	s := &Storage{
-		path:            path,
-		cachePath:       path + "/cache",
-		retentionMonths: retentionMonths,
+		path:           path,
+		cachePath:      path + "/cache",
+		retentionMsecs: retentionMsecs,
 
 		stop: make(chan struct{}),
 	}
+	if maxHourlySeries > 0 {
+		s.hourlySeriesLimiter = bloomfilter.NewLimiter(maxHourlySeries, time.Hour)
+	}
+	if maxDailySeries > 0 {
+		s.dailySeriesLimiter = bloomfilter.NewLimiter(maxDailySeries, 24*time.Hour)
+	}
 
 	if err := fs.MkdirAllIfNotExist(path); err != nil {
 		return nil, fmt.Errorf("cannot create a directory for the storage at %q: %w", path, err)
@@ -178,7 +204,7 @@ func OpenStorage(path string, retentionMonths int) (*Storage, error) {
 
 	// Load data
 	tablePath := path + "/data"
-	tb, err := openTable(tablePath, retentionMonths, s.getDeletedMetricIDs)
+	tb, err := openTable(tablePath, retentionMsecs, s.getDeletedMetricIDs, s.getMinTimestampForMetricID)
 	if err != nil {
 		s.idb().MustClose()
 		return nil, fmt.Errorf("cannot open table at %q: %w", tablePath, err)
@@ -192,8 +218,8 @@ func OpenStorage(path string, retentionMonths int) (*Storage, error) {
 	return s, nil
 }
 
-// debugFlush flushes recently added storage data, so it becomes visible to search.
-func (s *Storage) debugFlush() {
+// DebugFlush flushes recently added storage data, so it becomes visible to search.
+func (s *Storage) DebugFlush() {
 	s.tb.flushRawRows()
 	s.idb().tb.DebugFlush()
 }
@@ -318,7 +344,8 @@ func (s *Storage) idb() *indexDB {
 
 // Metrics contains essential metrics for the Storage.
 type Metrics struct {
-	DedupsDuringMerge uint64
+	DedupsDuringMerge          uint64
+	DownsampledRowsDuringMerge uint64
 
 	TooSmallTimestampRows uint64
 	TooBigTimestampRows   uint64
@@ -340,6 +367,14 @@ type Metrics struct {
 	SlowPerDayIndexInserts uint64
 	SlowMetricNameLoads    uint64
 
+	HourlySeriesLimitRowsDropped   uint64
+	HourlySeriesLimitMaxSeries     uint64
+	HourlySeriesLimitCurrentSeries uint64
+
+	DailySeriesLimitRowsDropped   uint64
+	DailySeriesLimitMaxSeries     uint64
+	DailySeriesLimitCurrentSeries uint64
+
 	TimestampsBlocksMerged uint64
 	TimestampsBytesSaved   uint64
 
@@ -387,6 +422,7 @@ func (m *Metrics) Reset() {
 // UpdateMetrics updates m with metrics from s.
 func (s *Storage) UpdateMetrics(m *Metrics) {
 	m.DedupsDuringMerge = atomic.LoadUint64(&dedupsDuringMerge)
+	m.DownsampledRowsDuringMerge = atomic.LoadUint64(&downsampledRowsDuringMerge)
 
 	m.TooSmallTimestampRows += atomic.LoadUint64(&s.tooSmallTimestampRows)
 	m.TooBigTimestampRows += atomic.LoadUint64(&s.tooBigTimestampRows)
@@ -408,6 +444,18 @@ func (s *Storage) UpdateMetrics(m *Metrics) {
 	m.SlowPerDayIndexInserts += atomic.LoadUint64(&s.slowPerDayIndexInserts)
 	m.SlowMetricNameLoads += atomic.LoadUint64(&s.slowMetricNameLoads)
 
+	if sl := s.hourlySeriesLimiter; sl != nil {
+		m.HourlySeriesLimitRowsDropped += atomic.LoadUint64(&s.hourlySeriesLimitRowsDropped)
+		m.HourlySeriesLimitMaxSeries += uint64(sl.MaxItems())
+		m.HourlySeriesLimitCurrentSeries += uint64(sl.CurrentItems())
+	}
+
+	if sl := s.dailySeriesLimiter; sl != nil {
+		m.DailySeriesLimitRowsDropped += atomic.LoadUint64(&s.dailySeriesLimitRowsDropped)
+		m.DailySeriesLimitMaxSeries += uint64(sl.MaxItems())
+		m.DailySeriesLimitCurrentSeries += uint64(sl.CurrentItems())
+	}
+
 	m.TimestampsBlocksMerged = atomic.LoadUint64(&timestampsBlocksMerged)
 	m.TimestampsBytesSaved = atomic.LoadUint64(&timestampsBytesSaved)
 
@@ -472,7 +520,7 @@ func (s *Storage) startRetentionWatcher() {
 
 func (s *Storage) retentionWatcher() {
 	for {
-		d := nextRetentionDuration(s.retentionMonths)
+		d := nextRetentionDuration(s.retentionMsecs)
 		select {
 		case <-s.stop:
 			return
@@ -576,6 +624,13 @@ func (s *Storage) MustClose() {
 	s.tb.MustClose()
 	s.idb().MustClose()
 
+	if sl := s.hourlySeriesLimiter; sl != nil {
+		sl.MustStop()
+	}
+	if sl := s.dailySeriesLimiter; sl != nil {
+		sl.MustStop()
+	}
+
 	// Save caches.
 	s.mustSaveAndStopCache(s.tsidCache, "MetricName->TSID", "metricName_tsid")
 	s.mustSaveAndStopCache(s.metricIDCache, "MetricID->TSID", "metricID_tsid")
@@ -781,21 +836,22 @@ func (s *Storage) mustSaveAndStopCache(c *workingsetcache.Cache, info, name stri
 		info, path, time.Since(startTime).Seconds(), cs.EntriesCount, cs.BytesSize)
 }
 
-func nextRetentionDuration(retentionMonths int) time.Duration {
-	t := time.Now().UTC()
-	n := t.Year()*12 + int(t.Month()) - 1 + retentionMonths
-	n -= n % retentionMonths
-	y := n / 12
-	m := time.Month((n % 12) + 1)
+func nextRetentionDuration(retentionMsecs int64) time.Duration {
+	// Round retentionMsecs to days. This guarantees that per-day inverted index works as expected.
+	retentionMsecs = ((retentionMsecs + msecPerDay - 1) / msecPerDay) * msecPerDay
+	t := time.Now().UnixNano() / 1e6
+	deadline := ((t + retentionMsecs - 1) / retentionMsecs) * retentionMsecs
 	// Schedule the deadline to +4 hours from the next retention period start.
 	// This should prevent from possible double deletion of indexdb
 	// due to time drift - see https://github.com/VictoriaMetrics/VictoriaMetrics/issues/248 .
-	deadline := time.Date(y, m, 1, 4, 0, 0, 0, time.UTC)
-	return deadline.Sub(t)
+	deadline += 4 * msecPerHour
+	return time.Duration(deadline-t) * time.Millisecond
 }
 
 // searchTSIDs returns sorted TSIDs for the given tfss and the given tr.
-func (s *Storage) searchTSIDs(tfss []*TagFilters, tr TimeRange, maxMetrics int, deadline uint64) ([]TSID, error) {
+func (s *Storage) searchTSIDs(qt *querytracer.Tracer, tfss []*TagFilters, tr TimeRange, maxMetrics int, deadline uint64) ([]TSID, error) {
+	qt = qt.NewChild("search for matching tsids")
+	defer qt.Done()
 	// Do not cache tfss -> tsids here, since the caching is performed
 	// on idb level.
 
@@ -829,6 +885,7 @@ func (s *Storage) searchTSIDs(tfss []*TagFilters, tr TimeRange, maxMetrics int,
 	if err != nil {
 		return nil, fmt.Errorf("error when searching tsids: %w", err)
 	}
+	qt.Printf("found %d matching series", len(tsids))
 	return tsids, nil
 }
 
@@ -842,8 +899,9 @@ var (
 // prefetchMetricNames pre-fetches metric names for the given tsids into metricID->metricName cache.
 //
 // This should speed-up further searchMetricName calls for metricIDs from tsids.
-func (s *Storage) prefetchMetricNames(tsids []TSID, deadline uint64) error {
+func (s *Storage) prefetchMetricNames(qt *querytracer.Tracer, tsids []TSID, deadline uint64) error {
 	if len(tsids) == 0 {
+		qt.Printf("nothing to prefetch")
 		return nil
 	}
 	var metricIDs uint64Sorter
@@ -858,6 +916,7 @@ func (s *Storage) prefetchMetricNames(tsids []TSID, deadline uint64) error {
 	}
 	if len(metricIDs) < 500 {
 		// It is cheaper to skip pre-fetching and obtain metricNames inline.
+		qt.Printf("skip pre-fetching metric names for low number of metric ids=%d", len(metricIDs))
 		return nil
 	}
 	atomic.AddUint64(&s.slowMetricNameLoads, uint64(len(metricIDs)))
@@ -890,6 +949,7 @@ func (s *Storage) prefetchMetricNames(tsids []TSID, deadline uint64) error {
 		prefetchedMetricIDsNew = &uint64set.Set{}
 	}
 	s.prefetchedMetricIDs.Store(prefetchedMetricIDsNew)
+	qt.Printf("pre-fetch metric names for %d metric ids", len(metricIDs))
 	return nil
 }
 
@@ -977,11 +1037,18 @@ func (s *Storage) GetSeriesCount(deadline uint64) (uint64, error) {
 	return s.idb().GetSeriesCount(deadline)
 }
 
-// GetTSDBStatusForDate returns TSDB status data for /api/v1/status/tsdb.
+// GetTSDBStatusWithFilters returns TSDB status data for /api/v1/status/tsdb for the given tfss, tr and focusLabel.
 //
 // See https://prometheus.io/docs/prometheus/latest/querying/api/#tsdb-stats
-func (s *Storage) GetTSDBStatusForDate(date uint64, topN int, deadline uint64) (*TSDBStatus, error) {
-	return s.idb().GetTSDBStatusForDate(date, topN, deadline)
+func (s *Storage) GetTSDBStatusWithFilters(tfss []*TagFilters, tr TimeRange, focusLabel string, topN, maxMetrics int, deadline uint64) (*TSDBStatus, error) {
+	return s.idb().GetTSDBStatusWithFilters(tfss, tr, focusLabel, topN, maxMetrics, deadline)
+}
+
+// GetTSDBStatusForEntries returns TSDB status data with the same entries as in the given status for the given tfss, tr and focusLabel.
+//
+// It is used for comparing TSDB status data for distinct time ranges.
+func (s *Storage) GetTSDBStatusForEntries(tfss []*TagFilters, tr TimeRange, focusLabel string, status *TSDBStatus, maxMetrics int, deadline uint64) (*TSDBStatus, error) {
+	return s.idb().GetTSDBStatusForEntries(tfss, tr, focusLabel, status, maxMetrics, deadline)
 }
 
 // MetricRow is a metric to insert into storage.
@@ -1088,6 +1155,46 @@ func (s *Storage) AddRows(mrs []MetricRow, precisionBits uint8) error {
 	return err
 }
 
+// RegisterMetricNames registers all the metric names from mrs in the indexdb, so they can be queried later.
+//
+// The MetricRow.Timestamp is used for registering the metric name starting from the given timestamp.
+// The MetricRow.Value field is ignored.
+func (s *Storage) RegisterMetricNames(mrs []MetricRow) error {
+	var (
+		tsid       TSID
+		mn         MetricName
+		metricName []byte
+	)
+	rows := make([]rawRow, 0, len(mrs))
+	idb := s.idb()
+	is := idb.getIndexSearch(noDeadline)
+	defer idb.putIndexSearch(is)
+	for i := range mrs {
+		mr := &mrs[i]
+		if !s.getTSIDFromCache(&tsid, mr.MetricNameRaw) {
+			// Slow path - register mr.MetricNameRaw.
+			if err := mn.unmarshalRaw(mr.MetricNameRaw); err != nil {
+				return fmt.Errorf("cannot register the metric because cannot unmarshal MetricNameRaw %q: %w", mr.MetricNameRaw, err)
+			}
+			mn.sortTags()
+			metricName = mn.Marshal(metricName[:0])
+			if err := is.GetOrCreateTSIDByName(&tsid, metricName); err != nil {
+				return fmt.Errorf("cannot register the metric because cannot create TSID for metricName %q: %w", metricName, err)
+			}
+			s.putTSIDToCache(&tsid, mr.MetricNameRaw)
+		}
+		rows = append(rows, rawRow{
+			TSID:      tsid,
+			Timestamp: mr.Timestamp,
+		})
+	}
+	// Register the metric names in per-day inverted index, so they could be found in searches on the given time range.
+	if err := s.updatePerDateData(rows); err != nil {
+		return fmt.Errorf("cannot register metric names in per-day index: %w", err)
+	}
+	return nil
+}
+
 var (
 	// Limit the concurrency for data ingestion to GOMAXPROCS, since this operation
 	// is CPU bound, so there is no sense in running more than GOMAXPROCS concurrent
@@ -1145,6 +1252,12 @@ func (s *Storage) add(rows []rawRow, mrs []MetricRow, precisionBits uint8) ([]ra
 			atomic.AddUint64(&s.tooBigTimestampRows, 1)
 			continue
 		}
+		if string(mr.MetricNameRaw) != string(prevMetricNameRaw) && !s.registerSeriesCardinality(mr.MetricNameRaw) {
+			// Skip row, since it exceeds cardinality limit.
+			// The check is performed before the TSID lookup, so the dropped series
+			// doesn't create new entries in indexdb.
+			continue
+		}
 		r := &rows[rowsLen+j]
 		j++
 		r.Timestamp = mr.Timestamp
@@ -1249,6 +1362,57 @@ func (s *Storage) add(rows []rawRow, mrs []MetricRow, precisionBits uint8) ([]ra
 	return rows, nil
 }
 
+// registerSeriesCardinality registers the series with the given metricNameRaw in series limiters.
+//
+// False is returned if the series cannot be added because of -storage.maxHourlySeries or -storage.maxDailySeries limits.
+func (s *Storage) registerSeriesCardinality(metricNameRaw []byte) bool {
+	hsl := s.hourlySeriesLimiter
+	dsl := s.dailySeriesLimiter
+	if hsl == nil && dsl == nil {
+		return true
+	}
+	h := xxhash.Sum64(metricNameRaw)
+	if hsl != nil && !hsl.Add(h) {
+		atomic.AddUint64(&s.hourlySeriesLimitRowsDropped, 1)
+		logSkippedSeries(metricNameRaw, "-storage.maxHourlySeries", hsl.MaxItems())
+		return false
+	}
+	if dsl != nil && !dsl.Add(h) {
+		atomic.AddUint64(&s.dailySeriesLimitRowsDropped, 1)
+		logSkippedSeries(metricNameRaw, "-storage.maxDailySeries", dsl.MaxItems())
+		return false
+	}
+	return true
+}
+
+// logSkippedSeries logs the skipped series at most once per logSkippedSeriesInterval,
+// so the log isn't flooded when the limit is reached.
+func logSkippedSeries(metricNameRaw []byte, flagName string, flagValue int) {
+	currentTime := fasttime.UnixTimestamp()
+	lastTime := atomic.LoadUint64(&logSkippedSeriesLastTime)
+	if currentTime < lastTime+logSkippedSeriesInterval {
+		return
+	}
+	if !atomic.CompareAndSwapUint64(&logSkippedSeriesLastTime, lastTime, currentTime) {
+		// Another goroutine has just logged the skipped series.
+		return
+	}
+	logger.Warnf("skip series %s because %s=%d reached", getUserReadableMetricName(metricNameRaw), flagName, flagValue)
+}
+
+// logSkippedSeriesInterval is the minimum interval in seconds between logSkippedSeries messages.
+const logSkippedSeriesInterval = 5
+
+var logSkippedSeriesLastTime uint64
+
+func getUserReadableMetricName(metricNameRaw []byte) string {
+	var mn MetricName
+	if err := mn.unmarshalRaw(metricNameRaw); err != nil {
+		return fmt.Sprintf("cannot unmarshal metricNameRaw %q: %s", metricNameRaw, err)
+	}
+	return mn.String()
+}
+
 type pendingMetricRow struct {
 	MetricName []byte
 	mr         MetricRow
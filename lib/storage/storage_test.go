@@ -307,12 +307,16 @@ func TestMetricRowMarshalUnmarshal(t *testing.T) {
 }
 
 func TestNextRetentionDuration(t *testing.T) {
-	for retentionMonths := 1; retentionMonths < 360; retentionMonths++ {
-		d := nextRetentionDuration(retentionMonths)
+	for retentionMonths := float64(0.1); retentionMonths < 120; retentionMonths += 0.3 {
+		retentionMsecs := int64(retentionMonths * msecPerMonth)
+		d := nextRetentionDuration(retentionMsecs)
 		if d <= 0 {
 			currTime := time.Now().UTC()
 			nextTime := time.Now().UTC().Add(d)
-			t.Fatalf("unexected retention duration for retentionMonths=%d; got %s; must be %s + %d months", retentionMonths, nextTime, currTime, retentionMonths)
+			t.Fatalf("unexected retention duration for retentionMonths=%f; got %s; must be %s + %f months", retentionMonths, nextTime, currTime, retentionMonths)
+		}
+		if d > time.Duration(retentionMsecs+msecPerDay+4*msecPerHour)*time.Millisecond {
+			t.Fatalf("too big retention duration for retentionMonths=%f; got %s", retentionMonths, d)
 		}
 	}
 }
@@ -320,7 +324,7 @@ func TestNextRetentionDuration(t *testing.T) {
 func TestStorageOpenClose(t *testing.T) {
 	path := "TestStorageOpenClose"
 	for i := 0; i < 10; i++ {
-		s, err := OpenStorage(path, -1)
+		s, err := OpenStorage(path, -1, 0, 0)
 		if err != nil {
 			t.Fatalf("cannot open storage: %s", err)
 		}
@@ -333,13 +337,13 @@ func TestStorageOpenClose(t *testing.T) {
 
 func TestStorageOpenMultipleTimes(t *testing.T) {
 	path := "TestStorageOpenMultipleTimes"
-	s1, err := OpenStorage(path, -1)
+	s1, err := OpenStorage(path, -1, 0, 0)
 	if err != nil {
 		t.Fatalf("cannot open storage the first time: %s", err)
 	}
 
 	for i := 0; i < 10; i++ {
-		s2, err := OpenStorage(path, -1)
+		s2, err := OpenStorage(path, -1, 0, 0)
 		if err == nil {
 			s2.MustClose()
 			t.Fatalf("expecting non-nil error when opening already opened storage")
@@ -353,8 +357,8 @@ func TestStorageOpenMultipleTimes(t *testing.T) {
 
 func TestStorageRandTimestamps(t *testing.T) {
 	path := "TestStorageRandTimestamps"
-	retentionMonths := 60
-	s, err := OpenStorage(path, retentionMonths)
+	retentionMsecs := int64(60 * msecPerMonth)
+	s, err := OpenStorage(path, retentionMsecs, 0, 0)
 	if err != nil {
 		t.Fatalf("cannot open storage: %s", err)
 	}
@@ -364,7 +368,7 @@ func TestStorageRandTimestamps(t *testing.T) {
 				t.Fatal(err)
 			}
 			s.MustClose()
-			s, err = OpenStorage(path, retentionMonths)
+			s, err = OpenStorage(path, retentionMsecs, 0, 0)
 		}
 	})
 	t.Run("concurrent", func(t *testing.T) {
@@ -447,7 +451,7 @@ func testStorageRandTimestamps(s *Storage) error {
 
 func TestStorageDeleteMetrics(t *testing.T) {
 	path := "TestStorageDeleteMetrics"
-	s, err := OpenStorage(path, 0)
+	s, err := OpenStorage(path, 0, 0, 0)
 	if err != nil {
 		t.Fatalf("cannot open storage: %s", err)
 	}
@@ -470,7 +474,7 @@ func TestStorageDeleteMetrics(t *testing.T) {
 			// Re-open the storage in order to check how deleted metricIDs
 			// are persisted.
 			s.MustClose()
-			s, err = OpenStorage(path, 0)
+			s, err = OpenStorage(path, 0, 0, 0)
 			if err != nil {
 				t.Fatalf("cannot open storage after closing on iteration %d: %s", i, err)
 			}
@@ -557,7 +561,7 @@ func testStorageDeleteMetrics(s *Storage, workerNum int) error {
 			return fmt.Errorf("unexpected error when adding mrs: %w", err)
 		}
 	}
-	s.debugFlush()
+	s.DebugFlush()
 
 	// Verify tag values exist
 	tvs, err := s.SearchTagValues(workerTag, 1e5, noDeadline)
@@ -585,7 +589,7 @@ func testStorageDeleteMetrics(s *Storage, workerNum int) error {
 	metricBlocksCount := func(tfs *TagFilters) int {
 		// Verify the number of blocks
 		n := 0
-		sr.Init(s, []*TagFilters{tfs}, tr, 1e5, noDeadline)
+		sr.Init(nil, s, []*TagFilters{tfs}, tr, 1e5, noDeadline)
 		for sr.NextMetricBlock() {
 			n++
 		}
@@ -666,7 +670,7 @@ func checkTagKeys(tks []string, tksExpected map[string]bool) error {
 
 func TestStorageAddRowsSerial(t *testing.T) {
 	path := "TestStorageAddRowsSerial"
-	s, err := OpenStorage(path, 0)
+	s, err := OpenStorage(path, 0, 0, 0)
 	if err != nil {
 		t.Fatalf("cannot open storage: %s", err)
 	}
@@ -679,9 +683,71 @@ func TestStorageAddRowsSerial(t *testing.T) {
 	}
 }
 
+func TestStorageRegisterMetricNames(t *testing.T) {
+	path := "TestStorageRegisterMetricNames"
+	s, err := OpenStorage(path, 0, 0, 0)
+	if err != nil {
+		t.Fatalf("cannot open storage: %s", err)
+	}
+	timestamp := int64(time.Now().UnixNano() / 1e6)
+	var mrs []MetricRow
+	var mn MetricName
+	for i := 0; i < 10; i++ {
+		mn.MetricGroup = []byte(fmt.Sprintf("foo.bar.metric_%d", i))
+		mn.Tags = []Tag{
+			{[]byte("job"), []byte("webservice")},
+		}
+		mrs = append(mrs, MetricRow{
+			MetricNameRaw: mn.marshalRaw(nil),
+			Timestamp:     timestamp,
+		})
+	}
+	// Register the same metric names twice in order to verify the registration is idempotent.
+	for i := 0; i < 2; i++ {
+		if err := s.RegisterMetricNames(mrs); err != nil {
+			t.Fatalf("unexpected error in RegisterMetricNames: %s", err)
+		}
+	}
+	s.DebugFlush()
+
+	// Verify the registered metric names are searchable via global index.
+	tvs, err := s.SearchTagValues(nil, 100, noDeadline)
+	if err != nil {
+		t.Fatalf("unexpected error in SearchTagValues: %s", err)
+	}
+	if len(tvs) != len(mrs) {
+		t.Fatalf("unexpected number of metric names; got %d; want %d; metric names: %q", len(tvs), len(mrs), tvs)
+	}
+
+	// Verify the registered metric names are searchable via per-day index.
+	tr := TimeRange{
+		MinTimestamp: timestamp - msecPerDay,
+		MaxTimestamp: timestamp,
+	}
+	suffixes, err := s.SearchTagValueSuffixes(tr, nil, []byte("foo.bar."), '.', 100, noDeadline)
+	if err != nil {
+		t.Fatalf("unexpected error in SearchTagValueSuffixes: %s", err)
+	}
+	if len(suffixes) != len(mrs) {
+		t.Fatalf("unexpected number of suffixes; got %d; want %d; suffixes: %q", len(suffixes), len(mrs), suffixes)
+	}
+
+	// Verify no data is stored for the registered metric names.
+	var m Metrics
+	s.UpdateMetrics(&m)
+	if m.TableMetrics.SmallRowsCount != 0 {
+		t.Fatalf("unexpected number of rows stored; got %d; want 0", m.TableMetrics.SmallRowsCount)
+	}
+
+	s.MustClose()
+	if err := os.RemoveAll(path); err != nil {
+		t.Fatalf("cannot remove %q: %s", path, err)
+	}
+}
+
 func TestStorageAddRowsConcurrent(t *testing.T) {
 	path := "TestStorageAddRowsConcurrent"
-	s, err := OpenStorage(path, 0)
+	s, err := OpenStorage(path, 0, 0, 0)
 	if err != nil {
 		t.Fatalf("cannot open storage: %s", err)
 	}
@@ -761,7 +827,7 @@ func testStorageAddRows(s *Storage) error {
 
 	// Try opening the storage from snapshot.
 	snapshotPath := s.path + "/snapshots/" + snapshotName
-	s1, err := OpenStorage(snapshotPath, 0)
+	s1, err := OpenStorage(snapshotPath, 0, 0, 0)
 	if err != nil {
 		return fmt.Errorf("cannot open storage from snapshot: %w", err)
 	}
@@ -792,7 +858,7 @@ func testStorageAddRows(s *Storage) error {
 
 func TestStorageRotateIndexDB(t *testing.T) {
 	path := "TestStorageRotateIndexDB"
-	s, err := OpenStorage(path, 0)
+	s, err := OpenStorage(path, 0, 0, 0)
 	if err != nil {
 		t.Fatalf("cannot open storage: %s", err)
 	}
@@ -874,6 +940,65 @@ func testStorageAddMetrics(s *Storage, workerNum int) error {
 	return nil
 }
 
+func TestStorageSeriesLimits(t *testing.T) {
+	f := func(maxHourlySeries, maxDailySeries int) {
+		t.Helper()
+		path := "TestStorageSeriesLimits"
+		s, err := OpenStorage(path, 0, maxHourlySeries, maxDailySeries)
+		if err != nil {
+			t.Fatalf("cannot open storage: %s", err)
+		}
+		const seriesCount = 100
+		timestamp := timestampFromTime(time.Now())
+		var mrs []MetricRow
+		var mn MetricName
+		for i := 0; i < seriesCount; i++ {
+			mn.MetricGroup = []byte(fmt.Sprintf("metric_%d", i))
+			mrs = append(mrs, MetricRow{
+				MetricNameRaw: mn.marshalRaw(nil),
+				Timestamp:     timestamp,
+				Value:         float64(i),
+			})
+		}
+		// Add the same series twice in order to verify the limits on both slow path and tsidCache path.
+		for i := 0; i < 2; i++ {
+			if err := s.AddRows(mrs, defaultPrecisionBits); err != nil {
+				t.Fatalf("unexpected error when adding mrs: %s", err)
+			}
+		}
+		var m Metrics
+		s.UpdateMetrics(&m)
+		s.MustClose()
+		if err := os.RemoveAll(path); err != nil {
+			t.Fatalf("cannot remove %q: %s", path, err)
+		}
+
+		maxSeries, currentSeries, rowsDropped := m.HourlySeriesLimitMaxSeries, m.HourlySeriesLimitCurrentSeries, m.HourlySeriesLimitRowsDropped
+		if maxDailySeries > 0 {
+			maxSeries, currentSeries, rowsDropped = m.DailySeriesLimitMaxSeries, m.DailySeriesLimitCurrentSeries, m.DailySeriesLimitRowsDropped
+		}
+		if currentSeries != maxSeries {
+			t.Fatalf("unexpected number of current series; got %d; want %d", currentSeries, maxSeries)
+		}
+		// Allow a few false positives in the underlying bloom filter.
+		minRowsDropped := 2*(seriesCount-maxSeries) - 10
+		if rowsDropped < minRowsDropped || rowsDropped > 2*(seriesCount-maxSeries) {
+			t.Fatalf("unexpected number of dropped rows; got %d; want in the range [%d ... %d]", rowsDropped, minRowsDropped, 2*(seriesCount-maxSeries))
+		}
+		// Dropped series mustn't be registered in indexdb.
+		maxSeriesCreated := seriesCount - rowsDropped/2
+		if m.IndexDBMetrics.NewTimeseriesCreated > maxSeriesCreated {
+			t.Fatalf("too many series created in indexdb; got %d; want no more than %d", m.IndexDBMetrics.NewTimeseriesCreated, maxSeriesCreated)
+		}
+		// Accepted series must be served from tsidCache on the second AddRows call.
+		if m.SlowRowInserts > maxSeriesCreated {
+			t.Fatalf("too many slow row inserts; got %d; want no more than %d", m.SlowRowInserts, maxSeriesCreated)
+		}
+	}
+	f(10, 0)
+	f(0, 20)
+}
+
 func containsString(a []string, s string) bool {
 	for i := range a {
 		if a[i] == s {
@@ -20,7 +20,8 @@ type table struct {
 	smallPartitionsPath string
 	bigPartitionsPath   string
 
-	getDeletedMetricIDs func() *uint64set.Set
+	getDeletedMetricIDs        func() *uint64set.Set
+	getMinTimestampForMetricID func(metricID uint64) int64
 
 	ptws     []*partitionWrapper
 	ptwsLock sync.Mutex
@@ -29,8 +30,10 @@ type table struct {
 
 	stop chan struct{}
 
-	retentionMilliseconds int64
-	retentionWatcherWG    sync.WaitGroup
+	retentionMsecs     int64
+	retentionWatcherWG sync.WaitGroup
+
+	downsamplingWatcherWG sync.WaitGroup
 }
 
 // partitionWrapper provides refcounting mechanism for the partition.
@@ -76,12 +79,12 @@ func (ptw *partitionWrapper) scheduleToDrop() {
 	atomic.AddUint64(&ptw.mustDrop, 1)
 }
 
-// openTable opens a table on the given path with the given retentionMonths.
+// openTable opens a table on the given path with the given retentionMsecs.
 //
 // The table is created if it doesn't exist.
 //
-// Data older than the retentionMonths may be dropped at any time.
-func openTable(path string, retentionMonths int, getDeletedMetricIDs func() *uint64set.Set) (*table, error) {
+// Data older than the retentionMsecs may be dropped at any time.
+func openTable(path string, retentionMsecs int64, getDeletedMetricIDs func() *uint64set.Set, getMinTimestampForMetricID func(metricID uint64) int64) (*table, error) {
 	path = filepath.Clean(path)
 
 	// Create a directory for the table if it doesn't exist yet.
@@ -114,16 +117,17 @@ func openTable(path string, retentionMonths int, getDeletedMetricIDs func() *uin
 	}
 
 	// Open partitions.
-	pts, err := openPartitions(smallPartitionsPath, bigPartitionsPath, getDeletedMetricIDs)
+	pts, err := openPartitions(smallPartitionsPath, bigPartitionsPath, getDeletedMetricIDs, getMinTimestampForMetricID)
 	if err != nil {
 		return nil, fmt.Errorf("cannot open partitions in the table %q: %w", path, err)
 	}
 
 	tb := &table{
-		path:                path,
-		smallPartitionsPath: smallPartitionsPath,
-		bigPartitionsPath:   bigPartitionsPath,
-		getDeletedMetricIDs: getDeletedMetricIDs,
+		path:                       path,
+		smallPartitionsPath:        smallPartitionsPath,
+		bigPartitionsPath:          bigPartitionsPath,
+		getDeletedMetricIDs:        getDeletedMetricIDs,
+		getMinTimestampForMetricID: getMinTimestampForMetricID,
 
 		flockF: flockF,
 
@@ -132,12 +136,13 @@ func openTable(path string, retentionMonths int, getDeletedMetricIDs func() *uin
 	for _, pt := range pts {
 		tb.addPartitionNolock(pt)
 	}
-	if retentionMonths <= 0 || retentionMonths > maxRetentionMonths {
-		retentionMonths = maxRetentionMonths
+	if retentionMsecs <= 0 || retentionMsecs > maxRetentionMsecs {
+		retentionMsecs = maxRetentionMsecs
 	}
-	tb.retentionMilliseconds = int64(retentionMonths) * 31 * 24 * 3600 * 1e3
+	tb.retentionMsecs = retentionMsecs
 
 	tb.startRetentionWatcher()
+	tb.startDownsamplingWatcher()
 	return tb, nil
 }
 
@@ -195,6 +200,7 @@ func (tb *table) addPartitionNolock(pt *partition) {
 func (tb *table) MustClose() {
 	close(tb.stop)
 	tb.retentionWatcherWG.Wait()
+	tb.downsamplingWatcherWG.Wait()
 
 	tb.ptwsLock.Lock()
 	ptws := tb.ptws
@@ -336,7 +342,7 @@ func (tb *table) AddRows(rows []rawRow) error {
 			continue
 		}
 
-		pt, err := createPartition(r.Timestamp, tb.smallPartitionsPath, tb.bigPartitionsPath, tb.getDeletedMetricIDs)
+		pt, err := createPartition(r.Timestamp, tb.smallPartitionsPath, tb.bigPartitionsPath, tb.getDeletedMetricIDs, tb.getMinTimestampForMetricID)
 		if err != nil {
 			errors = append(errors, err)
 			continue
@@ -355,7 +361,7 @@ func (tb *table) AddRows(rows []rawRow) error {
 
 func (tb *table) getMinMaxTimestamps() (int64, int64) {
 	now := int64(fasttime.UnixTimestamp() * 1000)
-	minTimestamp := now - tb.retentionMilliseconds
+	minTimestamp := now - tb.retentionMsecs
 	maxTimestamp := now + 2*24*3600*1000 // allow max +2 days from now due to timezones shit :)
 	if minTimestamp < 0 {
 		// Negative timestamps aren't supported by the storage.
@@ -385,7 +391,7 @@ func (tb *table) retentionWatcher() {
 		case <-ticker.C:
 		}
 
-		minTimestamp := int64(fasttime.UnixTimestamp()*1000) - tb.retentionMilliseconds
+		minTimestamp := int64(fasttime.UnixTimestamp()*1000) - tb.retentionMsecs
 		var ptwsDrop []*partitionWrapper
 		tb.ptwsLock.Lock()
 		dst := tb.ptws[:0]
@@ -399,18 +405,73 @@ func (tb *table) retentionWatcher() {
 		tb.ptws = dst
 		tb.ptwsLock.Unlock()
 
-		if len(ptwsDrop) == 0 {
-			continue
-		}
-
-		// There are paritions to drop. Drop them.
-
 		// Remove table references from partitions, so they will be eventually
 		// closed and dropped after all the pending searches are done.
 		for _, ptw := range ptwsDrop {
 			ptw.scheduleToDrop()
 			ptw.decRef()
 		}
+
+		tb.dropPartsOutsideRetention(minTimestamp)
+	}
+}
+
+// dropPartsOutsideRetention drops parts with all the rows older than minTimestamp
+// from the remaining partitions.
+//
+// This is needed for retentions smaller than a month, since the partition may contain
+// parts outside the retention, which are never merged if the partition doesn't receive new data.
+func (tb *table) dropPartsOutsideRetention(minTimestamp int64) {
+	ptws := tb.GetPartitions(nil)
+	defer tb.PutPartitions(ptws)
+
+	for _, ptw := range ptws {
+		if ptw.pt.tr.MinTimestamp >= minTimestamp {
+			// Fast path - all the rows in the partition are inside the retention.
+			continue
+		}
+		if err := ptw.pt.dropPartsOutsideRetention(minTimestamp, tb.stop); err != nil {
+			logger.Errorf("cannot drop parts outside the retention in the partition %q: %s", ptw.pt.name, err)
+		}
+	}
+}
+
+func (tb *table) startDownsamplingWatcher() {
+	if len(downsamplingPeriods) == 0 {
+		return
+	}
+	tb.downsamplingWatcherWG.Add(1)
+	go func() {
+		tb.downsamplingWatcher()
+		tb.downsamplingWatcherWG.Done()
+	}()
+}
+
+func (tb *table) downsamplingWatcher() {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-tb.stop:
+			return
+		case <-ticker.C:
+		}
+		tb.downsampleParts()
+	}
+}
+
+// downsampleParts performs forced downsampling merges for parts with samples, which passed downsampling offsets.
+//
+// Downsampling is performed during background merges, which aren't triggered
+// in partitions without new data, so old data in such partitions must be downsampled explicitly.
+func (tb *table) downsampleParts() {
+	ptws := tb.GetPartitions(nil)
+	defer tb.PutPartitions(ptws)
+
+	for _, ptw := range ptws {
+		if err := ptw.pt.downsampleParts(tb.stop); err != nil {
+			logger.Errorf("cannot downsample parts in the partition %q: %s", ptw.pt.name, err)
+		}
 	}
 }
 
@@ -436,7 +497,7 @@ func (tb *table) PutPartitions(ptws []*partitionWrapper) {
 	}
 }
 
-func openPartitions(smallPartitionsPath, bigPartitionsPath string, getDeletedMetricIDs func() *uint64set.Set) ([]*partition, error) {
+func openPartitions(smallPartitionsPath, bigPartitionsPath string, getDeletedMetricIDs func() *uint64set.Set, getMinTimestampForMetricID func(metricID uint64) int64) ([]*partition, error) {
 	// Certain partition directories in either `big` or `small` dir may be missing
 	// after restoring from backup. So populate partition names from both dirs.
 	ptNames := make(map[string]bool)
@@ -450,7 +511,7 @@ func openPartitions(smallPartitionsPath, bigPartitionsPath string, getDeletedMet
 	for ptName := range ptNames {
 		smallPartsPath := smallPartitionsPath + "/" + ptName
 		bigPartsPath := bigPartitionsPath + "/" + ptName
-		pt, err := openPartition(smallPartsPath, bigPartsPath, getDeletedMetricIDs)
+		pt, err := openPartition(smallPartsPath, bigPartsPath, getDeletedMetricIDs, getMinTimestampForMetricID)
 		if err != nil {
 			mustClosePartitions(pts)
 			return nil, fmt.Errorf("cannot open partition %q: %w", ptName, err)
@@ -47,7 +47,7 @@ func openBenchTable(b *testing.B, startTimestamp int64, rowsPerInsert, rowsCount
 		createBenchTable(b, path, startTimestamp, rowsPerInsert, rowsCount, tsidsCount)
 		createdBenchTables[path] = true
 	}
-	tb, err := openTable(path, -1, nilGetDeletedMetricIDs)
+	tb, err := openTable(path, -1, nilGetDeletedMetricIDs, nilGetMinTimestampForMetricID)
 	if err != nil {
 		b.Fatalf("cnanot open table %q: %s", path, err)
 	}
@@ -70,7 +70,7 @@ var createdBenchTables = make(map[string]bool)
 func createBenchTable(b *testing.B, path string, startTimestamp int64, rowsPerInsert, rowsCount, tsidsCount int) {
 	b.Helper()
 
-	tb, err := openTable(path, -1, nilGetDeletedMetricIDs)
+	tb, err := openTable(path, -1, nilGetDeletedMetricIDs, nilGetMinTimestampForMetricID)
 	if err != nil {
 		b.Fatalf("cannot open table %q: %s", path, err)
 	}
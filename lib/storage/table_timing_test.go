@@ -45,7 +45,7 @@ func benchmarkTableAddRows(b *testing.B, rowsPerInsert, tsidsCount int) {
 	b.SetBytes(int64(rowsCountExpected))
 	tablePath := "./benchmarkTableAddRows"
 	for i := 0; i < b.N; i++ {
-		tb, err := openTable(tablePath, -1, nilGetDeletedMetricIDs)
+		tb, err := openTable(tablePath, -1, nilGetDeletedMetricIDs, nilGetMinTimestampForMetricID)
 		if err != nil {
 			b.Fatalf("cannot open table %q: %s", tablePath, err)
 		}
@@ -93,7 +93,7 @@ func benchmarkTableAddRows(b *testing.B, rowsPerInsert, tsidsCount int) {
 		tb.MustClose()
 
 		// Open the table from files and verify the rows count on it
-		tb, err = openTable(tablePath, -1, nilGetDeletedMetricIDs)
+		tb, err = openTable(tablePath, -1, nilGetDeletedMetricIDs, nilGetMinTimestampForMetricID)
 		if err != nil {
 			b.Fatalf("cannot open table %q: %s", tablePath, err)
 		}
@@ -64,4 +64,6 @@ func (tr *TimeRange) fromPartitionTime(t time.Time) {
 
 const msecPerDay = 24 * 3600 * 1000
 
+const msecPerMonth = 31 * msecPerDay
+
 const msecPerHour = 3600 * 1000
@@ -0,0 +1,47 @@
+package streamaggr
+
+import (
+	"sync"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/fasttime"
+)
+
+// countSamplesAggrState calculates output=count_samples, e.g. the count of input samples.
+type countSamplesAggrState struct {
+	mu sync.Mutex
+	m  map[string]*countSamplesStateValue
+}
+
+type countSamplesStateValue struct {
+	n uint64
+}
+
+func newCountSamplesAggrState() *countSamplesAggrState {
+	return &countSamplesAggrState{
+		m: make(map[string]*countSamplesStateValue),
+	}
+}
+
+func (as *countSamplesAggrState) pushSample(inputKey, outputKey string, value float64) {
+	as.mu.Lock()
+	sv := as.m[outputKey]
+	if sv == nil {
+		// The outputKey may refer to a temporary buffer, so it must be copied before storing in the map.
+		sv = &countSamplesStateValue{}
+		as.m[copyString(outputKey)] = sv
+	}
+	sv.n++
+	as.mu.Unlock()
+}
+
+func (as *countSamplesAggrState) appendSeriesForFlush(ctx *flushCtx) {
+	as.mu.Lock()
+	m := as.m
+	as.m = make(map[string]*countSamplesStateValue, len(m))
+	as.mu.Unlock()
+
+	currentTimeMsec := int64(fasttime.UnixTimestamp()) * 1000
+	for outputKey, sv := range m {
+		ctx.appendSeries(outputKey, "count_samples", currentTimeMsec, float64(sv.n))
+	}
+}
@@ -0,0 +1,60 @@
+package streamaggr
+
+import (
+	"strconv"
+	"sync"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/fasttime"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/prompbmarshal"
+	"github.com/valyala/histogram"
+)
+
+// quantilesAggrState calculates output=quantiles, e.g. the given quantiles over the input samples.
+type quantilesAggrState struct {
+	phis []float64
+
+	mu sync.Mutex
+	m  map[string]*histogram.Fast
+}
+
+func newQuantilesAggrState(phis []float64) *quantilesAggrState {
+	return &quantilesAggrState{
+		phis: phis,
+		m:    make(map[string]*histogram.Fast),
+	}
+}
+
+func (as *quantilesAggrState) pushSample(inputKey, outputKey string, value float64) {
+	as.mu.Lock()
+	h := as.m[outputKey]
+	if h == nil {
+		h = histogram.GetFast()
+		as.m[copyString(outputKey)] = h
+	}
+	h.Update(value)
+	as.mu.Unlock()
+}
+
+func (as *quantilesAggrState) appendSeriesForFlush(ctx *flushCtx) {
+	as.mu.Lock()
+	m := as.m
+	as.m = make(map[string]*histogram.Fast, len(m))
+	as.mu.Unlock()
+
+	currentTimeMsec := int64(fasttime.UnixTimestamp()) * 1000
+	var quantiles []float64
+	phiStrs := make([]string, len(as.phis))
+	for i, phi := range as.phis {
+		phiStrs[i] = strconv.FormatFloat(phi, 'g', -1, 64)
+	}
+	for outputKey, h := range m {
+		quantiles = h.Quantiles(quantiles[:0], as.phis)
+		histogram.PutFast(h)
+		for i, quantile := range quantiles {
+			ctx.appendSeries(outputKey, "quantiles", currentTimeMsec, quantile, prompbmarshal.Label{
+				Name:  "quantile",
+				Value: phiStrs[i],
+			})
+		}
+	}
+}
@@ -0,0 +1,523 @@
+package streamaggr
+
+import (
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/bytesutil"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/encoding"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/envtemplate"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logger"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/prompbmarshal"
+	"github.com/VictoriaMetrics/metricsql"
+	"gopkg.in/yaml.v2"
+)
+
+// Config is a configuration for a single stream aggregation.
+type Config struct {
+	// Match is an optional series selector for the input series.
+	//
+	// All the input series are aggregated if Match is empty.
+	Match string `yaml:"match"`
+
+	// Interval is the interval for the aggregation.
+	// The aggregated stats are sent to pushFunc once per Interval.
+	Interval string `yaml:"interval"`
+
+	// By is an optional list of labels for grouping input series.
+	//
+	// See also Without.
+	By []string `yaml:"by"`
+
+	// Without is an optional list of labels, which must be removed from the output aggregation.
+	//
+	// See also By.
+	Without []string `yaml:"without"`
+
+	// Outputs is a list of output aggregate functions to produce.
+	//
+	// The following names are allowed:
+	//
+	// - total - aggregates input counters
+	// - sum_samples - sums input samples
+	// - count_samples - counts input samples
+	// - quantiles(phi1, ..., phiN) - quantiles' estimation for phi in the range [0..1]
+	Outputs []string `yaml:"outputs"`
+}
+
+// PushFunc is called by Aggregators when it needs to push the aggregated data.
+//
+// The pushFunc shouldn't hold references to tss after returning.
+type PushFunc func(tss []prompbmarshal.TimeSeries)
+
+// LoadFromFile loads Aggregators from the given path and uses the given pushFunc for pushing the aggregated data.
+//
+// MustStop must be called on the returned Aggregators when they are no longer needed.
+func LoadFromFile(path string, pushFunc PushFunc) (*Aggregators, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read stream aggregation config from %q: %w", path, err)
+	}
+	data = envtemplate.Replace(data)
+	var cfgs []*Config
+	if err := yaml.UnmarshalStrict(data, &cfgs); err != nil {
+		return nil, fmt.Errorf("cannot parse stream aggregation config from %q: %w", path, err)
+	}
+	as, err := NewAggregators(cfgs, pushFunc)
+	if err != nil {
+		return nil, fmt.Errorf("cannot initialize aggregators from %q: %w", path, err)
+	}
+	return as, nil
+}
+
+// Aggregators aggregates metrics passed to Push and calls pushFunc for aggregate data.
+type Aggregators struct {
+	as []*aggregator
+}
+
+// NewAggregators creates Aggregators from the given cfgs.
+//
+// pushFunc is called with the aggregated data at the interval set in the corresponding Config.
+//
+// MustStop must be called on the returned Aggregators when they are no longer needed.
+func NewAggregators(cfgs []*Config, pushFunc PushFunc) (*Aggregators, error) {
+	if len(cfgs) == 0 {
+		return nil, nil
+	}
+	as := make([]*aggregator, len(cfgs))
+	for i, cfg := range cfgs {
+		a, err := newAggregator(cfg, pushFunc)
+		if err != nil {
+			// Stop already initialized aggregators before returning the error.
+			for _, a := range as[:i] {
+				a.MustStop()
+			}
+			return nil, fmt.Errorf("cannot initialize aggregator #%d: %w", i+1, err)
+		}
+		as[i] = a
+	}
+	return &Aggregators{
+		as: as,
+	}, nil
+}
+
+// MustStop stops a.
+//
+// The aggregated data collected so far is pushed to pushFunc before returning.
+func (a *Aggregators) MustStop() {
+	if a == nil {
+		return
+	}
+	for _, aggr := range a.as {
+		aggr.MustStop()
+	}
+}
+
+// Push pushes tss to a.
+func (a *Aggregators) Push(tss []prompbmarshal.TimeSeries) {
+	if a == nil {
+		return
+	}
+	for _, aggr := range a.as {
+		aggr.Push(tss)
+	}
+}
+
+// aggregator aggregates input series according to the config passed to newAggregator.
+type aggregator struct {
+	match []labelFilter
+
+	inputLabels  []string
+	isBy         bool
+	outputSuffix string
+
+	aggrStates []aggrState
+	pushFunc   PushFunc
+
+	wg     sync.WaitGroup
+	stopCh chan struct{}
+}
+
+type aggrState interface {
+	pushSample(inputKey, outputKey string, value float64)
+	appendSeriesForFlush(ctx *flushCtx)
+}
+
+// newAggregator creates new aggregator for the given cfg, which pushes the aggregated data to pushFunc.
+//
+// The returned aggregator must be stopped when no longer needed by calling MustStop().
+func newAggregator(cfg *Config, pushFunc PushFunc) (*aggregator, error) {
+	// check cfg.Interval
+	interval, err := time.ParseDuration(cfg.Interval)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse `interval: %q`: %w", cfg.Interval, err)
+	}
+	if interval < time.Second {
+		return nil, fmt.Errorf("the minimum supported aggregation interval is 1s; got %s", interval)
+	}
+
+	// check cfg.Match
+	match, err := parseMatch(cfg.Match)
+	if err != nil {
+		return nil, err
+	}
+
+	// check by and without lists
+	if len(cfg.By) > 0 && len(cfg.Without) > 0 {
+		return nil, fmt.Errorf("`by: %s` and `without: %s` lists cannot be set simultaneously", cfg.By, cfg.Without)
+	}
+	isBy := len(cfg.By) > 0
+	inputLabels := cfg.Without
+	if isBy {
+		inputLabels = cfg.By
+	}
+	inputLabels = sortAndRemoveDuplicates(inputLabels)
+	labels := make([]string, 0, len(inputLabels))
+	for _, label := range inputLabels {
+		if label == "__name__" {
+			// The metric name is always preserved in the output.
+			continue
+		}
+		labels = append(labels, label)
+	}
+	if isBy {
+		labels = append(labels, "__name__")
+	}
+	inputLabels = labels
+
+	// initialize outputs list
+	if len(cfg.Outputs) == 0 {
+		return nil, fmt.Errorf("`outputs` list must contain at least a single entry from the list %s; "+
+			"see https://victoriametrics.github.io/vmagent.html#stream-aggregation", supportedOutputs)
+	}
+	intervalSecs := uint64(interval.Seconds())
+	aggrStates := make([]aggrState, len(cfg.Outputs))
+	for i, output := range cfg.Outputs {
+		if strings.HasPrefix(output, "quantiles(") {
+			if !strings.HasSuffix(output, ")") {
+				return nil, fmt.Errorf("missing closing brace for `quantiles()` output")
+			}
+			argsStr := output[len("quantiles(") : len(output)-1]
+			if len(argsStr) == 0 {
+				return nil, fmt.Errorf("`quantiles()` must contain at least one phi")
+			}
+			args := strings.Split(argsStr, ",")
+			phis := make([]float64, len(args))
+			for j, arg := range args {
+				arg = strings.TrimSpace(arg)
+				phi, err := strconv.ParseFloat(arg, 64)
+				if err != nil {
+					return nil, fmt.Errorf("cannot parse phi=%q for quantiles(%s): %w", arg, argsStr, err)
+				}
+				if phi < 0 || phi > 1 {
+					return nil, fmt.Errorf("phi inside quantiles(%s) must be in the range [0..1]; got %v", argsStr, phi)
+				}
+				phis[j] = phi
+			}
+			aggrStates[i] = newQuantilesAggrState(phis)
+			continue
+		}
+		switch output {
+		case "total":
+			aggrStates[i] = newTotalAggrState(intervalSecs)
+		case "sum_samples":
+			aggrStates[i] = newSumSamplesAggrState()
+		case "count_samples":
+			aggrStates[i] = newCountSamplesAggrState()
+		default:
+			return nil, fmt.Errorf("unsupported output=%q; supported values: %s; "+
+				"see https://victoriametrics.github.io/vmagent.html#stream-aggregation", output, supportedOutputs)
+		}
+	}
+
+	// initialize suffix to add to metric names after aggregation
+	suffix := ":" + cfg.Interval
+	if labels := removeUnderscoreName(inputLabels); len(labels) > 0 {
+		if isBy {
+			suffix += "_by_"
+		} else {
+			suffix += "_without_"
+		}
+		suffix += strings.Join(labels, "_")
+	}
+	suffix += "_"
+
+	// initialize the aggregator
+	a := &aggregator{
+		match: match,
+
+		inputLabels:  inputLabels,
+		isBy:         isBy,
+		outputSuffix: suffix,
+
+		aggrStates: aggrStates,
+		pushFunc:   pushFunc,
+
+		stopCh: make(chan struct{}),
+	}
+
+	a.wg.Add(1)
+	go func() {
+		a.runFlusher(interval)
+		a.wg.Done()
+	}()
+
+	return a, nil
+}
+
+var supportedOutputs = []string{
+	"total",
+	"sum_samples",
+	"count_samples",
+	"quantiles(...)",
+}
+
+func (a *aggregator) runFlusher(interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-a.stopCh:
+			return
+		case <-t.C:
+		}
+		a.flush()
+	}
+}
+
+func (a *aggregator) flush() {
+	ctx := &flushCtx{
+		suffix: a.outputSuffix,
+	}
+	for _, as := range a.aggrStates {
+		ctx.reset()
+		as.appendSeriesForFlush(ctx)
+		if len(ctx.tss) > 0 {
+			a.pushFunc(ctx.tss)
+		}
+	}
+}
+
+// MustStop stops the aggregator.
+//
+// The aggregator stops pushing the aggregated metrics after this call.
+func (a *aggregator) MustStop() {
+	close(a.stopCh)
+	a.wg.Wait()
+
+	// Flush the remaining data.
+	a.flush()
+}
+
+// Push pushes tss to a.
+func (a *aggregator) Push(tss []prompbmarshal.TimeSeries) {
+	var labels []prompbmarshal.Label
+	var inputKey, outputKey []byte
+	for _, ts := range tss {
+		if !matchLabels(a.match, ts.Labels) {
+			continue
+		}
+
+		labels = append(labels[:0], ts.Labels...)
+		sort.Slice(labels, func(i, j int) bool {
+			return labels[i].Name < labels[j].Name
+		})
+		inputKey = marshalLabels(inputKey[:0], labels)
+		if a.isBy {
+			labels = keepLabels(labels, a.inputLabels)
+		} else {
+			labels = removeLabels(labels, a.inputLabels)
+		}
+		outputKey = marshalLabels(outputKey[:0], labels)
+
+		for _, sample := range ts.Samples {
+			for _, as := range a.aggrStates {
+				as.pushSample(bytesutil.ToUnsafeString(inputKey), bytesutil.ToUnsafeString(outputKey), sample.Value)
+			}
+		}
+	}
+}
+
+func keepLabels(labels []prompbmarshal.Label, names []string) []prompbmarshal.Label {
+	dst := labels[:0]
+	for _, label := range labels {
+		if hasString(names, label.Name) {
+			dst = append(dst, label)
+		}
+	}
+	return dst
+}
+
+func removeLabels(labels []prompbmarshal.Label, names []string) []prompbmarshal.Label {
+	dst := labels[:0]
+	for _, label := range labels {
+		if !hasString(names, label.Name) {
+			dst = append(dst, label)
+		}
+	}
+	return dst
+}
+
+func hasString(a []string, s string) bool {
+	for _, x := range a {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
+
+func marshalLabels(dst []byte, labels []prompbmarshal.Label) []byte {
+	for _, label := range labels {
+		dst = encoding.MarshalBytes(dst, bytesutil.ToUnsafeBytes(label.Name))
+		dst = encoding.MarshalBytes(dst, bytesutil.ToUnsafeBytes(label.Value))
+	}
+	return dst
+}
+
+func unmarshalLabels(dst []prompbmarshal.Label, src string) ([]prompbmarshal.Label, error) {
+	tail := bytesutil.ToUnsafeBytes(src)
+	for len(tail) > 0 {
+		var name, value []byte
+		var err error
+		tail, name, err = encoding.UnmarshalBytes(tail)
+		if err != nil {
+			return dst, fmt.Errorf("cannot unmarshal label name: %w", err)
+		}
+		tail, value, err = encoding.UnmarshalBytes(tail)
+		if err != nil {
+			return dst, fmt.Errorf("cannot unmarshal label value: %w", err)
+		}
+		dst = append(dst, prompbmarshal.Label{
+			Name:  string(name),
+			Value: string(value),
+		})
+	}
+	return dst, nil
+}
+
+func sortAndRemoveDuplicates(a []string) []string {
+	if len(a) == 0 {
+		return nil
+	}
+	a = append([]string{}, a...)
+	sort.Strings(a)
+	dst := a[:1]
+	for _, v := range a[1:] {
+		if v != dst[len(dst)-1] {
+			dst = append(dst, v)
+		}
+	}
+	return dst
+}
+
+func removeUnderscoreName(labels []string) []string {
+	var result []string
+	for _, label := range labels {
+		if label == "__name__" {
+			continue
+		}
+		result = append(result, label)
+	}
+	return result
+}
+
+// flushCtx holds the aggregated series prepared for pushing to pushFunc.
+type flushCtx struct {
+	suffix string
+	tss    []prompbmarshal.TimeSeries
+}
+
+func (ctx *flushCtx) reset() {
+	ctx.tss = nil
+}
+
+// appendSeries appends a series with the given outputKey labels, the given output suffix, timestamp and value to ctx.
+//
+// extraLabels are added to the series labels.
+func (ctx *flushCtx) appendSeries(outputKey, suffix string, timestamp int64, value float64, extraLabels ...prompbmarshal.Label) {
+	labels, err := unmarshalLabels(nil, outputKey)
+	if err != nil {
+		logger.Panicf("BUG: cannot unmarshal labels from output key: %s", err)
+	}
+	for i := range labels {
+		label := &labels[i]
+		if label.Name == "__name__" {
+			label.Value += ctx.suffix + suffix
+		}
+	}
+	labels = append(labels, extraLabels...)
+	ctx.tss = append(ctx.tss, prompbmarshal.TimeSeries{
+		Labels: labels,
+		Samples: []prompbmarshal.Sample{{
+			Timestamp: timestamp,
+			Value:     value,
+		}},
+	})
+}
+
+// labelFilter is a single label filter from the series selector.
+type labelFilter struct {
+	label      string
+	value      string
+	isNegative bool
+	re         *regexp.Regexp
+}
+
+func parseMatch(s string) ([]labelFilter, error) {
+	if s == "" {
+		return nil, nil
+	}
+	expr, err := metricsql.Parse(s)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse `match: %q`: %w", s, err)
+	}
+	me, ok := expr.(*metricsql.MetricExpr)
+	if !ok {
+		return nil, fmt.Errorf("`match: %q` must contain series selector", s)
+	}
+	lfs := make([]labelFilter, len(me.LabelFilters))
+	for i, lf := range me.LabelFilters {
+		dst := &lfs[i]
+		dst.label = lf.Label
+		dst.value = lf.Value
+		dst.isNegative = lf.IsNegative
+		if lf.IsRegexp {
+			re, err := regexp.Compile("^(?:" + lf.Value + ")$")
+			if err != nil {
+				return nil, fmt.Errorf("cannot parse regexp %q in `match: %q`: %w", lf.Value, s, err)
+			}
+			dst.re = re
+		}
+	}
+	return lfs, nil
+}
+
+func matchLabels(lfs []labelFilter, labels []prompbmarshal.Label) bool {
+	for i := range lfs {
+		lf := &lfs[i]
+		value := ""
+		for _, label := range labels {
+			if label.Name == lf.label {
+				value = label.Value
+				break
+			}
+		}
+		var ok bool
+		if lf.re != nil {
+			ok = lf.re.MatchString(value)
+		} else {
+			ok = value == lf.value
+		}
+		if ok == lf.isNegative {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,344 @@
+package streamaggr
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/prompbmarshal"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/protoparser/prometheus"
+	"gopkg.in/yaml.v2"
+)
+
+func TestAggregatorsFailure(t *testing.T) {
+	f := func(config string) {
+		t.Helper()
+		pushFunc := func(tss []prompbmarshal.TimeSeries) {
+			panic(fmt.Errorf("pushFunc shouldn't be called"))
+		}
+		a, err := newAggregatorsFromString(config, pushFunc)
+		if err == nil {
+			t.Fatalf("expecting non-nil error")
+		}
+		if a != nil {
+			t.Fatalf("expecting nil a")
+		}
+	}
+
+	// Invalid config
+	f(`foobar`)
+
+	// Unknown option
+	f(`
+- interval: 1m
+  outputs: [total]
+  foobar: baz
+`)
+
+	// missing interval
+	f(`
+- outputs: [total]
+`)
+
+	// too small interval
+	f(`
+- interval: 10ms
+  outputs: [total]
+`)
+
+	// missing outputs
+	f(`
+- interval: 1m
+`)
+
+	// invalid output
+	f(`
+- interval: 1m
+  outputs: [foobar]
+`)
+
+	// invalid match
+	f(`
+- interval: 1m
+  outputs: [total]
+  match: 'foo{'
+`)
+
+	// match isn't a series selector
+	f(`
+- interval: 1m
+  outputs: [total]
+  match: 'sum(foo)'
+`)
+
+	// by and without are set simultaneously
+	f(`
+- interval: 1m
+  outputs: [total]
+  by: [foo]
+  without: [bar]
+`)
+
+	// invalid quantiles
+	f(`
+- interval: 1m
+  outputs: ["quantiles("]
+`)
+	f(`
+- interval: 1m
+  outputs: ["quantiles()"]
+`)
+	f(`
+- interval: 1m
+  outputs: ["quantiles(foo)"]
+`)
+	f(`
+- interval: 1m
+  outputs: ["quantiles(1.5)"]
+`)
+}
+
+func TestAggregatorsSuccess(t *testing.T) {
+	f := func(config, inputMetrics, outputMetricsExpected string) {
+		t.Helper()
+
+		// Initialize Aggregators
+		var tssOutput []prompbmarshal.TimeSeries
+		var tssOutputLock sync.Mutex
+		pushFunc := func(tss []prompbmarshal.TimeSeries) {
+			tssOutputLock.Lock()
+			for _, ts := range tss {
+				labelsCopy := append([]prompbmarshal.Label{}, ts.Labels...)
+				samplesCopy := append([]prompbmarshal.Sample{}, ts.Samples...)
+				tssOutput = append(tssOutput, prompbmarshal.TimeSeries{
+					Labels:  labelsCopy,
+					Samples: samplesCopy,
+				})
+			}
+			tssOutputLock.Unlock()
+		}
+		a, err := newAggregatorsFromString(config, pushFunc)
+		if err != nil {
+			t.Fatalf("cannot initialize aggregators: %s", err)
+		}
+
+		// Push the inputMetrics to Aggregators
+		tssInput := mustParsePromMetrics(inputMetrics)
+		a.Push(tssInput)
+		a.MustStop()
+
+		// Verify the tssOutput contains the expected metrics
+		tsStrings := make([]string, len(tssOutput))
+		for i, ts := range tssOutput {
+			tsStrings[i] = timeSeriesToString(ts)
+		}
+		sort.Strings(tsStrings)
+		outputMetrics := strings.Join(tsStrings, "")
+		if outputMetrics != outputMetricsExpected {
+			t.Fatalf("unexpected output metrics;\ngot\n%s\nwant\n%s", outputMetrics, outputMetricsExpected)
+		}
+	}
+
+	// Empty config
+	f(``, ``, ``)
+	f(``, `foo{bar="baz"} 1`, ``)
+
+	// Aggregate only by metric name
+	f(`
+- interval: 1m
+  by: [__name__]
+  outputs: [count_samples, sum_samples]
+`, `
+foo 123
+bar 567
+bar 1
+foo{abc="123"} 4
+bar{baz="x"} 8
+`, `bar:1m_count_samples 3
+bar:1m_sum_samples 576
+foo:1m_count_samples 2
+foo:1m_sum_samples 127
+`)
+
+	// No by and without lists - aggregate by all the labels
+	f(`
+- interval: 1m
+  outputs: [count_samples]
+`, `
+foo 123
+foo 34
+foo{abc="123"} 4
+`, `foo:1m_count_samples 2
+foo:1m_count_samples{abc="123"} 1
+`)
+
+	// Non-empty by list with non-existing labels
+	f(`
+- interval: 1m
+  by: [foo, bar]
+  outputs: [count_samples]
+`, `
+foo 123
+bar 567
+bar{de="fg"} 1
+foo{abc="123"} 4
+`, `bar:1m_by_bar_foo_count_samples 2
+foo:1m_by_bar_foo_count_samples 2
+`)
+
+	// Non-empty by list with existing label
+	f(`
+- interval: 1m
+  by: [abc]
+  outputs: [sum_samples]
+`, `
+foo 123
+bar 567
+foo{abc="123"} 4
+foo{abc="123",def="x"} 5
+foo{abc="456"} 8
+`, `bar:1m_by_abc_sum_samples 567
+foo:1m_by_abc_sum_samples 123
+foo:1m_by_abc_sum_samples{abc="123"} 9
+foo:1m_by_abc_sum_samples{abc="456"} 8
+`)
+
+	// Non-empty without list
+	f(`
+- interval: 1m
+  without: [de, __name__]
+  outputs: [sum_samples]
+`, `
+foo 123
+foo{de="fg"} 1
+foo{abc="123",de="x"} 4
+`, `foo:1m_without_de_sum_samples 124
+foo:1m_without_de_sum_samples{abc="123"} 4
+`)
+
+	// Match filter
+	f(`
+- interval: 1m
+  match: '{abc=~"1.+",de!="x"}'
+  outputs: [count_samples]
+`, `
+foo 123
+foo{abc="123"} 4
+foo{abc="223"} 4
+foo{abc="134",de="x"} 4
+bar{abc="15"} 4
+`, `bar:1m_count_samples{abc="15"} 1
+foo:1m_count_samples{abc="123"} 1
+`)
+
+	// Multiple aggregators
+	f(`
+- interval: 1m
+  match: foo
+  outputs: [count_samples]
+- interval: 5m
+  match: bar
+  outputs: [sum_samples]
+`, `
+foo 123
+bar 567
+foo 234
+`, `bar:5m_sum_samples 567
+foo:1m_count_samples 2
+`)
+
+	// Total output
+	f(`
+- interval: 1m
+  without: [instance]
+  outputs: [total]
+`, `
+foo{instance="a"} 10
+foo{instance="a"} 15
+foo{instance="a"} 3
+foo{instance="b"} 100
+foo{instance="b"} 110
+bar 5
+`, `bar:1m_without_instance_total 0
+foo:1m_without_instance_total 18
+`)
+
+	// Quantiles output
+	f(`
+- interval: 1m
+  outputs: ["quantiles(0, 0.5, 1)"]
+`, `
+foo 1
+foo 2
+foo 3
+foo 4
+foo 5
+`, `foo:1m_quantiles{quantile="0"} 1
+foo:1m_quantiles{quantile="0.5"} 3
+foo:1m_quantiles{quantile="1"} 5
+`)
+}
+
+func timeSeriesToString(ts prompbmarshal.TimeSeries) string {
+	labelsString := promLabelsToString(ts.Labels)
+	if len(ts.Samples) != 1 {
+		panic(fmt.Errorf("unexpected number of samples for %s: %d; want 1", labelsString, len(ts.Samples)))
+	}
+	return fmt.Sprintf("%s %v\n", labelsString, ts.Samples[0].Value)
+}
+
+func promLabelsToString(labels []prompbmarshal.Label) string {
+	var a []string
+	metricName := ""
+	for _, label := range labels {
+		if label.Name == "__name__" {
+			metricName = label.Value
+			continue
+		}
+		a = append(a, label.Name+"="+strconv.Quote(label.Value))
+	}
+	if len(a) == 0 {
+		return metricName
+	}
+	sort.Strings(a)
+	return metricName + "{" + strings.Join(a, ",") + "}"
+}
+
+func mustParsePromMetrics(s string) []prompbmarshal.TimeSeries {
+	var rows prometheus.Rows
+	errLogger := func(s string) {
+		panic(fmt.Errorf("unexpected error when parsing Prometheus metrics: %s", s))
+	}
+	rows.UnmarshalWithErrLogger(s, errLogger)
+	var tss []prompbmarshal.TimeSeries
+	for _, row := range rows.Rows {
+		labels := []prompbmarshal.Label{{
+			Name:  "__name__",
+			Value: row.Metric,
+		}}
+		for _, tag := range row.Tags {
+			labels = append(labels, prompbmarshal.Label{
+				Name:  tag.Key,
+				Value: tag.Value,
+			})
+		}
+		tss = append(tss, prompbmarshal.TimeSeries{
+			Labels: labels,
+			Samples: []prompbmarshal.Sample{{
+				Value: row.Value,
+			}},
+		})
+	}
+	return tss
+}
+
+func newAggregatorsFromString(config string, pushFunc PushFunc) (*Aggregators, error) {
+	var cfgs []*Config
+	if err := yaml.UnmarshalStrict([]byte(config), &cfgs); err != nil {
+		return nil, err
+	}
+	return NewAggregators(cfgs, pushFunc)
+}
@@ -0,0 +1,51 @@
+package streamaggr
+
+import (
+	"sync"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/fasttime"
+)
+
+// sumSamplesAggrState calculates output=sum_samples, e.g. the sum over input samples.
+type sumSamplesAggrState struct {
+	mu sync.Mutex
+	m  map[string]*sumSamplesStateValue
+}
+
+type sumSamplesStateValue struct {
+	sum float64
+}
+
+func newSumSamplesAggrState() *sumSamplesAggrState {
+	return &sumSamplesAggrState{
+		m: make(map[string]*sumSamplesStateValue),
+	}
+}
+
+func (as *sumSamplesAggrState) pushSample(inputKey, outputKey string, value float64) {
+	as.mu.Lock()
+	sv := as.m[outputKey]
+	if sv == nil {
+		// The outputKey may refer to a temporary buffer, so it must be copied before storing in the map.
+		sv = &sumSamplesStateValue{}
+		as.m[copyString(outputKey)] = sv
+	}
+	sv.sum += value
+	as.mu.Unlock()
+}
+
+func (as *sumSamplesAggrState) appendSeriesForFlush(ctx *flushCtx) {
+	as.mu.Lock()
+	m := as.m
+	as.m = make(map[string]*sumSamplesStateValue, len(m))
+	as.mu.Unlock()
+
+	currentTimeMsec := int64(fasttime.UnixTimestamp()) * 1000
+	for outputKey, sv := range m {
+		ctx.appendSeries(outputKey, "sum_samples", currentTimeMsec, sv.sum)
+	}
+}
+
+func copyString(s string) string {
+	return string(append([]byte{}, s...))
+}
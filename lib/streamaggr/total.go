@@ -0,0 +1,89 @@
+package streamaggr
+
+import (
+	"sync"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/fasttime"
+)
+
+// totalAggrState calculates output=total, e.g. the summary counter over input counters.
+type totalAggrState struct {
+	// stalenessSecs is the interval after which the input and output series are dropped if they receive no new samples.
+	stalenessSecs uint64
+
+	mu      sync.Mutex
+	inputs  map[string]*totalInputState
+	outputs map[string]*totalOutputState
+}
+
+type totalInputState struct {
+	lastValue      float64
+	deleteDeadline uint64
+}
+
+type totalOutputState struct {
+	total          float64
+	deleteDeadline uint64
+}
+
+func newTotalAggrState(intervalSecs uint64) *totalAggrState {
+	return &totalAggrState{
+		stalenessSecs: 2 * intervalSecs,
+		inputs:        make(map[string]*totalInputState),
+		outputs:       make(map[string]*totalOutputState),
+	}
+}
+
+func (as *totalAggrState) pushSample(inputKey, outputKey string, value float64) {
+	deleteDeadline := fasttime.UnixTimestamp() + as.stalenessSecs
+
+	as.mu.Lock()
+	os := as.outputs[outputKey]
+	if os == nil {
+		os = &totalOutputState{}
+		as.outputs[copyString(outputKey)] = os
+	}
+	os.deleteDeadline = deleteDeadline
+	is := as.inputs[inputKey]
+	if is == nil {
+		// The first sample for the input series is used only as a base for the next samples,
+		// since the previous value of the counter is unknown.
+		as.inputs[copyString(inputKey)] = &totalInputState{
+			lastValue:      value,
+			deleteDeadline: deleteDeadline,
+		}
+		as.mu.Unlock()
+		return
+	}
+	if value >= is.lastValue {
+		os.total += value - is.lastValue
+	} else {
+		// Counter reset.
+		os.total += value
+	}
+	is.lastValue = value
+	is.deleteDeadline = deleteDeadline
+	as.mu.Unlock()
+}
+
+func (as *totalAggrState) appendSeriesForFlush(ctx *flushCtx) {
+	currentTime := fasttime.UnixTimestamp()
+	currentTimeMsec := int64(currentTime) * 1000
+
+	as.mu.Lock()
+	defer as.mu.Unlock()
+
+	// Drop stale input and output series.
+	for inputKey, is := range as.inputs {
+		if currentTime > is.deleteDeadline {
+			delete(as.inputs, inputKey)
+		}
+	}
+	for outputKey, os := range as.outputs {
+		if currentTime > os.deleteDeadline {
+			delete(as.outputs, outputKey)
+			continue
+		}
+		ctx.appendSeries(outputKey, "total", currentTimeMsec, os.total)
+	}
+}